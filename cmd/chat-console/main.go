@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"twitch-rpg/internal/commands"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+)
+
+const usage = `Usage: chat-console [flags]
+
+Runs the chat bot's commands against an in-memory game, reading chat lines from
+standard input and printing the bot's replies. A line is a message from the default
+viewer, or "login> message" from another one; "login/moderator,vip> message" also
+gives the viewer badges. Commands are not throttled unless CHAT_COMMAND_COOLDOWN is set.
+
+Example:
+  alice> !char
+  bob> !duel @alice 50
+  alice> !accept
+
+Flags:
+  -user LOGIN      viewer of lines without a sender (default viewer)
+  -locale LOCALE   language of help and usage replies (default en; %s)
+  -points N        points each viewer's wallet starts with (default 1000)
+  -merchant        open a merchant event with random offers before reading input
+`
+
+func main() {
+	user := flag.String("user", "viewer", "default viewer")
+	locale := flag.String("locale", commands.DefaultLocale, "reply language")
+	points := flag.Int("points", 1000, "starting wallet points")
+	merchant := flag.Bool("merchant", false, "open a merchant event")
+	flag.Usage = func() { fmt.Fprintf(os.Stderr, usage, strings.Join(commands.Locales(), ", ")) }
+	flag.Parse()
+
+	if os.Getenv("CHAT_COMMAND_COOLDOWN") == "" {
+		os.Setenv("CHAT_COMMAND_COOLDOWN", "0s")
+	}
+	log.SetOutput(os.Stderr)
+
+	store := storage.NewMemoryStorage()
+	bot := services.NewChatBotService(store)
+	if *merchant {
+		if _, err := services.NewMerchantService(store).CreateMerchantEvent("Wandering Merchant", "", 30); err != nil {
+			log.Fatalf("Failed to open a merchant event: %v", err)
+		}
+	}
+
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		chatter, text := parseLine(scanner.Text(), *user)
+		if text == "" {
+			continue
+		}
+		if !seen[chatter.Login] {
+			seen[chatter.Login] = true
+			if err := fund(store, chatter.Login, *points); err != nil {
+				log.Printf("Failed to fund %s: %v", chatter.Login, err)
+			}
+		}
+
+		reply := bot.Handle(commands.Request{User: chatter, Channel: "#console", Text: text, Locale: *locale})
+		if reply != "" {
+			fmt.Printf("bot> @%s %s\n", chatter.Login, reply)
+		}
+	}
+}
+
+// parseLine splits a line into its sender and message
+func parseLine(line, defaultUser string) (*models.ChatUser, string) {
+	sender, text, found := strings.Cut(line, "> ")
+	if !found || strings.ContainsAny(sender, " !") {
+		sender, text = defaultUser, line
+	}
+
+	login, badgeList, _ := strings.Cut(strings.TrimSpace(sender), "/")
+	login = strings.ToLower(login)
+	badges := map[string]string{}
+	for _, badge := range strings.Split(badgeList, ",") {
+		if badge = strings.TrimSpace(badge); badge != "" {
+			badges[badge] = "1"
+		}
+	}
+	return &models.ChatUser{Login: login, DisplayName: login, Badges: badges}, strings.TrimSpace(text)
+}
+
+// fund creates a viewer's character with points in the wallet, so they can duel and buy
+func fund(store storage.Store, login string, points int) error {
+	character, err := services.NewCharacterService(store).GetOrCreateCharacter(login, "")
+	if err != nil || points <= 0 {
+		return err
+	}
+	_, err = services.NewWalletService(store).Credit(character.ID, points, models.WalletReasonAdjustment, "", "")
+	return err
+}
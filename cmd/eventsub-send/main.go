@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"twitch-rpg/internal/eventsub"
+	"twitch-rpg/internal/eventsub/eventsubtest"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: eventsub-send [flags] <fixture>
+
+Delivers a recorded Twitch EventSub webhook message to the server, signed with the
+EventSub secret, and prints the response.
+
+Flags:
+  -url URL          webhook endpoint (default http://localhost:8080/api/v1/twitch/eventsub)
+  -secret SECRET    signing secret (default TWITCH_EVENTSUB_SECRET)
+  -message-id ID    message ID to send; repeat one to see the delivery deduped
+  -age DURATION     backdate the message, e.g. 11m to see it rejected as a replay
+  -user LOGIN       redeem or chat as this viewer instead of the recorded one
+  -user-id ID       Twitch user ID of -user (default derived from the login, so
+                    each login is a viewer of its own; reuse an ID to see a rename)
+  -input TEXT       replace the redemption's text input or the chat message
+  -rewards          print the reward configuration matching the fixtures and exit
+
+Fixtures:
+  %s
+`
+
+func main() {
+	url := flag.String("url", "http://localhost:8080/api/v1/twitch/eventsub", "webhook endpoint")
+	secret := flag.String("secret", "", "signing secret")
+	messageID := flag.String("message-id", "", "message ID")
+	age := flag.Duration("age", 0, "message age")
+	user := flag.String("user", "", "viewer login")
+	userID := flag.String("user-id", "", "viewer Twitch user ID")
+	input := flag.String("input", "", "redemption text input")
+	rewards := flag.Bool("rewards", false, "print the reward configuration")
+	flag.Usage = func() { fmt.Fprintf(os.Stderr, usage, strings.Join(eventsubtest.Names(), "\n  ")) }
+	flag.Parse()
+
+	if *rewards {
+		os.Stdout.Write(eventsubtest.Rewards())
+		return
+	}
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *secret == "" {
+		if err := godotenv.Load(); err != nil {
+			log.Println("No .env file found, using environment variables")
+		}
+		*secret = os.Getenv("TWITCH_EVENTSUB_SECRET")
+	}
+	if *secret == "" {
+		log.Fatal("No secret given; set -secret or TWITCH_EVENTSUB_SECRET")
+	}
+
+	fixture, err := eventsubtest.Load(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	chat := fixture.SubscriptionType == eventsub.SubscriptionChatMessage
+	overrides := map[string]any{}
+	if *user != "" {
+		if *userID == "" {
+			*userID = derivedUserID(*user)
+		}
+		if chat {
+			overrides["chatter_user_id"] = *userID
+			overrides["chatter_user_login"] = strings.ToLower(*user)
+			overrides["chatter_user_name"] = *user
+		} else {
+			overrides["user_id"] = *userID
+			overrides["user_login"] = strings.ToLower(*user)
+			overrides["user_name"] = *user
+		}
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name != "input" {
+			return
+		}
+		if chat {
+			overrides["message"] = map[string]any{
+				"text":      *input,
+				"fragments": []map[string]any{{"type": "text", "text": *input}},
+			}
+		} else {
+			overrides["user_input"] = *input
+		}
+	})
+	if len(overrides) > 0 {
+		if fixture, err = fixture.WithEvent(overrides); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	request, err := eventsubtest.NewRequest(*url, *secret, fixture, *messageID, time.Now().Add(-*age))
+	if err != nil {
+		log.Fatal(err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		log.Fatalf("Delivery failed: %v", err)
+	}
+	defer response.Body.Close()
+
+	body, _ := io.ReadAll(response.Body)
+	fmt.Printf("%s %s\n", request.Header.Get(eventsub.HeaderMessageID), response.Status)
+	if len(body) > 0 {
+		fmt.Println(string(body))
+	}
+}
+
+// derivedUserID returns a stable numeric Twitch user ID for a login
+func derivedUserID(login string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(strings.ToLower(login)))
+	return strconv.FormatUint(uint64(hash.Sum32()%900000000+100000000), 10)
+}
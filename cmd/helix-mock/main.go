@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+	"twitch-rpg/internal/eventsub/eventsubtest"
+	"twitch-rpg/internal/helix"
+	"twitch-rpg/internal/helix/helixtest"
+	"twitch-rpg/internal/models"
+)
+
+const usage = `Usage: helix-mock [flags]
+
+Serves a local stand-in for the Twitch Helix API and OAuth token endpoint, so the
+server can sync channel point rewards and fulfill or cancel redemptions without a
+Twitch application. It prints the environment that points the server at it and logs
+every request it receives.
+
+The rewards redeemed by eventsub-send's fixtures are on the channel from the start,
+so delivering a fixture marks its redemption fulfilled or canceled here.
+
+Flags:
+  -addr ADDR       address to listen on (default localhost:8081)
+  -rate-limit N    requests allowed per minute (default 800)
+  -no-fixtures     start without the fixtures' rewards
+`
+
+func main() {
+	addr := flag.String("addr", "localhost:8081", "listen address")
+	rateLimit := flag.Int("rate-limit", 800, "requests per minute")
+	noFixtures := flag.Bool("no-fixtures", false, "start without the fixtures' rewards")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	server := helixtest.New()
+	server.SetRateLimit(*rateLimit, time.Minute)
+	if !*noFixtures {
+		if err := seedFixtureRewards(server); err != nil {
+			log.Fatalf("Failed to seed the fixtures' rewards: %v", err)
+		}
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", *addr, err)
+	}
+	base := "http://" + listener.Addr().String()
+	token := server.Token()
+	fmt.Printf("TWITCH_HELIX_URL=%s/helix\n", base)
+	fmt.Printf("TWITCH_AUTH_URL=%s/oauth2\n", base)
+	fmt.Printf("TWITCH_CLIENT_ID=%s\n", helixtest.ClientID)
+	fmt.Printf("TWITCH_CLIENT_SECRET=%s\n", helixtest.ClientSecret)
+	fmt.Printf("TWITCH_BROADCASTER_ID=%s\n", helixtest.BroadcasterID)
+	fmt.Printf("TWITCH_BROADCASTER_TOKEN=%s\n", token.AccessToken)
+	fmt.Printf("TWITCH_BROADCASTER_REFRESH_TOKEN=%s\n", token.RefreshToken)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.ServeHTTP(w, r)
+		log.Printf("%s %s", r.Method, r.URL.RequestURI())
+	})
+	log.Fatal(http.Serve(listener, handler))
+}
+
+// seedFixtureRewards adds the rewards of the recorded redemptions as rewards the
+// client manages
+func seedFixtureRewards(server *helixtest.Server) error {
+	for _, name := range eventsubtest.Names() {
+		fixture, err := eventsubtest.Load(name)
+		if err != nil {
+			return err
+		}
+		var body struct {
+			Event models.ChannelPointRedemption `json:"event"`
+		}
+		if err := json.Unmarshal(fixture.Body, &body); err != nil {
+			return err
+		}
+		if reward := body.Event.Reward; reward.ID != "" {
+			server.AddReward(helix.CustomReward{
+				ID:                  reward.ID,
+				Title:               reward.Title,
+				Prompt:              reward.Prompt,
+				Cost:                reward.Cost,
+				IsEnabled:           true,
+				IsUserInputRequired: body.Event.UserInput != "",
+			}, true)
+		}
+	}
+	return nil
+}
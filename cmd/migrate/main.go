@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"twitch-rpg/internal/database"
+	"twitch-rpg/internal/database/migrate"
+	"twitch-rpg/internal/database/migrations"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: migrate [-dir DIR] <command> [args]
+
+Commands:
+  up [N]         apply all pending migrations, or only the next N
+  down [N]       roll back the last N applied migrations (default 1)
+  status         list migrations and whether they are applied
+  create NAME    write an empty NNNN_NAME.up.sql/.down.sql pair to -dir
+
+Database settings are read from DB_HOST, DB_PORT, DB_USER, DB_PASSWORD and DB_NAME.
+`
+
+func main() {
+	dir := flag.String("dir", "internal/database/migrations", "migration source directory (used by create)")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			log.Fatal("create requires a migration name")
+		}
+		upPath, downPath, err := migrate.Create(*dir, args[1])
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		fmt.Printf("Created %s\nCreated %s\n", upPath, downPath)
+		return
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+
+	if err := database.Connect(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	migrator, err := migrate.New(database.DB, migrations.FS)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	migrator.Logf = log.Printf
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(countArg(args, 0))
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		fmt.Printf("Applied %d migration(s)\n", len(applied))
+
+	case "down":
+		reverted, err := migrator.Down(countArg(args, 1))
+		if err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+		fmt.Printf("Reverted %d migration(s)\n", len(reverted))
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if status.ChecksumMismatch {
+				state += " (CHECKSUM MISMATCH)"
+			}
+			if status.Missing {
+				state += " (MISSING FROM SOURCE)"
+			}
+			fmt.Printf("%04d  %-40s %s\n", status.Version, status.Name, state)
+		}
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// countArg parses the optional numeric argument of up/down
+func countArg(args []string, fallback int) int {
+	if len(args) < 2 {
+		return fallback
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 1 {
+		log.Fatalf("Invalid count %q", args[1])
+	}
+	return n
+}
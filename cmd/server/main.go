@@ -1,10 +1,18 @@
 package main
 
 import (
+        "context"
         "log"
         "os"
+        "time"
+        _ "time/tzdata" // the quest schedule's channel timezone must load without system zoneinfo
         "twitch-rpg/internal/database"
+        "twitch-rpg/internal/database/migrate"
+        "twitch-rpg/internal/database/migrations"
+        "twitch-rpg/internal/eventbus"
         "twitch-rpg/internal/handlers"
+        "twitch-rpg/internal/services"
+        "twitch-rpg/internal/storage"
 
         "github.com/gin-gonic/gin"
         "github.com/joho/godotenv"
@@ -19,15 +27,28 @@ func main() {
         }
 
         log.Println("Attempting database connection...")
-        // Connect to database
+        // Connect to database, falling back to in-memory storage
+        var store storage.Store
         if err := database.Connect(); err != nil {
                 log.Printf("Warning: Failed to connect to database: %v", err)
-                log.Println("Server will start without database connection for testing")
+                log.Println("Server will start with in-memory storage for testing")
+                store = storage.NewMemoryStorage()
         } else {
                 defer database.Close()
                 log.Println("Database connected successfully")
+
+                // Bring the schema up to date unless disabled (e.g. when migrations run as a separate deploy step)
+                if os.Getenv("DB_AUTO_MIGRATE") != "false" {
+                        if err := runMigrations(); err != nil {
+                                log.Fatalf("Failed to apply database migrations: %v", err)
+                        }
+                }
+                store = storage.NewMySQLStorage(database.DB)
         }
 
+        // Store every event the game publishes in game_events for the OBS endpoints
+        eventbus.Subscribe(services.NewEventService(store).PersistGameEvent)
+
         log.Println("Setting up HTTP server...")
         // Set Gin mode based on environment
         if os.Getenv("GIN_MODE") == "release" {
@@ -41,7 +62,7 @@ func main() {
         router.Use(func(c *gin.Context) {
                 c.Header("Access-Control-Allow-Origin", "*")
                 c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-                c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+                c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key, Last-Event-ID")
 
                 if c.Request.Method == "OPTIONS" {
                         c.AbortWithStatus(204)
@@ -51,9 +72,30 @@ func main() {
                 c.Next()
         })
 
+        // Expire unanswered duel challenges and refund their wagers
+        ctx, cancel := context.WithCancel(context.Background())
+        defer cancel()
+        go services.NewDuelService(store).RunExpiryWorker(ctx, 15*time.Second)
+
+        // Pair queued characters whose search windows widened while they waited
+        matchmaking := services.NewMatchmakingService(store)
+        go matchmaking.RunMatcher(ctx, 5*time.Second)
+
+        // Rotate daily and weekly quests at their resets and expire unfinished ones
+        go services.NewQuestService(store).RunRotationScheduler(ctx, 30*time.Second)
+
+        // Drive OBS scenes and sources from game events when an OBS WebSocket is configured
+        services.StartOBSIntegration(ctx)
+
+        // Answer game commands in Twitch chat when bot credentials are configured
+        services.StartChatBot(ctx, store)
+
+        // Create the configured channel point rewards on Twitch when the Helix API is configured
+        services.StartChannelRewardSync(ctx)
+
         log.Println("Registering API routes...")
         // Register API routes
-        handlers.RegisterRoutes(router)
+        handlers.RegisterRoutes(router, store, matchmaking)
 
         // Start server
         port := os.Getenv("SERVER_PORT")
@@ -66,4 +108,20 @@ func main() {
         if err := router.Run(":" + port); err != nil {
                 log.Fatal("Failed to start server:", err)
         }
-}
\ No newline at end of file
+}
+
+// runMigrations applies all pending embedded schema migrations
+func runMigrations() error {
+        migrator, err := migrate.New(database.DB, migrations.FS)
+        if err != nil {
+                return err
+        }
+        migrator.Logf = log.Printf
+
+        applied, err := migrator.Up(0)
+        if err != nil {
+                return err
+        }
+        log.Printf("Database schema up to date (%d migration(s) applied)", len(applied))
+        return nil
+}
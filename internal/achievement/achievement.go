@@ -0,0 +1,137 @@
+// Package achievement defines the achievement catalog and decides which badge tiers
+// a character has earned.
+//
+// Every achievement measures one metric of the character, such as fights won or
+// level, and has up to three tiers (bronze, silver, gold) that unlock when the
+// metric reaches their threshold.
+package achievement
+
+import "twitch-rpg/internal/models"
+
+// Metric is a character value achievements are measured against
+type Metric string
+
+const (
+	MetricFightsWon     Metric = "fights_won"           // fights won against other characters
+	MetricLevel         Metric = "level"                // character level
+	MetricBestRarity    Metric = "best_item_rarity"     // rank of the rarest owned item, see models.ItemRarity.Rank
+	MetricPointsSpent   Metric = "channel_points_spent" // channel points spent on stats and merchant items
+	MetricEquippedSlots Metric = "equipped_slots"       // equipment slots with an item, at most six
+)
+
+// Tier is one badge level of an achievement
+type Tier struct {
+	Tier        string `json:"tier"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Threshold   int    `json:"threshold"`
+}
+
+// Definition is an achievement of the catalog
+type Definition struct {
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	Metric Metric `json:"metric"`
+	Tiers  []Tier `json:"tiers"`
+}
+
+var catalog = []Definition{
+	{
+		Key:    "arena_victories",
+		Name:   "Arena Victories",
+		Metric: MetricFightsWon,
+		Tiers: []Tier{
+			{models.AchievementTierBronze, "First Blood", "Win your first fight", 1},
+			{models.AchievementTierSilver, "Gladiator", "Win 10 fights", 10},
+			{models.AchievementTierGold, "Centurion", "Win 100 fights", 100},
+		},
+	},
+	{
+		Key:    "veteran",
+		Name:   "Veteran",
+		Metric: MetricLevel,
+		Tiers: []Tier{
+			{models.AchievementTierBronze, "Adventurer", "Reach level 5", 5},
+			{models.AchievementTierSilver, "Hero", "Reach level 10", 10},
+			{models.AchievementTierGold, "Legend", "Reach level 25", 25},
+		},
+	},
+	{
+		Key:    "collector",
+		Name:   "Collector",
+		Metric: MetricBestRarity,
+		Tiers: []Tier{
+			{models.AchievementTierBronze, "Treasure Hunter", "Own a rare item", models.RarityRare.Rank()},
+			{models.AchievementTierSilver, "Connoisseur", "Own an epic item", models.RarityEpic.Rank()},
+			{models.AchievementTierGold, "Keeper of Legends", "Own a legendary item", models.RarityLegendary.Rank()},
+		},
+	},
+	{
+		Key:    "big_spender",
+		Name:   "Big Spender",
+		Metric: MetricPointsSpent,
+		Tiers: []Tier{
+			{models.AchievementTierBronze, "Customer", "Spend 1,000 channel points", 1000},
+			{models.AchievementTierSilver, "Patron", "Spend 10,000 channel points", 10000},
+			{models.AchievementTierGold, "Tycoon", "Spend 100,000 channel points", 100000},
+		},
+	},
+	{
+		Key:    "fully_equipped",
+		Name:   "Fully Equipped",
+		Metric: MetricEquippedSlots,
+		Tiers: []Tier{
+			{models.AchievementTierBronze, "Dressed for Battle", "Fill two equipment slots", 2},
+			{models.AchievementTierSilver, "Armored", "Fill four equipment slots", 4},
+			{models.AchievementTierGold, "Full Set", "Fill all six equipment slots", 6},
+		},
+	},
+}
+
+// Catalog returns every achievement in display order
+func Catalog() []Definition {
+	definitions := make([]Definition, len(catalog))
+	for i, definition := range catalog {
+		definitions[i] = definition
+		definitions[i].Tiers = append([]Tier(nil), definition.Tiers...)
+	}
+	return definitions
+}
+
+// Lookup finds an achievement and one of its tiers by key and tier
+func Lookup(key, tier string) (*Definition, *Tier, bool) {
+	for i := range catalog {
+		if catalog[i].Key != key {
+			continue
+		}
+		for j := range catalog[i].Tiers {
+			if catalog[i].Tiers[j].Tier == tier {
+				return &catalog[i], &catalog[i].Tiers[j], true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// Earned returns the tiers whose threshold value reaches
+func (d Definition) Earned(value int) []Tier {
+	earned := []Tier{}
+	for _, tier := range d.Tiers {
+		if value >= tier.Threshold {
+			earned = append(earned, tier)
+		}
+	}
+	return earned
+}
+
+// EquippedSlots counts the character's equipment slots that hold an item
+func EquippedSlots(character *models.Character) int {
+	count := 0
+	for _, slot := range []*int{character.BootsID, character.PantsID, character.ArmorID,
+		character.HelmetID, character.RingID, character.ChainID} {
+		if slot != nil {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,135 @@
+// Package channelpoints maps the channel's custom channel point rewards to game actions.
+//
+// A redeemed reward is found by its Twitch reward ID or, for rewards configured
+// without one, by its title. Its cost is paid into the redeemer's wallet and the
+// configured action spends it: buying stat points, buying from the merchant or
+// challenging another viewer to a duel. Rewards that are not configured are not part
+// of the game and are left alone. Rewards configured with a cost can be created on the
+// channel by the game, which then marks their redemptions fulfilled or canceled.
+package channelpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ActionType is the game action a reward triggers
+type ActionType string
+
+const (
+	ActionCredit           ActionType = "credit"            // only pay the cost into the wallet
+	ActionStatUpgrade      ActionType = "stat_upgrade"      // spend the cost on points of Stat, or the stat named in the input
+	ActionMerchantPurchase ActionType = "merchant_purchase" // buy the current merchant's offer named or numbered in the input
+	ActionDuel             ActionType = "duel"              // challenge the character named in the input, wagering Wager
+)
+
+// Stats lists the stats a stat_upgrade reward can raise
+var Stats = []string{"strength", "agility", "vitality", "intelligence"}
+
+// Reward configures one custom reward
+type Reward struct {
+	RewardID string     `json:"reward_id,omitempty"`
+	Title    string     `json:"title,omitempty"` // matched case-insensitively when reward_id is empty
+	Action   ActionType `json:"action"`
+	Stat     string     `json:"stat,omitempty"`  // stat_upgrade; empty lets the viewer pick in the input
+	Wager    int        `json:"wager,omitempty"` // duel
+	// Cost and Prompt set up the reward on Twitch when rewards are synced; rewards
+	// without a cost are managed on the Twitch dashboard
+	Cost   int    `json:"cost,omitempty"`
+	Prompt string `json:"prompt,omitempty"`
+}
+
+// NeedsInput reports whether redeeming the reward takes text from the viewer
+func (r *Reward) NeedsInput() bool {
+	switch r.Action {
+	case ActionStatUpgrade:
+		return r.Stat == ""
+	case ActionMerchantPurchase, ActionDuel:
+		return true
+	default:
+		return false
+	}
+}
+
+// Config is the set of rewards that are part of the game
+type Config struct {
+	Rewards []Reward `json:"rewards"`
+}
+
+// Load reads and validates a reward configuration from a JSON file
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read channel point rewards: %v", err)
+	}
+	config, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid channel point rewards in %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// Parse decodes and validates a reward configuration
+func Parse(data []byte) (*Config, error) {
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+
+	config := &Config{}
+	if err := decoder.Decode(config); err != nil {
+		return nil, err
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *Config) validate() error {
+	for i := range c.Rewards {
+		reward := &c.Rewards[i]
+		if reward.RewardID == "" && reward.Title == "" {
+			return fmt.Errorf("reward %d: needs a reward_id or a title", i+1)
+		}
+		if reward.Cost < 0 {
+			return fmt.Errorf("reward %d: cost cannot be negative", i+1)
+		}
+		if reward.Cost > 0 && reward.Title == "" {
+			return fmt.Errorf("reward %d: a reward with a cost needs a title", i+1)
+		}
+
+		switch reward.Action {
+		case ActionCredit, ActionMerchantPurchase:
+		case ActionStatUpgrade:
+			reward.Stat = strings.ToLower(reward.Stat)
+			if reward.Stat != "" && !slices.Contains(Stats, reward.Stat) {
+				return fmt.Errorf("reward %d: unknown stat %q", i+1, reward.Stat)
+			}
+		case ActionDuel:
+			if reward.Wager < 0 {
+				return fmt.Errorf("reward %d: wager cannot be negative", i+1)
+			}
+		default:
+			return fmt.Errorf("reward %d: unknown action %q", i+1, reward.Action)
+		}
+	}
+	return nil
+}
+
+// Find returns the reward configured for a Twitch reward ID or title, or nil when
+// the reward is not part of the game. A configured ID takes precedence over titles.
+func (c *Config) Find(rewardID, title string) *Reward {
+	for i := range c.Rewards {
+		if c.Rewards[i].RewardID != "" && c.Rewards[i].RewardID == rewardID {
+			return &c.Rewards[i]
+		}
+	}
+	for i := range c.Rewards {
+		if c.Rewards[i].RewardID == "" && strings.EqualFold(c.Rewards[i].Title, strings.TrimSpace(title)) {
+			return &c.Rewards[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+// Package combat simulates turn-based fights between two characters.
+//
+// Every stat has a distinct role: vitality sets hit points, strength drives
+// physical damage, agility grants dodge, critical hits and the first strike,
+// and intelligence lets a fighter cast spells that cannot be dodged.
+package combat
+
+import (
+	"math/rand"
+	"twitch-rpg/internal/models"
+)
+
+const (
+	baseHP        = 50
+	hpPerVitality = 10
+	hpPerLevel    = 5
+
+	baseDodgeChance = 0.05
+	dodgePerAgility = 0.005
+	maxDodgeChance  = 0.35
+
+	baseCritChance  = 0.05
+	critPerAgility  = 0.005
+	maxCritChance   = 0.40
+	critMultiplier  = 1.5
+	maxSpellChance  = 0.5
+	spellMultiplier = 1.2
+
+	// MaxRounds ends a fight that nobody managed to finish; the fighter
+	// with the larger share of their hit points left wins
+	MaxRounds = 30
+)
+
+// Fighter is a combatant's snapshot taken at the start of a fight
+type Fighter struct {
+	ID    int          `json:"id"`
+	Name  string       `json:"name"`
+	Level int          `json:"level"`
+	Stats models.Stats `json:"stats"`
+}
+
+// NewFighter snapshots a character including equipment bonuses
+func NewFighter(character *models.Character) Fighter {
+	return Fighter{
+		ID:    character.ID,
+		Name:  character.Username,
+		Level: character.Level,
+		Stats: character.CalculateTotalStats(),
+	}
+}
+
+// NewMonsterFighter builds a fighter from a bestiary entry scaled to level.
+// Monsters use negative IDs so they never collide with characters in transcripts.
+func NewMonsterFighter(monster *models.Monster, level int) Fighter {
+	return Fighter{
+		ID:    -monster.ID,
+		Name:  monster.Name,
+		Level: level,
+		Stats: monster.StatsAtLevel(level),
+	}
+}
+
+// NewRaidBossFighter builds a fighter from a raid boss, with a negative ID like monsters
+func NewRaidBossFighter(boss *models.RaidBoss) Fighter {
+	return Fighter{
+		ID:    -boss.ID,
+		Name:  boss.Name,
+		Level: boss.Level,
+		Stats: boss.Stats(),
+	}
+}
+
+// NewFighterFromSnapshot restores a fighter stored with a combat log
+func NewFighterFromSnapshot(snapshot models.FighterSnapshot) Fighter {
+	return Fighter(snapshot)
+}
+
+// Snapshot returns the fighter in the form stored with a combat log
+func (f Fighter) Snapshot() models.FighterSnapshot {
+	return models.FighterSnapshot(f)
+}
+
+// NewRNG returns the random source for a fight. The same seed always
+// produces the same fight for the same fighters.
+func NewRNG(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// MaxHP is the fighter's starting hit points
+func (f Fighter) MaxHP() int {
+	return baseHP + f.Stats.Vitality*hpPerVitality + f.Level*hpPerLevel
+}
+
+// DodgeChance is the probability of evading a physical attack
+func (f Fighter) DodgeChance() float64 {
+	return min(baseDodgeChance+float64(f.Stats.Agility)*dodgePerAgility, maxDodgeChance)
+}
+
+// CritChance is the probability of a physical attack dealing critical damage
+func (f Fighter) CritChance() float64 {
+	return min(baseCritChance+float64(f.Stats.Agility)*critPerAgility, maxCritChance)
+}
+
+// SpellChance is the probability of casting a spell instead of attacking
+func (f Fighter) SpellChance() float64 {
+	total := f.Stats.Strength + f.Stats.Intelligence
+	if total <= 0 {
+		return 0
+	}
+	return maxSpellChance * float64(f.Stats.Intelligence) / float64(total)
+}
+
+// Outcome is the result of a simulated fight
+type Outcome struct {
+	Winner     Fighter
+	Loser      Fighter
+	Transcript *models.CombatTranscript
+}
+
+// Simulate plays out a fight round by round using rng for every roll.
+// The faster fighter strikes first each round; ties go to the attacker.
+func Simulate(attacker, defender Fighter, rng *rand.Rand) *Outcome {
+	fighters := [2]Fighter{attacker, defender}
+	hp := [2]int{attacker.MaxHP(), defender.MaxHP()}
+	order := [2]int{0, 1}
+	if defender.Stats.Agility > attacker.Stats.Agility {
+		order = [2]int{1, 0}
+	}
+
+	transcript := &models.CombatTranscript{
+		AttackerMaxHP: hp[0],
+		DefenderMaxHP: hp[1],
+		Rounds:        []models.CombatRound{},
+	}
+
+	for number := 1; number <= MaxRounds && hp[0] > 0 && hp[1] > 0; number++ {
+		round := models.CombatRound{Number: number}
+		for _, actor := range order {
+			target := 1 - actor
+			action := Strike(fighters[actor], fighters[target], rng)
+			hp[target] = max(hp[target]-action.Damage, 0)
+			action.TargetHP = hp[target]
+			round.Actions = append(round.Actions, action)
+			if hp[target] == 0 {
+				break
+			}
+		}
+		transcript.Rounds = append(transcript.Rounds, round)
+	}
+
+	// Knockout, or the larger remaining share of hit points after MaxRounds.
+	// The defender holds their ground on an exact tie.
+	attackerWins := hp[1] == 0 ||
+		(hp[0] > 0 && hp[0]*transcript.DefenderMaxHP > hp[1]*transcript.AttackerMaxHP)
+	if attackerWins {
+		return &Outcome{Winner: attacker, Loser: defender, Transcript: transcript}
+	}
+	return &Outcome{Winner: defender, Loser: attacker, Transcript: transcript}
+}
+
+// Strike rolls a single attack or spell from actor against target. Raid bosses
+// are fought one strike at a time instead of in a simulated fight.
+func Strike(actor, target Fighter, rng *rand.Rand) models.CombatAction {
+	action := models.CombatAction{
+		ActorID:  actor.ID,
+		TargetID: target.ID,
+		Type:     models.CombatActionAttack,
+	}
+
+	if rng.Float64() < actor.SpellChance() {
+		// Spells always land but never crit
+		action.Type = models.CombatActionSpell
+		power := max(actor.Stats.Intelligence, 0)
+		action.Damage = max(int(float64(power+rng.Intn(power/2+1))*spellMultiplier), 1)
+		return action
+	}
+
+	if rng.Float64() < target.DodgeChance() {
+		action.Dodged = true
+		return action
+	}
+
+	power := max(actor.Stats.Strength, 0)
+	damage := float64(power + rng.Intn(power/2+1))
+	if rng.Float64() < actor.CritChance() {
+		action.Critical = true
+		damage *= critMultiplier
+	}
+	action.Damage = max(int(damage), 1)
+
+	return action
+}
@@ -0,0 +1,112 @@
+package combat_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"twitch-rpg/internal/combat"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+)
+
+var (
+	knight = combat.Fighter{ID: 1, Name: "knight", Level: 5, Stats: models.Stats{Strength: 14, Agility: 8, Vitality: 12, Intelligence: 3}}
+	mage   = combat.Fighter{ID: 2, Name: "mage", Level: 5, Stats: models.Stats{Strength: 4, Agility: 10, Vitality: 8, Intelligence: 16}}
+)
+
+func TestSameSeedReplaysFight(t *testing.T) {
+	for _, seed := range []int64{0, 1, 42, 1<<53 - 1} {
+		first := combat.Simulate(knight, mage, combat.NewRNG(seed))
+		second := combat.Simulate(knight, mage, combat.NewRNG(seed))
+		if !reflect.DeepEqual(first, second) {
+			t.Fatalf("seed %d produced different fights:\n%+v\n%+v", seed, first.Transcript, second.Transcript)
+		}
+		if len(first.Transcript.Rounds) == 0 || len(first.Transcript.Rounds) > combat.MaxRounds {
+			t.Fatalf("seed %d fought %d rounds; want 1 to %d", seed, len(first.Transcript.Rounds), combat.MaxRounds)
+		}
+
+		// The snapshot stored with a combat log restores the same fighter
+		restored := combat.Simulate(combat.NewFighterFromSnapshot(knight.Snapshot()),
+			combat.NewFighterFromSnapshot(mage.Snapshot()), combat.NewRNG(seed))
+		if !reflect.DeepEqual(first, restored) {
+			t.Fatalf("seed %d replayed from snapshots differs", seed)
+		}
+	}
+}
+
+func TestDifferentSeedsDiverge(t *testing.T) {
+	reference := combat.Simulate(knight, mage, combat.NewRNG(7))
+	for seed := int64(8); seed < 18; seed++ {
+		if !reflect.DeepEqual(reference.Transcript, combat.Simulate(knight, mage, combat.NewRNG(seed)).Transcript) {
+			return
+		}
+	}
+	t.Fatalf("ten other seeds all replayed the fight of seed 7")
+}
+
+func TestVerifyCombat(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	characterService := services.NewCharacterService(store)
+	attacker, err := characterService.CreateCharacter("verify_attacker", nil)
+	if err != nil {
+		t.Fatalf("CreateCharacter: %v", err)
+	}
+	defender, err := characterService.CreateCharacter("verify_defender", nil)
+	if err != nil {
+		t.Fatalf("CreateCharacter: %v", err)
+	}
+
+	combatService := services.NewCombatService(store)
+	result, err := combatService.StartCombat(attacker.ID, defender.ID)
+	if err != nil {
+		t.Fatalf("StartCombat: %v", err)
+	}
+
+	verification, err := combatService.VerifyCombat(result.CombatLogID)
+	if err != nil || !verification.Verified || !verification.TranscriptMatches || verification.Seed != result.Seed {
+		t.Fatalf("VerifyCombat(stored fight) = %+v, %v; want verified", verification, err)
+	}
+
+	stored, err := store.GetCombatLogByID(result.CombatLogID)
+	if err != nil || stored == nil {
+		t.Fatalf("GetCombatLogByID = %+v, %v", stored, err)
+	}
+	tamperings := map[string]func(log *models.CombatLog){
+		"winner": func(log *models.CombatLog) {
+			log.WinnerID = stored.AttackerID + stored.DefenderID - stored.WinnerID
+		},
+		"damage": func(log *models.CombatLog) {
+			rounds := append([]models.CombatRound(nil), log.Transcript.Rounds...)
+			actions := append([]models.CombatAction(nil), rounds[0].Actions...)
+			actions[0].Damage += 100
+			rounds[0].Actions = actions
+			transcript := *log.Transcript
+			transcript.Rounds = rounds
+			log.Transcript = &transcript
+		},
+	}
+	for name, tamper := range tamperings {
+		tampered := *stored
+		tamper(&tampered)
+		if err := store.AddCombatLog(&tampered); err != nil {
+			t.Fatalf("AddCombatLog(%s): %v", name, err)
+		}
+		verification, err := combatService.VerifyCombat(tampered.ID)
+		if err != nil || verification.Verified {
+			t.Fatalf("VerifyCombat(tampered %s) = %+v, %v; want it rejected", name, verification, err)
+		}
+	}
+
+	unseeded := *stored
+	unseeded.Seed = nil
+	if err := store.AddCombatLog(&unseeded); err != nil {
+		t.Fatalf("AddCombatLog(unseeded): %v", err)
+	}
+	if _, err := combatService.VerifyCombat(unseeded.ID); !errors.Is(err, services.ErrCombatNotReplayable) {
+		t.Fatalf("VerifyCombat(unseeded) = %v; want ErrCombatNotReplayable", err)
+	}
+	if _, err := combatService.VerifyCombat(unseeded.ID + 1000); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("VerifyCombat(missing) = %v; want ErrNotFound", err)
+	}
+}
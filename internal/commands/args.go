@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// loginPattern matches Twitch login names
+var loginPattern = regexp.MustCompile(`^[a-z0-9_]{1,25}$`)
+
+// Args are the words following a command
+type Args []string
+
+// Len returns the number of arguments
+func (a Args) Len() int {
+	return len(a)
+}
+
+// String returns the i-th argument, or "" when there are fewer
+func (a Args) String(i int) string {
+	if i < len(a) {
+		return a[i]
+	}
+	return ""
+}
+
+// Rest returns the arguments from the i-th on joined by spaces, such as an item name
+func (a Args) Rest(i int) string {
+	if i < len(a) {
+		return strings.Join(a[i:], " ")
+	}
+	return ""
+}
+
+// Mention returns the login named by the i-th argument, with or without "@". It fails
+// with ErrUsage when the argument is missing or not a login name.
+func (a Args) Mention(i int) (string, error) {
+	login := strings.ToLower(strings.TrimPrefix(a.String(i), "@"))
+	if !loginPattern.MatchString(login) {
+		return "", fmt.Errorf("%w: argument %d is not a user", ErrUsage, i+1)
+	}
+	return login, nil
+}
+
+// OptionalMention returns the login named by the i-th argument, or "" when there is none
+func (a Args) OptionalMention(i int) (string, error) {
+	if i >= len(a) {
+		return "", nil
+	}
+	return a.Mention(i)
+}
+
+// Int returns the i-th argument as a number. It fails with ErrUsage when the argument
+// is missing or not a whole number.
+func (a Args) Int(i int) (int, error) {
+	value, err := strconv.Atoi(a.String(i))
+	if err != nil {
+		return 0, fmt.Errorf("%w: argument %d is not a number", ErrUsage, i+1)
+	}
+	return value, nil
+}
+
+// OptionalInt returns the i-th argument as a number, or fallback when there is none
+func (a Args) OptionalInt(i, fallback int) (int, error) {
+	if i >= len(a) {
+		return fallback, nil
+	}
+	return a.Int(i)
+}
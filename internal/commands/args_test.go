@@ -0,0 +1,79 @@
+package commands_test
+
+import (
+	"errors"
+	"testing"
+	"twitch-rpg/internal/commands"
+)
+
+func TestMention(t *testing.T) {
+	for _, test := range []struct {
+		args    commands.Args
+		want    string
+		invalid bool
+	}{
+		{commands.Args{"@Alice"}, "alice", false},
+		{commands.Args{"cool_viewer_99"}, "cool_viewer_99", false},
+		{commands.Args{"@"}, "", true},
+		{commands.Args{"not-a-login"}, "", true},
+		{commands.Args{"@abcdefghijklmnopqrstuvwxyz"}, "", true},
+		{commands.Args{}, "", true},
+	} {
+		got, err := test.args.Mention(0)
+		if got != test.want || errors.Is(err, commands.ErrUsage) != test.invalid {
+			t.Fatalf("Mention(%q) = %q, %v; want %q, invalid %v", test.args, got, err, test.want, test.invalid)
+		}
+	}
+
+	if got, err := (commands.Args{}).OptionalMention(0); got != "" || err != nil {
+		t.Fatalf("OptionalMention without an argument = %q, %v; want none", got, err)
+	}
+	if _, err := (commands.Args{"#1"}).OptionalMention(0); !errors.Is(err, commands.ErrUsage) {
+		t.Fatalf("OptionalMention(#1) = %v; want ErrUsage", err)
+	}
+}
+
+func TestInt(t *testing.T) {
+	for _, test := range []struct {
+		args    commands.Args
+		want    int
+		invalid bool
+	}{
+		{commands.Args{"50"}, 50, false},
+		{commands.Args{"-3"}, -3, false},
+		{commands.Args{"+7"}, 7, false},
+		{commands.Args{"5x"}, 0, true},
+		{commands.Args{"1.5"}, 0, true},
+		{commands.Args{"99999999999999999999"}, 0, true},
+		{commands.Args{}, 0, true},
+	} {
+		got, err := test.args.Int(0)
+		if got != test.want || errors.Is(err, commands.ErrUsage) != test.invalid {
+			t.Fatalf("Int(%q) = %d, %v; want %d, invalid %v", test.args, got, err, test.want, test.invalid)
+		}
+	}
+
+	args := commands.Args{"@bob", "25"}
+	if got, err := args.OptionalInt(1, 10); got != 25 || err != nil {
+		t.Fatalf("OptionalInt(1) = %d, %v; want 25", got, err)
+	}
+	if got, err := args.OptionalInt(2, 10); got != 10 || err != nil {
+		t.Fatalf("OptionalInt(2) = %d, %v; want the fallback 10", got, err)
+	}
+	if _, err := args.OptionalInt(0, 10); !errors.Is(err, commands.ErrUsage) {
+		t.Fatalf("OptionalInt(@bob) = %v; want ErrUsage", err)
+	}
+}
+
+func TestRest(t *testing.T) {
+	args := commands.Args{"buy", "Iron", "Sword"}
+	if args.Len() != 3 || args.String(0) != "buy" || args.String(5) != "" {
+		t.Fatalf("Len, String = %d, %q, %q", args.Len(), args.String(0), args.String(5))
+	}
+	if got := args.Rest(1); got != "Iron Sword" {
+		t.Fatalf("Rest(1) = %q; want Iron Sword", got)
+	}
+	if got := args.Rest(3); got != "" {
+		t.Fatalf("Rest(3) = %q; want empty", got)
+	}
+}
@@ -0,0 +1,315 @@
+// Package commands routes chat commands such as "!duel @alice 50" to their handlers,
+// independent of the chat transport they arrived on.
+//
+// A Router knows the registered commands by name and alias, checks the sender's
+// permission level derived from their chat badges, enforces per-user and global
+// cooldowns and answers !help in the configured language. Transports turn their
+// messages into a Request: the IRC chat bot, EventSub channel.chat.message
+// notifications and test harnesses all call Router.Handle the same way.
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// DefaultPrefix starts every command
+const DefaultPrefix = "!"
+
+// cooldownSweepSize is how many cooldown entries the router keeps before dropping expired ones
+const cooldownSweepSize = 1000
+
+// ErrUsage is returned by handlers, usually through Args, when a command was called
+// with missing or malformed arguments. The router answers it with the command's usage.
+var ErrUsage = errors.New("invalid command usage")
+
+// Permission is a level of trust in a chat user, ordered from everyone to the broadcaster
+type Permission int
+
+const (
+	Everyone Permission = iota
+	Subscriber
+	VIP
+	Moderator
+	Broadcaster
+)
+
+// MessageKey returns the message key naming the permission level's audience
+func (p Permission) MessageKey() string {
+	switch p {
+	case Subscriber:
+		return "permission.subscriber"
+	case VIP:
+		return "permission.vip"
+	case Moderator:
+		return "permission.moderator"
+	case Broadcaster:
+		return "permission.broadcaster"
+	default:
+		return "permission.everyone"
+	}
+}
+
+// PermissionFromBadges returns the highest permission level the chat badges grant
+func PermissionFromBadges(badges map[string]string) Permission {
+	has := func(names ...string) bool {
+		for _, name := range names {
+			if _, ok := badges[name]; ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case has("broadcaster"):
+		return Broadcaster
+	case has("moderator", "lead_moderator"):
+		return Moderator
+	case has("vip"):
+		return VIP
+	case has("subscriber", "founder"):
+		return Subscriber
+	default:
+		return Everyone
+	}
+}
+
+// Request is a chat message that may contain a command
+type Request struct {
+	User    *models.ChatUser
+	Channel string
+	Text    string
+	Locale  string // overrides the router's locale
+}
+
+// Call is a command being run
+type Call struct {
+	Request
+	Command    *Command
+	Name       string // the name or alias the command was called by
+	Args       Args
+	Permission Permission
+	Locale     string
+}
+
+// T translates a message key into the call's language
+func (c *Call) T(key string, args ...any) string {
+	return Translate(c.Locale, key, args...)
+}
+
+// Handler runs a command and returns the reply, "" for none
+type Handler func(call *Call) (string, error)
+
+// Command is a chat command. Usage and Description are message keys, or plain text
+// when no translation has the key.
+type Command struct {
+	Name        string
+	Aliases     []string
+	Usage       string // e.g. "!duel @user [wager]"
+	Description string
+	Permission  Permission
+	// UserCooldown is how often one user may run the command, the router's default when
+	// zero; GlobalCooldown is how often anyone may
+	UserCooldown   time.Duration
+	GlobalCooldown time.Duration
+	Handler        Handler
+}
+
+// Router dispatches chat messages to commands
+type Router struct {
+	// Prefix starts every command; it defaults to DefaultPrefix
+	Prefix string
+	// Locale is the language of replies the router words itself, such as help and usage
+	Locale string
+	// UserCooldown applies to commands without a cooldown of their own
+	UserCooldown time.Duration
+	// CooldownExempt users and above are never throttled; it defaults to Moderator
+	CooldownExempt Permission
+	// ErrorReply words an error a handler returned other than ErrUsage. By default the
+	// error is reported as a generic failure.
+	ErrorReply func(call *Call, err error) string
+
+	mutex    sync.Mutex
+	commands []*Command
+	names    map[string]*Command
+	lastUse  map[string]time.Time
+}
+
+// NewRouter creates a router answering !help and !commands in locale
+func NewRouter(locale string) *Router {
+	r := &Router{
+		Prefix:         DefaultPrefix,
+		Locale:         locale,
+		CooldownExempt: Moderator,
+		names:          make(map[string]*Command),
+		lastUse:        make(map[string]time.Time),
+	}
+	r.Register(&Command{
+		Name:        "help",
+		Aliases:     []string{"commands"},
+		Usage:       "help.usage",
+		Description: "help.description",
+		Handler:     r.help,
+	})
+	return r
+}
+
+// Register adds a command. It panics when its name or an alias is already taken, like
+// registering a route twice.
+func (r *Router) Register(command *Command) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, name := range append([]string{command.Name}, command.Aliases...) {
+		name = strings.ToLower(name)
+		if _, taken := r.names[name]; taken || name == "" {
+			panic(fmt.Sprintf("commands: command name %q registered twice", name))
+		}
+		r.names[name] = command
+	}
+	r.commands = append(r.commands, command)
+}
+
+// Lookup returns the command registered under a name or alias, or nil
+func (r *Router) Lookup(name string) *Command {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.names[strings.ToLower(strings.TrimPrefix(name, r.Prefix))]
+}
+
+// Commands returns the registered commands in registration order
+func (r *Router) Commands() []*Command {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]*Command(nil), r.commands...)
+}
+
+// Handle runs the command in a chat message and returns the reply. Messages that are
+// not registered commands, and commands on cooldown, get no reply.
+func (r *Router) Handle(request Request) string {
+	text := strings.TrimSpace(request.Text)
+	if !strings.HasPrefix(text, r.Prefix) || request.User == nil || request.User.Login == "" {
+		return ""
+	}
+	fields := strings.Fields(text[len(r.Prefix):])
+	if len(fields) == 0 {
+		return ""
+	}
+	command := r.Lookup(fields[0])
+	if command == nil {
+		return ""
+	}
+
+	call := &Call{
+		Request:    request,
+		Command:    command,
+		Name:       strings.ToLower(fields[0]),
+		Args:       Args(fields[1:]),
+		Permission: PermissionFromBadges(request.User.Badges),
+		Locale:     request.Locale,
+	}
+	if call.Locale == "" {
+		call.Locale = r.Locale
+	}
+
+	// Cooldowns come first so that refusals are throttled as well
+	if !r.startCooldown(call) {
+		return ""
+	}
+	if call.Permission < command.Permission {
+		return call.T("permission.denied", r.Prefix+command.Name, call.T(command.Permission.MessageKey()))
+	}
+
+	reply, err := command.Handler(call)
+	switch {
+	case err == nil:
+		return reply
+	case errors.Is(err, ErrUsage):
+		return call.T("usage", call.T(command.Usage))
+	case r.ErrorReply != nil:
+		return r.ErrorReply(call, err)
+	default:
+		return call.T("error.generic")
+	}
+}
+
+// startCooldown reports whether the command is off cooldown for the caller and starts
+// its cooldowns
+func (r *Router) startCooldown(call *Call) bool {
+	if call.Permission >= r.CooldownExempt {
+		return true
+	}
+	userCooldown := call.Command.UserCooldown
+	if userCooldown == 0 {
+		userCooldown = r.UserCooldown
+	}
+	userKey := call.Command.Name + "\x00" + call.User.Login
+	globalKey := call.Command.Name
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := time.Now()
+	if userCooldown > 0 && now.Sub(r.lastUse[userKey]) < userCooldown {
+		return false
+	}
+	if call.Command.GlobalCooldown > 0 && now.Sub(r.lastUse[globalKey]) < call.Command.GlobalCooldown {
+		return false
+	}
+
+	if len(r.lastUse) >= cooldownSweepSize {
+		r.sweep(now)
+	}
+	r.lastUse[userKey] = now
+	r.lastUse[globalKey] = now
+	return true
+}
+
+// sweep drops cooldown entries older than the longest cooldown
+func (r *Router) sweep(now time.Time) {
+	longest := r.UserCooldown
+	for _, command := range r.commands {
+		longest = max(longest, command.UserCooldown, command.GlobalCooldown)
+	}
+	for key, used := range r.lastUse {
+		if now.Sub(used) >= longest {
+			delete(r.lastUse, key)
+		}
+	}
+}
+
+// help lists the commands the caller may use, or explains the one named
+func (r *Router) help(call *Call) (string, error) {
+	if call.Args.Len() > 0 {
+		name := strings.TrimPrefix(call.Args.String(0), r.Prefix)
+		command := r.Lookup(name)
+		if command == nil {
+			return call.T("help.unknown", r.Prefix+name), nil
+		}
+
+		reply := call.T("help.command", call.T(command.Usage), call.T(command.Description))
+		if len(command.Aliases) > 0 {
+			aliases := make([]string, len(command.Aliases))
+			for i, alias := range command.Aliases {
+				aliases[i] = r.Prefix + alias
+			}
+			reply += " " + call.T("help.aliases", strings.Join(aliases, ", "))
+		}
+		if command.Permission > Everyone {
+			reply += " " + call.T("help.permission", call.T(command.Permission.MessageKey()))
+		}
+		return reply, nil
+	}
+
+	usages := []string{}
+	for _, command := range r.Commands() {
+		if call.Permission >= command.Permission {
+			usages = append(usages, call.T(command.Usage))
+		}
+	}
+	return call.T("help.list", strings.Join(usages, ", ")), nil
+}
@@ -0,0 +1,202 @@
+package commands_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"twitch-rpg/internal/commands"
+	"twitch-rpg/internal/models"
+)
+
+// echo replies with the name the command was called by and its arguments
+func echo(call *commands.Call) (string, error) {
+	return strings.TrimSpace(call.Name + " " + call.Args.Rest(0)), nil
+}
+
+func viewer(login string, badges ...string) *models.ChatUser {
+	user := &models.ChatUser{ID: "id-" + login, Login: login, Badges: map[string]string{}}
+	for _, badge := range badges {
+		user.Badges[badge] = "1"
+	}
+	return user
+}
+
+func say(router *commands.Router, user *models.ChatUser, text string) string {
+	return router.Handle(commands.Request{User: user, Channel: "#c", Text: text})
+}
+
+func TestAliases(t *testing.T) {
+	router := commands.NewRouter("en")
+	router.Register(&commands.Command{Name: "char", Aliases: []string{"character", "Me"}, Handler: echo})
+
+	for _, test := range []struct {
+		text, want string
+	}{
+		{"!char", "char"},
+		{"!character @alice", "character @alice"},
+		{"!CHAR", "char"},
+		{"!me   extra   words ", "me extra words"},
+		{"  !char", "char"},
+		{"char", ""},
+		{"!", ""},
+		{"!dance", ""},
+		{"hello !char", ""},
+	} {
+		if got := say(router, viewer("alice"), test.text); got != test.want {
+			t.Fatalf("Handle(%q) = %q; want %q", test.text, got, test.want)
+		}
+	}
+
+	if command := router.Lookup("!Character"); command == nil || command.Name != "char" {
+		t.Fatalf("Lookup(!Character) = %+v; want char", command)
+	}
+	if got := router.Handle(commands.Request{Text: "!char"}); got != "" {
+		t.Fatalf("Handle without a user = %q; want no reply", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("registering a taken alias did not panic")
+		}
+	}()
+	router.Register(&commands.Command{Name: "profile", Aliases: []string{"CHARACTER"}, Handler: echo})
+}
+
+func TestPermissionFromBadges(t *testing.T) {
+	for _, test := range []struct {
+		badges []string
+		want   commands.Permission
+	}{
+		{nil, commands.Everyone},
+		{[]string{"premium", "glhf-pledge"}, commands.Everyone},
+		{[]string{"subscriber"}, commands.Subscriber},
+		{[]string{"founder"}, commands.Subscriber},
+		{[]string{"vip", "subscriber"}, commands.VIP},
+		{[]string{"moderator"}, commands.Moderator},
+		{[]string{"lead_moderator", "vip"}, commands.Moderator},
+		{[]string{"broadcaster", "subscriber"}, commands.Broadcaster},
+	} {
+		if got := commands.PermissionFromBadges(viewer("alice", test.badges...).Badges); got != test.want {
+			t.Fatalf("PermissionFromBadges(%v) = %d; want %d", test.badges, got, test.want)
+		}
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	router := commands.NewRouter("en")
+	router.Register(&commands.Command{Name: "purge", Usage: "!purge", Permission: commands.Moderator, Handler: echo})
+	router.Register(&commands.Command{Name: "perk", Usage: "!perk", Permission: commands.Subscriber, Handler: echo})
+
+	for _, test := range []struct {
+		user *models.ChatUser
+		text string
+		want string
+	}{
+		{viewer("alice"), "!purge", "!purge is for moderators only."},
+		{viewer("alice", "vip"), "!purge", "!purge is for moderators only."},
+		{viewer("mod", "moderator"), "!purge", "purge"},
+		{viewer("streamer", "broadcaster"), "!purge", "purge"},
+		{viewer("bob"), "!perk", "!perk is for subscribers only."},
+		{viewer("carol", "founder"), "!perk", "perk"},
+	} {
+		if got := say(router, test.user, test.text); got != test.want {
+			t.Fatalf("%s with %v: Handle(%q) = %q; want %q", test.user.Login, test.user.Badges, test.text, got, test.want)
+		}
+	}
+
+	// Help only lists the commands the caller may use
+	if got := say(router, viewer("dave"), "!help"); got != "Commands: !help [command]" {
+		t.Fatalf("!help for everyone = %q", got)
+	}
+	if got := say(router, viewer("erin", "moderator"), "!help"); got != "Commands: !help [command], !purge, !perk" {
+		t.Fatalf("!help for a moderator = %q", got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	router := commands.NewRouter("en")
+	router.Register(&commands.Command{Name: "duel", Usage: "!duel @user [wager]", Handler: func(call *commands.Call) (string, error) {
+		if _, err := call.Args.Mention(0); err != nil {
+			return "", err
+		}
+		return "", errors.New("database is down")
+	}})
+
+	if got := say(router, viewer("alice"), "!duel"); got != "Usage: !duel @user [wager]" {
+		t.Fatalf("usage reply = %q", got)
+	}
+	if got := say(router, viewer("bob"), "!duel @alice"); got != "Something went wrong, please try again later." {
+		t.Fatalf("default error reply = %q", got)
+	}
+
+	router.ErrorReply = func(call *commands.Call, err error) string { return call.Name + ": " + err.Error() }
+	if got := say(router, viewer("carol"), "!duel @alice"); got != "duel: database is down" {
+		t.Fatalf("ErrorReply = %q", got)
+	}
+}
+
+func TestCooldowns(t *testing.T) {
+	const cooldown = 200 * time.Millisecond
+	router := commands.NewRouter("en")
+	router.UserCooldown = cooldown
+	router.Register(&commands.Command{Name: "char", Usage: "!char", Handler: echo})
+	router.Register(&commands.Command{Name: "shop", Usage: "!shop", UserCooldown: time.Nanosecond, GlobalCooldown: cooldown, Handler: echo})
+	router.Register(&commands.Command{Name: "secret", Permission: commands.Broadcaster, Handler: echo})
+
+	alice, bob, mod := viewer("alice"), viewer("bob"), viewer("mod", "moderator")
+	for _, test := range []struct {
+		user *models.ChatUser
+		text string
+		want string
+	}{
+		// The default cooldown is per user and per command
+		{alice, "!char", "char"},
+		{alice, "!char", ""},
+		{bob, "!char", "char"},
+		{alice, "!help", "Commands: !help [command], !char, !shop"},
+		// A global cooldown holds everyone back
+		{alice, "!shop", "shop"},
+		{bob, "!shop", ""},
+		// Moderators are exempt
+		{mod, "!shop", "shop"},
+		{mod, "!shop", "shop"},
+		// Refusals are throttled too
+		{bob, "!secret", "!secret is for the broadcaster only."},
+		{bob, "!secret", ""},
+	} {
+		if got := say(router, test.user, test.text); got != test.want {
+			t.Fatalf("%s: Handle(%q) = %q; want %q", test.user.Login, test.text, got, test.want)
+		}
+	}
+
+	time.Sleep(cooldown)
+	if got := say(router, alice, "!char"); got != "char" {
+		t.Fatalf("!char after the cooldown = %q", got)
+	}
+	if got := say(router, bob, "!shop"); got != "shop" {
+		t.Fatalf("!shop after the global cooldown = %q", got)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	router := commands.NewRouter("en")
+	router.Register(&commands.Command{Name: "char", Aliases: []string{"character"}, Usage: "char.usage", Description: "char.description", Handler: echo})
+	router.Register(&commands.Command{Name: "purge", Usage: "!purge", Description: "Clear the queue.", Permission: commands.Moderator, Handler: echo})
+
+	for _, test := range []struct {
+		locale, text, want string
+	}{
+		{"", "!help !char", "!char [@user]: Show the level, power and gear of your character or someone else's. Also: !character."},
+		{"", "!commands purge", "!purge: Clear the queue. For moderators only."},
+		{"", "!help dance", "There is no command !dance."},
+		{"de", "!help", "Befehle: !help [Befehl], !char [@Nutzer]"},
+		{"de-AT", "!help dance", "Den Befehl !dance gibt es nicht."},
+		{"fr", "!help", "Commands: !help [command], !char [@user]"},
+	} {
+		got := router.Handle(commands.Request{User: viewer("v" + test.locale + test.text[1:3]), Text: test.text, Locale: test.locale})
+		if got != test.want {
+			t.Fatalf("Handle(%q) in %q = %q; want %q", test.text, test.locale, got, test.want)
+		}
+	}
+}
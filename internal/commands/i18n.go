@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// DefaultLocale is the language messages fall back to
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// translations maps locales to message keys to format strings, loaded from locales/*.json
+var translations = loadTranslations()
+
+func loadTranslations() map[string]map[string]string {
+	loaded := map[string]map[string]string{}
+	entries, _ := fs.ReadDir(localeFS, "locales")
+	for _, entry := range entries {
+		data, err := localeFS.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		messages := map[string]string{}
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("commands: invalid locale %s: %v", entry.Name(), err))
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return loaded
+}
+
+// Locales lists the languages with translations
+func Locales() []string {
+	locales := make([]string, 0, len(translations))
+	for locale := range translations {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// SupportedLocale reports whether locale, or its base language such as "de" for
+// "de-AT", has translations
+func SupportedLocale(locale string) bool {
+	return translations[baseLocale(locale)] != nil || translations[strings.ToLower(locale)] != nil
+}
+
+// Translate formats the message key in locale with args, falling back to the base
+// language, then DefaultLocale, then the key itself, so plain text passes through
+func Translate(locale, key string, args ...any) string {
+	format, ok := translations[strings.ToLower(locale)][key]
+	if !ok {
+		format, ok = translations[baseLocale(locale)][key]
+	}
+	if !ok {
+		format, ok = translations[DefaultLocale][key]
+	}
+	if !ok {
+		format = key
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+func baseLocale(locale string) string {
+	base, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	base, _, _ = strings.Cut(base, "_")
+	return base
+}
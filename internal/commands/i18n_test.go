@@ -0,0 +1,42 @@
+package commands_test
+
+import (
+	"slices"
+	"testing"
+	"twitch-rpg/internal/commands"
+)
+
+func TestTranslate(t *testing.T) {
+	for _, test := range []struct {
+		locale, key string
+		args        []any
+		want        string
+	}{
+		{"en", "usage", []any{"!stats"}, "Usage: !stats"},
+		{"de", "usage", []any{"!stats"}, "Verwendung: !stats"},
+		// Regional variants fall back to their base language
+		{"de-AT", "usage", []any{"!stats"}, "Verwendung: !stats"},
+		{"DE_ch", "permission.vip", nil, "VIPs"},
+		// Unknown languages fall back to English
+		{"fr", "usage", []any{"!stats"}, "Usage: !stats"},
+		{"", "permission.moderator", nil, "moderators"},
+		// Unknown keys are plain text
+		{"de", "Clear the queue.", nil, "Clear the queue."},
+		{"en", "%d points", []any{5}, "5 points"},
+	} {
+		if got := commands.Translate(test.locale, test.key, test.args...); got != test.want {
+			t.Fatalf("Translate(%q, %q) = %q; want %q", test.locale, test.key, got, test.want)
+		}
+	}
+}
+
+func TestLocales(t *testing.T) {
+	if got := commands.Locales(); !slices.Equal(got, []string{"de", "en"}) {
+		t.Fatalf("Locales() = %v; want [de en]", got)
+	}
+	for locale, want := range map[string]bool{"en": true, "de": true, "de-AT": true, "DE_de": true, "fr": false, "": false} {
+		if got := commands.SupportedLocale(locale); got != want {
+			t.Fatalf("SupportedLocale(%q) = %v; want %v", locale, got, want)
+		}
+	}
+}
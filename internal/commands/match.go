@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"twitch-rpg/internal/models"
+)
+
+// ErrNoMatch is returned when a name matches none of the candidates
+var ErrNoMatch = errors.New("no match")
+
+// maxSuggestions bounds the candidates an AmbiguousError names
+const maxSuggestions = 3
+
+// AmbiguousError is returned when a name matches several candidates equally well
+type AmbiguousError struct {
+	Query      string
+	Candidates []string
+}
+
+func (e *AmbiguousError) Error() string {
+	candidates := e.Candidates
+	if len(candidates) > maxSuggestions {
+		candidates = candidates[:maxSuggestions]
+	}
+	return fmt.Sprintf("%q is ambiguous, did you mean %s?", e.Query, strings.Join(candidates, " or "))
+}
+
+// Match finds the name a viewer meant, ignoring case and extra spaces, and returns its
+// index. An exact match wins, then a single name starting with the query, then a single
+// name containing it, then the names closest to the query by edit distance when they
+// are within a typo or two.
+func Match(query string, names []string) (int, error) {
+	query = normalize(query)
+	if query == "" {
+		return -1, ErrNoMatch
+	}
+	normalized := make([]string, len(names))
+	for i, name := range names {
+		normalized[i] = normalize(name)
+	}
+
+	steps := []func(name string) bool{
+		func(name string) bool { return name == query },
+		func(name string) bool { return strings.HasPrefix(name, query) },
+		func(name string) bool { return strings.Contains(name, query) },
+	}
+	for _, matches := range steps {
+		found := []int{}
+		for i, name := range normalized {
+			if matches(name) {
+				found = append(found, i)
+			}
+		}
+		if len(found) > 0 {
+			return pick(query, names, normalized, found)
+		}
+	}
+
+	tolerance := max(1, len([]rune(query))/4)
+	best, found := tolerance+1, []int{}
+	for i, name := range normalized {
+		distance := editDistance(query, name)
+		switch {
+		case distance < best:
+			best, found = distance, []int{i}
+		case distance == best:
+			found = append(found, i)
+		}
+	}
+	if len(found) > 0 {
+		return pick(query, names, normalized, found)
+	}
+	return -1, ErrNoMatch
+}
+
+// pick returns the single candidate found, treating candidates of the same name as one
+func pick(query string, names, normalized []string, found []int) (int, error) {
+	candidates := []string{}
+	seen := map[string]bool{}
+	for _, i := range found {
+		if !seen[normalized[i]] {
+			seen[normalized[i]] = true
+			candidates = append(candidates, names[i])
+		}
+	}
+	if len(candidates) > 1 {
+		return -1, &AmbiguousError{Query: query, Candidates: candidates}
+	}
+	return found[0], nil
+}
+
+// MatchItem finds the item a viewer meant by name, as Match does, or by ID written as "#12"
+func MatchItem(query string, items []models.Item) (*models.Item, error) {
+	if id, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(query), "#")); err == nil {
+		for i := range items {
+			if items[i].ID == id {
+				return &items[i], nil
+			}
+		}
+		return nil, ErrNoMatch
+	}
+
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.Name
+	}
+	i, err := Match(query, names)
+	if err != nil {
+		return nil, err
+	}
+	return &items[i], nil
+}
+
+func normalize(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// editDistance is the Levenshtein distance between two strings, counted in runes
+func editDistance(a, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(target)]
+}
@@ -0,0 +1,98 @@
+package commands_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"twitch-rpg/internal/commands"
+	"twitch-rpg/internal/models"
+)
+
+func TestMatch(t *testing.T) {
+	names := []string{"Iron Sword", "Iron Shield", "Steel Sword", "Wooden Bow", "Potion", "potion"}
+
+	for _, test := range []struct {
+		query     string
+		want      int
+		ambiguous []string
+		none      bool
+	}{
+		{query: "iron sword", want: 0},
+		{query: "  IRON   sword ", want: 0},
+		{query: "wooden", want: 3},
+		{query: "bow", want: 3},
+		{query: "steel sword", want: 2},
+		// Typos within a quarter of the query's length
+		{query: "iron swrod", want: 0},
+		{query: "wooden bwo", want: 3},
+		{query: "potoin", want: 4},
+		// Names that differ only in case are one candidate
+		{query: "POTION", want: 4},
+		// Ties at the same step are ambiguous
+		{query: "iron", ambiguous: []string{"Iron Sword", "Iron Shield"}},
+		{query: "sword", ambiguous: []string{"Iron Sword", "Steel Sword"}},
+		// Nothing close enough
+		{query: "dragon", none: true},
+		{query: "iron swxxxx", none: true},
+		{query: "   ", none: true},
+	} {
+		got, err := commands.Match(test.query, names)
+		var ambiguous *commands.AmbiguousError
+		switch {
+		case test.none:
+			if !errors.Is(err, commands.ErrNoMatch) {
+				t.Fatalf("Match(%q) = %d, %v; want ErrNoMatch", test.query, got, err)
+			}
+		case test.ambiguous != nil:
+			if !errors.As(err, &ambiguous) || !slices.Equal(ambiguous.Candidates, test.ambiguous) {
+				t.Fatalf("Match(%q) = %d, %v; want ambiguous between %v", test.query, got, err, test.ambiguous)
+			}
+		case err != nil || got != test.want:
+			t.Fatalf("Match(%q) = %d, %v; want %d", test.query, got, err, test.want)
+		}
+	}
+
+	// Typo ties are ambiguous too
+	var ambiguous *commands.AmbiguousError
+	if _, err := commands.Match("hat", []string{"cat", "bat", "dog"}); !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+		t.Fatalf("Match(hat) = %v; want ambiguous between cat and bat", err)
+	}
+
+	long := &commands.AmbiguousError{Query: "ring", Candidates: []string{"Ring A", "Ring B", "Ring C", "Ring D"}}
+	if got := long.Error(); got != `"ring" is ambiguous, did you mean Ring A or Ring B or Ring C?` {
+		t.Fatalf("Error() = %q", got)
+	}
+}
+
+func TestMatchItem(t *testing.T) {
+	items := []models.Item{{ID: 3, Name: "Iron Sword"}, {ID: 12, Name: "Iron Shield"}, {ID: 40, Name: "Leather Boots"}}
+
+	for _, test := range []struct {
+		query  string
+		wantID int
+		err    error
+	}{
+		{"#12", 12, nil},
+		{" 40 ", 40, nil},
+		{"#99", 0, commands.ErrNoMatch},
+		{"leather", 40, nil},
+		{"iron sheild", 12, nil},
+		{"cloak", 0, commands.ErrNoMatch},
+	} {
+		item, err := commands.MatchItem(test.query, items)
+		if test.err != nil {
+			if !errors.Is(err, test.err) {
+				t.Fatalf("MatchItem(%q) = %+v, %v; want %v", test.query, item, err, test.err)
+			}
+			continue
+		}
+		if err != nil || item.ID != test.wantID {
+			t.Fatalf("MatchItem(%q) = %+v, %v; want item %d", test.query, item, err, test.wantID)
+		}
+	}
+
+	var ambiguous *commands.AmbiguousError
+	if _, err := commands.MatchItem("iron", items); !errors.As(err, &ambiguous) {
+		t.Fatalf("MatchItem(iron) = %v; want AmbiguousError", err)
+	}
+}
@@ -0,0 +1,408 @@
+// Package migrate applies the versioned SQL migrations in internal/database/migrations
+// and tracks them in a schema_migrations table.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockName is the MySQL advisory lock that serializes concurrent migrators
+const lockName = "twitch_rpg_schema_migrations"
+
+// lockTimeoutSeconds is how long to wait for another migrator to finish
+const lockTimeoutSeconds = 30
+
+const createTrackingTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+)`
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a single numbered schema change
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // sha256 of the up script
+}
+
+// String returns the migration's file name stem, e.g. 0001_initial_schema
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Status describes a migration and whether it has been applied
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+
+	// ChecksumMismatch is set when the applied script differs from the embedded one
+	ChecksumMismatch bool `json:"checksum_mismatch,omitempty"`
+	// Missing is set when the database has a version that no longer exists in the source
+	Missing bool `json:"missing,omitempty"`
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Migrator applies migrations from a source file system to a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+
+	// Logf receives progress messages; defaults to discarding them
+	Logf func(format string, args ...interface{})
+}
+
+// New loads the migrations in source and prepares a migrator for db
+func New(db *sql.DB, source fs.FS) (*Migrator, error) {
+	migrations, err := Load(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		Logf:       func(string, ...interface{}) {},
+	}, nil
+}
+
+// Load parses every NNNN_name.up.sql / .down.sql pair in source, sorted by version.
+// Versions must count up from 1 without gaps, and each has one file per direction.
+func Load(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	files := map[string]string{} // version and direction to file name
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q (want NNNN_name.up.sql or NNNN_name.down.sql)", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %v", entry.Name(), err)
+		}
+
+		// 1_x.up.sql and 0001_x.up.sql are the same version
+		key := strconv.FormatInt(version, 10) + "." + match[3]
+		if other, exists := files[key]; exists {
+			return nil, fmt.Errorf("migration files %q and %q have the same version", other, entry.Name())
+		}
+		files[key] = entry.Name()
+
+		content, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", entry.Name(), err)
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d used by both %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Checksum == "" {
+			return nil, fmt.Errorf("migration %s has no up script", migration)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			return nil, fmt.Errorf("migration %s is out of sequence: want version %04d", migration, i+1)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Migrations returns the loaded migrations in version order
+func (m *Migrator) Migrations() []Migration {
+	return append([]Migration(nil), m.migrations...)
+}
+
+// Up applies pending migrations in order. A limit of 0 applies all of them.
+// It refuses to run if an applied migration's checksum no longer matches its source.
+func (m *Migrator) Up(limit int) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.withLock(func(conn *sql.Conn) error {
+		done, err := m.verify(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if limit > 0 && len(applied) >= limit {
+				break
+			}
+
+			m.Logf("Applying migration %s", migration)
+			if err := execScript(conn, migration.Up); err != nil {
+				return fmt.Errorf("migration %s failed: %v", migration, err)
+			}
+
+			_, err := conn.ExecContext(context.Background(),
+				`INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)`,
+				migration.Version, migration.Name, migration.Checksum)
+			if err != nil {
+				return fmt.Errorf("failed to record migration %s: %v", migration, err)
+			}
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the given number of most recently applied migrations
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var reverted []Migration
+
+	err := m.withLock(func(conn *sql.Conn) error {
+		done, err := m.verify(conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if strings.TrimSpace(migration.Down) == "" {
+				return fmt.Errorf("migration %s has no down script", migration)
+			}
+
+			m.Logf("Reverting migration %s", migration)
+			if err := execScript(conn, migration.Down); err != nil {
+				return fmt.Errorf("rollback of %s failed: %v", migration, err)
+			}
+
+			_, err := conn.ExecContext(context.Background(), `DELETE FROM schema_migrations WHERE version = ?`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("failed to unrecord migration %s: %v", migration, err)
+			}
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status reports every known migration and any applied version missing from the source
+func (m *Migrator) Status() ([]Status, error) {
+	var statuses []Status
+
+	err := m.withLock(func(conn *sql.Conn) error {
+		done, err := loadApplied(conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+			if row, ok := done[migration.Version]; ok {
+				appliedAt := row.AppliedAt
+				status.Applied = true
+				status.AppliedAt = &appliedAt
+				status.ChecksumMismatch = row.Checksum != migration.Checksum
+				delete(done, migration.Version)
+			}
+			statuses = append(statuses, status)
+		}
+
+		for _, row := range done {
+			appliedAt := row.AppliedAt
+			statuses = append(statuses, Status{
+				Version:   row.Version,
+				Name:      row.Name,
+				Applied:   true,
+				AppliedAt: &appliedAt,
+				Missing:   true,
+			})
+		}
+		sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// verify loads the applied migrations and checks them against the source
+func (m *Migrator) verify(conn *sql.Conn) (map[int64]appliedMigration, error) {
+	done, err := loadApplied(conn)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkApplied(m.migrations, done); err != nil {
+		return nil, err
+	}
+
+	return done, nil
+}
+
+// checkApplied fails when an applied migration is missing from the source or its up
+// script changed since it was applied
+func checkApplied(migrations []Migration, done map[int64]appliedMigration) error {
+	known := map[int64]Migration{}
+	for _, migration := range migrations {
+		known[migration.Version] = migration
+	}
+
+	for version, row := range done {
+		migration, ok := known[version]
+		if !ok {
+			return fmt.Errorf("database has migration %04d_%s which is not in the source tree", version, row.Name)
+		}
+		if row.Checksum != migration.Checksum {
+			return fmt.Errorf("checksum mismatch for applied migration %s: it was edited after being applied", migration)
+		}
+	}
+
+	return nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock
+func (m *Migrator) withLock(fn func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %v", err)
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, ?)`, lockName, lockTimeoutSeconds).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %v", err)
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return fmt.Errorf("timed out waiting for migration lock; is another migration running?")
+	}
+	defer conn.ExecContext(ctx, `SELECT RELEASE_LOCK(?)`, lockName)
+
+	if _, err := conn.ExecContext(ctx, createTrackingTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %v", err)
+	}
+
+	return fn(conn)
+}
+
+func loadApplied(conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(context.Background(), `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+	}
+	defer rows.Close()
+
+	done := map[int64]appliedMigration{}
+	for rows.Next() {
+		var row appliedMigration
+		if err := rows.Scan(&row.Version, &row.Name, &row.Checksum, &row.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %v", err)
+		}
+		done[row.Version] = row
+	}
+
+	return done, rows.Err()
+}
+
+// execScript runs each statement of a migration script in order.
+// MySQL commits DDL implicitly, so a failure part-way leaves earlier statements applied.
+func execScript(conn *sql.Conn, script string) error {
+	for i, statement := range SplitStatements(script) {
+		if _, err := conn.ExecContext(context.Background(), statement); err != nil {
+			return fmt.Errorf("statement %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Create writes an empty up/down migration pair to dir, numbered after the highest existing version
+func Create(dir, name string) (upPath, downPath string, err error) {
+	slug := strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" {
+		return "", "", fmt.Errorf("migration name must contain letters or digits")
+	}
+
+	existing, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	var version int64 = 1
+	if len(existing) > 0 {
+		version = existing[len(existing)-1].Version + 1
+	}
+
+	stem := fmt.Sprintf("%04d_%s", version, slug)
+	upPath = filepath.Join(dir, stem+".up.sql")
+	downPath = filepath.Join(dir, stem+".down.sql")
+
+	header := fmt.Sprintf("-- %s\n", stem)
+	if err := os.WriteFile(upPath, []byte(header), 0o644); err != nil {
+		return "", "", fmt.Errorf("failed to write %s: %v", upPath, err)
+	}
+	if err := os.WriteFile(downPath, []byte(header), 0o644); err != nil {
+		return "", "", fmt.Errorf("failed to write %s: %v", downPath, err)
+	}
+
+	return upPath, downPath, nil
+}
@@ -0,0 +1,143 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"twitch-rpg/internal/database/migrations"
+)
+
+func file(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content)}
+}
+
+func TestLoad(t *testing.T) {
+	source := fstest.MapFS{
+		"0002_wallets.up.sql":     file("CREATE TABLE wallets (id INT);"),
+		"0001_initial.up.sql":     file("CREATE TABLE characters (id INT);"),
+		"0001_initial.down.sql":   file("DROP TABLE characters;"),
+		"README.md":               file("not a migration"),
+		"drafts/0003_next.up.sql": file("SELECT 1;"),
+	}
+
+	loaded, err := Load(source)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].String() != "0001_initial" || loaded[1].String() != "0002_wallets" {
+		t.Fatalf("Load = %v; want 0001_initial and 0002_wallets", loaded)
+	}
+	sum := sha256.Sum256([]byte("CREATE TABLE characters (id INT);"))
+	if loaded[0].Checksum != hex.EncodeToString(sum[:]) || loaded[0].Down != "DROP TABLE characters;" || loaded[1].Down != "" {
+		t.Fatalf("unexpected migration: %+v", loaded[0])
+	}
+
+	// The checksum covers the up script only
+	source["0001_initial.down.sql"] = file("DROP TABLE IF EXISTS characters;")
+	if reloaded, _ := Load(source); reloaded[0].Checksum != loaded[0].Checksum {
+		t.Fatalf("editing the down script changed the checksum")
+	}
+	source["0001_initial.up.sql"] = file("CREATE TABLE characters (id BIGINT);")
+	if reloaded, _ := Load(source); reloaded[0].Checksum == loaded[0].Checksum {
+		t.Fatalf("editing the up script kept the checksum")
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	for _, test := range []struct {
+		name, wantError string
+		files           []string
+	}{
+		{"two names for a version", "used by both", []string{"0001_initial.up.sql", "0001_other.down.sql"}},
+		{"two files for a version", "have the same version", []string{"0001_initial.up.sql", "1_initial.up.sql"}},
+		{"gap", "out of sequence", []string{"0001_initial.up.sql", "0003_quests.up.sql"}},
+		{"not starting at one", "out of sequence", []string{"0002_wallets.up.sql"}},
+		{"down without up", "has no up script", []string{"0001_initial.up.sql", "0002_wallets.down.sql"}},
+		{"bad file name", "invalid migration file name", []string{"0001-initial.sql"}},
+		{"upper case name", "invalid migration file name", []string{"0001_Initial.up.sql"}},
+	} {
+		source := fstest.MapFS{}
+		for _, name := range test.files {
+			source[name] = file("SELECT 1;")
+		}
+		if _, err := Load(source); err == nil || !strings.Contains(err.Error(), test.wantError) {
+			t.Fatalf("%s: Load = %v; want an error containing %q", test.name, err, test.wantError)
+		}
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	if err != nil || len(loaded) == 0 {
+		t.Fatalf("Load(migrations.FS) = %v, %v", loaded, err)
+	}
+	for _, migration := range loaded {
+		if strings.TrimSpace(migration.Down) == "" {
+			t.Fatalf("migration %s has no down script", migration)
+		}
+	}
+}
+
+func TestCheckApplied(t *testing.T) {
+	loaded, err := Load(fstest.MapFS{
+		"0001_initial.up.sql": file("CREATE TABLE characters (id INT);"),
+		"0002_wallets.up.sql": file("CREATE TABLE wallets (id INT);"),
+	})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	applied := func(version int64, checksum string) appliedMigration {
+		return appliedMigration{Version: version, Name: "x", Checksum: checksum}
+	}
+
+	for _, test := range []struct {
+		name      string
+		done      map[int64]appliedMigration
+		wantError string
+	}{
+		{"nothing applied", map[int64]appliedMigration{}, ""},
+		{"applied as loaded", map[int64]appliedMigration{1: applied(1, loaded[0].Checksum), 2: applied(2, loaded[1].Checksum)}, ""},
+		{"edited after applying", map[int64]appliedMigration{1: applied(1, loaded[0].Checksum), 2: applied(2, loaded[0].Checksum)}, "checksum mismatch for applied migration 0002_wallets"},
+		{"missing from the source", map[int64]appliedMigration{7: applied(7, "abc")}, "0007_x which is not in the source tree"},
+	} {
+		err := checkApplied(loaded, test.done)
+		if (test.wantError == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), test.wantError)) {
+			t.Fatalf("%s: checkApplied = %v; want %q", test.name, err, test.wantError)
+		}
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	up, down, err := Create(dir, "Add Raid Bosses!")
+	if err != nil || filepath.Base(up) != "0001_add_raid_bosses.up.sql" || filepath.Base(down) != "0001_add_raid_bosses.down.sql" {
+		t.Fatalf("Create = %s, %s, %v", up, down, err)
+	}
+	up, _, err = Create(dir, "quests")
+	if err != nil || filepath.Base(up) != "0002_quests.up.sql" {
+		t.Fatalf("second Create = %s, %v; want 0002_quests", up, err)
+	}
+	if content, _ := os.ReadFile(up); string(content) != "-- 0002_quests\n" {
+		t.Fatalf("new migration = %q", content)
+	}
+
+	loaded, err := Load(os.DirFS(dir))
+	if err != nil || len(loaded) != 2 {
+		t.Fatalf("Load after Create = %v, %v", loaded, err)
+	}
+
+	if _, _, err := Create(dir, "!!!"); err == nil {
+		t.Fatalf("Create without letters or digits succeeded")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "0009_stray.up.sql"), nil, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, _, err := Create(dir, "next"); err == nil {
+		t.Fatalf("Create numbered a migration after a gap")
+	}
+}
@@ -0,0 +1,79 @@
+package migrate
+
+import "strings"
+
+// SplitStatements splits a SQL script on semicolons, ignoring semicolons inside
+// quoted strings, identifiers and comments. Comment-only statements are dropped.
+func SplitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	hasCode := false
+
+	flush := func() {
+		if hasCode {
+			statements = append(statements, strings.TrimSpace(current.String()))
+		}
+		current.Reset()
+		hasCode = false
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+
+		switch {
+		case c == '-' && i+1 < len(script) && script[i+1] == '-', c == '#':
+			// Line comment: skip to end of line
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end
+				current.WriteByte('\n')
+			}
+
+		case c == '/' && i+1 < len(script) && script[i+1] == '*':
+			// Block comment
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 3
+			}
+			current.WriteByte(' ')
+
+		case c == '\'' || c == '"' || c == '`':
+			// Quoted literal or identifier, with backslash and doubled-quote escapes
+			start := i
+			for i++; i < len(script); i++ {
+				if script[i] == '\\' && c != '`' {
+					i++
+					continue
+				}
+				if script[i] == c {
+					if i+1 < len(script) && script[i+1] == c {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			if i >= len(script) {
+				i = len(script) - 1
+			}
+			current.WriteString(script[start : i+1])
+			hasCode = true
+
+		case c == ';':
+			flush()
+
+		default:
+			current.WriteByte(c)
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				hasCode = true
+			}
+		}
+	}
+	flush()
+
+	return statements
+}
@@ -0,0 +1,61 @@
+package migrate
+
+import (
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+	"twitch-rpg/internal/database/migrations"
+)
+
+func TestSplitStatements(t *testing.T) {
+	for _, test := range []struct {
+		name, script string
+		want         []string
+	}{
+		{"statements", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n", []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"}},
+		{"no trailing semicolon", "SELECT 1", []string{"SELECT 1"}},
+		{"empty statements", "  ;\n; \n", nil},
+		{"semicolon in a string", "INSERT INTO t VALUES ('a;b'); SELECT 2", []string{"INSERT INTO t VALUES ('a;b')", "SELECT 2"}},
+		{"double quotes", `INSERT INTO t VALUES ("x;y")`, []string{`INSERT INTO t VALUES ("x;y")`}},
+		{"doubled quote", "INSERT INTO t VALUES ('it''s; fine'); SELECT 2", []string{"INSERT INTO t VALUES ('it''s; fine')", "SELECT 2"}},
+		{"backslash escape", `INSERT INTO t VALUES ('a\';b'); SELECT 2`, []string{`INSERT INTO t VALUES ('a\';b')`, "SELECT 2"}},
+		{"identifier", "SELECT `odd;name` FROM t; SELECT 2", []string{"SELECT `odd;name` FROM t", "SELECT 2"}},
+		{"backslash in an identifier", "SELECT `a\\`; SELECT 2", []string{"SELECT `a\\`", "SELECT 2"}},
+		{"doubled backtick", "SELECT `a``;b`; SELECT 2", []string{"SELECT `a``;b`", "SELECT 2"}},
+		{"unterminated string", "SELECT 'abc;", []string{"SELECT 'abc;"}},
+		{"line comments", "-- drop; everything\nSELECT 1; # another; comment\nSELECT 2", []string{"SELECT 1", "SELECT 2"}},
+		{"trailing line comment", "SELECT 1; -- done; really", []string{"SELECT 1"}},
+		{"comment in a string", "SELECT '-- not; a comment', '/* nor; this */'", []string{"SELECT '-- not; a comment', '/* nor; this */'"}},
+		{"block comment", "SELECT /* ; */ 1; /* only a comment; */ ;", []string{"SELECT   1"}},
+		{"multi-line block comment", "/*\n header;\n*/\nCREATE TABLE a (id INT);", []string{"CREATE TABLE a (id INT)"}},
+		{"unterminated block comment", "SELECT 1; /* open;", []string{"SELECT 1"}},
+		{"minus is not a comment", "SELECT 5-3; SELECT -1", []string{"SELECT 5-3", "SELECT -1"}},
+	} {
+		if got := SplitStatements(test.script); !slices.Equal(got, test.want) {
+			t.Fatalf("%s: SplitStatements(%q) = %q; want %q", test.name, test.script, got, test.want)
+		}
+	}
+}
+
+func TestSplitEmbeddedMigrations(t *testing.T) {
+	names, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil || len(names) == 0 {
+		t.Fatalf("Glob = %v, %v", names, err)
+	}
+	for _, name := range names {
+		script, err := fs.ReadFile(migrations.FS, name)
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", name, err)
+		}
+		statements := SplitStatements(string(script))
+		if len(statements) == 0 {
+			t.Fatalf("%s has no statements", name)
+		}
+		for i, statement := range statements {
+			if statement == "" || strings.HasPrefix(statement, "--") || strings.Contains(statement, "/*") {
+				t.Fatalf("%s statement %d was split badly: %q", name, i+1, statement)
+			}
+		}
+	}
+}
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS game_events;
+DROP TABLE IF EXISTS character_quests;
+DROP TABLE IF EXISTS quests;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS merchant_event_items;
+DROP TABLE IF EXISTS merchant_events;
+DROP TABLE IF EXISTS combat_logs;
+DROP TABLE IF EXISTS character_items;
+DROP TABLE IF EXISTS characters;
+DROP TABLE IF EXISTS items;
@@ -1,8 +1,29 @@
--- Twitch RPG Database Schema
--- Run this script to create the database structure
+-- Initial schema matching the queries in internal/storage.
+-- IF NOT EXISTS lets databases created from the old scripts/schema.sql adopt migrations.
 
-CREATE DATABASE IF NOT EXISTS twitch_rpg;
-USE twitch_rpg;
+-- Items table - all equipment pieces
+CREATE TABLE IF NOT EXISTS items (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    type ENUM('boots', 'pants', 'armor', 'helmet', 'ring', 'chain') NOT NULL,
+    rarity ENUM('common', 'rare', 'epic', 'legendary') DEFAULT 'common',
+
+    -- Stat bonuses
+    strength_bonus INT DEFAULT 0,
+    agility_bonus INT DEFAULT 0,
+    vitality_bonus INT DEFAULT 0,
+    intelligence_bonus INT DEFAULT 0,
+
+    -- Special properties
+    special_effect VARCHAR(500) DEFAULT NULL,
+    value INT DEFAULT 100, -- Channel points value
+    is_special BOOLEAN DEFAULT FALSE, -- For merchant items
+
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_items_type (type),
+    INDEX idx_items_is_special (is_special)
+);
 
 -- Characters table - one per Twitch user
 CREATE TABLE IF NOT EXISTS characters (
@@ -12,13 +33,13 @@ CREATE TABLE IF NOT EXISTS characters (
     level INT DEFAULT 1,
     experience INT DEFAULT 0,
     channel_points_spent INT DEFAULT 0,
-    
+
     -- Base stats
     strength INT DEFAULT 10,
     agility INT DEFAULT 10,
     vitality INT DEFAULT 10,
     intelligence INT DEFAULT 10,
-    
+
     -- Equipment slots (item IDs)
     boots_id INT DEFAULT NULL,
     pants_id INT DEFAULT NULL,
@@ -26,10 +47,10 @@ CREATE TABLE IF NOT EXISTS characters (
     helmet_id INT DEFAULT NULL,
     ring_id INT DEFAULT NULL,
     chain_id INT DEFAULT NULL,
-    
+
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    
+
     FOREIGN KEY (boots_id) REFERENCES items(id),
     FOREIGN KEY (pants_id) REFERENCES items(id),
     FOREIGN KEY (armor_id) REFERENCES items(id),
@@ -38,27 +59,6 @@ CREATE TABLE IF NOT EXISTS characters (
     FOREIGN KEY (chain_id) REFERENCES items(id)
 );
 
--- Items table - all equipment pieces
-CREATE TABLE IF NOT EXISTS items (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    name VARCHAR(255) NOT NULL,
-    type ENUM('boots', 'pants', 'armor', 'helmet', 'ring', 'chain') NOT NULL,
-    rarity ENUM('common', 'rare', 'epic', 'legendary') DEFAULT 'common',
-    
-    -- Stat bonuses
-    strength_bonus INT DEFAULT 0,
-    agility_bonus INT DEFAULT 0,
-    vitality_bonus INT DEFAULT 0,
-    intelligence_bonus INT DEFAULT 0,
-    
-    -- Special properties
-    special_effect VARCHAR(500) DEFAULT NULL,
-    value INT DEFAULT 100, -- Channel points value
-    is_special BOOLEAN DEFAULT FALSE, -- For merchant items
-    
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
 -- Character inventory - items owned by characters
 CREATE TABLE IF NOT EXISTS character_items (
     id INT AUTO_INCREMENT PRIMARY KEY,
@@ -66,7 +66,7 @@ CREATE TABLE IF NOT EXISTS character_items (
     item_id INT NOT NULL,
     quantity INT DEFAULT 1,
     acquired_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    
+
     FOREIGN KEY (character_id) REFERENCES characters(id) ON DELETE CASCADE,
     FOREIGN KEY (item_id) REFERENCES items(id),
     UNIQUE KEY unique_character_item (character_id, item_id)
@@ -78,17 +78,18 @@ CREATE TABLE IF NOT EXISTS combat_logs (
     attacker_id INT NOT NULL,
     defender_id INT NOT NULL,
     winner_id INT NOT NULL,
-    
+
     -- Combat details
     attacker_power INT NOT NULL,
     defender_power INT NOT NULL,
     combat_log TEXT,
-    
+
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    
+
     FOREIGN KEY (attacker_id) REFERENCES characters(id),
     FOREIGN KEY (defender_id) REFERENCES characters(id),
-    FOREIGN KEY (winner_id) REFERENCES characters(id)
+    FOREIGN KEY (winner_id) REFERENCES characters(id),
+    INDEX idx_combat_logs_created_at (created_at)
 );
 
 -- Merchant events tracking
@@ -101,6 +102,33 @@ CREATE TABLE IF NOT EXISTS merchant_events (
     is_active BOOLEAN DEFAULT TRUE
 );
 
+-- Items offered by a merchant event, with price and limited stock
+CREATE TABLE IF NOT EXISTS merchant_event_items (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    merchant_event_id INT NOT NULL,
+    item_id INT NOT NULL,
+    price_channel_points INT NOT NULL,
+    stock INT NOT NULL DEFAULT 1,
+    purchased INT NOT NULL DEFAULT 0,
+
+    FOREIGN KEY (merchant_event_id) REFERENCES merchant_events(id) ON DELETE CASCADE,
+    FOREIGN KEY (item_id) REFERENCES items(id)
+);
+
+-- General events read by the /api/v1/events endpoints
+CREATE TABLE IF NOT EXISTS events (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    type VARCHAR(64) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    data TEXT,
+    is_triggered BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NULL,
+
+    INDEX idx_events_created_at (created_at)
+);
+
 -- Quest system
 CREATE TABLE IF NOT EXISTS quests (
     id INT AUTO_INCREMENT PRIMARY KEY,
@@ -122,7 +150,7 @@ CREATE TABLE IF NOT EXISTS character_quests (
     completed BOOLEAN DEFAULT FALSE,
     completed_at TIMESTAMP NULL,
     started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    
+
     FOREIGN KEY (character_id) REFERENCES characters(id) ON DELETE CASCADE,
     FOREIGN KEY (quest_id) REFERENCES quests(id),
     UNIQUE KEY unique_character_quest (character_id, quest_id)
@@ -136,6 +164,6 @@ CREATE TABLE IF NOT EXISTS game_events (
     event_data JSON, -- Flexible event data for OBS
     obs_triggered BOOLEAN DEFAULT FALSE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    
+
     FOREIGN KEY (character_id) REFERENCES characters(id)
-);
\ No newline at end of file
+);
@@ -0,0 +1,28 @@
+-- Per-character channel point wallet and its append-only ledger
+
+CREATE TABLE wallets (
+    character_id INT PRIMARY KEY,
+    balance INT NOT NULL DEFAULT 0,
+    lifetime_earned INT NOT NULL DEFAULT 0,
+    lifetime_spent INT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (character_id) REFERENCES characters(id) ON DELETE CASCADE,
+    CHECK (balance >= 0)
+);
+
+CREATE TABLE wallet_transactions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    character_id INT NOT NULL,
+    type ENUM('credit', 'debit') NOT NULL,
+    amount INT NOT NULL,
+    balance_after INT NOT NULL,
+    reason VARCHAR(64) NOT NULL,
+    reference_type VARCHAR(64) NOT NULL DEFAULT '',
+    reference_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (character_id) REFERENCES characters(id) ON DELETE CASCADE,
+    INDEX idx_wallet_transactions_character (character_id, id),
+    CHECK (amount > 0)
+);
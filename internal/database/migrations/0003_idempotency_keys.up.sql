@@ -0,0 +1,14 @@
+-- Results of requests made with an Idempotency-Key header or redemption_id field
+
+CREATE TABLE idempotency_keys (
+    scope VARCHAR(255) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    response_body MEDIUMBLOB NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP NULL,
+
+    PRIMARY KEY (scope, idempotency_key)
+);
@@ -0,0 +1 @@
+ALTER TABLE combat_logs DROP COLUMN transcript;
@@ -0,0 +1,3 @@
+-- Round-by-round transcript of each fight for overlay replays
+
+ALTER TABLE combat_logs ADD COLUMN transcript JSON NULL AFTER combat_log;
@@ -0,0 +1,4 @@
+ALTER TABLE combat_logs
+    DROP COLUMN defender_snapshot,
+    DROP COLUMN attacker_snapshot,
+    DROP COLUMN seed;
@@ -0,0 +1,6 @@
+-- Seed and fighter snapshots so logged fights can be replayed and verified
+
+ALTER TABLE combat_logs
+    ADD COLUMN seed BIGINT NULL AFTER transcript,
+    ADD COLUMN attacker_snapshot JSON NULL AFTER seed,
+    ADD COLUMN defender_snapshot JSON NULL AFTER attacker_snapshot;
@@ -0,0 +1,21 @@
+-- Duel challenges that the defender must accept before a fight runs
+
+CREATE TABLE duel_challenges (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    challenger_id INT NOT NULL,
+    defender_id INT NOT NULL,
+    wager INT NOT NULL DEFAULT 0,
+    status ENUM('pending', 'accepted', 'declined', 'expired', 'cancelled') NOT NULL DEFAULT 'pending',
+    combat_log_id INT NULL,
+    winner_id INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    responded_at TIMESTAMP NULL,
+
+    FOREIGN KEY (challenger_id) REFERENCES characters(id) ON DELETE CASCADE,
+    FOREIGN KEY (defender_id) REFERENCES characters(id) ON DELETE CASCADE,
+    FOREIGN KEY (combat_log_id) REFERENCES combat_logs(id) ON DELETE SET NULL,
+    INDEX idx_duel_status_expires (status, expires_at),
+    INDEX idx_duel_challenger (challenger_id, status),
+    INDEX idx_duel_defender (defender_id, status)
+);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS rating_history;
+
+ALTER TABLE characters
+    DROP INDEX idx_character_rating,
+    DROP COLUMN rated_fights,
+    DROP COLUMN rating;
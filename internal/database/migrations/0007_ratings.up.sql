@@ -0,0 +1,22 @@
+-- Elo rating per character and the history of rated fights
+
+ALTER TABLE characters
+    ADD COLUMN rating INT NOT NULL DEFAULT 1200 AFTER intelligence,
+    ADD COLUMN rated_fights INT NOT NULL DEFAULT 0 AFTER rating,
+    ADD INDEX idx_character_rating (rating DESC);
+
+CREATE TABLE rating_history (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    character_id INT NOT NULL,
+    opponent_id INT NOT NULL,
+    combat_log_id INT NOT NULL,
+    won BOOLEAN NOT NULL,
+    rating_before INT NOT NULL,
+    rating_after INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (character_id) REFERENCES characters(id) ON DELETE CASCADE,
+    FOREIGN KEY (combat_log_id) REFERENCES combat_logs(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_character_fight (character_id, combat_log_id),
+    INDEX idx_rating_history_character (character_id, id)
+);
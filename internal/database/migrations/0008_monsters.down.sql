@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS hunt_logs;
+DROP TABLE IF EXISTS monster_loot;
+DROP TABLE IF EXISTS monsters;
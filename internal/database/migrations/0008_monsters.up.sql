@@ -0,0 +1,46 @@
+-- Monster bestiary, loot tables and hunt history.
+-- The catalog itself is loaded by scripts/populate_monsters.sql.
+
+CREATE TABLE monsters (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT,
+    level_min INT NOT NULL DEFAULT 1,
+    level_max INT NOT NULL DEFAULT 1,
+    strength INT NOT NULL DEFAULT 0,
+    agility INT NOT NULL DEFAULT 0,
+    vitality INT NOT NULL DEFAULT 0,
+    intelligence INT NOT NULL DEFAULT 0,
+    experience_reward INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_monster_levels (level_min, level_max)
+);
+
+CREATE TABLE monster_loot (
+    monster_id INT NOT NULL,
+    item_id INT NOT NULL,
+    drop_chance DECIMAL(5,4) NOT NULL,
+    quantity INT NOT NULL DEFAULT 1,
+
+    PRIMARY KEY (monster_id, item_id),
+    FOREIGN KEY (monster_id) REFERENCES monsters(id) ON DELETE CASCADE,
+    FOREIGN KEY (item_id) REFERENCES items(id) ON DELETE CASCADE
+);
+
+CREATE TABLE hunt_logs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    character_id INT NOT NULL,
+    monster_id INT NOT NULL,
+    monster_level INT NOT NULL,
+    won BOOLEAN NOT NULL,
+    experience_gained INT NOT NULL DEFAULT 0,
+    drop_item_ids JSON NULL,
+    seed BIGINT NOT NULL,
+    transcript JSON NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (character_id) REFERENCES characters(id) ON DELETE CASCADE,
+    FOREIGN KEY (monster_id) REFERENCES monsters(id) ON DELETE CASCADE,
+    INDEX idx_hunt_character (character_id, id)
+);
@@ -0,0 +1,9 @@
+DELETE FROM game_events
+WHERE event_type NOT IN ('combat', 'merchant', 'level_up', 'item_acquired', 'quest_completed');
+
+ALTER TABLE game_events
+    DROP INDEX idx_game_events_created,
+    MODIFY event_type ENUM('combat', 'merchant', 'level_up', 'item_acquired', 'quest_completed') NOT NULL;
+
+DROP TABLE IF EXISTS raid_contributions;
+DROP TABLE IF EXISTS raid_bosses;
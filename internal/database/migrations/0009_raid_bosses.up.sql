@@ -0,0 +1,44 @@
+-- Community raid bosses, per-character damage contributions and game event types beyond the original enum
+
+CREATE TABLE raid_bosses (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    level INT NOT NULL DEFAULT 1,
+    strength INT NOT NULL DEFAULT 0,
+    agility INT NOT NULL DEFAULT 0,
+    vitality INT NOT NULL DEFAULT 0,
+    intelligence INT NOT NULL DEFAULT 0,
+    max_hp INT NOT NULL,
+    current_hp INT NOT NULL,
+    reward_points INT NOT NULL DEFAULT 0,
+    reward_experience INT NOT NULL DEFAULT 0,
+    attack_cooldown_seconds INT NOT NULL DEFAULT 30,
+    status ENUM('active', 'defeated', 'escaped') NOT NULL DEFAULT 'active',
+    last_hit_by INT NULL,
+    spawned_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NULL,
+    ended_at TIMESTAMP NULL,
+
+    FOREIGN KEY (last_hit_by) REFERENCES characters(id) ON DELETE SET NULL,
+    INDEX idx_raid_status (status, id)
+);
+
+-- last_attack_at enforces the per-character attack cooldown
+CREATE TABLE raid_contributions (
+    raid_id INT NOT NULL,
+    character_id INT NOT NULL,
+    damage INT NOT NULL DEFAULT 0,
+    attacks INT NOT NULL DEFAULT 0,
+    last_attack_at TIMESTAMP(3) NOT NULL,
+    reward_points INT NULL,
+    reward_experience INT NULL,
+
+    PRIMARY KEY (raid_id, character_id),
+    FOREIGN KEY (raid_id) REFERENCES raid_bosses(id) ON DELETE CASCADE,
+    FOREIGN KEY (character_id) REFERENCES characters(id) ON DELETE CASCADE,
+    INDEX idx_raid_contribution_damage (raid_id, damage DESC)
+);
+
+ALTER TABLE game_events
+    MODIFY event_type VARCHAR(50) NOT NULL,
+    ADD INDEX idx_game_events_created (created_at, id);
@@ -0,0 +1,14 @@
+-- Keep only the latest attempt per quest so the original unique key can be restored
+DELETE older FROM character_quests older
+JOIN character_quests newer
+    ON newer.character_id = older.character_id AND newer.quest_id = older.quest_id AND newer.id > older.id;
+
+ALTER TABLE character_quests
+    ADD UNIQUE KEY unique_character_quest (character_id, quest_id),
+    DROP INDEX unique_character_quest_rotation,
+    DROP INDEX idx_character_quests_expiry,
+    DROP COLUMN expires_at,
+    DROP COLUMN expired,
+    DROP COLUMN rotation_id;
+
+DROP TABLE IF EXISTS quest_rotations;
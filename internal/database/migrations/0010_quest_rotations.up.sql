@@ -0,0 +1,22 @@
+-- Daily and weekly quest rotations. Character quests are keyed by the rotation they were
+-- accepted in, so rotating quests can be taken again after a reset; rotation_id 0 marks
+-- special quests that never rotate.
+
+CREATE TABLE quest_rotations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    quest_type ENUM('daily', 'weekly') NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    quest_ids JSON NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY unique_quest_rotation_period (quest_type, period_start)
+);
+
+ALTER TABLE character_quests
+    ADD COLUMN rotation_id INT NOT NULL DEFAULT 0 AFTER quest_id,
+    ADD COLUMN expired BOOLEAN NOT NULL DEFAULT FALSE AFTER completed_at,
+    ADD COLUMN expires_at TIMESTAMP NULL AFTER started_at,
+    ADD UNIQUE KEY unique_character_quest_rotation (character_id, quest_id, rotation_id),
+    DROP INDEX unique_character_quest,
+    ADD INDEX idx_character_quests_expiry (expired, completed, expires_at);
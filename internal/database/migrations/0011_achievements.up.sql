@@ -0,0 +1,12 @@
+-- Achievement tiers unlocked per character; the achievements themselves are defined in code
+
+CREATE TABLE character_achievements (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    character_id INT NOT NULL,
+    achievement_key VARCHAR(50) NOT NULL,
+    tier ENUM('bronze', 'silver', 'gold') NOT NULL,
+    unlocked_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+
+    FOREIGN KEY (character_id) REFERENCES characters(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_character_achievement_tier (character_id, achievement_key, tier)
+);
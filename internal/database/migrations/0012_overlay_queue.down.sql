@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS overlay_deliveries;
+DROP TABLE IF EXISTS overlay_consumers;
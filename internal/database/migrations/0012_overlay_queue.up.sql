@@ -0,0 +1,21 @@
+-- Per-overlay queues over game_events: each consumer has its own cursor, and claimed
+-- events stay in overlay_deliveries until the overlay acknowledges them
+
+CREATE TABLE overlay_consumers (
+    name VARCHAR(50) PRIMARY KEY,
+    cursor_event_id INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3)
+);
+
+CREATE TABLE overlay_deliveries (
+    consumer VARCHAR(50) NOT NULL,
+    event_id INT NOT NULL,
+    claim_token CHAR(32) NOT NULL,
+    claimed_until TIMESTAMP(3) NOT NULL,
+    attempts INT NOT NULL DEFAULT 1,
+
+    PRIMARY KEY (consumer, event_id),
+    FOREIGN KEY (consumer) REFERENCES overlay_consumers(name) ON DELETE CASCADE,
+    FOREIGN KEY (event_id) REFERENCES game_events(id) ON DELETE CASCADE,
+    INDEX idx_overlay_deliveries_expiry (consumer, claimed_until)
+);
@@ -0,0 +1,14 @@
+-- Characters are identified by their Twitch user ID; usernames follow Twitch renames and
+-- the names a character went by are kept so old mentions still resolve
+
+CREATE TABLE username_history (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    character_id INT NOT NULL,
+    old_username VARCHAR(255) NOT NULL,
+    new_username VARCHAR(255) NOT NULL,
+    changed_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+
+    FOREIGN KEY (character_id) REFERENCES characters(id) ON DELETE CASCADE,
+    INDEX idx_username_history_character (character_id, id),
+    INDEX idx_username_history_old_username (old_username, id)
+);
@@ -0,0 +1,13 @@
+// Package migrations embeds the versioned SQL schema migrations.
+//
+// Files are named NNNN_description.up.sql / NNNN_description.down.sql and are
+// applied in version order by the migrate package. Never edit a migration that
+// has been deployed; add a new one with `go run ./cmd/migrate create <name>`.
+package migrations
+
+import "embed"
+
+// FS holds every *.sql migration file in this directory
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,121 @@
+// Package eventbus is the in-process publish/subscribe bus game actions announce
+// what happened on.
+//
+// Services publish typed events such as a finished fight or a level-up, and
+// subscribers react to them without the services knowing who listens. The
+// persisting subscriber registered at startup turns every event into a
+// models.GameEvent row, which is what the OBS endpoints read.
+package eventbus
+
+import (
+	"log"
+	"runtime/debug"
+	"sync"
+	"twitch-rpg/internal/models"
+)
+
+// Event is something that happened in the game
+type Event interface {
+	// Type is the game event type the event is stored as
+	Type() models.GameEventType
+	// GameEvent builds the game event shown by the overlay
+	GameEvent() (*models.GameEvent, error)
+}
+
+// Handler receives published events
+type Handler func(Event)
+
+type subscription struct {
+	id      int
+	types   map[models.GameEventType]bool
+	handler Handler
+}
+
+// Bus delivers published events to its subscribers
+type Bus struct {
+	mutex         sync.RWMutex
+	subscriptions []subscription
+	nextID        int
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{nextID: 1}
+}
+
+// Subscribe registers a handler for events of the given types, or for every event when
+// no type is given. The returned function removes the subscription again.
+func (b *Bus) Subscribe(handler Handler, types ...models.GameEventType) (unsubscribe func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	sub := subscription{id: b.nextID, handler: handler}
+	if len(types) > 0 {
+		sub.types = map[models.GameEventType]bool{}
+		for _, eventType := range types {
+			sub.types[eventType] = true
+		}
+	}
+	b.nextID++
+
+	// Subscriptions are copied on write so Publish can iterate without holding the lock
+	subscriptions := make([]subscription, len(b.subscriptions), len(b.subscriptions)+1)
+	copy(subscriptions, b.subscriptions)
+	b.subscriptions = append(subscriptions, sub)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { b.unsubscribe(sub.id) })
+	}
+}
+
+func (b *Bus) unsubscribe(id int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	subscriptions := make([]subscription, 0, len(b.subscriptions))
+	for _, sub := range b.subscriptions {
+		if sub.id != id {
+			subscriptions = append(subscriptions, sub)
+		}
+	}
+	b.subscriptions = subscriptions
+}
+
+// Publish delivers an event to every matching subscriber in the order they subscribed.
+// Handlers run on the publishing goroutine, so they must be quick; a handler that
+// panics is logged and does not stop delivery to the others or fail the game action.
+func (b *Bus) Publish(event Event) {
+	b.mutex.RLock()
+	subscriptions := b.subscriptions
+	b.mutex.RUnlock()
+
+	for _, sub := range subscriptions {
+		if sub.types != nil && !sub.types[event.Type()] {
+			continue
+		}
+		deliver(sub.handler, event)
+	}
+}
+
+func deliver(handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Event handler panicked on %s event: %v\n%s", event.Type(), r, debug.Stack())
+		}
+	}()
+	handler(event)
+}
+
+// Default is the bus the services publish on
+var Default = NewBus()
+
+// Publish publishes an event on the default bus
+func Publish(event Event) {
+	Default.Publish(event)
+}
+
+// Subscribe subscribes a handler on the default bus
+func Subscribe(handler Handler, types ...models.GameEventType) (unsubscribe func()) {
+	return Default.Subscribe(handler, types...)
+}
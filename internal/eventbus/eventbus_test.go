@@ -0,0 +1,194 @@
+package eventbus_test
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"twitch-rpg/internal/eventbus"
+	"twitch-rpg/internal/models"
+)
+
+// testEvent is a numbered event of any type
+type testEvent struct {
+	kind models.GameEventType
+	n    int
+}
+
+func (e testEvent) Type() models.GameEventType { return e.kind }
+
+func (e testEvent) GameEvent() (*models.GameEvent, error) {
+	return &models.GameEvent{EventType: e.kind}, nil
+}
+
+// recorder collects what its handlers received
+type recorder struct {
+	mutex sync.Mutex
+	got   []string
+}
+
+func (r *recorder) handler(name string) eventbus.Handler {
+	return func(event eventbus.Event) {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		r.got = append(r.got, fmt.Sprintf("%s:%d", name, event.(testEvent).n))
+	}
+}
+
+func (r *recorder) take() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	got := r.got
+	r.got = nil
+	return got
+}
+
+func expect(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("delivered %v; want %v", got, want)
+	}
+}
+
+// within fails the test when fn does not return in time
+func within(t *testing.T, what string, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s blocked behind a slow subscriber", what)
+	}
+}
+
+func TestDeliveryOrder(t *testing.T) {
+	bus := eventbus.NewBus()
+	r := &recorder{}
+	bus.Subscribe(r.handler("a"))
+	bus.Subscribe(r.handler("b"))
+	bus.Subscribe(r.handler("c"))
+
+	for n := 1; n <= 3; n++ {
+		bus.Publish(testEvent{models.EventTypeCombat, n})
+	}
+	expect(t, r.take(), "a:1", "b:1", "c:1", "a:2", "b:2", "c:2", "a:3", "b:3", "c:3")
+}
+
+func TestTypeFilter(t *testing.T) {
+	bus := eventbus.NewBus()
+	r := &recorder{}
+	bus.Subscribe(r.handler("all"))
+	bus.Subscribe(r.handler("levels"), models.EventTypeLevelUp)
+	bus.Subscribe(r.handler("items"), models.EventTypeItemAcquired, models.EventTypeItemEquipped)
+
+	bus.Publish(testEvent{models.EventTypeLevelUp, 1})
+	bus.Publish(testEvent{models.EventTypeItemEquipped, 2})
+	bus.Publish(testEvent{models.EventTypeCombat, 3})
+	expect(t, r.take(), "all:1", "levels:1", "all:2", "items:2", "all:3")
+}
+
+func TestUnsubscribe(t *testing.T) {
+	bus := eventbus.NewBus()
+	r := &recorder{}
+	bus.Subscribe(r.handler("a"))
+	stopB := bus.Subscribe(r.handler("b"))
+	bus.Subscribe(r.handler("c"))
+
+	stopB()
+	stopB() // a second call is harmless
+	bus.Publish(testEvent{models.EventTypeCombat, 1})
+	expect(t, r.take(), "a:1", "c:1")
+
+	// A handler may unsubscribe itself; the event being delivered still reaches the
+	// rest, and later subscribers only see later events
+	var stopSelf func()
+	stopSelf = bus.Subscribe(func(event eventbus.Event) {
+		r.handler("once")(event)
+		stopSelf()
+		bus.Subscribe(r.handler("late"))
+	})
+	bus.Publish(testEvent{models.EventTypeCombat, 2})
+	expect(t, r.take(), "a:2", "c:2", "once:2")
+	bus.Publish(testEvent{models.EventTypeCombat, 3})
+	expect(t, r.take(), "a:3", "c:3", "late:3")
+}
+
+func TestPanickingHandler(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	bus := eventbus.NewBus()
+	r := &recorder{}
+	bus.Subscribe(r.handler("before"))
+	bus.Subscribe(func(eventbus.Event) { panic("broken subscriber") })
+	bus.Subscribe(r.handler("after"))
+
+	bus.Publish(testEvent{models.EventTypeCombat, 1})
+	expect(t, r.take(), "before:1", "after:1")
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	bus := eventbus.NewBus()
+	r := &recorder{}
+	entered, release := make(chan struct{}), make(chan struct{})
+	bus.Subscribe(func(event eventbus.Event) {
+		if event.(testEvent).n == 1 {
+			close(entered)
+			<-release
+		}
+	}, models.EventTypeCombat)
+	bus.Subscribe(r.handler("fast"))
+
+	published := make(chan struct{})
+	go func() {
+		bus.Publish(testEvent{models.EventTypeCombat, 1})
+		close(published)
+	}()
+	<-entered
+
+	// The slow handler holds up only the publisher it runs on
+	var stop func()
+	within(t, "Subscribe", func() { stop = bus.Subscribe(r.handler("new")) })
+	within(t, "Publish from another goroutine", func() { bus.Publish(testEvent{models.EventTypeCombat, 2}) })
+	within(t, "Publish of another type", func() { bus.Publish(testEvent{models.EventTypeLevelUp, 3}) })
+	within(t, "unsubscribe", stop)
+	expect(t, r.take(), "fast:2", "new:2", "fast:3", "new:3")
+
+	close(release)
+	<-published
+	expect(t, r.take(), "fast:1")
+}
+
+func TestConcurrentUse(t *testing.T) {
+	bus := eventbus.NewBus()
+	var wg sync.WaitGroup
+	var missed atomic.Int32
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				seen := false
+				stop := bus.Subscribe(func(eventbus.Event) { seen = true })
+				bus.Publish(testEvent{models.EventTypeCombat, n})
+				stop()
+				if !seen {
+					missed.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if missed.Load() != 0 {
+		t.Fatalf("%d events missed a subscriber registered before Publish", missed.Load())
+	}
+}
@@ -0,0 +1,130 @@
+package eventbus
+
+import "twitch-rpg/internal/models"
+
+// CombatFinished is published after a fight between two characters was logged
+type CombatFinished struct {
+	Result *models.CombatResult
+}
+
+func (e CombatFinished) Type() models.GameEventType { return models.EventTypeCombat }
+
+func (e CombatFinished) GameEvent() (*models.GameEvent, error) {
+	return models.CreateCombatEvent(e.Result)
+}
+
+// LevelUp is published after a character reached a new level
+type LevelUp struct {
+	Character *models.Character
+}
+
+func (e LevelUp) Type() models.GameEventType { return models.EventTypeLevelUp }
+
+func (e LevelUp) GameEvent() (*models.GameEvent, error) {
+	return models.CreateLevelUpEvent(e.Character)
+}
+
+// Ways a character acquires an item
+const (
+	MethodPurchase     = "purchase"
+	MethodQuestReward  = "quest_reward"
+	MethodCombatReward = "combat_reward"
+)
+
+// MerchantAppeared is published after a merchant event opened, with its offers loaded
+type MerchantAppeared struct {
+	Event *models.MerchantEvent
+}
+
+func (e MerchantAppeared) Type() models.GameEventType { return models.EventTypeMerchant }
+
+func (e MerchantAppeared) GameEvent() (*models.GameEvent, error) {
+	return models.CreateMerchantAppearedEvent(e.Event)
+}
+
+// ItemAcquired is published after an item was added to a character's inventory
+type ItemAcquired struct {
+	Character *models.Character
+	Item      *models.Item
+	Method    string
+}
+
+func (e ItemAcquired) Type() models.GameEventType { return models.EventTypeItemAcquired }
+
+func (e ItemAcquired) GameEvent() (*models.GameEvent, error) {
+	return models.CreateItemAcquiredEvent(e.Character, e.Item, e.Method)
+}
+
+// ItemEquipped is published after a character equipped an item
+type ItemEquipped struct {
+	Character *models.Character
+	Item      *models.Item
+}
+
+func (e ItemEquipped) Type() models.GameEventType { return models.EventTypeItemEquipped }
+
+func (e ItemEquipped) GameEvent() (*models.GameEvent, error) {
+	return models.CreateItemEquippedEvent(e.Character, e.Item)
+}
+
+// QuestCompleted is published after a character completed a quest and got its rewards
+type QuestCompleted struct {
+	Character *models.Character
+	Quest     *models.Quest
+	Rewards   models.QuestRewards
+}
+
+func (e QuestCompleted) Type() models.GameEventType { return models.EventTypeQuestCompleted }
+
+func (e QuestCompleted) GameEvent() (*models.GameEvent, error) {
+	return models.CreateQuestCompletedEvent(e.Character, e.Quest, e.Rewards)
+}
+
+// RaidSpawned is published after a raid boss appeared
+type RaidSpawned struct {
+	Boss *models.RaidBoss
+}
+
+func (e RaidSpawned) Type() models.GameEventType { return models.EventTypeRaidSpawned }
+
+func (e RaidSpawned) GameEvent() (*models.GameEvent, error) {
+	return models.CreateRaidSpawnedEvent(e.Boss)
+}
+
+// RaidMilestone is published for the hit that pushed a raid boss below a milestone
+type RaidMilestone struct {
+	Boss      *models.RaidBoss
+	Milestone int
+	Character *models.Character
+}
+
+func (e RaidMilestone) Type() models.GameEventType { return models.EventTypeRaidMilestone }
+
+func (e RaidMilestone) GameEvent() (*models.GameEvent, error) {
+	return models.CreateRaidMilestoneEvent(e.Boss, e.Milestone, e.Character)
+}
+
+// RaidDefeated is published after a raid boss was defeated
+type RaidDefeated struct {
+	Boss            *models.RaidBoss
+	Finisher        *models.Character
+	TopContributors []models.RaidContribution
+}
+
+func (e RaidDefeated) Type() models.GameEventType { return models.EventTypeRaidDefeated }
+
+func (e RaidDefeated) GameEvent() (*models.GameEvent, error) {
+	return models.CreateRaidDefeatedEvent(e.Boss, e.Finisher, e.TopContributors)
+}
+
+// AchievementUnlocked is published after a character unlocked an achievement tier
+type AchievementUnlocked struct {
+	Character   *models.Character
+	Achievement *models.CharacterAchievement
+}
+
+func (e AchievementUnlocked) Type() models.GameEventType { return models.EventTypeAchievementUnlocked }
+
+func (e AchievementUnlocked) GameEvent() (*models.GameEvent, error) {
+	return models.CreateAchievementUnlockedEvent(e.Character, e.Achievement)
+}
@@ -0,0 +1,158 @@
+// Package eventsub implements the receiving side of Twitch EventSub webhooks.
+//
+// Twitch signs every delivery with an HMAC-SHA256 of the message ID, timestamp and
+// body, keyed with the secret given when the subscription was created. A delivery is
+// either a verification challenge sent once when subscribing, a notification carrying
+// an event, or a revocation telling that Twitch ended the subscription. Twitch retries
+// deliveries that were not acknowledged in time, so receivers must dedupe them by
+// message ID.
+package eventsub
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Request headers of a webhook delivery
+const (
+	HeaderMessageID           = "Twitch-Eventsub-Message-Id"
+	HeaderMessageRetry        = "Twitch-Eventsub-Message-Retry"
+	HeaderMessageType         = "Twitch-Eventsub-Message-Type"
+	HeaderMessageSignature    = "Twitch-Eventsub-Message-Signature"
+	HeaderMessageTimestamp    = "Twitch-Eventsub-Message-Timestamp"
+	HeaderSubscriptionType    = "Twitch-Eventsub-Subscription-Type"
+	HeaderSubscriptionVersion = "Twitch-Eventsub-Subscription-Version"
+)
+
+// Message types of webhook deliveries
+const (
+	MessageTypeVerification = "webhook_callback_verification"
+	MessageTypeNotification = "notification"
+	MessageTypeRevocation   = "revocation"
+)
+
+// SubscriptionRedemptionAdd is the subscription type of channel point redemptions of
+// custom rewards
+const SubscriptionRedemptionAdd = "channel.channel_points_custom_reward_redemption.add"
+
+// SubscriptionChatMessage is the subscription type of chat messages in a channel
+const SubscriptionChatMessage = "channel.chat.message"
+
+// MaxMessageAge is how old a delivery may be before it is rejected as a possible replay
+const MaxMessageAge = 10 * time.Minute
+
+var (
+	// ErrInvalidSignature is returned for deliveries not signed with the secret
+	ErrInvalidSignature = errors.New("invalid EventSub message signature")
+	// ErrMessageTooOld is returned for deliveries older than MaxMessageAge
+	ErrMessageTooOld = errors.New("EventSub message is too old")
+	// ErrInvalidMessage is returned for deliveries with missing headers or a malformed body
+	ErrInvalidMessage = errors.New("invalid EventSub message")
+)
+
+// Headers are the EventSub headers of a delivery
+type Headers struct {
+	MessageID        string
+	MessageType      string
+	Signature        string
+	Timestamp        string
+	SubscriptionType string
+}
+
+// ParseHeaders reads the EventSub headers of a request
+func ParseHeaders(header http.Header) Headers {
+	return Headers{
+		MessageID:        header.Get(HeaderMessageID),
+		MessageType:      header.Get(HeaderMessageType),
+		Signature:        header.Get(HeaderMessageSignature),
+		Timestamp:        header.Get(HeaderMessageTimestamp),
+		SubscriptionType: header.Get(HeaderSubscriptionType),
+	}
+}
+
+// Signature computes the signature header value of a delivery:
+// "sha256=" + hex(HMAC-SHA256(secret, messageID + timestamp + body))
+func Signature(secret, messageID, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(messageID))
+	mac.Write([]byte(timestamp))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that a delivery was signed with secret and is recent enough
+func Verify(secret string, headers Headers, body []byte, now time.Time) error {
+	if headers.MessageID == "" || headers.MessageType == "" || headers.Timestamp == "" {
+		return fmt.Errorf("%w: missing headers", ErrInvalidMessage)
+	}
+
+	expected := Signature(secret, headers.MessageID, headers.Timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(headers.Signature)) {
+		return ErrInvalidSignature
+	}
+
+	// Checked after the signature so an attacker cannot probe the clock with forged messages
+	timestamp, err := time.Parse(time.RFC3339Nano, headers.Timestamp)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp", ErrInvalidMessage)
+	}
+	if now.Sub(timestamp) > MaxMessageAge {
+		return ErrMessageTooOld
+	}
+	return nil
+}
+
+// Transport is where Twitch delivers a subscription's messages
+type Transport struct {
+	Method   string `json:"method"`
+	Callback string `json:"callback,omitempty"`
+}
+
+// Subscription describes the subscription a delivery belongs to
+type Subscription struct {
+	ID        string            `json:"id"`
+	Status    string            `json:"status"` // reason of the revocation for revocation messages
+	Type      string            `json:"type"`
+	Version   string            `json:"version"`
+	Cost      int               `json:"cost"`
+	Condition map[string]string `json:"condition"`
+	Transport Transport         `json:"transport"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Message is the body of a delivery. Challenge is set for verification messages and
+// Event for notifications.
+type Message struct {
+	Subscription Subscription    `json:"subscription"`
+	Challenge    string          `json:"challenge,omitempty"`
+	Event        json.RawMessage `json:"event,omitempty"`
+}
+
+// ParseMessage decodes the body of a delivery of the given message type
+func ParseMessage(messageType string, body []byte) (*Message, error) {
+	message := &Message{}
+	if err := json.Unmarshal(body, message); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+
+	switch messageType {
+	case MessageTypeVerification:
+		if message.Challenge == "" {
+			return nil, fmt.Errorf("%w: verification without a challenge", ErrInvalidMessage)
+		}
+	case MessageTypeNotification:
+		if len(message.Event) == 0 {
+			return nil, fmt.Errorf("%w: notification without an event", ErrInvalidMessage)
+		}
+	case MessageTypeRevocation:
+	default:
+		return nil, fmt.Errorf("%w: unknown message type %q", ErrInvalidMessage, messageType)
+	}
+	return message, nil
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AchievementHandler handles achievement HTTP requests
+type AchievementHandler struct {
+	achievementService *services.AchievementService
+}
+
+// NewAchievementHandler creates a new achievement handler
+func NewAchievementHandler(store storage.Store) *AchievementHandler {
+	return &AchievementHandler{
+		achievementService: services.NewAchievementService(store),
+	}
+}
+
+// GetCatalog lists every achievement and the thresholds of its tiers
+func (ah *AchievementHandler) GetCatalog(c *gin.Context) {
+	catalog := ah.achievementService.GetCatalog()
+	c.JSON(http.StatusOK, gin.H{"achievements": catalog, "count": len(catalog)})
+}
+
+// GetCharacterAchievements lists the achievement tiers a character has unlocked
+func (ah *AchievementHandler) GetCharacterAchievements(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID"})
+		return
+	}
+
+	achievements, err := ah.achievementService.GetCharacterAchievements(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"achievements": achievements, "count": len(achievements)})
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+	"twitch-rpg/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChannelRewardHandler handles the game's custom channel point rewards on Twitch
+type ChannelRewardHandler struct {
+	channelRewardService *services.ChannelRewardService
+}
+
+// NewChannelRewardHandler creates a new channel reward handler
+func NewChannelRewardHandler() *ChannelRewardHandler {
+	return &ChannelRewardHandler{
+		channelRewardService: services.NewChannelRewardService(),
+	}
+}
+
+// SyncRewards creates or updates the configured rewards on the Twitch channel and
+// reports what happened to each
+func (ch *ChannelRewardHandler) SyncRewards(c *gin.Context) {
+	results, err := ch.channelRewardService.SyncRewards(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rewards": results})
+}
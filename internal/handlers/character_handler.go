@@ -3,21 +3,25 @@ package handlers
 import (
         "net/http"
         "strconv"
+        "strings"
         "twitch-rpg/internal/models"
         "twitch-rpg/internal/services"
+        "twitch-rpg/internal/storage"
 
         "github.com/gin-gonic/gin"
 )
 
 // CharacterHandler handles character-related HTTP requests
 type CharacterHandler struct {
-        characterService *services.CharacterService
+        characterService   *services.CharacterService
+        achievementService *services.AchievementService
 }
 
 // NewCharacterHandler creates a new character handler
-func NewCharacterHandler() *CharacterHandler {
+func NewCharacterHandler(store storage.Store) *CharacterHandler {
         return &CharacterHandler{
-                characterService: services.NewCharacterService(),
+                characterService:   services.NewCharacterService(store),
+                achievementService: services.NewAchievementService(store),
         }
 }
 
@@ -31,7 +35,7 @@ func (ch *CharacterHandler) CreateCharacter(c *gin.Context) {
 
         character, err := ch.characterService.CreateCharacter(req.Username, req.TwitchUserID)
         if err != nil {
-                c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+                c.JSON(errorStatus(err), gin.H{"error": err.Error()})
                 return
         }
 
@@ -58,6 +62,12 @@ func (ch *CharacterHandler) GetCharacter(c *gin.Context) {
                 return
         }
 
+        // The profile shows the character's badges
+        if err := ch.achievementService.LoadAchievements(character); err != nil {
+                c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+                return
+        }
+
         c.JSON(http.StatusOK, character)
 }
 
@@ -76,9 +86,78 @@ func (ch *CharacterHandler) GetCharacterByUsername(c *gin.Context) {
                 return
         }
 
+        // The profile shows the character's badges
+        if err := ch.achievementService.LoadAchievements(character); err != nil {
+                c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+                return
+        }
+
+        c.JSON(http.StatusOK, character)
+}
+
+// GetCharacterByTwitchUserID retrieves the character of a Twitch account
+func (ch *CharacterHandler) GetCharacterByTwitchUserID(c *gin.Context) {
+        character, err := ch.characterService.GetCharacterByTwitchUserID(c.Param("twitch_user_id"))
+        if err != nil {
+                c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+                return
+        }
+
+        if character == nil {
+                c.JSON(http.StatusNotFound, gin.H{"error": "Character not found"})
+                return
+        }
+
+        // The profile shows the character's badges
+        if err := ch.achievementService.LoadAchievements(character); err != nil {
+                c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+                return
+        }
+
         c.JSON(http.StatusOK, character)
 }
 
+// ResolveCharacter finds a character by the twitch_user_id or username query parameter,
+// trying the Twitch user ID first and former usernames last, and says which matched
+func (ch *CharacterHandler) ResolveCharacter(c *gin.Context) {
+        twitchUserID := c.Query("twitch_user_id")
+        username := strings.TrimPrefix(c.Query("username"), "@")
+        if twitchUserID == "" && username == "" {
+                c.JSON(http.StatusBadRequest, gin.H{"error": "twitch_user_id or username is required"})
+                return
+        }
+
+        resolution, err := ch.characterService.ResolveCharacter(twitchUserID, username)
+        if err != nil {
+                c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+                return
+        }
+
+        if resolution == nil {
+                c.JSON(http.StatusNotFound, gin.H{"error": "Character not found"})
+                return
+        }
+
+        c.JSON(http.StatusOK, resolution)
+}
+
+// GetUsernameHistory lists the username changes of a character, newest first
+func (ch *CharacterHandler) GetUsernameHistory(c *gin.Context) {
+        id, err := strconv.Atoi(c.Param("id"))
+        if err != nil {
+                c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID"})
+                return
+        }
+
+        history, err := ch.characterService.GetUsernameHistory(id)
+        if err != nil {
+                c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+                return
+        }
+
+        c.JSON(http.StatusOK, gin.H{"usernames": history})
+}
+
 // UpgradeStats upgrades character stats
 func (ch *CharacterHandler) UpgradeStats(c *gin.Context) {
         idStr := c.Param("id")
@@ -96,7 +175,7 @@ func (ch *CharacterHandler) UpgradeStats(c *gin.Context) {
 
         character, err := ch.characterService.UpgradeCharacterStat(id, req.StatType, req.ChannelPoints)
         if err != nil {
-                c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+                c.JSON(errorStatus(err), gin.H{"error": err.Error()})
                 return
         }
 
@@ -0,0 +1,45 @@
+package handlers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"twitch-rpg/internal/handlers"
+	"twitch-rpg/internal/storage"
+	"twitch-rpg/internal/storage/storagetest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCharacterErrorStatus(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	router := gin.New()
+	handlers.RegisterRoutes(router, store, nil)
+	character := storagetest.MustCreateCharacter(t, store)
+	storagetest.MustFund(t, store, character.ID, 50)
+
+	for _, test := range []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{"wallet of a missing character", http.MethodGet, "/api/v1/characters/999999/wallet", "", http.StatusNotFound},
+		{"ledger of a missing character", http.MethodGet, "/api/v1/characters/999999/wallet/transactions", "", http.StatusNotFound},
+		{"upgrade of a missing character", http.MethodPut, "/api/v1/characters/999999/stats", `{"stat_type":"strength","channel_points":100}`, http.StatusNotFound},
+		{"upgrade of an unknown stat", http.MethodPut, fmt.Sprintf("/api/v1/characters/%d/stats", character.ID), `{"stat_type":"charisma","channel_points":100}`, http.StatusBadRequest},
+		{"upgrade worth no points", http.MethodPut, fmt.Sprintf("/api/v1/characters/%d/stats", character.ID), `{"stat_type":"strength","channel_points":50}`, http.StatusBadRequest},
+		{"upgrade beyond the wallet", http.MethodPut, fmt.Sprintf("/api/v1/characters/%d/stats", character.ID), `{"stat_type":"strength","channel_points":100}`, http.StatusPaymentRequired},
+		{"wallet of an existing character", http.MethodGet, fmt.Sprintf("/api/v1/characters/%d/wallet", character.ID), "", http.StatusOK},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			request.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+			if recorder.Code != test.want {
+				t.Fatalf("%s %s = %d %s; want %d", test.method, test.path, recorder.Code, recorder.Body, test.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
         "net/http"
         "strconv"
         "twitch-rpg/internal/services"
+        "twitch-rpg/internal/storage"
 
         "github.com/gin-gonic/gin"
 )
@@ -14,33 +15,12 @@ type CombatHandler struct {
 }
 
 // NewCombatHandler creates a new combat handler
-func NewCombatHandler() *CombatHandler {
+func NewCombatHandler(store storage.Store) *CombatHandler {
         return &CombatHandler{
-                combatService: services.NewCombatService(),
+                combatService: services.NewCombatService(store),
         }
 }
 
-// StartCombat starts a combat encounter
-func (ch *CombatHandler) StartCombat(c *gin.Context) {
-        var req struct {
-                AttackerID int `json:"attacker_id" binding:"required"`
-                DefenderID int `json:"defender_id" binding:"required"`
-        }
-
-        if err := c.ShouldBindJSON(&req); err != nil {
-                c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-                return
-        }
-
-        result, err := ch.combatService.StartCombat(req.AttackerID, req.DefenderID)
-        if err != nil {
-                c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-                return
-        }
-
-        c.JSON(http.StatusOK, result)
-}
-
 // GetCombatHistory retrieves combat history
 func (ch *CombatHandler) GetCombatHistory(c *gin.Context) {
         limit := 20 // default
@@ -57,4 +37,21 @@ func (ch *CombatHandler) GetCombatHistory(c *gin.Context) {
         }
 
         c.JSON(http.StatusOK, gin.H{"combat_history": combatHistory, "count": len(combatHistory)})
-}
\ No newline at end of file
+}
+
+// VerifyCombat replays a logged fight from its seed to settle disputes
+func (ch *CombatHandler) VerifyCombat(c *gin.Context) {
+        id, err := strconv.Atoi(c.Param("id"))
+        if err != nil {
+                c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid combat log ID"})
+                return
+        }
+
+        verification, err := ch.combatService.VerifyCombat(id)
+        if err != nil {
+                c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+                return
+        }
+
+        c.JSON(http.StatusOK, verification)
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DuelHandler handles duel challenge HTTP requests
+type DuelHandler struct {
+	duelService *services.DuelService
+}
+
+// NewDuelHandler creates a new duel handler
+func NewDuelHandler(store storage.Store) *DuelHandler {
+	return &DuelHandler{
+		duelService: services.NewDuelService(store),
+	}
+}
+
+// CreateChallenge challenges another character to a duel; the fight runs once they accept
+func (dh *DuelHandler) CreateChallenge(c *gin.Context) {
+	var req models.DuelChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	challenge, err := dh.duelService.Challenge(req.AttackerID, req.DefenderID, req.Wager)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, challenge)
+}
+
+// GetChallenge retrieves a duel challenge
+func (dh *DuelHandler) GetChallenge(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid challenge ID"})
+		return
+	}
+
+	challenge, err := dh.duelService.GetChallenge(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if challenge == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Challenge not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, challenge)
+}
+
+// GetPendingChallenges lists the open challenges a character has sent or received
+func (dh *DuelHandler) GetPendingChallenges(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID"})
+		return
+	}
+
+	challenges, err := dh.duelService.GetPendingChallenges(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"challenges": challenges, "count": len(challenges)})
+}
+
+// AcceptChallenge accepts a challenge and runs the fight
+func (dh *DuelHandler) AcceptChallenge(c *gin.Context) {
+	id, req, ok := dh.bindResponse(c)
+	if !ok {
+		return
+	}
+
+	outcome, err := dh.duelService.Accept(id, req.CharacterID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, outcome)
+}
+
+// DeclineChallenge declines a challenge and refunds the challenger's wager
+func (dh *DuelHandler) DeclineChallenge(c *gin.Context) {
+	id, req, ok := dh.bindResponse(c)
+	if !ok {
+		return
+	}
+
+	challenge, err := dh.duelService.Decline(id, req.CharacterID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, challenge)
+}
+
+func (dh *DuelHandler) bindResponse(c *gin.Context) (int, models.DuelResponseRequest, bool) {
+	var req models.DuelResponseRequest
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid challenge ID"})
+		return 0, req, false
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return 0, req, false
+	}
+
+	return id, req, true
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"twitch-rpg/internal/eventsub"
+	"twitch-rpg/internal/helix"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+)
+
+// errorStatus maps well-known service errors to HTTP status codes,
+// falling back to 500 like the rest of the handlers
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrInsufficientFunds):
+		return http.StatusPaymentRequired
+	case errors.Is(err, storage.ErrOutOfStock), errors.Is(err, storage.ErrMerchantInactive),
+		errors.Is(err, storage.ErrRaidNotActive):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrRaidCooldown):
+		return http.StatusTooManyRequests
+	case errors.Is(err, storage.ErrOfferMismatch), errors.Is(err, services.ErrSelfChallenge),
+		errors.Is(err, services.ErrInvalidWager), errors.Is(err, services.ErrInvalidRaid),
+		errors.Is(err, services.ErrInvalidQuest), errors.Is(err, services.ErrInvalidOverlayConsumer),
+		errors.Is(err, services.ErrInvalidOverlayClaim), errors.Is(err, services.ErrInvalidRedemptionInput),
+		errors.Is(err, services.ErrUnknownOffer), errors.Is(err, services.ErrInvalidStatUpgrade),
+		errors.Is(err, eventsub.ErrInvalidMessage):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrNotChallengeDefender), errors.Is(err, eventsub.ErrInvalidSignature),
+		errors.Is(err, eventsub.ErrMessageTooOld):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrChallengeNotPending), errors.Is(err, services.ErrDuplicateChallenge),
+		errors.Is(err, services.ErrAlreadyQueued), errors.Is(err, services.ErrRaidInProgress),
+		errors.Is(err, storage.ErrQuestAlreadyAccepted), errors.Is(err, services.ErrQuestInactive),
+		errors.Is(err, services.ErrQuestNotInRotation), errors.Is(err, services.ErrIdempotencyInProgress),
+		errors.Is(err, storage.ErrDuplicateUsername), errors.Is(err, storage.ErrDuplicateTwitchUserID),
+		errors.Is(err, storage.ErrTwitchUserIDMismatch):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrChallengeExpired):
+		return http.StatusGone
+	case errors.Is(err, services.ErrCombatNotReplayable):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, storage.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrEventSubDisabled), errors.Is(err, services.ErrHelixDisabled):
+		return http.StatusServiceUnavailable
+	case errors.As(err, new(*helix.APIError)), errors.Is(err, helix.ErrTokenRefreshFailed):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -4,6 +4,7 @@ import (
         "net/http"
         "strconv"
         "twitch-rpg/internal/services"
+        "twitch-rpg/internal/storage"
 
         "github.com/gin-gonic/gin"
 )
@@ -14,13 +15,13 @@ type EventHandler struct {
 }
 
 // NewEventHandler creates a new event handler
-func NewEventHandler() *EventHandler {
+func NewEventHandler(store storage.Store) *EventHandler {
         return &EventHandler{
-                eventService: services.NewEventService(),
+                eventService: services.NewEventService(store),
         }
 }
 
-// GetLatestEvents retrieves latest events
+// GetLatestEvents retrieves the latest game events for the overlay
 func (eh *EventHandler) GetLatestEvents(c *gin.Context) {
         limit := 10 // default
         if limitStr := c.Query("limit"); limitStr != "" {
@@ -49,7 +50,7 @@ func (eh *EventHandler) MarkEventTriggered(c *gin.Context) {
 
         err = eh.eventService.MarkEventTriggered(id)
         if err != nil {
-                c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+                c.JSON(errorStatus(err), gin.H{"error": err.Error()})
                 return
         }
 
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"twitch-rpg/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// streamHeartbeat is how often an idle stream sends a comment so proxies and OBS keep
+	// the connection open. Each heartbeat also checks the store for events written by
+	// other server instances.
+	streamHeartbeat = 15 * time.Second
+	// streamBatchSize is how many stored events a stream reads at once while catching up
+	streamBatchSize = 100
+	// streamRetry is the reconnect delay in milliseconds suggested to the browser
+	streamRetry = 3000
+)
+
+// StreamEvents pushes game events to OBS browser sources as Server-Sent Events.
+//
+// Query parameters types (comma separated event types) and character_id filter the
+// stream. Each event is sent with its game event ID, so a reconnecting EventSource
+// resumes after the last event it received via the Last-Event-ID header; the
+// last_event_id query parameter does the same for the first connection. Without
+// either, the stream starts with the next event.
+func (eh *EventHandler) StreamEvents(c *gin.Context) {
+	filter, err := parseStreamFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	var cursor int
+	if lastID != "" {
+		if cursor, err = strconv.Atoi(lastID); err != nil || cursor < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid last event ID"})
+			return
+		}
+	} else if cursor, err = eh.eventService.LatestEventID(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Subscribe before catching up so no event published in between is missed
+	published, stop := eh.eventService.Watch()
+	defer stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		if cursor, err = eh.sendEventsAfter(c, cursor, filter); err != nil {
+			return
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-published:
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// sendEventsAfter writes every stored event after cursor that matches the filter and
+// returns the ID of the last one written
+func (eh *EventHandler) sendEventsAfter(c *gin.Context, cursor int, filter models.GameEventFilter) (int, error) {
+	for {
+		events, err := eh.eventService.GetEventsAfter(cursor, filter, streamBatchSize)
+		if err != nil {
+			// Tell the client and let it reconnect from the last event it received
+			fmt.Fprintf(c.Writer, "event: error\ndata: %s\n\n", strconv.Quote(err.Error()))
+			c.Writer.Flush()
+			return cursor, err
+		}
+
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return cursor, err
+			}
+			if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.EventType, data); err != nil {
+				return cursor, err
+			}
+			cursor = event.ID
+		}
+		c.Writer.Flush()
+
+		if len(events) < streamBatchSize {
+			return cursor, nil
+		}
+	}
+}
+
+func parseStreamFilter(c *gin.Context) (models.GameEventFilter, error) {
+	filter := models.GameEventFilter{}
+
+	if types := c.Query("types"); types != "" {
+		for _, name := range strings.Split(types, ",") {
+			eventType := models.GameEventType(strings.TrimSpace(name))
+			if !slices.Contains(models.GameEventTypes, eventType) {
+				return filter, fmt.Errorf("unknown event type %q", name)
+			}
+			filter.Types = append(filter.Types, eventType)
+		}
+	}
+
+	if characterID := c.Query("character_id"); characterID != "" {
+		id, err := strconv.Atoi(characterID)
+		if err != nil {
+			return filter, fmt.Errorf("invalid character ID")
+		}
+		filter.CharacterID = &id
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"twitch-rpg/internal/eventsub"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxEventSubBodySize bounds the body of a webhook delivery; Twitch's are a few kilobytes
+const maxEventSubBodySize = 1 << 20
+
+// EventSubHandler handles Twitch EventSub webhook deliveries
+type EventSubHandler struct {
+	eventSubService *services.EventSubService
+}
+
+// NewEventSubHandler creates a new EventSub handler
+func NewEventSubHandler(store storage.Store) *EventSubHandler {
+	return &EventSubHandler{
+		eventSubService: services.NewEventSubService(store),
+	}
+}
+
+// HandleWebhook receives a delivery. Verification challenges are echoed as plain text,
+// handled redemptions are answered with their outcome and everything else, including
+// retried deliveries, with 204 No Content.
+func (eh *EventSubHandler) HandleWebhook(c *gin.Context) {
+	// The signature covers the raw body, so it must be read before any decoding
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxEventSubBodySize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	reply, err := eh.eventSubService.HandleMessage(eventsub.ParseHeaders(c.Request.Header), body)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	switch {
+	case reply.Challenge != "":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(reply.Challenge))
+	case reply.Outcome != nil:
+		c.JSON(http.StatusOK, reply.Outcome)
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,261 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"twitch-rpg/internal/eventsub"
+	"twitch-rpg/internal/eventsub/eventsubtest"
+	"twitch-rpg/internal/handlers"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+	"twitch-rpg/internal/storage/storagetest"
+
+	"github.com/gin-gonic/gin"
+)
+
+const eventSubSecret = "eventsub-test-secret"
+
+// TestMain configures the fixtures' rewards and the webhook secret, which the services
+// read once per process
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "eventsub-handler")
+	if err != nil {
+		panic(err)
+	}
+	rewards := filepath.Join(dir, "rewards.json")
+	if err := os.WriteFile(rewards, eventsubtest.Rewards(), 0o600); err != nil {
+		panic(err)
+	}
+	os.Setenv("CHANNEL_POINT_REWARDS_PATH", rewards)
+	os.Setenv("TWITCH_EVENTSUB_SECRET", eventSubSecret)
+	os.Setenv("TWITCH_CHAT_SOURCE", "eventsub")
+	gin.SetMode(gin.TestMode)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+type webhook struct {
+	t      *testing.T
+	store  storage.Store
+	router *gin.Engine
+}
+
+func newWebhook(t *testing.T) *webhook {
+	store := storage.NewMemoryStorage()
+	router := gin.New()
+	router.POST("/twitch/eventsub", handlers.NewEventSubHandler(store).HandleWebhook)
+	return &webhook{t: t, store: store, router: router}
+}
+
+func (w *webhook) load(name string) *eventsubtest.Fixture {
+	w.t.Helper()
+	fixture, err := eventsubtest.Load(name)
+	if err != nil {
+		w.t.Fatalf("Load(%s): %v", name, err)
+	}
+	return fixture
+}
+
+// deliver signs a fixture with secret and posts it to the webhook
+func (w *webhook) deliver(fixture *eventsubtest.Fixture, secret, messageID string, timestamp time.Time) *httptest.ResponseRecorder {
+	w.t.Helper()
+	request, err := eventsubtest.NewRequest("/twitch/eventsub", secret, fixture, messageID, timestamp)
+	if err != nil {
+		w.t.Fatalf("NewRequest(%s): %v", fixture.Name, err)
+	}
+	recorder := httptest.NewRecorder()
+	w.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func (w *webhook) outcome(recorder *httptest.ResponseRecorder) *models.RedemptionOutcome {
+	w.t.Helper()
+	if recorder.Code != http.StatusOK {
+		w.t.Fatalf("status = %d %s; want 200 with the outcome", recorder.Code, recorder.Body)
+	}
+	outcome := &models.RedemptionOutcome{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), outcome); err != nil {
+		w.t.Fatalf("invalid outcome %s: %v", recorder.Body, err)
+	}
+	return outcome
+}
+
+func (w *webhook) balance(characterID int) int {
+	w.t.Helper()
+	wallet, err := w.store.GetWallet(characterID)
+	if err != nil {
+		w.t.Fatalf("GetWallet: %v", err)
+	}
+	return wallet.Balance
+}
+
+func TestEventSubVerification(t *testing.T) {
+	w := newWebhook(t)
+	fixture := w.load("verification")
+	var body struct {
+		Challenge string `json:"challenge"`
+	}
+	json.Unmarshal(fixture.Body, &body)
+
+	recorder := w.deliver(fixture, eventSubSecret, "", time.Time{})
+	if recorder.Code != http.StatusOK || recorder.Body.String() != body.Challenge ||
+		recorder.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Fatalf("verification = %d %q (%s); want the challenge %q as plain text",
+			recorder.Code, recorder.Body, recorder.Header().Get("Content-Type"), body.Challenge)
+	}
+}
+
+func TestEventSubRejectsUnverifiedDeliveries(t *testing.T) {
+	w := newWebhook(t)
+	fixture := w.load("redemption_stat_upgrade")
+
+	if recorder := w.deliver(fixture, "wrong-secret", "", time.Time{}); recorder.Code != http.StatusForbidden {
+		t.Fatalf("bad signature = %d %s; want 403", recorder.Code, recorder.Body)
+	}
+	stale := time.Now().Add(-eventsub.MaxMessageAge - time.Minute)
+	if recorder := w.deliver(fixture, eventSubSecret, "", stale); recorder.Code != http.StatusForbidden {
+		t.Fatalf("stale timestamp = %d %s; want 403", recorder.Code, recorder.Body)
+	}
+
+	// The signature covers the body, so one changed after signing is rejected
+	signed, err := eventsubtest.NewRequest("/twitch/eventsub", eventSubSecret, fixture, "", time.Time{})
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	tampered, err := fixture.WithEvent(map[string]any{"user_login": "mallory"})
+	if err != nil {
+		t.Fatalf("WithEvent: %v", err)
+	}
+	request := httptest.NewRequest(http.MethodPost, "/twitch/eventsub", bytes.NewReader(tampered.Body))
+	request.Header = signed.Header
+	recorder := httptest.NewRecorder()
+	w.router.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("tampered body = %d %s; want 403", recorder.Code, recorder.Body)
+	}
+
+	for _, name := range []string{"cooler_viewer", "mallory"} {
+		if character, _ := w.store.GetCharacterByUsername(name); character != nil {
+			t.Fatalf("a rejected delivery created a character: %+v", character)
+		}
+	}
+}
+
+func TestEventSubIgnoresDuplicateMessageID(t *testing.T) {
+	w := newWebhook(t)
+	fixture := w.load("redemption_stat_upgrade")
+	messageID := eventsubtest.NewMessageID()
+
+	first := w.outcome(w.deliver(fixture, eventSubSecret, messageID, time.Time{}))
+	if !first.Fulfilled {
+		t.Fatalf("first delivery = %+v; want it fulfilled", first)
+	}
+	character, _ := w.store.GetCharacterByID(first.CharacterID)
+
+	// Twitch retries with the same message ID and a fresh signature
+	if recorder := w.deliver(fixture, eventSubSecret, messageID, time.Time{}); recorder.Code != http.StatusNoContent {
+		t.Fatalf("retried delivery = %d %s; want 204", recorder.Code, recorder.Body)
+	}
+	again, _ := w.store.GetCharacterByID(first.CharacterID)
+	if again.Strength != character.Strength || again.ChannelPointsSpent != character.ChannelPointsSpent {
+		t.Fatalf("retried delivery changed the character: %+v, was %+v", again, character)
+	}
+
+	// A new message ID is a new redemption
+	second := w.outcome(w.deliver(fixture, eventSubSecret, "", time.Time{}))
+	if upgraded, _ := w.store.GetCharacterByID(second.CharacterID); upgraded.Strength <= character.Strength {
+		t.Fatalf("second redemption did not upgrade strength: %d, was %d", upgraded.Strength, character.Strength)
+	}
+}
+
+func TestEventSubRevocation(t *testing.T) {
+	w := newWebhook(t)
+	if recorder := w.deliver(w.load("revocation"), eventSubSecret, "", time.Time{}); recorder.Code != http.StatusNoContent {
+		t.Fatalf("revocation = %d %s; want 204", recorder.Code, recorder.Body)
+	}
+}
+
+func TestEventSubRedemptionActions(t *testing.T) {
+	w := newWebhook(t)
+	item := storagetest.MustCreateItem(t, w.store, models.ItemTypeHelmet, models.RarityRare, 300)
+	now := time.Now()
+	offers := []models.MerchantEventItem{{ItemID: item.ID, PriceChannelPoints: 600, Stock: 1}}
+	if _, err := w.store.CreateMerchantEvent("random_shop", now, now.Add(time.Hour), offers); err != nil {
+		t.Fatalf("CreateMerchantEvent: %v", err)
+	}
+	rival, err := w.store.CreateCharacter("rival_viewer", nil)
+	if err != nil {
+		t.Fatalf("CreateCharacter: %v", err)
+	}
+	viewerID := "9001"
+	created, err := w.store.CreateCharacter("cooler_viewer", &viewerID)
+	if err != nil {
+		t.Fatalf("CreateCharacter: %v", err)
+	}
+
+	// Strength is fixed by the reward; 300 points buy 3 points of it
+	outcome := w.outcome(w.deliver(w.load("redemption_stat_upgrade"), eventSubSecret, "", time.Time{}))
+	viewer, _ := w.store.GetCharacterByID(created.ID)
+	if !outcome.Fulfilled || outcome.Credited != 300 || outcome.CharacterID != viewer.ID ||
+		viewer.Strength != created.Strength+3 || w.balance(viewer.ID) != 0 {
+		t.Fatalf("stat upgrade = %+v, character %+v", outcome, viewer)
+	}
+
+	// The viewer picks the stat
+	outcome = w.outcome(w.deliver(w.load("redemption_stat_choice"), eventSubSecret, "", time.Time{}))
+	viewer, _ = w.store.GetCharacterByID(viewer.ID)
+	if !outcome.Fulfilled || viewer.Agility != created.Agility+2 {
+		t.Fatalf("stat choice = %+v, character %+v", outcome, viewer)
+	}
+	invalid, _ := w.load("redemption_stat_choice").WithEvent(map[string]any{"user_input": "luck"})
+	outcome = w.outcome(w.deliver(invalid, eventSubSecret, "", time.Time{}))
+	if outcome.Fulfilled || outcome.Error == "" || w.balance(viewer.ID) != 0 {
+		t.Fatalf("invalid stat choice = %+v, balance %d; want it failed and its credit reversed", outcome, w.balance(viewer.ID))
+	}
+
+	// Offer 1 costs 600 of the 1000 points the redemption pays in
+	outcome = w.outcome(w.deliver(w.load("redemption_merchant_purchase"), eventSubSecret, "", time.Time{}))
+	inventory, _ := w.store.GetCharacterItems(viewer.ID)
+	if !outcome.Fulfilled || len(inventory) != 1 || inventory[0].ItemID != item.ID || w.balance(viewer.ID) != 400 {
+		t.Fatalf("merchant purchase = %+v, inventory %+v, balance %d", outcome, inventory, w.balance(viewer.ID))
+	}
+	// The offer is sold out now, so the next purchase fails and is reversed
+	outcome = w.outcome(w.deliver(w.load("redemption_merchant_purchase"), eventSubSecret, "", time.Time{}))
+	if outcome.Fulfilled || w.balance(viewer.ID) != 400 {
+		t.Fatalf("sold out purchase = %+v, balance %d", outcome, w.balance(viewer.ID))
+	}
+
+	// The duel escrows the configured wager of 100 from the 500 points paid in
+	outcome = w.outcome(w.deliver(w.load("redemption_duel"), eventSubSecret, "", time.Time{}))
+	pending, _ := w.store.GetPendingDuelChallenges(rival.ID)
+	if !outcome.Fulfilled || len(pending) != 1 || pending[0].ChallengerID != viewer.ID || pending[0].Wager != 100 ||
+		w.balance(viewer.ID) != 800 {
+		t.Fatalf("duel = %+v, pending %+v, balance %d", outcome, pending, w.balance(viewer.ID))
+	}
+
+	// Rewards outside the game are acknowledged without doing anything
+	if recorder := w.deliver(w.load("redemption_unknown_reward"), eventSubSecret, "", time.Time{}); recorder.Code != http.StatusNoContent {
+		t.Fatalf("unknown reward = %d %s; want 204", recorder.Code, recorder.Body)
+	}
+	if w.balance(viewer.ID) != 800 {
+		t.Fatalf("unknown reward changed the balance to %d", w.balance(viewer.ID))
+	}
+}
+
+func TestEventSubChatMessage(t *testing.T) {
+	w := newWebhook(t)
+	if recorder := w.deliver(w.load("chat_message"), eventSubSecret, "", time.Time{}); recorder.Code != http.StatusNoContent {
+		t.Fatalf("chat message = %d %s; want 204", recorder.Code, recorder.Body)
+	}
+	if character, _ := w.store.GetCharacterByTwitchUserID("4145994"); character == nil || character.Username != "cool_viewer" {
+		t.Fatalf("!char did not create the chatter's character: %+v", character)
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HuntHandler handles bestiary and hunt HTTP requests
+type HuntHandler struct {
+	huntService *services.HuntService
+}
+
+// NewHuntHandler creates a new hunt handler
+func NewHuntHandler(store storage.Store) *HuntHandler {
+	return &HuntHandler{
+		huntService: services.NewHuntService(store),
+	}
+}
+
+// GetMonsters lists the bestiary
+func (hh *HuntHandler) GetMonsters(c *gin.Context) {
+	monsters, err := hh.huntService.GetMonsters()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"monsters": monsters, "count": len(monsters)})
+}
+
+// GetMonster retrieves a monster with its loot table
+func (hh *HuntHandler) GetMonster(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid monster ID"})
+		return
+	}
+
+	monster, err := hh.huntService.GetMonster(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if monster == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Monster not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, monster)
+}
+
+// Hunt sends a character against a level-appropriate monster
+func (hh *HuntHandler) Hunt(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID"})
+		return
+	}
+
+	result, err := hh.huntService.Hunt(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetHuntHistory retrieves a character's recent hunts
+func (hh *HuntHandler) GetHuntHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID"})
+		return
+	}
+
+	limit, _ := pagination(c, 20, 100)
+
+	hunts, err := hh.huntService.GetHuntHistory(id, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"hunts": hunts, "count": len(hunts)})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header carrying an idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyBodyFields are JSON body fields accepted as an idempotency key when the header is absent
+var idempotencyBodyFields = []string{"idempotency_key", "redemption_id"}
+
+// maxIdempotencyKeyLength matches the idempotency_keys.idempotency_key column
+const maxIdempotencyKeyLength = 255
+
+// responseRecorder captures the response body so it can be stored for replays
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// Idempotent makes a route safe to retry. Requests carrying an Idempotency-Key header, or an
+// idempotency_key / redemption_id body field, run at most once per key and route path; repeats
+// get the stored response with an Idempotent-Replayed header. Requests without a key run normally.
+func Idempotent(store storage.Store) gin.HandlerFunc {
+	idempotencyService := services.NewIdempotencyService(store)
+
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := idempotencyKey(c, body)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency key is too long"})
+			return
+		}
+
+		scope := c.Request.Method + " " + c.Request.URL.Path
+		replay, err := idempotencyService.Begin(scope, key, body)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, services.ErrIdempotencyKeyReused):
+				status = http.StatusUnprocessableEntity
+			case errors.Is(err, services.ErrIdempotencyInProgress):
+				status = http.StatusConflict
+			}
+			c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if replay != nil {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(replay.StatusCode, "application/json; charset=utf-8", replay.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors are not recorded so the client can retry them
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			if err := idempotencyService.Release(scope, key); err != nil {
+				log.Printf("Failed to release idempotency key %q: %v", key, err)
+			}
+			return
+		}
+		if err := idempotencyService.Complete(scope, key, c.Writer.Status(), recorder.body.Bytes()); err != nil {
+			log.Printf("Failed to store idempotent response for key %q: %v", key, err)
+		}
+	}
+}
+
+// idempotencyKey returns the key from the header, falling back to a JSON body field
+func idempotencyKey(c *gin.Context, body []byte) string {
+	if key := c.GetHeader(IdempotencyKeyHeader); key != "" {
+		return key
+	}
+
+	var fields map[string]any
+	if len(body) == 0 || json.Unmarshal(body, &fields) != nil {
+		return ""
+	}
+	for _, name := range idempotencyBodyFields {
+		if key, ok := fields[name].(string); ok && key != "" {
+			return key
+		}
+	}
+
+	return ""
+}
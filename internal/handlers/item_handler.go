@@ -5,6 +5,7 @@ import (
         "strconv"
         "twitch-rpg/internal/models"
         "twitch-rpg/internal/services"
+        "twitch-rpg/internal/storage"
 
         "github.com/gin-gonic/gin"
 )
@@ -15,9 +16,9 @@ type ItemHandler struct {
 }
 
 // NewItemHandler creates a new item handler
-func NewItemHandler() *ItemHandler {
+func NewItemHandler(store storage.Store) *ItemHandler {
         return &ItemHandler{
-                itemService: services.NewItemService(),
+                itemService: services.NewItemService(store),
         }
 }
 
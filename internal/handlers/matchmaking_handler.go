@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MatchmakingHandler handles the ranked matchmaking queue
+type MatchmakingHandler struct {
+	matchmakingService *services.MatchmakingService
+}
+
+// NewMatchmakingHandler creates a new matchmaking handler around the shared queue
+func NewMatchmakingHandler(matchmakingService *services.MatchmakingService) *MatchmakingHandler {
+	return &MatchmakingHandler{
+		matchmakingService: matchmakingService,
+	}
+}
+
+// JoinQueue queues a character for a rated fight, fighting right away if an opponent is waiting
+func (mh *MatchmakingHandler) JoinQueue(c *gin.Context) {
+	var req models.MatchmakingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ticket, match, err := mh.matchmakingService.Join(req.CharacterID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if match != nil {
+		c.JSON(http.StatusOK, gin.H{"status": "matched", "match": match})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "queued", "ticket": ticket})
+}
+
+// GetQueue lists the characters waiting for a match
+func (mh *MatchmakingHandler) GetQueue(c *gin.Context) {
+	queue := mh.matchmakingService.Queue()
+	c.JSON(http.StatusOK, gin.H{"queue": queue, "count": len(queue)})
+}
+
+// GetStatus reports whether a character is still queued or has been matched
+func (mh *MatchmakingHandler) GetStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("character_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID"})
+		return
+	}
+
+	ticket, match := mh.matchmakingService.Status(id)
+	switch {
+	case ticket != nil:
+		c.JSON(http.StatusOK, gin.H{"status": "queued", "ticket": ticket})
+	case match != nil:
+		c.JSON(http.StatusOK, gin.H{"status": "matched", "match": match})
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Character is not queued"})
+	}
+}
+
+// LeaveQueue removes a character from the queue
+func (mh *MatchmakingHandler) LeaveQueue(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("character_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID"})
+		return
+	}
+
+	if !mh.matchmakingService.Leave(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Character is not queued"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left the matchmaking queue"})
+}
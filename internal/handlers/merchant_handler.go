@@ -2,7 +2,9 @@ package handlers
 
 import (
         "net/http"
+        "twitch-rpg/internal/models"
         "twitch-rpg/internal/services"
+        "twitch-rpg/internal/storage"
 
         "github.com/gin-gonic/gin"
 )
@@ -13,9 +15,9 @@ type MerchantHandler struct {
 }
 
 // NewMerchantHandler creates a new merchant handler
-func NewMerchantHandler() *MerchantHandler {
+func NewMerchantHandler(store storage.Store) *MerchantHandler {
         return &MerchantHandler{
-                merchantService: services.NewMerchantService(),
+                merchantService: services.NewMerchantService(store),
         }
 }
 
@@ -59,21 +61,17 @@ func (mh *MerchantHandler) CreateMerchantEvent(c *gin.Context) {
 
 // PurchaseItem handles item purchases
 func (mh *MerchantHandler) PurchaseItem(c *gin.Context) {
-        var req struct {
-                CharacterID         int `json:"character_id" binding:"required"`
-                MerchantEventItemID int `json:"merchant_event_item_id" binding:"required"`
-        }
-
+        var req models.MerchantPurchaseRequest
         if err := c.ShouldBindJSON(&req); err != nil {
                 c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
                 return
         }
 
-        err := mh.merchantService.PurchaseItem(req.CharacterID, req.MerchantEventItemID)
+        purchase, err := mh.merchantService.PurchaseItem(req.CharacterID, req.MerchantEventID, req.MerchantEventItemID)
         if err != nil {
-                c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+                c.JSON(errorStatus(err), gin.H{"error": err.Error()})
                 return
         }
 
-        c.JSON(http.StatusOK, gin.H{"message": "Item purchased successfully"})
-}
\ No newline at end of file
+        c.JSON(http.StatusOK, gin.H{"message": "Item purchased successfully", "purchase": purchase})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OverlayHandler handles the overlay event queue HTTP requests
+type OverlayHandler struct {
+	overlayService *services.OverlayService
+}
+
+// NewOverlayHandler creates a new overlay handler
+func NewOverlayHandler(store storage.Store) *OverlayHandler {
+	return &OverlayHandler{
+		overlayService: services.NewOverlayService(store),
+	}
+}
+
+// ClaimEvents claims the next game events of an overlay consumer's queue. The body is
+// optional; without it one event is claimed for 30 seconds.
+func (oh *OverlayHandler) ClaimEvents(c *gin.Context) {
+	var req models.OverlayClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claim, err := oh.overlayService.Claim(c.Param("consumer"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, claim)
+}
+
+// AcknowledgeEvents removes played events from an overlay consumer's queue
+func (oh *OverlayHandler) AcknowledgeEvents(c *gin.Context) {
+	var req models.OverlayAckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ack, err := oh.overlayService.Acknowledge(c.Param("consumer"), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ack)
+}
+
+// GetConsumer retrieves an overlay consumer's cursor and unacknowledged events
+func (oh *OverlayHandler) GetConsumer(c *gin.Context) {
+	consumer, err := oh.overlayService.GetConsumer(c.Param("consumer"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, consumer)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QuestHandler handles quest HTTP requests
+type QuestHandler struct {
+	questService *services.QuestService
+}
+
+// NewQuestHandler creates a new quest handler
+func NewQuestHandler(store storage.Store) *QuestHandler {
+	return &QuestHandler{
+		questService: services.NewQuestService(store),
+	}
+}
+
+// GetQuests lists the quests on the quest board
+func (qh *QuestHandler) GetQuests(c *gin.Context) {
+	quests, err := qh.questService.GetAvailableQuests()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quests": quests, "count": len(quests)})
+}
+
+// GetRotation retrieves the current daily and weekly quest rotation and the time left until each resets
+func (qh *QuestHandler) GetRotation(c *gin.Context) {
+	rotation, err := qh.questService.GetRotation()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rotation)
+}
+
+// CreateQuest adds a quest to the quest board
+func (qh *QuestHandler) CreateQuest(c *gin.Context) {
+	var req models.QuestCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quest, err := qh.questService.CreateQuest(req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, quest)
+}
+
+// GetQuest retrieves a quest by ID
+func (qh *QuestHandler) GetQuest(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	quest, err := qh.questService.GetQuest(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quest)
+}
+
+// AcceptQuest starts a quest for a character
+func (qh *QuestHandler) AcceptQuest(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid quest ID"})
+		return
+	}
+
+	var req models.QuestAcceptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	characterQuest, err := qh.questService.AcceptQuest(id, req.CharacterID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, characterQuest)
+}
+
+// GetCharacterQuests lists the quests a character has accepted and their progress
+func (qh *QuestHandler) GetCharacterQuests(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID"})
+		return
+	}
+
+	quests, err := qh.questService.GetCharacterQuests(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quests": quests, "count": len(quests)})
+}
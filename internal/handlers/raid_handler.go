@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RaidHandler handles raid boss HTTP requests
+type RaidHandler struct {
+	raidService *services.RaidService
+}
+
+// NewRaidHandler creates a new raid handler
+func NewRaidHandler(store storage.Store) *RaidHandler {
+	return &RaidHandler{
+		raidService: services.NewRaidService(store),
+	}
+}
+
+// SpawnRaid spawns a new raid boss for the whole chat to fight
+func (rh *RaidHandler) SpawnRaid(c *gin.Context) {
+	var req models.RaidSpawnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	boss, err := rh.raidService.Spawn(req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, boss)
+}
+
+// GetRaids lists the most recent raid bosses
+func (rh *RaidHandler) GetRaids(c *gin.Context) {
+	limit, _ := pagination(c, 20, 100)
+
+	raids, err := rh.raidService.GetRaids(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"raids": raids, "count": len(raids)})
+}
+
+// GetCurrentRaid retrieves the live progress of the active or most recent raid for the overlay
+func (rh *RaidHandler) GetCurrentRaid(c *gin.Context) {
+	progress, err := rh.raidService.GetCurrentProgress()
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// GetRaid retrieves the live progress of a raid
+func (rh *RaidHandler) GetRaid(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid raid ID"})
+		return
+	}
+
+	progress, err := rh.raidService.GetProgress(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// GetContributions lists every character's damage against a raid boss
+func (rh *RaidHandler) GetContributions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid raid ID"})
+		return
+	}
+
+	contributions, err := rh.raidService.GetContributions(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"contributions": contributions, "count": len(contributions)})
+}
+
+// Attack strikes a raid boss once with a character
+func (rh *RaidHandler) Attack(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid raid ID"})
+		return
+	}
+
+	var req models.RaidAttackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := rh.raidService.Attack(id, req.CharacterID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RatingHandler handles ladder and rating history HTTP requests
+type RatingHandler struct {
+	ratingService *services.RatingService
+}
+
+// NewRatingHandler creates a new rating handler
+func NewRatingHandler(store storage.Store) *RatingHandler {
+	return &RatingHandler{
+		ratingService: services.NewRatingService(store),
+	}
+}
+
+// GetLadder retrieves the ranked ladder
+func (rh *RatingHandler) GetLadder(c *gin.Context) {
+	limit, offset := pagination(c, 50, 200)
+
+	ladder, err := rh.ratingService.GetLadder(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ladder": ladder, "count": len(ladder)})
+}
+
+// GetRatingHistory retrieves a character's rating changes
+func (rh *RatingHandler) GetRatingHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID"})
+		return
+	}
+
+	limit, offset := pagination(c, 50, 200)
+
+	history, err := rh.ratingService.GetHistory(id, limit, offset)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": history, "count": len(history)})
+}
+
+// pagination reads the limit and offset query parameters, ignoring invalid values
+func pagination(c *gin.Context, defaultLimit, maxLimit int) (int, int) {
+	limit := defaultLimit
+	offset := 0
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= maxLimit {
+			limit = l
+		}
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	return limit, offset
+}
@@ -1,37 +1,74 @@
 package handlers
 
 import (
+	"net/http"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+	"twitch-rpg/internal/widgets"
+
 	"github.com/gin-gonic/gin"
 )
 
-// RegisterRoutes registers all API routes
-func RegisterRoutes(router *gin.Engine) {
+// RegisterRoutes registers all API routes backed by the given store.
+// The matchmaking service is shared with the background matcher started in main.
+func RegisterRoutes(router *gin.Engine, store storage.Store, matchmaking *services.MatchmakingService) {
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok", "service": "twitch-rpg"})
 	})
 
+	// Overlay widget pages for OBS browser sources
+	widgetHandler := NewWidgetHandler()
+	router.GET("/widgets", widgetHandler.GetIndex)
+	router.GET("/widgets/:name", widgetHandler.GetWidget)
+	router.StaticFS("/widgets/static", http.FS(widgets.Static()))
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
+		// Endpoints that spend or grant channel points accept an idempotency key
+		idempotent := Idempotent(store)
+
+		// Shared by the character routes and their own route groups
+		duelHandler := NewDuelHandler(store)
+		ratingHandler := NewRatingHandler(store)
+		huntHandler := NewHuntHandler(store)
+		questHandler := NewQuestHandler(store)
+		achievementHandler := NewAchievementHandler(store)
+
 		// Character routes
 		characters := v1.Group("/characters")
 		{
-			characterHandler := NewCharacterHandler()
+			characterHandler := NewCharacterHandler(store)
 			characters.POST("/", characterHandler.CreateCharacter)
 			characters.GET("/:id", characterHandler.GetCharacter)
 			characters.GET("/username/:username", characterHandler.GetCharacterByUsername)
-			characters.PUT("/:id/stats", characterHandler.UpgradeStats)
+			characters.GET("/twitch/:twitch_user_id", characterHandler.GetCharacterByTwitchUserID)
+			characters.GET("/resolve", characterHandler.ResolveCharacter)
+			characters.GET("/:id/usernames", characterHandler.GetUsernameHistory)
+			characters.PUT("/:id/stats", idempotent, characterHandler.UpgradeStats)
 			characters.PUT("/:id/equip", characterHandler.EquipItem)
 			characters.DELETE("/:id/unequip/:slot", characterHandler.UnequipItem)
 			characters.GET("/:id/inventory", characterHandler.GetInventory)
 			characters.GET("/", characterHandler.GetAllCharacters)
+
+			walletHandler := NewWalletHandler(store)
+			characters.GET("/:id/wallet", walletHandler.GetWallet)
+			characters.GET("/:id/wallet/transactions", walletHandler.GetTransactions)
+			characters.POST("/:id/wallet/credit", idempotent, walletHandler.Credit)
+
+			characters.GET("/:id/challenges", duelHandler.GetPendingChallenges)
+			characters.GET("/:id/rating-history", ratingHandler.GetRatingHistory)
+			characters.POST("/:id/hunt", idempotent, huntHandler.Hunt)
+			characters.GET("/:id/hunts", huntHandler.GetHuntHistory)
+			characters.GET("/:id/quests", questHandler.GetCharacterQuests)
+			characters.GET("/:id/achievements", achievementHandler.GetCharacterAchievements)
 		}
 
 		// Item routes
 		items := v1.Group("/items")
 		{
-			itemHandler := NewItemHandler()
+			itemHandler := NewItemHandler(store)
 			items.GET("/:id", itemHandler.GetItem)
 			items.GET("/type/:type", itemHandler.GetItemsByType)
 			items.GET("/random", itemHandler.GetRandomItems)
@@ -40,26 +77,89 @@ func RegisterRoutes(router *gin.Engine) {
 		// Combat routes
 		combat := v1.Group("/combat")
 		{
-			combatHandler := NewCombatHandler()
-			combat.POST("/challenge", combatHandler.StartCombat)
+			combatHandler := NewCombatHandler(store)
+			combat.POST("/challenge", idempotent, duelHandler.CreateChallenge)
+			combat.GET("/challenges/:id", duelHandler.GetChallenge)
+			combat.POST("/challenges/:id/accept", idempotent, duelHandler.AcceptChallenge)
+			combat.POST("/challenges/:id/decline", duelHandler.DeclineChallenge)
 			combat.GET("/history", combatHandler.GetCombatHistory)
+			combat.GET("/:id/verify", combatHandler.VerifyCombat)
+		}
+
+		// Bestiary routes
+		monsters := v1.Group("/monsters")
+		{
+			monsters.GET("", huntHandler.GetMonsters)
+			monsters.GET("/:id", huntHandler.GetMonster)
+		}
+
+		// Raid boss routes
+		raids := v1.Group("/raids")
+		{
+			raidHandler := NewRaidHandler(store)
+			raids.POST("", raidHandler.SpawnRaid)
+			raids.GET("", raidHandler.GetRaids)
+			raids.GET("/current", raidHandler.GetCurrentRaid)
+			raids.GET("/:id", raidHandler.GetRaid)
+			raids.GET("/:id/contributions", raidHandler.GetContributions)
+			raids.POST("/:id/attack", idempotent, raidHandler.Attack)
+		}
+
+		// Quest routes
+		quests := v1.Group("/quests")
+		{
+			quests.GET("", questHandler.GetQuests)
+			quests.POST("", questHandler.CreateQuest)
+			quests.GET("/rotation", questHandler.GetRotation)
+			quests.GET("/:id", questHandler.GetQuest)
+			quests.POST("/:id/accept", idempotent, questHandler.AcceptQuest)
+		}
+
+		// Achievement catalog
+		v1.GET("/achievements", achievementHandler.GetCatalog)
+
+		// Ranked ladder and matchmaking routes
+		v1.GET("/ladder", ratingHandler.GetLadder)
+		queue := v1.Group("/matchmaking/queue")
+		{
+			matchmakingHandler := NewMatchmakingHandler(matchmaking)
+			queue.POST("", matchmakingHandler.JoinQueue)
+			queue.GET("", matchmakingHandler.GetQueue)
+			queue.GET("/:character_id", matchmakingHandler.GetStatus)
+			queue.DELETE("/:character_id", matchmakingHandler.LeaveQueue)
 		}
 
 		// Game events routes (for OBS integration)
 		events := v1.Group("/events")
 		{
-			eventHandler := NewEventHandler()
+			eventHandler := NewEventHandler(store)
 			events.GET("/latest", eventHandler.GetLatestEvents)
+			events.GET("/stream", eventHandler.StreamEvents)
 			events.PUT("/:id/trigger", eventHandler.MarkEventTriggered)
 		}
 
+		// Overlay event queues: every overlay consumer claims and acknowledges events on its own cursor
+		overlays := v1.Group("/overlays")
+		{
+			overlayHandler := NewOverlayHandler(store)
+			overlays.GET("/:consumer", overlayHandler.GetConsumer)
+			overlays.POST("/:consumer/claim", overlayHandler.ClaimEvents)
+			overlays.POST("/:consumer/ack", overlayHandler.AcknowledgeEvents)
+		}
+
+		// Twitch EventSub webhook: channel point redemptions trigger the configured game actions
+		v1.POST("/twitch/eventsub", NewEventSubHandler(store).HandleWebhook)
+
+		// Create or update the configured rewards on the channel through the Helix API
+		v1.POST("/twitch/rewards/sync", NewChannelRewardHandler().SyncRewards)
+
 		// Merchant routes
 		merchant := v1.Group("/merchant")
 		{
-			merchantHandler := NewMerchantHandler()
+			merchantHandler := NewMerchantHandler(store)
 			merchant.GET("/current", merchantHandler.GetCurrentEvent)
 			merchant.POST("/create", merchantHandler.CreateMerchantEvent)
-			merchant.POST("/purchase", merchantHandler.PurchaseItem)
+			merchant.POST("/purchase", idempotent, merchantHandler.PurchaseItem)
 		}
 	}
 }
\ No newline at end of file
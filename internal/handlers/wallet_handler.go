@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WalletHandler handles wallet-related HTTP requests
+type WalletHandler struct {
+	walletService *services.WalletService
+}
+
+// NewWalletHandler creates a new wallet handler
+func NewWalletHandler(store storage.Store) *WalletHandler {
+	return &WalletHandler{
+		walletService: services.NewWalletService(store),
+	}
+}
+
+// GetWallet retrieves a character's wallet balance
+func (wh *WalletHandler) GetWallet(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID"})
+		return
+	}
+
+	wallet, err := wh.walletService.GetWallet(id)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, wallet)
+}
+
+// GetTransactions retrieves a character's wallet ledger
+func (wh *WalletHandler) GetTransactions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID"})
+		return
+	}
+
+	limit := 50 // default
+	offset := 0 // default
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 200 {
+			limit = l
+		}
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	transactions, err := wh.walletService.GetTransactions(id, limit, offset)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transactions": transactions, "count": len(transactions)})
+}
+
+// Credit deposits redeemed channel points into a character's wallet
+func (wh *WalletHandler) Credit(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid character ID"})
+		return
+	}
+
+	var req models.WalletCreditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Reason == "" {
+		req.Reason = models.WalletReasonRedemption
+	}
+	if req.Reason != models.WalletReasonRedemption && req.Reason != models.WalletReasonAdjustment {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credit reason"})
+		return
+	}
+
+	transaction, err := wh.walletService.Credit(id, req.Amount, req.Reason, req.ReferenceType, req.ReferenceID)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, transaction)
+}
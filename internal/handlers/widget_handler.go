@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"twitch-rpg/internal/widgets"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WidgetHandler serves the built-in overlay widget pages
+type WidgetHandler struct{}
+
+// NewWidgetHandler creates a new widget handler
+func NewWidgetHandler() *WidgetHandler {
+	return &WidgetHandler{}
+}
+
+// GetIndex lists the widgets and their theme parameters
+func (wh *WidgetHandler) GetIndex(c *gin.Context) {
+	var page bytes.Buffer
+	if err := widgets.RenderIndex(&page); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// GetWidget renders a widget page styled with the theme parameters of the query string
+func (wh *WidgetHandler) GetWidget(c *gin.Context) {
+	widget, ok := widgets.Find(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Widget not found"})
+		return
+	}
+
+	theme, err := widgets.ParseTheme(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Render into a buffer so a template error still produces a clean error response
+	var page bytes.Buffer
+	if err := widgets.Render(&page, widget, theme); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
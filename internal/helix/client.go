@@ -0,0 +1,336 @@
+// Package helix is a client for the parts of the Twitch Helix API the game uses:
+// managing the channel's custom channel point rewards and marking their redemptions
+// fulfilled or canceled.
+//
+// Requests are made with the broadcaster's user access token. When Twitch rejects an
+// expired token, the client refreshes it with the refresh token once and retries. The
+// client follows Twitch's rate limit headers, waiting for the bucket to refill before
+// sending when it is empty and retrying requests rejected with 429 Too Many Requests.
+package helix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBaseURL is the Helix API
+	DefaultBaseURL = "https://api.twitch.tv/helix"
+	// DefaultAuthURL is Twitch's OAuth server, which refreshes tokens
+	DefaultAuthURL = "https://id.twitch.tv/oauth2"
+	// defaultMaxRetries bounds retries of requests rejected by the rate limit
+	defaultMaxRetries = 3
+	// refreshMargin is how long before its expiry a token is refreshed ahead of use
+	refreshMargin = time.Minute
+	// retryAfter is how long to wait after a 429 without a Retry-After or reset header
+	retryAfter = time.Second
+)
+
+// Rate limit headers of Helix responses
+const (
+	HeaderRateLimitLimit     = "Ratelimit-Limit"
+	HeaderRateLimitRemaining = "Ratelimit-Remaining"
+	HeaderRateLimitReset     = "Ratelimit-Reset" // Unix time in seconds when the bucket is full again
+)
+
+// ErrTokenRefreshFailed is returned when an expired token could not be refreshed
+var ErrTokenRefreshFailed = errors.New("helix: token refresh failed")
+
+// APIError is an error response of the Helix API
+type APIError struct {
+	StatusCode int    `json:"status"`
+	ErrorText  string `json:"error"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("helix: %d %s", e.StatusCode, e.ErrorText)
+	}
+	return fmt.Sprintf("helix: %d %s: %s", e.StatusCode, e.ErrorText, e.Message)
+}
+
+// Token is a user access token with the refresh token that renews it
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"` // zero when unknown
+}
+
+// RateLimit is the state of the rate limit bucket as of the last response
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// Client calls the Helix API on behalf of a broadcaster
+type Client struct {
+	clientID     string
+	clientSecret string
+
+	// BaseURL and AuthURL default to Twitch's and are overridden for a local mock server
+	BaseURL string
+	AuthURL string
+	// HTTPClient makes the requests; it defaults to a client with a 10 second timeout
+	HTTPClient *http.Client
+	// MaxRetries bounds the retries of a request rejected by the rate limit
+	MaxRetries int
+	// OnTokenRefresh is called with every refreshed token, e.g. to store it
+	OnTokenRefresh func(Token)
+	// Logf logs token refreshes and rate limit waits; it defaults to log.Printf
+	Logf func(format string, args ...any)
+
+	mutex     sync.Mutex
+	token     Token
+	rateLimit RateLimit
+	refresh   sync.Mutex // serializes refreshes
+}
+
+// NewClient creates a client for an application's client ID and secret, acting with
+// the broadcaster's token
+func NewClient(clientID, clientSecret string, token Token) *Client {
+	return &Client{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		BaseURL:      DefaultBaseURL,
+		AuthURL:      DefaultAuthURL,
+		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+		MaxRetries:   defaultMaxRetries,
+		Logf:         log.Printf,
+		token:        token,
+	}
+}
+
+// Token returns the current token, which changes when it is refreshed
+func (c *Client) Token() Token {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.token
+}
+
+// RateLimit returns the rate limit state reported by the last response
+func (c *Client) RateLimit() RateLimit {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.rateLimit
+}
+
+// do sends a request and decodes the data of the response into out, which may be nil
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	endpoint := strings.TrimRight(c.BaseURL, "/") + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		if err := c.waitForRateLimit(ctx); err != nil {
+			return err
+		}
+		token := c.Token()
+		if !refreshed && !token.ExpiresAt.IsZero() && time.Until(token.ExpiresAt) < refreshMargin {
+			if err := c.refreshToken(ctx, token.AccessToken); err != nil {
+				return err
+			}
+			refreshed = true
+			token = c.Token()
+		}
+
+		request, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Client-Id", c.clientID)
+		request.Header.Set("Authorization", "Bearer "+token.AccessToken)
+		if body != nil {
+			request.Header.Set("Content-Type", "application/json")
+		}
+
+		response, err := c.HTTPClient.Do(request)
+		if err != nil {
+			return fmt.Errorf("helix: %s %s: %v", method, path, err)
+		}
+		data, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return fmt.Errorf("helix: %s %s: %v", method, path, err)
+		}
+		c.updateRateLimit(response.Header)
+		if response.StatusCode == http.StatusTooManyRequests {
+			c.backOff(response.Header)
+		}
+
+		switch {
+		case response.StatusCode == http.StatusUnauthorized && !refreshed && token.RefreshToken != "":
+			if err := c.refreshToken(ctx, token.AccessToken); err != nil {
+				return err
+			}
+			refreshed = true
+			continue
+		case response.StatusCode == http.StatusTooManyRequests && attempt < c.MaxRetries:
+			c.Logf("Helix rate limit exceeded on %s %s, retrying", method, path)
+			continue
+		case response.StatusCode >= 400:
+			apiErr := &APIError{}
+			if json.Unmarshal(data, apiErr) != nil || apiErr.ErrorText == "" {
+				apiErr.ErrorText = http.StatusText(response.StatusCode)
+			}
+			apiErr.StatusCode = response.StatusCode
+			return apiErr
+		}
+
+		if out == nil || len(data) == 0 {
+			return nil
+		}
+		envelope := struct {
+			Data any `json:"data"`
+		}{Data: out}
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return fmt.Errorf("helix: invalid response to %s %s: %v", method, path, err)
+		}
+		return nil
+	}
+}
+
+// waitForRateLimit blocks while the last response reported an empty bucket that has
+// not been refilled yet
+func (c *Client) waitForRateLimit(ctx context.Context) error {
+	c.mutex.Lock()
+	limit := c.rateLimit
+	c.mutex.Unlock()
+
+	wait := time.Until(limit.Reset)
+	if limit.Remaining > 0 || wait <= 0 {
+		return nil
+	}
+	c.Logf("Helix rate limit exhausted, waiting %s", wait.Round(time.Millisecond))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+	}
+
+	c.mutex.Lock()
+	if c.rateLimit.Reset.Equal(limit.Reset) {
+		c.rateLimit.Remaining = c.rateLimit.Limit // refilled; the next response tells the truth
+	}
+	c.mutex.Unlock()
+	return nil
+}
+
+// updateRateLimit records the rate limit headers of a response, leaving the state
+// alone when they are missing
+func (c *Client) updateRateLimit(header http.Header) {
+	limit, limitErr := strconv.Atoi(header.Get(HeaderRateLimitLimit))
+	remaining, remainingErr := strconv.Atoi(header.Get(HeaderRateLimitRemaining))
+	reset, resetErr := strconv.ParseInt(header.Get(HeaderRateLimitReset), 10, 64)
+	if limitErr != nil || remainingErr != nil || resetErr != nil {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.rateLimit = RateLimit{Limit: limit, Remaining: remaining, Reset: time.Unix(reset, 0)}
+	if remaining == 0 && !c.rateLimit.Reset.After(time.Now()) {
+		c.rateLimit.Reset = time.Now().Add(retryAfter)
+	}
+}
+
+// backOff empties the bucket after a 429, whatever its headers said, so the retry
+// waits until the reported reset but at least Retry-After, or retryAfter without one
+func (c *Client) backOff(header http.Header) {
+	wait := retryAfter
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds > 0 {
+		wait = time.Duration(seconds) * time.Second
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.rateLimit.Remaining = 0
+	if reset := time.Now().Add(wait); c.rateLimit.Reset.Before(reset) {
+		c.rateLimit.Reset = reset
+	}
+}
+
+// refreshToken renews the access token unless another request already replaced stale
+func (c *Client) refreshToken(ctx context.Context, stale string) error {
+	c.refresh.Lock()
+	defer c.refresh.Unlock()
+
+	current := c.Token()
+	if current.AccessToken != stale {
+		return nil
+	}
+	if current.RefreshToken == "" {
+		return fmt.Errorf("%w: no refresh token", ErrTokenRefreshFailed)
+	}
+
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {current.RefreshToken},
+		"client_id":     {c.clientID},
+		"client_secret": {c.clientSecret},
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.AuthURL, "/")+"/token",
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := c.HTTPClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTokenRefreshFailed, err)
+	}
+	defer response.Body.Close()
+
+	var refreshed struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		Message      string `json:"message"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&refreshed); err != nil || response.StatusCode != http.StatusOK {
+		if refreshed.Message == "" {
+			refreshed.Message = response.Status
+		}
+		return fmt.Errorf("%w: %s", ErrTokenRefreshFailed, refreshed.Message)
+	}
+
+	token := Token{AccessToken: refreshed.AccessToken, RefreshToken: refreshed.RefreshToken}
+	if token.RefreshToken == "" {
+		token.RefreshToken = current.RefreshToken
+	}
+	if refreshed.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(refreshed.ExpiresIn) * time.Second)
+	}
+
+	c.mutex.Lock()
+	c.token = token
+	c.mutex.Unlock()
+	c.Logf("Refreshed the Twitch access token")
+	if c.OnTokenRefresh != nil {
+		c.OnTokenRefresh(token)
+	}
+	return nil
+}
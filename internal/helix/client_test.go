@@ -0,0 +1,225 @@
+package helix_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"twitch-rpg/internal/helix"
+	"twitch-rpg/internal/helix/helixtest"
+	"twitch-rpg/internal/models"
+)
+
+func newClient(t *testing.T, server *helixtest.Server) *helix.Client {
+	t.Helper()
+	client := helix.NewClient(helixtest.ClientID, helixtest.ClientSecret, server.Token())
+	client.BaseURL, client.AuthURL = server.URL, server.AuthURL
+	client.Logf = t.Logf
+	return client
+}
+
+func newServer(t *testing.T) *helixtest.Server {
+	t.Helper()
+	server := helixtest.NewServer()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRefreshesRejectedToken(t *testing.T) {
+	server := newServer(t)
+	client := newClient(t, server)
+	var refreshed []helix.Token
+	client.OnTokenRefresh = func(token helix.Token) { refreshed = append(refreshed, token) }
+
+	server.ExpireAccessToken()
+	if _, err := client.GetCustomRewards(context.Background(), helixtest.BroadcasterID, false); err != nil {
+		t.Fatalf("GetCustomRewards with an expired token: %v", err)
+	}
+	current := server.Token()
+	if token := client.Token(); token.AccessToken != current.AccessToken || token.RefreshToken != current.RefreshToken {
+		t.Fatalf("client token = %+v; want the server's %+v", token, current)
+	}
+	if len(refreshed) != 1 || refreshed[0].AccessToken != current.AccessToken || refreshed[0].ExpiresAt.IsZero() {
+		t.Fatalf("OnTokenRefresh calls = %+v; want one with the new token and its expiry", refreshed)
+	}
+
+	// A token nearing its expiry is refreshed before it is used
+	server.ExpireAccessToken()
+	stale := helix.NewClient(helixtest.ClientID, helixtest.ClientSecret, helix.Token{
+		AccessToken: "expiring", RefreshToken: current.RefreshToken, ExpiresAt: time.Now().Add(time.Second),
+	})
+	stale.BaseURL, stale.AuthURL, stale.Logf = server.URL, server.AuthURL, t.Logf
+	before := len(server.Requests())
+	if _, err := stale.GetCustomRewards(context.Background(), helixtest.BroadcasterID, false); err != nil {
+		t.Fatalf("GetCustomRewards with an expiring token: %v", err)
+	}
+	if requests := server.Requests()[before:]; len(requests) != 2 || requests[0].Path != "/token" {
+		t.Fatalf("requests = %+v; want a refresh, then the call", requests)
+	}
+}
+
+func TestFailedRefresh(t *testing.T) {
+	server := newServer(t)
+	client := helix.NewClient(helixtest.ClientID, "wrong-secret", server.Token())
+	client.BaseURL, client.AuthURL, client.Logf = server.URL, server.AuthURL, t.Logf
+
+	server.ExpireAccessToken()
+	_, err := client.GetCustomRewards(context.Background(), helixtest.BroadcasterID, false)
+	if !errors.Is(err, helix.ErrTokenRefreshFailed) {
+		t.Fatalf("GetCustomRewards = %v; want ErrTokenRefreshFailed", err)
+	}
+
+	// Without a refresh token the 401 is returned as is
+	client = helix.NewClient(helixtest.ClientID, helixtest.ClientSecret, helix.Token{AccessToken: "revoked"})
+	client.BaseURL, client.AuthURL, client.Logf = server.URL, server.AuthURL, t.Logf
+	var apiErr *helix.APIError
+	if _, err := client.GetCustomRewards(context.Background(), helixtest.BroadcasterID, false); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("GetCustomRewards without a refresh token = %v; want a 401 APIError", err)
+	}
+}
+
+func TestWaitsForEmptyBucket(t *testing.T) {
+	server := newServer(t)
+	server.SetRateLimit(1, time.Second)
+	client := newClient(t, server)
+
+	// The first client empties the bucket and waits itself; the second learns of it from a 429
+	other := newClient(t, server)
+	if _, err := client.GetCustomRewards(context.Background(), helixtest.BroadcasterID, false); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	if limit := client.RateLimit(); limit.Limit != 1 || limit.Remaining != 0 || !limit.Reset.After(time.Now()) {
+		t.Fatalf("RateLimit = %+v; want an empty bucket of 1 that resets later", limit)
+	}
+
+	start := time.Now()
+	if _, err := other.GetCustomRewards(context.Background(), helixtest.BroadcasterID, false); err != nil {
+		t.Fatalf("request after a 429: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Fatalf("retried after %s; want a wait for the bucket to refill", elapsed)
+	}
+	if requests := server.Requests(); len(requests) != 3 {
+		t.Fatalf("server saw %d requests; want the first, the rejected one and its retry", len(requests))
+	}
+
+	// A bucket the client knows is empty is waited for without sending
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := other.GetCustomRewards(ctx, helixtest.BroadcasterID, false); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("request on an empty bucket = %v; want the wait to hit the deadline", err)
+	}
+	if requests := server.Requests(); len(requests) != 3 {
+		t.Fatalf("server saw %d requests; want none sent on an empty bucket", len(requests))
+	}
+}
+
+func TestBacksOffAfterBare429(t *testing.T) {
+	var calls atomic.Int32
+	var saturated atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// No rate limit headers, as from a proxy in front of the API
+		if calls.Add(1) == 1 || saturated.Load() {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	client := helix.NewClient(helixtest.ClientID, helixtest.ClientSecret, helix.Token{AccessToken: "token"})
+	client.BaseURL, client.Logf = server.URL, t.Logf
+
+	start := time.Now()
+	if _, err := client.GetCustomRewards(context.Background(), helixtest.BroadcasterID, false); err != nil {
+		t.Fatalf("GetCustomRewards: %v", err)
+	}
+	if elapsed := time.Since(start); calls.Load() != 2 || elapsed < 900*time.Millisecond {
+		t.Fatalf("%d requests in %s; want one retry after backing off", calls.Load(), elapsed)
+	}
+
+	// Retries are bounded
+	saturated.Store(true)
+	client.MaxRetries = 0
+	var apiErr *helix.APIError
+	if _, err := client.GetCustomRewards(context.Background(), helixtest.BroadcasterID, false); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("GetCustomRewards without retries = %v; want a 429 APIError", err)
+	}
+}
+
+func TestCustomRewards(t *testing.T) {
+	server := newServer(t)
+	client := newClient(t, server)
+	ctx := context.Background()
+	dashboard := server.AddReward(helix.CustomReward{Title: "Hydrate", Cost: 100}, false)
+
+	prompt := "Pick a stat"
+	inputRequired := true
+	created, err := client.CreateCustomReward(ctx, helixtest.BroadcasterID, helix.CustomRewardSettings{
+		Title: "Train", Cost: 500, Prompt: &prompt, IsUserInputRequired: &inputRequired,
+	})
+	if err != nil || created.ID == "" || created.Cost != 500 || created.Prompt != prompt || !created.IsUserInputRequired {
+		t.Fatalf("CreateCustomReward = %+v, %v", created, err)
+	}
+
+	manageable, err := client.GetCustomRewards(ctx, helixtest.BroadcasterID, true)
+	if err != nil || len(manageable) != 1 || manageable[0].ID != created.ID {
+		t.Fatalf("GetCustomRewards(manageable) = %+v, %v; want only the created reward", manageable, err)
+	}
+	all, err := client.GetCustomRewards(ctx, helixtest.BroadcasterID, false, dashboard.ID)
+	if err != nil || len(all) != 1 || all[0].ID != dashboard.ID {
+		t.Fatalf("GetCustomRewards(id) = %+v, %v; want the dashboard reward", all, err)
+	}
+
+	updated, err := client.UpdateCustomReward(ctx, helixtest.BroadcasterID, created.ID, helix.CustomRewardSettings{Cost: 750})
+	if err != nil || updated.Cost != 750 || updated.Title != "Train" || updated.Prompt != prompt {
+		t.Fatalf("UpdateCustomReward = %+v, %v; want only the cost changed", updated, err)
+	}
+
+	var apiErr *helix.APIError
+	_, err = client.UpdateCustomReward(ctx, helixtest.BroadcasterID, dashboard.ID, helix.CustomRewardSettings{Cost: 1})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("UpdateCustomReward(dashboard reward) = %v; want a 403 APIError", err)
+	}
+	_, err = client.CreateCustomReward(ctx, helixtest.BroadcasterID, helix.CustomRewardSettings{Title: "train", Cost: 1})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("CreateCustomReward(duplicate title) = %v; want a 400 APIError", err)
+	}
+}
+
+func TestUpdateRedemptionStatus(t *testing.T) {
+	server := newServer(t)
+	client := newClient(t, server)
+	ctx := context.Background()
+	reward := server.AddReward(helix.CustomReward{Title: "Train", Cost: 500}, true)
+	dashboard := server.AddReward(helix.CustomReward{Title: "Hydrate", Cost: 100}, false)
+
+	redemptions, err := client.UpdateRedemptionStatus(ctx, helixtest.BroadcasterID, reward.ID, []string{"r-1", "r-2"}, models.RedemptionFulfilled)
+	if err != nil || len(redemptions) != 2 || redemptions[0].Status != models.RedemptionFulfilled || redemptions[0].Reward.ID != reward.ID {
+		t.Fatalf("UpdateRedemptionStatus = %+v, %v", redemptions, err)
+	}
+	if _, err := client.UpdateRedemptionStatus(ctx, helixtest.BroadcasterID, reward.ID, []string{"r-3"}, models.RedemptionCanceled); err != nil {
+		t.Fatalf("UpdateRedemptionStatus(cancel): %v", err)
+	}
+	for id, want := range map[string]string{"r-1": models.RedemptionFulfilled, "r-3": models.RedemptionCanceled, "r-4": models.RedemptionUnfulfilled} {
+		if status := server.RedemptionStatus(id); status != want {
+			t.Fatalf("redemption %s is %s; want %s", id, status, want)
+		}
+	}
+
+	var apiErr *helix.APIError
+	_, err = client.UpdateRedemptionStatus(ctx, helixtest.BroadcasterID, reward.ID, []string{"r-1"}, models.RedemptionCanceled)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("UpdateRedemptionStatus(already fulfilled) = %v; want a 404 APIError", err)
+	}
+	_, err = client.UpdateRedemptionStatus(ctx, helixtest.BroadcasterID, dashboard.ID, []string{"r-5"}, models.RedemptionFulfilled)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("UpdateRedemptionStatus(dashboard reward) = %v; want a 403 APIError", err)
+	}
+	if status := server.RedemptionStatus("r-5"); status != models.RedemptionUnfulfilled {
+		t.Fatalf("refused redemption is %s; want it unfulfilled", status)
+	}
+}
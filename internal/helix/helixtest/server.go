@@ -0,0 +1,431 @@
+// Package helixtest provides a local stand-in for the Twitch Helix API and OAuth token
+// endpoint, so the Helix client and the reward and redemption handling built on it can
+// be exercised without a Twitch application.
+//
+// The server checks the Client-Id header and bearer token of every request, refreshes
+// tokens with the refresh token, keeps custom rewards and redemption statuses in
+// memory and enforces a rate limit bucket with Twitch's Ratelimit-* headers. Rewards
+// created through the API are manageable by the client; rewards seeded with AddReward
+// can be made unmanageable, like rewards created on the Twitch dashboard. Redemptions
+// the server has not seen are assumed to be unfulfilled.
+package helixtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"twitch-rpg/internal/helix"
+	"twitch-rpg/internal/models"
+)
+
+// Credentials the server accepts by default
+const (
+	ClientID      = "helixtest-client"
+	ClientSecret  = "helixtest-secret"
+	BroadcasterID = "1337"
+)
+
+// tokenLifetime is the expires_in of refreshed tokens
+const tokenLifetime = 4 * time.Hour
+
+// Request is a request the server received
+type Request struct {
+	Method string
+	Path   string // relative to the Helix or OAuth base URL, e.g. /channel_points/custom_rewards
+	Query  url.Values
+	Body   json.RawMessage
+}
+
+type reward struct {
+	helix.CustomReward
+	manageable bool
+}
+
+// Server is a fake Helix API
+type Server struct {
+	// URL is the Helix base URL and AuthURL the OAuth base URL; both are empty for a
+	// server made with New until it is served somewhere
+	URL     string
+	AuthURL string
+
+	server *httptest.Server
+
+	mutex        sync.Mutex
+	accessToken  string
+	refreshToken string
+	tokens       int
+	rewards      []*reward
+	statuses     map[string]string // redemption ID to status
+	requests     []Request
+	limit        int
+	remaining    int
+	window       time.Duration
+	reset        time.Time
+	rewardIDs    int
+}
+
+// New creates a server that is not listening; serve it with ServeHTTP and set the URLs
+func New() *Server {
+	return &Server{
+		accessToken:  "helixtest-access-0",
+		refreshToken: "helixtest-refresh-0",
+		statuses:     make(map[string]string),
+		limit:        800,
+		remaining:    800,
+		window:       time.Minute,
+	}
+}
+
+// NewServer starts a server on a local port
+func NewServer() *Server {
+	s := New()
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL + "/helix"
+	s.AuthURL = s.server.URL + "/oauth2"
+	return s
+}
+
+// Close stops a server started with NewServer
+func (s *Server) Close() {
+	if s.server != nil {
+		s.server.Close()
+	}
+}
+
+// Token returns the access and refresh token the server currently accepts
+func (s *Server) Token() helix.Token {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return helix.Token{AccessToken: s.accessToken, RefreshToken: s.refreshToken}
+}
+
+// ExpireAccessToken makes the server reject the current access token until it is refreshed
+func (s *Server) ExpireAccessToken() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.accessToken = fmt.Sprintf("helixtest-expired-%d", s.tokens)
+}
+
+// SetRateLimit replaces the rate limit bucket with one of limit points refilling every window
+func (s *Server) SetRateLimit(limit int, window time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.limit, s.remaining, s.window, s.reset = limit, limit, window, time.Time{}
+}
+
+// AddReward seeds a reward, assigning an ID when it has none, and returns it
+func (s *Server) AddReward(customReward helix.CustomReward, manageable bool) helix.CustomReward {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if customReward.ID == "" {
+		customReward.ID = s.nextRewardID()
+	}
+	customReward.BroadcasterID = BroadcasterID
+	s.rewards = append(s.rewards, &reward{CustomReward: customReward, manageable: manageable})
+	return customReward
+}
+
+// Rewards returns the rewards on the channel
+func (s *Server) Rewards() []helix.CustomReward {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	rewards := make([]helix.CustomReward, len(s.rewards))
+	for i, r := range s.rewards {
+		rewards[i] = r.CustomReward
+	}
+	return rewards
+}
+
+// RedemptionStatus returns the status a redemption was given, UNFULFILLED when none
+func (s *Server) RedemptionStatus(redemptionID string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if status, ok := s.statuses[redemptionID]; ok {
+		return status
+	}
+	return models.RedemptionUnfulfilled
+}
+
+// Requests returns the requests received so far
+func (s *Server) Requests() []Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ServeHTTP answers the OAuth token endpoint under /oauth2 and the API under /helix
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/oauth2/token":
+		s.record(r, "/token", body)
+		s.serveToken(w, r, body)
+	case strings.HasPrefix(r.URL.Path, "/helix/"):
+		path := strings.TrimPrefix(r.URL.Path, "/helix")
+		s.record(r, path, body)
+		if !s.takeRateLimit(w) {
+			writeError(w, http.StatusTooManyRequests, "")
+			return
+		}
+		if r.Header.Get("Client-Id") != ClientID || r.Header.Get("Authorization") != "Bearer "+s.accessToken {
+			writeError(w, http.StatusUnauthorized, "Invalid OAuth token")
+			return
+		}
+		s.serveAPI(w, r, path, body)
+	default:
+		writeError(w, http.StatusNotFound, "")
+	}
+}
+
+func (s *Server) record(r *http.Request, path string, body []byte) {
+	request := Request{Method: r.Method, Path: path, Query: r.URL.Query()}
+	if json.Valid(body) {
+		request.Body = json.RawMessage(body)
+	}
+	s.requests = append(s.requests, request)
+}
+
+// takeRateLimit spends a point of the bucket and sets the rate limit headers, reporting
+// whether a point was left
+func (s *Server) takeRateLimit(w http.ResponseWriter) bool {
+	now := time.Now()
+	if !now.Before(s.reset) {
+		s.remaining, s.reset = s.limit, now.Add(s.window)
+	}
+	allowed := s.remaining > 0
+	if allowed {
+		s.remaining--
+	}
+	w.Header().Set(helix.HeaderRateLimitLimit, strconv.Itoa(s.limit))
+	w.Header().Set(helix.HeaderRateLimitRemaining, strconv.Itoa(s.remaining))
+	// Twitch reports whole seconds; round up so clients never retry before the refill
+	w.Header().Set(helix.HeaderRateLimitReset, strconv.FormatInt(s.reset.Add(time.Second-1).Unix(), 10))
+	return allowed
+}
+
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request, body []byte) {
+	form, _ := url.ParseQuery(string(body))
+	switch {
+	case form.Get("grant_type") != "refresh_token":
+		writeError(w, http.StatusBadRequest, "unsupported grant type")
+	case form.Get("client_id") != ClientID || form.Get("client_secret") != ClientSecret:
+		writeError(w, http.StatusForbidden, "invalid client secret")
+	case form.Get("refresh_token") != s.refreshToken:
+		writeError(w, http.StatusBadRequest, "Invalid refresh token")
+	default:
+		s.tokens++
+		s.accessToken = fmt.Sprintf("helixtest-access-%d", s.tokens)
+		s.refreshToken = fmt.Sprintf("helixtest-refresh-%d", s.tokens)
+		writeJSON(w, http.StatusOK, map[string]any{
+			"access_token":  s.accessToken,
+			"refresh_token": s.refreshToken,
+			"expires_in":    int(tokenLifetime.Seconds()),
+			"token_type":    "bearer",
+		})
+	}
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request, path string, body []byte) {
+	query := r.URL.Query()
+	if query.Get("broadcaster_id") != BroadcasterID {
+		writeError(w, http.StatusForbidden, "The ID in broadcaster_id must match the user ID found in the request's OAuth token.")
+		return
+	}
+
+	switch {
+	case path == "/channel_points/custom_rewards" && r.Method == http.MethodGet:
+		s.listRewards(w, query)
+	case path == "/channel_points/custom_rewards" && r.Method == http.MethodPost:
+		s.createReward(w, body)
+	case path == "/channel_points/custom_rewards" && r.Method == http.MethodPatch:
+		s.updateReward(w, query.Get("id"), body)
+	case path == "/channel_points/custom_rewards/redemptions" && r.Method == http.MethodPatch:
+		s.updateRedemptions(w, query, body)
+	default:
+		writeError(w, http.StatusNotFound, "")
+	}
+}
+
+func (s *Server) listRewards(w http.ResponseWriter, query url.Values) {
+	ids := query["id"]
+	rewards := []helix.CustomReward{}
+	for _, r := range s.rewards {
+		if query.Get("only_manageable_rewards") == "true" && !r.manageable {
+			continue
+		}
+		if len(ids) > 0 && !slices.Contains(ids, r.ID) {
+			continue
+		}
+		rewards = append(rewards, r.CustomReward)
+	}
+	writeData(w, http.StatusOK, rewards)
+}
+
+func (s *Server) createReward(w http.ResponseWriter, body []byte) {
+	settings := helix.CustomRewardSettings{}
+	if err := json.Unmarshal(body, &settings); err != nil {
+		writeError(w, http.StatusBadRequest, "Malformed request body")
+		return
+	}
+	if settings.Title == "" || settings.Cost < 1 {
+		writeError(w, http.StatusBadRequest, "title and cost are required")
+		return
+	}
+	if s.titleTaken(settings.Title, "") {
+		writeError(w, http.StatusBadRequest, "CREATE_CUSTOM_REWARD_DUPLICATE_REWARD")
+		return
+	}
+
+	r := &reward{manageable: true}
+	r.ID = s.nextRewardID()
+	r.BroadcasterID = BroadcasterID
+	r.IsEnabled = true
+	apply(&r.CustomReward, settings)
+	s.rewards = append(s.rewards, r)
+	writeData(w, http.StatusOK, []helix.CustomReward{r.CustomReward})
+}
+
+func (s *Server) updateReward(w http.ResponseWriter, id string, body []byte) {
+	settings := helix.CustomRewardSettings{}
+	if err := json.Unmarshal(body, &settings); err != nil {
+		writeError(w, http.StatusBadRequest, "Malformed request body")
+		return
+	}
+	r, status := s.manageableReward(id)
+	if r == nil {
+		writeError(w, status, rewardRefusal(status))
+		return
+	}
+	if settings.Title != "" && s.titleTaken(settings.Title, id) {
+		writeError(w, http.StatusBadRequest, "UPDATE_CUSTOM_REWARD_DUPLICATE_REWARD")
+		return
+	}
+	apply(&r.CustomReward, settings)
+	writeData(w, http.StatusOK, []helix.CustomReward{r.CustomReward})
+}
+
+func (s *Server) updateRedemptions(w http.ResponseWriter, query url.Values, body []byte) {
+	var update struct {
+		Status string `json:"status"`
+	}
+	ids := query["id"]
+	if err := json.Unmarshal(body, &update); err != nil ||
+		(update.Status != models.RedemptionFulfilled && update.Status != models.RedemptionCanceled) {
+		writeError(w, http.StatusBadRequest, "status must be FULFILLED or CANCELED")
+		return
+	}
+	if len(ids) == 0 || len(ids) > 50 {
+		writeError(w, http.StatusBadRequest, "between 1 and 50 redemption IDs are required")
+		return
+	}
+	r, status := s.manageableReward(query.Get("reward_id"))
+	if r == nil {
+		writeError(w, status, rewardRefusal(status))
+		return
+	}
+
+	redemptions := []models.ChannelPointRedemption{}
+	for _, id := range ids {
+		if current, ok := s.statuses[id]; ok && current != models.RedemptionUnfulfilled {
+			continue // only unfulfilled redemptions can change status
+		}
+		s.statuses[id] = update.Status
+		redemptions = append(redemptions, models.ChannelPointRedemption{
+			ID:                id,
+			BroadcasterUserID: BroadcasterID,
+			Status:            update.Status,
+			Reward: models.ChannelPointReward{
+				ID: r.ID, Title: r.Title, Cost: r.Cost, Prompt: r.Prompt,
+			},
+		})
+	}
+	if len(redemptions) == 0 {
+		writeError(w, http.StatusNotFound, "No unfulfilled redemptions found")
+		return
+	}
+	writeData(w, http.StatusOK, redemptions)
+}
+
+// manageableReward finds a reward the client may change, or the status refusing it
+func (s *Server) manageableReward(id string) (*reward, int) {
+	for _, r := range s.rewards {
+		if r.ID == id && id != "" {
+			if !r.manageable {
+				return nil, http.StatusForbidden
+			}
+			return r, http.StatusOK
+		}
+	}
+	return nil, http.StatusNotFound
+}
+
+// rewardRefusal words why manageableReward refused a reward
+func rewardRefusal(status int) string {
+	if status == http.StatusForbidden {
+		return "The ID in the Client-Id header must match the client ID used to create the custom reward."
+	}
+	return "The custom reward was not found."
+}
+
+func (s *Server) titleTaken(title, exceptID string) bool {
+	for _, r := range s.rewards {
+		if r.ID != exceptID && strings.EqualFold(r.Title, title) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) nextRewardID() string {
+	s.rewardIDs++
+	return fmt.Sprintf("helixtest-reward-%d", s.rewardIDs)
+}
+
+// apply copies the settings that are set onto a reward
+func apply(customReward *helix.CustomReward, settings helix.CustomRewardSettings) {
+	if settings.Title != "" {
+		customReward.Title = settings.Title
+	}
+	if settings.Cost > 0 {
+		customReward.Cost = settings.Cost
+	}
+	if settings.Prompt != nil {
+		customReward.Prompt = *settings.Prompt
+	}
+	if settings.IsEnabled != nil {
+		customReward.IsEnabled = *settings.IsEnabled
+	}
+	if settings.IsUserInputRequired != nil {
+		customReward.IsUserInputRequired = *settings.IsUserInputRequired
+	}
+	if settings.ShouldRedemptionsSkipRequestQueue != nil {
+		customReward.ShouldRedemptionsSkipRequestQueue = *settings.ShouldRedemptionsSkipRequestQueue
+	}
+}
+
+func writeData(w http.ResponseWriter, status int, data any) {
+	writeJSON(w, status, map[string]any{"data": data})
+}
+
+// writeError answers like Twitch does, with the status text as error
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, helix.APIError{StatusCode: status, ErrorText: http.StatusText(status), Message: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,88 @@
+package helix
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"twitch-rpg/internal/models"
+)
+
+// CustomReward is a custom channel point reward as Helix describes it
+type CustomReward struct {
+	ID                                string `json:"id"`
+	BroadcasterID                     string `json:"broadcaster_id"`
+	Title                             string `json:"title"`
+	Prompt                            string `json:"prompt"`
+	Cost                              int    `json:"cost"`
+	IsEnabled                         bool   `json:"is_enabled"`
+	IsPaused                          bool   `json:"is_paused"`
+	IsUserInputRequired               bool   `json:"is_user_input_required"`
+	ShouldRedemptionsSkipRequestQueue bool   `json:"should_redemptions_skip_request_queue"`
+}
+
+// CustomRewardSettings are the fields to set when creating or updating a reward. Nil
+// and zero fields are left unchanged on updates.
+type CustomRewardSettings struct {
+	Title                             string  `json:"title,omitempty"`
+	Cost                              int     `json:"cost,omitempty"`
+	Prompt                            *string `json:"prompt,omitempty"`
+	IsEnabled                         *bool   `json:"is_enabled,omitempty"`
+	IsUserInputRequired               *bool   `json:"is_user_input_required,omitempty"`
+	ShouldRedemptionsSkipRequestQueue *bool   `json:"should_redemptions_skip_request_queue,omitempty"`
+}
+
+// GetCustomRewards lists the broadcaster's custom rewards, only those this
+// application created and may manage when onlyManageable is set, and only the given
+// IDs when there are any
+func (c *Client) GetCustomRewards(ctx context.Context, broadcasterID string, onlyManageable bool, ids ...string) ([]CustomReward, error) {
+	query := url.Values{"broadcaster_id": {broadcasterID}}
+	if onlyManageable {
+		query.Set("only_manageable_rewards", "true")
+	}
+	for _, id := range ids {
+		query.Add("id", id)
+	}
+
+	rewards := []CustomReward{}
+	if err := c.do(ctx, http.MethodGet, "/channel_points/custom_rewards", query, nil, &rewards); err != nil {
+		return nil, err
+	}
+	return rewards, nil
+}
+
+// CreateCustomReward creates a custom reward, which this application may then manage
+func (c *Client) CreateCustomReward(ctx context.Context, broadcasterID string, settings CustomRewardSettings) (*CustomReward, error) {
+	return c.oneReward(ctx, http.MethodPost, url.Values{"broadcaster_id": {broadcasterID}}, settings)
+}
+
+// UpdateCustomReward changes a custom reward this application created
+func (c *Client) UpdateCustomReward(ctx context.Context, broadcasterID, rewardID string, settings CustomRewardSettings) (*CustomReward, error) {
+	return c.oneReward(ctx, http.MethodPatch, url.Values{"broadcaster_id": {broadcasterID}, "id": {rewardID}}, settings)
+}
+
+func (c *Client) oneReward(ctx context.Context, method string, query url.Values, settings CustomRewardSettings) (*CustomReward, error) {
+	rewards := []CustomReward{}
+	if err := c.do(ctx, method, "/channel_points/custom_rewards", query, settings, &rewards); err != nil {
+		return nil, err
+	}
+	if len(rewards) == 0 {
+		return nil, &APIError{StatusCode: http.StatusNotFound, ErrorText: "Not Found", Message: "no reward in response"}
+	}
+	return &rewards[0], nil
+}
+
+// UpdateRedemptionStatus marks unfulfilled redemptions of a reward this application
+// created as models.RedemptionFulfilled or models.RedemptionCanceled. Canceling
+// refunds the viewer's channel points.
+func (c *Client) UpdateRedemptionStatus(ctx context.Context, broadcasterID, rewardID string, redemptionIDs []string, status string) ([]models.ChannelPointRedemption, error) {
+	query := url.Values{"broadcaster_id": {broadcasterID}, "reward_id": {rewardID}, "id": redemptionIDs}
+	body := struct {
+		Status string `json:"status"`
+	}{Status: status}
+
+	redemptions := []models.ChannelPointRedemption{}
+	if err := c.do(ctx, http.MethodPatch, "/channel_points/custom_rewards/redemptions", query, body, &redemptions); err != nil {
+		return nil, err
+	}
+	return redemptions, nil
+}
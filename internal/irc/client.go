@@ -0,0 +1,434 @@
+// Package irc is a client for Twitch chat over IRC.
+//
+// A Client keeps a connection to the chat server open, registering with the bot's
+// OAuth token, requesting the IRCv3 tags and commands capabilities and joining the
+// configured channels, and reconnects with backoff whenever the connection drops or
+// Twitch asks it to. Outgoing chat messages are queued and sent no faster than the
+// configured rate limit, since Twitch drops bots that exceed it.
+package irc
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// connectTimeout bounds dialing the server and completing registration
+	connectTimeout = 15 * time.Second
+	// readTimeout is how long the connection may stay silent. Twitch sends a PING about
+	// every five minutes, so a longer silence means the connection is dead.
+	readTimeout = 6 * time.Minute
+	// defaultMinBackoff and defaultMaxBackoff bound the delay between reconnect attempts
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = time.Minute
+	// DefaultRateLimit messages may be sent per DefaultRateWindow, Twitch's limit for
+	// accounts that are not moderators of the channel
+	DefaultRateLimit  = 20
+	DefaultRateWindow = 30 * time.Second
+	// MaxMessageLength is the longest chat message Twitch accepts, in characters
+	MaxMessageLength = 500
+	// queueSize bounds the outgoing messages waiting for the rate limit
+	queueSize = 100
+)
+
+// Capabilities are the IRCv3 capabilities the client requests: tags carry user IDs,
+// display names and badges, and commands adds Twitch's RECONNECT and NOTICE messages
+const Capabilities = "twitch.tv/tags twitch.tv/commands"
+
+// ErrAuthenticationFailed is returned when Twitch rejects the bot's token
+var ErrAuthenticationFailed = errors.New("irc: authentication failed")
+
+// ErrQueueFull is returned by Say and Reply when too many messages wait for the rate limit
+var ErrQueueFull = errors.New("irc: outgoing queue is full")
+
+// errReconnect ends a connection Twitch asked the client to leave
+var errReconnect = errors.New("irc: server requested a reconnect")
+
+// Client is a reconnecting Twitch chat client
+type Client struct {
+	address  string
+	useTLS   bool
+	nick     string
+	password string
+	channels []string
+
+	// Handler is called for every message received after registration, including
+	// PRIVMSG, NOTICE and USERNOTICE, on the client's read loop
+	Handler func(*Message)
+	// Logf logs connection changes; it defaults to log.Printf
+	Logf func(format string, args ...any)
+	// MinBackoff and MaxBackoff bound the delay between reconnect attempts, which
+	// doubles after every failed attempt
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// RateLimit messages are sent per RateWindow at most
+	RateLimit  int
+	RateWindow time.Duration
+
+	mutex    sync.Mutex
+	conn     net.Conn
+	ready    chan struct{} // closed while connected
+	outgoing chan *Message
+}
+
+// NewClient creates a client for the chat server at rawURL, ircs://host:port for TLS or
+// irc://host:port for plain text, logging in as nick with password, an OAuth token with
+// or without the "oauth:" prefix. Call Run to connect.
+func NewClient(rawURL, nick, password string, channels ...string) (*Client, error) {
+	address, useTLS, err := parseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if password != "" && !strings.HasPrefix(password, "oauth:") {
+		password = "oauth:" + password
+	}
+
+	joined := []string{}
+	for _, channel := range channels {
+		if channel = NormalizeChannel(channel); channel != "#" {
+			joined = append(joined, channel)
+		}
+	}
+
+	return &Client{
+		address:    address,
+		useTLS:     useTLS,
+		nick:       strings.ToLower(nick),
+		password:   password,
+		channels:   joined,
+		Logf:       log.Printf,
+		MinBackoff: defaultMinBackoff,
+		MaxBackoff: defaultMaxBackoff,
+		RateLimit:  DefaultRateLimit,
+		RateWindow: DefaultRateWindow,
+		ready:      make(chan struct{}),
+		outgoing:   make(chan *Message, queueSize),
+	}, nil
+}
+
+func parseURL(rawURL string) (string, bool, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return "", false, fmt.Errorf("irc: invalid server URL %q", rawURL)
+	}
+
+	var useTLS bool
+	var port string
+	switch parsed.Scheme {
+	case "ircs":
+		useTLS, port = true, "6697"
+	case "irc":
+		port = "6667"
+	default:
+		return "", false, fmt.Errorf("irc: unsupported URL scheme %q, use ircs or irc", parsed.Scheme)
+	}
+	if parsed.Port() != "" {
+		port = parsed.Port()
+	}
+	return net.JoinHostPort(parsed.Hostname(), port), useTLS, nil
+}
+
+// NormalizeChannel returns a channel name in the form IRC uses, "#" and the lower case login
+func NormalizeChannel(channel string) string {
+	return "#" + strings.ToLower(strings.TrimPrefix(strings.TrimSpace(channel), "#"))
+}
+
+// Run connects to the chat server and keeps reconnecting until ctx is done
+func (c *Client) Run(ctx context.Context) {
+	go c.sendQueued(ctx)
+
+	backoff := c.MinBackoff
+	for {
+		conn, reader, err := c.connect(ctx)
+		if err == nil {
+			c.Logf("Connected to Twitch chat at %s as %s", c.address, c.nick)
+			backoff = c.MinBackoff
+			err = c.serve(ctx, conn, reader)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		delay := backoff
+		if errors.Is(err, errReconnect) {
+			delay = 0
+		} else {
+			backoff = min(backoff*2, c.MaxBackoff)
+		}
+		c.Logf("Twitch chat connection to %s failed: %v; retrying in %s", c.address, err, delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Connected reports whether the client is registered and joined
+func (c *Client) Connected() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.conn != nil
+}
+
+// WaitConnected blocks until the client is connected or ctx is done
+func (c *Client) WaitConnected(ctx context.Context) error {
+	c.mutex.Lock()
+	ready := c.ready
+	c.mutex.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Say queues a chat message to a channel. Messages wait for the rate limit and for a
+// connection; line breaks become spaces and text longer than MaxMessageLength is cut.
+func (c *Client) Say(channel, text string) error {
+	return c.enqueue(&Message{Command: "PRIVMSG", Params: []string{NormalizeChannel(channel), sanitize(text)}})
+}
+
+// Reply queues a chat message answering msg, threaded under it where Twitch supports that
+func (c *Client) Reply(msg *Message, text string) error {
+	reply := &Message{Command: "PRIVMSG", Params: []string{msg.Param(0), sanitize(text)}}
+	if id := msg.Tags["id"]; id != "" {
+		reply.Tags = map[string]string{"reply-parent-msg-id": id}
+	}
+	return c.enqueue(reply)
+}
+
+func (c *Client) enqueue(msg *Message) error {
+	select {
+	case c.outgoing <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// sanitize makes text a single chat line of at most MaxMessageLength characters
+func sanitize(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= MaxMessageLength {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:MaxMessageLength-1]) + "…"
+}
+
+// sendQueued writes queued messages as the rate limit allows, waiting for a connection
+// when there is none
+func (c *Client) sendQueued(ctx context.Context) {
+	limiter := newRateLimiter(c.RateLimit, c.RateWindow)
+	for {
+		var msg *Message
+		select {
+		case <-ctx.Done():
+			return
+		case msg = <-c.outgoing:
+		}
+
+		for {
+			if err := c.WaitConnected(ctx); err != nil {
+				return
+			}
+			if err := limiter.wait(ctx); err != nil {
+				return
+			}
+			err := c.write(msg)
+			if err == nil {
+				break
+			}
+			c.Logf("Failed to send chat message to %s: %v", msg.Param(0), err)
+		}
+	}
+}
+
+// write sends one message on the current connection
+func (c *Client) write(msg *Message) error {
+	c.mutex.Lock()
+	conn := c.conn
+	c.mutex.Unlock()
+	if conn == nil {
+		return errors.New("irc: not connected")
+	}
+	return writeMessage(conn, msg)
+}
+
+func writeMessage(conn net.Conn, msg *Message) error {
+	conn.SetWriteDeadline(time.Now().Add(connectTimeout))
+	_, err := conn.Write([]byte(msg.String() + "\r\n"))
+	return err
+}
+
+// connect dials the server, registers and joins the channels
+func (c *Client) connect(ctx context.Context) (net.Conn, *bufio.Reader, error) {
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+
+	var conn net.Conn
+	var err error
+	if c.useTLS {
+		host, _, _ := net.SplitHostPort(c.address)
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: host}}
+		conn, err = dialer.DialContext(ctx, "tcp", c.address)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", c.address)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetReadDeadline(deadline)
+
+	reader := bufio.NewReader(conn)
+	if err := c.register(conn, reader); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	c.mutex.Lock()
+	c.conn = conn
+	close(c.ready)
+	c.mutex.Unlock()
+	return conn, reader, nil
+}
+
+// register logs in and waits for the welcome reply before joining the channels
+func (c *Client) register(conn net.Conn, reader *bufio.Reader) error {
+	registration := []*Message{
+		{Command: "CAP", Params: []string{"REQ", Capabilities}},
+	}
+	if c.password != "" {
+		registration = append(registration, &Message{Command: "PASS", Params: []string{c.password}})
+	}
+	registration = append(registration, &Message{Command: "NICK", Params: []string{c.nick}})
+	for _, msg := range registration {
+		if err := writeMessage(conn, msg); err != nil {
+			return err
+		}
+	}
+
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			return err
+		}
+		switch msg.Command {
+		case "001": // RPL_WELCOME
+			if len(c.channels) > 0 {
+				return writeMessage(conn, &Message{Command: "JOIN", Params: []string{strings.Join(c.channels, ",")}})
+			}
+			return nil
+		case "PING":
+			if err := writeMessage(conn, &Message{Command: "PONG", Params: msg.Params}); err != nil {
+				return err
+			}
+		case "NOTICE":
+			// "Login authentication failed" or "Improperly formatted auth"; Twitch closes the connection next
+			if text := msg.Trailing(); strings.Contains(strings.ToLower(text), "auth") {
+				return fmt.Errorf("%w: %s", ErrAuthenticationFailed, text)
+			}
+		case "CAP":
+			if msg.Param(1) == "NAK" {
+				c.Logf("Twitch chat refused capabilities %q", msg.Trailing())
+			}
+		}
+	}
+}
+
+// serve answers PINGs and hands messages to the handler until the connection fails
+func (c *Client) serve(ctx context.Context, conn net.Conn, reader *bufio.Reader) error {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var err error
+	for {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		var msg *Message
+		if msg, err = readMessage(reader); err != nil {
+			break
+		}
+
+		switch msg.Command {
+		case "PING":
+			err = writeMessage(conn, &Message{Command: "PONG", Params: msg.Params})
+		case "RECONNECT":
+			err = errReconnect
+		default:
+			if c.Handler != nil {
+				c.Handler(msg)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	conn.Close()
+	c.mutex.Lock()
+	c.conn = nil
+	c.ready = make(chan struct{})
+	c.mutex.Unlock()
+	return err
+}
+
+// readMessage reads the next line that parses as a message, skipping blank and malformed ones
+func readMessage(reader *bufio.Reader) (*Message, error) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if msg, err := ParseMessage(line); err == nil {
+			return msg, nil
+		}
+	}
+}
+
+// rateLimiter allows limit events in any sliding window of the given length
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+	sent   []time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: max(limit, 1), window: window}
+}
+
+// wait blocks until another event is allowed and records it
+func (r *rateLimiter) wait(ctx context.Context) error {
+	now := time.Now()
+	for len(r.sent) > 0 && now.Sub(r.sent[0]) >= r.window {
+		r.sent = r.sent[1:]
+	}
+	if len(r.sent) >= r.limit {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.sent[0].Add(r.window).Sub(now)):
+		}
+		r.sent = r.sent[1:]
+	}
+	r.sent = append(r.sent, time.Now())
+	return nil
+}
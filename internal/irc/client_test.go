@@ -0,0 +1,319 @@
+package irc_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"twitch-rpg/internal/irc"
+	"twitch-rpg/internal/irc/irctest"
+)
+
+// logRecorder collects a client's log lines
+type logRecorder struct {
+	mutex sync.Mutex
+	lines []string
+}
+
+func (l *logRecorder) logf(format string, args ...any) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func (l *logRecorder) contains(text string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, line := range l.lines {
+		if strings.Contains(line, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// run runs a client until the test ends
+func run(t *testing.T, client *irc.Client) {
+	t.Helper()
+	client.MinBackoff, client.MaxBackoff = 10*time.Millisecond, 50*time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		client.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func connect(t *testing.T, server *irctest.Server, password string, channels ...string) *irc.Client {
+	t.Helper()
+	client, err := irc.NewClient(server.URL, "GameBot", password, channels...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	client.Logf = t.Logf
+	run(t, client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.WaitConnected(ctx); err != nil {
+		t.Fatalf("WaitConnected: %v", err)
+	}
+	return client
+}
+
+func TestRegistration(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer listener.Close()
+
+	client, err := irc.NewClient("irc://"+listener.Addr().String(), "GameBot", "secret-token", "Cool_Streamer", "#other")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	client.Logf = t.Logf
+	run(t, client)
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+	expect := func(want string) {
+		t.Helper()
+		line, err := reader.ReadString('\n')
+		if err != nil || line != want+"\r\n" {
+			t.Fatalf("client sent %q, %v; want %q", line, err, want)
+		}
+	}
+
+	// Capabilities are requested first, then the token is sent before the nick
+	expect("CAP REQ :twitch.tv/tags twitch.tv/commands")
+	expect("PASS oauth:secret-token")
+	expect("NICK gamebot")
+
+	// PINGs during registration are answered, and the client waits for the welcome
+	fmt.Fprint(conn, ":tmi.twitch.tv CAP * ACK :twitch.tv/tags twitch.tv/commands\r\n")
+	fmt.Fprint(conn, "PING :tmi.twitch.tv\r\n")
+	expect("PONG tmi.twitch.tv")
+	if client.Connected() {
+		t.Fatalf("client reports a connection before the welcome")
+	}
+	fmt.Fprint(conn, ":tmi.twitch.tv 001 gamebot :Welcome, GLHF!\r\n")
+	expect("JOIN #cool_streamer,#other")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.WaitConnected(ctx); err != nil {
+		t.Fatalf("WaitConnected: %v", err)
+	}
+}
+
+func TestAuthenticationFailure(t *testing.T) {
+	server := irctest.NewServer("right-token")
+	defer server.Close()
+
+	client, err := irc.NewClient(server.URL, "GameBot", "wrong-token", "#c")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	logs := &logRecorder{}
+	client.Logf = logs.logf
+	run(t, client)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !logs.contains(irc.ErrAuthenticationFailed.Error()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("no authentication failure was logged: %v", logs.lines)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if client.Connected() || server.Registered() != 0 {
+		t.Fatalf("Connected = %v, registered = %d; want the client rejected", client.Connected(), server.Registered())
+	}
+
+	if _, err := irc.NewClient("https://irc.chat.twitch.tv", "bot", "token"); err == nil {
+		t.Fatalf("NewClient accepted an https URL")
+	}
+}
+
+func TestPingAndMessages(t *testing.T) {
+	server := irctest.NewServer("token")
+	defer server.Close()
+
+	var mutex sync.Mutex
+	handled := []*irc.Message{}
+	client, err := irc.NewClient(server.URL, "GameBot", "oauth:token", "#c")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	client.Logf = t.Logf
+	client.Handler = func(msg *irc.Message) {
+		mutex.Lock()
+		handled = append(handled, msg)
+		mutex.Unlock()
+	}
+	run(t, client)
+	if server.WaitFor(func(msg *irc.Message) bool { return msg.Command == "JOIN" }, 5*time.Second) == nil {
+		t.Fatalf("client did not join")
+	}
+
+	server.SendRaw("PING :tmi.twitch.tv")
+	pong := server.WaitFor(func(msg *irc.Message) bool { return msg.Command == "PONG" }, 5*time.Second)
+	if pong == nil || pong.Trailing() != "tmi.twitch.tv" {
+		t.Fatalf("PONG = %v; want one echoing the PING", pong)
+	}
+
+	id := server.Say("#c", "viewer", "!char", map[string]string{"badges": "subscriber/6"})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mutex.Lock()
+		var privmsg *irc.Message
+		for _, msg := range handled {
+			if msg.Command == "PRIVMSG" {
+				privmsg = msg
+			}
+		}
+		mutex.Unlock()
+		if privmsg != nil {
+			if privmsg.Tags["id"] != id || privmsg.Tags["user-id"] != irctest.UserID("viewer") ||
+				privmsg.Nick() != "viewer" || privmsg.Trailing() != "!char" || privmsg.Badges()["subscriber"] != "6" {
+				t.Fatalf("handled PRIVMSG = %#v", privmsg)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the PRIVMSG did not reach the handler")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	for _, msg := range handled {
+		if msg.Command == "PING" {
+			t.Fatalf("PING was handed to the handler")
+		}
+	}
+}
+
+func TestReplyAndReconnect(t *testing.T) {
+	server := irctest.NewServer("")
+	defer server.Close()
+	client := connect(t, server, "", "#c")
+
+	parent := &irc.Message{Tags: map[string]string{"id": "parent-1"}, Command: "PRIVMSG", Params: []string{"#c", "!char"}}
+	if err := client.Reply(parent, "Level 3\nwarrior   with 120 HP"); err != nil {
+		t.Fatalf("Reply: %v", err)
+	}
+	reply := server.WaitForPrivmsg("#c", "Level 3", 5*time.Second)
+	if reply == nil || reply.Tags["reply-parent-msg-id"] != "parent-1" || reply.Trailing() != "Level 3 warrior with 120 HP" {
+		t.Fatalf("reply = %v; want a threaded single-line reply", reply)
+	}
+
+	long := strings.Repeat("é", irc.MaxMessageLength+10)
+	if err := client.Say("C", long); err != nil {
+		t.Fatalf("Say: %v", err)
+	}
+	said := server.WaitForPrivmsg("#c", "é", 5*time.Second)
+	if said == nil || len([]rune(said.Trailing())) != irc.MaxMessageLength || !strings.HasSuffix(said.Trailing(), "…") {
+		t.Fatalf("long message = %v; want it cut to %d characters", said, irc.MaxMessageLength)
+	}
+
+	// Twitch asks the client to move to another server; it reconnects at once
+	server.SendRaw("RECONNECT")
+	deadline := time.Now().Add(5 * time.Second)
+	for server.Registered() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("client did not reconnect after RECONNECT")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// A dropped connection is redialed, and chat keeps flowing on the new one
+	server.DropConnections()
+	deadline = time.Now().Add(5 * time.Second)
+	for server.Registered() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("client did not reconnect after the connection dropped")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := client.Say("#c", "still here"); err != nil {
+		t.Fatalf("Say: %v", err)
+	}
+	if server.WaitForPrivmsg("#c", "still here", 5*time.Second) == nil {
+		t.Fatalf("message after reconnecting was not sent")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	server := irctest.NewServer("")
+	defer server.Close()
+
+	client, err := irc.NewClient(server.URL, "GameBot", "", "#c")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	client.Logf = t.Logf
+	client.RateLimit, client.RateWindow = 3, 300*time.Millisecond
+	for i := 1; i <= 7; i++ {
+		if err := client.Say("#c", fmt.Sprintf("message %d", i)); err != nil {
+			t.Fatalf("Say: %v", err)
+		}
+	}
+
+	start := time.Now()
+	run(t, client)
+	sentAt := map[int]time.Duration{}
+	for i := 1; i <= 7; i++ {
+		if server.WaitForPrivmsg("#c", fmt.Sprintf("message %d", i), 5*time.Second) == nil {
+			t.Fatalf("message %d was not sent", i)
+		}
+		sentAt[i] = time.Since(start)
+	}
+
+	// Three messages fit in a window; the fourth and seventh wait for the next ones
+	if sentAt[3] >= 300*time.Millisecond {
+		t.Fatalf("the first three messages took %s; want them sent at once", sentAt[3])
+	}
+	if sentAt[4] < 300*time.Millisecond || sentAt[7] < 600*time.Millisecond {
+		t.Fatalf("message 4 after %s and 7 after %s; want them held for one and two windows", sentAt[4], sentAt[7])
+	}
+
+	received := server.Received()
+	order := []string{}
+	for _, msg := range received {
+		if msg.Command == "PRIVMSG" {
+			order = append(order, msg.Trailing())
+		}
+	}
+	if len(order) != 7 || order[0] != "message 1" || order[6] != "message 7" {
+		t.Fatalf("messages arrived as %v; want all seven in order", order)
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	client, err := irc.NewClient("irc://127.0.0.1:1", "GameBot", "")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	// Nothing drains the queue without Run
+	for {
+		if err = client.Say("#c", "spam"); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, irc.ErrQueueFull) {
+		t.Fatalf("Say on a full queue = %v; want ErrQueueFull", err)
+	}
+}
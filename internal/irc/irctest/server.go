@@ -0,0 +1,272 @@
+// Package irctest provides a local stand-in for the Twitch chat IRC server, so the chat
+// client and bot can be exercised without connecting to Twitch.
+//
+// The server speaks plain IRC over TCP. It acknowledges capability requests, checks the
+// PASS token, welcomes the client, echoes JOINs and answers PINGs. Say delivers a chat
+// message from a viewer with the tags Twitch would attach, and every message a client
+// sends after registering is recorded for WaitFor.
+package irctest
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"twitch-rpg/internal/irc"
+)
+
+// Server is a fake Twitch chat server
+type Server struct {
+	// URL is the irc:// address of the server
+	URL string
+
+	password string
+	listener net.Listener
+	nextID   atomic.Int64
+
+	mutex      sync.Mutex
+	conns      map[net.Conn]*client
+	registered int
+	received   []*irc.Message
+	notify     chan struct{}
+}
+
+type client struct {
+	nick     string
+	channels map[string]bool
+	write    sync.Mutex
+}
+
+// NewServer starts a server. With a password, clients must send it with PASS, with or
+// without the "oauth:" prefix.
+func NewServer(password string) *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("irctest: failed to listen: %v", err))
+	}
+
+	s := &Server{
+		URL:      "irc://" + listener.Addr().String(),
+		password: strings.TrimPrefix(password, "oauth:"),
+		listener: listener,
+		conns:    make(map[net.Conn]*client),
+		notify:   make(chan struct{}),
+	}
+	go s.accept()
+	return s
+}
+
+// Close disconnects every client and stops the server
+func (s *Server) Close() {
+	s.listener.Close()
+	s.DropConnections()
+}
+
+// Registered returns how many clients completed registration so far
+func (s *Server) Registered() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.registered
+}
+
+// Received returns the messages clients sent after registering, in order
+func (s *Server) Received() []*irc.Message {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*irc.Message(nil), s.received...)
+}
+
+// WaitFor waits for a received message matching match, including ones received
+// earlier, and returns the first. It returns nil when the timeout passed first.
+func (s *Server) WaitFor(match func(*irc.Message) bool, timeout time.Duration) *irc.Message {
+	deadline := time.After(timeout)
+	for {
+		s.mutex.Lock()
+		received := s.received
+		notify := s.notify
+		s.mutex.Unlock()
+		for _, msg := range received {
+			if match(msg) {
+				return msg
+			}
+		}
+
+		select {
+		case <-notify:
+		case <-deadline:
+			return nil
+		}
+	}
+}
+
+// WaitForPrivmsg waits for a chat message sent to channel whose text contains substring
+func (s *Server) WaitForPrivmsg(channel, substring string, timeout time.Duration) *irc.Message {
+	channel = irc.NormalizeChannel(channel)
+	return s.WaitFor(func(msg *irc.Message) bool {
+		return msg.Command == "PRIVMSG" && msg.Param(0) == channel && strings.Contains(msg.Trailing(), substring)
+	}, timeout)
+}
+
+// Say delivers a chat message from the viewer login to every client in channel and
+// returns its message ID. The user-id, display-name, badges and id tags get defaults
+// unless tags sets them.
+func (s *Server) Say(channel, login, text string, tags map[string]string) string {
+	id := "irctest-" + strconv.FormatInt(s.nextID.Add(1), 10)
+	msg := &irc.Message{
+		Tags: map[string]string{
+			"id":           id,
+			"user-id":      UserID(login),
+			"display-name": login,
+			"badges":       "",
+			"tmi-sent-ts":  strconv.FormatInt(time.Now().UnixMilli(), 10),
+		},
+		Prefix:  login + "!" + login + "@" + login + ".tmi.twitch.tv",
+		Command: "PRIVMSG",
+		Params:  []string{irc.NormalizeChannel(channel), text},
+	}
+	for key, value := range tags {
+		msg.Tags[key] = value
+	}
+
+	s.broadcast(msg.Param(0), msg.String())
+	return msg.Tags["id"]
+}
+
+// UserID returns the user ID Say gives a viewer by default, stable for each login
+func UserID(login string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(strings.ToLower(login)))
+	return strconv.FormatUint(uint64(hash.Sum32()%900000000+100000000), 10)
+}
+
+// SendRaw writes a line to every registered client, e.g. "RECONNECT" or "PING :tmi.twitch.tv"
+func (s *Server) SendRaw(line string) {
+	s.broadcast("", line)
+}
+
+// DropConnections closes every open client connection, as when Twitch restarts a server
+func (s *Server) DropConnections() {
+	s.mutex.Lock()
+	conns := make([]net.Conn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.mutex.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
+
+// broadcast writes a line to the registered clients, only those in channel unless it is empty
+func (s *Server) broadcast(channel, line string) {
+	s.mutex.Lock()
+	targets := map[net.Conn]*client{}
+	for conn, c := range s.conns {
+		if c.nick != "" && (channel == "" || c.channels[channel]) {
+			targets[conn] = c
+		}
+	}
+	s.mutex.Unlock()
+
+	for conn, c := range targets {
+		c.send(conn, line)
+	}
+}
+
+func (c *client) send(conn net.Conn, line string) error {
+	c.write.Lock()
+	defer c.write.Unlock()
+	_, err := conn.Write([]byte(line + "\r\n"))
+	return err
+}
+
+func (s *Server) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+func (s *Server) serve(conn net.Conn) {
+	c := &client{channels: map[string]bool{}}
+	s.mutex.Lock()
+	s.conns[conn] = c
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, conn)
+		s.mutex.Unlock()
+		conn.Close()
+	}()
+
+	const host = "tmi.twitch.tv"
+	var password, nick string
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		msg, err := irc.ParseMessage(line)
+		if err != nil {
+			continue
+		}
+
+		s.mutex.Lock()
+		registered := c.nick != ""
+		s.mutex.Unlock()
+
+		switch msg.Command {
+		case "CAP":
+			if msg.Param(0) == "REQ" {
+				c.send(conn, fmt.Sprintf(":%s CAP * ACK :%s", host, msg.Trailing()))
+			}
+		case "PASS":
+			password = strings.TrimPrefix(msg.Param(0), "oauth:")
+		case "NICK":
+			nick = strings.ToLower(msg.Param(0))
+			if s.password != "" && password != s.password {
+				c.send(conn, fmt.Sprintf(":%s NOTICE * :Login authentication failed", host))
+				return
+			}
+			s.mutex.Lock()
+			c.nick = nick
+			s.registered++
+			s.mutex.Unlock()
+			c.send(conn, fmt.Sprintf(":%s 001 %s :Welcome, GLHF!", host, nick))
+		case "PING":
+			c.send(conn, fmt.Sprintf(":%s PONG %s :%s", host, host, msg.Trailing()))
+		case "JOIN":
+			if !registered {
+				continue
+			}
+			for _, channel := range strings.Split(msg.Param(0), ",") {
+				s.mutex.Lock()
+				c.channels[channel] = true
+				s.mutex.Unlock()
+				c.send(conn, fmt.Sprintf(":%s!%s@%s.%s JOIN %s", nick, nick, nick, host, channel))
+			}
+		}
+
+		if registered && msg.Command != "PING" {
+			s.record(msg)
+		}
+	}
+}
+
+func (s *Server) record(msg *irc.Message) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.received = append(s.received, msg)
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
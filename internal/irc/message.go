@@ -0,0 +1,179 @@
+package irc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Message is one IRC line: optional IRCv3 tags, an optional prefix naming the sender,
+// the command and its parameters. The trailing parameter, written after " :", is the
+// last element of Params.
+type Message struct {
+	Tags    map[string]string
+	Prefix  string
+	Command string
+	Params  []string
+}
+
+// ParseMessage parses a line without its CRLF terminator
+func ParseMessage(line string) (*Message, error) {
+	line = strings.TrimRight(line, "\r\n")
+	message := &Message{}
+
+	if strings.HasPrefix(line, "@") {
+		tags, rest, ok := strings.Cut(line[1:], " ")
+		if !ok {
+			return nil, fmt.Errorf("irc: message has only tags: %q", line)
+		}
+		message.Tags = parseTags(tags)
+		line = strings.TrimLeft(rest, " ")
+	}
+
+	if strings.HasPrefix(line, ":") {
+		prefix, rest, ok := strings.Cut(line[1:], " ")
+		if !ok {
+			return nil, fmt.Errorf("irc: message has only a prefix: %q", line)
+		}
+		message.Prefix = prefix
+		line = strings.TrimLeft(rest, " ")
+	}
+
+	for line != "" {
+		if strings.HasPrefix(line, ":") && message.Command != "" {
+			message.Params = append(message.Params, line[1:])
+			break
+		}
+		var field string
+		field, line, _ = strings.Cut(line, " ")
+		line = strings.TrimLeft(line, " ")
+		if message.Command == "" {
+			message.Command = strings.ToUpper(field)
+		} else {
+			message.Params = append(message.Params, field)
+		}
+	}
+
+	if message.Command == "" {
+		return nil, fmt.Errorf("irc: message without a command")
+	}
+	return message, nil
+}
+
+// String formats the message as a line without its CRLF terminator. The last parameter
+// is written as trailing parameter when it needs to be: when it is empty, contains a
+// space or starts with a colon.
+func (m *Message) String() string {
+	var line strings.Builder
+	if len(m.Tags) > 0 {
+		line.WriteByte('@')
+		first := true
+		for key, value := range m.Tags {
+			if !first {
+				line.WriteByte(';')
+			}
+			first = false
+			line.WriteString(key)
+			if value != "" {
+				line.WriteByte('=')
+				line.WriteString(escapeTag(value))
+			}
+		}
+		line.WriteByte(' ')
+	}
+	if m.Prefix != "" {
+		line.WriteByte(':')
+		line.WriteString(m.Prefix)
+		line.WriteByte(' ')
+	}
+	line.WriteString(m.Command)
+	for i, param := range m.Params {
+		line.WriteByte(' ')
+		if i == len(m.Params)-1 && (param == "" || strings.Contains(param, " ") || strings.HasPrefix(param, ":")) {
+			line.WriteByte(':')
+		}
+		line.WriteString(param)
+	}
+	return line.String()
+}
+
+// Param returns the i-th parameter, or "" when there are fewer
+func (m *Message) Param(i int) string {
+	if i < len(m.Params) {
+		return m.Params[i]
+	}
+	return ""
+}
+
+// Trailing returns the last parameter, the text of PRIVMSG and NOTICE messages
+func (m *Message) Trailing() string {
+	if len(m.Params) == 0 {
+		return ""
+	}
+	return m.Params[len(m.Params)-1]
+}
+
+// Nick returns the nickname from the prefix, which is the login name on Twitch
+func (m *Message) Nick() string {
+	nick, _, _ := strings.Cut(m.Prefix, "!")
+	return nick
+}
+
+// Badges parses the Twitch badges tag, e.g. "broadcaster/1,subscriber/12", into
+// badge names and versions
+func (m *Message) Badges() map[string]string {
+	badges := map[string]string{}
+	for _, badge := range strings.Split(m.Tags["badges"], ",") {
+		if name, version, _ := strings.Cut(badge, "/"); name != "" {
+			badges[name] = version
+		}
+	}
+	return badges
+}
+
+func parseTags(raw string) map[string]string {
+	tags := map[string]string{}
+	for _, tag := range strings.Split(raw, ";") {
+		key, value, _ := strings.Cut(tag, "=")
+		if key != "" {
+			tags[key] = unescapeTag(value)
+		}
+	}
+	return tags
+}
+
+var tagEscaper = strings.NewReplacer(`\`, `\\`, ";", `\:`, " ", `\s`, "\r", `\r`, "\n", `\n`)
+
+func escapeTag(value string) string {
+	return tagEscaper.Replace(value)
+}
+
+func unescapeTag(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	var unescaped strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			unescaped.WriteByte(value[i])
+			continue
+		}
+		i++
+		if i == len(value) {
+			break // a trailing lone backslash is dropped
+		}
+		switch value[i] {
+		case ':':
+			unescaped.WriteByte(';')
+		case 's':
+			unescaped.WriteByte(' ')
+		case 'r':
+			unescaped.WriteByte('\r')
+		case 'n':
+			unescaped.WriteByte('\n')
+		default:
+			unescaped.WriteByte(value[i])
+		}
+	}
+	return unescaped.String()
+}
@@ -0,0 +1,134 @@
+package irc_test
+
+import (
+	"reflect"
+	"testing"
+	"twitch-rpg/internal/irc"
+)
+
+func TestParseMessage(t *testing.T) {
+	cases := []struct {
+		line string
+		want irc.Message
+	}{
+		{
+			line: "@badge-info=;badges=broadcaster/1,subscriber/12;display-name=Cool_Viewer;emotes=;id=b34ccfc7;user-id=4145994 " +
+				":cool_viewer!cool_viewer@cool_viewer.tmi.twitch.tv PRIVMSG #cool_streamer :!duel @rival 100\r\n",
+			want: irc.Message{
+				Tags: map[string]string{
+					"badge-info": "", "badges": "broadcaster/1,subscriber/12", "display-name": "Cool_Viewer",
+					"emotes": "", "id": "b34ccfc7", "user-id": "4145994",
+				},
+				Prefix:  "cool_viewer!cool_viewer@cool_viewer.tmi.twitch.tv",
+				Command: "PRIVMSG",
+				Params:  []string{"#cool_streamer", "!duel @rival 100"},
+			},
+		},
+		{
+			line: "PING :tmi.twitch.tv",
+			want: irc.Message{Command: "PING", Params: []string{"tmi.twitch.tv"}},
+		},
+		{
+			line: ":tmi.twitch.tv 001 bot :Welcome, GLHF!",
+			want: irc.Message{Prefix: "tmi.twitch.tv", Command: "001", Params: []string{"bot", "Welcome, GLHF!"}},
+		},
+		{
+			// Lower case commands, repeated spaces and an empty trailing parameter
+			line: ":tmi.twitch.tv  cap  *  ACK :",
+			want: irc.Message{Prefix: "tmi.twitch.tv", Command: "CAP", Params: []string{"*", "ACK", ""}},
+		},
+		{
+			// Valueless tags and a trailing parameter that starts with a colon
+			line: "@flag;empty= :a!a@a PRIVMSG #c ::)",
+			want: irc.Message{Tags: map[string]string{"flag": "", "empty": ""}, Prefix: "a!a@a", Command: "PRIVMSG", Params: []string{"#c", ":)"}},
+		},
+	}
+
+	for _, tc := range cases {
+		msg, err := irc.ParseMessage(tc.line)
+		if err != nil {
+			t.Fatalf("ParseMessage(%q): %v", tc.line, err)
+		}
+		if !reflect.DeepEqual(*msg, tc.want) {
+			t.Fatalf("ParseMessage(%q) = %#v; want %#v", tc.line, *msg, tc.want)
+		}
+	}
+
+	for _, line := range []string{"", "@tags-only", ":prefix-only", "@a=b :prefix"} {
+		if msg, err := irc.ParseMessage(line); err == nil {
+			t.Fatalf("ParseMessage(%q) = %#v; want an error", line, msg)
+		}
+	}
+}
+
+func TestTagUnescaping(t *testing.T) {
+	cases := map[string]string{
+		`hello\sworld`:    "hello world",
+		`semi\:colon`:     "semi;colon",
+		`back\\slash`:     `back\slash`,
+		`line\rbreak\n`:   "line\rbreak\n",
+		`unknown\xescape`: "unknownxescape",
+		`lone\`:           "lone",
+		`\\s`:             `\s`,
+		`plain`:           "plain",
+	}
+	for escaped, want := range cases {
+		msg, err := irc.ParseMessage("@system-msg=" + escaped + " USERNOTICE #c")
+		if err != nil {
+			t.Fatalf("ParseMessage(%q): %v", escaped, err)
+		}
+		if got := msg.Tags["system-msg"]; got != want {
+			t.Fatalf("tag %q unescaped to %q; want %q", escaped, got, want)
+		}
+	}
+}
+
+func TestMessageString(t *testing.T) {
+	msg := &irc.Message{
+		Tags:    map[string]string{"reply-parent-msg-id": `a b;c\d` + "\r\n"},
+		Command: "PRIVMSG",
+		Params:  []string{"#c", "Your character is level 3"},
+	}
+	line := msg.String()
+	if want := `@reply-parent-msg-id=a\sb\:c\\d\r\n PRIVMSG #c :Your character is level 3`; line != want {
+		t.Fatalf("String() = %q; want %q", line, want)
+	}
+	parsed, err := irc.ParseMessage(line)
+	if err != nil || !reflect.DeepEqual(parsed, msg) {
+		t.Fatalf("round trip = %#v, %v; want %#v", parsed, err, msg)
+	}
+
+	for _, tc := range []struct {
+		msg  irc.Message
+		want string
+	}{
+		{irc.Message{Command: "NICK", Params: []string{"bot"}}, "NICK bot"},
+		{irc.Message{Command: "PRIVMSG", Params: []string{"#c", ""}}, "PRIVMSG #c :"},
+		{irc.Message{Command: "PRIVMSG", Params: []string{"#c", ":)"}}, "PRIVMSG #c ::)"},
+		{irc.Message{Prefix: "tmi.twitch.tv", Command: "PONG", Params: []string{"tmi.twitch.tv"}}, ":tmi.twitch.tv PONG tmi.twitch.tv"},
+	} {
+		if line := tc.msg.String(); line != tc.want {
+			t.Fatalf("String() = %q; want %q", line, tc.want)
+		}
+	}
+}
+
+func TestMessageAccessors(t *testing.T) {
+	msg, err := irc.ParseMessage("@badges=broadcaster/1,subscriber/3000,glhf-pledge/1 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #c :hi there")
+	if err != nil {
+		t.Fatalf("ParseMessage: %v", err)
+	}
+	if msg.Nick() != "viewer" || msg.Param(0) != "#c" || msg.Param(5) != "" || msg.Trailing() != "hi there" {
+		t.Fatalf("Nick %q, Param(0) %q, Param(5) %q, Trailing %q", msg.Nick(), msg.Param(0), msg.Param(5), msg.Trailing())
+	}
+	want := map[string]string{"broadcaster": "1", "subscriber": "3000", "glhf-pledge": "1"}
+	if badges := msg.Badges(); !reflect.DeepEqual(badges, want) {
+		t.Fatalf("Badges() = %v; want %v", badges, want)
+	}
+	if badges := (&irc.Message{Command: "PRIVMSG"}).Badges(); len(badges) != 0 {
+		t.Fatalf("Badges() without the tag = %v; want none", badges)
+	}
+	if trailing := (&irc.Message{Command: "PING"}).Trailing(); trailing != "" {
+		t.Fatalf("Trailing() without params = %q", trailing)
+	}
+}
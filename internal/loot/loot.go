@@ -0,0 +1,212 @@
+// Package loot rolls item drops after fights from weighted loot tables.
+//
+// A table decides in two steps: whether a fight drops anything at all, with a
+// chance that grows when the winner beat a higher level opponent, and if so
+// which rarity and item type drop, weighted by the tier matching the
+// opponent's level. Picking the concrete catalog item is left to the caller.
+package loot
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"twitch-rpg/internal/models"
+)
+
+//go:embed default.json
+var defaultTable []byte
+
+// Rarities lists the item rarities from most to least common. Weighted picks walk
+// this order so the same random roll always selects the same rarity.
+var Rarities = []models.ItemRarity{
+	models.RarityCommon,
+	models.RarityRare,
+	models.RarityEpic,
+	models.RarityLegendary,
+}
+
+// ItemTypes lists the item types in the order weighted picks walk them
+var ItemTypes = []models.ItemType{
+	models.ItemTypeBoots,
+	models.ItemTypePants,
+	models.ItemTypeArmor,
+	models.ItemTypeHelmet,
+	models.ItemTypeRing,
+	models.ItemTypeChain,
+}
+
+// DropChance is the probability of a fight dropping an item. Every level the
+// opponent is above the winner adds PerLevel, every level below takes it away.
+type DropChance struct {
+	Base     float64 `json:"base"`
+	PerLevel float64 `json:"per_level"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+}
+
+// Tier weights rarities, and optionally item types, for opponents of MinLevel and up
+type Tier struct {
+	MinLevel  int                       `json:"min_level"`
+	Rarities  map[models.ItemRarity]int `json:"rarities"`
+	ItemTypes map[models.ItemType]int   `json:"item_types,omitempty"` // defaults to the table's weights
+}
+
+// Table is a complete loot configuration
+type Table struct {
+	DropChance DropChance              `json:"drop_chance"`
+	ItemTypes  map[models.ItemType]int `json:"item_types"`
+	Tiers      []Tier                  `json:"tiers"`
+}
+
+// Drop is the kind of item a fight dropped
+type Drop struct {
+	Rarity models.ItemRarity `json:"rarity"`
+	Type   models.ItemType   `json:"type"`
+}
+
+// Default returns the loot table compiled into the binary
+func Default() *Table {
+	table, err := Parse(defaultTable)
+	if err != nil {
+		panic(fmt.Sprintf("loot: invalid default table: %v", err))
+	}
+	return table
+}
+
+// Load reads and validates a loot table from a JSON file
+func Load(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read loot table: %v", err)
+	}
+
+	table, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid loot table %s: %v", path, err)
+	}
+	return table, nil
+}
+
+// Parse decodes and validates a loot table
+func Parse(data []byte) (*Table, error) {
+	var table Table
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, err
+	}
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(table.Tiers, func(i, j int) bool {
+		return table.Tiers[i].MinLevel < table.Tiers[j].MinLevel
+	})
+	return &table, nil
+}
+
+// Validate checks that chances are probabilities, weights are known and
+// non-negative, and every tier can drop at least one rarity and item type
+func (t *Table) Validate() error {
+	chance := t.DropChance
+	if chance.Min < 0 || chance.Max > 1 || chance.Min > chance.Max || chance.Base < 0 || chance.Base > 1 {
+		return fmt.Errorf("drop chances must satisfy 0 <= min <= max <= 1 and 0 <= base <= 1")
+	}
+	if err := validateWeights(t.ItemTypes, ItemTypes, "item type"); err != nil {
+		return err
+	}
+	if len(t.Tiers) == 0 {
+		return fmt.Errorf("at least one tier is required")
+	}
+
+	for _, tier := range t.Tiers {
+		if err := validateWeights(tier.Rarities, Rarities, "rarity"); err != nil {
+			return fmt.Errorf("tier %d: %v", tier.MinLevel, err)
+		}
+		if tier.ItemTypes != nil {
+			if err := validateWeights(tier.ItemTypes, ItemTypes, "item type"); err != nil {
+				return fmt.Errorf("tier %d: %v", tier.MinLevel, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateWeights[K ~string](weights map[K]int, known []K, kind string) error {
+	total := 0
+	for key, weight := range weights {
+		if !contains(known, key) {
+			return fmt.Errorf("unknown %s %q", kind, key)
+		}
+		if weight < 0 {
+			return fmt.Errorf("%s %q has a negative weight", kind, key)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return fmt.Errorf("at least one %s needs a positive weight", kind)
+	}
+	return nil
+}
+
+func contains[K comparable](values []K, value K) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ChanceFor returns the drop chance for a winner of winnerLevel beating an opponent of opponentLevel
+func (t *Table) ChanceFor(winnerLevel, opponentLevel int) float64 {
+	chance := t.DropChance.Base + float64(opponentLevel-winnerLevel)*t.DropChance.PerLevel
+	return min(max(chance, t.DropChance.Min), t.DropChance.Max)
+}
+
+// TierFor returns the highest tier whose MinLevel the opponent reaches, or the lowest tier
+func (t *Table) TierFor(opponentLevel int) *Tier {
+	tier := &t.Tiers[0]
+	for i := range t.Tiers {
+		if t.Tiers[i].MinLevel <= opponentLevel {
+			tier = &t.Tiers[i]
+		}
+	}
+	return tier
+}
+
+// Roll decides whether a fight drops an item and, if it does, its rarity and type
+func (t *Table) Roll(winnerLevel, opponentLevel int, rng *rand.Rand) (Drop, bool) {
+	if rng.Float64() >= t.ChanceFor(winnerLevel, opponentLevel) {
+		return Drop{}, false
+	}
+
+	tier := t.TierFor(opponentLevel)
+	itemTypes := tier.ItemTypes
+	if itemTypes == nil {
+		itemTypes = t.ItemTypes
+	}
+
+	return Drop{
+		Rarity: pick(tier.Rarities, Rarities, rng),
+		Type:   pick(itemTypes, ItemTypes, rng),
+	}, true
+}
+
+// pick chooses a key with probability proportional to its weight, walking keys in order
+func pick[K comparable](weights map[K]int, order []K, rng *rand.Rand) K {
+	total := 0
+	for _, key := range order {
+		total += weights[key]
+	}
+
+	roll := rng.Intn(total)
+	for _, key := range order {
+		if roll < weights[key] {
+			return key
+		}
+		roll -= weights[key]
+	}
+	return order[len(order)-1]
+}
@@ -0,0 +1,178 @@
+package loot_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"twitch-rpg/internal/loot"
+	"twitch-rpg/internal/models"
+)
+
+// tableJSON returns a loot table with the given base drop chance and tiers
+func tableJSON(base, tiers string) string {
+	return fmt.Sprintf(`{
+  "drop_chance": {"base": %s, "per_level": 0.1, "min": 0, "max": 1},
+  "item_types": {"boots": 1, "ring": 1},
+  "tiers": [%s]
+}`, base, tiers)
+}
+
+func TestDefaultTable(t *testing.T) {
+	defaults := loot.Default()
+	if err := defaults.Validate(); err != nil {
+		t.Fatalf("default table: %v", err)
+	}
+	for i := 1; i < len(defaults.Tiers); i++ {
+		if defaults.Tiers[i-1].MinLevel >= defaults.Tiers[i].MinLevel {
+			t.Fatalf("default tiers are not sorted by level: %+v", defaults.Tiers)
+		}
+	}
+	if defaults.Tiers[0].MinLevel > 1 {
+		t.Fatalf("the lowest default tier starts at level %d; want level 1 covered", defaults.Tiers[0].MinLevel)
+	}
+	for _, levels := range [][2]int{{1, 1}, {1, 50}, {50, 1}} {
+		chance := defaults.ChanceFor(levels[0], levels[1])
+		if chance < defaults.DropChance.Min || chance > defaults.DropChance.Max {
+			t.Fatalf("ChanceFor(%d, %d) = %v outside [%v, %v]", levels[0], levels[1], chance, defaults.DropChance.Min, defaults.DropChance.Max)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tier := `{"min_level": 1, "rarities": {"common": 1}}`
+	for _, test := range []struct {
+		name, data, wantError string
+	}{
+		{"not json", `{"tiers": [`, "unexpected end"},
+		{"no tiers", tableJSON("0.5", ""), "at least one tier"},
+		{"chance above one", tableJSON("1.5", tier), "drop chances"},
+		{"negative chance", tableJSON("-0.1", tier), "drop chances"},
+		{"min above max", `{"drop_chance": {"min": 0.8, "max": 0.2}, "item_types": {"boots": 1}, "tiers": [` + tier + `]}`, "drop chances"},
+		{"no item types", `{"drop_chance": {"max": 1}, "tiers": [` + tier + `]}`, "at least one item type needs a positive weight"},
+		{"zero item types", `{"drop_chance": {"max": 1}, "item_types": {"boots": 0}, "tiers": [` + tier + `]}`, "at least one item type needs a positive weight"},
+		{"unknown item type", `{"drop_chance": {"max": 1}, "item_types": {"cape": 1}, "tiers": [` + tier + `]}`, `unknown item type "cape"`},
+		{"negative item type", `{"drop_chance": {"max": 1}, "item_types": {"boots": 2, "ring": -1}, "tiers": [` + tier + `]}`, `item type "ring" has a negative weight`},
+		{"empty rarities", tableJSON("0.5", `{"min_level": 3, "rarities": {}}`), "tier 3: at least one rarity"},
+		{"zero rarities", tableJSON("0.5", `{"min_level": 1, "rarities": {"common": 0, "epic": 0}}`), "at least one rarity"},
+		{"unknown rarity", tableJSON("0.5", `{"min_level": 1, "rarities": {"mythic": 1}}`), `unknown rarity "mythic"`},
+		{"negative rarity", tableJSON("0.5", `{"min_level": 1, "rarities": {"common": 5, "rare": -1}}`), "negative weight"},
+		{"tier item types", tableJSON("0.5", `{"min_level": 7, "rarities": {"common": 1}, "item_types": {"boots": 0}}`), "tier 7: at least one item type"},
+	} {
+		if _, err := loot.Parse([]byte(test.data)); err == nil || !strings.Contains(err.Error(), test.wantError) {
+			t.Fatalf("%s: Parse = %v; want an error containing %q", test.name, err, test.wantError)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "loot.json")
+	data := tableJSON("0.5", `{"min_level": 10, "rarities": {"epic": 1}}, {"min_level": 1, "rarities": {"common": 1}}`)
+	if err := os.WriteFile(valid, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	loaded, err := loot.Load(valid)
+	if err != nil || loaded.Tiers[0].MinLevel != 1 || loaded.Tiers[1].MinLevel != 10 {
+		t.Fatalf("Load = %+v, %v; want tiers sorted by level", loaded, err)
+	}
+	if loaded.TierFor(0).MinLevel != 1 || loaded.TierFor(9).MinLevel != 1 || loaded.TierFor(10).MinLevel != 10 || loaded.TierFor(99).MinLevel != 10 {
+		t.Fatalf("TierFor picks the wrong tier")
+	}
+
+	if _, err := loot.Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatalf("Load of a missing file succeeded")
+	}
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{"tiers": []}`), 0o600)
+	if _, err := loot.Load(invalid); err == nil || !strings.Contains(err.Error(), invalid) {
+		t.Fatalf("Load of an invalid table = %v; want an error naming the file", err)
+	}
+}
+
+func TestChanceFor(t *testing.T) {
+	chances := loot.Table{DropChance: loot.DropChance{Base: 0.3, PerLevel: 0.05, Min: 0.1, Max: 0.6}}
+	for _, test := range []struct {
+		winner, opponent int
+		want             float64
+	}{
+		{5, 5, 0.3},
+		{5, 7, 0.4},
+		{5, 3, 0.2},
+		{5, 50, 0.6},
+		{50, 5, 0.1},
+	} {
+		if got := chances.ChanceFor(test.winner, test.opponent); math.Abs(got-test.want) > 1e-9 {
+			t.Fatalf("ChanceFor(%d, %d) = %v; want %v", test.winner, test.opponent, got, test.want)
+		}
+	}
+}
+
+func TestRollIsDeterministic(t *testing.T) {
+	defaults := loot.Default()
+	roll := func(seed int64) []loot.Drop {
+		rng := rand.New(rand.NewSource(seed))
+		drops := []loot.Drop{}
+		for i := 0; i < 200; i++ {
+			if drop, ok := defaults.Roll(5, 5+i%30, rng); ok {
+				drops = append(drops, drop)
+			} else {
+				drops = append(drops, loot.Drop{})
+			}
+		}
+		return drops
+	}
+
+	first, again, other := roll(42), roll(42), roll(43)
+	differs := false
+	for i := range first {
+		if first[i] != again[i] {
+			t.Fatalf("roll %d with the same seed = %+v, then %+v", i, first[i], again[i])
+		}
+		differs = differs || first[i] != other[i]
+	}
+	if !differs {
+		t.Fatalf("different seeds rolled the same drops")
+	}
+}
+
+func TestRollFollowsWeights(t *testing.T) {
+	rolls := func(data string, winner, opponent int) map[loot.Drop]int {
+		parsed, err := loot.Parse([]byte(data))
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		rng := rand.New(rand.NewSource(7))
+		counts := map[loot.Drop]int{}
+		for i := 0; i < 2000; i++ {
+			drop, _ := parsed.Roll(winner, opponent, rng)
+			counts[drop]++
+		}
+		return counts
+	}
+
+	// A drop chance of zero never drops, and one always does
+	never := rolls(tableJSON("0", `{"min_level": 1, "rarities": {"common": 1}}`), 5, 5)
+	if never[loot.Drop{}] != 2000 {
+		t.Fatalf("drops at chance 0: %v", never)
+	}
+	always := rolls(tableJSON("0", `{"min_level": 1, "rarities": {"common": 1}}`), 1, 11)
+	if always[loot.Drop{}] != 0 {
+		t.Fatalf("%d fights without a drop at chance 1", always[loot.Drop{}])
+	}
+
+	// Zero weights never drop, and a tier's own item types replace the table's
+	counts := rolls(tableJSON("1", `{"min_level": 1, "rarities": {"common": 3, "legendary": 0, "epic": 1}, "item_types": {"chain": 1}}`), 1, 1)
+	common := counts[loot.Drop{Rarity: models.RarityCommon, Type: models.ItemTypeChain}]
+	epic := counts[loot.Drop{Rarity: models.RarityEpic, Type: models.ItemTypeChain}]
+	if common+epic != 2000 {
+		t.Fatalf("unexpected drops: %v", counts)
+	}
+	// Three to one, within a generous margin
+	if common < 1350 || common > 1650 {
+		t.Fatalf("%d of 2000 drops were common; want about 1500", common)
+	}
+}
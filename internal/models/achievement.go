@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Achievement badge tiers, from easiest to hardest
+const (
+	AchievementTierBronze = "bronze"
+	AchievementTierSilver = "silver"
+	AchievementTierGold   = "gold"
+)
+
+// CharacterAchievement is one achievement tier a character has unlocked
+type CharacterAchievement struct {
+	ID             int       `json:"id" db:"id"`
+	CharacterID    int       `json:"character_id" db:"character_id"`
+	AchievementKey string    `json:"achievement_key" db:"achievement_key"`
+	Tier           string    `json:"tier" db:"tier"`
+	UnlockedAt     time.Time `json:"unlocked_at" db:"unlocked_at"`
+
+	// Populated from the achievement catalog
+	Name        string `json:"name,omitempty"`
+	TierName    string `json:"tier_name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// AchievementUnlockedEventData represents data for achievement unlock events
+type AchievementUnlockedEventData struct {
+	CharacterName   string `json:"character_name"`
+	AchievementKey  string `json:"achievement_key"`
+	AchievementName string `json:"achievement_name"`
+	Tier            string `json:"tier"`
+	TierName        string `json:"tier_name"`
+	Description     string `json:"description"`
+}
+
+// CreateAchievementUnlockedEvent creates an achievement unlock event so the overlay can show the badge
+func CreateAchievementUnlockedEvent(character *Character, achievement *CharacterAchievement) (*GameEvent, error) {
+	data := AchievementUnlockedEventData{
+		CharacterName:   character.Username,
+		AchievementKey:  achievement.AchievementKey,
+		AchievementName: achievement.Name,
+		Tier:            achievement.Tier,
+		TierName:        achievement.TierName,
+		Description:     achievement.Description,
+	}
+
+	return CreateGameEvent(EventTypeAchievementUnlocked, &character.ID, data)
+}
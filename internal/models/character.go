@@ -19,6 +19,10 @@ type Character struct {
         Vitality     int `json:"vitality" db:"vitality"`
         Intelligence int `json:"intelligence" db:"intelligence"`
         
+        // Skill rating, only changed by rated fights
+        Rating      int `json:"rating" db:"rating"`
+        RatedFights int `json:"rated_fights" db:"rated_fights"`
+        
         // Equipment slots (item IDs)
         BootsID   *int `json:"boots_id,omitempty" db:"boots_id"`
         PantsID   *int `json:"pants_id,omitempty" db:"pants_id"`
@@ -34,6 +38,7 @@ type Character struct {
         Equipment     *Equipment     `json:"equipment,omitempty"`
         TotalStats    *Stats         `json:"total_stats,omitempty"`
         CombatPower   int            `json:"combat_power,omitempty"`
+        Achievements  []CharacterAchievement `json:"achievements,omitempty"` // loaded for the profile only
 }
 
 // Stats represents character statistics
@@ -153,16 +158,22 @@ func (c *Character) CanUpgradeStat(statType string, channelPoints int) bool {
         return channelPoints > 0 && (statType == "strength" || statType == "agility" || statType == "vitality" || statType == "intelligence")
 }
 
+// StatPointsPerUpgrade is the channel point cost of one stat point
+const StatPointsPerUpgrade = 100
+
+// StatUpgradeAmount returns how many stat points channelPoints buy
+func StatUpgradeAmount(channelPoints int) int {
+        // This can be made more complex later (e.g., increasing costs)
+        return channelPoints / StatPointsPerUpgrade
+}
+
 // UpgradeStat upgrades a character's stat by spending channel points
 func (c *Character) UpgradeStat(statType string, channelPoints int) bool {
         if !c.CanUpgradeStat(statType, channelPoints) {
                 return false
         }
         
-        // Each stat upgrade costs channelPoints and increases stat by 1
-        // This can be made more complex later (e.g., increasing costs)
-        pointsPerUpgrade := 100 // Base cost for stat upgrade
-        upgradeAmount := channelPoints / pointsPerUpgrade
+        upgradeAmount := StatUpgradeAmount(channelPoints)
         
         if upgradeAmount <= 0 {
                 return false
@@ -0,0 +1,52 @@
+package models
+
+// ChatUser is the viewer who sent a chat message, as identified by the message's tags
+type ChatUser struct {
+	ID          string            `json:"id"` // Twitch user ID
+	Login       string            `json:"login"`
+	DisplayName string            `json:"display_name"`
+	Badges      map[string]string `json:"badges,omitempty"` // badge name to version, e.g. "subscriber": "12"
+}
+
+// Name returns the display name, or the login when the viewer has none
+func (u *ChatUser) Name() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	return u.Login
+}
+
+// ChatBadge is a badge shown next to a chatter's name, as EventSub reports it
+type ChatBadge struct {
+	SetID string `json:"set_id"` // badge name, e.g. "moderator"
+	ID    string `json:"id"`     // badge version
+	Info  string `json:"info"`
+}
+
+// ChatMessageEvent is a chat message delivered by an EventSub channel.chat.message subscription
+type ChatMessageEvent struct {
+	BroadcasterUserID    string      `json:"broadcaster_user_id"`
+	BroadcasterUserLogin string      `json:"broadcaster_user_login"`
+	BroadcasterUserName  string      `json:"broadcaster_user_name"`
+	ChatterUserID        string      `json:"chatter_user_id"`
+	ChatterUserLogin     string      `json:"chatter_user_login"`
+	ChatterUserName      string      `json:"chatter_user_name"`
+	MessageID            string      `json:"message_id"`
+	Message              ChatText    `json:"message"`
+	MessageType          string      `json:"message_type"`
+	Badges               []ChatBadge `json:"badges"`
+}
+
+// ChatText is the text of a chat message
+type ChatText struct {
+	Text string `json:"text"`
+}
+
+// Chatter returns the sender of the message
+func (e *ChatMessageEvent) Chatter() *ChatUser {
+	badges := make(map[string]string, len(e.Badges))
+	for _, badge := range e.Badges {
+		badges[badge.SetID] = badge.ID
+	}
+	return &ChatUser{ID: e.ChatterUserID, Login: e.ChatterUserLogin, DisplayName: e.ChatterUserName, Badges: badges}
+}
@@ -1,8 +1,6 @@
 package models
 
 import (
-        "fmt"
-        "math/rand"
         "time"
 )
 
@@ -15,6 +13,10 @@ type CombatLog struct {
         AttackerPower int       `json:"attacker_power" db:"attacker_power"`
         DefenderPower int       `json:"defender_power" db:"defender_power"`
         CombatLogText string    `json:"combat_log" db:"combat_log"`
+        Transcript    *CombatTranscript `json:"transcript,omitempty" db:"transcript"`
+        Seed          *int64    `json:"seed,omitempty" db:"seed"`
+        AttackerSnapshot *FighterSnapshot `json:"attacker_snapshot,omitempty" db:"attacker_snapshot"`
+        DefenderSnapshot *FighterSnapshot `json:"defender_snapshot,omitempty" db:"defender_snapshot"`
         CreatedAt     time.Time `json:"created_at" db:"created_at"`
         
         // Populated fields
@@ -30,52 +32,69 @@ type CombatRequest struct {
 
 // CombatResult represents the result of a combat encounter
 type CombatResult struct {
+        CombatLogID      int        `json:"combat_log_id"`
+        Seed             int64      `json:"seed"`
         Winner           *Character `json:"winner"`
         Loser            *Character `json:"loser"`
+        AttackerID       int        `json:"attacker_id"`
         AttackerPower    int        `json:"attacker_power"`
         DefenderPower    int        `json:"defender_power"`
         CombatLog        string     `json:"combat_log"`
+        Transcript       *CombatTranscript `json:"transcript"`
         ExperienceGained int        `json:"experience_gained"`
+        PointsAwarded    int        `json:"points_awarded"`
+        RatingChanges    []RatingHistoryEntry `json:"rating_changes,omitempty"`
         RewardItems      []Item     `json:"reward_items,omitempty"`
 }
 
-// SimulateCombat simulates a combat encounter between two characters
-func SimulateCombat(attacker, defender *Character) *CombatResult {
-        // Calculate combat powers
-        attackerPower := attacker.CalculateCombatPower()
-        defenderPower := defender.CalculateCombatPower()
-        
-        // Add some randomness (±20% variation)
-        attackerRoll := attackerPower + (attackerPower * (rand.Intn(40) - 20) / 100)
-        defenderRoll := defenderPower + (defenderPower * (rand.Intn(40) - 20) / 100)
-        
-        var winner, loser *Character
-        var combatLog string
-        
-        if attackerRoll > defenderRoll {
-                winner = attacker
-                loser = defender
-                combatLog = fmt.Sprintf("%s besiegt %s! (%d vs %d Kampfkraft)", 
-                        attacker.Username, defender.Username, attackerRoll, defenderRoll)
-        } else {
-                winner = defender
-                loser = attacker
-                combatLog = fmt.Sprintf("%s verteidigt sich erfolgreich gegen %s! (%d vs %d Kampfkraft)", 
-                        defender.Username, attacker.Username, defenderRoll, attackerRoll)
-        }
-        
-        // Calculate experience reward (based on opponent's level)
-        experienceGained := loser.Level * 50
-        if winner == defender {
-                experienceGained = int(float64(experienceGained) * 1.2) // Defender bonus
-        }
-        
-        return &CombatResult{
-                Winner:           winner,
-                Loser:            loser,
-                AttackerPower:    attackerPower,
-                DefenderPower:    defenderPower,
-                CombatLog:        combatLog,
-                ExperienceGained: experienceGained,
-        }
-}
\ No newline at end of file
+// CombatActionType is the kind of blow dealt in a combat round
+type CombatActionType string
+
+const (
+        CombatActionAttack CombatActionType = "attack"
+        CombatActionSpell  CombatActionType = "spell"
+)
+
+// CombatTranscript is the blow-by-blow record of a fight, used by the overlay to replay it
+type CombatTranscript struct {
+        AttackerMaxHP int           `json:"attacker_max_hp"`
+        DefenderMaxHP int           `json:"defender_max_hp"`
+        Rounds        []CombatRound `json:"rounds"`
+}
+
+// CombatRound holds the actions of both fighters in one round
+type CombatRound struct {
+        Number  int            `json:"round"`
+        Actions []CombatAction `json:"actions"`
+}
+
+// CombatAction is a single attack or spell and its outcome
+type CombatAction struct {
+        ActorID  int              `json:"actor_id"`
+        TargetID int              `json:"target_id"`
+        Type     CombatActionType `json:"type"`
+        Damage   int              `json:"damage"`
+        Critical bool             `json:"critical"`
+        Dodged   bool             `json:"dodged"`
+        TargetHP int              `json:"target_hp"`
+}
+
+// FighterSnapshot is a fighter's level and total stats at the start of a fight,
+// stored so the fight can be replayed later
+type FighterSnapshot struct {
+        ID    int    `json:"id"`
+        Name  string `json:"name"`
+        Level int    `json:"level"`
+        Stats Stats  `json:"stats"`
+}
+
+// CombatVerification is the result of replaying a logged fight from its seed
+type CombatVerification struct {
+        CombatLogID       int               `json:"combat_log_id"`
+        Seed              int64             `json:"seed"`
+        RecordedWinnerID  int               `json:"recorded_winner_id"`
+        ReplayedWinnerID  int               `json:"replayed_winner_id"`
+        TranscriptMatches bool              `json:"transcript_matches"`
+        Verified          bool              `json:"verified"`
+        Transcript        *CombatTranscript `json:"transcript"`
+}
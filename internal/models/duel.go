@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// DuelStatus is the lifecycle state of a duel challenge
+type DuelStatus string
+
+const (
+	DuelStatusPending   DuelStatus = "pending"   // waiting for the defender to respond
+	DuelStatusAccepted  DuelStatus = "accepted"  // defender accepted and the fight ran
+	DuelStatusDeclined  DuelStatus = "declined"  // defender declined
+	DuelStatusExpired   DuelStatus = "expired"   // defender did not respond in time
+	DuelStatusCancelled DuelStatus = "cancelled" // wager could not be escrowed or the fight failed
+)
+
+// DuelChallenge is a fight offered by one character to another.
+// Wagers are escrowed from the challenger on creation and from the defender
+// on acceptance; the winner takes both.
+type DuelChallenge struct {
+	ID           int        `json:"id" db:"id"`
+	ChallengerID int        `json:"challenger_id" db:"challenger_id"`
+	DefenderID   int        `json:"defender_id" db:"defender_id"`
+	Wager        int        `json:"wager" db:"wager"`
+	Status       DuelStatus `json:"status" db:"status"`
+	CombatLogID  *int       `json:"combat_log_id,omitempty" db:"combat_log_id"`
+	WinnerID     *int       `json:"winner_id,omitempty" db:"winner_id"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"`
+	RespondedAt  *time.Time `json:"responded_at,omitempty" db:"responded_at"`
+}
+
+// DuelChallengeRequest represents a request to challenge another character
+type DuelChallengeRequest struct {
+	AttackerID int `json:"attacker_id" binding:"required"`
+	DefenderID int `json:"defender_id" binding:"required"`
+	Wager      int `json:"wager,omitempty"`
+}
+
+// DuelResponseRequest identifies the character accepting or declining a challenge
+type DuelResponseRequest struct {
+	CharacterID int `json:"character_id" binding:"required"`
+}
+
+// DuelOutcome is returned when a challenge is accepted and fought
+type DuelOutcome struct {
+	Challenge *DuelChallenge `json:"challenge"`
+	Combat    *CombatResult  `json:"combat"`
+	Payout    int            `json:"payout"`
+}
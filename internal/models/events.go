@@ -13,9 +13,28 @@ const (
         EventTypeMerchant      GameEventType = "merchant"
         EventTypeLevelUp       GameEventType = "level_up"
         EventTypeItemAcquired  GameEventType = "item_acquired"
+        EventTypeItemEquipped  GameEventType = "item_equipped"
         EventTypeQuestCompleted GameEventType = "quest_completed"
+        EventTypeRaidSpawned   GameEventType = "raid_spawned"
+        EventTypeRaidMilestone GameEventType = "raid_milestone"
+        EventTypeRaidDefeated  GameEventType = "raid_defeated"
+        EventTypeAchievementUnlocked GameEventType = "achievement_unlocked"
 )
 
+// GameEventTypes lists every game event type
+var GameEventTypes = []GameEventType{
+        EventTypeCombat,
+        EventTypeMerchant,
+        EventTypeLevelUp,
+        EventTypeItemAcquired,
+        EventTypeItemEquipped,
+        EventTypeQuestCompleted,
+        EventTypeRaidSpawned,
+        EventTypeRaidMilestone,
+        EventTypeRaidDefeated,
+        EventTypeAchievementUnlocked,
+}
+
 // GameEvent represents an event that can trigger OBS animations
 type GameEvent struct {
         ID           int           `json:"id" db:"id"`
@@ -29,6 +48,28 @@ type GameEvent struct {
         Character *Character `json:"character,omitempty"`
 }
 
+// GameEventFilter selects game events by type and character; empty fields match every event
+type GameEventFilter struct {
+        Types       []GameEventType
+        CharacterID *int
+}
+
+// Matches reports whether an event passes the filter
+func (f GameEventFilter) Matches(event *GameEvent) bool {
+        if f.CharacterID != nil && (event.CharacterID == nil || *event.CharacterID != *f.CharacterID) {
+                return false
+        }
+        if len(f.Types) == 0 {
+                return true
+        }
+        for _, eventType := range f.Types {
+                if event.EventType == eventType {
+                        return true
+                }
+        }
+        return false
+}
+
 // MerchantEvent represents a merchant appearance event
 type MerchantEvent struct {
         ID             int             `json:"id" db:"id"`
@@ -39,7 +80,8 @@ type MerchantEvent struct {
         IsActive       bool            `json:"is_active" db:"is_active"`
         
         // Populated fields
-        Items []Item `json:"items,omitempty"`
+        Items  []Item              `json:"items,omitempty"`
+        Offers []MerchantEventItem `json:"offers,omitempty"`
 }
 
 // MerchantEventItem represents an item in a merchant event
@@ -55,6 +97,27 @@ type MerchantEventItem struct {
         Item *Item `json:"item,omitempty"`
 }
 
+// MerchantPurchaseRequest represents a request to buy an item from a merchant event
+type MerchantPurchaseRequest struct {
+        CharacterID         int `json:"character_id" binding:"required"`
+        MerchantEventID     int `json:"merchant_event_id,omitempty"` // optional; the item must belong to it
+        MerchantEventItemID int `json:"merchant_event_item_id" binding:"required"`
+}
+
+// MerchantPurchase is the receipt of a completed merchant purchase
+type MerchantPurchase struct {
+        CharacterID         int                `json:"character_id"`
+        MerchantEventID     int                `json:"merchant_event_id"`
+        MerchantEventItemID int                `json:"merchant_event_item_id"`
+        ItemID              int                `json:"item_id"`
+        Price               int                `json:"price"`
+        RemainingStock      int                `json:"remaining_stock"`
+        Transaction         *WalletTransaction `json:"transaction"`
+
+        // Populated fields
+        Item *Item `json:"item,omitempty"`
+}
+
 // Event represents a general game event
 type Event struct {
         ID          int       `json:"id" db:"id"`
@@ -84,16 +147,24 @@ type CharacterQuest struct {
         ID          int             `json:"id" db:"id"`
         CharacterID int             `json:"character_id" db:"character_id"`
         QuestID     int             `json:"quest_id" db:"quest_id"`
+        RotationID  int             `json:"rotation_id,omitempty" db:"rotation_id"` // 0 for quests outside a rotation
         Progress    json.RawMessage `json:"progress" db:"progress"`
         Completed   bool            `json:"completed" db:"completed"`
         CompletedAt *time.Time      `json:"completed_at,omitempty" db:"completed_at"`
+        Expired     bool            `json:"expired" db:"expired"`
         StartedAt   time.Time       `json:"started_at" db:"started_at"`
+        ExpiresAt   *time.Time      `json:"expires_at,omitempty" db:"expires_at"` // reset of the rotation it was accepted in
         
         // Populated fields
         Quest     *Quest     `json:"quest,omitempty"`
         Character *Character `json:"character,omitempty"`
 }
 
+// IsPastExpiry reports whether the rotation the quest was accepted in has ended
+func (cq *CharacterQuest) IsPastExpiry(now time.Time) bool {
+        return cq.ExpiresAt != nil && !now.Before(*cq.ExpiresAt)
+}
+
 // OBSEventData represents data sent to OBS for animations
 type OBSEventData struct {
         EventType   GameEventType          `json:"event_type"`
@@ -114,9 +185,11 @@ type CombatEventData struct {
 
 // MerchantEventData represents data for merchant events
 type MerchantEventData struct {
-        MerchantType  string   `json:"merchant_type"`
-        AvailableItems []string `json:"available_items"`
-        Duration      int      `json:"duration_minutes"`
+        MerchantEventID int        `json:"merchant_event_id"`
+        MerchantType    string     `json:"merchant_type"`
+        AvailableItems  []string   `json:"available_items"` // item names
+        Duration        int        `json:"duration_minutes"`
+        EndTime         *time.Time `json:"end_time,omitempty"`
 }
 
 // LevelUpEventData represents data for level up events
@@ -134,6 +207,14 @@ type ItemAcquiredEventData struct {
         Method        string     `json:"method"` // 'purchase', 'quest_reward', 'combat_reward'
 }
 
+// ItemEquippedEventData represents data for item equip events
+type ItemEquippedEventData struct {
+        CharacterName string     `json:"character_name"`
+        ItemName      string     `json:"item_name"`
+        ItemRarity    ItemRarity `json:"item_rarity"`
+        Slot          ItemType   `json:"slot"`
+}
+
 // CreateGameEvent creates a new game event
 func CreateGameEvent(eventType GameEventType, characterID *int, data interface{}) (*GameEvent, error) {
         eventData, err := json.Marshal(data)
@@ -152,9 +233,14 @@ func CreateGameEvent(eventType GameEventType, characterID *int, data interface{}
 
 // CreateCombatEvent creates a combat event for OBS
 func CreateCombatEvent(combat *CombatResult) (*GameEvent, error) {
+        attacker, defender := combat.Winner, combat.Loser
+        if combat.AttackerID == combat.Loser.ID {
+                attacker, defender = combat.Loser, combat.Winner
+        }
+
         data := CombatEventData{
-                AttackerName:  combat.Winner.Username,
-                DefenderName:  combat.Loser.Username,
+                AttackerName:  attacker.Username,
+                DefenderName:  defender.Username,
                 WinnerName:    combat.Winner.Username,
                 AttackerPower: combat.AttackerPower,
                 DefenderPower: combat.DefenderPower,
@@ -164,6 +250,24 @@ func CreateCombatEvent(combat *CombatResult) (*GameEvent, error) {
         return CreateGameEvent(EventTypeCombat, &combat.Winner.ID, data)
 }
 
+// CreateMerchantAppearedEvent creates a merchant event for OBS
+func CreateMerchantAppearedEvent(event *MerchantEvent) (*GameEvent, error) {
+        data := MerchantEventData{
+                MerchantEventID: event.ID,
+                MerchantType:    event.EventType,
+                AvailableItems:  []string{},
+                EndTime:         event.EndTime,
+        }
+        for _, item := range event.Items {
+                data.AvailableItems = append(data.AvailableItems, item.Name)
+        }
+        if event.EndTime != nil {
+                data.Duration = int(event.EndTime.Sub(event.StartTime).Minutes())
+        }
+        
+        return CreateGameEvent(EventTypeMerchant, nil, data)
+}
+
 // CreateLevelUpEvent creates a level up event for OBS
 func CreateLevelUpEvent(character *Character) (*GameEvent, error) {
         data := LevelUpEventData{
@@ -185,4 +289,16 @@ func CreateItemAcquiredEvent(character *Character, item *Item, method string) (*
         }
         
         return CreateGameEvent(EventTypeItemAcquired, &character.ID, data)
+}
+
+// CreateItemEquippedEvent creates an item equip event for OBS
+func CreateItemEquippedEvent(character *Character, item *Item) (*GameEvent, error) {
+        data := ItemEquippedEventData{
+                CharacterName: character.Username,
+                ItemName:      item.Name,
+                ItemRarity:    item.Rarity,
+                Slot:          item.Type,
+        }
+        
+        return CreateGameEvent(EventTypeItemEquipped, &character.ID, data)
 }
\ No newline at end of file
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyRecord stores the outcome of the first request made with an idempotency key,
+// e.g. a Twitch redemption ID, so retries can be answered without repeating the effect
+type IdempotencyRecord struct {
+	Scope        string     `json:"scope" db:"scope"`
+	Key          string     `json:"key" db:"idempotency_key"`
+	RequestHash  string     `json:"request_hash" db:"request_hash"`
+	StatusCode   int        `json:"status_code" db:"status_code"`
+	ResponseBody []byte     `json:"-" db:"response_body"`
+	Completed    bool       `json:"completed" db:"completed"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// UsernameChange records a character's username being replaced, usually because the
+// viewer renamed their Twitch account
+type UsernameChange struct {
+	ID          int       `json:"id" db:"id"`
+	CharacterID int       `json:"character_id" db:"character_id"`
+	OldUsername string    `json:"old_username" db:"old_username"`
+	NewUsername string    `json:"new_username" db:"new_username"`
+	ChangedAt   time.Time `json:"changed_at" db:"changed_at"`
+}
+
+// Ways a character was resolved from a Twitch user ID or login
+const (
+	ResolvedByTwitchUserID   = "twitch_user_id"
+	ResolvedByUsername       = "username"
+	ResolvedByFormerUsername = "former_username"
+)
+
+// CharacterResolution is a character found by Twitch user ID, current username or a
+// username it went by before
+type CharacterResolution struct {
+	Character  *Character `json:"character"`
+	ResolvedBy string     `json:"resolved_by"`
+}
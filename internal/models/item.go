@@ -28,6 +28,22 @@ const (
         RarityLegendary ItemRarity = "legendary"
 )
 
+// Rank orders rarities from common (1) to legendary (4), with 0 for unknown rarities
+func (r ItemRarity) Rank() int {
+        switch r {
+        case RarityCommon:
+                return 1
+        case RarityRare:
+                return 2
+        case RarityEpic:
+                return 3
+        case RarityLegendary:
+                return 4
+        default:
+                return 0
+        }
+}
+
 // Item represents an equipment item in the game
 type Item struct {
         ID               int         `json:"id" db:"id"`
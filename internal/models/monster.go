@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+)
+
+// Monster is a bestiary entry that characters can hunt
+type Monster struct {
+	ID               int       `json:"id" db:"id"`
+	Name             string    `json:"name" db:"name"`
+	Description      string    `json:"description" db:"description"`
+	LevelMin         int       `json:"level_min" db:"level_min"`
+	LevelMax         int       `json:"level_max" db:"level_max"`
+	Strength         int       `json:"strength" db:"strength"`
+	Agility          int       `json:"agility" db:"agility"`
+	Vitality         int       `json:"vitality" db:"vitality"`
+	Intelligence     int       `json:"intelligence" db:"intelligence"`
+	ExperienceReward int       `json:"experience_reward" db:"experience_reward"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+
+	// Populated fields
+	Loot []MonsterLoot `json:"loot"`
+}
+
+// MonsterLoot is one entry of a monster's loot table
+type MonsterLoot struct {
+	MonsterID  int     `json:"monster_id" db:"monster_id"`
+	ItemID     int     `json:"item_id" db:"item_id"`
+	DropChance float64 `json:"drop_chance" db:"drop_chance"` // 0..1
+	Quantity   int     `json:"quantity" db:"quantity"`
+
+	// Populated fields
+	Item *Item `json:"item,omitempty"`
+}
+
+// LevelFor returns the level the monster spawns at for a hunter of the given level
+func (m *Monster) LevelFor(characterLevel int) int {
+	return min(max(characterLevel, m.LevelMin), m.LevelMax)
+}
+
+// monsterGrowthPercent is how much stats and experience grow per level above LevelMin
+const monsterGrowthPercent = 10
+
+// StatsAtLevel returns the monster's stat block scaled to a level within its range
+func (m *Monster) StatsAtLevel(level int) Stats {
+	return Stats{
+		Strength:     m.scale(m.Strength, level),
+		Agility:      m.scale(m.Agility, level),
+		Vitality:     m.scale(m.Vitality, level),
+		Intelligence: m.scale(m.Intelligence, level),
+	}
+}
+
+// ExperienceAtLevel returns the experience awarded for defeating the monster at a level
+func (m *Monster) ExperienceAtLevel(level int) int {
+	return m.scale(m.ExperienceReward, level)
+}
+
+func (m *Monster) scale(value, level int) int {
+	return value + value*(level-m.LevelMin)*monsterGrowthPercent/100
+}
+
+// HuntLog records a character's fight against a monster
+type HuntLog struct {
+	ID               int               `json:"id" db:"id"`
+	CharacterID      int               `json:"character_id" db:"character_id"`
+	MonsterID        int               `json:"monster_id" db:"monster_id"`
+	MonsterLevel     int               `json:"monster_level" db:"monster_level"`
+	Won              bool              `json:"won" db:"won"`
+	ExperienceGained int               `json:"experience_gained" db:"experience_gained"`
+	DropItemIDs      []int             `json:"drop_item_ids" db:"drop_item_ids"`
+	Seed             int64             `json:"seed" db:"seed"`
+	Transcript       *CombatTranscript `json:"transcript,omitempty" db:"transcript"`
+	CreatedAt        time.Time         `json:"created_at" db:"created_at"`
+}
+
+// HuntResult is returned after a character hunts a monster
+type HuntResult struct {
+	HuntID           int               `json:"hunt_id"`
+	Character        *Character        `json:"character"`
+	Monster          *Monster          `json:"monster"`
+	MonsterLevel     int               `json:"monster_level"`
+	Won              bool              `json:"won"`
+	ExperienceGained int               `json:"experience_gained"`
+	LeveledUp        bool              `json:"leveled_up"`
+	Drops            []Item            `json:"drops"`
+	Seed             int64             `json:"seed"`
+	Transcript       *CombatTranscript `json:"transcript"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// OverlayConsumer is a named overlay, such as an alerts box or a ticker, that consumes the
+// game event stream at its own pace. Every instance of the overlay shares the consumer,
+// so each event is played by only one of them.
+type OverlayConsumer struct {
+	Name      string    `json:"name" db:"name"`
+	Cursor    int       `json:"cursor" db:"cursor_event_id"` // ID of the newest event handed out
+	InFlight  int       `json:"in_flight"`                   // claimed events not acknowledged yet
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// OverlayDelivery is a game event claimed by an overlay consumer. Unless it is
+// acknowledged before ClaimedUntil, it is handed out again by a later claim.
+type OverlayDelivery struct {
+	Consumer     string    `json:"-" db:"consumer"`
+	EventID      int       `json:"event_id" db:"event_id"`
+	ClaimToken   string    `json:"-" db:"claim_token"`
+	ClaimedUntil time.Time `json:"claimed_until" db:"claimed_until"`
+	Attempts     int       `json:"attempts" db:"attempts"` // how often the event was claimed
+
+	// Populated fields
+	Event *GameEvent `json:"event,omitempty"`
+}
+
+// OverlayClaimRequest represents a request to claim the next events of a consumer
+type OverlayClaimRequest struct {
+	Limit                    int `json:"limit"`                      // defaults to 1
+	VisibilityTimeoutSeconds int `json:"visibility_timeout_seconds"` // defaults to 30
+}
+
+// OverlayClaim is the result of a claim. Its token acknowledges the claimed events.
+type OverlayClaim struct {
+	Consumer     string            `json:"consumer"`
+	ClaimToken   string            `json:"claim_token"`
+	VisibleUntil time.Time         `json:"visible_until"`
+	Events       []OverlayDelivery `json:"events"`
+}
+
+// OverlayAckRequest represents a request to acknowledge played events
+type OverlayAckRequest struct {
+	ClaimToken string `json:"claim_token" binding:"required"`
+	EventIDs   []int  `json:"event_ids" binding:"required"`
+}
+
+// OverlayAck reports which events were acknowledged. Events whose claim expired and
+// were handed out again under a newer token are rejected.
+type OverlayAck struct {
+	Acknowledged []int `json:"acknowledged"`
+	Rejected     []int `json:"rejected"`
+}
@@ -0,0 +1,138 @@
+package models
+
+import "time"
+
+// Quest types
+const (
+	QuestTypeDaily   = "daily"
+	QuestTypeWeekly  = "weekly"
+	QuestTypeSpecial = "special"
+)
+
+// QuestRotation is the subset of daily or weekly quests on offer for one reset period
+type QuestRotation struct {
+	ID          int       `json:"id" db:"id"`
+	QuestType   string    `json:"quest_type" db:"quest_type"`
+	PeriodStart time.Time `json:"period_start" db:"period_start"`
+	PeriodEnd   time.Time `json:"period_end" db:"period_end"` // the next reset
+	QuestIDs    []int     `json:"quest_ids" db:"quest_ids"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+
+	// Populated fields
+	Quests          []Quest `json:"quests,omitempty"`
+	ResetsInSeconds int     `json:"resets_in_seconds"`
+}
+
+// Includes reports whether the quest is part of the rotation
+func (r *QuestRotation) Includes(questID int) bool {
+	for _, id := range r.QuestIDs {
+		if id == questID {
+			return true
+		}
+	}
+	return false
+}
+
+// QuestRotationStatus is the current daily and weekly rotation shown on the quest board
+type QuestRotationStatus struct {
+	Timezone string         `json:"timezone"`
+	Daily    *QuestRotation `json:"daily"`
+	Weekly   *QuestRotation `json:"weekly"`
+}
+
+// QuestRequirementType is a kind of condition a quest can require
+type QuestRequirementType string
+
+const (
+	RequirementWinFights        QuestRequirementType = "win_fights"        // win Count duels, hunts or raids after accepting
+	RequirementReachLevel       QuestRequirementType = "reach_level"       // be at Level or higher
+	RequirementOwnItemRarity    QuestRequirementType = "own_item_rarity"   // own Count items of Rarity or better
+	RequirementMerchantPurchase QuestRequirementType = "merchant_purchase" // buy Count items from merchants after accepting
+)
+
+// QuestRequirement is one condition of a quest's Requirements JSON, which holds a list
+// of them that must all be met, e.g. [{"type": "win_fights", "count": 3}, {"type": "reach_level", "level": 5}]
+type QuestRequirement struct {
+	Type   QuestRequirementType `json:"type"`
+	Count  int                  `json:"count,omitempty"`
+	Level  int                  `json:"level,omitempty"`
+	Rarity ItemRarity           `json:"rarity,omitempty"`
+}
+
+// Target is the value the requirement's progress must reach
+func (r QuestRequirement) Target() int {
+	if r.Type == RequirementReachLevel {
+		return r.Level
+	}
+	return max(r.Count, 1)
+}
+
+// IsCounter reports whether the requirement counts actions taken after accepting the quest,
+// as opposed to checking the character's current state
+func (r QuestRequirement) IsCounter() bool {
+	return r.Type == RequirementWinFights || r.Type == RequirementMerchantPurchase
+}
+
+// QuestRewards is the decoded Rewards JSON of a quest
+type QuestRewards struct {
+	Experience    int   `json:"experience,omitempty"`
+	ChannelPoints int   `json:"channel_points,omitempty"`
+	ItemIDs       []int `json:"item_ids,omitempty"`
+}
+
+// QuestProgress is the decoded Progress JSON of a character quest, one entry per requirement
+type QuestProgress struct {
+	Requirements []RequirementProgress `json:"requirements"`
+}
+
+// RequirementProgress tracks one requirement of an accepted quest
+type RequirementProgress struct {
+	Type    QuestRequirementType `json:"type"`
+	Current int                  `json:"current"`
+	Target  int                  `json:"target"`
+	Met     bool                 `json:"met"`
+}
+
+// QuestAction is a game action that can advance quest progress
+type QuestAction string
+
+const (
+	QuestActionFightWon         QuestAction = "fight_won" // a duel or hunt won, or a raid boss defeated
+	QuestActionMerchantPurchase QuestAction = "merchant_purchase"
+	QuestActionStateChanged     QuestAction = "state_changed" // level or inventory may have changed
+)
+
+// QuestCreateRequest represents a request to add a quest to the quest board
+type QuestCreateRequest struct {
+	Name             string             `json:"name" binding:"required"`
+	Description      string             `json:"description,omitempty"`
+	QuestType        string             `json:"quest_type,omitempty"` // daily (default), weekly or special
+	Requirements     []QuestRequirement `json:"requirements" binding:"required"`
+	Rewards          QuestRewards       `json:"rewards"`
+	ChannelPointCost int                `json:"channel_point_cost,omitempty"`
+}
+
+// QuestAcceptRequest identifies the character accepting a quest
+type QuestAcceptRequest struct {
+	CharacterID int `json:"character_id" binding:"required"`
+}
+
+// QuestCompletedEventData represents data for quest completion events
+type QuestCompletedEventData struct {
+	CharacterName string       `json:"character_name"`
+	QuestName     string       `json:"quest_name"`
+	QuestType     string       `json:"quest_type"`
+	Rewards       QuestRewards `json:"rewards"`
+}
+
+// CreateQuestCompletedEvent creates a quest completion event for OBS
+func CreateQuestCompletedEvent(character *Character, quest *Quest, rewards QuestRewards) (*GameEvent, error) {
+	data := QuestCompletedEventData{
+		CharacterName: character.Username,
+		QuestName:     quest.Name,
+		QuestType:     quest.QuestType,
+		Rewards:       rewards,
+	}
+
+	return CreateGameEvent(EventTypeQuestCompleted, &character.ID, data)
+}
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"time"
+)
+
+// RaidStatus is the lifecycle state of a raid boss
+type RaidStatus string
+
+const (
+	RaidStatusActive   RaidStatus = "active"   // can be attacked
+	RaidStatusDefeated RaidStatus = "defeated" // killed by chat; rewards were distributed
+	RaidStatusEscaped  RaidStatus = "escaped"  // survived until its deadline
+)
+
+// RaidMilestones are the remaining hit point percentages announced to the overlay
+var RaidMilestones = []int{75, 50, 25}
+
+// RaidBoss is a community boss with a large hit point pool that every character
+// can attack. Its stats only matter for dodging and are never used to strike back.
+type RaidBoss struct {
+	ID                    int        `json:"id" db:"id"`
+	Name                  string     `json:"name" db:"name"`
+	Level                 int        `json:"level" db:"level"`
+	Strength              int        `json:"strength" db:"strength"`
+	Agility               int        `json:"agility" db:"agility"`
+	Vitality              int        `json:"vitality" db:"vitality"`
+	Intelligence          int        `json:"intelligence" db:"intelligence"`
+	MaxHP                 int        `json:"max_hp" db:"max_hp"`
+	CurrentHP             int        `json:"current_hp" db:"current_hp"`
+	RewardPoints          int        `json:"reward_points" db:"reward_points"`         // split by damage share
+	RewardExperience      int        `json:"reward_experience" db:"reward_experience"` // split by damage share
+	AttackCooldownSeconds int        `json:"attack_cooldown_seconds" db:"attack_cooldown_seconds"`
+	Status                RaidStatus `json:"status" db:"status"`
+	LastHitBy             *int       `json:"last_hit_by,omitempty" db:"last_hit_by"`
+	SpawnedAt             time.Time  `json:"spawned_at" db:"spawned_at"`
+	ExpiresAt             *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	EndedAt               *time.Time `json:"ended_at,omitempty" db:"ended_at"`
+}
+
+// Stats returns the boss's stat block
+func (b *RaidBoss) Stats() Stats {
+	return Stats{
+		Strength:     b.Strength,
+		Agility:      b.Agility,
+		Vitality:     b.Vitality,
+		Intelligence: b.Intelligence,
+	}
+}
+
+// AttackCooldown is how long a character must wait between attacks
+func (b *RaidBoss) AttackCooldown() time.Duration {
+	return time.Duration(b.AttackCooldownSeconds) * time.Second
+}
+
+// HPPercent returns the remaining hit points as a percentage of the maximum
+func (b *RaidBoss) HPPercent() float64 {
+	if b.MaxHP <= 0 {
+		return 0
+	}
+	return float64(b.CurrentHP) * 100 / float64(b.MaxHP)
+}
+
+// IsOver reports whether the boss has been defeated, escaped or passed its deadline
+func (b *RaidBoss) IsOver(now time.Time) bool {
+	return b.Status != RaidStatusActive || (b.ExpiresAt != nil && !b.ExpiresAt.After(now))
+}
+
+// MilestonesCrossed returns the milestones passed when hit points dropped from hpBefore
+// to the current value. Every milestone is crossed by exactly one hit.
+func (b *RaidBoss) MilestonesCrossed(hpBefore int) []int {
+	crossed := []int{}
+	for _, milestone := range RaidMilestones {
+		threshold := milestone * b.MaxHP
+		if hpBefore*100 > threshold && b.CurrentHP*100 <= threshold {
+			crossed = append(crossed, milestone)
+		}
+	}
+	return crossed
+}
+
+// RaidContribution is the damage one character dealt to a raid boss
+type RaidContribution struct {
+	RaidID           int       `json:"raid_id" db:"raid_id"`
+	CharacterID      int       `json:"character_id" db:"character_id"`
+	Damage           int       `json:"damage" db:"damage"`
+	Attacks          int       `json:"attacks" db:"attacks"`
+	LastAttackAt     time.Time `json:"last_attack_at" db:"last_attack_at"`
+	RewardPoints     *int      `json:"reward_points,omitempty" db:"reward_points"`
+	RewardExperience *int      `json:"reward_experience,omitempty" db:"reward_experience"`
+
+	// Populated fields
+	Username string `json:"username,omitempty"`
+}
+
+// RaidHit is one attack as applied by the store
+type RaidHit struct {
+	Raid         *RaidBoss         // boss after the hit
+	Contribution *RaidContribution // attacker's contribution after the hit
+	Damage       int               // damage applied, capped at the remaining hit points
+	HPBefore     int
+	Defeated     bool // this hit killed the boss
+}
+
+// RaidSpawnRequest represents the broadcaster's request to spawn a raid boss
+type RaidSpawnRequest struct {
+	Name                  string `json:"name" binding:"required"`
+	Level                 int    `json:"level,omitempty"`
+	MaxHP                 int    `json:"max_hp" binding:"required"`
+	Strength              int    `json:"strength,omitempty"`
+	Agility               int    `json:"agility,omitempty"`
+	Vitality              int    `json:"vitality,omitempty"`
+	Intelligence          int    `json:"intelligence,omitempty"`
+	RewardPoints          int    `json:"reward_points,omitempty"`
+	RewardExperience      int    `json:"reward_experience,omitempty"`
+	AttackCooldownSeconds int    `json:"attack_cooldown_seconds,omitempty"`
+	DurationMinutes       int    `json:"duration_minutes,omitempty"` // 0 keeps the boss until it dies
+}
+
+// RaidAttackRequest identifies the character attacking a raid boss
+type RaidAttackRequest struct {
+	CharacterID int `json:"character_id" binding:"required"`
+}
+
+// RaidAttackResult is returned after a character attacks a raid boss
+type RaidAttackResult struct {
+	Raid         *RaidBoss          `json:"raid"`
+	Action       CombatAction       `json:"action"`
+	Damage       int                `json:"damage"`
+	Contribution *RaidContribution  `json:"contribution"`
+	NextAttackAt time.Time          `json:"next_attack_at"`
+	Milestones   []int              `json:"milestones,omitempty"`
+	Defeated     bool               `json:"defeated"`
+	Rewards      []RaidContribution `json:"rewards,omitempty"` // set for the finishing blow only
+}
+
+// RaidProgress is the live state of a raid shown by the overlay
+type RaidProgress struct {
+	Raid            *RaidBoss          `json:"raid"`
+	HPPercent       float64            `json:"hp_percent"`
+	TotalDamage     int                `json:"total_damage"`
+	Contributors    int                `json:"contributors"`
+	TopContributors []RaidContribution `json:"top_contributors"`
+}
+
+// RaidEventData represents data for raid spawn, milestone and defeat events
+type RaidEventData struct {
+	RaidID          int                `json:"raid_id"`
+	BossName        string             `json:"boss_name"`
+	Level           int                `json:"level"`
+	MaxHP           int                `json:"max_hp"`
+	CurrentHP       int                `json:"current_hp"`
+	Milestone       int                `json:"milestone,omitempty"` // remaining hit point percentage
+	CharacterName   string             `json:"character_name,omitempty"`
+	TopContributors []RaidContribution `json:"top_contributors,omitempty"`
+}
+
+func newRaidEventData(boss *RaidBoss) RaidEventData {
+	return RaidEventData{
+		RaidID:    boss.ID,
+		BossName:  boss.Name,
+		Level:     boss.Level,
+		MaxHP:     boss.MaxHP,
+		CurrentHP: boss.CurrentHP,
+	}
+}
+
+// CreateRaidSpawnedEvent creates a raid spawn event for OBS
+func CreateRaidSpawnedEvent(boss *RaidBoss) (*GameEvent, error) {
+	return CreateGameEvent(EventTypeRaidSpawned, nil, newRaidEventData(boss))
+}
+
+// CreateRaidMilestoneEvent creates an event for the hit that pushed a boss below a milestone
+func CreateRaidMilestoneEvent(boss *RaidBoss, milestone int, character *Character) (*GameEvent, error) {
+	data := newRaidEventData(boss)
+	data.Milestone = milestone
+	data.CharacterName = character.Username
+
+	return CreateGameEvent(EventTypeRaidMilestone, &character.ID, data)
+}
+
+// CreateRaidDefeatedEvent creates a raid defeat event crediting the finishing blow and top contributors
+func CreateRaidDefeatedEvent(boss *RaidBoss, finisher *Character, topContributors []RaidContribution) (*GameEvent, error) {
+	data := newRaidEventData(boss)
+	data.CharacterName = finisher.Username
+	data.TopContributors = topContributors
+
+	return CreateGameEvent(EventTypeRaidDefeated, &finisher.ID, data)
+}
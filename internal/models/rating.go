@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+)
+
+// DefaultRating is the rating every character starts with
+const DefaultRating = 1200
+
+// RatingHistoryEntry records how one fight changed a character's rating
+type RatingHistoryEntry struct {
+	ID           int       `json:"id" db:"id"`
+	CharacterID  int       `json:"character_id" db:"character_id"`
+	OpponentID   int       `json:"opponent_id" db:"opponent_id"`
+	CombatLogID  int       `json:"combat_log_id" db:"combat_log_id"`
+	Won          bool      `json:"won" db:"won"`
+	RatingBefore int       `json:"rating_before" db:"rating_before"`
+	RatingAfter  int       `json:"rating_after" db:"rating_after"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// Delta is the rating change caused by the fight
+func (e *RatingHistoryEntry) Delta() int {
+	return e.RatingAfter - e.RatingBefore
+}
+
+// LadderEntry is one row of the ranked ladder
+type LadderEntry struct {
+	Rank        int    `json:"rank"`
+	CharacterID int    `json:"character_id"`
+	Username    string `json:"username"`
+	Level       int    `json:"level"`
+	Rating      int    `json:"rating"`
+	RatedFights int    `json:"rated_fights"`
+	CombatPower int    `json:"combat_power"`
+}
+
+// MatchmakingTicket is a character waiting in the matchmaking queue
+type MatchmakingTicket struct {
+	CharacterID int       `json:"character_id"`
+	Username    string    `json:"username"`
+	Rating      int       `json:"rating"`
+	CombatPower int       `json:"combat_power"`
+	QueuedAt    time.Time `json:"queued_at"`
+}
+
+// MatchmakingRequest represents a request to join the matchmaking queue
+type MatchmakingRequest struct {
+	CharacterID int `json:"character_id" binding:"required"`
+}
+
+// Match is a pairing made by the matchmaking queue and the fight it produced
+type Match struct {
+	AttackerID int           `json:"attacker_id"`
+	DefenderID int           `json:"defender_id"`
+	Combat     *CombatResult `json:"combat"`
+	MatchedAt  time.Time     `json:"matched_at"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+)
+
+// Statuses of a channel point redemption as the Helix API names them; EventSub
+// reports them in lower case
+const (
+	RedemptionUnfulfilled = "UNFULFILLED"
+	RedemptionFulfilled   = "FULFILLED"
+	RedemptionCanceled    = "CANCELED"
+)
+
+// ChannelPointReward is the custom channel point reward a viewer redeemed
+type ChannelPointReward struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Cost   int    `json:"cost"`
+	Prompt string `json:"prompt"`
+}
+
+// ChannelPointRedemption is a viewer redeeming a custom reward, as delivered by Twitch
+type ChannelPointRedemption struct {
+	ID                   string             `json:"id"`
+	BroadcasterUserID    string             `json:"broadcaster_user_id"`
+	BroadcasterUserLogin string             `json:"broadcaster_user_login"`
+	BroadcasterUserName  string             `json:"broadcaster_user_name"`
+	UserID               string             `json:"user_id"`
+	UserLogin            string             `json:"user_login"`
+	UserName             string             `json:"user_name"`
+	UserInput            string             `json:"user_input"`
+	Status               string             `json:"status"`
+	Reward               ChannelPointReward `json:"reward"`
+	RedeemedAt           time.Time          `json:"redeemed_at"`
+}
+
+// RedemptionOutcome records what a redemption did in the game. The reward cost is
+// credited to the redeemer's wallet before the action runs; when the action fails the
+// credit is reversed and Fulfilled stays false.
+type RedemptionOutcome struct {
+	RedemptionID string `json:"redemption_id"`
+	RewardTitle  string `json:"reward_title"`
+	Action       string `json:"action"`
+	CharacterID  int    `json:"character_id"`
+	Credited     int    `json:"credited"`
+	Fulfilled    bool   `json:"fulfilled"`
+	// RedemptionStatus is the status the redemption was given on Twitch, FULFILLED or
+	// CANCELED to refund the points; empty when it was left in the reward queue
+	RedemptionStatus string `json:"redemption_status,omitempty"`
+	Error            string `json:"error,omitempty"`
+	Result           any    `json:"result,omitempty"` // upgraded character, merchant purchase or duel challenge
+}
+
+// RewardSync records what syncing a configured reward to the Twitch channel did
+type RewardSync struct {
+	Title    string `json:"title"`
+	RewardID string `json:"reward_id,omitempty"`
+	Result   string `json:"result"` // created, updated, unchanged, skipped or failed
+	Error    string `json:"error,omitempty"`
+}
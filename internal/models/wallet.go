@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+)
+
+// WalletTransactionType is the direction of a wallet ledger entry
+type WalletTransactionType string
+
+const (
+	TransactionCredit WalletTransactionType = "credit"
+	TransactionDebit  WalletTransactionType = "debit"
+)
+
+// Reasons recorded on wallet ledger entries
+const (
+	WalletReasonRedemption       = "redemption"        // channel points redeemed on Twitch
+	WalletReasonStatUpgrade      = "stat_upgrade"      // stat points bought with the wallet
+	WalletReasonMerchantPurchase = "merchant_purchase" // item bought from a merchant event
+	WalletReasonCombatReward     = "combat_reward"     // points won in a fight
+	WalletReasonRefund           = "refund"            // reversal of a failed debit
+	WalletReasonDuelWager        = "duel_wager"        // wager escrowed for a duel challenge
+	WalletReasonDuelPayout       = "duel_payout"       // escrowed wagers paid to the duel winner
+	WalletReasonRaidReward       = "raid_reward"       // share of a defeated raid boss's reward pool
+	WalletReasonQuestFee         = "quest_fee"         // channel point cost of accepting a quest
+	WalletReasonQuestReward      = "quest_reward"      // points granted for completing a quest
+	WalletReasonAdjustment       = "admin_adjustment"  // manual correction by the broadcaster
+	WalletReasonRedemptionUndo   = "redemption_undo"   // reversal of a redemption whose game action failed
+)
+
+// Reference types linking a ledger entry to the record that caused it
+const (
+	ReferenceMerchantEventItem = "merchant_event_item"
+	ReferenceCombatLog         = "combat_log"
+	ReferenceStatUpgrade       = "stat_upgrade"
+	ReferenceTwitchRedemption  = "twitch_redemption"
+	ReferenceDuelChallenge     = "duel_challenge"
+	ReferenceRaidBoss          = "raid_boss"
+	ReferenceQuest             = "quest"
+)
+
+// Wallet holds a character's spendable channel point balance
+type Wallet struct {
+	CharacterID    int       `json:"character_id" db:"character_id"`
+	Balance        int       `json:"balance" db:"balance"`
+	LifetimeEarned int       `json:"lifetime_earned" db:"lifetime_earned"`
+	LifetimeSpent  int       `json:"lifetime_spent" db:"lifetime_spent"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WalletTransaction is an append-only ledger entry for a wallet
+type WalletTransaction struct {
+	ID            int                   `json:"id" db:"id"`
+	CharacterID   int                   `json:"character_id" db:"character_id"`
+	Type          WalletTransactionType `json:"type" db:"type"`
+	Amount        int                   `json:"amount" db:"amount"`
+	BalanceAfter  int                   `json:"balance_after" db:"balance_after"`
+	Reason        string                `json:"reason" db:"reason"`
+	ReferenceType string                `json:"reference_type,omitempty" db:"reference_type"`
+	ReferenceID   string                `json:"reference_id,omitempty" db:"reference_id"`
+	CreatedAt     time.Time             `json:"created_at" db:"created_at"`
+}
+
+// WalletCreditRequest represents a request to deposit channel points into a wallet
+type WalletCreditRequest struct {
+	Amount        int    `json:"amount" binding:"required"`
+	Reason        string `json:"reason,omitempty"` // redemption (default) or admin_adjustment
+	ReferenceType string `json:"reference_type,omitempty"`
+	ReferenceID   string `json:"reference_id,omitempty"`
+}
+
+// SignedAmount returns the amount as a balance delta (negative for debits)
+func (t *WalletTransaction) SignedAmount() int {
+	if t.Type == TransactionDebit {
+		return -t.Amount
+	}
+	return t.Amount
+}
@@ -0,0 +1,357 @@
+// Package obs drives OBS Studio through its WebSocket v5 API.
+//
+// A Client keeps a connection to OBS open, authenticating with the configured password
+// and reconnecting with backoff whenever the connection drops. A Dispatcher turns game
+// events into OBS requests according to configured rules, such as switching scenes,
+// showing a source for a few seconds or setting the text of a text source.
+package obs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+	"twitch-rpg/internal/websocket"
+)
+
+const (
+	// connectTimeout bounds dialing OBS and completing the Hello/Identify handshake
+	connectTimeout = 10 * time.Second
+	// defaultMinBackoff and defaultMaxBackoff bound the delay between reconnect attempts
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// ErrNotConnected is returned by requests while the client has no connection to OBS
+var ErrNotConnected = errors.New("obs: not connected")
+
+// ErrAuthenticationFailed is returned when OBS rejects the password
+var ErrAuthenticationFailed = errors.New("obs: authentication failed")
+
+// RequestError is returned when OBS could not perform a request
+type RequestError struct {
+	RequestType string
+	Status      RequestStatus
+}
+
+func (e *RequestError) Error() string {
+	if e.Status.Comment == "" {
+		return fmt.Sprintf("obs: %s failed with code %d", e.RequestType, e.Status.Code)
+	}
+	return fmt.Sprintf("obs: %s failed with code %d: %s", e.RequestType, e.Status.Code, e.Status.Comment)
+}
+
+type result struct {
+	data json.RawMessage
+	err  error
+}
+
+// Client is a reconnecting OBS WebSocket v5 client. Requests fail fast with
+// ErrNotConnected while OBS is unreachable instead of queueing, since overlay actions
+// are only meaningful when they happen right away.
+type Client struct {
+	url      string
+	password string
+
+	// Logf logs connection changes; it defaults to log.Printf
+	Logf func(format string, args ...any)
+	// MinBackoff and MaxBackoff bound the delay between reconnect attempts, which
+	// doubles after every failed attempt
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	mutex   sync.Mutex
+	conn    *websocket.Conn
+	ready   chan struct{} // closed while connected
+	pending map[string]chan result
+	nextID  uint64
+}
+
+// NewClient creates a client for the OBS WebSocket server at url, e.g. ws://localhost:4455.
+// An empty password skips authentication. Call Run to connect.
+func NewClient(url, password string) *Client {
+	return &Client{
+		url:        url,
+		password:   password,
+		Logf:       log.Printf,
+		MinBackoff: defaultMinBackoff,
+		MaxBackoff: defaultMaxBackoff,
+		ready:      make(chan struct{}),
+		pending:    make(map[string]chan result),
+	}
+}
+
+// Run connects to OBS and keeps reconnecting until ctx is done
+func (c *Client) Run(ctx context.Context) {
+	backoff := c.MinBackoff
+	for {
+		conn, err := c.connect(ctx)
+		if err == nil {
+			c.Logf("Connected to OBS at %s", c.url)
+			backoff = c.MinBackoff
+			err = c.serve(ctx, conn)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		c.Logf("OBS connection to %s failed: %v; retrying in %s", c.url, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, c.MaxBackoff)
+	}
+}
+
+// Connected reports whether the client is connected and identified
+func (c *Client) Connected() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.conn != nil
+}
+
+// WaitConnected blocks until the client is connected or ctx is done
+func (c *Client) WaitConnected(ctx context.Context) error {
+	c.mutex.Lock()
+	ready := c.ready
+	c.mutex.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Request performs a single request and returns its response data
+func (c *Client) Request(ctx context.Context, requestType string, requestData any) (json.RawMessage, error) {
+	id, responses, err := c.send(OpRequest, func(id string) any {
+		return Request{RequestType: requestType, RequestID: id, RequestData: requestData}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := c.await(ctx, id, responses)
+	if err != nil {
+		return nil, err
+	}
+
+	var response RequestResponse
+	if err := json.Unmarshal(d, &response); err != nil {
+		return nil, fmt.Errorf("obs: invalid response to %s: %v", requestType, err)
+	}
+	if !response.RequestStatus.Result {
+		return nil, &RequestError{RequestType: requestType, Status: response.RequestStatus}
+	}
+	return response.ResponseData, nil
+}
+
+// Batch performs several requests in one message, in order. The results are in request
+// order; when haltOnFailure is set, requests after the first failure are not performed
+// and have no result.
+func (c *Client) Batch(ctx context.Context, requests []Request, haltOnFailure bool) ([]RequestResponse, error) {
+	id, responses, err := c.send(OpRequestBatch, func(id string) any {
+		return RequestBatch{
+			RequestID:     id,
+			HaltOnFailure: haltOnFailure,
+			ExecutionType: ExecutionSerialRealtime,
+			Requests:      requests,
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := c.await(ctx, id, responses)
+	if err != nil {
+		return nil, err
+	}
+
+	var response RequestBatchResponse
+	if err := json.Unmarshal(d, &response); err != nil {
+		return nil, fmt.Errorf("obs: invalid batch response: %v", err)
+	}
+	return response.Results, nil
+}
+
+// send registers a pending request and writes it to the connection
+func (c *Client) send(op OpCode, build func(id string) any) (string, chan result, error) {
+	c.mutex.Lock()
+	conn := c.conn
+	if conn == nil {
+		c.mutex.Unlock()
+		return "", nil, ErrNotConnected
+	}
+	c.nextID++
+	id := strconv.FormatUint(c.nextID, 10)
+	responses := make(chan result, 1)
+	c.pending[id] = responses
+	c.mutex.Unlock()
+
+	message, err := encodeMessage(op, build(id))
+	if err == nil {
+		err = conn.WriteMessage(websocket.OpText, message)
+	}
+	if err != nil {
+		c.forget(id)
+		return "", nil, fmt.Errorf("obs: failed to send request: %v", err)
+	}
+	return id, responses, nil
+}
+
+func (c *Client) await(ctx context.Context, id string, responses chan result) (json.RawMessage, error) {
+	select {
+	case res := <-responses:
+		return res.data, res.err
+	case <-ctx.Done():
+		c.forget(id)
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Client) forget(id string) {
+	c.mutex.Lock()
+	delete(c.pending, id)
+	c.mutex.Unlock()
+}
+
+// connect dials OBS and completes the Hello/Identify handshake
+func (c *Client) connect(ctx context.Context) (*websocket.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
+
+	conn, err := websocket.Dial(ctx, c.url, Subprotocol)
+	if err != nil {
+		return nil, err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetReadDeadline(deadline)
+
+	identified, err := c.identify(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	c.mutex.Lock()
+	c.conn = conn
+	close(c.ready)
+	c.mutex.Unlock()
+
+	if identified.NegotiatedRPCVersion != RPCVersion {
+		c.Logf("OBS negotiated RPC version %d", identified.NegotiatedRPCVersion)
+	}
+	return conn, nil
+}
+
+func (c *Client) identify(conn *websocket.Conn) (*Identified, error) {
+	var hello Hello
+	if err := readMessage(conn, OpHello, &hello); err != nil {
+		return nil, err
+	}
+
+	identify := Identify{RPCVersion: RPCVersion}
+	if hello.Authentication != nil {
+		if c.password == "" {
+			return nil, fmt.Errorf("%w: OBS requires a password", ErrAuthenticationFailed)
+		}
+		identify.Authentication = AuthenticationString(c.password, *hello.Authentication)
+	}
+	message, err := encodeMessage(OpIdentify, identify)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.WriteMessage(websocket.OpText, message); err != nil {
+		return nil, err
+	}
+
+	var identified Identified
+	if err := readMessage(conn, OpIdentified, &identified); err != nil {
+		var closeErr *websocket.CloseError
+		if errors.As(err, &closeErr) && closeErr.Code == CloseAuthenticationFailed {
+			return nil, ErrAuthenticationFailed
+		}
+		return nil, err
+	}
+	return &identified, nil
+}
+
+// serve routes responses to their pending requests until the connection fails
+func (c *Client) serve(ctx context.Context, conn *websocket.Conn) error {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var err error
+	for {
+		var message Message
+		if message, err = nextMessage(conn); err != nil {
+			break
+		}
+
+		switch message.Op {
+		case OpRequestResponse, OpRequestBatchResponse:
+			var envelope struct {
+				RequestID string `json:"requestId"`
+			}
+			if json.Unmarshal(message.D, &envelope) != nil {
+				continue
+			}
+			c.mutex.Lock()
+			responses, ok := c.pending[envelope.RequestID]
+			delete(c.pending, envelope.RequestID)
+			c.mutex.Unlock()
+			if ok {
+				responses <- result{data: message.D}
+			}
+		}
+	}
+
+	conn.Close()
+	c.mutex.Lock()
+	c.conn = nil
+	c.ready = make(chan struct{})
+	for id, responses := range c.pending {
+		responses <- result{err: fmt.Errorf("%w: %v", ErrNotConnected, err)}
+		delete(c.pending, id)
+	}
+	c.mutex.Unlock()
+	return err
+}
+
+func nextMessage(conn *websocket.Conn) (Message, error) {
+	var message Message
+	for {
+		opcode, payload, err := conn.ReadMessage()
+		if err != nil {
+			return message, err
+		}
+		if opcode != websocket.OpText {
+			continue
+		}
+		if err := json.Unmarshal(payload, &message); err != nil {
+			return message, fmt.Errorf("obs: invalid message: %v", err)
+		}
+		return message, nil
+	}
+}
+
+// readMessage reads the next message and decodes it, which must have the given opcode
+func readMessage(conn *websocket.Conn, op OpCode, data any) error {
+	message, err := nextMessage(conn)
+	if err != nil {
+		return err
+	}
+	if message.Op != op {
+		return fmt.Errorf("obs: expected message %d, got %d", op, message.Op)
+	}
+	return json.Unmarshal(message.D, data)
+}
@@ -0,0 +1,207 @@
+package obs_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"twitch-rpg/internal/obs"
+	"twitch-rpg/internal/obs/obstest"
+)
+
+// logRecorder collects a client's log lines
+type logRecorder struct {
+	mutex sync.Mutex
+	lines []string
+}
+
+func (l *logRecorder) logf(format string, args ...any) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func (l *logRecorder) contains(text string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for _, line := range l.lines {
+		if strings.Contains(line, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// startClient runs a client against server until the test ends and waits for it to connect
+func startClient(t *testing.T, server *obstest.Server, password string) *obs.Client {
+	t.Helper()
+	client := obs.NewClient(server.URL, password)
+	client.Logf = t.Logf
+	client.MinBackoff, client.MaxBackoff = 10*time.Millisecond, 50*time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		client.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer waitCancel()
+	if err := client.WaitConnected(waitCtx); err != nil {
+		t.Fatalf("WaitConnected: %v", err)
+	}
+	return client
+}
+
+func eventually(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	server := obstest.NewServer("hunter2")
+	defer server.Close()
+
+	client := startClient(t, server, "hunter2")
+	if !client.Connected() || server.Identified() != 1 {
+		t.Fatalf("Connected = %v, identified clients = %d; want one authenticated client", client.Connected(), server.Identified())
+	}
+
+	for password, want := range map[string]string{"wrong": "authentication failed", "": "OBS requires a password"} {
+		rejected := obs.NewClient(server.URL, password)
+		logs := &logRecorder{}
+		rejected.Logf = logs.logf
+		rejected.MinBackoff, rejected.MaxBackoff = time.Hour, time.Hour
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			rejected.Run(ctx)
+			close(done)
+		}()
+		eventually(t, "the rejected connection to be logged", func() bool { return logs.contains(want) })
+		cancel()
+		<-done
+
+		if rejected.Connected() || server.Identified() != 1 {
+			t.Fatalf("password %q: Connected = %v, identified clients = %d; want it rejected", password, rejected.Connected(), server.Identified())
+		}
+		if _, err := rejected.Request(context.Background(), "GetVersion", nil); !errors.Is(err, obs.ErrNotConnected) {
+			t.Fatalf("password %q: Request = %v; want ErrNotConnected", password, err)
+		}
+	}
+}
+
+func TestRequest(t *testing.T) {
+	server := obstest.NewServer("")
+	defer server.Close()
+	client := startClient(t, server, "")
+	ctx := context.Background()
+
+	if _, err := client.Request(ctx, "SetCurrentProgramScene", map[string]any{"sceneName": "Boss Fight"}); err != nil {
+		t.Fatalf("SetCurrentProgramScene: %v", err)
+	}
+	data, err := client.Request(ctx, "GetCurrentProgramScene", nil)
+	var scene struct {
+		CurrentProgramSceneName string `json:"currentProgramSceneName"`
+	}
+	if err != nil || json.Unmarshal(data, &scene) != nil || scene.CurrentProgramSceneName != "Boss Fight" {
+		t.Fatalf("GetCurrentProgramScene = %s, %v; want Boss Fight", data, err)
+	}
+
+	server.Handle("SetInputSettings", func(json.RawMessage) (*obs.RequestStatus, any) {
+		return &obs.RequestStatus{Code: obstest.StatusResourceNotFound, Comment: "No source was found"}, nil
+	})
+	_, err = client.Request(ctx, "SetInputSettings", map[string]any{"inputName": "missing"})
+	var requestErr *obs.RequestError
+	if !errors.As(err, &requestErr) || requestErr.RequestType != "SetInputSettings" || requestErr.Status.Code != obstest.StatusResourceNotFound {
+		t.Fatalf("SetInputSettings = %v; want a RequestError with code %d", err, obstest.StatusResourceNotFound)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 3 || requests[0].RequestType != "SetCurrentProgramScene" || requests[0].Batch ||
+		!strings.Contains(string(requests[0].RequestData), `"Boss Fight"`) {
+		t.Fatalf("server requests = %+v", requests)
+	}
+
+	// A request nobody answers gives up with its context
+	server.Handle("Sleep", func(json.RawMessage) (*obs.RequestStatus, any) {
+		time.Sleep(200 * time.Millisecond)
+		return nil, nil
+	})
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Request(short, "Sleep", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Sleep = %v; want DeadlineExceeded", err)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	server := obstest.NewServer("")
+	defer server.Close()
+	client := startClient(t, server, "")
+	server.Handle("Fail", func(json.RawMessage) (*obs.RequestStatus, any) {
+		return &obs.RequestStatus{Code: obstest.StatusUnknownRequest}, nil
+	})
+
+	requests := []obs.Request{
+		{RequestType: "GetSceneItemId", RequestData: map[string]any{"sceneName": "Scene", "sourceName": "Banner"}},
+		{RequestType: "Fail"},
+		{RequestType: "SetCurrentProgramScene", RequestData: map[string]any{"sceneName": "Victory"}},
+	}
+
+	results, err := client.Batch(context.Background(), requests, true)
+	if err != nil || len(results) != 2 || !results[0].RequestStatus.Result || results[1].RequestStatus.Result {
+		t.Fatalf("Batch(halt) = %+v, %v; want a success and the failure", results, err)
+	}
+	var item struct {
+		SceneItemID int `json:"sceneItemId"`
+	}
+	if json.Unmarshal(results[0].ResponseData, &item) != nil || item.SceneItemID == 0 {
+		t.Fatalf("GetSceneItemId result = %s; want a scene item ID", results[0].ResponseData)
+	}
+	if server.CurrentScene() == "Victory" {
+		t.Fatalf("the batch went on after the failure")
+	}
+
+	results, err = client.Batch(context.Background(), requests, false)
+	if err != nil || len(results) != 3 || results[2].RequestType != "SetCurrentProgramScene" || server.CurrentScene() != "Victory" {
+		t.Fatalf("Batch(no halt) = %+v, %v; want all three performed", results, err)
+	}
+	for _, request := range server.Requests() {
+		if !request.Batch {
+			t.Fatalf("request %s was not received in a batch", request.RequestType)
+		}
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	server := obstest.NewServer("hunter2")
+	defer server.Close()
+	client := startClient(t, server, "hunter2")
+
+	server.DropConnections()
+	eventually(t, "the client to reconnect", func() bool { return server.Identified() == 2 && client.Connected() })
+
+	if _, err := client.Request(context.Background(), "SetCurrentProgramScene", map[string]any{"sceneName": "Back"}); err != nil {
+		t.Fatalf("Request after reconnecting: %v", err)
+	}
+	if server.CurrentScene() != "Back" {
+		t.Fatalf("scene = %q; want the request performed on the new connection", server.CurrentScene())
+	}
+}
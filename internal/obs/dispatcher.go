@@ -0,0 +1,361 @@
+package obs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+const (
+	// dispatchQueueSize is how many events wait for OBS before new ones are dropped
+	dispatchQueueSize = 64
+	// dispatchTimeout bounds the OBS requests made for one event or revert
+	dispatchTimeout = 5 * time.Second
+)
+
+// OBS request types used by the dispatcher
+const (
+	requestGetCurrentProgramScene = "GetCurrentProgramScene"
+	requestSetCurrentProgramScene = "SetCurrentProgramScene"
+	requestGetSceneItemID         = "GetSceneItemId"
+	requestSetSceneItemEnabled    = "SetSceneItemEnabled"
+	requestSetInputSettings       = "SetInputSettings"
+)
+
+// sceneItem identifies a source within a scene
+type sceneItem struct {
+	scene, source string
+}
+
+// Dispatcher performs the configured OBS actions for game events. Events are queued and
+// handled one at a time so a slow or unreachable OBS never holds up the game.
+type Dispatcher struct {
+	client *Client
+	rules  *Rules
+	queue  chan *models.GameEvent
+
+	// Logf logs dispatch failures; it defaults to log.Printf
+	Logf func(format string, args ...any)
+
+	mutex       sync.Mutex
+	sceneItems  map[sceneItem]int // scene item IDs looked up so far
+	generations map[string]uint64 // latest change per target, so stale reverts are skipped
+	reverts     map[string]*scheduledRevert
+}
+
+// scheduledRevert is a timed action waiting to be undone
+type scheduledRevert struct {
+	timer   *time.Timer
+	request Request
+}
+
+// NewDispatcher creates a dispatcher sending the actions of rules through client
+func NewDispatcher(client *Client, rules *Rules) *Dispatcher {
+	return &Dispatcher{
+		client:      client,
+		rules:       rules,
+		queue:       make(chan *models.GameEvent, dispatchQueueSize),
+		Logf:        log.Printf,
+		sceneItems:  make(map[sceneItem]int),
+		generations: make(map[string]uint64),
+		reverts:     make(map[string]*scheduledRevert),
+	}
+}
+
+// Enqueue queues an event for Run without blocking. Events are dropped while the queue
+// is full, since late overlay actions are worse than missing ones.
+func (d *Dispatcher) Enqueue(event *models.GameEvent) {
+	select {
+	case d.queue <- event:
+	default:
+		d.Logf("OBS dispatch queue is full, dropping %s event", event.EventType)
+	}
+}
+
+// Run handles queued events until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			d.mutex.Lock()
+			for _, scheduled := range d.reverts {
+				scheduled.timer.Stop()
+			}
+			d.mutex.Unlock()
+			return
+		case event := <-d.queue:
+			dispatchCtx, cancel := context.WithTimeout(ctx, dispatchTimeout)
+			if err := d.Dispatch(dispatchCtx, event); err != nil {
+				d.Logf("Failed to send %s event to OBS: %v", event.EventType, err)
+			}
+			cancel()
+		}
+	}
+}
+
+// pendingAction is an action ready to be sent, with the request undoing it
+type pendingAction struct {
+	action  Action
+	target  string
+	request Request
+	revert  *Request
+}
+
+// Dispatch performs the actions configured for an event. Actions that cannot be
+// prepared, such as a text template failing on the event data, are logged and skipped.
+func (d *Dispatcher) Dispatch(ctx context.Context, event *models.GameEvent) error {
+	actions := d.rules.Actions(event.EventType)
+	if len(actions) == 0 {
+		return nil
+	}
+	if !d.client.Connected() {
+		return ErrNotConnected
+	}
+
+	data := eventTemplateData(event)
+	if err := d.resolveSceneItems(ctx, actions); err != nil {
+		return err
+	}
+	previousScene, err := d.currentSceneIfNeeded(ctx, actions)
+	if err != nil {
+		return err
+	}
+
+	pending := []pendingAction{}
+	for _, action := range actions {
+		prepared, err := d.prepare(action, data, previousScene)
+		if err != nil {
+			d.Logf("Skipping OBS %s action for %s event: %v", action.Type, event.EventType, err)
+			continue
+		}
+		pending = append(pending, prepared)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	requests := make([]Request, len(pending))
+	for i, prepared := range pending {
+		requests[i] = prepared.request
+	}
+	results, err := d.client.Batch(ctx, requests, false)
+	if err != nil {
+		return err
+	}
+
+	for i, prepared := range pending {
+		if i >= len(results) || !results[i].RequestStatus.Result {
+			status := RequestStatus{}
+			if i < len(results) {
+				status = results[i].RequestStatus
+			}
+			d.Logf("OBS %s action for %s event failed: %v", prepared.action.Type, event.EventType,
+				&RequestError{RequestType: prepared.request.RequestType, Status: status})
+			if prepared.action.Type == ActionSetSourceVisibility {
+				// The item may have been removed or recreated; look it up again next time
+				d.mutex.Lock()
+				delete(d.sceneItems, sceneItem{prepared.action.Scene, prepared.action.Source})
+				d.mutex.Unlock()
+			}
+			continue
+		}
+		d.scheduleRevert(prepared)
+	}
+	return nil
+}
+
+// prepare builds the request for an action and, when it has a duration, its revert
+func (d *Dispatcher) prepare(action Action, data map[string]any, previousScene string) (pendingAction, error) {
+	prepared := pendingAction{action: action}
+
+	switch action.Type {
+	case ActionSwitchScene:
+		prepared.target = "scene"
+		prepared.request = Request{
+			RequestType: requestSetCurrentProgramScene,
+			RequestData: map[string]any{"sceneName": action.Scene},
+		}
+		if action.Duration() > 0 {
+			// While an earlier timed switch is showing, return to the scene it came from
+			d.mutex.Lock()
+			scheduled, ok := d.reverts[prepared.target]
+			d.mutex.Unlock()
+			if ok {
+				prepared.revert = &scheduled.request
+			} else if previousScene != "" && previousScene != action.Scene {
+				prepared.revert = &Request{
+					RequestType: requestSetCurrentProgramScene,
+					RequestData: map[string]any{"sceneName": previousScene},
+				}
+			}
+		}
+
+	case ActionSetSourceVisibility:
+		d.mutex.Lock()
+		id, ok := d.sceneItems[sceneItem{action.Scene, action.Source}]
+		d.mutex.Unlock()
+		if !ok {
+			return prepared, fmt.Errorf("source %q not found in scene %q", action.Source, action.Scene)
+		}
+		prepared.target = "item\x00" + action.Scene + "\x00" + action.Source
+		prepared.request = sceneItemEnabledRequest(action.Scene, id, action.visible())
+		if action.Duration() > 0 {
+			revert := sceneItemEnabledRequest(action.Scene, id, !action.visible())
+			prepared.revert = &revert
+		}
+
+	case ActionSetText:
+		text, err := action.renderText(data)
+		if err != nil {
+			return prepared, err
+		}
+		prepared.target = "text\x00" + action.Source
+		prepared.request = textRequest(action.Source, text)
+		if action.Duration() > 0 {
+			revert := textRequest(action.Source, "")
+			prepared.revert = &revert
+		}
+	}
+
+	return prepared, nil
+}
+
+// scheduleRevert undoes a timed action after its duration unless a later action changed
+// the same target in the meantime
+func (d *Dispatcher) scheduleRevert(prepared pendingAction) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.generations[prepared.target]++
+	generation := d.generations[prepared.target]
+	if scheduled, ok := d.reverts[prepared.target]; ok {
+		scheduled.timer.Stop()
+		delete(d.reverts, prepared.target)
+	}
+	if prepared.revert == nil {
+		return
+	}
+
+	revert := *prepared.revert
+	scheduled := &scheduledRevert{request: revert}
+	d.reverts[prepared.target] = scheduled
+	scheduled.timer = time.AfterFunc(prepared.action.Duration(), func() {
+		d.mutex.Lock()
+		current := d.generations[prepared.target] == generation
+		if current {
+			delete(d.reverts, prepared.target)
+		}
+		d.mutex.Unlock()
+		if !current {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), dispatchTimeout)
+		defer cancel()
+		if _, err := d.client.Request(ctx, revert.RequestType, revert.RequestData); err != nil {
+			d.Logf("Failed to revert OBS %s action: %v", prepared.action.Type, err)
+		}
+	})
+}
+
+// resolveSceneItems looks up the scene item IDs of visibility actions not seen before
+func (d *Dispatcher) resolveSceneItems(ctx context.Context, actions []Action) error {
+	missing := []sceneItem{}
+	d.mutex.Lock()
+	for _, action := range actions {
+		item := sceneItem{action.Scene, action.Source}
+		if _, ok := d.sceneItems[item]; action.Type == ActionSetSourceVisibility && !ok {
+			missing = append(missing, item)
+		}
+	}
+	d.mutex.Unlock()
+	if len(missing) == 0 {
+		return nil
+	}
+
+	requests := make([]Request, len(missing))
+	for i, item := range missing {
+		requests[i] = Request{
+			RequestType: requestGetSceneItemID,
+			RequestData: map[string]any{"sceneName": item.scene, "sourceName": item.source},
+		}
+	}
+	results, err := d.client.Batch(ctx, requests, false)
+	if err != nil {
+		return err
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for i, item := range missing {
+		if i >= len(results) || !results[i].RequestStatus.Result {
+			continue
+		}
+		var response struct {
+			SceneItemID int `json:"sceneItemId"`
+		}
+		if json.Unmarshal(results[i].ResponseData, &response) == nil {
+			d.sceneItems[item] = response.SceneItemID
+		}
+	}
+	return nil
+}
+
+// currentSceneIfNeeded returns the program scene when a timed scene switch has to
+// return to it later
+func (d *Dispatcher) currentSceneIfNeeded(ctx context.Context, actions []Action) (string, error) {
+	needed := false
+	for _, action := range actions {
+		needed = needed || (action.Type == ActionSwitchScene && action.Duration() > 0)
+	}
+	if !needed {
+		return "", nil
+	}
+
+	data, err := d.client.Request(ctx, requestGetCurrentProgramScene, nil)
+	if err != nil {
+		return "", err
+	}
+	var response struct {
+		CurrentProgramSceneName string `json:"currentProgramSceneName"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return "", fmt.Errorf("invalid %s response: %v", requestGetCurrentProgramScene, err)
+	}
+	return response.CurrentProgramSceneName, nil
+}
+
+func sceneItemEnabledRequest(scene string, id int, enabled bool) Request {
+	return Request{
+		RequestType: requestSetSceneItemEnabled,
+		RequestData: map[string]any{"sceneName": scene, "sceneItemId": id, "sceneItemEnabled": enabled},
+	}
+}
+
+func textRequest(source, text string) Request {
+	return Request{
+		RequestType: requestSetInputSettings,
+		RequestData: map[string]any{"inputName": source, "inputSettings": map[string]any{"text": text}, "overlay": true},
+	}
+}
+
+// eventTemplateData is the data set_text templates are executed with
+func eventTemplateData(event *models.GameEvent) map[string]any {
+	// Numbers stay json.Number so large values print as written, not as 1e+06
+	data := map[string]any{}
+	decoder := json.NewDecoder(bytes.NewReader(event.EventData))
+	decoder.UseNumber()
+	decoder.Decode(&data)
+
+	data["event_id"] = event.ID
+	data["event_type"] = string(event.EventType)
+	if event.CharacterID != nil {
+		data["character_id"] = *event.CharacterID
+	}
+	return data
+}
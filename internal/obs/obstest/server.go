@@ -0,0 +1,317 @@
+// Package obstest provides a local stand-in for the OBS WebSocket v5 server, so the OBS
+// client and dispatcher can be exercised without running OBS.
+//
+// The server performs the Hello/Identify handshake, including password authentication,
+// answers requests and request batches, and records every request it received. By
+// default every request succeeds with empty response data, GetSceneItemId returns a
+// stable ID per scene and source, and GetCurrentProgramScene returns the scene last set
+// with SetCurrentProgramScene.
+package obstest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+	"twitch-rpg/internal/obs"
+	"twitch-rpg/internal/websocket"
+)
+
+// Status codes of request responses used by the server
+const (
+	StatusSuccess          = 100
+	StatusUnknownRequest   = 204
+	StatusResourceNotFound = 600
+)
+
+// HandlerFunc answers a request. A nil status means success.
+type HandlerFunc func(requestData json.RawMessage) (status *obs.RequestStatus, responseData any)
+
+// Request is a request the server received
+type Request struct {
+	RequestType string
+	RequestData json.RawMessage
+	Batch       bool // received as part of a request batch
+}
+
+// Server is a fake OBS WebSocket server
+type Server struct {
+	// URL is the ws:// address of the server
+	URL string
+
+	password string
+	server   *httptest.Server
+
+	mutex      sync.Mutex
+	handlers   map[string]HandlerFunc
+	requests   []Request
+	conns      map[*websocket.Conn]bool
+	identified int
+	scene      string
+	sceneItems map[string]int
+	notify     chan struct{}
+}
+
+// NewServer starts a server. With a password, clients must authenticate.
+func NewServer(password string) *Server {
+	s := &Server{
+		password:   password,
+		handlers:   make(map[string]HandlerFunc),
+		conns:      make(map[*websocket.Conn]bool),
+		scene:      "Scene",
+		sceneItems: make(map[string]int),
+		notify:     make(chan struct{}),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.URL = "ws" + strings.TrimPrefix(s.server.URL, "http")
+	return s
+}
+
+// Close disconnects every client and stops the server
+func (s *Server) Close() {
+	s.DropConnections()
+	s.server.Close()
+}
+
+// Handle overrides the answer to a request type
+func (s *Server) Handle(requestType string, handler HandlerFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[requestType] = handler
+}
+
+// Requests returns the requests received so far, in order
+func (s *Server) Requests() []Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// WaitForRequests waits until at least n requests were received and returns them. It
+// reports false when the timeout passed first.
+func (s *Server) WaitForRequests(n int, timeout time.Duration) ([]Request, bool) {
+	deadline := time.After(timeout)
+	for {
+		s.mutex.Lock()
+		requests := append([]Request(nil), s.requests...)
+		notify := s.notify
+		s.mutex.Unlock()
+		if len(requests) >= n {
+			return requests, true
+		}
+
+		select {
+		case <-notify:
+		case <-deadline:
+			return requests, false
+		}
+	}
+}
+
+// Identified returns how many clients completed the handshake so far
+func (s *Server) Identified() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.identified
+}
+
+// CurrentScene returns the program scene last set by a client
+func (s *Server) CurrentScene() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.scene
+}
+
+// DropConnections closes every open client connection, as when OBS restarts
+func (s *Server) DropConnections() {
+	s.mutex.Lock()
+	conns := make([]*websocket.Conn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.mutex.Unlock()
+
+	for _, conn := range conns {
+		conn.CloseWithStatus(1001, "going away")
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, obs.Subprotocol)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	if !s.handshake(conn) {
+		return
+	}
+
+	s.mutex.Lock()
+	s.conns[conn] = true
+	s.identified++
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, conn)
+		s.mutex.Unlock()
+	}()
+
+	for {
+		var message obs.Message
+		if err := readJSON(conn, &message); err != nil {
+			return
+		}
+
+		switch message.Op {
+		case obs.OpRequest:
+			var request obs.Request
+			if json.Unmarshal(message.D, &request) != nil {
+				return
+			}
+			writeJSON(conn, obs.OpRequestResponse, s.answer(request, false))
+
+		case obs.OpRequestBatch:
+			var batch struct {
+				RequestID     string `json:"requestId"`
+				HaltOnFailure bool   `json:"haltOnFailure"`
+				Requests      []struct {
+					RequestType string          `json:"requestType"`
+					RequestID   string          `json:"requestId"`
+					RequestData json.RawMessage `json:"requestData"`
+				} `json:"requests"`
+			}
+			if json.Unmarshal(message.D, &batch) != nil {
+				return
+			}
+			response := obs.RequestBatchResponse{RequestID: batch.RequestID, Results: []obs.RequestResponse{}}
+			for _, request := range batch.Requests {
+				result := s.answer(obs.Request{
+					RequestType: request.RequestType,
+					RequestID:   request.RequestID,
+					RequestData: request.RequestData,
+				}, true)
+				response.Results = append(response.Results, result)
+				if batch.HaltOnFailure && !result.RequestStatus.Result {
+					break
+				}
+			}
+			writeJSON(conn, obs.OpRequestBatchResponse, response)
+		}
+	}
+}
+
+// handshake sends Hello and checks the client's Identify
+func (s *Server) handshake(conn *websocket.Conn) bool {
+	hello := obs.Hello{OBSWebSocketVersion: "5.0.0-obstest", RPCVersion: obs.RPCVersion}
+	if s.password != "" {
+		hello.Authentication = &obs.Authentication{
+			Challenge: "+IxH4CnCiqpX1rM9scsNynZzbOe4KhDeYcTNS3PDaeY=",
+			Salt:      "lM1GncleQOaCu9lT1yeUZhFYnqhsLLP1G5lAGo3ixaI=",
+		}
+	}
+	if writeJSON(conn, obs.OpHello, hello) != nil {
+		return false
+	}
+
+	var message obs.Message
+	var identify obs.Identify
+	if readJSON(conn, &message) != nil || message.Op != obs.OpIdentify || json.Unmarshal(message.D, &identify) != nil {
+		conn.CloseWithStatus(4007, "not identified")
+		return false
+	}
+	if hello.Authentication != nil && identify.Authentication != obs.AuthenticationString(s.password, *hello.Authentication) {
+		conn.CloseWithStatus(obs.CloseAuthenticationFailed, "authentication failed")
+		return false
+	}
+
+	return writeJSON(conn, obs.OpIdentified, obs.Identified{NegotiatedRPCVersion: obs.RPCVersion}) == nil
+}
+
+// answer records a request and builds its response
+func (s *Server) answer(request obs.Request, batch bool) obs.RequestResponse {
+	data, _ := request.RequestData.(json.RawMessage)
+	if data == nil {
+		data, _ = json.Marshal(request.RequestData)
+	}
+
+	s.mutex.Lock()
+	s.requests = append(s.requests, Request{RequestType: request.RequestType, RequestData: data, Batch: batch})
+	close(s.notify)
+	s.notify = make(chan struct{})
+	handler, ok := s.handlers[request.RequestType]
+	s.mutex.Unlock()
+
+	var status *obs.RequestStatus
+	var responseData any
+	if ok {
+		status, responseData = handler(data)
+	} else {
+		status, responseData = s.defaultAnswer(request.RequestType, data)
+	}
+	if status == nil {
+		status = &obs.RequestStatus{Result: true, Code: StatusSuccess}
+	}
+
+	response := obs.RequestResponse{
+		RequestType:   request.RequestType,
+		RequestID:     request.RequestID,
+		RequestStatus: *status,
+	}
+	if responseData != nil {
+		response.ResponseData, _ = json.Marshal(responseData)
+	}
+	return response
+}
+
+func (s *Server) defaultAnswer(requestType string, data json.RawMessage) (*obs.RequestStatus, any) {
+	var fields struct {
+		SceneName  string `json:"sceneName"`
+		SourceName string `json:"sourceName"`
+	}
+	json.Unmarshal(data, &fields)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch requestType {
+	case "GetCurrentProgramScene":
+		return nil, map[string]any{"currentProgramSceneName": s.scene, "sceneName": s.scene}
+	case "SetCurrentProgramScene":
+		s.scene = fields.SceneName
+		return nil, nil
+	case "GetSceneItemId":
+		key := fields.SceneName + "\x00" + fields.SourceName
+		if _, ok := s.sceneItems[key]; !ok {
+			s.sceneItems[key] = len(s.sceneItems) + 1
+		}
+		return nil, map[string]any{"sceneItemId": s.sceneItems[key]}
+	}
+	return nil, nil
+}
+
+func readJSON(conn *websocket.Conn, message *obs.Message) error {
+	for {
+		opcode, payload, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if opcode == websocket.OpText {
+			return json.Unmarshal(payload, message)
+		}
+	}
+}
+
+func writeJSON(conn *websocket.Conn, op obs.OpCode, data any) error {
+	d, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(obs.Message{Op: op, D: d})
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.OpText, payload)
+}
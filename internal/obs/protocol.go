@@ -0,0 +1,123 @@
+package obs
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+)
+
+// Subprotocol is the WebSocket subprotocol of OBS WebSocket v5 with JSON messages
+const Subprotocol = "obswebsocket.json"
+
+// RPCVersion is the OBS WebSocket RPC version this client speaks
+const RPCVersion = 1
+
+// OpCode identifies the kind of an OBS WebSocket message
+type OpCode int
+
+const (
+	OpHello                OpCode = 0
+	OpIdentify             OpCode = 1
+	OpIdentified           OpCode = 2
+	OpReidentify           OpCode = 3
+	OpEvent                OpCode = 5
+	OpRequest              OpCode = 6
+	OpRequestResponse      OpCode = 7
+	OpRequestBatch         OpCode = 8
+	OpRequestBatchResponse OpCode = 9
+)
+
+// CloseAuthenticationFailed is the close status OBS sends after a wrong password
+const CloseAuthenticationFailed = 4009
+
+// Message is the envelope of every OBS WebSocket message
+type Message struct {
+	Op OpCode          `json:"op"`
+	D  json.RawMessage `json:"d"`
+}
+
+// Hello is the first message OBS sends after the connection opened
+type Hello struct {
+	OBSWebSocketVersion string          `json:"obsWebSocketVersion"`
+	RPCVersion          int             `json:"rpcVersion"`
+	Authentication      *Authentication `json:"authentication,omitempty"`
+}
+
+// Authentication is the challenge OBS sends when a password is set
+type Authentication struct {
+	Challenge string `json:"challenge"`
+	Salt      string `json:"salt"`
+}
+
+// Identify answers Hello with the client's RPC version and authentication string
+type Identify struct {
+	RPCVersion         int    `json:"rpcVersion"`
+	Authentication     string `json:"authentication,omitempty"`
+	EventSubscriptions int    `json:"eventSubscriptions"`
+}
+
+// Identified confirms a successful Identify
+type Identified struct {
+	NegotiatedRPCVersion int `json:"negotiatedRpcVersion"`
+}
+
+// Request asks OBS to perform a single request such as SetCurrentProgramScene
+type Request struct {
+	RequestType string `json:"requestType"`
+	RequestID   string `json:"requestId,omitempty"`
+	RequestData any    `json:"requestData,omitempty"`
+}
+
+// RequestStatus reports whether OBS performed a request
+type RequestStatus struct {
+	Result  bool   `json:"result"`
+	Code    int    `json:"code"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// RequestResponse is OBS's answer to a request
+type RequestResponse struct {
+	RequestType   string          `json:"requestType"`
+	RequestID     string          `json:"requestId"`
+	RequestStatus RequestStatus   `json:"requestStatus"`
+	ResponseData  json.RawMessage `json:"responseData,omitempty"`
+}
+
+// Execution types of request batches
+const (
+	ExecutionSerialRealtime = 0
+	ExecutionSerialFrame    = 1
+	ExecutionParallel       = 2
+)
+
+// RequestBatch asks OBS to perform several requests in one message
+type RequestBatch struct {
+	RequestID     string    `json:"requestId"`
+	HaltOnFailure bool      `json:"haltOnFailure,omitempty"`
+	ExecutionType int       `json:"executionType"`
+	Requests      []Request `json:"requests"`
+}
+
+// RequestBatchResponse holds the results of a batch in request order. Requests after a
+// failure are missing when the batch halted on it.
+type RequestBatchResponse struct {
+	RequestID string            `json:"requestId"`
+	Results   []RequestResponse `json:"results"`
+}
+
+// AuthenticationString computes the Identify authentication for a password and the
+// challenge and salt from Hello: base64(sha256(base64(sha256(password + salt)) + challenge))
+func AuthenticationString(password string, auth Authentication) string {
+	secret := sha256.Sum256([]byte(password + auth.Salt))
+	secretString := base64.StdEncoding.EncodeToString(secret[:])
+	response := sha256.Sum256([]byte(secretString + auth.Challenge))
+	return base64.StdEncoding.EncodeToString(response[:])
+}
+
+func encodeMessage(op OpCode, data any) ([]byte, error) {
+	d, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Message{Op: op, D: d})
+}
@@ -0,0 +1,168 @@
+package obs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// ActionType is something the dispatcher can make OBS do
+type ActionType string
+
+const (
+	ActionSwitchScene         ActionType = "switch_scene"          // make Scene the program scene
+	ActionSetSourceVisibility ActionType = "set_source_visibility" // show or hide Source in Scene
+	ActionSetText             ActionType = "set_text"              // set the text of the text source Source
+)
+
+// Action is one OBS change made when a matching game event happens.
+//
+// Text is a text/template executed with the event data, e.g. "{{.character_name}}
+// reached level {{.new_level}}!"; event_id, event_type and character_id are available
+// as well, and referring to a field the event does not have skips the action. With a
+// duration the change is reverted afterwards: the previous scene comes back, the source
+// is hidden (or shown) again, or the text is cleared.
+type Action struct {
+	Type            ActionType `json:"type"`
+	Scene           string     `json:"scene,omitempty"`
+	Source          string     `json:"source,omitempty"`
+	Visible         *bool      `json:"visible,omitempty"` // defaults to true
+	Text            string     `json:"text,omitempty"`
+	DurationSeconds int        `json:"duration_seconds,omitempty"`
+
+	template *template.Template
+}
+
+// Duration is how long the change lasts before it is reverted, zero for permanent changes
+func (a *Action) Duration() time.Duration {
+	return time.Duration(a.DurationSeconds) * time.Second
+}
+
+// visible reports whether a visibility action shows its source
+func (a *Action) visible() bool {
+	return a.Visible == nil || *a.Visible
+}
+
+// Rule maps a game event type to the actions it triggers, performed in order
+type Rule struct {
+	EventType models.GameEventType `json:"event_type"`
+	Actions   []Action             `json:"actions"`
+}
+
+// Rules configures how game events drive OBS
+type Rules struct {
+	Rules []Rule `json:"rules"`
+}
+
+// LoadRules reads and validates rules from a JSON file
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OBS rules: %v", err)
+	}
+	rules, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OBS rules in %s: %v", path, err)
+	}
+	return rules, nil
+}
+
+// ParseRules decodes and validates rules
+func ParseRules(data []byte) (*Rules, error) {
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+
+	rules := &Rules{}
+	if err := decoder.Decode(rules); err != nil {
+		return nil, err
+	}
+	if err := rules.validate(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *Rules) validate() error {
+	for i := range r.Rules {
+		rule := &r.Rules[i]
+		if !slices.Contains(models.GameEventTypes, rule.EventType) {
+			return fmt.Errorf("rule %d: unknown event type %q", i+1, rule.EventType)
+		}
+		if len(rule.Actions) == 0 {
+			return fmt.Errorf("rule %d: no actions", i+1)
+		}
+
+		for j := range rule.Actions {
+			action := &rule.Actions[j]
+			if err := action.validate(); err != nil {
+				return fmt.Errorf("rule %d (%s), action %d: %v", i+1, rule.EventType, j+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (a *Action) validate() error {
+	if a.DurationSeconds < 0 {
+		return fmt.Errorf("duration_seconds cannot be negative")
+	}
+
+	switch a.Type {
+	case ActionSwitchScene:
+		if a.Scene == "" {
+			return fmt.Errorf("switch_scene needs a scene")
+		}
+	case ActionSetSourceVisibility:
+		if a.Scene == "" || a.Source == "" {
+			return fmt.Errorf("set_source_visibility needs a scene and a source")
+		}
+	case ActionSetText:
+		if a.Source == "" {
+			return fmt.Errorf("set_text needs a source")
+		}
+		tmpl, err := template.New(a.Source).Option("missingkey=error").Parse(a.Text)
+		if err != nil {
+			return fmt.Errorf("invalid text template: %v", err)
+		}
+		a.template = tmpl
+	default:
+		return fmt.Errorf("unknown action type %q", a.Type)
+	}
+	return nil
+}
+
+// EventTypes lists the event types that have rules
+func (r *Rules) EventTypes() []models.GameEventType {
+	types := []models.GameEventType{}
+	for _, rule := range r.Rules {
+		if !slices.Contains(types, rule.EventType) {
+			types = append(types, rule.EventType)
+		}
+	}
+	return types
+}
+
+// Actions returns the actions of every rule for the event type, in configuration order
+func (r *Rules) Actions(eventType models.GameEventType) []Action {
+	actions := []Action{}
+	for _, rule := range r.Rules {
+		if rule.EventType == eventType {
+			actions = append(actions, rule.Actions...)
+		}
+	}
+	return actions
+}
+
+// renderText executes a set_text action's template with the event data
+func (a *Action) renderText(data map[string]any) (string, error) {
+	var text strings.Builder
+	if err := a.template.Execute(&text, data); err != nil {
+		return "", err
+	}
+	return text.String(), nil
+}
@@ -0,0 +1,164 @@
+// Package quest implements the quest requirements language and the schedule that
+// rotates daily and weekly quests.
+//
+// A quest's Requirements JSON is a list of typed conditions that must all be met.
+// Counter requirements (win_fights, merchant_purchase) count actions taken after
+// the quest was accepted; state requirements (reach_level, own_item_rarity) are
+// checked against the character whenever progress is advanced. Duels and hunts won
+// and raid bosses defeated all count towards win_fights. Progress is kept per
+// requirement in the character quest's Progress JSON.
+package quest
+
+import (
+	"encoding/json"
+	"fmt"
+	"twitch-rpg/internal/models"
+)
+
+// State is the character state that state requirements are checked against
+type State struct {
+	Level int
+	// ItemsByRarity counts the item units the character owns per rarity
+	ItemsByRarity map[models.ItemRarity]int
+}
+
+// ItemsAtLeast counts owned item units of the given rarity or better
+func (s State) ItemsAtLeast(rarity models.ItemRarity) int {
+	count := 0
+	for owned, units := range s.ItemsByRarity {
+		if owned.Rank() >= rarity.Rank() {
+			count += units
+		}
+	}
+	return count
+}
+
+// ParseRequirements decodes and validates a quest's Requirements JSON
+func ParseRequirements(data json.RawMessage) ([]models.QuestRequirement, error) {
+	var requirements []models.QuestRequirement
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &requirements); err != nil {
+			return nil, fmt.Errorf("invalid quest requirements: %v", err)
+		}
+	}
+	if err := ValidateRequirements(requirements); err != nil {
+		return nil, err
+	}
+	return requirements, nil
+}
+
+// ValidateRequirements checks that there is at least one requirement and that each
+// has the fields its type needs
+func ValidateRequirements(requirements []models.QuestRequirement) error {
+	if len(requirements) == 0 {
+		return fmt.Errorf("a quest needs at least one requirement")
+	}
+
+	for i, requirement := range requirements {
+		switch requirement.Type {
+		case models.RequirementWinFights, models.RequirementMerchantPurchase:
+			if requirement.Count < 1 {
+				return fmt.Errorf("requirement %d (%s) needs a positive count", i+1, requirement.Type)
+			}
+		case models.RequirementReachLevel:
+			if requirement.Level < 1 {
+				return fmt.Errorf("requirement %d (%s) needs a positive level", i+1, requirement.Type)
+			}
+		case models.RequirementOwnItemRarity:
+			if !models.ValidateItemRarity(string(requirement.Rarity)) {
+				return fmt.Errorf("requirement %d (%s) has unknown rarity %q", i+1, requirement.Type, requirement.Rarity)
+			}
+			if requirement.Count < 0 {
+				return fmt.Errorf("requirement %d (%s) cannot have a negative count", i+1, requirement.Type)
+			}
+		default:
+			return fmt.Errorf("requirement %d has unknown type %q", i+1, requirement.Type)
+		}
+	}
+	return nil
+}
+
+// ParseRewards decodes and validates a quest's Rewards JSON
+func ParseRewards(data json.RawMessage) (models.QuestRewards, error) {
+	var rewards models.QuestRewards
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &rewards); err != nil {
+			return rewards, fmt.Errorf("invalid quest rewards: %v", err)
+		}
+	}
+	if rewards.Experience < 0 || rewards.ChannelPoints < 0 {
+		return rewards, fmt.Errorf("quest rewards cannot be negative")
+	}
+	return rewards, nil
+}
+
+// NewProgress returns empty progress for a quest's requirements
+func NewProgress(requirements []models.QuestRequirement) models.QuestProgress {
+	progress := models.QuestProgress{Requirements: make([]models.RequirementProgress, len(requirements))}
+	for i, requirement := range requirements {
+		progress.Requirements[i] = models.RequirementProgress{
+			Type:   requirement.Type,
+			Target: requirement.Target(),
+		}
+	}
+	return progress
+}
+
+// ParseProgress decodes a character quest's Progress JSON. Progress that does not
+// line up with the requirements (missing, or the quest was edited) starts over.
+func ParseProgress(data json.RawMessage, requirements []models.QuestRequirement) models.QuestProgress {
+	var progress models.QuestProgress
+	if len(data) == 0 || json.Unmarshal(data, &progress) != nil || len(progress.Requirements) != len(requirements) {
+		return NewProgress(requirements)
+	}
+
+	for i, requirement := range requirements {
+		if progress.Requirements[i].Type != requirement.Type {
+			return NewProgress(requirements)
+		}
+		progress.Requirements[i].Target = requirement.Target()
+	}
+	return progress
+}
+
+// Advance counts action towards the matching counter requirements, re-checks the state
+// requirements against state and reports whether every requirement is now met
+func Advance(requirements []models.QuestRequirement, progress *models.QuestProgress, action models.QuestAction, state State) bool {
+	complete := true
+	for i, requirement := range requirements {
+		entry := &progress.Requirements[i]
+
+		switch requirement.Type {
+		case models.RequirementWinFights:
+			if action == models.QuestActionFightWon {
+				entry.Current++
+			}
+		case models.RequirementMerchantPurchase:
+			if action == models.QuestActionMerchantPurchase {
+				entry.Current++
+			}
+		case models.RequirementReachLevel:
+			entry.Current = state.Level
+		case models.RequirementOwnItemRarity:
+			entry.Current = state.ItemsAtLeast(requirement.Rarity)
+		}
+
+		// Counters stop at their target so the progress bar never overflows
+		if requirement.IsCounter() {
+			entry.Current = min(entry.Current, entry.Target)
+		}
+		entry.Met = entry.Current >= entry.Target
+		complete = complete && entry.Met
+	}
+	return complete
+}
+
+// NeedsInventory reports whether any requirement checks the character's items
+func NeedsInventory(requirements []models.QuestRequirement) bool {
+	for _, requirement := range requirements {
+		if requirement.Type == models.RequirementOwnItemRarity {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package quest_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/quest"
+)
+
+func TestParseRequirements(t *testing.T) {
+	for _, test := range []struct {
+		data string
+		want []models.QuestRequirement
+	}{
+		{`[{"type": "win_fights", "count": 3}]`, []models.QuestRequirement{{Type: models.RequirementWinFights, Count: 3}}},
+		{`[{"type": "reach_level", "level": 5}, {"type": "merchant_purchase", "count": 1}]`, []models.QuestRequirement{
+			{Type: models.RequirementReachLevel, Level: 5}, {Type: models.RequirementMerchantPurchase, Count: 1}}},
+		{`[{"type": "own_item_rarity", "rarity": "epic"}]`, []models.QuestRequirement{{Type: models.RequirementOwnItemRarity, Rarity: models.RarityEpic}}},
+		{`[{"type": "own_item_rarity", "rarity": "rare", "count": 2}]`, []models.QuestRequirement{{Type: models.RequirementOwnItemRarity, Rarity: models.RarityRare, Count: 2}}},
+	} {
+		got, err := quest.ParseRequirements(json.RawMessage(test.data))
+		if err != nil || len(got) != len(test.want) {
+			t.Fatalf("ParseRequirements(%s) = %+v, %v; want %+v", test.data, got, err, test.want)
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Fatalf("ParseRequirements(%s)[%d] = %+v; want %+v", test.data, i, got[i], test.want[i])
+			}
+		}
+	}
+
+	for _, test := range []struct {
+		data, wantError string
+	}{
+		{``, "at least one requirement"},
+		{`[]`, "at least one requirement"},
+		{`null`, "at least one requirement"},
+		{`{"type": "win_fights"}`, "invalid quest requirements"},
+		{`[{"type": "win_fights", "count": "3"}]`, "invalid quest requirements"},
+		{`[{"type": "win_fights"}]`, "requirement 1 (win_fights) needs a positive count"},
+		{`[{"type": "win_fights", "count": 1}, {"type": "merchant_purchase", "count": -1}]`, "requirement 2 (merchant_purchase) needs a positive count"},
+		{`[{"type": "reach_level", "level": 0}]`, "needs a positive level"},
+		{`[{"type": "reach_level", "count": 5}]`, "needs a positive level"},
+		{`[{"type": "own_item_rarity", "rarity": "mythic"}]`, `unknown rarity "mythic"`},
+		{`[{"type": "own_item_rarity"}]`, `unknown rarity ""`},
+		{`[{"type": "own_item_rarity", "rarity": "rare", "count": -2}]`, "cannot have a negative count"},
+		{`[{"type": "slay_dragons", "count": 1}]`, `unknown type "slay_dragons"`},
+		{`[{"count": 1}]`, `unknown type ""`},
+	} {
+		if _, err := quest.ParseRequirements(json.RawMessage(test.data)); err == nil || !strings.Contains(err.Error(), test.wantError) {
+			t.Fatalf("ParseRequirements(%s) = %v; want an error containing %q", test.data, err, test.wantError)
+		}
+	}
+}
+
+func TestParseRewards(t *testing.T) {
+	rewards, err := quest.ParseRewards(json.RawMessage(`{"experience": 100, "channel_points": 50, "item_ids": [3, 4]}`))
+	if err != nil || rewards.Experience != 100 || rewards.ChannelPoints != 50 || len(rewards.ItemIDs) != 2 {
+		t.Fatalf("ParseRewards = %+v, %v", rewards, err)
+	}
+	if rewards, err := quest.ParseRewards(nil); err != nil || rewards.Experience != 0 {
+		t.Fatalf("ParseRewards(nil) = %+v, %v; want no rewards", rewards, err)
+	}
+	for _, data := range []string{`{"experience": -1}`, `{"channel_points": -5}`, `[1]`} {
+		if _, err := quest.ParseRewards(json.RawMessage(data)); err == nil {
+			t.Fatalf("ParseRewards(%s) succeeded", data)
+		}
+	}
+}
+
+func TestParseProgress(t *testing.T) {
+	requirements := []models.QuestRequirement{
+		{Type: models.RequirementWinFights, Count: 3},
+		{Type: models.RequirementReachLevel, Level: 5},
+	}
+
+	saved := json.RawMessage(`{"requirements": [{"type": "win_fights", "current": 2, "target": 9}, {"type": "reach_level", "current": 4, "target": 5}]}`)
+	progress := quest.ParseProgress(saved, requirements)
+	if progress.Requirements[0].Current != 2 || progress.Requirements[1].Current != 4 {
+		t.Fatalf("ParseProgress lost saved progress: %+v", progress)
+	}
+	// Targets follow the requirements, which may have been edited since
+	if progress.Requirements[0].Target != 3 || progress.Requirements[1].Target != 5 {
+		t.Fatalf("ParseProgress targets = %+v; want 3 and 5", progress)
+	}
+
+	// Progress that does not line up with the requirements starts over
+	for _, data := range []string{
+		``,
+		`not json`,
+		`{"requirements": [{"type": "win_fights", "current": 2}]}`,
+		`{"requirements": [{"type": "merchant_purchase", "current": 2}, {"type": "reach_level", "current": 4}]}`,
+	} {
+		progress := quest.ParseProgress(json.RawMessage(data), requirements)
+		if len(progress.Requirements) != 2 || progress.Requirements[0].Current != 0 || progress.Requirements[0].Type != models.RequirementWinFights ||
+			progress.Requirements[1].Target != 5 {
+			t.Fatalf("ParseProgress(%s) = %+v; want fresh progress", data, progress)
+		}
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	level := func(level int) quest.State { return quest.State{Level: level} }
+	items := func(byRarity map[models.ItemRarity]int) quest.State {
+		return quest.State{Level: 1, ItemsByRarity: byRarity}
+	}
+
+	for _, test := range []struct {
+		name        string
+		requirement models.QuestRequirement
+		steps       []models.QuestAction
+		states      []quest.State
+		want        []int // current after each step
+		complete    int   // index of the step that completes the requirement, -1 for none
+	}{
+		{
+			name:        "win_fights counts won fights only",
+			requirement: models.QuestRequirement{Type: models.RequirementWinFights, Count: 2},
+			steps: []models.QuestAction{models.QuestActionFightWon, models.QuestActionMerchantPurchase,
+				models.QuestActionStateChanged, models.QuestActionFightWon, models.QuestActionFightWon},
+			states:   []quest.State{level(1), level(1), level(9), level(1), level(1)},
+			want:     []int{1, 1, 1, 2, 2},
+			complete: 3,
+		},
+		{
+			name:        "merchant_purchase counts purchases only",
+			requirement: models.QuestRequirement{Type: models.RequirementMerchantPurchase, Count: 1},
+			steps:       []models.QuestAction{models.QuestActionFightWon, models.QuestActionMerchantPurchase, models.QuestActionMerchantPurchase},
+			states:      []quest.State{level(1), level(1), level(1)},
+			want:        []int{0, 1, 1},
+			complete:    1,
+		},
+		{
+			name:        "reach_level follows the level on any action",
+			requirement: models.QuestRequirement{Type: models.RequirementReachLevel, Level: 5},
+			steps:       []models.QuestAction{models.QuestActionStateChanged, models.QuestActionFightWon, models.QuestActionMerchantPurchase},
+			states:      []quest.State{level(3), level(5), level(7)},
+			want:        []int{3, 5, 7},
+			complete:    1,
+		},
+		{
+			name:        "own_item_rarity counts units of the rarity or better",
+			requirement: models.QuestRequirement{Type: models.RequirementOwnItemRarity, Rarity: models.RarityRare, Count: 3},
+			steps:       []models.QuestAction{models.QuestActionStateChanged, models.QuestActionStateChanged, models.QuestActionStateChanged},
+			states: []quest.State{
+				items(map[models.ItemRarity]int{models.RarityCommon: 5, models.RarityRare: 1}),
+				items(map[models.ItemRarity]int{models.RarityRare: 1, models.RarityEpic: 1, models.RarityLegendary: 1}),
+				items(map[models.ItemRarity]int{models.RarityRare: 1}),
+			},
+			want:     []int{1, 3, 1},
+			complete: 1,
+		},
+		{
+			name:        "own_item_rarity without a count needs one item",
+			requirement: models.QuestRequirement{Type: models.RequirementOwnItemRarity, Rarity: models.RarityLegendary},
+			steps:       []models.QuestAction{models.QuestActionStateChanged, models.QuestActionStateChanged},
+			states:      []quest.State{items(map[models.ItemRarity]int{models.RarityEpic: 4}), items(map[models.ItemRarity]int{models.RarityLegendary: 1})},
+			want:        []int{0, 1},
+			complete:    1,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			requirements := []models.QuestRequirement{test.requirement}
+			progress := quest.NewProgress(requirements)
+			for i, action := range test.steps {
+				complete := quest.Advance(requirements, &progress, action, test.states[i])
+				entry := progress.Requirements[0]
+				if entry.Current != test.want[i] {
+					t.Fatalf("step %d (%s): current = %d; want %d", i, action, entry.Current, test.want[i])
+				}
+				if complete != entry.Met || entry.Met != (entry.Current >= entry.Target) {
+					t.Fatalf("step %d (%s): complete %v with %+v", i, action, complete, entry)
+				}
+				if i == test.complete && !complete {
+					t.Fatalf("step %d (%s) did not complete the requirement: %+v", i, action, entry)
+				}
+				if i < test.complete && complete {
+					t.Fatalf("step %d (%s) completed the requirement early: %+v", i, action, entry)
+				}
+			}
+		})
+	}
+}
+
+func TestAdvanceNeedsEveryRequirement(t *testing.T) {
+	requirements, err := quest.ParseRequirements(json.RawMessage(
+		`[{"type": "win_fights", "count": 1}, {"type": "reach_level", "level": 2}, {"type": "own_item_rarity", "rarity": "epic"}]`))
+	if err != nil {
+		t.Fatalf("ParseRequirements: %v", err)
+	}
+	if !quest.NeedsInventory(requirements) || quest.NeedsInventory(requirements[:2]) {
+		t.Fatalf("NeedsInventory is wrong")
+	}
+
+	progress := quest.NewProgress(requirements)
+	state := quest.State{Level: 2}
+	if quest.Advance(requirements, &progress, models.QuestActionFightWon, state) {
+		t.Fatalf("quest completed without the epic item: %+v", progress)
+	}
+	state.ItemsByRarity = map[models.ItemRarity]int{models.RarityEpic: 1}
+	if !quest.Advance(requirements, &progress, models.QuestActionStateChanged, state) {
+		t.Fatalf("quest not completed with every requirement met: %+v", progress)
+	}
+
+	// Saved progress keeps the counters
+	data, _ := json.Marshal(progress)
+	reloaded := quest.ParseProgress(data, requirements)
+	if reloaded.Requirements[0].Current != 1 || !reloaded.Requirements[0].Met {
+		t.Fatalf("reloaded progress = %+v", reloaded)
+	}
+}
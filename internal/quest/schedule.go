@@ -0,0 +1,123 @@
+package quest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Clock is a wall clock time of day in the schedule's timezone
+type Clock struct {
+	Hour, Minute int
+}
+
+// ParseClock parses a time of day such as "04:30"
+func ParseClock(value string) (Clock, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return Clock{}, fmt.Errorf("invalid time of day %q, want HH:MM", value)
+	}
+	return Clock{Hour: t.Hour(), Minute: t.Minute()}, nil
+}
+
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", c.Hour, c.Minute)
+}
+
+// on returns the clock time on the given calendar day. Days out of range are
+// normalized, and times skipped by a DST change move forward like time.Date does.
+func (c Clock) on(year int, month time.Month, day int, location *time.Location) time.Time {
+	return time.Date(year, month, day, c.Hour, c.Minute, 0, 0, location)
+}
+
+// ParseWeeklyReset parses a weekday and time of day such as "monday 04:30"
+func ParseWeeklyReset(value string) (time.Weekday, Clock, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return 0, Clock{}, fmt.Errorf("invalid weekly reset %q, want e.g. \"monday 04:00\"", value)
+	}
+
+	day := -1
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		name := strings.ToLower(weekday.String())
+		if strings.EqualFold(fields[0], name) || strings.EqualFold(fields[0], name[:3]) {
+			day = int(weekday)
+		}
+	}
+	if day < 0 {
+		return 0, Clock{}, fmt.Errorf("invalid weekday %q in weekly reset", fields[0])
+	}
+
+	clock, err := ParseClock(fields[1])
+	if err != nil {
+		return 0, Clock{}, err
+	}
+	return time.Weekday(day), clock, nil
+}
+
+// Schedule decides when daily and weekly quests rotate and how many of each are offered
+type Schedule struct {
+	// Location is the channel's timezone that reset times are given in
+	Location     *time.Location
+	DailyReset   Clock
+	WeeklyDay    time.Weekday
+	WeeklyReset  Clock
+	DailyQuests  int
+	WeeklyQuests int
+}
+
+// DefaultSchedule resets dailies at midnight UTC and weeklies on Monday at midnight UTC,
+// offering three daily and two weekly quests
+func DefaultSchedule() Schedule {
+	return Schedule{
+		Location:     time.UTC,
+		WeeklyDay:    time.Monday,
+		DailyQuests:  3,
+		WeeklyQuests: 2,
+	}
+}
+
+// Rotates reports whether quests of the given type are offered in rotations.
+// Special quests stay on the board until they are deactivated.
+func Rotates(questType string) bool {
+	return questType == models.QuestTypeDaily || questType == models.QuestTypeWeekly
+}
+
+// Size is how many quests of the given type each rotation offers
+func (s Schedule) Size(questType string) int {
+	switch questType {
+	case models.QuestTypeDaily:
+		return s.DailyQuests
+	case models.QuestTypeWeekly:
+		return s.WeeklyQuests
+	}
+	return 0
+}
+
+// Period returns the start and end of the rotation period of questType that contains now.
+// The end is the next reset. It reports false for quest types that do not rotate.
+func (s Schedule) Period(questType string, now time.Time) (start, end time.Time, ok bool) {
+	local := now.In(s.Location)
+	year, month, day := local.Date()
+
+	switch questType {
+	case models.QuestTypeDaily:
+		start = s.DailyReset.on(year, month, day, s.Location)
+		if start.After(now) {
+			start = s.DailyReset.on(year, month, day-1, s.Location)
+		}
+		year, month, day = start.Date()
+		return start, s.DailyReset.on(year, month, day+1, s.Location), true
+
+	case models.QuestTypeWeekly:
+		back := (int(local.Weekday()) - int(s.WeeklyDay) + 7) % 7
+		start = s.WeeklyReset.on(year, month, day-back, s.Location)
+		if start.After(now) {
+			start = s.WeeklyReset.on(year, month, day-back-7, s.Location)
+		}
+		year, month, day = start.Date()
+		return start, s.WeeklyReset.on(year, month, day+7, s.Location), true
+	}
+	return time.Time{}, time.Time{}, false
+}
@@ -0,0 +1,211 @@
+package quest_test
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/quest"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%s): %v", name, err)
+	}
+	return location
+}
+
+func TestDailyPeriodAcrossDST(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	schedule := quest.DefaultSchedule()
+	schedule.Location = berlin
+	schedule.DailyReset = quest.Clock{Hour: 4}
+
+	for _, test := range []struct {
+		name       string
+		now        time.Time
+		start, end time.Time
+		length     time.Duration
+	}{
+		{
+			name:  "spring forward",
+			now:   time.Date(2026, time.March, 29, 1, 0, 0, 0, berlin),
+			start: time.Date(2026, time.March, 28, 4, 0, 0, 0, berlin),
+			end:   time.Date(2026, time.March, 29, 4, 0, 0, 0, berlin), length: 23 * time.Hour,
+		},
+		{
+			name:  "day after spring forward",
+			now:   time.Date(2026, time.March, 29, 4, 0, 0, 0, berlin),
+			start: time.Date(2026, time.March, 29, 4, 0, 0, 0, berlin),
+			end:   time.Date(2026, time.March, 30, 4, 0, 0, 0, berlin), length: 24 * time.Hour,
+		},
+		{
+			name:  "fall back",
+			now:   time.Date(2026, time.October, 25, 2, 30, 0, 0, berlin),
+			start: time.Date(2026, time.October, 24, 4, 0, 0, 0, berlin),
+			end:   time.Date(2026, time.October, 25, 4, 0, 0, 0, berlin), length: 25 * time.Hour,
+		},
+	} {
+		start, end, ok := schedule.Period(models.QuestTypeDaily, test.now)
+		if !ok || !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Fatalf("%s: Period(%s) = %s - %s; want %s - %s", test.name, test.now, start, end, test.start, test.end)
+		}
+		// The reset stays at 04:00 on the wall clock, so the day is an hour shorter or longer
+		if end.Sub(start) != test.length || end.In(berlin).Hour() != 4 {
+			t.Fatalf("%s: period lasts %s and ends at %s; want %s ending at 04:00", test.name, end.Sub(start), end.In(berlin), test.length)
+		}
+	}
+
+	// A reset in the hour that spring forward skips happens when the clocks resume
+	schedule.DailyReset = quest.Clock{Hour: 2, Minute: 30}
+	start, end, _ := schedule.Period(models.QuestTypeDaily, time.Date(2026, time.March, 29, 12, 0, 0, 0, berlin))
+	if want := time.Date(2026, time.March, 29, 3, 30, 0, 0, berlin); !start.Equal(want) {
+		t.Fatalf("skipped reset starts at %s; want %s", start, want)
+	}
+	if want := time.Date(2026, time.March, 30, 2, 30, 0, 0, berlin); !end.Equal(want) {
+		t.Fatalf("period after the skipped reset ends at %s; want %s", end, want)
+	}
+	previousStart, previousEnd, _ := schedule.Period(models.QuestTypeDaily, start.Add(-time.Minute))
+	if !previousEnd.Equal(start) || !previousStart.Equal(time.Date(2026, time.March, 28, 2, 30, 0, 0, berlin)) {
+		t.Fatalf("period before the skipped reset = %s - %s; want it to end at %s", previousStart, previousEnd, start)
+	}
+}
+
+func TestWeeklyPeriodWraps(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	schedule := quest.DefaultSchedule()
+	schedule.Location = berlin
+	schedule.WeeklyDay = time.Monday
+	schedule.WeeklyReset = quest.Clock{Hour: 4}
+
+	for _, test := range []struct {
+		name       string
+		weekday    time.Weekday
+		now        time.Time
+		start, end time.Time
+	}{
+		{
+			name: "sunday night belongs to the week before", weekday: time.Monday,
+			now:   time.Date(2026, time.March, 29, 23, 0, 0, 0, berlin),
+			start: time.Date(2026, time.March, 23, 4, 0, 0, 0, berlin),
+			end:   time.Date(2026, time.March, 30, 4, 0, 0, 0, berlin),
+		},
+		{
+			name: "reset day before the reset time", weekday: time.Monday,
+			now:   time.Date(2026, time.March, 30, 3, 59, 0, 0, berlin),
+			start: time.Date(2026, time.March, 23, 4, 0, 0, 0, berlin),
+			end:   time.Date(2026, time.March, 30, 4, 0, 0, 0, berlin),
+		},
+		{
+			name: "reset day at the reset time", weekday: time.Monday,
+			now:   time.Date(2026, time.March, 30, 4, 0, 0, 0, berlin),
+			start: time.Date(2026, time.March, 30, 4, 0, 0, 0, berlin),
+			end:   time.Date(2026, time.April, 6, 4, 0, 0, 0, berlin),
+		},
+		{
+			name: "across the new year", weekday: time.Saturday,
+			now:   time.Date(2026, time.January, 1, 12, 0, 0, 0, berlin),
+			start: time.Date(2025, time.December, 27, 4, 0, 0, 0, berlin),
+			end:   time.Date(2026, time.January, 3, 4, 0, 0, 0, berlin),
+		},
+		{
+			name: "sunday reset seen from saturday", weekday: time.Sunday,
+			now:   time.Date(2026, time.October, 31, 12, 0, 0, 0, berlin),
+			start: time.Date(2026, time.October, 25, 4, 0, 0, 0, berlin),
+			end:   time.Date(2026, time.November, 1, 4, 0, 0, 0, berlin),
+		},
+	} {
+		schedule.WeeklyDay = test.weekday
+		start, end, ok := schedule.Period(models.QuestTypeWeekly, test.now)
+		if !ok || !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Fatalf("%s: Period(%s) = %s - %s; want %s - %s", test.name, test.now, start, end, test.start, test.end)
+		}
+	}
+}
+
+func TestPeriodInChannelTimezone(t *testing.T) {
+	losAngeles := mustLoad(t, "America/Los_Angeles")
+	kolkata := mustLoad(t, "Asia/Kolkata")
+	now := time.Date(2026, time.June, 15, 3, 0, 0, 0, time.UTC)
+
+	for _, test := range []struct {
+		location   *time.Location
+		questType  string
+		start, end time.Time
+	}{
+		// 03:00 UTC is still the evening before in California
+		{time.UTC, models.QuestTypeDaily,
+			time.Date(2026, time.June, 15, 0, 0, 0, 0, time.UTC), time.Date(2026, time.June, 16, 0, 0, 0, 0, time.UTC)},
+		{losAngeles, models.QuestTypeDaily,
+			time.Date(2026, time.June, 14, 7, 0, 0, 0, time.UTC), time.Date(2026, time.June, 15, 7, 0, 0, 0, time.UTC)},
+		// Half-hour offsets are kept
+		{kolkata, models.QuestTypeDaily,
+			time.Date(2026, time.June, 14, 18, 30, 0, 0, time.UTC), time.Date(2026, time.June, 15, 18, 30, 0, 0, time.UTC)},
+		// Monday 03:00 UTC is Sunday in California, so the week has not turned over there
+		{time.UTC, models.QuestTypeWeekly,
+			time.Date(2026, time.June, 15, 0, 0, 0, 0, time.UTC), time.Date(2026, time.June, 22, 0, 0, 0, 0, time.UTC)},
+		{losAngeles, models.QuestTypeWeekly,
+			time.Date(2026, time.June, 8, 7, 0, 0, 0, time.UTC), time.Date(2026, time.June, 15, 7, 0, 0, 0, time.UTC)},
+	} {
+		schedule := quest.DefaultSchedule()
+		schedule.Location = test.location
+		start, end, ok := schedule.Period(test.questType, now)
+		if !ok || !start.Equal(test.start) || !end.Equal(test.end) {
+			t.Fatalf("%s %s: Period = %s - %s; want %s - %s", test.location, test.questType, start.UTC(), end.UTC(), test.start, test.end)
+		}
+	}
+
+	if _, _, ok := quest.DefaultSchedule().Period(models.QuestTypeSpecial, now); ok {
+		t.Fatalf("special quests have a rotation period")
+	}
+}
+
+// TestPeriodsTile checks that consecutive periods meet exactly, so that no moment
+// belongs to two rotations or none, through a whole year of DST changes
+func TestPeriodsTile(t *testing.T) {
+	for _, name := range []string{"UTC", "Europe/Berlin", "America/New_York", "Australia/Lord_Howe"} {
+		schedule := quest.DefaultSchedule()
+		schedule.Location = mustLoad(t, name)
+		schedule.DailyReset = quest.Clock{Hour: 2, Minute: 30}
+		schedule.WeeklyDay = time.Sunday
+		schedule.WeeklyReset = quest.Clock{Hour: 2, Minute: 15}
+
+		for _, questType := range []string{models.QuestTypeDaily, models.QuestTypeWeekly} {
+			_, end, _ := schedule.Period(questType, time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+			for end.Year() < 2027 {
+				start, next, ok := schedule.Period(questType, end)
+				if !ok || !start.Equal(end) || !next.After(start) {
+					t.Fatalf("%s %s: period at %s = %s - %s; want it to start there", name, questType, end, start, next)
+				}
+				if _, sameEnd, _ := schedule.Period(questType, next.Add(-time.Nanosecond)); !sameEnd.Equal(next) {
+					t.Fatalf("%s %s: the moment before %s ends at %s", name, questType, next, sameEnd)
+				}
+				end = next
+			}
+		}
+	}
+}
+
+func TestParseWeeklyReset(t *testing.T) {
+	for _, test := range []struct {
+		value   string
+		weekday time.Weekday
+		clock   string
+	}{
+		{"monday 04:30", time.Monday, "04:30"},
+		{"SUN 23:59", time.Sunday, "23:59"},
+		{"  Friday   00:00 ", time.Friday, "00:00"},
+	} {
+		weekday, clock, err := quest.ParseWeeklyReset(test.value)
+		if err != nil || weekday != test.weekday || clock.String() != test.clock {
+			t.Fatalf("ParseWeeklyReset(%q) = %s, %s, %v", test.value, weekday, clock, err)
+		}
+	}
+	for _, value := range []string{"", "monday", "someday 04:00", "monday 25:00", "monday 4pm"} {
+		if _, _, err := quest.ParseWeeklyReset(value); err == nil {
+			t.Fatalf("ParseWeeklyReset(%q) succeeded", value)
+		}
+	}
+}
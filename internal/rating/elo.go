@@ -0,0 +1,40 @@
+// Package rating implements the Elo skill rating used for ranked fights.
+package rating
+
+import (
+	"math"
+)
+
+const (
+	// ProvisionalFights is the number of rated fights during which ratings move faster
+	ProvisionalFights = 10
+
+	provisionalK = 40
+	establishedK = 20
+
+	// Floor is the lowest rating a character can drop to
+	Floor = 100
+)
+
+// Expected is the probability that a player rated rating beats one rated opponent
+func Expected(rating, opponent int) float64 {
+	return 1 / (1 + math.Pow(10, float64(opponent-rating)/400))
+}
+
+// KFactor is the maximum rating change of a single fight for a character with the given number of rated fights
+func KFactor(ratedFights int) float64 {
+	if ratedFights < ProvisionalFights {
+		return provisionalK
+	}
+	return establishedK
+}
+
+// Update returns the new ratings of the winner and loser of a fight
+func Update(winnerRating, winnerFights, loserRating, loserFights int) (int, int) {
+	winnerGain := KFactor(winnerFights) * (1 - Expected(winnerRating, loserRating))
+	loserLoss := KFactor(loserFights) * Expected(loserRating, winnerRating)
+
+	newWinner := winnerRating + int(math.Round(winnerGain))
+	newLoser := max(loserRating-int(math.Round(loserLoss)), Floor)
+	return newWinner, newLoser
+}
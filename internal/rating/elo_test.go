@@ -0,0 +1,77 @@
+package rating_test
+
+import (
+	"math"
+	"testing"
+	"twitch-rpg/internal/rating"
+)
+
+func TestExpected(t *testing.T) {
+	for _, test := range []struct {
+		rating, opponent int
+		want             float64
+	}{
+		{1000, 1000, 0.5},
+		{1400, 1000, 10.0 / 11},
+		{1000, 1400, 1.0 / 11},
+		{1200, 1000, 0.7597},
+	} {
+		if got := rating.Expected(test.rating, test.opponent); math.Abs(got-test.want) > 1e-4 {
+			t.Fatalf("Expected(%d, %d) = %v; want %v", test.rating, test.opponent, got, test.want)
+		}
+	}
+
+	// Both players' expectations always add up to one win
+	for _, pair := range [][2]int{{1000, 1000}, {1500, 900}, {100, 2800}, {1234, 1233}} {
+		if sum := rating.Expected(pair[0], pair[1]) + rating.Expected(pair[1], pair[0]); math.Abs(sum-1) > 1e-12 {
+			t.Fatalf("Expected(%d, %d) + Expected(%d, %d) = %v; want 1", pair[0], pair[1], pair[1], pair[0], sum)
+		}
+	}
+}
+
+func TestKFactor(t *testing.T) {
+	for fights, want := range map[int]float64{0: 40, 1: 40, rating.ProvisionalFights - 1: 40, rating.ProvisionalFights: 20, 500: 20} {
+		if got := rating.KFactor(fights); got != want {
+			t.Fatalf("KFactor(%d) = %v; want %v", fights, got, want)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	for _, test := range []struct {
+		winner, winnerFights, loser, loserFights int
+		wantWinner, wantLoser                    int
+	}{
+		{1000, 20, 1000, 20, 1010, 990},
+		{1000, 0, 1000, 0, 1020, 980},
+		// Upsets move ratings further than expected wins
+		{1000, 20, 1400, 20, 1018, 1382},
+		{1400, 20, 1000, 20, 1402, 998},
+		// A provisional player moves twice as fast as an established opponent
+		{1000, 3, 1000, 30, 1020, 990},
+		{1000, 30, 1000, 3, 1010, 980},
+		// Ratings never drop below the floor
+		{110, 20, 110, 0, 120, rating.Floor},
+		{200, 20, rating.Floor, 20, 207, rating.Floor},
+	} {
+		winner, loser := rating.Update(test.winner, test.winnerFights, test.loser, test.loserFights)
+		if winner != test.wantWinner || loser != test.wantLoser {
+			t.Fatalf("Update(%d/%d beats %d/%d) = %d, %d; want %d, %d", test.winner, test.winnerFights,
+				test.loser, test.loserFights, winner, loser, test.wantWinner, test.wantLoser)
+		}
+	}
+}
+
+func TestUpdateConservesPoints(t *testing.T) {
+	// With the same K-factor and away from the floor, the winner gains what the loser loses
+	for _, fights := range []int{0, rating.ProvisionalFights} {
+		for winner := 600; winner <= 2000; winner += 35 {
+			for loser := 600; loser <= 2000; loser += 45 {
+				newWinner, newLoser := rating.Update(winner, fights, loser, fights)
+				if gain, loss := newWinner-winner, loser-newLoser; gain != loss || gain < 0 {
+					t.Fatalf("Update(%d, %d, %d fights) gained %d and lost %d", winner, loser, fights, gain, loss)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+	"twitch-rpg/internal/achievement"
+	"twitch-rpg/internal/eventbus"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+)
+
+// AchievementService unlocks achievement badges as characters progress
+type AchievementService struct {
+	store storage.Store
+}
+
+// NewAchievementService creates a new achievement service
+func NewAchievementService(store storage.Store) *AchievementService {
+	return &AchievementService{store: store}
+}
+
+// GetCatalog retrieves every achievement and its tiers
+func (as *AchievementService) GetCatalog() []achievement.Definition {
+	return achievement.Catalog()
+}
+
+// GetCharacterAchievements retrieves the achievement tiers a character has unlocked
+func (as *AchievementService) GetCharacterAchievements(characterID int) ([]models.CharacterAchievement, error) {
+	character, err := as.store.GetCharacterByID(characterID)
+	if err != nil {
+		return nil, err
+	}
+	if character == nil {
+		return nil, fmt.Errorf("character not found: %w", storage.ErrNotFound)
+	}
+
+	return as.unlocked(characterID)
+}
+
+// LoadAchievements populates the achievements shown on a character's profile
+func (as *AchievementService) LoadAchievements(character *models.Character) error {
+	achievements, err := as.unlocked(character.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load achievements: %v", err)
+	}
+
+	character.Achievements = achievements
+	return nil
+}
+
+// Check evaluates a character's achievements after a game action. Achievements never fail
+// the action itself, so errors are logged instead of returned.
+func (as *AchievementService) Check(characterID int) {
+	if _, err := as.Evaluate(characterID); err != nil {
+		log.Printf("Failed to check achievements of character %d: %v", characterID, err)
+	}
+}
+
+// Evaluate measures the character against every achievement, unlocks the tiers it has
+// reached and announces each new badge. It returns the newly unlocked tiers.
+func (as *AchievementService) Evaluate(characterID int) ([]models.CharacterAchievement, error) {
+	character, err := as.store.GetCharacterByID(characterID)
+	if err != nil {
+		return nil, err
+	}
+	if character == nil {
+		return nil, fmt.Errorf("character not found: %w", storage.ErrNotFound)
+	}
+
+	existing, err := as.store.GetCharacterAchievements(characterID)
+	if err != nil {
+		return nil, err
+	}
+	have := map[string]bool{}
+	for _, unlocked := range existing {
+		have[unlocked.AchievementKey+"/"+unlocked.Tier] = true
+	}
+
+	unlocked := []models.CharacterAchievement{}
+	metrics := map[achievement.Metric]int{}
+	now := time.Now()
+	for _, definition := range achievement.Catalog() {
+		if as.complete(definition, have) {
+			continue
+		}
+
+		value, measured := metrics[definition.Metric]
+		if !measured {
+			if value, err = as.measure(character, definition.Metric); err != nil {
+				return unlocked, err
+			}
+			metrics[definition.Metric] = value
+		}
+
+		for _, tier := range definition.Earned(value) {
+			if have[definition.Key+"/"+tier.Tier] {
+				continue
+			}
+
+			badge := models.CharacterAchievement{
+				CharacterID:    character.ID,
+				AchievementKey: definition.Key,
+				Tier:           tier.Tier,
+				UnlockedAt:     now,
+			}
+			isNew, err := as.store.UnlockAchievement(&badge)
+			if err != nil {
+				return unlocked, fmt.Errorf("failed to unlock %s (%s): %v", definition.Key, tier.Tier, err)
+			}
+			if !isNew {
+				// Unlocked concurrently by another action
+				continue
+			}
+
+			describe(&badge)
+			unlocked = append(unlocked, badge)
+			eventbus.Publish(eventbus.AchievementUnlocked{Character: character, Achievement: &badge})
+		}
+	}
+
+	return unlocked, nil
+}
+
+// complete reports whether every tier of the achievement is already unlocked
+func (as *AchievementService) complete(definition achievement.Definition, have map[string]bool) bool {
+	for _, tier := range definition.Tiers {
+		if !have[definition.Key+"/"+tier.Tier] {
+			return false
+		}
+	}
+	return true
+}
+
+// measure computes one metric of the character
+func (as *AchievementService) measure(character *models.Character, metric achievement.Metric) (int, error) {
+	switch metric {
+	case achievement.MetricFightsWon:
+		return as.store.CountCombatWins(character.ID)
+	case achievement.MetricLevel:
+		return character.Level, nil
+	case achievement.MetricPointsSpent:
+		return character.ChannelPointsSpent, nil
+	case achievement.MetricEquippedSlots:
+		return achievement.EquippedSlots(character), nil
+	case achievement.MetricBestRarity:
+		items, err := as.store.GetCharacterItems(character.ID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load inventory: %v", err)
+		}
+		best := 0
+		for _, owned := range items {
+			if owned.Item != nil && owned.Quantity > 0 {
+				best = max(best, owned.Item.Rarity.Rank())
+			}
+		}
+		return best, nil
+	}
+	return 0, fmt.Errorf("unknown achievement metric %q", metric)
+}
+
+func (as *AchievementService) unlocked(characterID int) ([]models.CharacterAchievement, error) {
+	achievements, err := as.store.GetCharacterAchievements(characterID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range achievements {
+		describe(&achievements[i])
+	}
+	return achievements, nil
+}
+
+// describe fills in the catalog names of an unlocked tier
+func describe(badge *models.CharacterAchievement) {
+	definition, tier, ok := achievement.Lookup(badge.AchievementKey, badge.Tier)
+	if !ok {
+		return
+	}
+	badge.Name = definition.Name
+	badge.TierName = tier.Name
+	badge.Description = tier.Description
+}
+
+// recordProgress updates quests and achievements after a game action changed a character
+func recordProgress(store storage.Store, characterID int, action models.QuestAction) {
+	NewQuestService(store).RecordAction(characterID, action)
+	NewAchievementService(store).Check(characterID)
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"twitch-rpg/internal/channelpoints"
+	"twitch-rpg/internal/helix"
+	"twitch-rpg/internal/models"
+)
+
+// redemptionStatusTimeout bounds marking a redemption fulfilled or canceled, which
+// happens while Twitch waits for the webhook's answer
+const redemptionStatusTimeout = 5 * time.Second
+
+// rewardSyncTimeout bounds syncing the configured rewards at startup
+const rewardSyncTimeout = time.Minute
+
+// ErrHelixDisabled is returned when no Twitch application or broadcaster token is configured
+var ErrHelixDisabled = errors.New("Twitch Helix API is not configured")
+
+var (
+	helixClientOnce    sync.Once
+	helixClient        *helix.Client
+	helixBroadcasterID string
+)
+
+// configuredHelixClient creates the Helix client once from TWITCH_CLIENT_ID,
+// TWITCH_CLIENT_SECRET, TWITCH_BROADCASTER_ID and the broadcaster's
+// TWITCH_BROADCASTER_TOKEN and TWITCH_BROADCASTER_REFRESH_TOKEN. TWITCH_HELIX_URL and
+// TWITCH_AUTH_URL point it elsewhere, e.g. at cmd/helix-mock. It returns nil when
+// the client ID, broadcaster ID or token is missing.
+func configuredHelixClient() (*helix.Client, string) {
+	helixClientOnce.Do(func() {
+		clientID := os.Getenv("TWITCH_CLIENT_ID")
+		broadcasterID := os.Getenv("TWITCH_BROADCASTER_ID")
+		token := helix.Token{
+			AccessToken:  strings.TrimPrefix(os.Getenv("TWITCH_BROADCASTER_TOKEN"), "oauth:"),
+			RefreshToken: os.Getenv("TWITCH_BROADCASTER_REFRESH_TOKEN"),
+		}
+		if clientID == "" || broadcasterID == "" || token.AccessToken == "" {
+			return
+		}
+
+		client := helix.NewClient(clientID, os.Getenv("TWITCH_CLIENT_SECRET"), token)
+		if url := os.Getenv("TWITCH_HELIX_URL"); url != "" {
+			client.BaseURL = url
+		}
+		if url := os.Getenv("TWITCH_AUTH_URL"); url != "" {
+			client.AuthURL = url
+		}
+		helixClient, helixBroadcasterID = client, broadcasterID
+		log.Printf("Twitch Helix API enabled for broadcaster %s", broadcasterID)
+	})
+	return helixClient, helixBroadcasterID
+}
+
+// StartChannelRewardSync creates or updates the configured rewards that have a cost on
+// the Twitch channel in the background, when the Helix API is configured
+func StartChannelRewardSync(ctx context.Context) {
+	rewardService := NewChannelRewardService()
+	if !rewardService.Enabled() {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, rewardSyncTimeout)
+		defer cancel()
+		results, err := rewardService.SyncRewards(ctx)
+		if err != nil {
+			log.Printf("Failed to sync channel point rewards: %v", err)
+			return
+		}
+		for _, result := range results {
+			if result.Error != "" {
+				log.Printf("Channel point reward %q %s: %s", result.Title, result.Result, result.Error)
+			} else {
+				log.Printf("Channel point reward %q %s (%s)", result.Title, result.Result, result.RewardID)
+			}
+		}
+	}()
+}
+
+// ChannelRewardService manages the game's custom rewards and their redemptions on Twitch
+type ChannelRewardService struct {
+	client        *helix.Client
+	broadcasterID string
+	rewards       *channelpoints.Config
+}
+
+// NewChannelRewardService creates a new channel reward service using the configured
+// Helix client and rewards
+func NewChannelRewardService() *ChannelRewardService {
+	client, broadcasterID := configuredHelixClient()
+	return &ChannelRewardService{client: client, broadcasterID: broadcasterID, rewards: configuredChannelPointRewards()}
+}
+
+// Enabled reports whether the Helix API is configured
+func (rs *ChannelRewardService) Enabled() bool {
+	return rs.client != nil
+}
+
+// SyncRewards creates the configured rewards with a cost that the channel does not have
+// yet and updates those whose title, cost, prompt or input requirement changed. Only
+// rewards this application created can be changed; a configured reward_id naming one
+// created on the Twitch dashboard is skipped.
+func (rs *ChannelRewardService) SyncRewards(ctx context.Context) ([]models.RewardSync, error) {
+	if !rs.Enabled() {
+		return nil, ErrHelixDisabled
+	}
+	existing, err := rs.client.GetCustomRewards(ctx, rs.broadcasterID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []models.RewardSync{}
+	for i := range rs.rewards.Rewards {
+		if reward := &rs.rewards.Rewards[i]; reward.Cost > 0 {
+			results = append(results, rs.syncReward(ctx, reward, existing))
+		}
+	}
+	return results, nil
+}
+
+// syncReward creates or updates one configured reward
+func (rs *ChannelRewardService) syncReward(ctx context.Context, reward *channelpoints.Reward, existing []helix.CustomReward) models.RewardSync {
+	result := models.RewardSync{Title: reward.Title, RewardID: reward.RewardID}
+
+	var current *helix.CustomReward
+	for i := range existing {
+		if (reward.RewardID != "" && existing[i].ID == reward.RewardID) ||
+			(reward.RewardID == "" && strings.EqualFold(existing[i].Title, reward.Title)) {
+			current = &existing[i]
+			break
+		}
+	}
+
+	inputRequired := reward.NeedsInput()
+	settings := helix.CustomRewardSettings{
+		Title:               reward.Title,
+		Cost:                reward.Cost,
+		Prompt:              &reward.Prompt,
+		IsUserInputRequired: &inputRequired,
+	}
+
+	var synced *helix.CustomReward
+	var err error
+	switch {
+	case current == nil && reward.RewardID != "":
+		result.Result = "skipped"
+		result.Error = "the reward was not created by this application, so it cannot be managed"
+		return result
+	case current == nil:
+		result.Result = "created"
+		synced, err = rs.client.CreateCustomReward(ctx, rs.broadcasterID, settings)
+	case current.Title == reward.Title && current.Cost == reward.Cost &&
+		current.Prompt == reward.Prompt && current.IsUserInputRequired == inputRequired:
+		result.Result = "unchanged"
+		result.RewardID = current.ID
+		return result
+	default:
+		result.Result = "updated"
+		synced, err = rs.client.UpdateCustomReward(ctx, rs.broadcasterID, current.ID, settings)
+	}
+
+	if err != nil {
+		result.Result = "failed"
+		result.Error = err.Error()
+		return result
+	}
+	result.RewardID = synced.ID
+	return result
+}
+
+// CompleteRedemption marks an unfulfilled redemption FULFILLED when its action
+// succeeded and CANCELED otherwise, which refunds the viewer's points, and returns the
+// status it was given. Redemptions Twitch already fulfilled, because their reward skips
+// the request queue, are left alone and "" is returned.
+func (rs *ChannelRewardService) CompleteRedemption(ctx context.Context, redemption *models.ChannelPointRedemption, outcome *models.RedemptionOutcome) (string, error) {
+	if !rs.Enabled() {
+		return "", ErrHelixDisabled
+	}
+	if !strings.EqualFold(redemption.Status, models.RedemptionUnfulfilled) {
+		return "", nil
+	}
+
+	status := models.RedemptionCanceled
+	if outcome.Fulfilled {
+		status = models.RedemptionFulfilled
+	}
+	ctx, cancel := context.WithTimeout(ctx, redemptionStatusTimeout)
+	defer cancel()
+	if _, err := rs.client.UpdateRedemptionStatus(ctx, rs.broadcasterID, redemption.Reward.ID,
+		[]string{redemption.ID}, status); err != nil {
+		return "", fmt.Errorf("failed to mark redemption %s %s: %w", redemption.ID, status, err)
+	}
+	return status, nil
+}
@@ -1,398 +1,439 @@
 package services
 
 import (
-        "database/sql"
-        "fmt"
-        "twitch-rpg/internal/database"
-        "twitch-rpg/internal/models"
-        "twitch-rpg/internal/storage"
+	"errors"
+	"fmt"
+	"log"
+	"twitch-rpg/internal/eventbus"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
 )
 
+// ErrInvalidStatUpgrade is returned for an unknown stat or too few channel points to raise it
+var ErrInvalidStatUpgrade = errors.New("invalid stat upgrade parameters")
+
 // CharacterService handles character-related operations
-type CharacterService struct{}
+type CharacterService struct {
+	store storage.Store
+}
 
 // NewCharacterService creates a new character service
-func NewCharacterService() *CharacterService {
-        return &CharacterService{}
+func NewCharacterService(store storage.Store) *CharacterService {
+	return &CharacterService{store: store}
 }
 
 // CreateCharacter creates a new character for a user
 func (cs *CharacterService) CreateCharacter(username string, twitchUserID *string) (*models.Character, error) {
-        if database.DB == nil {
-                return storage.Memory.CreateCharacter(username, twitchUserID)
-        }
-        
-        // Check if character already exists
-        existing, err := cs.GetCharacterByUsername(username)
-        if err != nil {
-                return nil, fmt.Errorf("failed to check existing character: %v", err)
-        }
-        if existing != nil {
-                return nil, fmt.Errorf("character with username '%s' already exists", username)
-        }
-        
-        query := `
-                INSERT INTO characters (username, twitch_user_id, level, experience, channel_points_spent, 
-                        strength, agility, vitality, intelligence) 
-                VALUES (?, ?, 1, 0, 0, 10, 10, 10, 10)`
-        
-        result, err := database.DB.Exec(query, username, twitchUserID)
-        if err != nil {
-                return nil, fmt.Errorf("failed to create character: %v", err)
-        }
-        
-        id, err := result.LastInsertId()
-        if err != nil {
-                return nil, fmt.Errorf("failed to get character ID: %v", err)
-        }
-        
-        return cs.GetCharacterByID(int(id))
+	// Check if character already exists
+	existing, err := cs.store.GetCharacterByUsername(username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing character: %v", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("character with username '%s' already exists: %w", username, storage.ErrDuplicateUsername)
+	}
+
+	character, err := cs.store.CreateCharacter(username, twitchUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return cs.GetCharacterByID(character.ID)
+}
+
+// GetOrCreateCharacter finds a viewer's character by Twitch user ID, creating it the
+// first time the viewer plays through chat or channel points. When a known account
+// shows up under a new login the character is renamed to match. Without a user ID, as
+// in the chat console, the character is found by login name alone. The character is
+// returned with its equipment and derived stats.
+func (cs *CharacterService) GetOrCreateCharacter(username, twitchUserID string) (*models.Character, error) {
+	if twitchUserID == "" {
+		character, err := cs.GetCharacterByUsername(username)
+		if err != nil || character != nil {
+			return character, err
+		}
+		return cs.CreateCharacter(username, nil)
+	}
+
+	character, err := cs.store.GetCharacterByTwitchUserID(twitchUserID)
+	if err != nil {
+		return nil, err
+	}
+	if character != nil {
+		if username == "" || character.Username == username {
+			if err := cs.hydrate(character); err != nil {
+				return nil, err
+			}
+			return character, nil
+		}
+		return cs.syncUsername(character, username)
+	}
+	if username == "" {
+		return nil, fmt.Errorf("Twitch user %s has no login name", twitchUserID)
+	}
+
+	// A character created before its viewer's Twitch account was known is claimed by the
+	// account with its name; one belonging to another account holds a stale name
+	holder, err := cs.store.GetCharacterByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if holder != nil && holder.TwitchUserID == nil {
+		if err := cs.store.LinkTwitchUserID(holder.ID, twitchUserID); err != nil && !errors.Is(err, storage.ErrDuplicateTwitchUserID) {
+			return nil, err
+		}
+		return cs.GetCharacterByTwitchUserID(twitchUserID)
+	}
+	if holder != nil {
+		if err := cs.releaseUsername(holder); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := cs.CreateCharacter(username, &twitchUserID)
+	if errors.Is(err, storage.ErrDuplicateTwitchUserID) {
+		// Another message of the same viewer created it first
+		return cs.GetCharacterByTwitchUserID(twitchUserID)
+	}
+	return created, err
+}
+
+// syncUsername renames a character to its viewer's new login. Twitch logins are unique,
+// so another character still holding the login belongs to a viewer who renamed away;
+// it is moved aside until that viewer shows up under their new name.
+func (cs *CharacterService) syncUsername(character *models.Character, username string) (*models.Character, error) {
+	holder, err := cs.store.GetCharacterByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if holder != nil && holder.ID != character.ID {
+		if err := cs.releaseUsername(holder); err != nil {
+			return nil, err
+		}
+	}
+
+	change, err := cs.store.RenameCharacter(character.ID, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename character %d to %s: %w", character.ID, username, err)
+	}
+	if change != nil {
+		log.Printf("Character %d renamed from %s to %s", character.ID, change.OldUsername, change.NewUsername)
+	}
+	return cs.GetCharacterByID(character.ID)
+}
+
+// releaseUsername renames a character off a login another viewer now has, to a
+// placeholder no Twitch login can take
+func (cs *CharacterService) releaseUsername(holder *models.Character) error {
+	placeholder := fmt.Sprintf("%s~%d", holder.Username, holder.ID)
+	if _, err := cs.store.RenameCharacter(holder.ID, placeholder); err != nil {
+		return fmt.Errorf("failed to release username %s: %w", holder.Username, err)
+	}
+	log.Printf("Character %d gave up username %s to another Twitch account", holder.ID, holder.Username)
+	return nil
 }
 
 // GetCharacterByID retrieves a character by ID
 func (cs *CharacterService) GetCharacterByID(id int) (*models.Character, error) {
-        if database.DB == nil {
-                return storage.Memory.GetCharacterByID(id)
-        }
-        query := `
-                SELECT id, username, twitch_user_id, level, experience, channel_points_spent,
-                        strength, agility, vitality, intelligence,
-                        boots_id, pants_id, armor_id, helmet_id, ring_id, chain_id,
-                        created_at, updated_at
-                FROM characters WHERE id = ?`
-        
-        character := &models.Character{}
-        err := database.DB.QueryRow(query, id).Scan(
-                &character.ID, &character.Username, &character.TwitchUserID,
-                &character.Level, &character.Experience, &character.ChannelPointsSpent,
-                &character.Strength, &character.Agility, &character.Vitality, &character.Intelligence,
-                &character.BootsID, &character.PantsID, &character.ArmorID,
-                &character.HelmetID, &character.RingID, &character.ChainID,
-                &character.CreatedAt, &character.UpdatedAt,
-        )
-        
-        if err != nil {
-                if err == sql.ErrNoRows {
-                        return nil, nil
-                }
-                return nil, fmt.Errorf("failed to get character: %v", err)
-        }
-        
-        // Load equipment
-        if err := cs.loadCharacterEquipment(character); err != nil {
-                return nil, fmt.Errorf("failed to load equipment: %v", err)
-        }
-        
-        // Calculate derived stats
-        totalStats := character.CalculateTotalStats()
-        character.TotalStats = &totalStats
-        character.CombatPower = character.CalculateCombatPower()
-        
-        return character, nil
+	character, err := cs.store.GetCharacterByID(id)
+	if err != nil || character == nil {
+		return nil, err
+	}
+
+	if err := cs.hydrate(character); err != nil {
+		return nil, err
+	}
+	return character, nil
 }
 
 // GetCharacterByUsername retrieves a character by username
 func (cs *CharacterService) GetCharacterByUsername(username string) (*models.Character, error) {
-        if database.DB == nil {
-                return storage.Memory.GetCharacterByUsername(username)
-        }
-        query := `
-                SELECT id, username, twitch_user_id, level, experience, channel_points_spent,
-                        strength, agility, vitality, intelligence,
-                        boots_id, pants_id, armor_id, helmet_id, ring_id, chain_id,
-                        created_at, updated_at
-                FROM characters WHERE username = ?`
-        
-        character := &models.Character{}
-        err := database.DB.QueryRow(query, username).Scan(
-                &character.ID, &character.Username, &character.TwitchUserID,
-                &character.Level, &character.Experience, &character.ChannelPointsSpent,
-                &character.Strength, &character.Agility, &character.Vitality, &character.Intelligence,
-                &character.BootsID, &character.PantsID, &character.ArmorID,
-                &character.HelmetID, &character.RingID, &character.ChainID,
-                &character.CreatedAt, &character.UpdatedAt,
-        )
-        
-        if err != nil {
-                if err == sql.ErrNoRows {
-                        return nil, nil
-                }
-                return nil, fmt.Errorf("failed to get character: %v", err)
-        }
-        
-        // Load equipment
-        if err := cs.loadCharacterEquipment(character); err != nil {
-                return nil, fmt.Errorf("failed to load equipment: %v", err)
-        }
-        
-        // Calculate derived stats
-        totalStats := character.CalculateTotalStats()
-        character.TotalStats = &totalStats
-        character.CombatPower = character.CalculateCombatPower()
-        
-        return character, nil
+	character, err := cs.store.GetCharacterByUsername(username)
+	if err != nil || character == nil {
+		return nil, err
+	}
+
+	if err := cs.hydrate(character); err != nil {
+		return nil, err
+	}
+	return character, nil
+}
+
+// GetCharacterByTwitchUserID retrieves the character of a Twitch account
+func (cs *CharacterService) GetCharacterByTwitchUserID(twitchUserID string) (*models.Character, error) {
+	character, err := cs.store.GetCharacterByTwitchUserID(twitchUserID)
+	if err != nil || character == nil {
+		return nil, err
+	}
+
+	if err := cs.hydrate(character); err != nil {
+		return nil, err
+	}
+	return character, nil
+}
+
+// ResolveCharacter finds a character by Twitch user ID, then by current username and
+// finally by a username it went by before. It returns nil when nothing matches.
+func (cs *CharacterService) ResolveCharacter(twitchUserID, username string) (*models.CharacterResolution, error) {
+	lookups := []struct {
+		value      string
+		resolvedBy string
+		lookup     func(string) (*models.Character, error)
+	}{
+		{twitchUserID, models.ResolvedByTwitchUserID, cs.store.GetCharacterByTwitchUserID},
+		{username, models.ResolvedByUsername, cs.store.GetCharacterByUsername},
+		{username, models.ResolvedByFormerUsername, cs.store.GetCharacterByFormerUsername},
+	}
+	for _, step := range lookups {
+		if step.value == "" {
+			continue
+		}
+		character, err := step.lookup(step.value)
+		if err != nil {
+			return nil, err
+		}
+		if character != nil {
+			if err := cs.hydrate(character); err != nil {
+				return nil, err
+			}
+			return &models.CharacterResolution{Character: character, ResolvedBy: step.resolvedBy}, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetUsernameHistory retrieves the usernames a character went by, newest change first
+func (cs *CharacterService) GetUsernameHistory(characterID int) ([]models.UsernameChange, error) {
+	character, err := cs.store.GetCharacterByID(characterID)
+	if err != nil {
+		return nil, err
+	}
+	if character == nil {
+		return nil, fmt.Errorf("character %d: %w", characterID, storage.ErrNotFound)
+	}
+	return cs.store.GetUsernameHistory(characterID)
+}
+
+// hydrate loads equipment and calculates the derived stats of a stored character
+func (cs *CharacterService) hydrate(character *models.Character) error {
+	// Load equipment
+	if err := cs.loadCharacterEquipment(character); err != nil {
+		return fmt.Errorf("failed to load equipment: %v", err)
+	}
+
+	// Calculate derived stats
+	totalStats := character.CalculateTotalStats()
+	character.TotalStats = &totalStats
+	character.CombatPower = character.CalculateCombatPower()
+
+	return nil
 }
 
 // UpdateCharacter updates character information
 func (cs *CharacterService) UpdateCharacter(character *models.Character) error {
-        if database.DB == nil {
-                return storage.Memory.UpdateCharacter(character)
-        }
-        
-        query := `
-                UPDATE characters SET 
-                        level = ?, experience = ?, channel_points_spent = ?,
-                        strength = ?, agility = ?, vitality = ?, intelligence = ?,
-                        boots_id = ?, pants_id = ?, armor_id = ?, helmet_id = ?, ring_id = ?, chain_id = ?
-                WHERE id = ?`
-        
-        _, err := database.DB.Exec(query,
-                character.Level, character.Experience, character.ChannelPointsSpent,
-                character.Strength, character.Agility, character.Vitality, character.Intelligence,
-                character.BootsID, character.PantsID, character.ArmorID,
-                character.HelmetID, character.RingID, character.ChainID,
-                character.ID,
-        )
-        
-        if err != nil {
-                return fmt.Errorf("failed to update character: %v", err)
-        }
-        
-        return nil
+	return cs.store.UpdateCharacter(character)
 }
 
-// UpgradeCharacterStat upgrades a character's stat using channel points
+// UpgradeCharacterStat upgrades a character's stat using channel points from its wallet.
+// The debit and the stat increase happen in one store operation that only adds to the
+// stat and the points spent, and UpdateCharacter never writes either.
 func (cs *CharacterService) UpgradeCharacterStat(characterID int, statType string, channelPoints int) (*models.Character, error) {
-        character, err := cs.GetCharacterByID(characterID)
-        if err != nil {
-                return nil, err
-        }
-        
-        if character == nil {
-                return nil, fmt.Errorf("character not found")
-        }
-        
-        if !character.UpgradeStat(statType, channelPoints) {
-                return nil, fmt.Errorf("invalid stat upgrade parameters")
-        }
-        
-        if err := cs.UpdateCharacter(character); err != nil {
-                return nil, err
-        }
-        
-        return cs.GetCharacterByID(characterID)
+	character, err := cs.store.GetCharacterByID(characterID)
+	if err != nil {
+		return nil, err
+	}
+
+	if character == nil {
+		return nil, fmt.Errorf("character not found: %w", storage.ErrNotFound)
+	}
+
+	amount := models.StatUpgradeAmount(channelPoints)
+	if !character.CanUpgradeStat(statType, channelPoints) || amount <= 0 {
+		return nil, ErrInvalidStatUpgrade
+	}
+
+	if _, err := cs.store.UpgradeCharacterStat(characterID, statType, amount, channelPoints); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("character not found: %w", err)
+		}
+		return nil, err
+	}
+	recordProgress(cs.store, characterID, models.QuestActionStateChanged)
+
+	return cs.GetCharacterByID(characterID)
 }
 
 // EquipItem equips an item to a character
 func (cs *CharacterService) EquipItem(characterID, itemID int) error {
-        // Get the item first to check its type
-        itemService := NewItemService()
-        item, err := itemService.GetItemByID(itemID)
-        if err != nil {
-                return err
-        }
-        
-        if item == nil {
-                return fmt.Errorf("item not found")
-        }
-        
-        // Get the character
-        character, err := cs.GetCharacterByID(characterID)
-        if err != nil {
-                return err
-        }
-        
-        if character == nil {
-                return fmt.Errorf("character not found")
-        }
-        
-        // Check if character owns this item
-        owns, err := itemService.CharacterOwnsItem(characterID, itemID)
-        if err != nil {
-                return err
-        }
-        
-        if !owns {
-                return fmt.Errorf("character does not own this item")
-        }
-        
-        // Equip the item based on its type
-        switch item.Type {
-        case models.ItemTypeBoots:
-                character.BootsID = &itemID
-        case models.ItemTypePants:
-                character.PantsID = &itemID
-        case models.ItemTypeArmor:
-                character.ArmorID = &itemID
-        case models.ItemTypeHelmet:
-                character.HelmetID = &itemID
-        case models.ItemTypeRing:
-                character.RingID = &itemID
-        case models.ItemTypeChain:
-                character.ChainID = &itemID
-        default:
-                return fmt.Errorf("invalid item type")
-        }
-        
-        return cs.UpdateCharacter(character)
+	// Get the item first to check its type
+	itemService := NewItemService(cs.store)
+	item, err := itemService.GetItemByID(itemID)
+	if err != nil {
+		return err
+	}
+
+	if item == nil {
+		return fmt.Errorf("item not found")
+	}
+
+	// Get the character
+	character, err := cs.GetCharacterByID(characterID)
+	if err != nil {
+		return err
+	}
+
+	if character == nil {
+		return fmt.Errorf("character not found: %w", storage.ErrNotFound)
+	}
+
+	// Check if character owns this item
+	owns, err := itemService.CharacterOwnsItem(characterID, itemID)
+	if err != nil {
+		return err
+	}
+
+	if !owns {
+		return fmt.Errorf("character does not own this item")
+	}
+
+	// Equip the item based on its type
+	switch item.Type {
+	case models.ItemTypeBoots:
+		character.BootsID = &itemID
+	case models.ItemTypePants:
+		character.PantsID = &itemID
+	case models.ItemTypeArmor:
+		character.ArmorID = &itemID
+	case models.ItemTypeHelmet:
+		character.HelmetID = &itemID
+	case models.ItemTypeRing:
+		character.RingID = &itemID
+	case models.ItemTypeChain:
+		character.ChainID = &itemID
+	default:
+		return fmt.Errorf("invalid item type")
+	}
+
+	if err := cs.UpdateCharacter(character); err != nil {
+		return err
+	}
+	eventbus.Publish(eventbus.ItemEquipped{Character: character, Item: item})
+	recordProgress(cs.store, characterID, models.QuestActionStateChanged)
+	return nil
 }
 
 // UnequipItem removes an equipped item from a character
 func (cs *CharacterService) UnequipItem(characterID int, slotType models.ItemType) error {
-        character, err := cs.GetCharacterByID(characterID)
-        if err != nil {
-                return err
-        }
-        
-        if character == nil {
-                return fmt.Errorf("character not found")
-        }
-        
-        // Unequip the item based on slot type
-        switch slotType {
-        case models.ItemTypeBoots:
-                character.BootsID = nil
-        case models.ItemTypePants:
-                character.PantsID = nil
-        case models.ItemTypeArmor:
-                character.ArmorID = nil
-        case models.ItemTypeHelmet:
-                character.HelmetID = nil
-        case models.ItemTypeRing:
-                character.RingID = nil
-        case models.ItemTypeChain:
-                character.ChainID = nil
-        default:
-                return fmt.Errorf("invalid slot type")
-        }
-        
-        return cs.UpdateCharacter(character)
+	character, err := cs.GetCharacterByID(characterID)
+	if err != nil {
+		return err
+	}
+
+	if character == nil {
+		return fmt.Errorf("character not found: %w", storage.ErrNotFound)
+	}
+
+	// Unequip the item based on slot type
+	switch slotType {
+	case models.ItemTypeBoots:
+		character.BootsID = nil
+	case models.ItemTypePants:
+		character.PantsID = nil
+	case models.ItemTypeArmor:
+		character.ArmorID = nil
+	case models.ItemTypeHelmet:
+		character.HelmetID = nil
+	case models.ItemTypeRing:
+		character.RingID = nil
+	case models.ItemTypeChain:
+		character.ChainID = nil
+	default:
+		return fmt.Errorf("invalid slot type")
+	}
+
+	return cs.UpdateCharacter(character)
 }
 
 // loadCharacterEquipment loads the equipped items for a character
 func (cs *CharacterService) loadCharacterEquipment(character *models.Character) error {
-        itemService := NewItemService()
-        equipment := &models.Equipment{}
-        
-        var err error
-        
-        // Load each equipped item
-        if character.BootsID != nil {
-                equipment.Boots, err = itemService.GetItemByID(*character.BootsID)
-                if err != nil {
-                        return err
-                }
-        }
-        
-        if character.PantsID != nil {
-                equipment.Pants, err = itemService.GetItemByID(*character.PantsID)
-                if err != nil {
-                        return err
-                }
-        }
-        
-        if character.ArmorID != nil {
-                equipment.Armor, err = itemService.GetItemByID(*character.ArmorID)
-                if err != nil {
-                        return err
-                }
-        }
-        
-        if character.HelmetID != nil {
-                equipment.Helmet, err = itemService.GetItemByID(*character.HelmetID)
-                if err != nil {
-                        return err
-                }
-        }
-        
-        if character.RingID != nil {
-                equipment.Ring, err = itemService.GetItemByID(*character.RingID)
-                if err != nil {
-                        return err
-                }
-        }
-        
-        if character.ChainID != nil {
-                equipment.Chain, err = itemService.GetItemByID(*character.ChainID)
-                if err != nil {
-                        return err
-                }
-        }
-        
-        character.Equipment = equipment
-        return nil
+	itemService := NewItemService(cs.store)
+	equipment := &models.Equipment{}
+
+	var err error
+
+	// Load each equipped item
+	if character.BootsID != nil {
+		equipment.Boots, err = itemService.GetItemByID(*character.BootsID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if character.PantsID != nil {
+		equipment.Pants, err = itemService.GetItemByID(*character.PantsID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if character.ArmorID != nil {
+		equipment.Armor, err = itemService.GetItemByID(*character.ArmorID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if character.HelmetID != nil {
+		equipment.Helmet, err = itemService.GetItemByID(*character.HelmetID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if character.RingID != nil {
+		equipment.Ring, err = itemService.GetItemByID(*character.RingID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if character.ChainID != nil {
+		equipment.Chain, err = itemService.GetItemByID(*character.ChainID)
+		if err != nil {
+			return err
+		}
+	}
+
+	character.Equipment = equipment
+	return nil
 }
 
 // GetCharacterInventory retrieves a character's inventory (list of owned items)
 func (cs *CharacterService) GetCharacterInventory(characterID int) ([]models.Item, error) {
-        if database.DB == nil {
-                return storage.Memory.GetCharacterInventory(characterID)
-        }
-        
-        query := `
-                SELECT i.id, i.name, i.type, i.rarity, i.strength_bonus, i.agility_bonus, 
-                       i.vitality_bonus, i.intelligence_bonus, i.created_at
-                FROM items i
-                JOIN character_inventory ci ON i.id = ci.item_id
-                WHERE ci.character_id = ?
-                ORDER BY i.rarity DESC, i.name ASC`
-        
-        rows, err := database.DB.Query(query, characterID)
-        if err != nil {
-                return nil, fmt.Errorf("failed to get character inventory: %v", err)
-        }
-        defer rows.Close()
-        
-        var items []models.Item
-        for rows.Next() {
-                var item models.Item
-                err := rows.Scan(
-                        &item.ID, &item.Name, &item.Type, &item.Rarity,
-                        &item.StrengthBonus, &item.AgilityBonus, &item.VitalityBonus, &item.IntelligenceBonus,
-                        &item.CreatedAt,
-                )
-                if err != nil {
-                        return nil, fmt.Errorf("failed to scan inventory item: %v", err)
-                }
-                items = append(items, item)
-        }
-        
-        return items, nil
+	characterItems, err := cs.store.GetCharacterItems(characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get character inventory: %v", err)
+	}
+
+	items := []models.Item{}
+	for _, ci := range characterItems {
+		items = append(items, *ci.Item)
+	}
+
+	return items, nil
 }
 
 // GetAllCharacters retrieves all characters with basic info
 func (cs *CharacterService) GetAllCharacters() ([]models.Character, error) {
-        if database.DB == nil {
-                return storage.Memory.GetAllCharacters()
-        }
-        query := `
-                SELECT id, username, level, experience, 
-                        strength, agility, vitality, intelligence, created_at
-                FROM characters 
-                ORDER BY level DESC, experience DESC`
-        
-        rows, err := database.DB.Query(query)
-        if err != nil {
-                return nil, fmt.Errorf("failed to get characters: %v", err)
-        }
-        defer rows.Close()
-        
-        var characters []models.Character
-        for rows.Next() {
-                var char models.Character
-                err := rows.Scan(
-                        &char.ID, &char.Username, &char.Level, &char.Experience,
-                        &char.Strength, &char.Agility, &char.Vitality, &char.Intelligence,
-                        &char.CreatedAt,
-                )
-                if err != nil {
-                        return nil, fmt.Errorf("failed to scan character: %v", err)
-                }
-                
-                // Calculate basic stats
-                char.CombatPower = char.CalculateCombatPower()
-                characters = append(characters, char)
-        }
-        
-        return characters, nil
-}
\ No newline at end of file
+	characters, err := cs.store.GetAllCharacters()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range characters {
+		// Calculate basic stats
+		characters[i].CombatPower = characters[i].CalculateCombatPower()
+	}
+
+	return characters, nil
+}
@@ -0,0 +1,556 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+	"twitch-rpg/internal/commands"
+	"twitch-rpg/internal/irc"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+)
+
+// defaultChatCommandCooldown is how often a viewer may use a command unless CHAT_COMMAND_COOLDOWN is set
+const defaultChatCommandCooldown = 3 * time.Second
+
+// shopCooldown keeps the merchant's offers from being listed over and over
+const shopCooldown = 15 * time.Second
+
+// defaultTwitchIRCURL is Twitch's chat server over TLS
+const defaultTwitchIRCURL = "ircs://irc.chat.twitch.tv:6697"
+
+// Where the chat bot reads commands from; replies are always sent over IRC
+const (
+	chatSourceIRC      = "irc"
+	chatSourceEventSub = "eventsub"
+)
+
+// chatConnection is the running chat client, which also answers commands that arrive
+// through EventSub
+var chatConnection atomic.Pointer[irc.Client]
+
+// StartChatBot answers game commands in Twitch chat when TWITCH_BOT_USERNAME,
+// TWITCH_BOT_TOKEN and TWITCH_CHANNEL are set. TWITCH_CHANNEL may list several channels
+// separated by commas; TWITCH_IRC_URL overrides the chat server, e.g. for a local fake.
+// Commands are read from IRC unless TWITCH_CHAT_SOURCE is "eventsub", for channels
+// whose chat messages are delivered by an EventSub channel.chat.message subscription.
+// The client reconnects on its own until ctx is done.
+func StartChatBot(ctx context.Context, store storage.Store) {
+	username := os.Getenv("TWITCH_BOT_USERNAME")
+	token := os.Getenv("TWITCH_BOT_TOKEN")
+	channels := os.Getenv("TWITCH_CHANNEL")
+	if username == "" && token == "" && channels == "" {
+		return
+	}
+	if username == "" || token == "" || channels == "" {
+		log.Println("TWITCH_BOT_USERNAME, TWITCH_BOT_TOKEN and TWITCH_CHANNEL must all be set, chat bot disabled")
+		return
+	}
+
+	source := os.Getenv("TWITCH_CHAT_SOURCE")
+	switch source {
+	case "":
+		source = chatSourceIRC
+	case chatSourceIRC, chatSourceEventSub:
+	default:
+		log.Printf("Invalid TWITCH_CHAT_SOURCE %q, reading commands from %s", source, chatSourceIRC)
+		source = chatSourceIRC
+	}
+
+	url := os.Getenv("TWITCH_IRC_URL")
+	if url == "" {
+		url = defaultTwitchIRCURL
+	}
+	client, err := irc.NewClient(url, username, token, strings.Split(channels, ",")...)
+	if err != nil {
+		log.Printf("%v, chat bot disabled", err)
+		return
+	}
+
+	bot := NewChatBotService(store)
+	client.Handler = func(msg *irc.Message) {
+		if source != chatSourceIRC || msg.Command != "PRIVMSG" || strings.EqualFold(msg.Nick(), username) {
+			return
+		}
+		request := commands.Request{
+			User: &models.ChatUser{
+				ID:          msg.Tags["user-id"],
+				Login:       msg.Nick(),
+				DisplayName: msg.Tags["display-name"],
+				Badges:      msg.Badges(),
+			},
+			Channel: msg.Param(0),
+			Text:    msg.Trailing(),
+		}
+		go func() {
+			reply := bot.Handle(request)
+			if reply == "" {
+				return
+			}
+			if err := client.Reply(msg, reply); err != nil {
+				log.Printf("Failed to reply to %s in %s: %v", request.User.Login, request.Channel, err)
+			}
+		}()
+	}
+	chatConnection.Store(client)
+	go client.Run(ctx)
+
+	log.Printf("Chat bot enabled as %s in %s, reading commands from %s", username, channels, source)
+}
+
+// ChatBotService runs game commands viewers type in chat
+type ChatBotService struct {
+	store  storage.Store
+	router *commands.Router
+}
+
+// NewChatBotService creates a new chat bot service with the game's commands. Help is
+// answered in the language of CHAT_LOCALE, English by default. How often a viewer may
+// use a command is read from CHAT_COMMAND_COOLDOWN (a Go duration such as "5s"),
+// defaulting to three seconds; moderators and the broadcaster are never throttled.
+func NewChatBotService(store storage.Store) *ChatBotService {
+	router := commands.NewRouter(chatLocale())
+	router.UserCooldown = chatCommandCooldown()
+	router.ErrorReply = chatErrorReply
+
+	cb := &ChatBotService{store: store, router: router}
+	for _, command := range []*commands.Command{
+		{Name: "char", Aliases: []string{"character"}, Handler: cb.character},
+		{Name: "stats", Handler: cb.stats},
+		{Name: "duel", Aliases: []string{"fight"}, Handler: cb.duel},
+		{Name: "accept", Handler: cb.acceptDuel},
+		{Name: "decline", Handler: cb.declineDuel},
+		{Name: "equip", Handler: cb.equip},
+		{Name: "item", Handler: cb.item},
+		{Name: "shop", GlobalCooldown: shopCooldown, Handler: cb.shop},
+		{Name: "buy", Handler: cb.buy},
+	} {
+		command.Usage = command.Name + ".usage"
+		command.Description = command.Name + ".description"
+		router.Register(command)
+	}
+	return cb
+}
+
+func chatLocale() string {
+	locale := os.Getenv("CHAT_LOCALE")
+	if locale == "" {
+		return commands.DefaultLocale
+	}
+	if !commands.SupportedLocale(locale) {
+		log.Printf("Unsupported CHAT_LOCALE %q, using %s (available: %s)",
+			locale, commands.DefaultLocale, strings.Join(commands.Locales(), ", "))
+		return commands.DefaultLocale
+	}
+	return locale
+}
+
+func chatCommandCooldown() time.Duration {
+	value := os.Getenv("CHAT_COMMAND_COOLDOWN")
+	if value == "" {
+		return defaultChatCommandCooldown
+	}
+
+	cooldown, err := time.ParseDuration(value)
+	if err != nil || cooldown < 0 {
+		log.Printf("Invalid CHAT_COMMAND_COOLDOWN %q, using %s", value, defaultChatCommandCooldown)
+		return defaultChatCommandCooldown
+	}
+	return cooldown
+}
+
+// Handle runs the command in a chat message and returns the reply. Messages that are
+// not game commands, and commands on cooldown, get no reply.
+func (cb *ChatBotService) Handle(request commands.Request) string {
+	return cb.router.Handle(request)
+}
+
+// HandleChatMessage answers a command delivered by EventSub over the chat connection.
+// Messages are ignored unless TWITCH_CHAT_SOURCE selects EventSub, so a command is
+// never answered twice.
+func (cb *ChatBotService) HandleChatMessage(event *models.ChatMessageEvent) {
+	if os.Getenv("TWITCH_CHAT_SOURCE") != chatSourceEventSub ||
+		strings.EqualFold(event.ChatterUserLogin, os.Getenv("TWITCH_BOT_USERNAME")) {
+		return
+	}
+
+	channel := irc.NormalizeChannel(event.BroadcasterUserLogin)
+	reply := cb.Handle(commands.Request{User: event.Chatter(), Channel: channel, Text: event.Message.Text})
+	if reply == "" {
+		return
+	}
+
+	client := chatConnection.Load()
+	if client == nil {
+		log.Printf("No chat connection to answer %s in %s: %s", event.ChatterUserLogin, channel, reply)
+		return
+	}
+	parent := &irc.Message{Tags: map[string]string{"id": event.MessageID}, Command: "PRIVMSG", Params: []string{channel}}
+	if err := client.Reply(parent, reply); err != nil {
+		log.Printf("Failed to reply to %s in %s: %v", event.ChatterUserLogin, channel, err)
+	}
+}
+
+// chatErrorReply words a failed command for chat. Errors of the game's rules are shown
+// as they are; anything else is logged and reported vaguely.
+func chatErrorReply(call *commands.Call, err error) string {
+	gameErrors := []error{
+		storage.ErrNotFound, storage.ErrInsufficientFunds, storage.ErrOutOfStock, storage.ErrMerchantInactive,
+		storage.ErrOfferMismatch, ErrSelfChallenge, ErrInvalidWager, ErrDuplicateChallenge,
+		ErrNotChallengeDefender, ErrChallengeNotPending, ErrChallengeExpired, ErrUnknownOffer,
+	}
+	var ambiguous *commands.AmbiguousError
+	isGameError := errors.As(err, &ambiguous)
+	for _, gameErr := range gameErrors {
+		isGameError = isGameError || errors.Is(err, gameErr)
+	}
+	if isGameError {
+		message := err.Error()
+		return strings.ToUpper(message[:1]) + message[1:] + "."
+	}
+
+	log.Printf("Chat command !%s of %s failed: %v", call.Command.Name, call.User.Login, err)
+	return call.T("error.generic")
+}
+
+// player finds the viewer's character, creating it on their first command
+func (cb *ChatBotService) player(user *models.ChatUser) (*models.Character, error) {
+	return NewCharacterService(cb.store).GetOrCreateCharacter(user.Login, user.ID)
+}
+
+// character answers !char with the level, power and gear of the viewer's character or
+// of the mentioned viewer's
+func (cb *ChatBotService) character(call *commands.Call) (string, error) {
+	name, err := call.Args.OptionalMention(0)
+	if err != nil {
+		return "", err
+	}
+
+	var character *models.Character
+	if name != "" && name != call.User.Login {
+		if character, err = NewCharacterService(cb.store).GetCharacterByUsername(name); err != nil {
+			return "", err
+		}
+		if character == nil {
+			return fmt.Sprintf("%s has no character yet.", name), nil
+		}
+	} else if character, err = cb.player(call.User); err != nil {
+		return "", err
+	}
+
+	gear := []string{}
+	if equipment := character.Equipment; equipment != nil {
+		for _, item := range []*models.Item{equipment.Helmet, equipment.Armor, equipment.Pants,
+			equipment.Boots, equipment.Ring, equipment.Chain} {
+			if item != nil {
+				gear = append(gear, item.Name)
+			}
+		}
+	}
+	equipped := "nothing equipped"
+	if len(gear) > 0 {
+		equipped = "equipped: " + strings.Join(gear, ", ")
+	}
+
+	return fmt.Sprintf("%s: level %d (%d XP), power %d, rating %d, %s.",
+		character.Username, character.Level, character.Experience, character.CombatPower, character.Rating, equipped), nil
+}
+
+// stats answers !stats with the viewer's stats, equipment bonuses included, and wallet balance
+func (cb *ChatBotService) stats(call *commands.Call) (string, error) {
+	character, err := cb.player(call.User)
+	if err != nil {
+		return "", err
+	}
+	wallet, err := NewWalletService(cb.store).GetWallet(character.ID)
+	if err != nil {
+		return "", err
+	}
+	balance := 0
+	if wallet != nil {
+		balance = wallet.Balance
+	}
+
+	base := character.CalculateBaseStats()
+	total := base
+	if character.TotalStats != nil {
+		total = *character.TotalStats
+	}
+	stat := func(name string, base, total int) string {
+		if total == base {
+			return fmt.Sprintf("%s %d", name, total)
+		}
+		return fmt.Sprintf("%s %d (%+d)", name, total, total-base)
+	}
+
+	return fmt.Sprintf("%s: %s, %s, %s, %s. %d points in the wallet.", character.Username,
+		stat("STR", base.Strength, total.Strength), stat("AGI", base.Agility, total.Agility),
+		stat("VIT", base.Vitality, total.Vitality), stat("INT", base.Intelligence, total.Intelligence), balance), nil
+}
+
+// duel answers !duel @user [wager] by challenging the mentioned viewer's character
+func (cb *ChatBotService) duel(call *commands.Call) (string, error) {
+	name, err := call.Args.Mention(0)
+	if err != nil {
+		return "", err
+	}
+	wager, err := call.Args.OptionalInt(1, 0)
+	if err != nil {
+		return "", err
+	}
+
+	challenger, err := cb.player(call.User)
+	if err != nil {
+		return "", err
+	}
+	defender, err := cb.store.GetCharacterByUsername(name)
+	if err != nil {
+		return "", err
+	}
+	if defender == nil {
+		return fmt.Sprintf("%s has no character yet.", name), nil
+	}
+
+	challenge, err := NewDuelService(cb.store).Challenge(challenger.ID, defender.ID, wager)
+	if err != nil {
+		return "", err
+	}
+
+	stakes := ""
+	if challenge.Wager > 0 {
+		stakes = fmt.Sprintf(" for %d points", challenge.Wager)
+	}
+	return fmt.Sprintf("@%s, %s challenges you to a duel%s! Type !accept or !decline within %s.",
+		defender.Username, call.User.Name(), stakes, time.Until(challenge.ExpiresAt).Round(time.Second)), nil
+}
+
+// pendingChallenge finds the newest unexpired challenge the viewer received, from the
+// mentioned challenger when the call names one
+func (cb *ChatBotService) pendingChallenge(call *commands.Call) (*models.Character, *models.DuelChallenge, error) {
+	name, err := call.Args.OptionalMention(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	defender, err := cb.player(call.User)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	challengerID := 0
+	if name != "" {
+		challenger, err := cb.store.GetCharacterByUsername(name)
+		if err != nil || challenger == nil {
+			return nil, nil, err
+		}
+		challengerID = challenger.ID
+	}
+
+	pending, err := NewDuelService(cb.store).GetPendingChallenges(defender.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var newest *models.DuelChallenge
+	for i := range pending {
+		challenge := &pending[i]
+		if challenge.DefenderID != defender.ID || (challengerID != 0 && challenge.ChallengerID != challengerID) {
+			continue
+		}
+		if newest == nil || challenge.CreatedAt.After(newest.CreatedAt) {
+			newest = challenge
+		}
+	}
+	return defender, newest, nil
+}
+
+// acceptDuel answers !accept [@user] by fighting the pending duel
+func (cb *ChatBotService) acceptDuel(call *commands.Call) (string, error) {
+	defender, challenge, err := cb.pendingChallenge(call)
+	if err != nil {
+		return "", err
+	}
+	if challenge == nil {
+		return "You have no pending duel challenge.", nil
+	}
+
+	outcome, err := NewDuelService(cb.store).Accept(challenge.ID, defender.ID)
+	if err != nil {
+		return "", err
+	}
+
+	result := outcome.Combat
+	reply := fmt.Sprintf("%s defeats %s", result.Winner.Username, result.Loser.Username)
+	if outcome.Payout > 0 {
+		reply += fmt.Sprintf(" and wins %d points", outcome.Payout)
+	}
+	if result.ExperienceGained > 0 {
+		reply += fmt.Sprintf(" (+%d XP)", result.ExperienceGained)
+	}
+	return reply + "!", nil
+}
+
+// declineDuel answers !decline [@user] by declining the pending duel
+func (cb *ChatBotService) declineDuel(call *commands.Call) (string, error) {
+	defender, challenge, err := cb.pendingChallenge(call)
+	if err != nil {
+		return "", err
+	}
+	if challenge == nil {
+		return "You have no pending duel challenge.", nil
+	}
+
+	if _, err := NewDuelService(cb.store).Decline(challenge.ID, defender.ID); err != nil {
+		return "", err
+	}
+	challenger, err := cb.store.GetCharacterByID(challenge.ChallengerID)
+	if err != nil || challenger == nil {
+		return fmt.Sprintf("%s declines the duel.", call.User.Name()), err
+	}
+	return fmt.Sprintf("%s declines the duel with %s.", call.User.Name(), challenger.Username), nil
+}
+
+// equip answers !equip <item> by equipping the inventory item the viewer named
+func (cb *ChatBotService) equip(call *commands.Call) (string, error) {
+	query := call.Args.Rest(0)
+	if query == "" {
+		return "", commands.ErrUsage
+	}
+
+	character, err := cb.player(call.User)
+	if err != nil {
+		return "", err
+	}
+	characterService := NewCharacterService(cb.store)
+	inventory, err := characterService.GetCharacterInventory(character.ID)
+	if err != nil {
+		return "", err
+	}
+
+	item, err := commands.MatchItem(query, inventory)
+	if errors.Is(err, commands.ErrNoMatch) {
+		catalog, err := NewItemService(cb.store).GetCatalog()
+		if err != nil {
+			return "", err
+		}
+		if known, err := commands.MatchItem(query, catalog); err == nil {
+			return fmt.Sprintf("You don't own %s.", known.Name), nil
+		}
+		return fmt.Sprintf("There is no item called %q.", query), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := characterService.EquipItem(character.ID, item.ID); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s equips %s (%s).", call.User.Name(), item.Name, item.Type), nil
+}
+
+// item answers !item <item> with the bonuses and value of a catalog item
+func (cb *ChatBotService) item(call *commands.Call) (string, error) {
+	query := call.Args.Rest(0)
+	if query == "" {
+		return "", commands.ErrUsage
+	}
+
+	catalog, err := NewItemService(cb.store).GetCatalog()
+	if err != nil {
+		return "", err
+	}
+	item, err := commands.MatchItem(query, catalog)
+	if errors.Is(err, commands.ErrNoMatch) {
+		return fmt.Sprintf("There is no item called %q.", query), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	bonuses := []string{}
+	for _, bonus := range []struct {
+		name  string
+		value int
+	}{
+		{"STR", item.StrengthBonus}, {"AGI", item.AgilityBonus},
+		{"VIT", item.VitalityBonus}, {"INT", item.IntelligenceBonus},
+	} {
+		if bonus.value != 0 {
+			bonuses = append(bonuses, fmt.Sprintf("%s %+d", bonus.name, bonus.value))
+		}
+	}
+	if len(bonuses) == 0 {
+		bonuses = append(bonuses, "no bonuses")
+	}
+
+	reply := fmt.Sprintf("%s (#%d): %s %s, %s, worth %d points.",
+		item.Name, item.ID, item.Rarity, item.Type, strings.Join(bonuses, ", "), item.Value)
+	if item.SpecialEffect != nil && *item.SpecialEffect != "" {
+		reply += " " + *item.SpecialEffect
+	}
+	return reply, nil
+}
+
+// shop answers !shop with the current merchant's offers
+func (cb *ChatBotService) shop(call *commands.Call) (string, error) {
+	event, err := NewMerchantService(cb.store).GetCurrentEvent()
+	if err != nil {
+		return "", err
+	}
+	if event == nil || len(event.Offers) == 0 {
+		return "The merchant is not around right now.", nil
+	}
+
+	offers := make([]string, len(event.Offers))
+	for i, offer := range event.Offers {
+		name := fmt.Sprintf("item %d", offer.ItemID)
+		if offer.Item != nil {
+			name = offer.Item.Name
+		}
+		offers[i] = fmt.Sprintf("%d. %s (%d points, %d left)", i+1, name, offer.PriceChannelPoints, offer.Stock-offer.Purchased)
+	}
+
+	leaves := ""
+	if event.EndTime != nil {
+		leaves = fmt.Sprintf(" The merchant leaves in %s.", time.Until(*event.EndTime).Round(time.Minute))
+	}
+	return fmt.Sprintf("Merchant: %s. Use !buy <number>.%s", strings.Join(offers, " | "), leaves), nil
+}
+
+// buy answers !buy <number|item> by buying a merchant offer with the viewer's wallet
+func (cb *ChatBotService) buy(call *commands.Call) (string, error) {
+	merchantService := NewMerchantService(cb.store)
+	event, err := merchantService.GetCurrentEvent()
+	if err != nil {
+		return "", err
+	}
+	if event == nil {
+		return "The merchant is not around right now.", nil
+	}
+	offer, err := merchantService.ChooseOffer(event, call.Args.Rest(0))
+	if err != nil {
+		return "", err
+	}
+
+	character, err := cb.player(call.User)
+	if err != nil {
+		return "", err
+	}
+	purchase, err := merchantService.PurchaseItem(character.ID, event.ID, offer.ID)
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("item %d", purchase.ItemID)
+	if purchase.Item != nil {
+		name = purchase.Item.Name
+	}
+	reply := fmt.Sprintf("%s buys %s for %d points", call.User.Name(), name, purchase.Price)
+	if purchase.Transaction != nil {
+		reply += fmt.Sprintf(", %d left in the wallet", purchase.Transaction.BalanceAfter)
+	}
+	return reply + ". Type !equip " + name + " to wear it.", nil
+}
@@ -0,0 +1,75 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"twitch-rpg/internal/irc"
+	"twitch-rpg/internal/irc/irctest"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+)
+
+// replyTo waits for the bot's reply threaded under the chat message with the given id
+func replyTo(server *irctest.Server, id string) *irc.Message {
+	return server.WaitFor(func(msg *irc.Message) bool {
+		return msg.Command == "PRIVMSG" && msg.Tags["reply-parent-msg-id"] == id
+	}, 5*time.Second)
+}
+
+func TestChatBotAnswersCommands(t *testing.T) {
+	server := irctest.NewServer("bot-token")
+	defer server.Close()
+	t.Setenv("TWITCH_BOT_USERNAME", "GameBot")
+	t.Setenv("TWITCH_BOT_TOKEN", "oauth:bot-token")
+	t.Setenv("TWITCH_CHANNEL", "cool_streamer")
+	t.Setenv("TWITCH_IRC_URL", server.URL)
+	t.Setenv("TWITCH_CHAT_SOURCE", "")
+	t.Setenv("CHAT_COMMAND_COOLDOWN", "0s")
+
+	store := storage.NewMemoryStorage()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	services.StartChatBot(ctx, store)
+	if server.WaitFor(func(msg *irc.Message) bool { return msg.Command == "JOIN" }, 5*time.Second) == nil {
+		t.Fatalf("chat bot did not join the channel")
+	}
+
+	// A viewer's first command creates their character, and the answer is threaded
+	id := server.Say("#cool_streamer", "cool_viewer", "!char", nil)
+	reply := replyTo(server, id)
+	if reply == nil || reply.Param(0) != "#cool_streamer" || !strings.HasPrefix(reply.Trailing(), "cool_viewer: level 1") {
+		t.Fatalf("reply to !char = %v; want the new character threaded under %s", reply, id)
+	}
+	character, err := store.GetCharacterByTwitchUserID(irctest.UserID("cool_viewer"))
+	if err != nil || character == nil || character.Username != "cool_viewer" {
+		t.Fatalf("GetCharacterByTwitchUserID = %v, %v; want the viewer's new character", character, err)
+	}
+
+	id = server.Say("#cool_streamer", "cool_viewer", "!stats", nil)
+	reply = replyTo(server, id)
+	if reply == nil || !strings.Contains(reply.Trailing(), "STR") || !strings.Contains(reply.Trailing(), "points in the wallet") {
+		t.Fatalf("reply to !stats = %v", reply)
+	}
+
+	// Chatter, unknown commands and the bot's own messages get no answer
+	server.Say("#cool_streamer", "cool_viewer", "hello chat", nil)
+	server.Say("#cool_streamer", "cool_viewer", "!dance", nil)
+	server.Say("#cool_streamer", "gamebot", "!char", nil)
+	id = server.Say("#cool_streamer", "other_viewer", "!char @cool_viewer", nil)
+	reply = replyTo(server, id)
+	if reply == nil || !strings.HasPrefix(reply.Trailing(), "cool_viewer: level 1") {
+		t.Fatalf("reply to !char @cool_viewer = %v", reply)
+	}
+
+	replies := 0
+	for _, msg := range server.Received() {
+		if msg.Command == "PRIVMSG" {
+			replies++
+		}
+	}
+	if replies != 3 {
+		t.Fatalf("bot sent %d messages; want only the 3 replies", replies)
+	}
+}
@@ -1,156 +1,210 @@
 package services
 
 import (
-        "fmt"
-        "math/rand"
-        "twitch-rpg/internal/database"
-        "twitch-rpg/internal/models"
-        "twitch-rpg/internal/storage"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"twitch-rpg/internal/combat"
+	"twitch-rpg/internal/eventbus"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
 )
 
+// ErrCombatNotReplayable is returned when verifying a fight logged before seeds were recorded
+var ErrCombatNotReplayable = errors.New("combat was logged without a seed and cannot be replayed")
+
 // CombatService handles combat-related operations
-type CombatService struct{}
+type CombatService struct {
+	store storage.Store
+}
 
 // NewCombatService creates a new combat service
-func NewCombatService() *CombatService {
-        return &CombatService{}
+func NewCombatService(store storage.Store) *CombatService {
+	return &CombatService{store: store}
 }
 
 // StartCombat initiates combat between two characters
 func (cs *CombatService) StartCombat(attackerID, defenderID int) (*models.CombatResult, error) {
-        if database.DB == nil {
-                // Use memory storage for testing
-                return cs.startCombatMemory(attackerID, defenderID)
-        }
-
-        // Get both characters
-        charService := NewCharacterService()
-        attacker, err := charService.GetCharacterByID(attackerID)
-        if err != nil {
-                return nil, fmt.Errorf("failed to get attacker: %v", err)
-        }
-        if attacker == nil {
-                return nil, fmt.Errorf("attacker not found")
-        }
-
-        defender, err := charService.GetCharacterByID(defenderID)
-        if err != nil {
-                return nil, fmt.Errorf("failed to get defender: %v", err)
-        }
-        if defender == nil {
-                return nil, fmt.Errorf("defender not found")
-        }
-
-        // Calculate combat power for both characters
-        attackerPower := attacker.CalculateCombatPower()
-        defenderPower := defender.CalculateCombatPower()
-
-        // Simulate combat with some randomness
-        attackerChance := float64(attackerPower) / float64(attackerPower+defenderPower)
-        randomRoll := rand.Float64()
-
-        winner := attacker
-        loser := defender
-        if randomRoll > attackerChance {
-                winner = defender
-                loser = attacker
-        }
-
-        // Calculate experience and rewards
-        experienceGained := 50 + (loser.Level * 10)
-        channelPointsReward := 25 + (loser.Level * 5)
-
-        // Update winner's stats
-        winner.Experience += experienceGained
-        winner.ChannelPointsSpent -= channelPointsReward // Give channel points as reward
-
-        // Check for level up
-        expForNextLevel := winner.Level * 100
-        if winner.Experience >= expForNextLevel {
-                winner.Level++
-                winner.Experience -= expForNextLevel
-        }
-
-        // Save changes
-        err = charService.UpdateCharacter(winner)
-        if err != nil {
-                return nil, fmt.Errorf("failed to update winner: %v", err)
-        }
-
-        // Create combat log
-        combatResult := &models.CombatResult{
-                Winner:           winner,
-                Loser:            loser,
-                AttackerPower:    attackerPower,
-                DefenderPower:    defenderPower,
-                CombatLog:        fmt.Sprintf("%s defeated %s! Experience gained: %d", winner.Username, loser.Username, experienceGained),
-                ExperienceGained: experienceGained,
-                RewardItems:      []models.Item{}, // No item rewards for now
-        }
-
-        err = cs.logCombat(combatResult)
-        if err != nil {
-                return nil, fmt.Errorf("failed to log combat: %v", err)
-        }
-
-        return combatResult, nil
+	// Get both characters
+	charService := NewCharacterService(cs.store)
+	attacker, err := charService.GetCharacterByID(attackerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attacker: %v", err)
+	}
+	if attacker == nil {
+		return nil, fmt.Errorf("attacker not found")
+	}
+
+	defender, err := charService.GetCharacterByID(defenderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get defender: %v", err)
+	}
+	if defender == nil {
+		return nil, fmt.Errorf("defender not found")
+	}
+
+	// Fight it out round by round from a fresh seed, which is logged so the fight can be replayed.
+	// Seeds stay below 2^53 so JavaScript overlays can read them without losing precision.
+	seed := rand.Int63n(1 << 53)
+	rng := combat.NewRNG(seed)
+	attackerFighter, defenderFighter := combat.NewFighter(attacker), combat.NewFighter(defender)
+	outcome := combat.Simulate(attackerFighter, defenderFighter, rng)
+
+	winner := attacker
+	loser := defender
+	if outcome.Winner.ID != attacker.ID {
+		winner = defender
+		loser = attacker
+	}
+
+	// Calculate experience and rewards
+	experienceGained := 50 + (loser.Level * 10)
+	channelPointsReward := 25 + (loser.Level * 5)
+
+	// Update winner's stats
+	leveledUp := grantExperience(winner, experienceGained)
+
+	// Save changes
+	err = charService.UpdateCharacter(winner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update winner: %v", err)
+	}
+	if leveledUp {
+		eventbus.Publish(eventbus.LevelUp{Character: winner})
+	}
+
+	// Create combat log
+	combatResult := &models.CombatResult{
+		Seed:          seed,
+		Winner:        winner,
+		Loser:         loser,
+		AttackerID:    attacker.ID,
+		AttackerPower: attacker.CalculateCombatPower(),
+		DefenderPower: defender.CalculateCombatPower(),
+		CombatLog: fmt.Sprintf("%s defeated %s in %d rounds! Experience gained: %d",
+			winner.Username, loser.Username, len(outcome.Transcript.Rounds), experienceGained),
+		Transcript:       outcome.Transcript,
+		ExperienceGained: experienceGained,
+		PointsAwarded:    channelPointsReward,
+	}
+
+	combatLog, err := cs.logCombat(attackerFighter, defenderFighter, combatResult)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log combat: %v", err)
+	}
+	combatResult.CombatLogID = combatLog.ID
+	eventbus.Publish(eventbus.CombatFinished{Result: combatResult})
+
+	// Every fight between two characters is rated
+	combatResult.RatingChanges, err = NewRatingService(cs.store).RecordFight(combatLog.ID, winner.ID, loser.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update ratings: %v", err)
+	}
+	winner.Rating, loser.Rating = combatResult.RatingChanges[0].RatingAfter, combatResult.RatingChanges[1].RatingAfter
+	winner.RatedFights++
+	loser.RatedFights++
+
+	// Pay the winner's channel point reward into their wallet
+	_, err = NewWalletService(cs.store).Credit(winner.ID, channelPointsReward, models.WalletReasonCombatReward,
+		models.ReferenceCombatLog, strconv.Itoa(combatLog.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to pay combat reward: %v", err)
+	}
+
+	// Loot rolls continue the fight's random stream, so the seed also decides the drop
+	combatResult.RewardItems, err = NewLootService(cs.store).RollCombatLoot(winner, loser, rng)
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll combat loot: %v", err)
+	}
+
+	recordProgress(cs.store, winner.ID, models.QuestActionFightWon)
+
+	return combatResult, nil
 }
 
 // GetCombatHistory retrieves recent combat history
 func (cs *CombatService) GetCombatHistory(limit int) ([]models.CombatLog, error) {
-        if database.DB == nil {
-                return storage.Memory.GetCombatHistory(limit)
-        }
-
-        query := `
-                SELECT cl.id, cl.attacker_id, cl.defender_id, cl.winner_id, 
-                       cl.attacker_power, cl.defender_power, cl.combat_log, cl.created_at
-                FROM combat_logs cl
-                ORDER BY cl.created_at DESC
-                LIMIT ?`
-
-        rows, err := database.DB.Query(query, limit)
-        if err != nil {
-                return nil, fmt.Errorf("failed to get combat history: %v", err)
-        }
-        defer rows.Close()
-
-        var combatLogs []models.CombatLog
-        for rows.Next() {
-                var log models.CombatLog
-                err := rows.Scan(
-                        &log.ID, &log.AttackerID, &log.DefenderID, &log.WinnerID,
-                        &log.AttackerPower, &log.DefenderPower, &log.CombatLogText,
-                        &log.CreatedAt,
-                )
-                if err != nil {
-                        return nil, fmt.Errorf("failed to scan combat log: %v", err)
-                }
-                combatLogs = append(combatLogs, log)
-        }
-
-        return combatLogs, nil
+	return cs.store.GetCombatHistory(limit)
+}
+
+// VerifyCombat replays a logged fight from its seed and fighter snapshots and
+// reports whether it reproduces the recorded winner and transcript
+func (cs *CombatService) VerifyCombat(combatLogID int) (*models.CombatVerification, error) {
+	combatLog, err := cs.store.GetCombatLogByID(combatLogID)
+	if err != nil {
+		return nil, err
+	}
+	if combatLog == nil {
+		return nil, storage.ErrNotFound
+	}
+	if combatLog.Seed == nil || combatLog.AttackerSnapshot == nil || combatLog.DefenderSnapshot == nil {
+		return nil, ErrCombatNotReplayable
+	}
+
+	outcome := combat.Simulate(
+		combat.NewFighterFromSnapshot(*combatLog.AttackerSnapshot),
+		combat.NewFighterFromSnapshot(*combatLog.DefenderSnapshot),
+		combat.NewRNG(*combatLog.Seed),
+	)
+
+	recorded, err := json.Marshal(combatLog.Transcript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode recorded transcript: %v", err)
+	}
+	replayed, err := json.Marshal(outcome.Transcript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode replayed transcript: %v", err)
+	}
+
+	verification := &models.CombatVerification{
+		CombatLogID:       combatLog.ID,
+		Seed:              *combatLog.Seed,
+		RecordedWinnerID:  combatLog.WinnerID,
+		ReplayedWinnerID:  outcome.Winner.ID,
+		TranscriptMatches: bytes.Equal(recorded, replayed),
+		Transcript:        outcome.Transcript,
+	}
+	verification.Verified = verification.TranscriptMatches && verification.RecordedWinnerID == verification.ReplayedWinnerID
+
+	return verification, nil
 }
 
-// logCombat records a combat result to the database
-func (cs *CombatService) logCombat(result *models.CombatResult) error {
-        query := `
-                INSERT INTO combat_logs (attacker_id, defender_id, winner_id, attacker_power, 
-                                        defender_power, combat_log)
-                VALUES (?, ?, ?, ?, ?, ?)`
-
-        // Determine attacker/defender from winner/loser
-        attackerID := result.Winner.ID  // Simplified logic
-        defenderID := result.Loser.ID
-
-        _, err := database.DB.Exec(query,
-                attackerID,
-                defenderID,
-                result.Winner.ID,
-                result.AttackerPower,
-                result.DefenderPower,
-                result.CombatLog,
-        )
-
-        return err
-}
\ No newline at end of file
+// grantExperience adds experience and levels the character up when it crosses Level*100.
+// It reports whether the character leveled up.
+func grantExperience(character *models.Character, experience int) bool {
+	character.Experience += experience
+
+	expForNextLevel := character.Level * 100
+	if character.Experience >= expForNextLevel {
+		character.Level++
+		character.Experience -= expForNextLevel
+		return true
+	}
+	return false
+}
+
+// logCombat records a combat result together with everything needed to replay it
+func (cs *CombatService) logCombat(attacker, defender combat.Fighter, result *models.CombatResult) (*models.CombatLog, error) {
+	seed := result.Seed
+	attackerSnapshot, defenderSnapshot := attacker.Snapshot(), defender.Snapshot()
+	combatLog := &models.CombatLog{
+		AttackerID:       attacker.ID,
+		DefenderID:       defender.ID,
+		WinnerID:         result.Winner.ID,
+		AttackerPower:    result.AttackerPower,
+		DefenderPower:    result.DefenderPower,
+		CombatLogText:    result.CombatLog,
+		Transcript:       result.Transcript,
+		Seed:             &seed,
+		AttackerSnapshot: &attackerSnapshot,
+		DefenderSnapshot: &defenderSnapshot,
+	}
+	if err := cs.store.AddCombatLog(combatLog); err != nil {
+		return nil, err
+	}
+
+	return combatLog, nil
+}
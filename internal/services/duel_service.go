@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+)
+
+// defaultDuelChallengeTTL is how long a defender has to respond unless DUEL_CHALLENGE_TTL is set
+const defaultDuelChallengeTTL = 2 * time.Minute
+
+var (
+	// ErrSelfChallenge is returned when a character challenges itself
+	ErrSelfChallenge = errors.New("characters cannot challenge themselves")
+	// ErrInvalidWager is returned for negative wagers
+	ErrInvalidWager = errors.New("wager cannot be negative")
+	// ErrDuplicateChallenge is returned when the pair already has a pending challenge
+	ErrDuplicateChallenge = storage.ErrDuelChallengePending
+	// ErrNotChallengeDefender is returned when someone other than the defender responds to a challenge
+	ErrNotChallengeDefender = errors.New("only the challenged character can respond")
+	// ErrChallengeNotPending is returned when a challenge was already answered
+	ErrChallengeNotPending = errors.New("challenge is no longer pending")
+	// ErrChallengeExpired is returned when responding after the deadline
+	ErrChallengeExpired = errors.New("challenge has expired")
+)
+
+// DuelService handles the challenge, accept and decline flow for duels
+type DuelService struct {
+	store storage.Store
+	ttl   time.Duration
+}
+
+// NewDuelService creates a new duel service. The response window is read from
+// DUEL_CHALLENGE_TTL (a Go duration such as "90s"), defaulting to two minutes.
+func NewDuelService(store storage.Store) *DuelService {
+	return &DuelService{store: store, ttl: duelChallengeTTL()}
+}
+
+func duelChallengeTTL() time.Duration {
+	value := os.Getenv("DUEL_CHALLENGE_TTL")
+	if value == "" {
+		return defaultDuelChallengeTTL
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		log.Printf("Invalid DUEL_CHALLENGE_TTL %q, using %s", value, defaultDuelChallengeTTL)
+		return defaultDuelChallengeTTL
+	}
+	return ttl
+}
+
+// Challenge creates a pending challenge and escrows the challenger's wager. The store
+// checks for a pending challenge between the pair and debits the wager in the same
+// operation, so concurrent challenges cannot both go through.
+func (ds *DuelService) Challenge(challengerID, defenderID, wager int) (*models.DuelChallenge, error) {
+	if challengerID == defenderID {
+		return nil, ErrSelfChallenge
+	}
+	if wager < 0 {
+		return nil, ErrInvalidWager
+	}
+	if err := ds.requireCharacter(challengerID, "challenger"); err != nil {
+		return nil, err
+	}
+	if err := ds.requireCharacter(defenderID, "defender"); err != nil {
+		return nil, err
+	}
+
+	challenge := &models.DuelChallenge{
+		ChallengerID: challengerID,
+		DefenderID:   defenderID,
+		Wager:        wager,
+		ExpiresAt:    time.Now().Add(ds.ttl),
+	}
+	if err := ds.store.CreateDuelChallenge(challenge); err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// GetChallenge retrieves a challenge by ID
+func (ds *DuelService) GetChallenge(id int) (*models.DuelChallenge, error) {
+	return ds.store.GetDuelChallengeByID(id)
+}
+
+// GetPendingChallenges lists unexpired challenges a character has sent or received
+func (ds *DuelService) GetPendingChallenges(characterID int) ([]models.DuelChallenge, error) {
+	pending, err := ds.store.GetPendingDuelChallenges(characterID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	open := []models.DuelChallenge{}
+	for _, challenge := range pending {
+		if challenge.ExpiresAt.After(now) {
+			open = append(open, challenge)
+		}
+	}
+	return open, nil
+}
+
+// Accept escrows the defender's wager, runs the fight and pays both wagers to the winner
+func (ds *DuelService) Accept(challengeID, characterID int) (*models.DuelOutcome, error) {
+	challenge, err := ds.pendingChallengeFor(challengeID, characterID)
+	if err != nil {
+		return nil, err
+	}
+
+	walletService := NewWalletService(ds.store)
+	reference := strconv.Itoa(challenge.ID)
+
+	var escrow *models.WalletTransaction
+	if challenge.Wager > 0 {
+		escrow, err = walletService.Debit(characterID, challenge.Wager, models.WalletReasonDuelWager,
+			models.ReferenceDuelChallenge, reference)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	accepted, err := ds.store.TransitionDuelChallenge(challenge.ID, models.DuelStatusPending, models.DuelStatusAccepted)
+	if err != nil || !accepted {
+		// Declined or expired while we were escrowing
+		if escrow != nil {
+			if refundErr := walletService.Refund(escrow); refundErr != nil {
+				log.Printf("Failed to refund duel wager for challenge %d: %v", challenge.ID, refundErr)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrChallengeNotPending
+	}
+
+	result, err := NewCombatService(ds.store).StartCombat(challenge.ChallengerID, challenge.DefenderID)
+	if err != nil {
+		if _, cancelErr := ds.store.TransitionDuelChallenge(challenge.ID, models.DuelStatusAccepted, models.DuelStatusCancelled); cancelErr != nil {
+			log.Printf("Failed to cancel duel challenge %d: %v", challenge.ID, cancelErr)
+		}
+		ds.refundWager(challenge, challenge.ChallengerID)
+		ds.refundWager(challenge, challenge.DefenderID)
+		return nil, fmt.Errorf("duel fight failed: %v", err)
+	}
+
+	payout := challenge.Wager * 2
+	if payout > 0 {
+		_, err := walletService.Credit(result.Winner.ID, payout, models.WalletReasonDuelPayout,
+			models.ReferenceDuelChallenge, reference)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pay duel winnings: %v", err)
+		}
+	}
+
+	if err := ds.store.SetDuelChallengeResult(challenge.ID, result.CombatLogID, result.Winner.ID); err != nil {
+		return nil, err
+	}
+
+	challenge, err = ds.store.GetDuelChallengeByID(challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DuelOutcome{Challenge: challenge, Combat: result, Payout: payout}, nil
+}
+
+// Decline rejects a challenge and returns the challenger's wager
+func (ds *DuelService) Decline(challengeID, characterID int) (*models.DuelChallenge, error) {
+	challenge, err := ds.pendingChallengeFor(challengeID, characterID)
+	if err != nil {
+		return nil, err
+	}
+
+	declined, err := ds.store.TransitionDuelChallenge(challenge.ID, models.DuelStatusPending, models.DuelStatusDeclined)
+	if err != nil {
+		return nil, err
+	}
+	if !declined {
+		return nil, ErrChallengeNotPending
+	}
+	ds.refundWager(challenge, challenge.ChallengerID)
+
+	return ds.store.GetDuelChallengeByID(challenge.ID)
+}
+
+// ExpireChallenges marks overdue challenges as expired and refunds their wagers
+func (ds *DuelService) ExpireChallenges(now time.Time) (int, error) {
+	overdue, err := ds.store.GetExpiredDuelChallenges(now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range overdue {
+		if ok, err := ds.expire(&overdue[i]); err != nil {
+			return expired, err
+		} else if ok {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// RunExpiryWorker expires overdue challenges every interval until ctx is cancelled
+func (ds *DuelService) RunExpiryWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if count, err := ds.ExpireChallenges(now); err != nil {
+				log.Printf("Failed to expire duel challenges: %v", err)
+			} else if count > 0 {
+				log.Printf("Expired %d duel challenge(s)", count)
+			}
+		}
+	}
+}
+
+// pendingChallengeFor loads a challenge the given character may respond to,
+// expiring it on the spot if the deadline has passed
+func (ds *DuelService) pendingChallengeFor(challengeID, characterID int) (*models.DuelChallenge, error) {
+	challenge, err := ds.store.GetDuelChallengeByID(challengeID)
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil {
+		return nil, fmt.Errorf("challenge not found: %w", storage.ErrNotFound)
+	}
+	if challenge.DefenderID != characterID {
+		return nil, ErrNotChallengeDefender
+	}
+	if challenge.Status != models.DuelStatusPending {
+		return nil, ErrChallengeNotPending
+	}
+	if !challenge.ExpiresAt.After(time.Now()) {
+		if _, err := ds.expire(challenge); err != nil {
+			return nil, err
+		}
+		return nil, ErrChallengeExpired
+	}
+
+	return challenge, nil
+}
+
+func (ds *DuelService) expire(challenge *models.DuelChallenge) (bool, error) {
+	expired, err := ds.store.TransitionDuelChallenge(challenge.ID, models.DuelStatusPending, models.DuelStatusExpired)
+	if err != nil || !expired {
+		return false, err
+	}
+	ds.refundWager(challenge, challenge.ChallengerID)
+	return true, nil
+}
+
+// refundWager returns an escrowed wager. Failures are logged rather than returned
+// because the challenge has already moved to its final status.
+func (ds *DuelService) refundWager(challenge *models.DuelChallenge, characterID int) {
+	if challenge.Wager <= 0 {
+		return
+	}
+
+	_, err := NewWalletService(ds.store).Credit(characterID, challenge.Wager, models.WalletReasonRefund,
+		models.ReferenceDuelChallenge, strconv.Itoa(challenge.ID))
+	if err != nil {
+		log.Printf("Failed to refund duel wager of character %d for challenge %d: %v", characterID, challenge.ID, err)
+	}
+}
+
+func (ds *DuelService) requireCharacter(id int, role string) error {
+	character, err := ds.store.GetCharacterByID(id)
+	if err != nil {
+		return err
+	}
+	if character == nil {
+		return fmt.Errorf("%s not found: %w", role, storage.ErrNotFound)
+	}
+	return nil
+}
@@ -1,130 +1,109 @@
 package services
 
 import (
-        "fmt"
-        "twitch-rpg/internal/database"
-        "twitch-rpg/internal/models"
-        "twitch-rpg/internal/storage"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"twitch-rpg/internal/eventbus"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
 )
 
 // EventService handles event-related operations
-type EventService struct{}
+type EventService struct {
+	store storage.Store
+}
 
 // NewEventService creates a new event service
-func NewEventService() *EventService {
-        return &EventService{}
+func NewEventService(store storage.Store) *EventService {
+	return &EventService{store: store}
 }
 
-// GetLatestEvents retrieves the most recent events
-func (es *EventService) GetLatestEvents(limit int) ([]models.Event, error) {
-        if database.DB == nil {
-                return storage.Memory.GetLatestEvents(limit)
-        }
-
-        query := `
-                SELECT id, type, title, description, data, is_triggered, created_at, expires_at
-                FROM events 
-                ORDER BY created_at DESC 
-                LIMIT ?`
-
-        rows, err := database.DB.Query(query, limit)
-        if err != nil {
-                return nil, fmt.Errorf("failed to get events: %v", err)
-        }
-        defer rows.Close()
-
-        var events []models.Event
-        for rows.Next() {
-                var event models.Event
-                err := rows.Scan(
-                        &event.ID, &event.Type, &event.Title, &event.Description,
-                        &event.Data, &event.IsTriggered, &event.CreatedAt, &event.ExpiresAt,
-                )
-                if err != nil {
-                        return nil, fmt.Errorf("failed to scan event: %v", err)
-                }
-                events = append(events, event)
-        }
-
-        return events, nil
+// GetLatestEvents retrieves the most recent game events for the overlay, newest first
+func (es *EventService) GetLatestEvents(limit int) ([]models.GameEvent, error) {
+	return es.store.GetLatestGameEvents(limit)
 }
 
-// MarkEventTriggered marks an event as triggered
+// MarkEventTriggered marks a game event as shown by the overlay
 func (es *EventService) MarkEventTriggered(eventID int) error {
-        if database.DB == nil {
-                return storage.Memory.MarkEventTriggered(eventID)
-        }
-
-        query := `UPDATE events SET is_triggered = true WHERE id = ?`
-        
-        result, err := database.DB.Exec(query, eventID)
-        if err != nil {
-                return fmt.Errorf("failed to mark event as triggered: %v", err)
-        }
+	if err := es.store.MarkGameEventTriggered(eventID); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("event not found: %w", storage.ErrNotFound)
+		}
+		return err
+	}
+	return nil
+}
 
-        rowsAffected, err := result.RowsAffected()
-        if err != nil {
-                return fmt.Errorf("failed to get rows affected: %v", err)
-        }
+// GetEventsAfter retrieves up to limit game events matching the filter that were stored
+// after afterID, oldest first
+func (es *EventService) GetEventsAfter(afterID int, filter models.GameEventFilter, limit int) ([]models.GameEvent, error) {
+	return es.store.GetGameEventsAfter(afterID, filter, limit)
+}
 
-        if rowsAffected == 0 {
-                return fmt.Errorf("event not found")
-        }
+// LatestEventID returns the ID of the newest game event, or 0 when there is none yet
+func (es *EventService) LatestEventID() (int, error) {
+	latest, err := es.store.GetLatestGameEvents(1)
+	if err != nil || len(latest) == 0 {
+		return 0, err
+	}
+	return latest[0].ID, nil
+}
 
-        return nil
+// Watch signals on the returned channel after events were published. Signals are
+// coalesced, so a reader should fetch everything new each time it wakes up. The
+// persisting subscriber is registered at startup before any watcher, so published
+// events are already stored when the signal arrives. Call stop to unsubscribe.
+func (es *EventService) Watch() (signal <-chan struct{}, stop func()) {
+	published := make(chan struct{}, 1)
+	stop = eventbus.Subscribe(func(eventbus.Event) {
+		select {
+		case published <- struct{}{}:
+		default:
+		}
+	})
+	return published, stop
 }
 
 // CreateEvent creates a new event
 func (es *EventService) CreateEvent(eventType, title, description string, data map[string]interface{}) (*models.Event, error) {
-        if database.DB == nil {
-                return nil, fmt.Errorf("database connection not available")
-        }
-
-        // Convert data to JSON string (simplified)
-        dataJSON := "{}"
-        if len(data) > 0 {
-                // In a real implementation, you'd use json.Marshal here
-                dataJSON = fmt.Sprintf("%v", data)
-        }
-
-        query := `
-                INSERT INTO events (type, title, description, data, is_triggered)
-                VALUES (?, ?, ?, ?, false)`
-
-        result, err := database.DB.Exec(query, eventType, title, description, dataJSON)
-        if err != nil {
-                return nil, fmt.Errorf("failed to create event: %v", err)
-        }
-
-        id, err := result.LastInsertId()
-        if err != nil {
-                return nil, fmt.Errorf("failed to get event ID: %v", err)
-        }
-
-        // Return the created event
-        return es.GetEventByID(int(id))
+	dataJSON := []byte("{}")
+	if len(data) > 0 {
+		var err error
+		dataJSON, err = json.Marshal(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode event data: %v", err)
+		}
+	}
+
+	event := &models.Event{
+		Type:        eventType,
+		Title:       title,
+		Description: description,
+		Data:        string(dataJSON),
+	}
+	if err := es.store.CreateEvent(event); err != nil {
+		return nil, err
+	}
+
+	return event, nil
 }
 
 // GetEventByID retrieves an event by ID
 func (es *EventService) GetEventByID(id int) (*models.Event, error) {
-        if database.DB == nil {
-                return nil, fmt.Errorf("database connection not available")
-        }
-
-        query := `
-                SELECT id, type, title, description, data, is_triggered, created_at, expires_at
-                FROM events 
-                WHERE id = ?`
-
-        event := &models.Event{}
-        err := database.DB.QueryRow(query, id).Scan(
-                &event.ID, &event.Type, &event.Title, &event.Description,
-                &event.Data, &event.IsTriggered, &event.CreatedAt, &event.ExpiresAt,
-        )
-
-        if err != nil {
-                return nil, fmt.Errorf("failed to get event: %v", err)
-        }
+	return es.store.GetEventByID(id)
+}
 
-        return event, nil
-}
\ No newline at end of file
+// PersistGameEvent is the event bus subscriber that stores every published event in
+// game_events for the overlay. Events are best effort and never fail the action that
+// published them, so errors from building or storing the event are logged.
+func (es *EventService) PersistGameEvent(event eventbus.Event) {
+	gameEvent, err := event.GameEvent()
+	if err == nil {
+		err = es.store.CreateGameEvent(gameEvent)
+	}
+	if err != nil {
+		log.Printf("Failed to record %s event: %v", event.Type(), err)
+	}
+}
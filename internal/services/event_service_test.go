@@ -0,0 +1,56 @@
+package services_test
+
+import (
+	"testing"
+	"twitch-rpg/internal/eventbus"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/services"
+	"twitch-rpg/internal/storage"
+	"twitch-rpg/internal/storage/storagetest"
+)
+
+func TestPersistGameEvent(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	bus := eventbus.NewBus()
+	bus.Subscribe(services.NewEventService(store).PersistGameEvent)
+
+	character := storagetest.MustCreateCharacter(t, store)
+	character.Level = 2
+	bus.Publish(eventbus.LevelUp{Character: character})
+	bus.Publish(eventbus.ItemEquipped{Character: character, Item: &models.Item{ID: 7, Name: "Iron Boots", Type: models.ItemTypeBoots}})
+
+	events, err := store.GetLatestGameEvents(10)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("GetLatestGameEvents = %+v, %v; want both events stored", events, err)
+	}
+	if events[0].EventType != models.EventTypeItemEquipped || events[1].EventType != models.EventTypeLevelUp {
+		t.Fatalf("stored %s and %s; want item_equipped after level_up", events[1].EventType, events[0].EventType)
+	}
+	if events[1].CharacterID == nil || *events[1].CharacterID != character.ID {
+		t.Fatalf("level-up event = %+v; want it tied to character %d", events[1], character.ID)
+	}
+}
+
+func TestWatchDoesNotBlockPublishers(t *testing.T) {
+	signal, stop := services.NewEventService(storage.NewMemoryStorage()).Watch()
+	defer stop()
+
+	// Nobody reads the signal, yet publishing carries on and the signals coalesce
+	for i := 0; i < 100; i++ {
+		eventbus.Publish(eventbus.LevelUp{Character: &models.Character{ID: 1, Level: i}})
+	}
+	<-signal
+	select {
+	case <-signal:
+		t.Fatalf("signals were not coalesced")
+	default:
+	}
+
+	stop()
+	eventbus.Publish(eventbus.LevelUp{Character: &models.Character{ID: 1}})
+	select {
+	case <-signal:
+		t.Fatalf("signal after stop")
+	default:
+	}
+}
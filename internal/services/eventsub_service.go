@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"twitch-rpg/internal/eventsub"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+)
+
+// eventSubScope is the idempotency scope EventSub message IDs are recorded under
+const eventSubScope = "twitch_eventsub"
+
+// ErrEventSubDisabled is returned when no EventSub secret is configured
+var ErrEventSubDisabled = errors.New("Twitch EventSub is not configured")
+
+// EventSubReply is what a webhook delivery should be answered with. Challenge is set
+// for verification messages and Outcome for redemptions of rewards that are part of
+// the game.
+type EventSubReply struct {
+	Challenge string
+	Duplicate bool
+	Outcome   *models.RedemptionOutcome
+}
+
+// EventSubService receives Twitch EventSub webhook deliveries
+type EventSubService struct {
+	secret      string
+	idempotency *IdempotencyService
+	redemptions *RedemptionService
+	rewards     *ChannelRewardService
+	chatBot     *ChatBotService
+}
+
+// NewEventSubService creates a new EventSub service verifying deliveries with the
+// secret from TWITCH_EVENTSUB_SECRET
+func NewEventSubService(store storage.Store) *EventSubService {
+	return &EventSubService{
+		secret:      os.Getenv("TWITCH_EVENTSUB_SECRET"),
+		idempotency: NewIdempotencyService(store),
+		redemptions: NewRedemptionService(store),
+		rewards:     NewChannelRewardService(),
+		chatBot:     NewChatBotService(store),
+	}
+}
+
+// HandleMessage verifies a delivery and acts on it. Each message ID is handled once;
+// retried deliveries are reported as duplicates. An error other than a verification
+// failure leaves the message unhandled so Twitch's retry can try again.
+func (es *EventSubService) HandleMessage(headers eventsub.Headers, body []byte) (*EventSubReply, error) {
+	if es.secret == "" {
+		return nil, ErrEventSubDisabled
+	}
+	if err := eventsub.Verify(es.secret, headers, body, time.Now()); err != nil {
+		return nil, err
+	}
+	message, err := eventsub.ParseMessage(headers.MessageType, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if headers.MessageType == eventsub.MessageTypeVerification {
+		log.Printf("Verified EventSub subscription %s (%s)", message.Subscription.ID, message.Subscription.Type)
+		return &EventSubReply{Challenge: message.Challenge}, nil
+	}
+
+	replay, err := es.idempotency.Begin(eventSubScope, headers.MessageID, body)
+	if errors.Is(err, ErrIdempotencyKeyReused) {
+		// Message IDs are unique per delivery, so this is a retry whose body Twitch re-encoded
+		return &EventSubReply{Duplicate: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if replay != nil {
+		return &EventSubReply{Duplicate: true}, nil
+	}
+
+	reply := &EventSubReply{}
+	switch headers.MessageType {
+	case eventsub.MessageTypeNotification:
+		if reply.Outcome, err = es.handleNotification(message); err != nil {
+			if releaseErr := es.idempotency.Release(eventSubScope, headers.MessageID); releaseErr != nil {
+				log.Printf("Failed to release EventSub message %s: %v", headers.MessageID, releaseErr)
+			}
+			return nil, err
+		}
+	case eventsub.MessageTypeRevocation:
+		log.Printf("Twitch revoked EventSub subscription %s (%s): %s",
+			message.Subscription.ID, message.Subscription.Type, message.Subscription.Status)
+	}
+
+	record, err := json.Marshal(reply.Outcome)
+	if err == nil {
+		err = es.idempotency.Complete(eventSubScope, headers.MessageID, 204, record)
+	}
+	if err != nil {
+		log.Printf("Failed to record EventSub message %s as handled: %v", headers.MessageID, err)
+	}
+	return reply, nil
+}
+
+// handleNotification dispatches the event of a notification by subscription type
+func (es *EventSubService) handleNotification(message *eventsub.Message) (*models.RedemptionOutcome, error) {
+	switch message.Subscription.Type {
+	case eventsub.SubscriptionRedemptionAdd:
+		redemption := &models.ChannelPointRedemption{}
+		if err := json.Unmarshal(message.Event, redemption); err != nil {
+			return nil, fmt.Errorf("%w: %v", eventsub.ErrInvalidMessage, err)
+		}
+
+		outcome, err := es.redemptions.Redeem(redemption)
+		if err != nil {
+			return nil, fmt.Errorf("failed to handle redemption %s: %v", redemption.ID, err)
+		}
+		if outcome == nil {
+			return nil, nil
+		}
+		if !outcome.Fulfilled {
+			log.Printf("Redemption %s of %q by %s failed: %s",
+				redemption.ID, redemption.Reward.Title, redemption.UserLogin, outcome.Error)
+		}
+		// The game action already happened, so a failure to update Twitch leaves the
+		// redemption in the reward queue for the broadcaster instead of failing the delivery
+		if es.rewards.Enabled() {
+			if outcome.RedemptionStatus, err = es.rewards.CompleteRedemption(context.Background(), redemption, outcome); err != nil {
+				log.Println(err)
+			}
+		}
+		return outcome, nil
+
+	case eventsub.SubscriptionChatMessage:
+		event := &models.ChatMessageEvent{}
+		if err := json.Unmarshal(message.Event, event); err != nil {
+			return nil, fmt.Errorf("%w: %v", eventsub.ErrInvalidMessage, err)
+		}
+		es.chatBot.HandleChatMessage(event)
+		return nil, nil
+
+	default:
+		log.Printf("Ignoring EventSub notification of type %s", message.Subscription.Type)
+		return nil, nil
+	}
+}
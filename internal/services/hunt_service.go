@@ -0,0 +1,182 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"twitch-rpg/internal/combat"
+	"twitch-rpg/internal/eventbus"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+)
+
+// HuntService handles PvE fights against bestiary monsters
+type HuntService struct {
+	store storage.Store
+}
+
+// NewHuntService creates a new hunt service
+func NewHuntService(store storage.Store) *HuntService {
+	return &HuntService{store: store}
+}
+
+// GetMonsters retrieves the bestiary
+func (hs *HuntService) GetMonsters() ([]models.Monster, error) {
+	monsters, err := hs.store.GetMonsters()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range monsters {
+		if err := hs.loadLootItems(&monsters[i]); err != nil {
+			return nil, err
+		}
+	}
+	return monsters, nil
+}
+
+// GetMonster retrieves a bestiary entry with its loot table
+func (hs *HuntService) GetMonster(id int) (*models.Monster, error) {
+	monster, err := hs.store.GetMonsterByID(id)
+	if err != nil || monster == nil {
+		return nil, err
+	}
+
+	if err := hs.loadLootItems(monster); err != nil {
+		return nil, err
+	}
+	return monster, nil
+}
+
+// GetHuntHistory retrieves a character's recent hunts
+func (hs *HuntService) GetHuntHistory(characterID, limit int) ([]models.HuntLog, error) {
+	return hs.store.GetHuntHistory(characterID, limit)
+}
+
+// Hunt sends a character against a level-appropriate monster using the same combat
+// engine as PvP. Winning awards experience and rolls the monster's loot table.
+// Monster choice, the fight and the loot rolls all draw from one logged seed.
+func (hs *HuntService) Hunt(characterID int) (*models.HuntResult, error) {
+	charService := NewCharacterService(hs.store)
+	character, err := charService.GetCharacterByID(characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get character: %v", err)
+	}
+	if character == nil {
+		return nil, fmt.Errorf("character not found: %w", storage.ErrNotFound)
+	}
+
+	monsters, err := hs.store.GetMonsters()
+	if err != nil {
+		return nil, err
+	}
+
+	seed := rand.Int63n(1 << 53)
+	rng := combat.NewRNG(seed)
+
+	monster := pickMonster(monsters, character.Level, rng)
+	if monster == nil {
+		return nil, fmt.Errorf("the bestiary is empty: %w", storage.ErrNotFound)
+	}
+	level := monster.LevelFor(character.Level)
+
+	outcome := combat.Simulate(combat.NewFighter(character), combat.NewMonsterFighter(monster, level), rng)
+
+	result := &models.HuntResult{
+		Character:    character,
+		Monster:      monster,
+		MonsterLevel: level,
+		Won:          outcome.Winner.ID == character.ID,
+		Drops:        []models.Item{},
+		Seed:         seed,
+		Transcript:   outcome.Transcript,
+	}
+
+	huntLog := &models.HuntLog{
+		CharacterID:  character.ID,
+		MonsterID:    monster.ID,
+		MonsterLevel: level,
+		Won:          result.Won,
+		DropItemIDs:  []int{},
+		Seed:         seed,
+		Transcript:   outcome.Transcript,
+	}
+
+	if result.Won {
+		result.ExperienceGained = monster.ExperienceAtLevel(level)
+		result.LeveledUp = grantExperience(character, result.ExperienceGained)
+		if err := charService.UpdateCharacter(character); err != nil {
+			return nil, fmt.Errorf("failed to update character: %v", err)
+		}
+		if result.LeveledUp {
+			eventbus.Publish(eventbus.LevelUp{Character: character})
+		}
+		huntLog.ExperienceGained = result.ExperienceGained
+
+		for _, loot := range monster.Loot {
+			if rng.Float64() >= loot.DropChance {
+				continue
+			}
+			item, err := hs.store.GetItemByID(loot.ItemID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load drop: %v", err)
+			}
+			if item == nil {
+				continue
+			}
+			if err := hs.store.AddItemToCharacter(character.ID, item.ID, max(loot.Quantity, 1)); err != nil {
+				return nil, fmt.Errorf("failed to add drop to inventory: %v", err)
+			}
+			eventbus.Publish(eventbus.ItemAcquired{Character: character, Item: item, Method: eventbus.MethodCombatReward})
+			result.Drops = append(result.Drops, *item)
+			huntLog.DropItemIDs = append(huntLog.DropItemIDs, item.ID)
+		}
+	}
+
+	if err := hs.store.AddHuntLog(huntLog); err != nil {
+		return nil, fmt.Errorf("failed to log hunt: %v", err)
+	}
+	result.HuntID = huntLog.ID
+
+	if result.Won {
+		recordProgress(hs.store, character.ID, models.QuestActionFightWon)
+	}
+
+	if err := hs.loadLootItems(monster); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// pickMonster chooses a random monster whose level range covers level, falling
+// back to the monsters with the closest range when none does
+func pickMonster(monsters []models.Monster, level int, rng *rand.Rand) *models.Monster {
+	var candidates []*models.Monster
+	bestDistance := -1
+	for i := range monsters {
+		monster := &monsters[i]
+		distance := max(monster.LevelMin-level, level-monster.LevelMax, 0)
+		switch {
+		case bestDistance < 0 || distance < bestDistance:
+			candidates, bestDistance = []*models.Monster{monster}, distance
+		case distance == bestDistance:
+			candidates = append(candidates, monster)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[rng.Intn(len(candidates))]
+}
+
+// loadLootItems populates the items of a monster's loot table
+func (hs *HuntService) loadLootItems(monster *models.Monster) error {
+	for i := range monster.Loot {
+		item, err := hs.store.GetItemByID(monster.Loot[i].ItemID)
+		if err != nil {
+			return fmt.Errorf("failed to load loot item: %v", err)
+		}
+		monster.Loot[i].Item = item
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+)
+
+// idempotencyStaleAfter is how long a reservation may stay pending before another
+// request with the same key may take it over, e.g. after a crash mid-request
+const idempotencyStaleAfter = 2 * time.Minute
+
+// ErrIdempotencyKeyReused is returned when a key is replayed with a different request
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+
+// ErrIdempotencyInProgress is returned when the first request with a key has not finished yet
+var ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still in progress")
+
+// IdempotencyService records the outcome of requests carrying an idempotency key,
+// such as a Twitch redemption ID, so retries replay the original result
+type IdempotencyService struct {
+	store storage.Store
+}
+
+// NewIdempotencyService creates a new idempotency service
+func NewIdempotencyService(store storage.Store) *IdempotencyService {
+	return &IdempotencyService{store: store}
+}
+
+// Begin reserves a key for a request. It returns nil if the caller should execute the request,
+// or the completed record to replay if the same request already ran.
+func (is *IdempotencyService) Begin(scope, key string, request []byte) (*models.IdempotencyRecord, error) {
+	hash := sha256.Sum256(request)
+	record := &models.IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: hex.EncodeToString(hash[:]),
+	}
+
+	existing, err := is.store.ReserveIdempotencyKey(record, time.Now().Add(-idempotencyStaleAfter))
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	if existing.RequestHash != record.RequestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if !existing.Completed {
+		return nil, ErrIdempotencyInProgress
+	}
+
+	return existing, nil
+}
+
+// Complete stores the response of a request started with Begin
+func (is *IdempotencyService) Complete(scope, key string, statusCode int, response []byte) error {
+	return is.store.CompleteIdempotencyKey(scope, key, statusCode, bytes.Clone(response))
+}
+
+// Release drops the reservation of a request that failed without side effects so it can be retried
+func (is *IdempotencyService) Release(scope, key string) error {
+	return is.store.ReleaseIdempotencyKey(scope, key)
+}
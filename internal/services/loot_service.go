@@ -0,0 +1,113 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"twitch-rpg/internal/eventbus"
+	"twitch-rpg/internal/loot"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+)
+
+// lootCatalogLimit caps how many items of one type are considered when picking a drop
+const lootCatalogLimit = 500
+
+var (
+	lootTableOnce sync.Once
+	lootTable     *loot.Table
+)
+
+// configuredLootTable loads the loot table named by LOOT_TABLE_PATH once, falling
+// back to the built-in table when the variable is unset or the file is invalid
+func configuredLootTable() *loot.Table {
+	lootTableOnce.Do(func() {
+		lootTable = loot.Default()
+
+		path := os.Getenv("LOOT_TABLE_PATH")
+		if path == "" {
+			return
+		}
+		table, err := loot.Load(path)
+		if err != nil {
+			log.Printf("%v, using the built-in loot table", err)
+			return
+		}
+		lootTable = table
+		log.Printf("Loaded loot table from %s", path)
+	})
+	return lootTable
+}
+
+// LootService rolls item drops after fights and hands them to the winner
+type LootService struct {
+	store storage.Store
+	table *loot.Table
+}
+
+// NewLootService creates a new loot service using the configured loot table
+func NewLootService(store storage.Store) *LootService {
+	return &LootService{store: store, table: configuredLootTable()}
+}
+
+// RollCombatLoot rolls the loot table for a won fight. A drop is added to the winner's
+// inventory and announced with an item-acquired event. Rolls draw from rng so a
+// seeded fight always drops the same kind of item.
+func (ls *LootService) RollCombatLoot(winner, loser *models.Character, rng *rand.Rand) ([]models.Item, error) {
+	drops := []models.Item{}
+
+	drop, ok := ls.table.Roll(winner.Level, loser.Level, rng)
+	if !ok {
+		return drops, nil
+	}
+
+	item, err := ls.pickItem(drop, rng)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return drops, nil
+	}
+
+	if err := NewItemService(ls.store).AddItemToCharacter(winner.ID, item.ID, 1); err != nil {
+		return nil, fmt.Errorf("failed to add drop to inventory: %v", err)
+	}
+	eventbus.Publish(eventbus.ItemAcquired{Character: winner, Item: item, Method: eventbus.MethodCombatReward})
+
+	return append(drops, *item), nil
+}
+
+// pickItem chooses a catalog item of the dropped type and rarity. When the catalog has
+// none of that rarity the next more common rarity is tried. Special merchant items never drop.
+func (ls *LootService) pickItem(drop loot.Drop, rng *rand.Rand) (*models.Item, error) {
+	items, err := NewItemService(ls.store).GetItemsByType(drop.Type, lootCatalogLimit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load items for loot: %v", err)
+	}
+
+	byRarity := map[models.ItemRarity][]models.Item{}
+	for _, item := range items {
+		if !item.IsSpecial {
+			byRarity[item.Rarity] = append(byRarity[item.Rarity], item)
+		}
+	}
+
+	for rank := rarityIndex(drop.Rarity); rank >= 0; rank-- {
+		if candidates := byRarity[loot.Rarities[rank]]; len(candidates) > 0 {
+			item := candidates[rng.Intn(len(candidates))]
+			return &item, nil
+		}
+	}
+	return nil, nil
+}
+
+func rarityIndex(rarity models.ItemRarity) int {
+	for i, candidate := range loot.Rarities {
+		if candidate == rarity {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+)
+
+const (
+	// Rating difference accepted right away, widened the longer a ticket waits
+	matchRatingWindow       = 100
+	matchRatingWindowGrowth = 10 // per second waited
+	maxMatchRatingWindow    = 400
+
+	// Relative combat power difference accepted right away, widened the longer a ticket waits
+	matchPowerTolerance       = 0.20
+	matchPowerToleranceGrowth = 0.01 // per second waited
+	maxMatchPowerTolerance    = 0.50
+
+	// matchmakingTicketTTL drops characters that waited too long without a match
+	matchmakingTicketTTL = 10 * time.Minute
+)
+
+// ErrAlreadyQueued is returned when a character joins the queue twice
+var ErrAlreadyQueued = errors.New("character is already in the matchmaking queue")
+
+// MatchmakingService pairs queued characters of similar rating and combat power
+// for rated fights. The queue lives in memory, so one instance must be shared
+// by the HTTP handlers and the background matcher.
+type MatchmakingService struct {
+	store   storage.Store
+	mutex   sync.Mutex
+	queue   []models.MatchmakingTicket
+	matches map[int]*models.Match // latest match per character
+}
+
+// NewMatchmakingService creates a new matchmaking service with an empty queue
+func NewMatchmakingService(store storage.Store) *MatchmakingService {
+	return &MatchmakingService{
+		store:   store,
+		queue:   []models.MatchmakingTicket{},
+		matches: make(map[int]*models.Match),
+	}
+}
+
+// Join queues a character. If a suitable opponent is already waiting the fight runs
+// immediately and the match is returned instead of a ticket.
+func (ms *MatchmakingService) Join(characterID int) (*models.MatchmakingTicket, *models.Match, error) {
+	character, err := NewCharacterService(ms.store).GetCharacterByID(characterID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if character == nil {
+		return nil, nil, fmt.Errorf("character not found: %w", storage.ErrNotFound)
+	}
+
+	ticket := models.MatchmakingTicket{
+		CharacterID: character.ID,
+		Username:    character.Username,
+		Rating:      character.Rating,
+		CombatPower: character.CombatPower,
+		QueuedAt:    time.Now(),
+	}
+
+	ms.mutex.Lock()
+	ms.dropStaleLocked(ticket.QueuedAt)
+	for _, queued := range ms.queue {
+		if queued.CharacterID == characterID {
+			ms.mutex.Unlock()
+			return nil, nil, ErrAlreadyQueued
+		}
+	}
+
+	best := -1
+	bestScore := math.Inf(1)
+	for i := range ms.queue {
+		if score, ok := matchScore(&ms.queue[i], &ticket, ticket.QueuedAt); ok && score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		ms.queue = append(ms.queue, ticket)
+		ms.mutex.Unlock()
+		return &ticket, nil, nil
+	}
+
+	opponent := ms.queue[best]
+	ms.queue = append(ms.queue[:best], ms.queue[best+1:]...)
+	ms.mutex.Unlock()
+
+	// The character who waited longer attacks
+	match, err := ms.fight(opponent, ticket)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, match, nil
+}
+
+// Leave removes a character from the queue and reports whether it was queued
+func (ms *MatchmakingService) Leave(characterID int) bool {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	for i, queued := range ms.queue {
+		if queued.CharacterID == characterID {
+			ms.queue = append(ms.queue[:i], ms.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Queue returns the waiting characters, longest waiting first
+func (ms *MatchmakingService) Queue() []models.MatchmakingTicket {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.dropStaleLocked(time.Now())
+	return append([]models.MatchmakingTicket{}, ms.queue...)
+}
+
+// Status returns a character's ticket while queued, or its latest match once paired
+func (ms *MatchmakingService) Status(characterID int) (*models.MatchmakingTicket, *models.Match) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	for _, queued := range ms.queue {
+		if queued.CharacterID == characterID {
+			ticket := queued
+			return &ticket, nil
+		}
+	}
+	return nil, ms.matches[characterID]
+}
+
+// MatchWaiting pairs queued characters whose search windows have widened enough to meet
+func (ms *MatchmakingService) MatchWaiting(now time.Time) []*models.Match {
+	var pairs [][2]models.MatchmakingTicket
+
+	ms.mutex.Lock()
+	ms.dropStaleLocked(now)
+	for i := 0; i < len(ms.queue); i++ {
+		best := -1
+		bestScore := math.Inf(1)
+		for j := i + 1; j < len(ms.queue); j++ {
+			if score, ok := matchScore(&ms.queue[i], &ms.queue[j], now); ok && score < bestScore {
+				best, bestScore = j, score
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		pairs = append(pairs, [2]models.MatchmakingTicket{ms.queue[i], ms.queue[best]})
+		ms.queue = append(ms.queue[:best], ms.queue[best+1:]...)
+		ms.queue = append(ms.queue[:i], ms.queue[i+1:]...)
+		i--
+	}
+	ms.mutex.Unlock()
+
+	matches := []*models.Match{}
+	for _, pair := range pairs {
+		match, err := ms.fight(pair[0], pair[1])
+		if err != nil {
+			log.Printf("Failed to run matchmaking fight %d vs %d: %v", pair[0].CharacterID, pair[1].CharacterID, err)
+			continue
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// RunMatcher pairs waiting characters every interval until ctx is cancelled
+func (ms *MatchmakingService) RunMatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if matches := ms.MatchWaiting(now); len(matches) > 0 {
+				log.Printf("Matchmaking paired %d fight(s)", len(matches))
+			}
+		}
+	}
+}
+
+func (ms *MatchmakingService) fight(attacker, defender models.MatchmakingTicket) (*models.Match, error) {
+	result, err := NewCombatService(ms.store).StartCombat(attacker.CharacterID, defender.CharacterID)
+	if err != nil {
+		return nil, err
+	}
+
+	match := &models.Match{
+		AttackerID: attacker.CharacterID,
+		DefenderID: defender.CharacterID,
+		Combat:     result,
+		MatchedAt:  time.Now(),
+	}
+
+	ms.mutex.Lock()
+	ms.matches[attacker.CharacterID] = match
+	ms.matches[defender.CharacterID] = match
+	ms.mutex.Unlock()
+
+	return match, nil
+}
+
+func (ms *MatchmakingService) dropStaleLocked(now time.Time) {
+	fresh := ms.queue[:0]
+	for _, queued := range ms.queue {
+		if now.Sub(queued.QueuedAt) < matchmakingTicketTTL {
+			fresh = append(fresh, queued)
+		}
+	}
+	ms.queue = fresh
+}
+
+// matchScore rates how well two tickets fit, lower is better. The windows widen with
+// the longer of the two waits so nobody stays queued forever on a quiet night.
+func matchScore(a, b *models.MatchmakingTicket, now time.Time) (float64, bool) {
+	waited := now.Sub(a.QueuedAt)
+	if other := now.Sub(b.QueuedAt); other > waited {
+		waited = other
+	}
+	seconds := waited.Seconds()
+
+	ratingWindow := min(matchRatingWindow+matchRatingWindowGrowth*seconds, maxMatchRatingWindow)
+	powerTolerance := min(matchPowerTolerance+matchPowerToleranceGrowth*seconds, maxMatchPowerTolerance)
+
+	ratingGap := math.Abs(float64(a.Rating - b.Rating))
+	powerGap := 0.0
+	if strongest := max(a.CombatPower, b.CombatPower); strongest > 0 {
+		powerGap = math.Abs(float64(a.CombatPower-b.CombatPower)) / float64(strongest)
+	}
+
+	if ratingGap > ratingWindow || powerGap > powerTolerance {
+		return 0, false
+	}
+	return ratingGap/ratingWindow + powerGap/powerTolerance, true
+}
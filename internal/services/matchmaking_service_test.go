@@ -0,0 +1,109 @@
+package services
+
+import (
+	"testing"
+	"time"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+	"twitch-rpg/internal/storage/storagetest"
+)
+
+func TestMatchScore(t *testing.T) {
+	now := time.Now()
+	ticket := func(rating, power int, waited time.Duration) *models.MatchmakingTicket {
+		return &models.MatchmakingTicket{Rating: rating, CombatPower: power, QueuedAt: now.Add(-waited)}
+	}
+
+	for _, test := range []struct {
+		name  string
+		a, b  *models.MatchmakingTicket
+		match bool
+		score float64
+	}{
+		{"equal", ticket(1000, 100, 0), ticket(1000, 100, 0), true, 0},
+		{"no combat power yet", ticket(1000, 0, 0), ticket(1000, 0, 0), true, 0},
+		{"edge of the rating window", ticket(1000, 100, 0), ticket(1100, 100, 0), true, 1},
+		{"outside the rating window", ticket(1000, 100, 0), ticket(1101, 100, 0), false, 0},
+		{"rating window widens", ticket(1000, 100, 2*time.Second), ticket(1101, 100, 0), true, 101.0 / 120},
+		{"rating window stops widening", ticket(1000, 100, time.Hour), ticket(1401, 100, 0), false, 0},
+		{"widest rating window", ticket(1000, 100, time.Hour), ticket(1400, 100, 0), true, 1},
+		{"edge of the power tolerance", ticket(1000, 100, 0), ticket(1000, 80, 0), true, 1},
+		{"outside the power tolerance", ticket(1000, 100, 0), ticket(1000, 75, 0), false, 0},
+		{"power tolerance widens", ticket(1000, 100, 0), ticket(1000, 75, 10*time.Second), true, 0.25 / 0.30},
+		{"power tolerance stops widening", ticket(1000, 100, time.Hour), ticket(1000, 40, 0), false, 0},
+		{"both gaps count", ticket(1000, 100, 0), ticket(1050, 90, 0), true, 0.5 + 0.5},
+	} {
+		for _, pair := range [][2]*models.MatchmakingTicket{{test.a, test.b}, {test.b, test.a}} {
+			score, ok := matchScore(pair[0], pair[1], now)
+			if ok != test.match || (ok && (score < test.score-1e-9 || score > test.score+1e-9)) {
+				t.Fatalf("%s: matchScore = %v, %v; want %v, %v", test.name, score, ok, test.score, test.match)
+			}
+		}
+	}
+
+	// Closer opponents score better
+	closer, _ := matchScore(ticket(1000, 100, 0), ticket(1020, 100, 0), now)
+	further, _ := matchScore(ticket(1000, 100, 0), ticket(1060, 100, 0), now)
+	if closer >= further {
+		t.Fatalf("closer opponent scored %v, further %v", closer, further)
+	}
+}
+
+func TestMatchmakingPairs(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	matchmaking := NewMatchmakingService(store)
+	first := storagetest.MustCreateCharacter(t, store)
+	second := storagetest.MustCreateCharacter(t, store)
+
+	ticket, match, err := matchmaking.Join(first.ID)
+	if err != nil || ticket == nil || match != nil {
+		t.Fatalf("Join(first) = %+v, %+v, %v; want a ticket", ticket, match, err)
+	}
+	if _, _, err := matchmaking.Join(first.ID); err != ErrAlreadyQueued {
+		t.Fatalf("second Join(first) = %v; want ErrAlreadyQueued", err)
+	}
+
+	// Equal new characters are within each other's windows at once
+	ticket, match, err = matchmaking.Join(second.ID)
+	if err != nil || ticket != nil || match == nil {
+		t.Fatalf("Join(second) = %+v, %+v, %v; want a match", ticket, match, err)
+	}
+	if match.AttackerID != first.ID || match.DefenderID != second.ID || match.Combat == nil {
+		t.Fatalf("match = %+v; want the longer waiting character to attack", match)
+	}
+	if len(matchmaking.Queue()) != 0 {
+		t.Fatalf("queue after the match = %+v; want it empty", matchmaking.Queue())
+	}
+	if _, latest := matchmaking.Status(second.ID); latest != match {
+		t.Fatalf("Status(second) = %+v; want the match", latest)
+	}
+}
+
+func TestMatchWaitingWidensWindows(t *testing.T) {
+	matchmaking := NewMatchmakingService(storage.NewMemoryStorage())
+	now := time.Now()
+	matchmaking.queue = []models.MatchmakingTicket{
+		{CharacterID: 1, Rating: 1000, CombatPower: 100, QueuedAt: now},
+		{CharacterID: 2, Rating: 1250, CombatPower: 100, QueuedAt: now},
+		{CharacterID: 3, Rating: 1000, CombatPower: 10, QueuedAt: now},
+	}
+
+	// Nobody fits yet, so nothing is paired
+	if matches := matchmaking.MatchWaiting(now); len(matches) != 0 || len(matchmaking.Queue()) != 3 {
+		t.Fatalf("MatchWaiting = %+v with %d queued; want no pairs", matches, len(matchmaking.Queue()))
+	}
+
+	// After 15 seconds the rating window reaches 250, but 10 and 100 power stay too far
+	// apart. The pair is taken out of the queue before the fight runs, which fails here
+	// because the characters do not exist.
+	matchmaking.MatchWaiting(now.Add(15 * time.Second))
+	queue := matchmaking.Queue()
+	if len(queue) != 1 || queue[0].CharacterID != 3 {
+		t.Fatalf("queue after widening = %+v; want only character 3 left", queue)
+	}
+
+	// Tickets that waited too long are dropped
+	if matchmaking.MatchWaiting(now.Add(matchmakingTicketTTL)); len(matchmaking.queue) != 0 {
+		t.Fatalf("stale tickets kept: %+v", matchmaking.queue)
+	}
+}
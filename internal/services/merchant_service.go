@@ -1,199 +1,187 @@
 package services
 
 import (
-        "fmt"
-        "math/rand"
-        "time"
-        "twitch-rpg/internal/database"
-        "twitch-rpg/internal/models"
-        "twitch-rpg/internal/storage"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+	"twitch-rpg/internal/commands"
+	"twitch-rpg/internal/eventbus"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
 )
 
+// ErrUnknownOffer is returned when a viewer's choice matches none of the merchant's offers
+var ErrUnknownOffer = errors.New("no such merchant offer")
+
 // MerchantService handles merchant-related operations
-type MerchantService struct{}
+type MerchantService struct {
+	store storage.Store
+}
 
 // NewMerchantService creates a new merchant service
-func NewMerchantService() *MerchantService {
-        return &MerchantService{}
+func NewMerchantService(store storage.Store) *MerchantService {
+	return &MerchantService{store: store}
 }
 
 // GetCurrentEvent retrieves the current active merchant event
 func (ms *MerchantService) GetCurrentEvent() (*models.MerchantEvent, error) {
-        if database.DB == nil {
-                return storage.Memory.GetCurrentMerchant()
-        }
-
-        query := `
-                SELECT me.id, me.event_type, me.available_items, me.start_time, me.end_time, me.is_active
-                FROM merchant_events me
-                WHERE me.is_active = true AND (me.end_time IS NULL OR me.end_time > NOW())
-                ORDER BY me.start_time DESC
-                LIMIT 1`
-
-        rows, err := database.DB.Query(query)
-        if err != nil {
-                return nil, fmt.Errorf("failed to get current merchant event: %v", err)
-        }
-        defer rows.Close()
-
-        var merchantEvent *models.MerchantEvent
-
-        if rows.Next() {
-                merchantEvent = &models.MerchantEvent{}
-                err := rows.Scan(
-                        &merchantEvent.ID, &merchantEvent.EventType, &merchantEvent.AvailableItems,
-                        &merchantEvent.StartTime, &merchantEvent.EndTime, &merchantEvent.IsActive,
-                )
-                if err != nil {
-                        return nil, fmt.Errorf("failed to scan merchant event: %v", err)
-                }
-        }
-
-        return merchantEvent, nil
+	event, err := ms.store.GetCurrentMerchantEvent()
+	if err != nil || event == nil {
+		return nil, err
+	}
+
+	if err := ms.loadOffers(event); err != nil {
+		return nil, err
+	}
+	return event, nil
 }
 
 // CreateMerchantEvent creates a new merchant event with random items
 func (ms *MerchantService) CreateMerchantEvent(title, description string, durationMinutes int) (*models.MerchantEvent, error) {
-        if database.DB == nil {
-                return storage.Memory.CreateMerchant("random_shop", durationMinutes)
-        }
-
-        // End any existing active merchant events
-        _, err := database.DB.Exec("UPDATE merchant_events SET is_active = false WHERE is_active = true")
-        if err != nil {
-                return nil, fmt.Errorf("failed to deactivate existing events: %v", err)
-        }
-
-        // Create the merchant event
-        startTime := time.Now()
-        endTime := time.Now().Add(time.Duration(durationMinutes) * time.Minute)
-        query := `
-                INSERT INTO merchant_events (event_type, available_items, start_time, end_time, is_active)
-                VALUES (?, ?, ?, ?, true)`
-
-        // Simplified available_items as JSON string
-        availableItems := `[]` // Empty for now
-        result, err := database.DB.Exec(query, "random_shop", availableItems, startTime, endTime)
-        if err != nil {
-                return nil, fmt.Errorf("failed to create merchant event: %v", err)
-        }
-
-        eventID, err := result.LastInsertId()
-        if err != nil {
-                return nil, fmt.Errorf("failed to get merchant event ID: %v", err)
-        }
-
-        // Get random special items for the event
-        itemService := NewItemService()
-        randomItems, err := itemService.GetRandomItems(3, true) // 3 special items
-        if err != nil {
-                return nil, fmt.Errorf("failed to get random items: %v", err)
-        }
-
-        // Add items to the merchant event
-        for _, item := range randomItems {
-                price := item.Value * 2 // Double the item value as channel points price
-                stock := rand.Intn(3) + 1 // 1-3 stock
-
-                itemQuery := `
-                        INSERT INTO merchant_event_items (merchant_event_id, item_id, price_channel_points, stock, purchased)
-                        VALUES (?, ?, ?, ?, 0)`
-
-                _, err = database.DB.Exec(itemQuery, eventID, item.ID, price, stock)
-                if err != nil {
-                        return nil, fmt.Errorf("failed to add item to merchant event: %v", err)
-                }
-        }
-
-        return ms.GetMerchantEventByID(int(eventID))
+	// Get random special items for the event
+	itemService := NewItemService(ms.store)
+	randomItems, err := itemService.GetRandomItems(3, true) // 3 special items
+	if err != nil {
+		return nil, fmt.Errorf("failed to get random items: %v", err)
+	}
+
+	offers := make([]models.MerchantEventItem, 0, len(randomItems))
+	for _, item := range randomItems {
+		offers = append(offers, models.MerchantEventItem{
+			ItemID:             item.ID,
+			PriceChannelPoints: item.Value * 2,   // Double the item value as channel points price
+			Stock:              rand.Intn(3) + 1, // 1-3 stock
+		})
+	}
+
+	// Create the merchant event, ending any existing active one
+	startTime := time.Now()
+	endTime := startTime.Add(time.Duration(durationMinutes) * time.Minute)
+	event, err := ms.store.CreateMerchantEvent("random_shop", startTime, endTime, offers)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ms.loadOffers(event); err != nil {
+		return nil, err
+	}
+
+	eventbus.Publish(eventbus.MerchantAppeared{Event: event})
+	return event, nil
+}
+
+// PurchaseItem buys one unit of a merchant offer for a character.
+// The stock check, wallet debit and inventory update happen atomically in the store.
+func (ms *MerchantService) PurchaseItem(characterID, merchantEventID, merchantEventItemID int) (*models.MerchantPurchase, error) {
+	purchase, err := ms.store.PurchaseMerchantItem(characterID, merchantEventID, merchantEventItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	ms.announcePurchase(purchase)
+	recordProgress(ms.store, characterID, models.QuestActionMerchantPurchase)
+
+	return purchase, nil
 }
 
-// PurchaseItem handles a purchase from a merchant event
-func (ms *MerchantService) PurchaseItem(characterID, merchantEventItemID int) error {
-        if database.DB == nil {
-                // Use item ID directly for simplicity in memory mode
-                itemID := merchantEventItemID // Simplified mapping
-                price := 100 // Fixed price for testing
-                return storage.Memory.PurchaseItem(characterID, itemID, price)
-        }
-
-        // Get the merchant event item
-        var itemID, price, stock, purchased int
-        query := `
-                SELECT item_id, price_channel_points, stock, purchased
-                FROM merchant_event_items
-                WHERE id = ?`
-
-        err := database.DB.QueryRow(query, merchantEventItemID).Scan(&itemID, &price, &stock, &purchased)
-        if err != nil {
-                return fmt.Errorf("failed to get merchant event item: %v", err)
-        }
-
-        // Check if item is still in stock
-        if purchased >= stock {
-                return fmt.Errorf("item is out of stock")
-        }
-
-        // Get character to check channel points
-        charService := NewCharacterService()
-        character, err := charService.GetCharacterByID(characterID)
-        if err != nil {
-                return fmt.Errorf("failed to get character: %v", err)
-        }
-
-        if character == nil {
-                return fmt.Errorf("character not found")
-        }
-
-        // Check if character has enough channel points (simplified logic)
-        if character.ChannelPointsSpent+price > character.ChannelPointsSpent+1000 { // Simplified check
-                return fmt.Errorf("insufficient channel points")
-        }
-
-        // Update purchased count
-        _, err = database.DB.Exec("UPDATE merchant_event_items SET purchased = purchased + 1 WHERE id = ?", merchantEventItemID)
-        if err != nil {
-                return fmt.Errorf("failed to update purchase count: %v", err)
-        }
-
-        // Add item to character's inventory
-        itemService := NewItemService()
-        err = itemService.AddItemToCharacter(characterID, itemID, 1)
-        if err != nil {
-                return fmt.Errorf("failed to add item to character: %v", err)
-        }
-
-        // Update character's channel points spent
-        character.ChannelPointsSpent += price
-        err = charService.UpdateCharacter(character)
-        if err != nil {
-                return fmt.Errorf("failed to update character: %v", err)
-        }
-
-        return nil
+// announcePurchase fills in the bought item and publishes its acquisition. The purchase
+// is already complete, so failing to load the character or item is only logged.
+func (ms *MerchantService) announcePurchase(purchase *models.MerchantPurchase) {
+	character, err := ms.store.GetCharacterByID(purchase.CharacterID)
+	if err == nil && character == nil {
+		err = storage.ErrNotFound
+	}
+	if err != nil {
+		log.Printf("Failed to load character %d after merchant purchase: %v", purchase.CharacterID, err)
+		return
+	}
+
+	item, err := ms.store.GetItemByID(purchase.ItemID)
+	if err == nil && item == nil {
+		err = storage.ErrNotFound
+	}
+	if err != nil {
+		log.Printf("Failed to load item %d after merchant purchase: %v", purchase.ItemID, err)
+		return
+	}
+
+	purchase.Item = item
+	eventbus.Publish(eventbus.ItemAcquired{Character: character, Item: item, Method: eventbus.MethodPurchase})
+}
+
+// ChooseOffer picks the offer a viewer asked for by its position in the list, starting
+// at 1, or by item name, forgiving typos and partial names. Without a choice, a merchant
+// with a single offer sells that one.
+func (ms *MerchantService) ChooseOffer(event *models.MerchantEvent, choice string) (*models.MerchantEventItem, error) {
+	offers := event.Offers
+	choice = strings.TrimSpace(choice)
+	if choice == "" {
+		if len(offers) == 1 {
+			return &offers[0], nil
+		}
+		return nil, fmt.Errorf("%w: name the item or its number (1-%d)", ErrUnknownOffer, len(offers))
+	}
+
+	if number, err := strconv.Atoi(strings.TrimPrefix(choice, "#")); err == nil {
+		if number < 1 || number > len(offers) {
+			return nil, fmt.Errorf("%w: the merchant has offers 1-%d", ErrUnknownOffer, len(offers))
+		}
+		return &offers[number-1], nil
+	}
+
+	names := make([]string, len(offers))
+	for i, offer := range offers {
+		if offer.Item != nil {
+			names[i] = offer.Item.Name
+		}
+	}
+	i, err := commands.Match(choice, names)
+	if errors.Is(err, commands.ErrNoMatch) {
+		return nil, fmt.Errorf("%w: the merchant does not sell %q", ErrUnknownOffer, choice)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownOffer, err)
+	}
+	return &offers[i], nil
 }
 
 // GetMerchantEventByID retrieves a merchant event by ID
 func (ms *MerchantService) GetMerchantEventByID(id int) (*models.MerchantEvent, error) {
-        if database.DB == nil {
-                return nil, fmt.Errorf("database connection not available")
-        }
-
-        // This is a simplified version - in reality you'd do a proper join query
-        query := `
-                SELECT id, event_type, available_items, start_time, end_time, is_active
-                FROM merchant_events 
-                WHERE id = ?`
-
-        event := &models.MerchantEvent{}
-        err := database.DB.QueryRow(query, id).Scan(
-                &event.ID, &event.EventType, &event.AvailableItems,
-                &event.StartTime, &event.EndTime, &event.IsActive,
-        )
-
-        if err != nil {
-                return nil, fmt.Errorf("failed to get merchant event: %v", err)
-        }
-
-        return event, nil
-}
\ No newline at end of file
+	event, err := ms.store.GetMerchantEventByID(id)
+	if err != nil || event == nil {
+		return nil, err
+	}
+
+	if err := ms.loadOffers(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// loadOffers populates the offers and items of a merchant event
+func (ms *MerchantService) loadOffers(event *models.MerchantEvent) error {
+	offers, err := ms.store.GetMerchantEventItems(event.ID)
+	if err != nil {
+		return err
+	}
+
+	event.Offers = offers
+	event.Items = []models.Item{}
+	for i := range event.Offers {
+		item, err := ms.store.GetItemByID(event.Offers[i].ItemID)
+		if err != nil {
+			return fmt.Errorf("failed to load merchant item: %v", err)
+		}
+		if item == nil {
+			continue
+		}
+		event.Offers[i].Item = item
+		event.Items = append(event.Items, *item)
+	}
+
+	return nil
+}
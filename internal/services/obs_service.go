@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"log"
+	"os"
+	"twitch-rpg/internal/eventbus"
+	"twitch-rpg/internal/obs"
+)
+
+// StartOBSIntegration drives OBS from game events when OBS_WEBSOCKET_URL is set. The
+// rules in the JSON file named by OBS_RULES_PATH decide which events switch scenes,
+// toggle sources or set texts; OBS_WEBSOCKET_PASSWORD authenticates the connection.
+// The client reconnects on its own until ctx is done.
+func StartOBSIntegration(ctx context.Context) {
+	url := os.Getenv("OBS_WEBSOCKET_URL")
+	if url == "" {
+		return
+	}
+
+	path := os.Getenv("OBS_RULES_PATH")
+	if path == "" {
+		log.Println("OBS_WEBSOCKET_URL is set without OBS_RULES_PATH, OBS integration disabled")
+		return
+	}
+	rules, err := obs.LoadRules(path)
+	if err != nil {
+		log.Printf("%v, OBS integration disabled", err)
+		return
+	}
+
+	client := obs.NewClient(url, os.Getenv("OBS_WEBSOCKET_PASSWORD"))
+	dispatcher := obs.NewDispatcher(client, rules)
+	go client.Run(ctx)
+	go dispatcher.Run(ctx)
+
+	unsubscribe := eventbus.Subscribe(func(event eventbus.Event) {
+		gameEvent, err := event.GameEvent()
+		if err != nil {
+			log.Printf("Failed to build %s event for OBS: %v", event.Type(), err)
+			return
+		}
+		dispatcher.Enqueue(gameEvent)
+	}, rules.EventTypes()...)
+	context.AfterFunc(ctx, unsubscribe)
+
+	log.Printf("OBS integration enabled for %s with %d rule(s)", url, len(rules.Rules))
+}
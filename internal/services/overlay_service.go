@@ -0,0 +1,138 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+)
+
+const (
+	// defaultOverlayClaimLimit is how many events a claim returns when no limit is given
+	defaultOverlayClaimLimit = 1
+	// maxOverlayClaimLimit caps the events handed out by a single claim
+	maxOverlayClaimLimit = 50
+	// defaultOverlayVisibility is how long claimed events stay hidden from other instances
+	// when the claim does not set a visibility timeout
+	defaultOverlayVisibility = 30 * time.Second
+	// maxOverlayVisibility caps the visibility timeout so a crashed overlay cannot hold events for long
+	maxOverlayVisibility = 10 * time.Minute
+)
+
+var (
+	// ErrInvalidOverlayConsumer is returned for consumer names that are not 1-50 letters, digits, '-' or '_'
+	ErrInvalidOverlayConsumer = errors.New("overlay consumer names are 1-50 letters, digits, '-' or '_'")
+	// ErrInvalidOverlayClaim is returned for claims with a limit or visibility timeout out of range
+	ErrInvalidOverlayClaim = errors.New("claims take 1-50 events with a visibility timeout of 1-600 seconds")
+)
+
+var overlayConsumerName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,50}$`)
+
+// OverlayService hands game events to overlays through a queue per overlay consumer.
+// Instances of the same overlay share its queue, so every event is played once, and an
+// event that is not acknowledged in time is handed out again.
+type OverlayService struct {
+	store storage.Store
+}
+
+// NewOverlayService creates a new overlay service
+func NewOverlayService(store storage.Store) *OverlayService {
+	return &OverlayService{store: store}
+}
+
+// Claim hands the next events of a consumer's queue to an overlay instance. The events stay
+// hidden from the consumer's other instances until the visibility timeout passes or they
+// are acknowledged with the returned claim token.
+func (ovs *OverlayService) Claim(consumer string, req models.OverlayClaimRequest) (*models.OverlayClaim, error) {
+	if !overlayConsumerName.MatchString(consumer) {
+		return nil, ErrInvalidOverlayConsumer
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultOverlayClaimLimit
+	}
+	visibility := time.Duration(req.VisibilityTimeoutSeconds) * time.Second
+	if visibility == 0 {
+		visibility = defaultOverlayVisibility
+	}
+	if limit < 1 || limit > maxOverlayClaimLimit || visibility < time.Second || visibility > maxOverlayVisibility {
+		return nil, ErrInvalidOverlayClaim
+	}
+
+	token, err := newClaimToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Truncate(time.Millisecond)
+	claim := &models.OverlayClaim{
+		Consumer:     consumer,
+		ClaimToken:   token,
+		VisibleUntil: now.Add(visibility),
+	}
+	claim.Events, err = ovs.store.ClaimOverlayEvents(consumer, token, limit, now, claim.VisibleUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+// Acknowledge removes played events from a consumer's queue. Only events still claimed
+// under the token are acknowledged; the others were handed out again after their
+// visibility timeout and are reported as rejected.
+func (ovs *OverlayService) Acknowledge(consumer string, req models.OverlayAckRequest) (*models.OverlayAck, error) {
+	if _, err := ovs.GetConsumer(consumer); err != nil {
+		return nil, err
+	}
+
+	acknowledged, err := ovs.store.AckOverlayEvents(consumer, req.ClaimToken, req.EventIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.OverlayAck{Acknowledged: acknowledged, Rejected: []int{}}
+	done := map[int]bool{}
+	for _, eventID := range acknowledged {
+		done[eventID] = true
+	}
+	for _, eventID := range req.EventIDs {
+		if !done[eventID] {
+			result.Rejected = append(result.Rejected, eventID)
+			done[eventID] = true
+		}
+	}
+
+	return result, nil
+}
+
+// GetConsumer retrieves a consumer's cursor and the number of events it has not acknowledged
+func (ovs *OverlayService) GetConsumer(consumer string) (*models.OverlayConsumer, error) {
+	if !overlayConsumerName.MatchString(consumer) {
+		return nil, ErrInvalidOverlayConsumer
+	}
+
+	status, err := ovs.store.GetOverlayConsumer(consumer)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return nil, fmt.Errorf("overlay consumer not found: %w", storage.ErrNotFound)
+	}
+
+	return status, nil
+}
+
+// newClaimToken returns a random token identifying one claim
+func newClaimToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate claim token: %v", err)
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -0,0 +1,569 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"twitch-rpg/internal/eventbus"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/quest"
+	"twitch-rpg/internal/storage"
+)
+
+var (
+	// ErrQuestInactive is returned when accepting a quest that was taken off the quest board
+	ErrQuestInactive = errors.New("quest is not active")
+	// ErrInvalidQuest is returned for quest definitions with bad requirements, rewards or cost
+	ErrInvalidQuest = errors.New("invalid quest")
+	// ErrQuestNotInRotation is returned when accepting a daily or weekly quest that is not offered this period
+	ErrQuestNotInRotation = errors.New("quest is not in the current rotation")
+)
+
+var (
+	questScheduleOnce sync.Once
+	questSchedule     quest.Schedule
+)
+
+// configuredQuestSchedule reads the rotation schedule once from QUEST_TIMEZONE (an IANA
+// name such as "Europe/Berlin"), QUEST_DAILY_RESET ("04:00"), QUEST_WEEKLY_RESET
+// ("monday 04:00"), QUEST_DAILY_COUNT and QUEST_WEEKLY_COUNT. Invalid values are
+// logged and replaced by the defaults.
+func configuredQuestSchedule() quest.Schedule {
+	questScheduleOnce.Do(func() {
+		questSchedule = quest.DefaultSchedule()
+
+		if value := os.Getenv("QUEST_TIMEZONE"); value != "" {
+			location, err := time.LoadLocation(value)
+			if err != nil {
+				log.Printf("Invalid QUEST_TIMEZONE %q, using %s", value, questSchedule.Location)
+			} else {
+				questSchedule.Location = location
+			}
+		}
+
+		if value := os.Getenv("QUEST_DAILY_RESET"); value != "" {
+			clock, err := quest.ParseClock(value)
+			if err != nil {
+				log.Printf("Invalid QUEST_DAILY_RESET: %v, using %s", err, questSchedule.DailyReset)
+			} else {
+				questSchedule.DailyReset = clock
+			}
+		}
+
+		if value := os.Getenv("QUEST_WEEKLY_RESET"); value != "" {
+			day, clock, err := quest.ParseWeeklyReset(value)
+			if err != nil {
+				log.Printf("Invalid QUEST_WEEKLY_RESET: %v, using %s %s", err, questSchedule.WeeklyDay, questSchedule.WeeklyReset)
+			} else {
+				questSchedule.WeeklyDay, questSchedule.WeeklyReset = day, clock
+			}
+		}
+
+		questSchedule.DailyQuests = questRotationSize("QUEST_DAILY_COUNT", questSchedule.DailyQuests)
+		questSchedule.WeeklyQuests = questRotationSize("QUEST_WEEKLY_COUNT", questSchedule.WeeklyQuests)
+
+		log.Printf("Quests reset daily at %s and weekly on %s at %s (%s)", questSchedule.DailyReset,
+			questSchedule.WeeklyDay, questSchedule.WeeklyReset, questSchedule.Location)
+	})
+	return questSchedule
+}
+
+func questRotationSize(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 1 {
+		log.Printf("Invalid %s %q, using %d", name, value, fallback)
+		return fallback
+	}
+	return size
+}
+
+// QuestService handles the quest board, accepted quests and their progress
+type QuestService struct {
+	store    storage.Store
+	schedule quest.Schedule
+}
+
+// NewQuestService creates a new quest service using the configured rotation schedule
+func NewQuestService(store storage.Store) *QuestService {
+	return &QuestService{store: store, schedule: configuredQuestSchedule()}
+}
+
+// GetAvailableQuests retrieves the quests on the quest board: every active special quest
+// plus the daily and weekly quests of the current rotation
+func (qs *QuestService) GetAvailableQuests() ([]models.Quest, error) {
+	rotations, err := qs.currentRotations(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	quests, err := qs.store.GetActiveQuests()
+	if err != nil {
+		return nil, err
+	}
+
+	available := []models.Quest{}
+	for _, q := range quests {
+		if !quest.Rotates(q.QuestType) || rotations[q.QuestType].Includes(q.ID) {
+			available = append(available, q)
+		}
+	}
+	return available, nil
+}
+
+// GetRotation retrieves the current daily and weekly rotations with their quests
+// and the time left until each of them resets
+func (qs *QuestService) GetRotation() (*models.QuestRotationStatus, error) {
+	now := time.Now()
+	rotations, err := qs.currentRotations(now)
+	if err != nil {
+		return nil, err
+	}
+
+	quests, err := qs.store.GetActiveQuests()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int]models.Quest, len(quests))
+	for _, q := range quests {
+		byID[q.ID] = q
+	}
+
+	for _, rotation := range rotations {
+		rotation.Quests = []models.Quest{}
+		for _, id := range rotation.QuestIDs {
+			// Quests deactivated since the rotation was drawn drop off the board
+			if q, ok := byID[id]; ok {
+				rotation.Quests = append(rotation.Quests, q)
+			}
+		}
+		rotation.ResetsInSeconds = int(math.Ceil(rotation.PeriodEnd.Sub(now).Seconds()))
+	}
+
+	return &models.QuestRotationStatus{
+		Timezone: qs.schedule.Location.String(),
+		Daily:    rotations[models.QuestTypeDaily],
+		Weekly:   rotations[models.QuestTypeWeekly],
+	}, nil
+}
+
+// Rotate makes sure the daily and weekly rotations of the period containing now exist
+// and expires quests from earlier rotations that were not finished in time
+func (qs *QuestService) Rotate(now time.Time) error {
+	if _, err := qs.currentRotations(now); err != nil {
+		return err
+	}
+
+	expired, err := qs.store.ExpireCharacterQuests(now)
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		log.Printf("Expired %d unfinished quest(s)", expired)
+	}
+	return nil
+}
+
+// RunRotationScheduler rotates quests right away and then every interval until ctx is
+// cancelled, so every reset takes effect within one interval of its scheduled time
+func (qs *QuestService) RunRotationScheduler(ctx context.Context, interval time.Duration) {
+	if err := qs.Rotate(time.Now()); err != nil {
+		log.Printf("Failed to rotate quests: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := qs.Rotate(now); err != nil {
+				log.Printf("Failed to rotate quests: %v", err)
+			}
+		}
+	}
+}
+
+// currentRotations returns the daily and weekly rotations of the period containing now
+func (qs *QuestService) currentRotations(now time.Time) (map[string]*models.QuestRotation, error) {
+	rotations := map[string]*models.QuestRotation{}
+	for _, questType := range []string{models.QuestTypeDaily, models.QuestTypeWeekly} {
+		rotation, err := qs.rotation(questType, now)
+		if err != nil {
+			return nil, err
+		}
+		rotations[questType] = rotation
+	}
+	return rotations, nil
+}
+
+// rotation returns the rotation of questType for the period containing now, drawing a
+// random subset of the active quests of that type when the period has none yet
+func (qs *QuestService) rotation(questType string, now time.Time) (*models.QuestRotation, error) {
+	current, err := qs.store.GetQuestRotation(questType, now)
+	if err != nil || current != nil {
+		return current, err
+	}
+
+	quests, err := qs.store.GetActiveQuests()
+	if err != nil {
+		return nil, err
+	}
+	candidates := []int{}
+	for _, q := range quests {
+		if q.QuestType == questType {
+			candidates = append(candidates, q.ID)
+		}
+	}
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	picked := candidates[:min(len(candidates), qs.schedule.Size(questType))]
+	sort.Ints(picked)
+
+	start, end, _ := qs.schedule.Period(questType, now)
+	rotation := &models.QuestRotation{
+		QuestType:   questType,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		QuestIDs:    picked,
+	}
+	if err := qs.store.CreateQuestRotation(rotation); err != nil {
+		if errors.Is(err, storage.ErrQuestRotationExists) {
+			// Someone else drew this period's rotation first
+			return qs.store.GetQuestRotation(questType, now)
+		}
+		return nil, err
+	}
+
+	log.Printf("Rotated %d %s quest(s) until %s", len(picked), questType, end.Format(time.RFC3339))
+	return rotation, nil
+}
+
+// GetQuest retrieves a quest by ID
+func (qs *QuestService) GetQuest(questID int) (*models.Quest, error) {
+	q, err := qs.store.GetQuestByID(questID)
+	if err != nil {
+		return nil, err
+	}
+	if q == nil {
+		return nil, fmt.Errorf("quest not found: %w", storage.ErrNotFound)
+	}
+	return q, nil
+}
+
+// CreateQuest validates a quest definition and adds it to the quest board
+func (qs *QuestService) CreateQuest(req models.QuestCreateRequest) (*models.Quest, error) {
+	if err := quest.ValidateRequirements(req.Requirements); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuest, err)
+	}
+	if req.Rewards.Experience < 0 || req.Rewards.ChannelPoints < 0 || req.ChannelPointCost < 0 {
+		return nil, fmt.Errorf("%w: rewards and cost cannot be negative", ErrInvalidQuest)
+	}
+
+	questType := req.QuestType
+	switch questType {
+	case "":
+		questType = models.QuestTypeDaily
+	case models.QuestTypeDaily, models.QuestTypeWeekly, models.QuestTypeSpecial:
+	default:
+		return nil, fmt.Errorf("%w: unknown quest type %q", ErrInvalidQuest, questType)
+	}
+
+	for _, itemID := range req.Rewards.ItemIDs {
+		item, err := qs.store.GetItemByID(itemID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check reward item: %v", err)
+		}
+		if item == nil {
+			return nil, fmt.Errorf("%w: reward item %d does not exist", ErrInvalidQuest, itemID)
+		}
+	}
+
+	requirements, err := json.Marshal(req.Requirements)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode quest requirements: %v", err)
+	}
+	rewards, err := json.Marshal(req.Rewards)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode quest rewards: %v", err)
+	}
+
+	q := &models.Quest{
+		Name:             req.Name,
+		Description:      req.Description,
+		QuestType:        questType,
+		Requirements:     requirements,
+		Rewards:          rewards,
+		ChannelPointCost: req.ChannelPointCost,
+		IsActive:         true,
+	}
+	if err := qs.store.CreateQuest(q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// AcceptQuest starts a quest for a character, paying its channel point cost from the
+// wallet. Daily and weekly quests must be in the current rotation and expire at its
+// reset. State requirements the character already meets count right away.
+func (qs *QuestService) AcceptQuest(questID, characterID int) (*models.CharacterQuest, error) {
+	character, err := NewCharacterService(qs.store).GetCharacterByID(characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get character: %v", err)
+	}
+	if character == nil {
+		return nil, fmt.Errorf("character not found: %w", storage.ErrNotFound)
+	}
+
+	q, err := qs.GetQuest(questID)
+	if err != nil {
+		return nil, err
+	}
+	if !q.IsActive {
+		return nil, ErrQuestInactive
+	}
+
+	requirements, err := quest.ParseRequirements(q.Requirements)
+	if err != nil {
+		return nil, fmt.Errorf("quest %d cannot be accepted: %v", q.ID, err)
+	}
+	progress, err := json.Marshal(quest.NewProgress(requirements))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode quest progress: %v", err)
+	}
+
+	characterQuest := &models.CharacterQuest{
+		CharacterID: character.ID,
+		QuestID:     q.ID,
+		Progress:    progress,
+	}
+	if quest.Rotates(q.QuestType) {
+		rotation, err := qs.rotation(q.QuestType, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if !rotation.Includes(q.ID) {
+			return nil, ErrQuestNotInRotation
+		}
+		characterQuest.RotationID = rotation.ID
+		characterQuest.ExpiresAt = &rotation.PeriodEnd
+	}
+
+	walletService := NewWalletService(qs.store)
+	var fee *models.WalletTransaction
+	if q.ChannelPointCost > 0 {
+		fee, err = walletService.Debit(character.ID, q.ChannelPointCost, models.WalletReasonQuestFee,
+			models.ReferenceQuest, strconv.Itoa(q.ID))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := qs.store.CreateCharacterQuest(characterQuest); err != nil {
+		if fee != nil {
+			if refundErr := walletService.Refund(fee); refundErr != nil {
+				log.Printf("Failed to refund quest fee of character %d for quest %d: %v", character.ID, q.ID, refundErr)
+			}
+		}
+		return nil, err
+	}
+
+	recordProgress(qs.store, character.ID, models.QuestActionStateChanged)
+
+	characterQuests, err := qs.store.GetCharacterQuests(character.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range characterQuests {
+		if characterQuests[i].ID == characterQuest.ID {
+			return &characterQuests[i], nil
+		}
+	}
+	return nil, fmt.Errorf("character quest not found: %w", storage.ErrNotFound)
+}
+
+// GetCharacterQuests retrieves every quest a character has accepted, oldest first
+func (qs *QuestService) GetCharacterQuests(characterID int) ([]models.CharacterQuest, error) {
+	character, err := qs.store.GetCharacterByID(characterID)
+	if err != nil {
+		return nil, err
+	}
+	if character == nil {
+		return nil, fmt.Errorf("character not found: %w", storage.ErrNotFound)
+	}
+
+	return qs.store.GetCharacterQuests(characterID)
+}
+
+// RecordAction advances a character's open quests after a game action and grants the
+// rewards of those it completes. Quest progress never fails the action itself, so
+// errors are logged instead of returned.
+func (qs *QuestService) RecordAction(characterID int, action models.QuestAction) {
+	if err := qs.recordAction(characterID, action); err != nil {
+		log.Printf("Failed to update quests of character %d after %s: %v", characterID, action, err)
+	}
+}
+
+func (qs *QuestService) recordAction(characterID int, action models.QuestAction) error {
+	for {
+		completed, err := qs.advance(characterID, action)
+		if err != nil || !completed {
+			return err
+		}
+		// Rewards can raise the level or add items, which may complete further quests
+		action = models.QuestActionStateChanged
+	}
+}
+
+// advance applies action to every open quest of the character and reports whether any of them completed
+func (qs *QuestService) advance(characterID int, action models.QuestAction) (bool, error) {
+	characterQuests, err := qs.store.GetCharacterQuests(characterID)
+	if err != nil {
+		return false, err
+	}
+
+	type openQuest struct {
+		characterQuest models.CharacterQuest
+		requirements   []models.QuestRequirement
+	}
+	open := []openQuest{}
+	needsInventory := false
+	now := time.Now()
+	for _, characterQuest := range characterQuests {
+		if characterQuest.Completed || characterQuest.Expired || characterQuest.IsPastExpiry(now) {
+			continue
+		}
+		requirements, err := quest.ParseRequirements(characterQuest.Quest.Requirements)
+		if err != nil {
+			log.Printf("Skipping quest %d of character %d: %v", characterQuest.QuestID, characterID, err)
+			continue
+		}
+		open = append(open, openQuest{characterQuest, requirements})
+		needsInventory = needsInventory || quest.NeedsInventory(requirements)
+	}
+	if len(open) == 0 {
+		return false, nil
+	}
+
+	character, err := NewCharacterService(qs.store).GetCharacterByID(characterID)
+	if err != nil {
+		return false, err
+	}
+	if character == nil {
+		return false, storage.ErrNotFound
+	}
+	state, err := qs.characterState(character, needsInventory)
+	if err != nil {
+		return false, err
+	}
+
+	anyCompleted := false
+	for _, entry := range open {
+		requirements := entry.requirements
+		_, completed, err := qs.store.UpdateCharacterQuestProgress(entry.characterQuest.ID,
+			func(data json.RawMessage) (json.RawMessage, bool, error) {
+				progress := quest.ParseProgress(data, requirements)
+				done := quest.Advance(requirements, &progress, action, state)
+				encoded, err := json.Marshal(progress)
+				return encoded, done, err
+			})
+		if err != nil {
+			return anyCompleted, fmt.Errorf("failed to update progress of quest %d: %v", entry.characterQuest.QuestID, err)
+		}
+
+		if completed {
+			qs.grantRewards(character, entry.characterQuest.Quest)
+			anyCompleted = true
+		}
+	}
+
+	return anyCompleted, nil
+}
+
+// characterState collects what state requirements are checked against, loading the
+// inventory only when one of the open quests asks about items
+func (qs *QuestService) characterState(character *models.Character, needsInventory bool) (quest.State, error) {
+	state := quest.State{Level: character.Level, ItemsByRarity: map[models.ItemRarity]int{}}
+	if !needsInventory {
+		return state, nil
+	}
+
+	items, err := NewItemService(qs.store).GetCharacterItems(character.ID)
+	if err != nil {
+		return state, fmt.Errorf("failed to load inventory: %v", err)
+	}
+	for _, owned := range items {
+		if owned.Item != nil {
+			state.ItemsByRarity[owned.Item.Rarity] += owned.Quantity
+		}
+	}
+	return state, nil
+}
+
+// grantRewards hands out a completed quest's rewards and announces the completion. The
+// quest is already marked completed, so failures to grant single rewards are logged.
+func (qs *QuestService) grantRewards(character *models.Character, q *models.Quest) {
+	rewards, err := quest.ParseRewards(q.Rewards)
+	if err != nil {
+		log.Printf("Failed to read rewards of quest %d: %v", q.ID, err)
+		rewards = models.QuestRewards{}
+	}
+	granted := models.QuestRewards{ItemIDs: []int{}}
+
+	if rewards.Experience > 0 {
+		leveledUp := grantExperience(character, rewards.Experience)
+		if err := NewCharacterService(qs.store).UpdateCharacter(character); err != nil {
+			log.Printf("Failed to grant quest experience to character %d for quest %d: %v", character.ID, q.ID, err)
+		} else {
+			granted.Experience = rewards.Experience
+			if leveledUp {
+				eventbus.Publish(eventbus.LevelUp{Character: character})
+			}
+		}
+	}
+
+	if rewards.ChannelPoints > 0 {
+		_, err := NewWalletService(qs.store).Credit(character.ID, rewards.ChannelPoints, models.WalletReasonQuestReward,
+			models.ReferenceQuest, strconv.Itoa(q.ID))
+		if err != nil {
+			log.Printf("Failed to pay quest reward of character %d for quest %d: %v", character.ID, q.ID, err)
+		} else {
+			granted.ChannelPoints = rewards.ChannelPoints
+		}
+	}
+
+	itemService := NewItemService(qs.store)
+	for _, itemID := range rewards.ItemIDs {
+		item, err := itemService.GetItemByID(itemID)
+		if err == nil && item == nil {
+			err = storage.ErrNotFound
+		}
+		if err == nil {
+			err = itemService.AddItemToCharacter(character.ID, item.ID, 1)
+		}
+		if err != nil {
+			log.Printf("Failed to grant quest item %d to character %d for quest %d: %v", itemID, character.ID, q.ID, err)
+			continue
+		}
+		granted.ItemIDs = append(granted.ItemIDs, item.ID)
+		eventbus.Publish(eventbus.ItemAcquired{Character: character, Item: item, Method: eventbus.MethodQuestReward})
+	}
+
+	eventbus.Publish(eventbus.QuestCompleted{Character: character, Quest: q, Rewards: granted})
+}
@@ -0,0 +1,387 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+	"twitch-rpg/internal/combat"
+	"twitch-rpg/internal/eventbus"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+)
+
+const (
+	// defaultRaidCooldown applies when a boss is spawned without an attack cooldown
+	defaultRaidCooldown = 30 * time.Second
+	// raidTopContributors is how many contributors the progress view and defeat event list
+	raidTopContributors = 10
+)
+
+var (
+	// ErrRaidInProgress is returned when spawning a boss while another one is still active
+	ErrRaidInProgress = errors.New("a raid boss is already active")
+	// ErrInvalidRaid is returned for spawn requests with non-positive hit points or negative settings
+	ErrInvalidRaid = errors.New("raid boss needs positive hit points and non-negative rewards, cooldown and duration")
+)
+
+// RaidService handles community raid bosses that the whole chat attacks together
+type RaidService struct {
+	store storage.Store
+}
+
+// NewRaidService creates a new raid service
+func NewRaidService(store storage.Store) *RaidService {
+	return &RaidService{store: store}
+}
+
+// Spawn creates a new raid boss at full hit points and announces it to the overlay.
+// Only one boss can be active at a time.
+func (rs *RaidService) Spawn(req models.RaidSpawnRequest) (*models.RaidBoss, error) {
+	if req.MaxHP <= 0 || req.Level < 0 || req.RewardPoints < 0 || req.RewardExperience < 0 ||
+		req.AttackCooldownSeconds < 0 || req.DurationMinutes < 0 {
+		return nil, ErrInvalidRaid
+	}
+
+	active, err := rs.store.GetActiveRaidBoss()
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		if active, err = rs.settle(active); err != nil {
+			return nil, err
+		}
+		if active.Status == models.RaidStatusActive {
+			return nil, ErrRaidInProgress
+		}
+	}
+
+	boss := &models.RaidBoss{
+		Name:                  req.Name,
+		Level:                 max(req.Level, 1),
+		Strength:              req.Strength,
+		Agility:               req.Agility,
+		Vitality:              req.Vitality,
+		Intelligence:          req.Intelligence,
+		MaxHP:                 req.MaxHP,
+		RewardPoints:          req.RewardPoints,
+		RewardExperience:      req.RewardExperience,
+		AttackCooldownSeconds: req.AttackCooldownSeconds,
+	}
+	if boss.AttackCooldownSeconds == 0 {
+		boss.AttackCooldownSeconds = int(defaultRaidCooldown / time.Second)
+	}
+	if req.DurationMinutes > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.DurationMinutes) * time.Minute)
+		boss.ExpiresAt = &expiresAt
+	}
+
+	if err := rs.store.CreateRaidBoss(boss); err != nil {
+		return nil, err
+	}
+	eventbus.Publish(eventbus.RaidSpawned{Boss: boss})
+
+	return boss, nil
+}
+
+// GetRaids retrieves the most recent raid bosses
+func (rs *RaidService) GetRaids(limit int) ([]models.RaidBoss, error) {
+	bosses, err := rs.store.GetRaidBosses(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range bosses {
+		settled, err := rs.settle(&bosses[i])
+		if err != nil {
+			return nil, err
+		}
+		bosses[i] = *settled
+	}
+	return bosses, nil
+}
+
+// GetProgress retrieves the live state of a raid for the overlay
+func (rs *RaidService) GetProgress(raidID int) (*models.RaidProgress, error) {
+	boss, err := rs.getRaid(raidID)
+	if err != nil {
+		return nil, err
+	}
+	return rs.progress(boss)
+}
+
+// GetCurrentProgress retrieves the live state of the active raid, or of the most
+// recent one so the overlay can keep showing the result after the fight ends
+func (rs *RaidService) GetCurrentProgress() (*models.RaidProgress, error) {
+	boss, err := rs.store.GetActiveRaidBoss()
+	if err != nil {
+		return nil, err
+	}
+	if boss == nil {
+		recent, err := rs.store.GetRaidBosses(1)
+		if err != nil {
+			return nil, err
+		}
+		if len(recent) == 0 {
+			return nil, fmt.Errorf("no raid boss has been spawned: %w", storage.ErrNotFound)
+		}
+		boss = &recent[0]
+	}
+
+	if boss, err = rs.settle(boss); err != nil {
+		return nil, err
+	}
+	return rs.progress(boss)
+}
+
+// GetContributions retrieves every character's damage against a raid boss, most damage first
+func (rs *RaidService) GetContributions(raidID int) ([]models.RaidContribution, error) {
+	if _, err := rs.getRaid(raidID); err != nil {
+		return nil, err
+	}
+	return rs.store.GetRaidContributions(raidID)
+}
+
+// Attack strikes a raid boss once with the character's total stats including equipment.
+// Milestones crossed by the hit are announced, and the finishing blow distributes the rewards.
+func (rs *RaidService) Attack(raidID, characterID int) (*models.RaidAttackResult, error) {
+	character, err := NewCharacterService(rs.store).GetCharacterByID(characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get character: %v", err)
+	}
+	if character == nil {
+		return nil, fmt.Errorf("character not found: %w", storage.ErrNotFound)
+	}
+
+	boss, err := rs.getRaid(raidID)
+	if err != nil {
+		return nil, err
+	}
+	if boss.Status != models.RaidStatusActive {
+		return nil, storage.ErrRaidNotActive
+	}
+
+	now := time.Now()
+	action := combat.Strike(combat.NewFighter(character), combat.NewRaidBossFighter(boss), combat.NewRNG(rand.Int63()))
+
+	hit, err := rs.store.ApplyRaidDamage(boss.ID, character.ID, action.Damage, now)
+	if err != nil {
+		if errors.Is(err, storage.ErrRaidCooldown) {
+			return nil, rs.cooldownError(boss, character.ID, now)
+		}
+		if errors.Is(err, storage.ErrRaidNotActive) {
+			// Expired while we were rolling the strike
+			if _, settleErr := rs.settle(boss); settleErr != nil {
+				log.Printf("Failed to settle raid boss %d: %v", boss.ID, settleErr)
+			}
+		}
+		return nil, err
+	}
+
+	action.Damage = hit.Damage
+	action.TargetHP = hit.Raid.CurrentHP
+
+	result := &models.RaidAttackResult{
+		Raid:         hit.Raid,
+		Action:       action,
+		Damage:       hit.Damage,
+		Contribution: hit.Contribution,
+		NextAttackAt: now.Add(hit.Raid.AttackCooldown()),
+		Milestones:   hit.Raid.MilestonesCrossed(hit.HPBefore),
+		Defeated:     hit.Defeated,
+	}
+	result.Contribution.Username = character.Username
+
+	if !hit.Defeated {
+		// The defeat event supersedes milestones passed by the same hit
+		for _, milestone := range result.Milestones {
+			eventbus.Publish(eventbus.RaidMilestone{Boss: hit.Raid, Milestone: milestone, Character: character})
+		}
+		return result, nil
+	}
+
+	result.Rewards, err = rs.distributeRewards(hit.Raid)
+	if err != nil {
+		return nil, err
+	}
+	eventbus.Publish(eventbus.RaidDefeated{Boss: hit.Raid, Finisher: character, TopContributors: topContributors(result.Rewards)})
+
+	return result, nil
+}
+
+// distributeRewards pays out a defeated boss's reward pool by damage share. The boss is
+// already dead, so failures to pay individual characters are logged instead of returned.
+func (rs *RaidService) distributeRewards(boss *models.RaidBoss) ([]models.RaidContribution, error) {
+	contributions, err := rs.store.GetRaidContributions(boss.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load raid contributions: %v", err)
+	}
+
+	points := splitByDamage(boss.RewardPoints, contributions)
+	experience := splitByDamage(boss.RewardExperience, contributions)
+
+	walletService := NewWalletService(rs.store)
+	charService := NewCharacterService(rs.store)
+	reference := strconv.Itoa(boss.ID)
+
+	for i := range contributions {
+		contribution := &contributions[i]
+
+		if points[i] > 0 {
+			_, err := walletService.Credit(contribution.CharacterID, points[i], models.WalletReasonRaidReward,
+				models.ReferenceRaidBoss, reference)
+			if err != nil {
+				log.Printf("Failed to pay raid reward of character %d for raid %d: %v", contribution.CharacterID, boss.ID, err)
+				points[i] = 0
+			}
+		}
+
+		if experience[i] > 0 {
+			if err := rs.grantRaidExperience(charService, contribution.CharacterID, experience[i]); err != nil {
+				log.Printf("Failed to grant raid experience to character %d for raid %d: %v", contribution.CharacterID, boss.ID, err)
+				experience[i] = 0
+			}
+		}
+
+		if err := rs.store.SetRaidReward(boss.ID, contribution.CharacterID, points[i], experience[i]); err != nil {
+			log.Printf("Failed to record raid reward of character %d for raid %d: %v", contribution.CharacterID, boss.ID, err)
+		}
+		contribution.RewardPoints = &points[i]
+		contribution.RewardExperience = &experience[i]
+
+		// Defeating the boss counts as a won fight for everyone who hit it
+		recordProgress(rs.store, contribution.CharacterID, models.QuestActionFightWon)
+	}
+
+	return contributions, nil
+}
+
+func (rs *RaidService) grantRaidExperience(charService *CharacterService, characterID, experience int) error {
+	character, err := charService.GetCharacterByID(characterID)
+	if err != nil {
+		return err
+	}
+	if character == nil {
+		return storage.ErrNotFound
+	}
+
+	leveledUp := grantExperience(character, experience)
+	if err := charService.UpdateCharacter(character); err != nil {
+		return err
+	}
+	if leveledUp {
+		eventbus.Publish(eventbus.LevelUp{Character: character})
+	}
+	return nil
+}
+
+// splitByDamage divides total in proportion to each contribution's damage. Units lost to
+// rounding go to the largest remainders, ties to the earlier (bigger) contributor.
+func splitByDamage(total int, contributions []models.RaidContribution) []int {
+	shares := make([]int, len(contributions))
+
+	totalDamage := 0
+	for _, contribution := range contributions {
+		totalDamage += contribution.Damage
+	}
+	if total <= 0 || totalDamage <= 0 {
+		return shares
+	}
+
+	remainders := make([]int64, len(contributions))
+	distributed := 0
+	for i, contribution := range contributions {
+		product := int64(total) * int64(contribution.Damage)
+		shares[i] = int(product / int64(totalDamage))
+		remainders[i] = product % int64(totalDamage)
+		distributed += shares[i]
+	}
+
+	order := make([]int, len(contributions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for _, i := range order[:total-distributed] {
+		shares[i]++
+	}
+
+	return shares
+}
+
+func (rs *RaidService) progress(boss *models.RaidBoss) (*models.RaidProgress, error) {
+	contributions, err := rs.store.GetRaidContributions(boss.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	totalDamage := 0
+	for _, contribution := range contributions {
+		totalDamage += contribution.Damage
+	}
+
+	return &models.RaidProgress{
+		Raid:            boss,
+		HPPercent:       boss.HPPercent(),
+		TotalDamage:     totalDamage,
+		Contributors:    len(contributions),
+		TopContributors: topContributors(contributions),
+	}, nil
+}
+
+func topContributors(contributions []models.RaidContribution) []models.RaidContribution {
+	return contributions[:min(len(contributions), raidTopContributors)]
+}
+
+// getRaid loads a boss, letting it escape on the spot if its deadline has passed
+func (rs *RaidService) getRaid(raidID int) (*models.RaidBoss, error) {
+	boss, err := rs.store.GetRaidBossByID(raidID)
+	if err != nil {
+		return nil, err
+	}
+	if boss == nil {
+		return nil, fmt.Errorf("raid boss not found: %w", storage.ErrNotFound)
+	}
+	return rs.settle(boss)
+}
+
+// settle marks an active boss past its deadline as escaped and returns its current state
+func (rs *RaidService) settle(boss *models.RaidBoss) (*models.RaidBoss, error) {
+	if boss.Status != models.RaidStatusActive || !boss.IsOver(time.Now()) {
+		return boss, nil
+	}
+
+	if _, err := rs.store.TransitionRaidBoss(boss.ID, models.RaidStatusActive, models.RaidStatusEscaped); err != nil {
+		return nil, err
+	}
+	settled, err := rs.store.GetRaidBossByID(boss.ID)
+	if err != nil {
+		return nil, err
+	}
+	if settled == nil {
+		return nil, fmt.Errorf("raid boss not found: %w", storage.ErrNotFound)
+	}
+	return settled, nil
+}
+
+// cooldownError tells the character how long to wait before attacking again
+func (rs *RaidService) cooldownError(boss *models.RaidBoss, characterID int, now time.Time) error {
+	contributions, err := rs.store.GetRaidContributions(boss.ID)
+	if err != nil {
+		return storage.ErrRaidCooldown
+	}
+
+	for _, contribution := range contributions {
+		if contribution.CharacterID == characterID {
+			wait := contribution.LastAttackAt.Add(boss.AttackCooldown()).Sub(now)
+			return fmt.Errorf("%w, try again in %ds", storage.ErrRaidCooldown, max(int(math.Ceil(wait.Seconds())), 1))
+		}
+	}
+	return storage.ErrRaidCooldown
+}
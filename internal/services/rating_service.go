@@ -0,0 +1,68 @@
+package services
+
+import (
+	"fmt"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/rating"
+	"twitch-rpg/internal/storage"
+)
+
+// RatingService handles Elo ratings and the ranked ladder
+type RatingService struct {
+	store storage.Store
+}
+
+// NewRatingService creates a new rating service
+func NewRatingService(store storage.Store) *RatingService {
+	return &RatingService{store: store}
+}
+
+// RecordFight updates both fighters' ratings after a logged fight
+func (rs *RatingService) RecordFight(combatLogID, winnerID, loserID int) ([]models.RatingHistoryEntry, error) {
+	if winnerID == loserID {
+		return nil, fmt.Errorf("a character cannot be rated against itself")
+	}
+
+	return rs.store.ApplyFightRating(combatLogID, winnerID, loserID, rating.Update)
+}
+
+// GetHistory retrieves a page of a character's rating changes, newest first
+func (rs *RatingService) GetHistory(characterID, limit, offset int) ([]models.RatingHistoryEntry, error) {
+	character, err := rs.store.GetCharacterByID(characterID)
+	if err != nil {
+		return nil, err
+	}
+	if character == nil {
+		return nil, fmt.Errorf("character not found: %w", storage.ErrNotFound)
+	}
+
+	return rs.store.GetRatingHistory(characterID, limit, offset)
+}
+
+// GetLadder retrieves a page of the ranked ladder
+func (rs *RatingService) GetLadder(limit, offset int) ([]models.LadderEntry, error) {
+	characters, err := rs.store.GetLadder(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	charService := NewCharacterService(rs.store)
+	ladder := make([]models.LadderEntry, 0, len(characters))
+	for i := range characters {
+		character := &characters[i]
+		if err := charService.hydrate(character); err != nil {
+			return nil, err
+		}
+		ladder = append(ladder, models.LadderEntry{
+			Rank:        offset + i + 1,
+			CharacterID: character.ID,
+			Username:    character.Username,
+			Level:       character.Level,
+			Rating:      character.Rating,
+			RatedFights: character.RatedFights,
+			CombatPower: character.CombatPower,
+		})
+	}
+
+	return ladder, nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"twitch-rpg/internal/channelpoints"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+)
+
+// ErrInvalidRedemptionInput is returned when a redemption's text input does not name
+// a valid stat or duel opponent
+var ErrInvalidRedemptionInput = errors.New("invalid redemption input")
+
+var (
+	channelPointRewardsOnce sync.Once
+	channelPointRewards     *channelpoints.Config
+)
+
+// configuredChannelPointRewards loads the rewards named by CHANNEL_POINT_REWARDS_PATH
+// once. Without the variable, or with an invalid file, no reward is part of the game.
+func configuredChannelPointRewards() *channelpoints.Config {
+	channelPointRewardsOnce.Do(func() {
+		channelPointRewards = &channelpoints.Config{}
+
+		path := os.Getenv("CHANNEL_POINT_REWARDS_PATH")
+		if path == "" {
+			return
+		}
+		config, err := channelpoints.Load(path)
+		if err != nil {
+			log.Printf("%v, no channel point rewards are configured", err)
+			return
+		}
+		channelPointRewards = config
+		log.Printf("Loaded %d channel point reward(s) from %s", len(config.Rewards), path)
+	})
+	return channelPointRewards
+}
+
+// RedemptionService turns channel point redemptions into game actions
+type RedemptionService struct {
+	store   storage.Store
+	rewards *channelpoints.Config
+}
+
+// NewRedemptionService creates a new redemption service using the configured rewards
+func NewRedemptionService(store storage.Store) *RedemptionService {
+	return &RedemptionService{store: store, rewards: configuredChannelPointRewards()}
+}
+
+// Redeem pays the cost of a redeemed reward into the redeemer's wallet and performs the
+// reward's action, creating a character for first-time redeemers. It returns nil for
+// rewards that are not part of the game.
+//
+// A failing action is reported in the outcome and its credit is reversed, so the
+// redemption can be canceled on Twitch. An error means nothing happened and the
+// redemption may be retried.
+func (rs *RedemptionService) Redeem(redemption *models.ChannelPointRedemption) (*models.RedemptionOutcome, error) {
+	reward := rs.rewards.Find(redemption.Reward.ID, redemption.Reward.Title)
+	if reward == nil {
+		return nil, nil
+	}
+
+	character, err := rs.characterFor(redemption)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome := &models.RedemptionOutcome{
+		RedemptionID: redemption.ID,
+		RewardTitle:  redemption.Reward.Title,
+		Action:       string(reward.Action),
+		CharacterID:  character.ID,
+	}
+
+	walletService := NewWalletService(rs.store)
+	if redemption.Reward.Cost > 0 {
+		if _, err := walletService.Credit(character.ID, redemption.Reward.Cost, models.WalletReasonRedemption,
+			models.ReferenceTwitchRedemption, redemption.ID); err != nil {
+			return nil, err
+		}
+		outcome.Credited = redemption.Reward.Cost
+	}
+
+	result, err := rs.perform(reward, character, redemption)
+	if err != nil {
+		outcome.Error = err.Error()
+		if outcome.Credited > 0 {
+			if _, undoErr := walletService.Debit(character.ID, outcome.Credited, models.WalletReasonRedemptionUndo,
+				models.ReferenceTwitchRedemption, redemption.ID); undoErr != nil {
+				log.Printf("Failed to reverse the credit of redemption %s: %v", redemption.ID, undoErr)
+			}
+		}
+		return outcome, nil
+	}
+
+	outcome.Fulfilled = true
+	outcome.Result = result
+	return outcome, nil
+}
+
+// characterFor finds the redeemer's character by Twitch user ID, following a changed
+// login name and creating the character on their first redemption
+func (rs *RedemptionService) characterFor(redemption *models.ChannelPointRedemption) (*models.Character, error) {
+	if redemption.UserLogin == "" {
+		return nil, fmt.Errorf("redemption %s has no user", redemption.ID)
+	}
+	return NewCharacterService(rs.store).GetOrCreateCharacter(redemption.UserLogin, redemption.UserID)
+}
+
+// perform runs the action of a reward for the redeemer's character
+func (rs *RedemptionService) perform(reward *channelpoints.Reward, character *models.Character, redemption *models.ChannelPointRedemption) (any, error) {
+	input := strings.TrimSpace(redemption.UserInput)
+
+	switch reward.Action {
+	case channelpoints.ActionStatUpgrade:
+		stat := reward.Stat
+		if stat == "" {
+			stat = strings.ToLower(input)
+		}
+		if !slices.Contains(channelpoints.Stats, stat) {
+			return nil, fmt.Errorf("%w: choose one of %s", ErrInvalidRedemptionInput, strings.Join(channelpoints.Stats, ", "))
+		}
+		return NewCharacterService(rs.store).UpgradeCharacterStat(character.ID, stat, redemption.Reward.Cost)
+
+	case channelpoints.ActionMerchantPurchase:
+		merchantService := NewMerchantService(rs.store)
+		event, err := merchantService.GetCurrentEvent()
+		if err != nil {
+			return nil, err
+		}
+		if event == nil {
+			return nil, storage.ErrMerchantInactive
+		}
+		offer, err := merchantService.ChooseOffer(event, input)
+		if err != nil {
+			return nil, err
+		}
+		return merchantService.PurchaseItem(character.ID, event.ID, offer.ID)
+
+	case channelpoints.ActionDuel:
+		name := ""
+		if fields := strings.Fields(input); len(fields) > 0 {
+			name = strings.TrimPrefix(fields[0], "@")
+		}
+		if name == "" {
+			return nil, fmt.Errorf("%w: name the character to challenge", ErrInvalidRedemptionInput)
+		}
+		opponent, err := rs.store.GetCharacterByUsername(name)
+		if err != nil {
+			return nil, err
+		}
+		if opponent == nil {
+			return nil, fmt.Errorf("character %q not found: %w", name, storage.ErrNotFound)
+		}
+		return NewDuelService(rs.store).Challenge(character.ID, opponent.ID, reward.Wager)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+)
+
+// WalletService handles channel point balances and the transaction ledger
+type WalletService struct {
+	store storage.Store
+}
+
+// NewWalletService creates a new wallet service
+func NewWalletService(store storage.Store) *WalletService {
+	return &WalletService{store: store}
+}
+
+// GetWallet retrieves the wallet of a character
+func (ws *WalletService) GetWallet(characterID int) (*models.Wallet, error) {
+	if err := ws.requireCharacter(characterID); err != nil {
+		return nil, err
+	}
+
+	return ws.store.GetWallet(characterID)
+}
+
+// GetTransactions retrieves a page of a character's ledger, newest first
+func (ws *WalletService) GetTransactions(characterID, limit, offset int) ([]models.WalletTransaction, error) {
+	if err := ws.requireCharacter(characterID); err != nil {
+		return nil, err
+	}
+
+	return ws.store.GetWalletTransactions(characterID, limit, offset)
+}
+
+// Credit adds channel points to a character's wallet
+func (ws *WalletService) Credit(characterID, amount int, reason, referenceType, referenceID string) (*models.WalletTransaction, error) {
+	return ws.apply(models.TransactionCredit, characterID, amount, reason, referenceType, referenceID)
+}
+
+// Debit removes channel points from a character's wallet, failing with
+// storage.ErrInsufficientFunds instead of overdrawing it
+func (ws *WalletService) Debit(characterID, amount int, reason, referenceType, referenceID string) (*models.WalletTransaction, error) {
+	return ws.apply(models.TransactionDebit, characterID, amount, reason, referenceType, referenceID)
+}
+
+// Refund reverses a debit after the operation it paid for failed
+func (ws *WalletService) Refund(debit *models.WalletTransaction) error {
+	_, err := ws.Credit(debit.CharacterID, debit.Amount, models.WalletReasonRefund, debit.ReferenceType, debit.ReferenceID)
+	return err
+}
+
+func (ws *WalletService) apply(transactionType models.WalletTransactionType, characterID, amount int, reason, referenceType, referenceID string) (*models.WalletTransaction, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if reason == "" {
+		return nil, fmt.Errorf("transaction reason is required")
+	}
+
+	transaction := &models.WalletTransaction{
+		CharacterID:   characterID,
+		Type:          transactionType,
+		Amount:        amount,
+		Reason:        reason,
+		ReferenceType: referenceType,
+		ReferenceID:   referenceID,
+	}
+	if err := ws.store.ApplyWalletTransaction(transaction); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+func (ws *WalletService) requireCharacter(characterID int) error {
+	character, err := ws.store.GetCharacterByID(characterID)
+	if err != nil {
+		return err
+	}
+	if character == nil {
+		return fmt.Errorf("character not found: %w", storage.ErrNotFound)
+	}
+	return nil
+}
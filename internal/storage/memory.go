@@ -1,418 +1,971 @@
 package storage
 
 import (
-        "fmt"
-        "sync"
-        "time"
-        "twitch-rpg/internal/models"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"twitch-rpg/internal/models"
 )
 
 // MemoryStorage provides in-memory storage for testing when database is unavailable
 type MemoryStorage struct {
-        characters     map[int]*models.Character
-        items          map[int]*models.Item
-        combatLogs     []models.CombatLog
-        events         []models.Event
-        merchants      []models.MerchantEvent
-        activeMerchant *models.MerchantEvent
-        inventories    map[int][]models.Item  // characterID -> items
-        
-        nextCharacterID int
-        nextCombatLogID int
-        nextEventID     int
-        nextMerchantID  int
-        
-        mutex sync.RWMutex
+	characters     map[int]*models.Character
+	items          map[int]*models.Item
+	characterItems []models.CharacterItem
+	combatLogs     []models.CombatLog
+	events         []models.Event
+	merchants      []models.MerchantEvent
+	merchantItems  []models.MerchantEventItem
+	wallets        map[int]*models.Wallet
+	walletLedger   []models.WalletTransaction
+	idempotency    map[string]*models.IdempotencyRecord // scope + "\x00" + key
+	duels          map[int]*models.DuelChallenge
+	ratingHistory  []models.RatingHistoryEntry
+	monsters       map[int]*models.Monster
+	huntLogs       []models.HuntLog
+	raids          map[int]*models.RaidBoss
+	raidDamage     map[int]map[int]*models.RaidContribution // raid ID -> character ID
+	gameEvents     []models.GameEvent
+	quests         map[int]*models.Quest
+	questProgress  map[int]*models.CharacterQuest // character quest ID
+	questRotations map[int]*models.QuestRotation
+	achievements   []models.CharacterAchievement
+	overlayQueues  map[string]*overlayQueue
+	usernames      []models.UsernameChange
+
+	nextCharacterID     int
+	nextItemID          int
+	nextCharacterItemID int
+	nextCombatLogID     int
+	nextEventID         int
+	nextMerchantID      int
+	nextMerchantItemID  int
+	nextWalletTxID      int
+	nextDuelID          int
+	nextRatingHistoryID int
+	nextMonsterID       int
+	nextHuntLogID       int
+	nextRaidID          int
+	nextGameEventID     int
+	nextQuestID         int
+	nextQuestProgressID int
+	nextQuestRotationID int
+	nextAchievementID   int
+	nextUsernameID      int
+
+	mutex sync.RWMutex
 }
 
 // NewMemoryStorage creates a new in-memory storage
 func NewMemoryStorage() *MemoryStorage {
-        ms := &MemoryStorage{
-                characters:      make(map[int]*models.Character),
-                items:           make(map[int]*models.Item),
-                combatLogs:      []models.CombatLog{},
-                events:          []models.Event{},
-                merchants:       []models.MerchantEvent{},
-                activeMerchant:   nil,
-                inventories:      make(map[int][]models.Item),
-                nextCharacterID: 1,
-                nextCombatLogID: 1,
-                nextEventID:     1,
-                nextMerchantID:  1,
-        }
-        
-        // Initialize with sample data
-        ms.initializeSampleData()
-        return ms
-}
-
-// Global instance
-var Memory *MemoryStorage
-
-func init() {
-        Memory = NewMemoryStorage()
+	ms := &MemoryStorage{
+		characters:          make(map[int]*models.Character),
+		items:               make(map[int]*models.Item),
+		characterItems:      []models.CharacterItem{},
+		combatLogs:          []models.CombatLog{},
+		events:              []models.Event{},
+		merchants:           []models.MerchantEvent{},
+		merchantItems:       []models.MerchantEventItem{},
+		wallets:             make(map[int]*models.Wallet),
+		walletLedger:        []models.WalletTransaction{},
+		idempotency:         make(map[string]*models.IdempotencyRecord),
+		duels:               make(map[int]*models.DuelChallenge),
+		ratingHistory:       []models.RatingHistoryEntry{},
+		monsters:            make(map[int]*models.Monster),
+		huntLogs:            []models.HuntLog{},
+		raids:               make(map[int]*models.RaidBoss),
+		raidDamage:          make(map[int]map[int]*models.RaidContribution),
+		gameEvents:          []models.GameEvent{},
+		quests:              make(map[int]*models.Quest),
+		questProgress:       make(map[int]*models.CharacterQuest),
+		questRotations:      make(map[int]*models.QuestRotation),
+		achievements:        []models.CharacterAchievement{},
+		overlayQueues:       make(map[string]*overlayQueue),
+		usernames:           []models.UsernameChange{},
+		nextCharacterID:     1,
+		nextItemID:          1,
+		nextCharacterItemID: 1,
+		nextCombatLogID:     1,
+		nextEventID:         1,
+		nextMerchantID:      1,
+		nextMerchantItemID:  1,
+		nextWalletTxID:      1,
+		nextDuelID:          1,
+		nextRatingHistoryID: 1,
+		nextMonsterID:       1,
+		nextHuntLogID:       1,
+		nextRaidID:          1,
+		nextGameEventID:     1,
+		nextQuestID:         1,
+		nextQuestProgressID: 1,
+		nextQuestRotationID: 1,
+		nextAchievementID:   1,
+		nextUsernameID:      1,
+	}
+
+	// Initialize with sample data
+	ms.initializeSampleData()
+	return ms
 }
 
 // Character operations
 func (ms *MemoryStorage) CreateCharacter(username string, twitchUserID *string) (*models.Character, error) {
-        ms.mutex.Lock()
-        defer ms.mutex.Unlock()
-        
-        // Check if username exists
-        for _, char := range ms.characters {
-                if char.Username == username {
-                        return nil, fmt.Errorf("character with username '%s' already exists", username)
-                }
-        }
-        
-        char := &models.Character{
-                ID:                 ms.nextCharacterID,
-                Username:           username,
-                TwitchUserID:       twitchUserID,
-                Level:              1,
-                Experience:         0,
-                ChannelPointsSpent: 0,
-                Strength:           10,
-                Agility:            10,
-                Vitality:           10,
-                Intelligence:       10,
-                CreatedAt:          time.Now(),
-                UpdatedAt:          time.Now(),
-        }
-        
-        char.CombatPower = char.CalculateCombatPower()
-        
-        ms.characters[ms.nextCharacterID] = char
-        ms.nextCharacterID++
-        
-        return char, nil
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	// Check if username or Twitch user ID exists
+	for _, char := range ms.characters {
+		if char.Username == username {
+			return nil, fmt.Errorf("character with username '%s' already exists: %w", username, ErrDuplicateUsername)
+		}
+		if twitchUserID != nil && char.TwitchUserID != nil && *char.TwitchUserID == *twitchUserID {
+			return nil, fmt.Errorf("character with twitch user id '%s' already exists: %w", *twitchUserID, ErrDuplicateTwitchUserID)
+		}
+	}
+
+	now := time.Now()
+	char := &models.Character{
+		ID:                 ms.nextCharacterID,
+		Username:           username,
+		TwitchUserID:       copyString(twitchUserID),
+		Level:              1,
+		Experience:         0,
+		ChannelPointsSpent: 0,
+		Strength:           10,
+		Agility:            10,
+		Vitality:           10,
+		Intelligence:       10,
+		Rating:             models.DefaultRating,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+
+	ms.characters[char.ID] = char
+	ms.nextCharacterID++
+
+	return cloneCharacter(char), nil
 }
 
 func (ms *MemoryStorage) GetCharacterByID(id int) (*models.Character, error) {
-        ms.mutex.RLock()
-        defer ms.mutex.RUnlock()
-        
-        char, exists := ms.characters[id]
-        if !exists {
-                return nil, nil
-        }
-        
-        // Create a copy to avoid race conditions
-        result := *char
-        result.CombatPower = result.CalculateCombatPower()
-        
-        return &result, nil
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	char, exists := ms.characters[id]
+	if !exists {
+		return nil, nil
+	}
+
+	return cloneCharacter(char), nil
 }
 
 func (ms *MemoryStorage) GetCharacterByUsername(username string) (*models.Character, error) {
-        ms.mutex.RLock()
-        defer ms.mutex.RUnlock()
-        
-        for _, char := range ms.characters {
-                if char.Username == username {
-                        result := *char
-                        result.CombatPower = result.CalculateCombatPower()
-                        return &result, nil
-                }
-        }
-        
-        return nil, nil
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	for _, char := range ms.characters {
+		if char.Username == username {
+			return cloneCharacter(char), nil
+		}
+	}
+
+	return nil, nil
 }
 
 func (ms *MemoryStorage) UpdateCharacter(char *models.Character) error {
-        ms.mutex.Lock()
-        defer ms.mutex.Unlock()
-        
-        if _, exists := ms.characters[char.ID]; !exists {
-                return fmt.Errorf("character not found")
-        }
-        
-        char.UpdatedAt = time.Now()
-        ms.characters[char.ID] = char
-        
-        return nil
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	stored, exists := ms.characters[char.ID]
+	if !exists {
+		return fmt.Errorf("character not found")
+	}
+
+	updated := cloneCharacter(char)
+	updated.Username = stored.Username
+	updated.TwitchUserID = stored.TwitchUserID
+	updated.Rating = stored.Rating
+	updated.RatedFights = stored.RatedFights
+	updated.Strength = stored.Strength
+	updated.Agility = stored.Agility
+	updated.Vitality = stored.Vitality
+	updated.Intelligence = stored.Intelligence
+	updated.ChannelPointsSpent = stored.ChannelPointsSpent
+	updated.CreatedAt = stored.CreatedAt
+	updated.UpdatedAt = time.Now()
+	ms.characters[char.ID] = updated
+
+	return nil
+}
+
+func (ms *MemoryStorage) UpgradeCharacterStat(characterID int, stat string, amount, cost int) (*models.WalletTransaction, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	char, exists := ms.characters[characterID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	var field *int
+	switch stat {
+	case "strength":
+		field = &char.Strength
+	case "agility":
+		field = &char.Agility
+	case "vitality":
+		field = &char.Vitality
+	case "intelligence":
+		field = &char.Intelligence
+	default:
+		return nil, fmt.Errorf("unknown stat %q", stat)
+	}
+
+	// The debit is the only step that can fail, so apply it first
+	debit := &models.WalletTransaction{
+		CharacterID:   characterID,
+		Type:          models.TransactionDebit,
+		Amount:        cost,
+		Reason:        models.WalletReasonStatUpgrade,
+		ReferenceType: models.ReferenceStatUpgrade,
+		ReferenceID:   stat,
+	}
+	if err := ms.applyWalletTransactionLocked(debit); err != nil {
+		return nil, err
+	}
+
+	*field += amount
+	char.ChannelPointsSpent += cost
+	char.UpdatedAt = time.Now()
+	return debit, nil
 }
 
 func (ms *MemoryStorage) GetAllCharacters() ([]models.Character, error) {
-        ms.mutex.RLock()
-        defer ms.mutex.RUnlock()
-        
-        var characters []models.Character
-        for _, char := range ms.characters {
-                result := *char
-                result.CombatPower = result.CalculateCombatPower()
-                characters = append(characters, result)
-        }
-        
-        return characters, nil
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	characters := make([]models.Character, 0, len(ms.characters))
+	for _, char := range ms.characters {
+		characters = append(characters, *cloneCharacter(char))
+	}
+
+	// Same ordering as the MySQL query: level DESC, experience DESC
+	sort.Slice(characters, func(i, j int) bool {
+		if characters[i].Level != characters[j].Level {
+			return characters[i].Level > characters[j].Level
+		}
+		if characters[i].Experience != characters[j].Experience {
+			return characters[i].Experience > characters[j].Experience
+		}
+		return characters[i].ID < characters[j].ID
+	})
+
+	return characters, nil
 }
 
 // Item operations
+func (ms *MemoryStorage) CreateItem(item *models.Item) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	stored := *item
+	stored.ID = ms.nextItemID
+	stored.CreatedAt = time.Now()
+	stored.SpecialEffect = copyString(item.SpecialEffect)
+	ms.items[stored.ID] = &stored
+	ms.nextItemID++
+
+	item.ID = stored.ID
+	item.CreatedAt = stored.CreatedAt
+	return nil
+}
+
 func (ms *MemoryStorage) GetItemByID(id int) (*models.Item, error) {
-        ms.mutex.RLock()
-        defer ms.mutex.RUnlock()
-        
-        item, exists := ms.items[id]
-        if !exists {
-                return nil, nil
-        }
-        
-        result := *item
-        return &result, nil
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	item, exists := ms.items[id]
+	if !exists {
+		return nil, nil
+	}
+
+	result := *item
+	return &result, nil
 }
 
 func (ms *MemoryStorage) GetRandomItems(count int, isSpecial bool) ([]models.Item, error) {
-        ms.mutex.RLock()
-        defer ms.mutex.RUnlock()
-        
-        var items []models.Item
-        added := 0
-        
-        for _, item := range ms.items {
-                if item.IsSpecial == isSpecial && added < count {
-                        items = append(items, *item)
-                        added++
-                }
-        }
-        
-        return items, nil
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	var items []models.Item
+	for _, item := range ms.items {
+		if item.IsSpecial == isSpecial {
+			items = append(items, *item)
+		}
+	}
+
+	rand.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+	if len(items) > count {
+		items = items[:count]
+	}
+
+	return items, nil
 }
 
 func (ms *MemoryStorage) GetItemsByType(itemType models.ItemType, limit, offset int) ([]models.Item, error) {
-        ms.mutex.RLock()
-        defer ms.mutex.RUnlock()
-        
-        var items []models.Item
-        skipped := 0
-        added := 0
-        
-        for _, item := range ms.items {
-                if item.Type == itemType {
-                        if skipped < offset {
-                                skipped++
-                                continue
-                        }
-                        if added >= limit {
-                                break
-                        }
-                        items = append(items, *item)
-                        added++
-                }
-        }
-        
-        return items, nil
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	var items []models.Item
+	for _, item := range ms.items {
+		if item.Type == itemType {
+			items = append(items, *item)
+		}
+	}
+	sortItemsByRarityAndValue(items)
+
+	if offset >= len(items) {
+		return nil, nil
+	}
+	items = items[offset:]
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
+func (ms *MemoryStorage) CharacterOwnsItem(characterID, itemID int) (bool, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	for _, ci := range ms.characterItems {
+		if ci.CharacterID == characterID && ci.ItemID == itemID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (ms *MemoryStorage) AddItemToCharacter(characterID, itemID, quantity int) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	return ms.addItemToCharacterLocked(characterID, itemID, quantity)
+}
+
+// addItemToCharacterLocked adds to an inventory; the caller must hold the write lock
+func (ms *MemoryStorage) addItemToCharacterLocked(characterID, itemID, quantity int) error {
+	if _, exists := ms.characters[characterID]; !exists {
+		return fmt.Errorf("character not found")
+	}
+	if _, exists := ms.items[itemID]; !exists {
+		return fmt.Errorf("item not found")
+	}
+
+	for i := range ms.characterItems {
+		if ms.characterItems[i].CharacterID == characterID && ms.characterItems[i].ItemID == itemID {
+			ms.characterItems[i].Quantity += quantity
+			return nil
+		}
+	}
+
+	ms.characterItems = append(ms.characterItems, models.CharacterItem{
+		ID:          ms.nextCharacterItemID,
+		CharacterID: characterID,
+		ItemID:      itemID,
+		Quantity:    quantity,
+		AcquiredAt:  time.Now(),
+	})
+	ms.nextCharacterItemID++
+
+	return nil
+}
+
+func (ms *MemoryStorage) GetCharacterItems(characterID int) ([]models.CharacterItem, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	var result []models.CharacterItem
+	for _, ci := range ms.characterItems {
+		if ci.CharacterID != characterID {
+			continue
+		}
+		item := *ms.items[ci.ItemID]
+		ci.Item = &item
+		result = append(result, ci)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return itemLess(result[i].Item, result[j].Item)
+	})
+
+	return result, nil
 }
 
 // Combat operations
-func (ms *MemoryStorage) AddCombatLog(log models.CombatLog) error {
-        ms.mutex.Lock()
-        defer ms.mutex.Unlock()
-        
-        log.ID = ms.nextCombatLogID
-        log.CreatedAt = time.Now()
-        ms.combatLogs = append(ms.combatLogs, log)
-        ms.nextCombatLogID++
-        
-        return nil
+func (ms *MemoryStorage) AddCombatLog(log *models.CombatLog) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	log.ID = ms.nextCombatLogID
+	log.CreatedAt = time.Now()
+
+	stored := *log
+	stored.Attacker, stored.Defender, stored.Winner = nil, nil, nil
+	ms.combatLogs = append(ms.combatLogs, cloneCombatLog(stored))
+	ms.nextCombatLogID++
+
+	return nil
+}
+
+func (ms *MemoryStorage) GetCombatLogByID(id int) (*models.CombatLog, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	for _, log := range ms.combatLogs {
+		if log.ID == id {
+			clone := cloneCombatLog(log)
+			return &clone, nil
+		}
+	}
+
+	return nil, nil
 }
 
 func (ms *MemoryStorage) GetCombatHistory(limit int) ([]models.CombatLog, error) {
-        ms.mutex.RLock()
-        defer ms.mutex.RUnlock()
-        
-        // Return most recent logs
-        start := len(ms.combatLogs) - limit
-        if start < 0 {
-                start = 0
-        }
-        
-        var result []models.CombatLog
-        for i := len(ms.combatLogs) - 1; i >= start; i-- {
-                result = append(result, ms.combatLogs[i])
-        }
-        
-        return result, nil
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	// Return most recent logs
+	start := len(ms.combatLogs) - limit
+	if start < 0 {
+		start = 0
+	}
+
+	var result []models.CombatLog
+	for i := len(ms.combatLogs) - 1; i >= start; i-- {
+		result = append(result, cloneCombatLog(ms.combatLogs[i]))
+	}
+
+	return result, nil
+}
+
+func (ms *MemoryStorage) CountCombatWins(characterID int) (int, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	wins := 0
+	for _, combatLog := range ms.combatLogs {
+		if combatLog.WinnerID == characterID {
+			wins++
+		}
+	}
+
+	return wins, nil
 }
 
 // Event operations
+func (ms *MemoryStorage) CreateEvent(event *models.Event) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	event.ID = ms.nextEventID
+	event.CreatedAt = time.Now()
+	ms.events = append(ms.events, *event)
+	ms.nextEventID++
+
+	return nil
+}
+
+func (ms *MemoryStorage) GetEventByID(id int) (*models.Event, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	for _, event := range ms.events {
+		if event.ID == id {
+			result := event
+			return &result, nil
+		}
+	}
+
+	return nil, nil
+}
+
 func (ms *MemoryStorage) GetLatestEvents(limit int) ([]models.Event, error) {
-        ms.mutex.RLock()
-        defer ms.mutex.RUnlock()
-        
-        // Return most recent events
-        start := len(ms.events) - limit
-        if start < 0 {
-                start = 0
-        }
-        
-        var result []models.Event
-        for i := len(ms.events) - 1; i >= start; i-- {
-                result = append(result, ms.events[i])
-        }
-        
-        return result, nil
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	// Return most recent events
+	start := len(ms.events) - limit
+	if start < 0 {
+		start = 0
+	}
+
+	var result []models.Event
+	for i := len(ms.events) - 1; i >= start; i-- {
+		result = append(result, ms.events[i])
+	}
+
+	return result, nil
 }
 
 func (ms *MemoryStorage) MarkEventTriggered(eventID int) error {
-        ms.mutex.Lock()
-        defer ms.mutex.Unlock()
-        
-        for i := range ms.events {
-                if ms.events[i].ID == eventID {
-                        ms.events[i].IsTriggered = true
-                        return nil
-                }
-        }
-        
-        return fmt.Errorf("event not found")
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	for i := range ms.events {
+		if ms.events[i].ID == eventID {
+			ms.events[i].IsTriggered = true
+			return nil
+		}
+	}
+
+	return fmt.Errorf("event not found")
+}
+
+// Merchant operations
+func (ms *MemoryStorage) CreateMerchantEvent(eventType string, startTime, endTime time.Time, offers []models.MerchantEventItem) (*models.MerchantEvent, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	// Deactivate current merchants
+	for i := range ms.merchants {
+		ms.merchants[i].IsActive = false
+	}
+
+	itemIDs := make([]int, 0, len(offers))
+	for _, offer := range offers {
+		if _, exists := ms.items[offer.ItemID]; !exists {
+			return nil, fmt.Errorf("item %d not found", offer.ItemID)
+		}
+		itemIDs = append(itemIDs, offer.ItemID)
+	}
+	availableItems, err := json.Marshal(itemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode available items: %v", err)
+	}
+
+	end := endTime
+	merchant := models.MerchantEvent{
+		ID:             ms.nextMerchantID,
+		EventType:      eventType,
+		AvailableItems: availableItems,
+		StartTime:      startTime,
+		EndTime:        &end,
+		IsActive:       true,
+	}
+	ms.merchants = append(ms.merchants, merchant)
+	ms.nextMerchantID++
+
+	for _, offer := range offers {
+		offer.ID = ms.nextMerchantItemID
+		offer.MerchantEventID = merchant.ID
+		offer.Purchased = 0
+		offer.Item = nil
+		ms.merchantItems = append(ms.merchantItems, offer)
+		ms.nextMerchantItemID++
+	}
+
+	return cloneMerchantEvent(merchant), nil
+}
+
+func (ms *MemoryStorage) GetCurrentMerchantEvent() (*models.MerchantEvent, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	now := time.Now()
+	var current *models.MerchantEvent
+	for i := range ms.merchants {
+		merchant := &ms.merchants[i]
+		if !merchant.IsActive || (merchant.EndTime != nil && !merchant.EndTime.After(now)) {
+			continue
+		}
+		if current == nil || merchant.StartTime.After(current.StartTime) {
+			current = merchant
+		}
+	}
+
+	if current == nil {
+		return nil, nil
+	}
+	return cloneMerchantEvent(*current), nil
+}
+
+func (ms *MemoryStorage) GetMerchantEventByID(id int) (*models.MerchantEvent, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	for _, merchant := range ms.merchants {
+		if merchant.ID == id {
+			return cloneMerchantEvent(merchant), nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (ms *MemoryStorage) GetMerchantEventItem(id int) (*models.MerchantEventItem, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	for _, offer := range ms.merchantItems {
+		if offer.ID == id {
+			result := offer
+			return &result, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (ms *MemoryStorage) GetMerchantEventItems(merchantEventID int) ([]models.MerchantEventItem, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	var result []models.MerchantEventItem
+	for _, offer := range ms.merchantItems {
+		if offer.MerchantEventID == merchantEventID {
+			result = append(result, offer)
+		}
+	}
+
+	return result, nil
+}
+
+func (ms *MemoryStorage) PurchaseMerchantItem(characterID, merchantEventID, merchantEventItemID int) (*models.MerchantPurchase, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	// Validate everything before mutating so a failure leaves no trace, like a rolled back transaction
+	var offer *models.MerchantEventItem
+	for i := range ms.merchantItems {
+		if ms.merchantItems[i].ID == merchantEventItemID {
+			offer = &ms.merchantItems[i]
+			break
+		}
+	}
+	if offer == nil {
+		return nil, fmt.Errorf("merchant event item: %w", ErrNotFound)
+	}
+	if merchantEventID != 0 && offer.MerchantEventID != merchantEventID {
+		return nil, ErrOfferMismatch
+	}
+
+	var merchant *models.MerchantEvent
+	for i := range ms.merchants {
+		if ms.merchants[i].ID == offer.MerchantEventID {
+			merchant = &ms.merchants[i]
+			break
+		}
+	}
+	if merchant == nil || !merchant.IsActive || (merchant.EndTime != nil && !merchant.EndTime.After(time.Now())) {
+		return nil, ErrMerchantInactive
+	}
+
+	if offer.Purchased >= offer.Stock {
+		return nil, ErrOutOfStock
+	}
+
+	char, exists := ms.characters[characterID]
+	if !exists {
+		return nil, fmt.Errorf("character not found")
+	}
+	if _, exists := ms.items[offer.ItemID]; !exists {
+		return nil, fmt.Errorf("item not found")
+	}
+
+	// The debit is the only step that can still fail, so apply it first
+	debit := &models.WalletTransaction{
+		CharacterID:   characterID,
+		Type:          models.TransactionDebit,
+		Amount:        offer.PriceChannelPoints,
+		Reason:        models.WalletReasonMerchantPurchase,
+		ReferenceType: models.ReferenceMerchantEventItem,
+		ReferenceID:   strconv.Itoa(offer.ID),
+	}
+	if err := ms.applyWalletTransactionLocked(debit); err != nil {
+		return nil, err
+	}
+
+	offer.Purchased++
+	if err := ms.addItemToCharacterLocked(characterID, offer.ItemID, 1); err != nil {
+		return nil, err
+	}
+	char.ChannelPointsSpent += offer.PriceChannelPoints
+	char.UpdatedAt = time.Now()
+
+	item := *ms.items[offer.ItemID]
+	return &models.MerchantPurchase{
+		CharacterID:         characterID,
+		MerchantEventID:     offer.MerchantEventID,
+		MerchantEventItemID: offer.ID,
+		ItemID:              offer.ItemID,
+		Price:               offer.PriceChannelPoints,
+		RemainingStock:      offer.Stock - offer.Purchased,
+		Transaction:         debit,
+		Item:                &item,
+	}, nil
 }
 
 // Initialize sample data for testing
 func (ms *MemoryStorage) initializeSampleData() {
-        // Sample items
-        ms.items[1] = &models.Item{
-                ID:               1,
-                Name:             "Iron Boots",
-                Type:             models.ItemTypeBoots,
-                Rarity:           models.RarityCommon,
-                StrengthBonus:    2,
-                AgilityBonus:     1,
-                VitalityBonus:    1,
-                IntelligenceBonus: 0,
-                Value:            50,
-                IsSpecial:        false,
-                CreatedAt:        time.Now(),
-        }
-        
-        ms.items[2] = &models.Item{
-                ID:               2,
-                Name:             "Mystic Sword",
-                Type:             models.ItemTypeRing, // Using ring as example
-                Rarity:           models.RarityRare,
-                StrengthBonus:    5,
-                AgilityBonus:     3,
-                VitalityBonus:    2,
-                IntelligenceBonus: 4,
-                SpecialEffect:    stringPtr("Increases magic damage"),
-                Value:            200,
-                IsSpecial:        true,
-                CreatedAt:        time.Now(),
-        }
-        
-        ms.items[3] = &models.Item{
-                ID:               3,
-                Name:             "Leather Pants",
-                Type:             models.ItemTypePants,
-                Rarity:           models.RarityCommon,
-                StrengthBonus:    1,
-                AgilityBonus:     3,
-                VitalityBonus:    2,
-                IntelligenceBonus: 0,
-                Value:            40,
-                IsSpecial:        false,
-                CreatedAt:        time.Now(),
-        }
-        
-        // Sample events
-        ms.events = append(ms.events, models.Event{
-                ID:          1,
-                Type:        "combat",
-                Title:       "Epic Battle",
-                Description: "A fierce battle took place in the arena",
-                Data:        `{"winner": "TestUser", "experience": 100}`,
-                IsTriggered: false,
-                CreatedAt:   time.Now(),
-        })
+	// Sample items
+	sampleItems := []models.Item{
+		{
+			Name:              "Iron Boots",
+			Type:              models.ItemTypeBoots,
+			Rarity:            models.RarityCommon,
+			StrengthBonus:     2,
+			AgilityBonus:      1,
+			VitalityBonus:     1,
+			IntelligenceBonus: 0,
+			Value:             50,
+			IsSpecial:         false,
+		},
+		{
+			Name:              "Mystic Sword",
+			Type:              models.ItemTypeRing, // Using ring as example
+			Rarity:            models.RarityRare,
+			StrengthBonus:     5,
+			AgilityBonus:      3,
+			VitalityBonus:     2,
+			IntelligenceBonus: 4,
+			SpecialEffect:     stringPtr("Increases magic damage"),
+			Value:             200,
+			IsSpecial:         true,
+		},
+		{
+			Name:              "Leather Pants",
+			Type:              models.ItemTypePants,
+			Rarity:            models.RarityCommon,
+			StrengthBonus:     1,
+			AgilityBonus:      3,
+			VitalityBonus:     2,
+			IntelligenceBonus: 0,
+			Value:             40,
+			IsSpecial:         false,
+		},
+	}
+	for i := range sampleItems {
+		item := sampleItems[i]
+		item.ID = ms.nextItemID
+		item.CreatedAt = time.Now()
+		ms.items[item.ID] = &item
+		ms.nextItemID++
+	}
+
+	// Sample monsters, dropping the sample items
+	sampleMonsters := []models.Monster{
+		{
+			Name:             "Giant Rat",
+			Description:      "A fat rat from the sewers",
+			LevelMin:         1,
+			LevelMax:         3,
+			Strength:         6,
+			Agility:          8,
+			Vitality:         5,
+			Intelligence:     1,
+			ExperienceReward: 30,
+			Loot:             []models.MonsterLoot{{ItemID: 1, DropChance: 0.15, Quantity: 1}}, // Iron Boots
+		},
+		{
+			Name:             "Goblin",
+			Description:      "A greedy little thief with a rusty knife",
+			LevelMin:         1,
+			LevelMax:         5,
+			Strength:         8,
+			Agility:          9,
+			Vitality:         7,
+			Intelligence:     3,
+			ExperienceReward: 45,
+			Loot:             []models.MonsterLoot{{ItemID: 3, DropChance: 0.12, Quantity: 1}}, // Leather Pants
+		},
+		{
+			Name:             "Forest Witch",
+			Description:      "Hurls curses from the undergrowth",
+			LevelMin:         8,
+			LevelMax:         15,
+			Strength:         6,
+			Agility:          10,
+			Vitality:         11,
+			Intelligence:     18,
+			ExperienceReward: 150,
+			Loot:             []models.MonsterLoot{{ItemID: 2, DropChance: 0.05, Quantity: 1}}, // Mystic Sword
+		},
+	}
+	for i := range sampleMonsters {
+		ms.createMonsterLocked(&sampleMonsters[i])
+	}
+
+	// Sample quests
+	sampleQuests := []models.Quest{
+		{
+			Name:         "Arena Warm-up",
+			Description:  "Win two fights in the arena",
+			QuestType:    models.QuestTypeDaily,
+			Requirements: json.RawMessage(`[{"type": "win_fights", "count": 2}]`),
+			Rewards:      json.RawMessage(`{"experience": 60, "channel_points": 50}`),
+			IsActive:     true,
+		},
+		{
+			Name:         "Window Shopping",
+			Description:  "Buy something from a travelling merchant",
+			QuestType:    models.QuestTypeDaily,
+			Requirements: json.RawMessage(`[{"type": "merchant_purchase", "count": 1}]`),
+			Rewards:      json.RawMessage(`{"channel_points": 75}`),
+			IsActive:     true,
+		},
+		{
+			Name:             "Seasoned Adventurer",
+			Description:      "Reach level 5 and own a rare item",
+			QuestType:        models.QuestTypeWeekly,
+			Requirements:     json.RawMessage(`[{"type": "reach_level", "level": 5}, {"type": "own_item_rarity", "rarity": "rare"}]`),
+			Rewards:          json.RawMessage(`{"experience": 250, "channel_points": 300, "item_ids": [1]}`),
+			ChannelPointCost: 50,
+			IsActive:         true,
+		},
+	}
+	for i := range sampleQuests {
+		ms.createQuestLocked(&sampleQuests[i])
+	}
+
+	// Sample events
+	ms.events = append(ms.events, models.Event{
+		ID:          ms.nextEventID,
+		Type:        "combat",
+		Title:       "Epic Battle",
+		Description: "A fierce battle took place in the arena",
+		Data:        `{"winner": "TestUser", "experience": 100}`,
+		IsTriggered: false,
+		CreatedAt:   time.Now(),
+	})
+	ms.nextEventID++
 }
 
-// Merchant operations
-func (ms *MemoryStorage) GetCurrentMerchant() (*models.MerchantEvent, error) {
-        ms.mutex.RLock()
-        defer ms.mutex.RUnlock()
-        
-        if ms.activeMerchant != nil && ms.activeMerchant.IsActive {
-                // Check if still active
-                if ms.activeMerchant.EndTime == nil || ms.activeMerchant.EndTime.After(time.Now()) {
-                        result := *ms.activeMerchant
-                        return &result, nil
-                }
-                // Expired
-                ms.activeMerchant = nil
-        }
-        
-        return nil, nil
-}
-
-func (ms *MemoryStorage) CreateMerchant(eventType string, durationMinutes int) (*models.MerchantEvent, error) {
-        ms.mutex.Lock()
-        defer ms.mutex.Unlock()
-        
-        // Deactivate current merchant
-        if ms.activeMerchant != nil {
-                ms.activeMerchant.IsActive = false
-        }
-        
-        // Create new merchant event
-        endTime := time.Now().Add(time.Duration(durationMinutes) * time.Minute)
-        merchant := &models.MerchantEvent{
-                ID:             ms.nextMerchantID,
-                EventType:      eventType,
-                AvailableItems: []byte(`[1,2,3]`), // Sample items
-                StartTime:      time.Now(),
-                EndTime:        &endTime,
-                IsActive:       true,
-        }
-        
-        ms.activeMerchant = merchant
-        ms.merchants = append(ms.merchants, *merchant)
-        ms.nextMerchantID++
-        
-        return merchant, nil
-}
-
-func (ms *MemoryStorage) PurchaseItem(characterID, itemID int, price int) error {
-        ms.mutex.Lock()
-        defer ms.mutex.Unlock()
-        
-        // Get character
-        char, exists := ms.characters[characterID]
-        if !exists {
-                return fmt.Errorf("character not found")
-        }
-        
-        // Get item
-        item, exists := ms.items[itemID]
-        if !exists {
-                return fmt.Errorf("item not found")
-        }
-        
-        // Update character points
-        char.ChannelPointsSpent += price
-        
-        // Save the updated character back to storage
-        ms.characters[characterID] = char
-        
-        // Add item to character's inventory
-        if ms.inventories[characterID] == nil {
-                ms.inventories[characterID] = []models.Item{}
-        }
-        ms.inventories[characterID] = append(ms.inventories[characterID], *item)
-        
-        return nil
-}
-
-func (ms *MemoryStorage) GetCharacterInventory(characterID int) ([]models.Item, error) {
-        ms.mutex.RLock()
-        defer ms.mutex.RUnlock()
-        
-        if inventory, exists := ms.inventories[characterID]; exists {
-                return inventory, nil
-        }
-        
-        return []models.Item{}, nil
+// rarityRank orders rarities the same way the MySQL ENUM does
+func rarityRank(rarity models.ItemRarity) int {
+	switch rarity {
+	case models.RarityCommon:
+		return 1
+	case models.RarityRare:
+		return 2
+	case models.RarityEpic:
+		return 3
+	case models.RarityLegendary:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// itemLess reports whether a sorts before b in "rarity DESC, value DESC" order
+func itemLess(a, b *models.Item) bool {
+	if rarityRank(a.Rarity) != rarityRank(b.Rarity) {
+		return rarityRank(a.Rarity) > rarityRank(b.Rarity)
+	}
+	if a.Value != b.Value {
+		return a.Value > b.Value
+	}
+	return a.ID < b.ID
+}
+
+func sortItemsByRarityAndValue(items []models.Item) {
+	sort.Slice(items, func(i, j int) bool {
+		return itemLess(&items[i], &items[j])
+	})
+}
+
+// cloneCharacter returns a deep copy of the stored columns of a character
+func cloneCharacter(char *models.Character) *models.Character {
+	result := *char
+	result.TwitchUserID = copyString(char.TwitchUserID)
+	result.BootsID = copyInt(char.BootsID)
+	result.PantsID = copyInt(char.PantsID)
+	result.ArmorID = copyInt(char.ArmorID)
+	result.HelmetID = copyInt(char.HelmetID)
+	result.RingID = copyInt(char.RingID)
+	result.ChainID = copyInt(char.ChainID)
+
+	// Calculated fields are owned by the service layer
+	result.Equipment = nil
+	result.TotalStats = nil
+	result.CombatPower = 0
+
+	return &result
+}
+
+func cloneMerchantEvent(merchant models.MerchantEvent) *models.MerchantEvent {
+	result := merchant
+	result.AvailableItems = append(json.RawMessage(nil), merchant.AvailableItems...)
+	if merchant.EndTime != nil {
+		end := *merchant.EndTime
+		result.EndTime = &end
+	}
+	result.Items = nil
+	return &result
 }
 
 // Helper function to create string pointer
 func stringPtr(s string) *string {
-        return &s
-}
\ No newline at end of file
+	return &s
+}
+
+func copyString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := *s
+	return &v
+}
+
+func copyInt(i *int) *int {
+	if i == nil {
+		return nil
+	}
+	v := *i
+	return &v
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	value := *t
+	return &value
+}
+
+// cloneCombatLog deep-copies a combat log so stored logs cannot be mutated by callers
+func cloneCombatLog(log models.CombatLog) models.CombatLog {
+	log.Transcript = cloneTranscript(log.Transcript)
+	if log.Seed != nil {
+		seed := *log.Seed
+		log.Seed = &seed
+	}
+	if log.AttackerSnapshot != nil {
+		snapshot := *log.AttackerSnapshot
+		log.AttackerSnapshot = &snapshot
+	}
+	if log.DefenderSnapshot != nil {
+		snapshot := *log.DefenderSnapshot
+		log.DefenderSnapshot = &snapshot
+	}
+	return log
+}
+
+// cloneTranscript deep-copies a combat transcript
+func cloneTranscript(transcript *models.CombatTranscript) *models.CombatTranscript {
+	if transcript == nil {
+		return nil
+	}
+	clone := *transcript
+	clone.Rounds = make([]models.CombatRound, len(transcript.Rounds))
+	for i, round := range transcript.Rounds {
+		clone.Rounds[i] = round
+		clone.Rounds[i].Actions = append([]models.CombatAction(nil), round.Actions...)
+	}
+	return &clone
+}
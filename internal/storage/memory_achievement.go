@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"sort"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Achievement operations
+func (ms *MemoryStorage) UnlockAchievement(achievement *models.CharacterAchievement) (bool, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	if _, exists := ms.characters[achievement.CharacterID]; !exists {
+		return false, ErrNotFound
+	}
+	for _, existing := range ms.achievements {
+		if existing.CharacterID == achievement.CharacterID && existing.AchievementKey == achievement.AchievementKey &&
+			existing.Tier == achievement.Tier {
+			return false, nil
+		}
+	}
+
+	achievement.ID = ms.nextAchievementID
+	if achievement.UnlockedAt.IsZero() {
+		achievement.UnlockedAt = time.Now()
+	}
+
+	ms.achievements = append(ms.achievements, models.CharacterAchievement{
+		ID:             achievement.ID,
+		CharacterID:    achievement.CharacterID,
+		AchievementKey: achievement.AchievementKey,
+		Tier:           achievement.Tier,
+		UnlockedAt:     achievement.UnlockedAt,
+	})
+	ms.nextAchievementID++
+
+	return true, nil
+}
+
+func (ms *MemoryStorage) GetCharacterAchievements(characterID int) ([]models.CharacterAchievement, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	result := []models.CharacterAchievement{}
+	for _, achievement := range ms.achievements {
+		if achievement.CharacterID == characterID {
+			result = append(result, achievement)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].UnlockedAt.Equal(result[j].UnlockedAt) {
+			return result[i].UnlockedAt.Before(result[j].UnlockedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
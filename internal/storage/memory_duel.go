@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"sort"
+	"strconv"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+func cloneDuelChallenge(challenge *models.DuelChallenge) *models.DuelChallenge {
+	clone := *challenge
+	clone.CombatLogID = copyInt(challenge.CombatLogID)
+	clone.WinnerID = copyInt(challenge.WinnerID)
+	if challenge.RespondedAt != nil {
+		respondedAt := *challenge.RespondedAt
+		clone.RespondedAt = &respondedAt
+	}
+	return &clone
+}
+
+// Duel operations
+func (ms *MemoryStorage) CreateDuelChallenge(challenge *models.DuelChallenge) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	now := time.Now()
+	for _, existing := range ms.duels {
+		samePair := (existing.ChallengerID == challenge.ChallengerID && existing.DefenderID == challenge.DefenderID) ||
+			(existing.ChallengerID == challenge.DefenderID && existing.DefenderID == challenge.ChallengerID)
+		if samePair && existing.Status == models.DuelStatusPending && existing.ExpiresAt.After(now) {
+			return ErrDuelChallengePending
+		}
+	}
+
+	// The escrow is the only step that can fail, so apply it first
+	id := ms.nextDuelID
+	if challenge.Wager > 0 {
+		escrow := &models.WalletTransaction{
+			CharacterID:   challenge.ChallengerID,
+			Type:          models.TransactionDebit,
+			Amount:        challenge.Wager,
+			Reason:        models.WalletReasonDuelWager,
+			ReferenceType: models.ReferenceDuelChallenge,
+			ReferenceID:   strconv.Itoa(id),
+		}
+		if err := ms.applyWalletTransactionLocked(escrow); err != nil {
+			return err
+		}
+	}
+
+	challenge.ID = id
+	challenge.Status = models.DuelStatusPending
+	challenge.CreatedAt = now
+	challenge.CombatLogID, challenge.WinnerID, challenge.RespondedAt = nil, nil, nil
+
+	ms.duels[challenge.ID] = cloneDuelChallenge(challenge)
+	ms.nextDuelID++
+
+	return nil
+}
+
+func (ms *MemoryStorage) GetDuelChallengeByID(id int) (*models.DuelChallenge, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	challenge, exists := ms.duels[id]
+	if !exists {
+		return nil, nil
+	}
+
+	return cloneDuelChallenge(challenge), nil
+}
+
+func (ms *MemoryStorage) GetPendingDuelChallenges(characterID int) ([]models.DuelChallenge, error) {
+	return ms.filterDuelChallenges(func(challenge *models.DuelChallenge) bool {
+		return challenge.Status == models.DuelStatusPending &&
+			(challenge.ChallengerID == characterID || challenge.DefenderID == characterID)
+	}), nil
+}
+
+func (ms *MemoryStorage) GetExpiredDuelChallenges(now time.Time) ([]models.DuelChallenge, error) {
+	return ms.filterDuelChallenges(func(challenge *models.DuelChallenge) bool {
+		return challenge.Status == models.DuelStatusPending && !challenge.ExpiresAt.After(now)
+	}), nil
+}
+
+func (ms *MemoryStorage) filterDuelChallenges(match func(*models.DuelChallenge) bool) []models.DuelChallenge {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	result := []models.DuelChallenge{}
+	for _, challenge := range ms.duels {
+		if match(challenge) {
+			result = append(result, *cloneDuelChallenge(challenge))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result
+}
+
+func (ms *MemoryStorage) TransitionDuelChallenge(id int, from, to models.DuelStatus) (bool, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	challenge, exists := ms.duels[id]
+	if !exists {
+		return false, ErrNotFound
+	}
+	if challenge.Status != from {
+		return false, nil
+	}
+
+	if from == models.DuelStatusPending {
+		now := time.Now()
+		challenge.RespondedAt = &now
+	}
+	challenge.Status = to
+
+	return true, nil
+}
+
+func (ms *MemoryStorage) SetDuelChallengeResult(id, combatLogID, winnerID int) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	challenge, exists := ms.duels[id]
+	if !exists {
+		return ErrNotFound
+	}
+
+	challenge.CombatLogID = &combatLogID
+	challenge.WinnerID = &winnerID
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+func cloneGameEvent(event *models.GameEvent) models.GameEvent {
+	clone := *event
+	clone.CharacterID = copyInt(event.CharacterID)
+	clone.EventData = append(json.RawMessage(nil), event.EventData...)
+	clone.Character = nil
+	return clone
+}
+
+// Game event operations
+func (ms *MemoryStorage) CreateGameEvent(event *models.GameEvent) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	event.ID = ms.nextGameEventID
+	event.CreatedAt = time.Now()
+
+	ms.gameEvents = append(ms.gameEvents, cloneGameEvent(event))
+	ms.nextGameEventID++
+
+	return nil
+}
+
+func (ms *MemoryStorage) GetLatestGameEvents(limit int) ([]models.GameEvent, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	events := []models.GameEvent{}
+	for i := len(ms.gameEvents) - 1; i >= 0 && len(events) < limit; i-- {
+		events = append(events, cloneGameEvent(&ms.gameEvents[i]))
+	}
+
+	return events, nil
+}
+
+func (ms *MemoryStorage) GetGameEventsAfter(afterID int, filter models.GameEventFilter, limit int) ([]models.GameEvent, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	events := []models.GameEvent{}
+	for i := range ms.gameEvents {
+		if len(events) >= limit {
+			break
+		}
+		if ms.gameEvents[i].ID > afterID && filter.Matches(&ms.gameEvents[i]) {
+			events = append(events, cloneGameEvent(&ms.gameEvents[i]))
+		}
+	}
+
+	return events, nil
+}
+
+func (ms *MemoryStorage) MarkGameEventTriggered(id int) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	for i := range ms.gameEvents {
+		if ms.gameEvents[i].ID == id {
+			ms.gameEvents[i].OBSTriggered = true
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
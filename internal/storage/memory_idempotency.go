@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+func idempotencyMapKey(scope, key string) string {
+	return scope + "\x00" + key
+}
+
+// Idempotency operations
+func (ms *MemoryStorage) ReserveIdempotencyKey(record *models.IdempotencyRecord, staleBefore time.Time) (*models.IdempotencyRecord, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	mapKey := idempotencyMapKey(record.Scope, record.Key)
+	if existing, exists := ms.idempotency[mapKey]; exists {
+		if existing.Completed || !existing.CreatedAt.Before(staleBefore) {
+			result := *existing
+			result.ResponseBody = append([]byte(nil), existing.ResponseBody...)
+			return &result, nil
+		}
+		// Abandoned reservation: take it over below
+	}
+
+	stored := models.IdempotencyRecord{
+		Scope:       record.Scope,
+		Key:         record.Key,
+		RequestHash: record.RequestHash,
+		CreatedAt:   time.Now(),
+	}
+	ms.idempotency[mapKey] = &stored
+	record.CreatedAt = stored.CreatedAt
+
+	return nil, nil
+}
+
+func (ms *MemoryStorage) CompleteIdempotencyKey(scope, key string, statusCode int, responseBody []byte) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	record, exists := ms.idempotency[idempotencyMapKey(scope, key)]
+	if !exists {
+		return ErrNotFound
+	}
+
+	now := time.Now()
+	record.StatusCode = statusCode
+	record.ResponseBody = append([]byte(nil), responseBody...)
+	record.Completed = true
+	record.CompletedAt = &now
+
+	return nil
+}
+
+func (ms *MemoryStorage) ReleaseIdempotencyKey(scope, key string) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	mapKey := idempotencyMapKey(scope, key)
+	if record, exists := ms.idempotency[mapKey]; exists && !record.Completed {
+		delete(ms.idempotency, mapKey)
+	}
+
+	return nil
+}
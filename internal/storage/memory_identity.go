@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Identity operations
+func (ms *MemoryStorage) GetCharacterByTwitchUserID(twitchUserID string) (*models.Character, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	for _, char := range ms.characters {
+		if char.TwitchUserID != nil && *char.TwitchUserID == twitchUserID {
+			return cloneCharacter(char), nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (ms *MemoryStorage) LinkTwitchUserID(characterID int, twitchUserID string) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	char, exists := ms.characters[characterID]
+	if !exists {
+		return ErrNotFound
+	}
+	if char.TwitchUserID != nil {
+		if *char.TwitchUserID == twitchUserID {
+			return nil
+		}
+		return ErrTwitchUserIDMismatch
+	}
+	for _, other := range ms.characters {
+		if other.TwitchUserID != nil && *other.TwitchUserID == twitchUserID {
+			return fmt.Errorf("character with twitch user id '%s' already exists: %w", twitchUserID, ErrDuplicateTwitchUserID)
+		}
+	}
+
+	char.TwitchUserID = &twitchUserID
+	char.UpdatedAt = time.Now()
+	return nil
+}
+
+func (ms *MemoryStorage) RenameCharacter(characterID int, username string) (*models.UsernameChange, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	char, exists := ms.characters[characterID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	if char.Username == username {
+		return nil, nil
+	}
+	for _, other := range ms.characters {
+		if other.Username == username {
+			return nil, fmt.Errorf("character with username '%s' already exists: %w", username, ErrDuplicateUsername)
+		}
+	}
+
+	now := time.Now()
+	change := models.UsernameChange{
+		ID:          ms.nextUsernameID,
+		CharacterID: characterID,
+		OldUsername: char.Username,
+		NewUsername: username,
+		ChangedAt:   now,
+	}
+	ms.nextUsernameID++
+	ms.usernames = append(ms.usernames, change)
+
+	char.Username = username
+	char.UpdatedAt = now
+	return &change, nil
+}
+
+func (ms *MemoryStorage) GetUsernameHistory(characterID int) ([]models.UsernameChange, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	result := []models.UsernameChange{}
+	for i := len(ms.usernames) - 1; i >= 0; i-- {
+		if ms.usernames[i].CharacterID == characterID {
+			result = append(result, ms.usernames[i])
+		}
+	}
+
+	return result, nil
+}
+
+func (ms *MemoryStorage) GetCharacterByFormerUsername(username string) (*models.Character, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	for i := len(ms.usernames) - 1; i >= 0; i-- {
+		if ms.usernames[i].OldUsername != username {
+			continue
+		}
+		if char, exists := ms.characters[ms.usernames[i].CharacterID]; exists {
+			return cloneCharacter(char), nil
+		}
+	}
+
+	return nil, nil
+}
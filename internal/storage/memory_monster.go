@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"sort"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+func cloneMonster(monster *models.Monster) *models.Monster {
+	clone := *monster
+	clone.Loot = make([]models.MonsterLoot, len(monster.Loot))
+	for i, loot := range monster.Loot {
+		loot.Item = nil
+		clone.Loot[i] = loot
+	}
+	return &clone
+}
+
+// Monster operations
+func (ms *MemoryStorage) CreateMonster(monster *models.Monster) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.createMonsterLocked(monster)
+	return nil
+}
+
+func (ms *MemoryStorage) createMonsterLocked(monster *models.Monster) {
+	monster.ID = ms.nextMonsterID
+	monster.CreatedAt = time.Now()
+	for i := range monster.Loot {
+		monster.Loot[i].MonsterID = monster.ID
+	}
+
+	ms.monsters[monster.ID] = cloneMonster(monster)
+	ms.nextMonsterID++
+}
+
+func (ms *MemoryStorage) GetMonsterByID(id int) (*models.Monster, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	monster, exists := ms.monsters[id]
+	if !exists {
+		return nil, nil
+	}
+
+	return cloneMonster(monster), nil
+}
+
+func (ms *MemoryStorage) GetMonsters() ([]models.Monster, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	monsters := make([]models.Monster, 0, len(ms.monsters))
+	for _, monster := range ms.monsters {
+		monsters = append(monsters, *cloneMonster(monster))
+	}
+
+	sort.Slice(monsters, func(i, j int) bool {
+		if monsters[i].LevelMin != monsters[j].LevelMin {
+			return monsters[i].LevelMin < monsters[j].LevelMin
+		}
+		return monsters[i].ID < monsters[j].ID
+	})
+
+	return monsters, nil
+}
+
+func (ms *MemoryStorage) AddHuntLog(log *models.HuntLog) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	log.ID = ms.nextHuntLogID
+	log.CreatedAt = time.Now()
+
+	stored := *log
+	stored.DropItemIDs = append([]int{}, log.DropItemIDs...)
+	stored.Transcript = cloneTranscript(log.Transcript)
+	ms.huntLogs = append(ms.huntLogs, stored)
+	ms.nextHuntLogID++
+
+	return nil
+}
+
+func (ms *MemoryStorage) GetHuntHistory(characterID, limit int) ([]models.HuntLog, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	result := []models.HuntLog{}
+	for i := len(ms.huntLogs) - 1; i >= 0 && len(result) < limit; i-- {
+		if ms.huntLogs[i].CharacterID != characterID {
+			continue
+		}
+		log := ms.huntLogs[i]
+		log.DropItemIDs = append([]int{}, log.DropItemIDs...)
+		log.Transcript = cloneTranscript(log.Transcript)
+		result = append(result, log)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"sort"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+type overlayQueue struct {
+	consumer   models.OverlayConsumer
+	deliveries map[int]*models.OverlayDelivery // event ID
+}
+
+// gameEventByID finds a stored game event; IDs are assigned in increasing order
+func (ms *MemoryStorage) gameEventByID(id int) *models.GameEvent {
+	i := sort.Search(len(ms.gameEvents), func(i int) bool { return ms.gameEvents[i].ID >= id })
+	if i == len(ms.gameEvents) || ms.gameEvents[i].ID != id {
+		return nil
+	}
+	event := cloneGameEvent(&ms.gameEvents[i])
+	return &event
+}
+
+// Overlay queue operations
+func (ms *MemoryStorage) ClaimOverlayEvents(consumer, token string, limit int, now, visibleUntil time.Time) ([]models.OverlayDelivery, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	queue, exists := ms.overlayQueues[consumer]
+	if !exists {
+		queue = &overlayQueue{
+			consumer:   models.OverlayConsumer{Name: consumer, CreatedAt: now},
+			deliveries: make(map[int]*models.OverlayDelivery),
+		}
+		if len(ms.gameEvents) > 0 {
+			queue.consumer.Cursor = ms.gameEvents[len(ms.gameEvents)-1].ID
+		}
+		ms.overlayQueues[consumer] = queue
+	}
+
+	claimed := []*models.OverlayDelivery{}
+
+	// Redeliver expired claims first, oldest event first
+	expired := []int{}
+	for eventID, delivery := range queue.deliveries {
+		if !delivery.ClaimedUntil.After(now) {
+			expired = append(expired, eventID)
+		}
+	}
+	sort.Ints(expired)
+	for _, eventID := range expired[:min(len(expired), limit)] {
+		delivery := queue.deliveries[eventID]
+		delivery.ClaimToken = token
+		delivery.ClaimedUntil = visibleUntil
+		delivery.Attempts++
+		claimed = append(claimed, delivery)
+	}
+
+	for i := range ms.gameEvents {
+		if len(claimed) >= limit {
+			break
+		}
+		if ms.gameEvents[i].ID <= queue.consumer.Cursor {
+			continue
+		}
+		delivery := &models.OverlayDelivery{
+			Consumer:     consumer,
+			EventID:      ms.gameEvents[i].ID,
+			ClaimToken:   token,
+			ClaimedUntil: visibleUntil,
+			Attempts:     1,
+		}
+		queue.deliveries[delivery.EventID] = delivery
+		queue.consumer.Cursor = delivery.EventID
+		claimed = append(claimed, delivery)
+	}
+
+	deliveries := make([]models.OverlayDelivery, 0, len(claimed))
+	for _, delivery := range claimed {
+		clone := *delivery
+		clone.Event = ms.gameEventByID(delivery.EventID)
+		deliveries = append(deliveries, clone)
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].EventID < deliveries[j].EventID })
+
+	return deliveries, nil
+}
+
+func (ms *MemoryStorage) AckOverlayEvents(consumer, token string, eventIDs []int) ([]int, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	acknowledged := []int{}
+	queue, exists := ms.overlayQueues[consumer]
+	if !exists {
+		return acknowledged, nil
+	}
+
+	for _, eventID := range eventIDs {
+		delivery, claimed := queue.deliveries[eventID]
+		if claimed && delivery.ClaimToken == token {
+			delete(queue.deliveries, eventID)
+			acknowledged = append(acknowledged, eventID)
+		}
+	}
+
+	return acknowledged, nil
+}
+
+func (ms *MemoryStorage) GetOverlayConsumer(consumer string) (*models.OverlayConsumer, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	queue, exists := ms.overlayQueues[consumer]
+	if !exists {
+		return nil, nil
+	}
+
+	status := queue.consumer
+	status.InFlight = len(queue.deliveries)
+	return &status, nil
+}
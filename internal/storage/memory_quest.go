@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+func cloneQuest(quest *models.Quest) *models.Quest {
+	clone := *quest
+	clone.Requirements = append(json.RawMessage(nil), quest.Requirements...)
+	clone.Rewards = append(json.RawMessage(nil), quest.Rewards...)
+	return &clone
+}
+
+func cloneCharacterQuest(characterQuest *models.CharacterQuest) *models.CharacterQuest {
+	clone := *characterQuest
+	clone.Progress = append(json.RawMessage(nil), characterQuest.Progress...)
+	clone.CompletedAt = copyTime(characterQuest.CompletedAt)
+	clone.ExpiresAt = copyTime(characterQuest.ExpiresAt)
+	clone.Quest, clone.Character = nil, nil
+	return &clone
+}
+
+// Quest operations
+func (ms *MemoryStorage) CreateQuest(quest *models.Quest) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	ms.createQuestLocked(quest)
+	return nil
+}
+
+func (ms *MemoryStorage) createQuestLocked(quest *models.Quest) {
+	quest.ID = ms.nextQuestID
+	ms.quests[quest.ID] = cloneQuest(quest)
+	ms.nextQuestID++
+}
+
+func (ms *MemoryStorage) GetQuestByID(id int) (*models.Quest, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	quest, exists := ms.quests[id]
+	if !exists {
+		return nil, nil
+	}
+
+	return cloneQuest(quest), nil
+}
+
+func (ms *MemoryStorage) GetActiveQuests() ([]models.Quest, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	quests := []models.Quest{}
+	for _, quest := range ms.quests {
+		if quest.IsActive {
+			quests = append(quests, *cloneQuest(quest))
+		}
+	}
+
+	sort.Slice(quests, func(i, j int) bool {
+		return quests[i].ID < quests[j].ID
+	})
+
+	return quests, nil
+}
+
+func (ms *MemoryStorage) CreateCharacterQuest(characterQuest *models.CharacterQuest) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	if _, exists := ms.characters[characterQuest.CharacterID]; !exists {
+		return ErrNotFound
+	}
+	if _, exists := ms.quests[characterQuest.QuestID]; !exists {
+		return ErrNotFound
+	}
+	for _, existing := range ms.questProgress {
+		if existing.CharacterID == characterQuest.CharacterID && existing.QuestID == characterQuest.QuestID &&
+			existing.RotationID == characterQuest.RotationID {
+			return ErrQuestAlreadyAccepted
+		}
+	}
+
+	characterQuest.ID = ms.nextQuestProgressID
+	characterQuest.StartedAt = time.Now()
+	characterQuest.Completed, characterQuest.CompletedAt = false, nil
+	characterQuest.Expired = false
+
+	ms.questProgress[characterQuest.ID] = cloneCharacterQuest(characterQuest)
+	ms.nextQuestProgressID++
+
+	return nil
+}
+
+func (ms *MemoryStorage) GetCharacterQuests(characterID int) ([]models.CharacterQuest, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	result := []models.CharacterQuest{}
+	for _, characterQuest := range ms.questProgress {
+		if characterQuest.CharacterID != characterID {
+			continue
+		}
+		clone := cloneCharacterQuest(characterQuest)
+		clone.Quest = cloneQuest(ms.quests[clone.QuestID])
+		result = append(result, *clone)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+func (ms *MemoryStorage) UpdateCharacterQuestProgress(id int, update QuestProgressFunc) (*models.CharacterQuest, bool, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	characterQuest, exists := ms.questProgress[id]
+	if !exists {
+		return nil, false, ErrNotFound
+	}
+	if characterQuest.Completed || characterQuest.Expired || characterQuest.IsPastExpiry(time.Now()) {
+		return cloneCharacterQuest(characterQuest), false, nil
+	}
+
+	progress, completed, err := update(append(json.RawMessage(nil), characterQuest.Progress...))
+	if err != nil {
+		return nil, false, err
+	}
+
+	characterQuest.Progress = append(json.RawMessage(nil), progress...)
+	if completed {
+		now := time.Now()
+		characterQuest.Completed = true
+		characterQuest.CompletedAt = &now
+	}
+
+	return cloneCharacterQuest(characterQuest), completed, nil
+}
+
+func (ms *MemoryStorage) ExpireCharacterQuests(now time.Time) (int, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	expired := 0
+	for _, characterQuest := range ms.questProgress {
+		if !characterQuest.Completed && !characterQuest.Expired && characterQuest.IsPastExpiry(now) {
+			characterQuest.Expired = true
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+func cloneQuestRotation(rotation *models.QuestRotation) *models.QuestRotation {
+	clone := *rotation
+	clone.QuestIDs = append([]int{}, rotation.QuestIDs...)
+	clone.Quests = nil
+	return &clone
+}
+
+// Quest rotation operations
+func (ms *MemoryStorage) CreateQuestRotation(rotation *models.QuestRotation) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	for _, existing := range ms.questRotations {
+		if existing.QuestType == rotation.QuestType && existing.PeriodStart.Equal(rotation.PeriodStart) {
+			return ErrQuestRotationExists
+		}
+	}
+
+	rotation.ID = ms.nextQuestRotationID
+	rotation.CreatedAt = time.Now()
+
+	ms.questRotations[rotation.ID] = cloneQuestRotation(rotation)
+	ms.nextQuestRotationID++
+
+	return nil
+}
+
+func (ms *MemoryStorage) GetQuestRotation(questType string, at time.Time) (*models.QuestRotation, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	var latest *models.QuestRotation
+	for _, rotation := range ms.questRotations {
+		if rotation.QuestType != questType || rotation.PeriodStart.After(at) || !rotation.PeriodEnd.After(at) {
+			continue
+		}
+		if latest == nil || rotation.PeriodStart.After(latest.PeriodStart) {
+			latest = rotation
+		}
+	}
+	if latest == nil {
+		return nil, nil
+	}
+
+	return cloneQuestRotation(latest), nil
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"sort"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+func cloneRaidBoss(boss *models.RaidBoss) *models.RaidBoss {
+	clone := *boss
+	clone.LastHitBy = copyInt(boss.LastHitBy)
+	clone.ExpiresAt = copyTime(boss.ExpiresAt)
+	clone.EndedAt = copyTime(boss.EndedAt)
+	return &clone
+}
+
+func cloneRaidContribution(contribution *models.RaidContribution) *models.RaidContribution {
+	clone := *contribution
+	clone.RewardPoints = copyInt(contribution.RewardPoints)
+	clone.RewardExperience = copyInt(contribution.RewardExperience)
+	clone.Username = ""
+	return &clone
+}
+
+// Raid operations
+func (ms *MemoryStorage) CreateRaidBoss(boss *models.RaidBoss) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	boss.ID = ms.nextRaidID
+	boss.Status = models.RaidStatusActive
+	boss.CurrentHP = boss.MaxHP
+	boss.SpawnedAt = time.Now()
+	boss.LastHitBy, boss.EndedAt = nil, nil
+
+	ms.raids[boss.ID] = cloneRaidBoss(boss)
+	ms.raidDamage[boss.ID] = make(map[int]*models.RaidContribution)
+	ms.nextRaidID++
+
+	return nil
+}
+
+func (ms *MemoryStorage) GetRaidBossByID(id int) (*models.RaidBoss, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	boss, exists := ms.raids[id]
+	if !exists {
+		return nil, nil
+	}
+
+	return cloneRaidBoss(boss), nil
+}
+
+func (ms *MemoryStorage) GetActiveRaidBoss() (*models.RaidBoss, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	var active *models.RaidBoss
+	for _, boss := range ms.raids {
+		if boss.Status == models.RaidStatusActive && (active == nil || boss.ID > active.ID) {
+			active = boss
+		}
+	}
+	if active == nil {
+		return nil, nil
+	}
+
+	return cloneRaidBoss(active), nil
+}
+
+func (ms *MemoryStorage) GetRaidBosses(limit int) ([]models.RaidBoss, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	bosses := make([]models.RaidBoss, 0, len(ms.raids))
+	for _, boss := range ms.raids {
+		bosses = append(bosses, *cloneRaidBoss(boss))
+	}
+
+	sort.Slice(bosses, func(i, j int) bool {
+		return bosses[i].ID > bosses[j].ID
+	})
+	if len(bosses) > limit {
+		bosses = bosses[:limit]
+	}
+
+	return bosses, nil
+}
+
+func (ms *MemoryStorage) ApplyRaidDamage(raidID, characterID, damage int, now time.Time) (*models.RaidHit, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	boss, exists := ms.raids[raidID]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	if _, exists := ms.characters[characterID]; !exists {
+		return nil, ErrNotFound
+	}
+	if boss.IsOver(now) {
+		return nil, ErrRaidNotActive
+	}
+
+	contribution, exists := ms.raidDamage[raidID][characterID]
+	if exists && now.Before(contribution.LastAttackAt.Add(boss.AttackCooldown())) {
+		return nil, ErrRaidCooldown
+	}
+	if !exists {
+		contribution = &models.RaidContribution{RaidID: raidID, CharacterID: characterID}
+		ms.raidDamage[raidID][characterID] = contribution
+	}
+
+	hit := &models.RaidHit{
+		Damage:   min(max(damage, 0), boss.CurrentHP),
+		HPBefore: boss.CurrentHP,
+	}
+
+	boss.CurrentHP -= hit.Damage
+	boss.LastHitBy = &characterID
+	if boss.CurrentHP == 0 {
+		boss.Status = models.RaidStatusDefeated
+		boss.EndedAt = &now
+		hit.Defeated = true
+	}
+
+	contribution.Damage += hit.Damage
+	contribution.Attacks++
+	contribution.LastAttackAt = now
+
+	hit.Raid = cloneRaidBoss(boss)
+	hit.Contribution = cloneRaidContribution(contribution)
+	return hit, nil
+}
+
+func (ms *MemoryStorage) GetRaidContributions(raidID int) ([]models.RaidContribution, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	contributions := make([]models.RaidContribution, 0, len(ms.raidDamage[raidID]))
+	for _, contribution := range ms.raidDamage[raidID] {
+		clone := cloneRaidContribution(contribution)
+		if character, exists := ms.characters[clone.CharacterID]; exists {
+			clone.Username = character.Username
+		}
+		contributions = append(contributions, *clone)
+	}
+
+	sort.Slice(contributions, func(i, j int) bool {
+		if contributions[i].Damage != contributions[j].Damage {
+			return contributions[i].Damage > contributions[j].Damage
+		}
+		return contributions[i].CharacterID < contributions[j].CharacterID
+	})
+
+	return contributions, nil
+}
+
+func (ms *MemoryStorage) TransitionRaidBoss(id int, from, to models.RaidStatus) (bool, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	boss, exists := ms.raids[id]
+	if !exists {
+		return false, ErrNotFound
+	}
+	if boss.Status != from {
+		return false, nil
+	}
+
+	if from == models.RaidStatusActive {
+		now := time.Now()
+		boss.EndedAt = &now
+	}
+	boss.Status = to
+
+	return true, nil
+}
+
+func (ms *MemoryStorage) SetRaidReward(raidID, characterID, points, experience int) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	contribution, exists := ms.raidDamage[raidID][characterID]
+	if !exists {
+		return ErrNotFound
+	}
+
+	contribution.RewardPoints = &points
+	contribution.RewardExperience = &experience
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"sort"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Rating operations
+func (ms *MemoryStorage) ApplyFightRating(combatLogID, winnerID, loserID int, update RatingFunc) ([]models.RatingHistoryEntry, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	winner, winnerExists := ms.characters[winnerID]
+	loser, loserExists := ms.characters[loserID]
+	if !winnerExists || !loserExists {
+		return nil, ErrNotFound
+	}
+
+	newWinnerRating, newLoserRating := update(winner.Rating, winner.RatedFights, loser.Rating, loser.RatedFights)
+
+	now := time.Now()
+	entries := []models.RatingHistoryEntry{
+		{CharacterID: winnerID, OpponentID: loserID, Won: true, RatingBefore: winner.Rating, RatingAfter: newWinnerRating},
+		{CharacterID: loserID, OpponentID: winnerID, Won: false, RatingBefore: loser.Rating, RatingAfter: newLoserRating},
+	}
+	for i := range entries {
+		entries[i].ID = ms.nextRatingHistoryID
+		entries[i].CombatLogID = combatLogID
+		entries[i].CreatedAt = now
+		ms.nextRatingHistoryID++
+		ms.ratingHistory = append(ms.ratingHistory, entries[i])
+	}
+
+	winner.Rating, loser.Rating = newWinnerRating, newLoserRating
+	winner.RatedFights++
+	loser.RatedFights++
+
+	return entries, nil
+}
+
+func (ms *MemoryStorage) GetRatingHistory(characterID, limit, offset int) ([]models.RatingHistoryEntry, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	result := []models.RatingHistoryEntry{}
+	skipped := 0
+	for i := len(ms.ratingHistory) - 1; i >= 0 && len(result) < limit; i-- {
+		if ms.ratingHistory[i].CharacterID != characterID {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		result = append(result, ms.ratingHistory[i])
+	}
+
+	return result, nil
+}
+
+func (ms *MemoryStorage) GetLadder(limit, offset int) ([]models.Character, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	ranked := []models.Character{}
+	for _, char := range ms.characters {
+		if char.RatedFights > 0 {
+			ranked = append(ranked, *cloneCharacter(char))
+		}
+	}
+
+	// Same ordering as the MySQL query: rating DESC, rated_fights DESC, id ASC
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Rating != ranked[j].Rating {
+			return ranked[i].Rating > ranked[j].Rating
+		}
+		if ranked[i].RatedFights != ranked[j].RatedFights {
+			return ranked[i].RatedFights > ranked[j].RatedFights
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+
+	if offset >= len(ranked) {
+		return []models.Character{}, nil
+	}
+	end := min(offset+limit, len(ranked))
+	return ranked[offset:end], nil
+}
@@ -0,0 +1,13 @@
+package storage_test
+
+import (
+	"testing"
+	"twitch-rpg/internal/storage"
+	"twitch-rpg/internal/storage/storagetest"
+)
+
+func TestMemoryStorageConformance(t *testing.T) {
+	storagetest.RunConformance(t, func(t *testing.T) storage.Store {
+		return storage.NewMemoryStorage()
+	})
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Wallet operations
+func (ms *MemoryStorage) GetWallet(characterID int) (*models.Wallet, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	if wallet, exists := ms.wallets[characterID]; exists {
+		result := *wallet
+		return &result, nil
+	}
+
+	return &models.Wallet{CharacterID: characterID}, nil
+}
+
+func (ms *MemoryStorage) ApplyWalletTransaction(transaction *models.WalletTransaction) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	return ms.applyWalletTransactionLocked(transaction)
+}
+
+// applyWalletTransactionLocked updates a balance and appends to the ledger; the caller must hold the write lock
+func (ms *MemoryStorage) applyWalletTransactionLocked(transaction *models.WalletTransaction) error {
+	if transaction.Amount <= 0 {
+		return fmt.Errorf("wallet transaction amount must be positive")
+	}
+	if transaction.Type != models.TransactionCredit && transaction.Type != models.TransactionDebit {
+		return fmt.Errorf("invalid wallet transaction type %q", transaction.Type)
+	}
+	if _, exists := ms.characters[transaction.CharacterID]; !exists {
+		return fmt.Errorf("character not found")
+	}
+
+	wallet, exists := ms.wallets[transaction.CharacterID]
+	if !exists {
+		wallet = &models.Wallet{CharacterID: transaction.CharacterID}
+	}
+
+	balance := wallet.Balance + transaction.SignedAmount()
+	if balance < 0 {
+		return ErrInsufficientFunds
+	}
+
+	now := time.Now()
+	wallet.Balance = balance
+	if transaction.Type == models.TransactionCredit {
+		wallet.LifetimeEarned += transaction.Amount
+	} else {
+		wallet.LifetimeSpent += transaction.Amount
+	}
+	wallet.UpdatedAt = now
+	ms.wallets[transaction.CharacterID] = wallet
+
+	transaction.ID = ms.nextWalletTxID
+	transaction.BalanceAfter = balance
+	transaction.CreatedAt = now
+	ms.walletLedger = append(ms.walletLedger, *transaction)
+	ms.nextWalletTxID++
+
+	return nil
+}
+
+func (ms *MemoryStorage) GetWalletTransactions(characterID, limit, offset int) ([]models.WalletTransaction, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	var result []models.WalletTransaction
+	skipped := 0
+	for i := len(ms.walletLedger) - 1; i >= 0 && len(result) < limit; i-- {
+		if ms.walletLedger[i].CharacterID != characterID {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		result = append(result, ms.walletLedger[i])
+	}
+
+	return result, nil
+}
@@ -0,0 +1,859 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"twitch-rpg/internal/models"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQLStorage implements Store on top of a MySQL connection
+type MySQLStorage struct {
+	db *sql.DB
+}
+
+// NewMySQLStorage creates a store backed by the given database handle
+func NewMySQLStorage(db *sql.DB) *MySQLStorage {
+	return &MySQLStorage{db: db}
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// isDuplicateKey reports whether err is a MySQL unique constraint violation
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+// isForeignKeyViolation reports whether err is a MySQL error for a missing referenced row
+func isForeignKeyViolation(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1452
+}
+
+const characterColumns = `id, username, twitch_user_id, level, experience, channel_points_spent,
+		strength, agility, vitality, intelligence, rating, rated_fights,
+		boots_id, pants_id, armor_id, helmet_id, ring_id, chain_id,
+		created_at, updated_at`
+
+func scanCharacter(row rowScanner) (*models.Character, error) {
+	character := &models.Character{}
+	err := row.Scan(
+		&character.ID, &character.Username, &character.TwitchUserID,
+		&character.Level, &character.Experience, &character.ChannelPointsSpent,
+		&character.Strength, &character.Agility, &character.Vitality, &character.Intelligence,
+		&character.Rating, &character.RatedFights,
+		&character.BootsID, &character.PantsID, &character.ArmorID,
+		&character.HelmetID, &character.RingID, &character.ChainID,
+		&character.CreatedAt, &character.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return character, nil
+}
+
+const itemColumns = `id, name, type, rarity, strength_bonus, agility_bonus,
+		vitality_bonus, intelligence_bonus, special_effect, value, is_special, created_at`
+
+func scanItem(row rowScanner) (*models.Item, error) {
+	item := &models.Item{}
+	err := row.Scan(
+		&item.ID, &item.Name, &item.Type, &item.Rarity,
+		&item.StrengthBonus, &item.AgilityBonus, &item.VitalityBonus, &item.IntelligenceBonus,
+		&item.SpecialEffect, &item.Value, &item.IsSpecial, &item.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func scanItems(rows *sql.Rows) ([]models.Item, error) {
+	defer rows.Close()
+
+	var items []models.Item
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan item: %v", err)
+		}
+		items = append(items, *item)
+	}
+
+	return items, rows.Err()
+}
+
+// Character operations
+
+func (s *MySQLStorage) CreateCharacter(username string, twitchUserID *string) (*models.Character, error) {
+	query := `
+		INSERT INTO characters (username, twitch_user_id, level, experience, channel_points_spent,
+			strength, agility, vitality, intelligence)
+		VALUES (?, ?, 1, 0, 0, 10, 10, 10, 10)`
+
+	result, err := s.db.Exec(query, username, twitchUserID)
+	if err != nil {
+		if isDuplicateKey(err) && strings.Contains(err.Error(), "twitch_user_id") {
+			return nil, fmt.Errorf("character with twitch user id '%s' already exists: %w", *twitchUserID, ErrDuplicateTwitchUserID)
+		}
+		if isDuplicateKey(err) {
+			return nil, fmt.Errorf("character with username '%s' already exists: %w", username, ErrDuplicateUsername)
+		}
+		return nil, fmt.Errorf("failed to create character: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get character ID: %v", err)
+	}
+
+	return s.GetCharacterByID(int(id))
+}
+
+func (s *MySQLStorage) GetCharacterByID(id int) (*models.Character, error) {
+	query := `SELECT ` + characterColumns + ` FROM characters WHERE id = ?`
+
+	character, err := scanCharacter(s.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get character: %v", err)
+	}
+
+	return character, nil
+}
+
+func (s *MySQLStorage) GetCharacterByUsername(username string) (*models.Character, error) {
+	query := `SELECT ` + characterColumns + ` FROM characters WHERE username = ?`
+
+	character, err := scanCharacter(s.db.QueryRow(query, username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get character: %v", err)
+	}
+
+	return character, nil
+}
+
+func (s *MySQLStorage) UpdateCharacter(character *models.Character) error {
+	query := `
+		UPDATE characters SET
+			level = ?, experience = ?,
+			boots_id = ?, pants_id = ?, armor_id = ?, helmet_id = ?, ring_id = ?, chain_id = ?
+		WHERE id = ?`
+
+	result, err := s.db.Exec(query,
+		character.Level, character.Experience,
+		character.BootsID, character.PantsID, character.ArmorID,
+		character.HelmetID, character.RingID, character.ChainID,
+		character.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update character: %v", err)
+	}
+
+	// MySQL reports 0 affected rows when nothing changed, so check existence separately
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		var exists bool
+		if err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM characters WHERE id = ?)`, character.ID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to update character: %v", err)
+		}
+		if !exists {
+			return fmt.Errorf("character not found")
+		}
+	}
+
+	return nil
+}
+
+// statColumns whitelists the stat names UpgradeCharacterStat may interpolate into SQL
+var statColumns = map[string]string{
+	"strength":     "strength",
+	"agility":      "agility",
+	"vitality":     "vitality",
+	"intelligence": "intelligence",
+}
+
+func (s *MySQLStorage) UpgradeCharacterStat(characterID int, stat string, amount, cost int) (*models.WalletTransaction, error) {
+	column, ok := statColumns[stat]
+	if !ok {
+		return nil, fmt.Errorf("unknown stat %q", stat)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Relative updates leave concurrent changes to the rest of the row intact
+	result, err := tx.Exec(`UPDATE characters SET `+column+` = `+column+` + ?,
+		channel_points_spent = channel_points_spent + ? WHERE id = ?`, amount, cost, characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upgrade stat: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, ErrNotFound
+	}
+
+	debit := &models.WalletTransaction{
+		CharacterID:   characterID,
+		Type:          models.TransactionDebit,
+		Amount:        cost,
+		Reason:        models.WalletReasonStatUpgrade,
+		ReferenceType: models.ReferenceStatUpgrade,
+		ReferenceID:   stat,
+	}
+	if err := applyWalletTransaction(tx, debit); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit stat upgrade: %v", err)
+	}
+
+	return debit, nil
+}
+
+func (s *MySQLStorage) GetAllCharacters() ([]models.Character, error) {
+	query := `SELECT ` + characterColumns + ` FROM characters ORDER BY level DESC, experience DESC, id ASC`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get characters: %v", err)
+	}
+	defer rows.Close()
+
+	var characters []models.Character
+	for rows.Next() {
+		character, err := scanCharacter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan character: %v", err)
+		}
+		characters = append(characters, *character)
+	}
+
+	return characters, rows.Err()
+}
+
+// Item operations
+
+func (s *MySQLStorage) CreateItem(item *models.Item) error {
+	query := `
+		INSERT INTO items (name, type, rarity, strength_bonus, agility_bonus, vitality_bonus,
+			intelligence_bonus, special_effect, value, is_special)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := s.db.Exec(query,
+		item.Name, item.Type, item.Rarity,
+		item.StrengthBonus, item.AgilityBonus, item.VitalityBonus, item.IntelligenceBonus,
+		item.SpecialEffect, item.Value, item.IsSpecial,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create item: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get item ID: %v", err)
+	}
+
+	created, err := s.GetItemByID(int(id))
+	if err != nil {
+		return err
+	}
+	*item = *created
+	return nil
+}
+
+func (s *MySQLStorage) GetItemByID(id int) (*models.Item, error) {
+	query := `SELECT ` + itemColumns + ` FROM items WHERE id = ?`
+
+	item, err := scanItem(s.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get item: %v", err)
+	}
+
+	return item, nil
+}
+
+func (s *MySQLStorage) GetItemsByType(itemType models.ItemType, limit, offset int) ([]models.Item, error) {
+	query := `SELECT ` + itemColumns + ` FROM items WHERE type = ?
+		ORDER BY rarity DESC, value DESC, id ASC LIMIT ? OFFSET ?`
+
+	rows, err := s.db.Query(query, itemType, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get items: %v", err)
+	}
+
+	return scanItems(rows)
+}
+
+func (s *MySQLStorage) GetRandomItems(count int, isSpecial bool) ([]models.Item, error) {
+	query := `SELECT ` + itemColumns + ` FROM items WHERE is_special = ? ORDER BY RAND() LIMIT ?`
+
+	rows, err := s.db.Query(query, isSpecial, count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get random items: %v", err)
+	}
+
+	return scanItems(rows)
+}
+
+func (s *MySQLStorage) CharacterOwnsItem(characterID, itemID int) (bool, error) {
+	query := `SELECT COUNT(*) FROM character_items WHERE character_id = ? AND item_id = ?`
+
+	var count int
+	if err := s.db.QueryRow(query, characterID, itemID).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check item ownership: %v", err)
+	}
+
+	return count > 0, nil
+}
+
+func (s *MySQLStorage) AddItemToCharacter(characterID, itemID, quantity int) error {
+	return addItemToCharacter(s.db, characterID, itemID, quantity)
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func addItemToCharacter(db execer, characterID, itemID, quantity int) error {
+	query := `
+		INSERT INTO character_items (character_id, item_id, quantity) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE quantity = quantity + VALUES(quantity)`
+
+	if _, err := db.Exec(query, characterID, itemID, quantity); err != nil {
+		return fmt.Errorf("failed to add item to character: %v", err)
+	}
+
+	return nil
+}
+
+func (s *MySQLStorage) GetCharacterItems(characterID int) ([]models.CharacterItem, error) {
+	query := `
+		SELECT ci.id, ci.character_id, ci.item_id, ci.quantity, ci.acquired_at,
+			i.id, i.name, i.type, i.rarity, i.strength_bonus, i.agility_bonus,
+			i.vitality_bonus, i.intelligence_bonus, i.special_effect, i.value, i.is_special, i.created_at
+		FROM character_items ci
+		JOIN items i ON ci.item_id = i.id
+		WHERE ci.character_id = ?
+		ORDER BY i.rarity DESC, i.value DESC, i.id ASC`
+
+	rows, err := s.db.Query(query, characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get character items: %v", err)
+	}
+	defer rows.Close()
+
+	var characterItems []models.CharacterItem
+	for rows.Next() {
+		var ci models.CharacterItem
+		var item models.Item
+
+		err := rows.Scan(
+			&ci.ID, &ci.CharacterID, &ci.ItemID, &ci.Quantity, &ci.AcquiredAt,
+			&item.ID, &item.Name, &item.Type, &item.Rarity,
+			&item.StrengthBonus, &item.AgilityBonus, &item.VitalityBonus, &item.IntelligenceBonus,
+			&item.SpecialEffect, &item.Value, &item.IsSpecial, &item.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan character item: %v", err)
+		}
+
+		ci.Item = &item
+		characterItems = append(characterItems, ci)
+	}
+
+	return characterItems, rows.Err()
+}
+
+// Combat operations
+
+const combatLogColumns = `id, attacker_id, defender_id, winner_id, attacker_power, defender_power,
+	combat_log, transcript, seed, attacker_snapshot, defender_snapshot, created_at`
+
+func scanCombatLog(row rowScanner) (*models.CombatLog, error) {
+	log := &models.CombatLog{}
+	var transcript, attackerSnapshot, defenderSnapshot []byte
+	var seed sql.NullInt64
+	err := row.Scan(
+		&log.ID, &log.AttackerID, &log.DefenderID, &log.WinnerID,
+		&log.AttackerPower, &log.DefenderPower, &log.CombatLogText,
+		&transcript, &seed, &attackerSnapshot, &defenderSnapshot, &log.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if seed.Valid {
+		log.Seed = &seed.Int64
+	}
+	if err := decodeOptionalJSON(transcript, &log.Transcript); err != nil {
+		return nil, fmt.Errorf("failed to decode combat transcript: %v", err)
+	}
+	if err := decodeOptionalJSON(attackerSnapshot, &log.AttackerSnapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode attacker snapshot: %v", err)
+	}
+	if err := decodeOptionalJSON(defenderSnapshot, &log.DefenderSnapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode defender snapshot: %v", err)
+	}
+
+	return log, nil
+}
+
+// encodeOptionalJSON encodes value for a nullable JSON column, storing NULL for nil pointers
+func encodeOptionalJSON[T any](value *T) ([]byte, error) {
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
+
+// decodeOptionalJSON decodes a nullable JSON column, leaving target nil for NULL
+func decodeOptionalJSON[T any](data []byte, target **T) error {
+	if len(data) == 0 {
+		return nil
+	}
+	*target = new(T)
+	return json.Unmarshal(data, *target)
+}
+
+func (s *MySQLStorage) AddCombatLog(log *models.CombatLog) error {
+	query := `
+		INSERT INTO combat_logs (attacker_id, defender_id, winner_id, attacker_power,
+			defender_power, combat_log, transcript, seed, attacker_snapshot, defender_snapshot)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	transcript, err := encodeOptionalJSON(log.Transcript)
+	if err != nil {
+		return fmt.Errorf("failed to encode combat transcript: %v", err)
+	}
+	attackerSnapshot, err := encodeOptionalJSON(log.AttackerSnapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode attacker snapshot: %v", err)
+	}
+	defenderSnapshot, err := encodeOptionalJSON(log.DefenderSnapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode defender snapshot: %v", err)
+	}
+
+	result, err := s.db.Exec(query,
+		log.AttackerID, log.DefenderID, log.WinnerID,
+		log.AttackerPower, log.DefenderPower, log.CombatLogText,
+		transcript, log.Seed, attackerSnapshot, defenderSnapshot,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to log combat: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get combat log ID: %v", err)
+	}
+
+	log.ID = int(id)
+	log.CreatedAt = time.Now()
+	return nil
+}
+
+func (s *MySQLStorage) GetCombatLogByID(id int) (*models.CombatLog, error) {
+	query := `SELECT ` + combatLogColumns + ` FROM combat_logs WHERE id = ?`
+
+	log, err := scanCombatLog(s.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get combat log: %v", err)
+	}
+
+	return log, nil
+}
+
+func (s *MySQLStorage) GetCombatHistory(limit int) ([]models.CombatLog, error) {
+	query := `SELECT ` + combatLogColumns + ` FROM combat_logs ORDER BY created_at DESC, id DESC LIMIT ?`
+
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get combat history: %v", err)
+	}
+	defer rows.Close()
+
+	var combatLogs []models.CombatLog
+	for rows.Next() {
+		log, err := scanCombatLog(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan combat log: %v", err)
+		}
+		combatLogs = append(combatLogs, *log)
+	}
+
+	return combatLogs, rows.Err()
+}
+
+func (s *MySQLStorage) CountCombatWins(characterID int) (int, error) {
+	var wins int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM combat_logs WHERE winner_id = ?`, characterID).Scan(&wins); err != nil {
+		return 0, fmt.Errorf("failed to count combat wins: %v", err)
+	}
+	return wins, nil
+}
+
+// Event operations
+
+const eventColumns = `id, type, title, description, data, is_triggered, created_at, expires_at`
+
+func scanEvent(row rowScanner) (*models.Event, error) {
+	event := &models.Event{}
+	err := row.Scan(
+		&event.ID, &event.Type, &event.Title, &event.Description,
+		&event.Data, &event.IsTriggered, &event.CreatedAt, &event.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func (s *MySQLStorage) CreateEvent(event *models.Event) error {
+	query := `
+		INSERT INTO events (type, title, description, data, is_triggered, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+
+	result, err := s.db.Exec(query, event.Type, event.Title, event.Description, event.Data, event.IsTriggered, event.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create event: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get event ID: %v", err)
+	}
+
+	event.ID = int(id)
+	event.CreatedAt = time.Now()
+	return nil
+}
+
+func (s *MySQLStorage) GetEventByID(id int) (*models.Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events WHERE id = ?`
+
+	event, err := scanEvent(s.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get event: %v", err)
+	}
+
+	return event, nil
+}
+
+func (s *MySQLStorage) GetLatestEvents(limit int) ([]models.Event, error) {
+	query := `SELECT ` + eventColumns + ` FROM events ORDER BY created_at DESC, id DESC LIMIT ?`
+
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %v", err)
+	}
+	defer rows.Close()
+
+	var events []models.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %v", err)
+		}
+		events = append(events, *event)
+	}
+
+	return events, rows.Err()
+}
+
+func (s *MySQLStorage) MarkEventTriggered(id int) error {
+	result, err := s.db.Exec(`UPDATE events SET is_triggered = true WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark event as triggered: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		// Already-triggered events report 0 affected rows too
+		event, err := s.GetEventByID(id)
+		if err != nil {
+			return err
+		}
+		if event == nil {
+			return fmt.Errorf("event not found")
+		}
+	}
+
+	return nil
+}
+
+// Merchant operations
+
+const merchantEventColumns = `id, event_type, available_items, start_time, end_time, is_active`
+
+func scanMerchantEvent(row rowScanner) (*models.MerchantEvent, error) {
+	event := &models.MerchantEvent{}
+	var availableItems []byte
+	err := row.Scan(
+		&event.ID, &event.EventType, &availableItems,
+		&event.StartTime, &event.EndTime, &event.IsActive,
+	)
+	if err != nil {
+		return nil, err
+	}
+	event.AvailableItems = availableItems
+	return event, nil
+}
+
+const merchantEventItemColumns = `id, merchant_event_id, item_id, price_channel_points, stock, purchased`
+
+func scanMerchantEventItem(row rowScanner) (*models.MerchantEventItem, error) {
+	offer := &models.MerchantEventItem{}
+	err := row.Scan(
+		&offer.ID, &offer.MerchantEventID, &offer.ItemID,
+		&offer.PriceChannelPoints, &offer.Stock, &offer.Purchased,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+func (s *MySQLStorage) CreateMerchantEvent(eventType string, startTime, endTime time.Time, offers []models.MerchantEventItem) (*models.MerchantEvent, error) {
+	itemIDs := make([]int, 0, len(offers))
+	for _, offer := range offers {
+		itemIDs = append(itemIDs, offer.ItemID)
+	}
+	availableItems, err := json.Marshal(itemIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode available items: %v", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// End any existing active merchant events
+	if _, err := tx.Exec("UPDATE merchant_events SET is_active = false WHERE is_active = true"); err != nil {
+		return nil, fmt.Errorf("failed to deactivate existing events: %v", err)
+	}
+
+	query := `
+		INSERT INTO merchant_events (event_type, available_items, start_time, end_time, is_active)
+		VALUES (?, ?, ?, ?, true)`
+
+	result, err := tx.Exec(query, eventType, string(availableItems), startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create merchant event: %v", err)
+	}
+
+	eventID, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merchant event ID: %v", err)
+	}
+
+	itemQuery := `
+		INSERT INTO merchant_event_items (merchant_event_id, item_id, price_channel_points, stock, purchased)
+		VALUES (?, ?, ?, ?, 0)`
+
+	for _, offer := range offers {
+		if _, err := tx.Exec(itemQuery, eventID, offer.ItemID, offer.PriceChannelPoints, offer.Stock); err != nil {
+			return nil, fmt.Errorf("failed to add item to merchant event: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit merchant event: %v", err)
+	}
+
+	return s.GetMerchantEventByID(int(eventID))
+}
+
+func (s *MySQLStorage) GetCurrentMerchantEvent() (*models.MerchantEvent, error) {
+	query := `SELECT ` + merchantEventColumns + ` FROM merchant_events
+		WHERE is_active = true AND (end_time IS NULL OR end_time > NOW())
+		ORDER BY start_time DESC, id DESC
+		LIMIT 1`
+
+	event, err := scanMerchantEvent(s.db.QueryRow(query))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get current merchant event: %v", err)
+	}
+
+	return event, nil
+}
+
+func (s *MySQLStorage) GetMerchantEventByID(id int) (*models.MerchantEvent, error) {
+	query := `SELECT ` + merchantEventColumns + ` FROM merchant_events WHERE id = ?`
+
+	event, err := scanMerchantEvent(s.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get merchant event: %v", err)
+	}
+
+	return event, nil
+}
+
+func (s *MySQLStorage) GetMerchantEventItem(id int) (*models.MerchantEventItem, error) {
+	query := `SELECT ` + merchantEventItemColumns + ` FROM merchant_event_items WHERE id = ?`
+
+	offer, err := scanMerchantEventItem(s.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get merchant event item: %v", err)
+	}
+
+	return offer, nil
+}
+
+func (s *MySQLStorage) GetMerchantEventItems(merchantEventID int) ([]models.MerchantEventItem, error) {
+	query := `SELECT ` + merchantEventItemColumns + ` FROM merchant_event_items
+		WHERE merchant_event_id = ? ORDER BY id ASC`
+
+	rows, err := s.db.Query(query, merchantEventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merchant event items: %v", err)
+	}
+	defer rows.Close()
+
+	var offers []models.MerchantEventItem
+	for rows.Next() {
+		offer, err := scanMerchantEventItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan merchant event item: %v", err)
+		}
+		offers = append(offers, *offer)
+	}
+
+	return offers, rows.Err()
+}
+
+func (s *MySQLStorage) PurchaseMerchantItem(characterID, merchantEventID, merchantEventItemID int) (*models.MerchantPurchase, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the offer and its event so concurrent buyers queue up behind us
+	var offer models.MerchantEventItem
+	var isActive, notExpired bool
+	err = tx.QueryRow(`
+		SELECT mei.id, mei.merchant_event_id, mei.item_id, mei.price_channel_points, mei.stock, mei.purchased,
+			me.is_active, (me.end_time IS NULL OR me.end_time > NOW())
+		FROM merchant_event_items mei
+		JOIN merchant_events me ON me.id = mei.merchant_event_id
+		WHERE mei.id = ?
+		FOR UPDATE`, merchantEventItemID).Scan(
+		&offer.ID, &offer.MerchantEventID, &offer.ItemID, &offer.PriceChannelPoints, &offer.Stock, &offer.Purchased,
+		&isActive, &notExpired,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("merchant event item: %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get merchant event item: %v", err)
+	}
+
+	if merchantEventID != 0 && offer.MerchantEventID != merchantEventID {
+		return nil, ErrOfferMismatch
+	}
+	if !isActive || !notExpired {
+		return nil, ErrMerchantInactive
+	}
+
+	var spent int
+	err = tx.QueryRow(`SELECT channel_points_spent FROM characters WHERE id = ? FOR UPDATE`, characterID).Scan(&spent)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("character not found")
+		}
+		return nil, fmt.Errorf("failed to lock character: %v", err)
+	}
+
+	// Conditional decrement: only succeeds while stock remains
+	result, err := tx.Exec(`UPDATE merchant_event_items SET purchased = purchased + 1 WHERE id = ? AND purchased < stock`, offer.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update purchase count: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, ErrOutOfStock
+	}
+
+	debit := &models.WalletTransaction{
+		CharacterID:   characterID,
+		Type:          models.TransactionDebit,
+		Amount:        offer.PriceChannelPoints,
+		Reason:        models.WalletReasonMerchantPurchase,
+		ReferenceType: models.ReferenceMerchantEventItem,
+		ReferenceID:   strconv.Itoa(offer.ID),
+	}
+	if err := applyWalletTransaction(tx, debit); err != nil {
+		return nil, err
+	}
+
+	if err := addItemToCharacter(tx, characterID, offer.ItemID, 1); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE characters SET channel_points_spent = ? WHERE id = ?`, spent+offer.PriceChannelPoints, characterID); err != nil {
+		return nil, fmt.Errorf("failed to update character: %v", err)
+	}
+
+	item, err := scanItem(tx.QueryRow(`SELECT `+itemColumns+` FROM items WHERE id = ?`, offer.ItemID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load purchased item: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit purchase: %v", err)
+	}
+
+	return &models.MerchantPurchase{
+		CharacterID:         characterID,
+		MerchantEventID:     offer.MerchantEventID,
+		MerchantEventItemID: offer.ID,
+		ItemID:              offer.ItemID,
+		Price:               offer.PriceChannelPoints,
+		RemainingStock:      offer.Stock - offer.Purchased - 1,
+		Transaction:         debit,
+		Item:                item,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Achievement operations
+
+func (s *MySQLStorage) UnlockAchievement(achievement *models.CharacterAchievement) (bool, error) {
+	unlockedAt := achievement.UnlockedAt
+	if unlockedAt.IsZero() {
+		unlockedAt = time.Now()
+	}
+
+	result, err := s.db.Exec(`
+		INSERT INTO character_achievements (character_id, achievement_key, tier, unlocked_at)
+		VALUES (?, ?, ?, ?)`,
+		achievement.CharacterID, achievement.AchievementKey, achievement.Tier, unlockedAt)
+	if err != nil {
+		if isDuplicateKey(err) {
+			return false, nil
+		}
+		if isForeignKeyViolation(err) {
+			return false, ErrNotFound
+		}
+		return false, fmt.Errorf("failed to unlock achievement: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return false, fmt.Errorf("failed to get achievement ID: %v", err)
+	}
+
+	achievement.ID = int(id)
+	achievement.UnlockedAt = unlockedAt
+	return true, nil
+}
+
+func (s *MySQLStorage) GetCharacterAchievements(characterID int) ([]models.CharacterAchievement, error) {
+	query := `
+		SELECT id, character_id, achievement_key, tier, unlocked_at
+		FROM character_achievements
+		WHERE character_id = ?
+		ORDER BY unlocked_at, id`
+
+	rows, err := s.db.Query(query, characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievements: %v", err)
+	}
+	defer rows.Close()
+
+	achievements := []models.CharacterAchievement{}
+	for rows.Next() {
+		var achievement models.CharacterAchievement
+		err := rows.Scan(&achievement.ID, &achievement.CharacterID, &achievement.AchievementKey,
+			&achievement.Tier, &achievement.UnlockedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan achievement: %v", err)
+		}
+		achievements = append(achievements, achievement)
+	}
+
+	return achievements, rows.Err()
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Duel operations
+
+const duelColumns = `id, challenger_id, defender_id, wager, status, combat_log_id, winner_id,
+	created_at, expires_at, responded_at`
+
+func scanDuelChallenge(row rowScanner) (*models.DuelChallenge, error) {
+	challenge := &models.DuelChallenge{}
+	err := row.Scan(
+		&challenge.ID, &challenge.ChallengerID, &challenge.DefenderID, &challenge.Wager,
+		&challenge.Status, &challenge.CombatLogID, &challenge.WinnerID,
+		&challenge.CreatedAt, &challenge.ExpiresAt, &challenge.RespondedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+func (s *MySQLStorage) queryDuelChallenges(query string, args ...any) ([]models.DuelChallenge, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duel challenges: %v", err)
+	}
+	defer rows.Close()
+
+	challenges := []models.DuelChallenge{}
+	for rows.Next() {
+		challenge, err := scanDuelChallenge(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan duel challenge: %v", err)
+		}
+		challenges = append(challenges, *challenge)
+	}
+
+	return challenges, rows.Err()
+}
+
+func (s *MySQLStorage) CreateDuelChallenge(challenge *models.DuelChallenge) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Lock both characters in ID order so concurrent challenges between them queue up
+	// behind the pending check below
+	first, second := challenge.ChallengerID, challenge.DefenderID
+	if first > second {
+		first, second = second, first
+	}
+	rows, err := tx.Query(`SELECT id FROM characters WHERE id IN (?, ?) ORDER BY id FOR UPDATE`, first, second)
+	if err != nil {
+		return fmt.Errorf("failed to lock characters: %v", err)
+	}
+	rows.Close()
+
+	var pending bool
+	err = tx.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM duel_challenges
+			WHERE status = ? AND expires_at > NOW()
+				AND ((challenger_id = ? AND defender_id = ?) OR (challenger_id = ? AND defender_id = ?))
+		)`, models.DuelStatusPending, challenge.ChallengerID, challenge.DefenderID,
+		challenge.DefenderID, challenge.ChallengerID).Scan(&pending)
+	if err != nil {
+		return fmt.Errorf("failed to check pending duel challenges: %v", err)
+	}
+	if pending {
+		return ErrDuelChallengePending
+	}
+
+	query := `
+		INSERT INTO duel_challenges (challenger_id, defender_id, wager, status, expires_at)
+		VALUES (?, ?, ?, ?, ?)`
+
+	result, err := tx.Exec(query, challenge.ChallengerID, challenge.DefenderID, challenge.Wager,
+		models.DuelStatusPending, challenge.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create duel challenge: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get duel challenge ID: %v", err)
+	}
+
+	if challenge.Wager > 0 {
+		escrow := &models.WalletTransaction{
+			CharacterID:   challenge.ChallengerID,
+			Type:          models.TransactionDebit,
+			Amount:        challenge.Wager,
+			Reason:        models.WalletReasonDuelWager,
+			ReferenceType: models.ReferenceDuelChallenge,
+			ReferenceID:   strconv.FormatInt(id, 10),
+		}
+		if err := applyWalletTransaction(tx, escrow); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit duel challenge: %v", err)
+	}
+
+	challenge.ID = int(id)
+	challenge.Status = models.DuelStatusPending
+	challenge.CreatedAt = time.Now()
+	challenge.CombatLogID, challenge.WinnerID, challenge.RespondedAt = nil, nil, nil
+	return nil
+}
+
+func (s *MySQLStorage) GetDuelChallengeByID(id int) (*models.DuelChallenge, error) {
+	query := `SELECT ` + duelColumns + ` FROM duel_challenges WHERE id = ?`
+
+	challenge, err := scanDuelChallenge(s.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get duel challenge: %v", err)
+	}
+
+	return challenge, nil
+}
+
+func (s *MySQLStorage) GetPendingDuelChallenges(characterID int) ([]models.DuelChallenge, error) {
+	query := `
+		SELECT ` + duelColumns + ` FROM duel_challenges
+		WHERE status = ? AND (challenger_id = ? OR defender_id = ?)
+		ORDER BY id`
+
+	return s.queryDuelChallenges(query, models.DuelStatusPending, characterID, characterID)
+}
+
+func (s *MySQLStorage) GetExpiredDuelChallenges(now time.Time) ([]models.DuelChallenge, error) {
+	query := `
+		SELECT ` + duelColumns + ` FROM duel_challenges
+		WHERE status = ? AND expires_at <= ?
+		ORDER BY id`
+
+	return s.queryDuelChallenges(query, models.DuelStatusPending, now)
+}
+
+func (s *MySQLStorage) TransitionDuelChallenge(id int, from, to models.DuelStatus) (bool, error) {
+	query := `
+		UPDATE duel_challenges
+		SET status = ?, responded_at = IF(?, CURRENT_TIMESTAMP, responded_at)
+		WHERE id = ? AND status = ?`
+
+	result, err := s.db.Exec(query, to, from == models.DuelStatusPending, id, from)
+	if err != nil {
+		return false, fmt.Errorf("failed to update duel challenge: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update duel challenge: %v", err)
+	}
+	if affected == 1 {
+		return true, nil
+	}
+
+	// Distinguish a lost race from a missing challenge
+	var exists bool
+	if err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM duel_challenges WHERE id = ?)`, id).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check duel challenge: %v", err)
+	}
+	if !exists {
+		return false, ErrNotFound
+	}
+	return false, nil
+}
+
+func (s *MySQLStorage) SetDuelChallengeResult(id, combatLogID, winnerID int) error {
+	result, err := s.db.Exec(`UPDATE duel_challenges SET combat_log_id = ?, winner_id = ? WHERE id = ?`,
+		combatLogID, winnerID, id)
+	if err != nil {
+		return fmt.Errorf("failed to record duel result: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
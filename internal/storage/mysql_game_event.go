@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Game event operations
+
+func (s *MySQLStorage) CreateGameEvent(event *models.GameEvent) error {
+	query := `
+		INSERT INTO game_events (event_type, character_id, event_data, obs_triggered)
+		VALUES (?, ?, ?, ?)`
+
+	result, err := s.db.Exec(query, event.EventType, event.CharacterID, []byte(event.EventData), event.OBSTriggered)
+	if err != nil {
+		return fmt.Errorf("failed to create game event: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get game event ID: %v", err)
+	}
+
+	event.ID = int(id)
+	event.CreatedAt = time.Now()
+	return nil
+}
+
+func (s *MySQLStorage) GetLatestGameEvents(limit int) ([]models.GameEvent, error) {
+	return s.queryGameEvents(`
+		SELECT id, event_type, character_id, event_data, obs_triggered, created_at
+		FROM game_events
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?`, limit)
+}
+
+func (s *MySQLStorage) GetGameEventsAfter(afterID int, filter models.GameEventFilter, limit int) ([]models.GameEvent, error) {
+	conditions := []string{"id > ?"}
+	args := []interface{}{afterID}
+	if len(filter.Types) > 0 {
+		conditions = append(conditions, "event_type IN (?"+strings.Repeat(", ?", len(filter.Types)-1)+")")
+		for _, eventType := range filter.Types {
+			args = append(args, eventType)
+		}
+	}
+	if filter.CharacterID != nil {
+		conditions = append(conditions, "character_id = ?")
+		args = append(args, *filter.CharacterID)
+	}
+
+	return s.queryGameEvents(`
+		SELECT id, event_type, character_id, event_data, obs_triggered, created_at
+		FROM game_events
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY id
+		LIMIT ?`, append(args, limit)...)
+}
+
+func (s *MySQLStorage) queryGameEvents(query string, args ...interface{}) ([]models.GameEvent, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game events: %v", err)
+	}
+	defer rows.Close()
+
+	events := []models.GameEvent{}
+	for rows.Next() {
+		var event models.GameEvent
+		var data []byte
+		err := rows.Scan(&event.ID, &event.EventType, &event.CharacterID, &data, &event.OBSTriggered, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan game event: %v", err)
+		}
+		event.EventData = data
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (s *MySQLStorage) MarkGameEventTriggered(id int) error {
+	result, err := s.db.Exec(`UPDATE game_events SET obs_triggered = TRUE WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark game event as triggered: %v", err)
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		// Already-triggered events report 0 affected rows too
+		var exists bool
+		if err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM game_events WHERE id = ?)`, id).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to mark game event as triggered: %v", err)
+		}
+		if !exists {
+			return ErrNotFound
+		}
+	}
+
+	return nil
+}
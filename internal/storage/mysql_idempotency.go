@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Idempotency operations
+
+func (s *MySQLStorage) ReserveIdempotencyKey(record *models.IdempotencyRecord, staleBefore time.Time) (*models.IdempotencyRecord, error) {
+	_, err := s.db.Exec(`INSERT INTO idempotency_keys (scope, idempotency_key, request_hash) VALUES (?, ?, ?)`,
+		record.Scope, record.Key, record.RequestHash)
+	if err == nil {
+		record.CreatedAt = time.Now()
+		return nil, nil
+	}
+	if !isDuplicateKey(err) {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %v", err)
+	}
+
+	// Take over an abandoned reservation left behind by a crashed request
+	result, err := s.db.Exec(`
+		UPDATE idempotency_keys SET request_hash = ?, created_at = CURRENT_TIMESTAMP
+		WHERE scope = ? AND idempotency_key = ? AND completed = false AND created_at < ?`,
+		record.RequestHash, record.Scope, record.Key, staleBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reclaim idempotency key: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 1 {
+		record.CreatedAt = time.Now()
+		return nil, nil
+	}
+
+	existing := &models.IdempotencyRecord{}
+	err = s.db.QueryRow(`
+		SELECT scope, idempotency_key, request_hash, status_code, response_body, completed, created_at, completed_at
+		FROM idempotency_keys WHERE scope = ? AND idempotency_key = ?`, record.Scope, record.Key).Scan(
+		&existing.Scope, &existing.Key, &existing.RequestHash, &existing.StatusCode, &existing.ResponseBody,
+		&existing.Completed, &existing.CreatedAt, &existing.CompletedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// Released between our insert and select; let the client retry
+			return nil, fmt.Errorf("idempotency key was released concurrently, retry the request")
+		}
+		return nil, fmt.Errorf("failed to load idempotency key: %v", err)
+	}
+
+	return existing, nil
+}
+
+func (s *MySQLStorage) CompleteIdempotencyKey(scope, key string, statusCode int, responseBody []byte) error {
+	result, err := s.db.Exec(`
+		UPDATE idempotency_keys SET status_code = ?, response_body = ?, completed = true, completed_at = CURRENT_TIMESTAMP
+		WHERE scope = ? AND idempotency_key = ?`, statusCode, responseBody, scope, key)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *MySQLStorage) ReleaseIdempotencyKey(scope, key string) error {
+	_, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE scope = ? AND idempotency_key = ? AND completed = false`, scope, key)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %v", err)
+	}
+
+	return nil
+}
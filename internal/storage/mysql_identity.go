@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Identity operations
+
+func (s *MySQLStorage) GetCharacterByTwitchUserID(twitchUserID string) (*models.Character, error) {
+	query := `SELECT ` + characterColumns + ` FROM characters WHERE twitch_user_id = ?`
+
+	character, err := scanCharacter(s.db.QueryRow(query, twitchUserID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get character: %v", err)
+	}
+
+	return character, nil
+}
+
+func (s *MySQLStorage) LinkTwitchUserID(characterID int, twitchUserID string) error {
+	var current sql.NullString
+	err := s.db.QueryRow(`SELECT twitch_user_id FROM characters WHERE id = ?`, characterID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to link Twitch account: %v", err)
+	}
+	if current.Valid {
+		if current.String == twitchUserID {
+			return nil
+		}
+		return ErrTwitchUserIDMismatch
+	}
+
+	// The IS NULL condition keeps a concurrent link from being overwritten
+	result, err := s.db.Exec(`UPDATE characters SET twitch_user_id = ? WHERE id = ? AND twitch_user_id IS NULL`,
+		twitchUserID, characterID)
+	if err != nil {
+		if isDuplicateKey(err) {
+			return fmt.Errorf("character with twitch user id '%s' already exists: %w", twitchUserID, ErrDuplicateTwitchUserID)
+		}
+		return fmt.Errorf("failed to link Twitch account: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrTwitchUserIDMismatch
+	}
+
+	return nil
+}
+
+func (s *MySQLStorage) RenameCharacter(characterID int, username string) (*models.UsernameChange, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	change := &models.UsernameChange{CharacterID: characterID, NewUsername: username}
+	err = tx.QueryRow(`SELECT username FROM characters WHERE id = ? FOR UPDATE`, characterID).Scan(&change.OldUsername)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename character: %v", err)
+	}
+	if change.OldUsername == username {
+		return nil, nil
+	}
+
+	if _, err := tx.Exec(`UPDATE characters SET username = ? WHERE id = ?`, username, characterID); err != nil {
+		if isDuplicateKey(err) {
+			return nil, fmt.Errorf("character with username '%s' already exists: %w", username, ErrDuplicateUsername)
+		}
+		return nil, fmt.Errorf("failed to rename character: %v", err)
+	}
+
+	result, err := tx.Exec(`INSERT INTO username_history (character_id, old_username, new_username) VALUES (?, ?, ?)`,
+		characterID, change.OldUsername, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record username change: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get username change ID: %v", err)
+	}
+	change.ID = int(id)
+	change.ChangedAt = time.Now()
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rename: %v", err)
+	}
+
+	return change, nil
+}
+
+func (s *MySQLStorage) GetUsernameHistory(characterID int) ([]models.UsernameChange, error) {
+	rows, err := s.db.Query(`
+		SELECT id, character_id, old_username, new_username, changed_at
+		FROM username_history WHERE character_id = ? ORDER BY id DESC`, characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get username history: %v", err)
+	}
+	defer rows.Close()
+
+	changes := []models.UsernameChange{}
+	for rows.Next() {
+		var change models.UsernameChange
+		if err := rows.Scan(&change.ID, &change.CharacterID, &change.OldUsername, &change.NewUsername, &change.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan username change: %v", err)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+func (s *MySQLStorage) GetCharacterByFormerUsername(username string) (*models.Character, error) {
+	query := `SELECT ` + characterColumns + ` FROM characters WHERE id = (
+		SELECT character_id FROM username_history WHERE old_username = ? ORDER BY id DESC LIMIT 1)`
+
+	character, err := scanCharacter(s.db.QueryRow(query, username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get character: %v", err)
+	}
+
+	return character, nil
+}
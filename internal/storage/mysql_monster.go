@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Monster operations
+
+const monsterColumns = `id, name, description, level_min, level_max, strength, agility, vitality,
+	intelligence, experience_reward, created_at`
+
+func scanMonster(row rowScanner) (*models.Monster, error) {
+	monster := &models.Monster{Loot: []models.MonsterLoot{}}
+	var description sql.NullString
+	err := row.Scan(
+		&monster.ID, &monster.Name, &description, &monster.LevelMin, &monster.LevelMax,
+		&monster.Strength, &monster.Agility, &monster.Vitality, &monster.Intelligence,
+		&monster.ExperienceReward, &monster.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	monster.Description = description.String
+	return monster, nil
+}
+
+func (s *MySQLStorage) CreateMonster(monster *models.Monster) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO monsters (name, description, level_min, level_max, strength, agility, vitality,
+			intelligence, experience_reward)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		monster.Name, monster.Description, monster.LevelMin, monster.LevelMax, monster.Strength,
+		monster.Agility, monster.Vitality, monster.Intelligence, monster.ExperienceReward)
+	if err != nil {
+		return fmt.Errorf("failed to create monster: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get monster ID: %v", err)
+	}
+
+	for i := range monster.Loot {
+		loot := &monster.Loot[i]
+		loot.MonsterID = int(id)
+		_, err := tx.Exec(`INSERT INTO monster_loot (monster_id, item_id, drop_chance, quantity) VALUES (?, ?, ?, ?)`,
+			loot.MonsterID, loot.ItemID, loot.DropChance, loot.Quantity)
+		if err != nil {
+			return fmt.Errorf("failed to add monster loot: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit monster: %v", err)
+	}
+
+	monster.ID = int(id)
+	monster.CreatedAt = time.Now()
+	return nil
+}
+
+func (s *MySQLStorage) GetMonsterByID(id int) (*models.Monster, error) {
+	query := `SELECT ` + monsterColumns + ` FROM monsters WHERE id = ?`
+
+	monster, err := scanMonster(s.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get monster: %v", err)
+	}
+
+	if err := s.loadMonsterLoot(map[int]*models.Monster{monster.ID: monster}); err != nil {
+		return nil, err
+	}
+
+	return monster, nil
+}
+
+func (s *MySQLStorage) GetMonsters() ([]models.Monster, error) {
+	query := `SELECT ` + monsterColumns + ` FROM monsters ORDER BY level_min, id`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monsters: %v", err)
+	}
+	defer rows.Close()
+
+	monsters := []models.Monster{}
+	for rows.Next() {
+		monster, err := scanMonster(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan monster: %v", err)
+		}
+		monsters = append(monsters, *monster)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*models.Monster, len(monsters))
+	for i := range monsters {
+		byID[monsters[i].ID] = &monsters[i]
+	}
+	if err := s.loadMonsterLoot(byID); err != nil {
+		return nil, err
+	}
+
+	return monsters, nil
+}
+
+// loadMonsterLoot fills in the loot tables of the given monsters
+func (s *MySQLStorage) loadMonsterLoot(monsters map[int]*models.Monster) error {
+	if len(monsters) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(monsters))
+	args := make([]any, 0, len(monsters))
+	for id := range monsters {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+
+	query := `SELECT monster_id, item_id, drop_chance, quantity FROM monster_loot
+		WHERE monster_id IN (` + strings.Join(placeholders, ", ") + `)
+		ORDER BY monster_id, item_id`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get monster loot: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var loot models.MonsterLoot
+		if err := rows.Scan(&loot.MonsterID, &loot.ItemID, &loot.DropChance, &loot.Quantity); err != nil {
+			return fmt.Errorf("failed to scan monster loot: %v", err)
+		}
+		if monster, ok := monsters[loot.MonsterID]; ok {
+			monster.Loot = append(monster.Loot, loot)
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *MySQLStorage) AddHuntLog(log *models.HuntLog) error {
+	drops, err := json.Marshal(append([]int{}, log.DropItemIDs...))
+	if err != nil {
+		return fmt.Errorf("failed to encode hunt drops: %v", err)
+	}
+	transcript, err := encodeOptionalJSON(log.Transcript)
+	if err != nil {
+		return fmt.Errorf("failed to encode hunt transcript: %v", err)
+	}
+
+	result, err := s.db.Exec(`
+		INSERT INTO hunt_logs (character_id, monster_id, monster_level, won, experience_gained,
+			drop_item_ids, seed, transcript)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		log.CharacterID, log.MonsterID, log.MonsterLevel, log.Won, log.ExperienceGained,
+		drops, log.Seed, transcript)
+	if err != nil {
+		return fmt.Errorf("failed to log hunt: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get hunt log ID: %v", err)
+	}
+
+	log.ID = int(id)
+	log.CreatedAt = time.Now()
+	return nil
+}
+
+func (s *MySQLStorage) GetHuntHistory(characterID, limit int) ([]models.HuntLog, error) {
+	query := `
+		SELECT id, character_id, monster_id, monster_level, won, experience_gained,
+			drop_item_ids, seed, transcript, created_at
+		FROM hunt_logs
+		WHERE character_id = ?
+		ORDER BY id DESC
+		LIMIT ?`
+
+	rows, err := s.db.Query(query, characterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hunt history: %v", err)
+	}
+	defer rows.Close()
+
+	hunts := []models.HuntLog{}
+	for rows.Next() {
+		var log models.HuntLog
+		var drops, transcript []byte
+		err := rows.Scan(&log.ID, &log.CharacterID, &log.MonsterID, &log.MonsterLevel, &log.Won,
+			&log.ExperienceGained, &drops, &log.Seed, &transcript, &log.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan hunt log: %v", err)
+		}
+
+		log.DropItemIDs = []int{}
+		if len(drops) > 0 {
+			if err := json.Unmarshal(drops, &log.DropItemIDs); err != nil {
+				return nil, fmt.Errorf("failed to decode hunt drops: %v", err)
+			}
+		}
+		if err := decodeOptionalJSON(transcript, &log.Transcript); err != nil {
+			return nil, fmt.Errorf("failed to decode hunt transcript: %v", err)
+		}
+		hunts = append(hunts, log)
+	}
+
+	return hunts, rows.Err()
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Overlay queue operations
+
+func (s *MySQLStorage) ClaimOverlayEvents(consumer, token string, limit int, now, visibleUntil time.Time) ([]models.OverlayDelivery, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// New consumers start after the newest event instead of replaying the whole history
+	_, err = tx.Exec(`
+		INSERT IGNORE INTO overlay_consumers (name, cursor_event_id, created_at)
+		SELECT ?, COALESCE(MAX(id), 0), ? FROM game_events`, consumer, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create overlay consumer: %v", err)
+	}
+
+	// Locking the consumer serializes the claims of all its overlay instances
+	var cursor int
+	if err := tx.QueryRow(`SELECT cursor_event_id FROM overlay_consumers WHERE name = ? FOR UPDATE`, consumer).Scan(&cursor); err != nil {
+		return nil, fmt.Errorf("failed to lock overlay consumer: %v", err)
+	}
+
+	// Redeliver expired claims first, oldest event first
+	expired, err := queryInts(tx, `
+		SELECT event_id FROM overlay_deliveries
+		WHERE consumer = ? AND claimed_until <= ?
+		ORDER BY event_id
+		LIMIT ?`, consumer, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired overlay claims: %v", err)
+	}
+	if len(expired) > 0 {
+		args := []interface{}{token, visibleUntil, consumer}
+		for _, eventID := range expired {
+			args = append(args, eventID)
+		}
+		_, err = tx.Exec(`
+			UPDATE overlay_deliveries SET claim_token = ?, claimed_until = ?, attempts = attempts + 1
+			WHERE consumer = ? AND event_id IN (?`+strings.Repeat(", ?", len(expired)-1)+`)`, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reclaim overlay events: %v", err)
+		}
+	}
+
+	if remaining := limit - len(expired); remaining > 0 {
+		fresh, err := queryInts(tx, `SELECT id FROM game_events WHERE id > ? ORDER BY id LIMIT ?`, cursor, remaining)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get new game events: %v", err)
+		}
+		if len(fresh) > 0 {
+			values := make([]string, len(fresh))
+			args := []interface{}{}
+			for i, eventID := range fresh {
+				values[i] = "(?, ?, ?, ?, 1)"
+				args = append(args, consumer, eventID, token, visibleUntil)
+			}
+			_, err = tx.Exec(`
+				INSERT INTO overlay_deliveries (consumer, event_id, claim_token, claimed_until, attempts)
+				VALUES `+strings.Join(values, ", "), args...)
+			if err != nil {
+				return nil, fmt.Errorf("failed to claim overlay events: %v", err)
+			}
+			_, err = tx.Exec(`UPDATE overlay_consumers SET cursor_event_id = ? WHERE name = ?`, fresh[len(fresh)-1], consumer)
+			if err != nil {
+				return nil, fmt.Errorf("failed to advance overlay cursor: %v", err)
+			}
+		}
+	}
+
+	rows, err := tx.Query(`
+		SELECT d.consumer, d.event_id, d.claim_token, d.claimed_until, d.attempts,
+			e.id, e.event_type, e.character_id, e.event_data, e.obs_triggered, e.created_at
+		FROM overlay_deliveries d
+		JOIN game_events e ON e.id = d.event_id
+		WHERE d.consumer = ? AND d.claim_token = ?
+		ORDER BY d.event_id`, consumer, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get claimed overlay events: %v", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.OverlayDelivery{}
+	for rows.Next() {
+		var delivery models.OverlayDelivery
+		event := &models.GameEvent{}
+		var data []byte
+		err := rows.Scan(&delivery.Consumer, &delivery.EventID, &delivery.ClaimToken, &delivery.ClaimedUntil, &delivery.Attempts,
+			&event.ID, &event.EventType, &event.CharacterID, &data, &event.OBSTriggered, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan overlay delivery: %v", err)
+		}
+		event.EventData = data
+		delivery.Event = event
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get claimed overlay events: %v", err)
+	}
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit overlay claim: %v", err)
+	}
+
+	return deliveries, nil
+}
+
+func (s *MySQLStorage) AckOverlayEvents(consumer, token string, eventIDs []int) ([]int, error) {
+	acknowledged := []int{}
+	if len(eventIDs) == 0 {
+		return acknowledged, nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	args := []interface{}{consumer, token}
+	for _, eventID := range eventIDs {
+		args = append(args, eventID)
+	}
+	in := "(?" + strings.Repeat(", ?", len(eventIDs)-1) + ")"
+
+	acknowledged, err = queryInts(tx, `
+		SELECT event_id FROM overlay_deliveries
+		WHERE consumer = ? AND claim_token = ? AND event_id IN `+in+`
+		ORDER BY event_id
+		FOR UPDATE`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overlay claims: %v", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM overlay_deliveries WHERE consumer = ? AND claim_token = ? AND event_id IN `+in, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acknowledge overlay events: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit overlay acknowledgement: %v", err)
+	}
+
+	return acknowledged, nil
+}
+
+func (s *MySQLStorage) GetOverlayConsumer(consumer string) (*models.OverlayConsumer, error) {
+	status := &models.OverlayConsumer{}
+	err := s.db.QueryRow(`
+		SELECT c.name, c.cursor_event_id, c.created_at,
+			(SELECT COUNT(*) FROM overlay_deliveries d WHERE d.consumer = c.name)
+		FROM overlay_consumers c
+		WHERE c.name = ?`, consumer).Scan(&status.Name, &status.Cursor, &status.CreatedAt, &status.InFlight)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overlay consumer: %v", err)
+	}
+
+	return status, nil
+}
+
+// queryInts runs a query selecting a single integer column
+func queryInts(tx *sql.Tx, query string, args ...interface{}) ([]int, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []int{}
+	for rows.Next() {
+		var value int
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
@@ -0,0 +1,301 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Quest operations
+
+const questColumns = `id, name, description, quest_type, requirements, rewards, channel_point_cost, is_active`
+
+func scanQuest(row rowScanner) (*models.Quest, error) {
+	quest := &models.Quest{}
+	var description, questType sql.NullString
+	var requirements, rewards []byte
+	var cost sql.NullInt64
+	var isActive sql.NullBool
+	err := row.Scan(&quest.ID, &quest.Name, &description, &questType, &requirements, &rewards, &cost, &isActive)
+	if err != nil {
+		return nil, err
+	}
+
+	quest.Description = description.String
+	quest.QuestType = questType.String
+	quest.Requirements = json.RawMessage(requirements)
+	quest.Rewards = json.RawMessage(rewards)
+	quest.ChannelPointCost = int(cost.Int64)
+	quest.IsActive = isActive.Valid && isActive.Bool
+	return quest, nil
+}
+
+const characterQuestColumns = `id, character_id, quest_id, rotation_id, progress, completed, completed_at,
+		expired, started_at, expires_at`
+
+func scanCharacterQuest(row rowScanner) (*models.CharacterQuest, error) {
+	characterQuest := &models.CharacterQuest{}
+	var progress []byte
+	var completed sql.NullBool
+	err := row.Scan(
+		&characterQuest.ID, &characterQuest.CharacterID, &characterQuest.QuestID, &characterQuest.RotationID, &progress,
+		&completed, &characterQuest.CompletedAt, &characterQuest.Expired, &characterQuest.StartedAt, &characterQuest.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	characterQuest.Progress = json.RawMessage(progress)
+	characterQuest.Completed = completed.Valid && completed.Bool
+	return characterQuest, nil
+}
+
+// nullableJSON stores empty JSON documents as NULL
+func nullableJSON(data json.RawMessage) any {
+	if len(data) == 0 {
+		return nil
+	}
+	return []byte(data)
+}
+
+func (s *MySQLStorage) CreateQuest(quest *models.Quest) error {
+	query := `
+		INSERT INTO quests (name, description, quest_type, requirements, rewards, channel_point_cost, is_active)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := s.db.Exec(query, quest.Name, quest.Description, quest.QuestType,
+		nullableJSON(quest.Requirements), nullableJSON(quest.Rewards), quest.ChannelPointCost, quest.IsActive)
+	if err != nil {
+		return fmt.Errorf("failed to create quest: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get quest ID: %v", err)
+	}
+
+	quest.ID = int(id)
+	return nil
+}
+
+func (s *MySQLStorage) GetQuestByID(id int) (*models.Quest, error) {
+	quest, err := scanQuest(s.db.QueryRow(`SELECT `+questColumns+` FROM quests WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get quest: %v", err)
+	}
+
+	return quest, nil
+}
+
+func (s *MySQLStorage) GetActiveQuests() ([]models.Quest, error) {
+	rows, err := s.db.Query(`SELECT ` + questColumns + ` FROM quests WHERE is_active = TRUE ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quests: %v", err)
+	}
+	defer rows.Close()
+
+	quests := []models.Quest{}
+	for rows.Next() {
+		quest, err := scanQuest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan quest: %v", err)
+		}
+		quests = append(quests, *quest)
+	}
+
+	return quests, rows.Err()
+}
+
+func (s *MySQLStorage) CreateCharacterQuest(characterQuest *models.CharacterQuest) error {
+	query := `
+		INSERT INTO character_quests (character_id, quest_id, rotation_id, progress, expires_at)
+		VALUES (?, ?, ?, ?, ?)`
+
+	result, err := s.db.Exec(query, characterQuest.CharacterID, characterQuest.QuestID, characterQuest.RotationID,
+		nullableJSON(characterQuest.Progress), characterQuest.ExpiresAt)
+	if err != nil {
+		if isDuplicateKey(err) {
+			return ErrQuestAlreadyAccepted
+		}
+		if isForeignKeyViolation(err) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to start quest: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get character quest ID: %v", err)
+	}
+
+	characterQuest.ID = int(id)
+	characterQuest.StartedAt = time.Now()
+	characterQuest.Completed, characterQuest.CompletedAt = false, nil
+	characterQuest.Expired = false
+	return nil
+}
+
+func (s *MySQLStorage) GetCharacterQuests(characterID int) ([]models.CharacterQuest, error) {
+	query := `
+		SELECT cq.id, cq.character_id, cq.quest_id, cq.rotation_id, cq.progress, cq.completed, cq.completed_at,
+			cq.expired, cq.started_at, cq.expires_at,
+			q.id, q.name, q.description, q.quest_type, q.requirements, q.rewards, q.channel_point_cost, q.is_active
+		FROM character_quests cq
+		JOIN quests q ON q.id = cq.quest_id
+		WHERE cq.character_id = ?
+		ORDER BY cq.id`
+
+	rows, err := s.db.Query(query, characterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get character quests: %v", err)
+	}
+	defer rows.Close()
+
+	result := []models.CharacterQuest{}
+	for rows.Next() {
+		characterQuest, quest := &models.CharacterQuest{}, &models.Quest{}
+		var progress, requirements, rewards []byte
+		var completed, isActive sql.NullBool
+		var description, questType sql.NullString
+		var cost sql.NullInt64
+		err := rows.Scan(
+			&characterQuest.ID, &characterQuest.CharacterID, &characterQuest.QuestID, &characterQuest.RotationID, &progress,
+			&completed, &characterQuest.CompletedAt, &characterQuest.Expired, &characterQuest.StartedAt, &characterQuest.ExpiresAt,
+			&quest.ID, &quest.Name, &description, &questType, &requirements, &rewards, &cost, &isActive,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan character quest: %v", err)
+		}
+
+		characterQuest.Progress = json.RawMessage(progress)
+		characterQuest.Completed = completed.Valid && completed.Bool
+		quest.Description = description.String
+		quest.QuestType = questType.String
+		quest.Requirements = json.RawMessage(requirements)
+		quest.Rewards = json.RawMessage(rewards)
+		quest.ChannelPointCost = int(cost.Int64)
+		quest.IsActive = isActive.Valid && isActive.Bool
+		characterQuest.Quest = quest
+
+		result = append(result, *characterQuest)
+	}
+
+	return result, rows.Err()
+}
+
+func (s *MySQLStorage) UpdateCharacterQuestProgress(id int, update QuestProgressFunc) (*models.CharacterQuest, bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	characterQuest, err := scanCharacterQuest(tx.QueryRow(
+		`SELECT `+characterQuestColumns+` FROM character_quests WHERE id = ? FOR UPDATE`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, false, ErrNotFound
+		}
+		return nil, false, fmt.Errorf("failed to lock character quest: %v", err)
+	}
+	if characterQuest.Completed || characterQuest.Expired || characterQuest.IsPastExpiry(time.Now()) {
+		return characterQuest, false, nil
+	}
+
+	progress, completed, err := update(characterQuest.Progress)
+	if err != nil {
+		return nil, false, err
+	}
+
+	characterQuest.Progress = progress
+	if completed {
+		now := time.Now()
+		characterQuest.Completed = true
+		characterQuest.CompletedAt = &now
+	}
+
+	_, err = tx.Exec(`UPDATE character_quests SET progress = ?, completed = ?, completed_at = ? WHERE id = ?`,
+		nullableJSON(characterQuest.Progress), characterQuest.Completed, characterQuest.CompletedAt, id)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to update quest progress: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit quest progress: %v", err)
+	}
+
+	return characterQuest, completed, nil
+}
+
+func (s *MySQLStorage) ExpireCharacterQuests(now time.Time) (int, error) {
+	result, err := s.db.Exec(`
+		UPDATE character_quests SET expired = TRUE
+		WHERE completed = FALSE AND expired = FALSE AND expires_at <= ?`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire character quests: %v", err)
+	}
+
+	expired, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count expired character quests: %v", err)
+	}
+	return int(expired), nil
+}
+
+// Quest rotation operations
+
+func (s *MySQLStorage) CreateQuestRotation(rotation *models.QuestRotation) error {
+	questIDs, err := json.Marshal(rotation.QuestIDs)
+	if err != nil {
+		return fmt.Errorf("failed to encode rotation quests: %v", err)
+	}
+
+	result, err := s.db.Exec(`INSERT INTO quest_rotations (quest_type, period_start, period_end, quest_ids) VALUES (?, ?, ?, ?)`,
+		rotation.QuestType, rotation.PeriodStart, rotation.PeriodEnd, questIDs)
+	if err != nil {
+		if isDuplicateKey(err) {
+			return ErrQuestRotationExists
+		}
+		return fmt.Errorf("failed to create quest rotation: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get quest rotation ID: %v", err)
+	}
+
+	rotation.ID = int(id)
+	rotation.CreatedAt = time.Now()
+	return nil
+}
+
+func (s *MySQLStorage) GetQuestRotation(questType string, at time.Time) (*models.QuestRotation, error) {
+	query := `
+		SELECT id, quest_type, period_start, period_end, quest_ids, created_at
+		FROM quest_rotations
+		WHERE quest_type = ? AND period_start <= ? AND period_end > ?
+		ORDER BY period_start DESC
+		LIMIT 1`
+
+	rotation := &models.QuestRotation{}
+	var questIDs []byte
+	err := s.db.QueryRow(query, questType, at, at).Scan(
+		&rotation.ID, &rotation.QuestType, &rotation.PeriodStart, &rotation.PeriodEnd, &questIDs, &rotation.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get quest rotation: %v", err)
+	}
+
+	if err := json.Unmarshal(questIDs, &rotation.QuestIDs); err != nil {
+		return nil, fmt.Errorf("failed to decode rotation quests: %v", err)
+	}
+	return rotation, nil
+}
@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Raid operations
+
+const raidColumns = `id, name, level, strength, agility, vitality, intelligence, max_hp, current_hp,
+	reward_points, reward_experience, attack_cooldown_seconds, status, last_hit_by,
+	spawned_at, expires_at, ended_at`
+
+func scanRaidBoss(row rowScanner) (*models.RaidBoss, error) {
+	boss := &models.RaidBoss{}
+	err := row.Scan(
+		&boss.ID, &boss.Name, &boss.Level, &boss.Strength, &boss.Agility, &boss.Vitality,
+		&boss.Intelligence, &boss.MaxHP, &boss.CurrentHP, &boss.RewardPoints, &boss.RewardExperience,
+		&boss.AttackCooldownSeconds, &boss.Status, &boss.LastHitBy,
+		&boss.SpawnedAt, &boss.ExpiresAt, &boss.EndedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return boss, nil
+}
+
+const raidContributionColumns = `rc.raid_id, rc.character_id, rc.damage, rc.attacks, rc.last_attack_at,
+	rc.reward_points, rc.reward_experience, c.username`
+
+func scanRaidContribution(row rowScanner) (*models.RaidContribution, error) {
+	contribution := &models.RaidContribution{}
+	err := row.Scan(
+		&contribution.RaidID, &contribution.CharacterID, &contribution.Damage, &contribution.Attacks,
+		&contribution.LastAttackAt, &contribution.RewardPoints, &contribution.RewardExperience,
+		&contribution.Username,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return contribution, nil
+}
+
+func (s *MySQLStorage) CreateRaidBoss(boss *models.RaidBoss) error {
+	query := `
+		INSERT INTO raid_bosses (name, level, strength, agility, vitality, intelligence, max_hp, current_hp,
+			reward_points, reward_experience, attack_cooldown_seconds, status, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := s.db.Exec(query, boss.Name, boss.Level, boss.Strength, boss.Agility, boss.Vitality,
+		boss.Intelligence, boss.MaxHP, boss.MaxHP, boss.RewardPoints, boss.RewardExperience,
+		boss.AttackCooldownSeconds, models.RaidStatusActive, boss.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create raid boss: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get raid boss ID: %v", err)
+	}
+
+	boss.ID = int(id)
+	boss.Status = models.RaidStatusActive
+	boss.CurrentHP = boss.MaxHP
+	boss.SpawnedAt = time.Now()
+	boss.LastHitBy, boss.EndedAt = nil, nil
+	return nil
+}
+
+func (s *MySQLStorage) GetRaidBossByID(id int) (*models.RaidBoss, error) {
+	return s.queryRaidBoss(`SELECT `+raidColumns+` FROM raid_bosses WHERE id = ?`, id)
+}
+
+func (s *MySQLStorage) GetActiveRaidBoss() (*models.RaidBoss, error) {
+	return s.queryRaidBoss(`SELECT `+raidColumns+` FROM raid_bosses WHERE status = ? ORDER BY id DESC LIMIT 1`,
+		models.RaidStatusActive)
+}
+
+func (s *MySQLStorage) queryRaidBoss(query string, args ...any) (*models.RaidBoss, error) {
+	boss, err := scanRaidBoss(s.db.QueryRow(query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get raid boss: %v", err)
+	}
+
+	return boss, nil
+}
+
+func (s *MySQLStorage) GetRaidBosses(limit int) ([]models.RaidBoss, error) {
+	rows, err := s.db.Query(`SELECT `+raidColumns+` FROM raid_bosses ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get raid bosses: %v", err)
+	}
+	defer rows.Close()
+
+	bosses := []models.RaidBoss{}
+	for rows.Next() {
+		boss, err := scanRaidBoss(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan raid boss: %v", err)
+		}
+		bosses = append(bosses, *boss)
+	}
+
+	return bosses, rows.Err()
+}
+
+func (s *MySQLStorage) ApplyRaidDamage(raidID, characterID, damage int, now time.Time) (*models.RaidHit, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the boss first serializes every attack on it
+	boss, err := scanRaidBoss(tx.QueryRow(`SELECT `+raidColumns+` FROM raid_bosses WHERE id = ? FOR UPDATE`, raidID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to lock raid boss: %v", err)
+	}
+
+	var characterExists bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM characters WHERE id = ?)`, characterID).Scan(&characterExists); err != nil {
+		return nil, fmt.Errorf("failed to check character: %v", err)
+	}
+	if !characterExists {
+		return nil, ErrNotFound
+	}
+	if boss.IsOver(now) {
+		return nil, ErrRaidNotActive
+	}
+
+	var lastAttackAt time.Time
+	err = tx.QueryRow(`SELECT last_attack_at FROM raid_contributions WHERE raid_id = ? AND character_id = ?`,
+		raidID, characterID).Scan(&lastAttackAt)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return nil, fmt.Errorf("failed to get raid contribution: %v", err)
+	case now.Before(lastAttackAt.Add(boss.AttackCooldown())):
+		return nil, ErrRaidCooldown
+	}
+
+	hit := &models.RaidHit{
+		Damage:   min(max(damage, 0), boss.CurrentHP),
+		HPBefore: boss.CurrentHP,
+	}
+
+	boss.CurrentHP -= hit.Damage
+	boss.LastHitBy = &characterID
+	if boss.CurrentHP == 0 {
+		boss.Status = models.RaidStatusDefeated
+		boss.EndedAt = &now
+		hit.Defeated = true
+	}
+
+	_, err = tx.Exec(`UPDATE raid_bosses SET current_hp = ?, status = ?, last_hit_by = ?, ended_at = ? WHERE id = ?`,
+		boss.CurrentHP, boss.Status, characterID, boss.EndedAt, raidID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update raid boss: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO raid_contributions (raid_id, character_id, damage, attacks, last_attack_at)
+		VALUES (?, ?, ?, 1, ?)
+		ON DUPLICATE KEY UPDATE damage = damage + VALUES(damage), attacks = attacks + 1,
+			last_attack_at = VALUES(last_attack_at)`,
+		raidID, characterID, hit.Damage, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record raid contribution: %v", err)
+	}
+
+	hit.Contribution, err = scanRaidContribution(tx.QueryRow(`
+		SELECT `+raidContributionColumns+`
+		FROM raid_contributions rc
+		JOIN characters c ON c.id = rc.character_id
+		WHERE rc.raid_id = ? AND rc.character_id = ?`, raidID, characterID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get raid contribution: %v", err)
+	}
+	hit.Contribution.Username = ""
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit raid attack: %v", err)
+	}
+
+	hit.Raid = boss
+	return hit, nil
+}
+
+func (s *MySQLStorage) GetRaidContributions(raidID int) ([]models.RaidContribution, error) {
+	query := `
+		SELECT ` + raidContributionColumns + `
+		FROM raid_contributions rc
+		JOIN characters c ON c.id = rc.character_id
+		WHERE rc.raid_id = ?
+		ORDER BY rc.damage DESC, rc.character_id`
+
+	rows, err := s.db.Query(query, raidID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get raid contributions: %v", err)
+	}
+	defer rows.Close()
+
+	contributions := []models.RaidContribution{}
+	for rows.Next() {
+		contribution, err := scanRaidContribution(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan raid contribution: %v", err)
+		}
+		contributions = append(contributions, *contribution)
+	}
+
+	return contributions, rows.Err()
+}
+
+func (s *MySQLStorage) TransitionRaidBoss(id int, from, to models.RaidStatus) (bool, error) {
+	query := `
+		UPDATE raid_bosses
+		SET status = ?, ended_at = IF(?, CURRENT_TIMESTAMP, ended_at)
+		WHERE id = ? AND status = ?`
+
+	result, err := s.db.Exec(query, to, from == models.RaidStatusActive, id, from)
+	if err != nil {
+		return false, fmt.Errorf("failed to update raid boss: %v", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update raid boss: %v", err)
+	}
+	if affected == 1 {
+		return true, nil
+	}
+
+	// Distinguish a lost race from a missing boss
+	var exists bool
+	if err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM raid_bosses WHERE id = ?)`, id).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check raid boss: %v", err)
+	}
+	if !exists {
+		return false, ErrNotFound
+	}
+	return false, nil
+}
+
+func (s *MySQLStorage) SetRaidReward(raidID, characterID, points, experience int) error {
+	result, err := s.db.Exec(`
+		UPDATE raid_contributions SET reward_points = ?, reward_experience = ?
+		WHERE raid_id = ? AND character_id = ?`,
+		points, experience, raidID, characterID)
+	if err != nil {
+		return fmt.Errorf("failed to record raid reward: %v", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		// Also zero when the same reward is recorded twice
+		var exists bool
+		err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM raid_contributions WHERE raid_id = ? AND character_id = ?)`,
+			raidID, characterID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check raid contribution: %v", err)
+		}
+		if !exists {
+			return ErrNotFound
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Rating operations
+
+func (s *MySQLStorage) ApplyFightRating(combatLogID, winnerID, loserID int, update RatingFunc) ([]models.RatingHistoryEntry, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// Lock both rows in ID order so concurrent fights cannot deadlock
+	ratings := map[int][2]int{}
+	rows, err := tx.Query(`SELECT id, rating, rated_fights FROM characters WHERE id IN (?, ?) ORDER BY id FOR UPDATE`, winnerID, loserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock ratings: %v", err)
+	}
+	for rows.Next() {
+		var id, rating, fights int
+		if err := rows.Scan(&id, &rating, &fights); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan rating: %v", err)
+		}
+		ratings[id] = [2]int{rating, fights}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to lock ratings: %v", err)
+	}
+
+	winner, winnerExists := ratings[winnerID]
+	loser, loserExists := ratings[loserID]
+	if !winnerExists || !loserExists {
+		return nil, ErrNotFound
+	}
+
+	newWinnerRating, newLoserRating := update(winner[0], winner[1], loser[0], loser[1])
+
+	entries := []models.RatingHistoryEntry{
+		{CharacterID: winnerID, OpponentID: loserID, Won: true, RatingBefore: winner[0], RatingAfter: newWinnerRating},
+		{CharacterID: loserID, OpponentID: winnerID, Won: false, RatingBefore: loser[0], RatingAfter: newLoserRating},
+	}
+	for i := range entries {
+		entry := &entries[i]
+		entry.CombatLogID = combatLogID
+
+		_, err := tx.Exec(`UPDATE characters SET rating = ?, rated_fights = rated_fights + 1 WHERE id = ?`,
+			entry.RatingAfter, entry.CharacterID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update rating: %v", err)
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO rating_history (character_id, opponent_id, combat_log_id, won, rating_before, rating_after)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			entry.CharacterID, entry.OpponentID, entry.CombatLogID, entry.Won, entry.RatingBefore, entry.RatingAfter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record rating history: %v", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rating history ID: %v", err)
+		}
+		entry.ID = int(id)
+		entry.CreatedAt = time.Now()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rating update: %v", err)
+	}
+
+	return entries, nil
+}
+
+func (s *MySQLStorage) GetRatingHistory(characterID, limit, offset int) ([]models.RatingHistoryEntry, error) {
+	query := `
+		SELECT id, character_id, opponent_id, combat_log_id, won, rating_before, rating_after, created_at
+		FROM rating_history
+		WHERE character_id = ?
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := s.db.Query(query, characterID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating history: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []models.RatingHistoryEntry{}
+	for rows.Next() {
+		var entry models.RatingHistoryEntry
+		err := rows.Scan(&entry.ID, &entry.CharacterID, &entry.OpponentID, &entry.CombatLogID,
+			&entry.Won, &entry.RatingBefore, &entry.RatingAfter, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rating history: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (s *MySQLStorage) GetLadder(limit, offset int) ([]models.Character, error) {
+	query := `
+		SELECT ` + characterColumns + ` FROM characters
+		WHERE rated_fights > 0
+		ORDER BY rating DESC, rated_fights DESC, id ASC
+		LIMIT ? OFFSET ?`
+
+	rows, err := s.db.Query(query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ladder: %v", err)
+	}
+	defer rows.Close()
+
+	characters := []models.Character{}
+	for rows.Next() {
+		character, err := scanCharacter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan character: %v", err)
+		}
+		characters = append(characters, *character)
+	}
+
+	return characters, rows.Err()
+}
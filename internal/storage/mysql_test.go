@@ -0,0 +1,44 @@
+package storage_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"twitch-rpg/internal/database/migrate"
+	"twitch-rpg/internal/database/migrations"
+	"twitch-rpg/internal/storage"
+	"twitch-rpg/internal/storage/storagetest"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// TestMySQLStorageConformance runs the suite against the database named by
+// TEST_MYSQL_DSN, e.g. "user:pass@tcp(localhost:3306)/twitch_rpg_test?parseTime=true",
+// after bringing its schema up to date. Rows are left behind, so use a scratch database.
+func TestMySQLStorageConformance(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Fatalf("ping database: %v", err)
+	}
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(0); err != nil {
+		t.Fatalf("apply migrations: %v", err)
+	}
+
+	storagetest.RunConformance(t, func(t *testing.T) storage.Store {
+		return storage.NewMySQLStorage(db)
+	})
+}
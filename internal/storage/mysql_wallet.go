@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// Wallet operations
+
+func (s *MySQLStorage) GetWallet(characterID int) (*models.Wallet, error) {
+	query := `SELECT character_id, balance, lifetime_earned, lifetime_spent, updated_at
+		FROM wallets WHERE character_id = ?`
+
+	wallet := &models.Wallet{}
+	err := s.db.QueryRow(query, characterID).Scan(
+		&wallet.CharacterID, &wallet.Balance, &wallet.LifetimeEarned, &wallet.LifetimeSpent, &wallet.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &models.Wallet{CharacterID: characterID}, nil
+		}
+		return nil, fmt.Errorf("failed to get wallet: %v", err)
+	}
+
+	return wallet, nil
+}
+
+func (s *MySQLStorage) ApplyWalletTransaction(transaction *models.WalletTransaction) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if err := applyWalletTransaction(tx, transaction); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit wallet transaction: %v", err)
+	}
+	return nil
+}
+
+// applyWalletTransaction locks the wallet row, checks for overdraft, updates the
+// balance and appends the ledger entry inside the caller's transaction
+func applyWalletTransaction(tx *sql.Tx, transaction *models.WalletTransaction) error {
+	if transaction.Amount <= 0 {
+		return fmt.Errorf("wallet transaction amount must be positive")
+	}
+	if transaction.Type != models.TransactionCredit && transaction.Type != models.TransactionDebit {
+		return fmt.Errorf("invalid wallet transaction type %q", transaction.Type)
+	}
+
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM characters WHERE id = ?)`, transaction.CharacterID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check character: %v", err)
+	}
+	if !exists {
+		return fmt.Errorf("character not found")
+	}
+
+	// Make sure the wallet row exists so it can be locked
+	if _, err := tx.Exec(`INSERT IGNORE INTO wallets (character_id) VALUES (?)`, transaction.CharacterID); err != nil {
+		return fmt.Errorf("failed to create wallet: %v", err)
+	}
+
+	var balance int
+	if err := tx.QueryRow(`SELECT balance FROM wallets WHERE character_id = ? FOR UPDATE`, transaction.CharacterID).Scan(&balance); err != nil {
+		return fmt.Errorf("failed to lock wallet: %v", err)
+	}
+
+	balance += transaction.SignedAmount()
+	if balance < 0 {
+		return ErrInsufficientFunds
+	}
+
+	update := `UPDATE wallets SET balance = ?, lifetime_earned = lifetime_earned + ? WHERE character_id = ?`
+	if transaction.Type == models.TransactionDebit {
+		update = `UPDATE wallets SET balance = ?, lifetime_spent = lifetime_spent + ? WHERE character_id = ?`
+	}
+	if _, err := tx.Exec(update, balance, transaction.Amount, transaction.CharacterID); err != nil {
+		return fmt.Errorf("failed to update wallet: %v", err)
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO wallet_transactions (character_id, type, amount, balance_after, reason, reference_type, reference_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		transaction.CharacterID, transaction.Type, transaction.Amount, balance,
+		transaction.Reason, transaction.ReferenceType, transaction.ReferenceID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record wallet transaction: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get wallet transaction ID: %v", err)
+	}
+
+	transaction.ID = int(id)
+	transaction.BalanceAfter = balance
+	transaction.CreatedAt = time.Now()
+	return nil
+}
+
+func (s *MySQLStorage) GetWalletTransactions(characterID, limit, offset int) ([]models.WalletTransaction, error) {
+	query := `
+		SELECT id, character_id, type, amount, balance_after, reason, reference_type, reference_id, created_at
+		FROM wallet_transactions
+		WHERE character_id = ?
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?`
+
+	rows, err := s.db.Query(query, characterID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet transactions: %v", err)
+	}
+	defer rows.Close()
+
+	var transactions []models.WalletTransaction
+	for rows.Next() {
+		var t models.WalletTransaction
+		err := rows.Scan(
+			&t.ID, &t.CharacterID, &t.Type, &t.Amount, &t.BalanceAfter,
+			&t.Reason, &t.ReferenceType, &t.ReferenceID, &t.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan wallet transaction: %v", err)
+		}
+		transactions = append(transactions, t)
+	}
+
+	return transactions, rows.Err()
+}
@@ -0,0 +1,309 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+	"twitch-rpg/internal/models"
+)
+
+// ErrNotFound is returned by mutating operations when the target row does not exist.
+// Lookups return (nil, nil) for missing rows instead, matching the service layer contract.
+var ErrNotFound = errors.New("not found")
+
+// ErrDuplicateUsername is returned when a character with the same username already exists
+var ErrDuplicateUsername = errors.New("username already taken")
+
+var (
+	// ErrDuplicateTwitchUserID is returned when a Twitch account already has a character
+	ErrDuplicateTwitchUserID = errors.New("Twitch account already has a character")
+	// ErrTwitchUserIDMismatch is returned when linking a character that already belongs to another Twitch account
+	ErrTwitchUserIDMismatch = errors.New("character belongs to another Twitch account")
+)
+
+// ErrInsufficientFunds is returned when a debit would overdraw a wallet
+var ErrInsufficientFunds = errors.New("insufficient channel points")
+
+// ErrOutOfStock is returned when a merchant offer has no units left
+var ErrOutOfStock = errors.New("item is out of stock")
+
+// ErrMerchantInactive is returned when buying from a merchant event that has ended
+var ErrMerchantInactive = errors.New("merchant event is no longer active")
+
+// ErrOfferMismatch is returned when a merchant offer does not belong to the requested merchant event
+var ErrOfferMismatch = errors.New("item does not belong to this merchant event")
+
+// ErrDuelChallengePending is returned when two characters already have an unexpired pending challenge
+var ErrDuelChallengePending = errors.New("a challenge between these characters is already pending")
+
+// ErrRaidNotActive is returned when attacking a raid boss that was defeated, escaped or is past its deadline
+var ErrRaidNotActive = errors.New("raid boss is no longer active")
+
+// ErrRaidCooldown is returned when a character attacks a raid boss again before its cooldown has passed
+var ErrRaidCooldown = errors.New("attack is on cooldown")
+
+var (
+	// ErrQuestAlreadyAccepted is returned when a character accepts a quest it already has
+	ErrQuestAlreadyAccepted = errors.New("quest already accepted")
+	// ErrQuestRotationExists is returned when a rotation for the same quest type and period was already created
+	ErrQuestRotationExists = errors.New("quest rotation already exists")
+)
+
+// Store is the persistence layer used by the services.
+// Both MySQLStorage and MemoryStorage implement it and must pass storagetest.RunConformance.
+type Store interface {
+	CharacterStore
+	IdentityStore
+	ItemStore
+	CombatStore
+	EventStore
+	MerchantStore
+	WalletStore
+	IdempotencyStore
+	DuelStore
+	RatingStore
+	MonsterStore
+	RaidStore
+	GameEventStore
+	QuestStore
+	AchievementStore
+	OverlayQueueStore
+}
+
+// CharacterStore persists characters
+type CharacterStore interface {
+	CreateCharacter(username string, twitchUserID *string) (*models.Character, error)
+	GetCharacterByID(id int) (*models.Character, error)
+	GetCharacterByUsername(username string) (*models.Character, error)
+	// UpdateCharacter saves level, experience and equipment. Stats and ChannelPointsSpent
+	// only change through UpgradeCharacterStat and PurchaseMerchantItem, and Rating and
+	// RatedFights through ApplyFightRating, so saving an older copy never reverts them.
+	UpdateCharacter(character *models.Character) error
+	GetAllCharacters() ([]models.Character, error)
+	// UpgradeCharacterStat atomically debits cost from the character's wallet, raises stat by
+	// amount and adds cost to the points spent, leaving the rest of the row alone. It returns
+	// ErrInsufficientFunds on overdraft and ErrNotFound for a missing character.
+	UpgradeCharacterStat(characterID int, stat string, amount, cost int) (*models.WalletTransaction, error)
+}
+
+// IdentityStore ties characters to Twitch accounts and remembers the usernames they went by
+type IdentityStore interface {
+	// GetCharacterByTwitchUserID returns the character of a Twitch account
+	GetCharacterByTwitchUserID(twitchUserID string) (*models.Character, error)
+	// LinkTwitchUserID sets the Twitch account of a character created without one. It returns
+	// ErrDuplicateTwitchUserID when another character has the account and ErrTwitchUserIDMismatch
+	// when the character belongs to a different one.
+	LinkTwitchUserID(characterID int, twitchUserID string) error
+	// RenameCharacter changes a character's username and records the change, atomically. It
+	// returns nil without recording anything when the username is unchanged and
+	// ErrDuplicateUsername when another character has it.
+	RenameCharacter(characterID int, username string) (*models.UsernameChange, error)
+	// GetUsernameHistory returns a character's username changes, newest first
+	GetUsernameHistory(characterID int) ([]models.UsernameChange, error)
+	// GetCharacterByFormerUsername returns the character that most recently gave up a username
+	GetCharacterByFormerUsername(username string) (*models.Character, error)
+}
+
+// ItemStore persists the item catalog and character inventories
+type ItemStore interface {
+	CreateItem(item *models.Item) error
+	GetItemByID(id int) (*models.Item, error)
+	GetItemsByType(itemType models.ItemType, limit, offset int) ([]models.Item, error)
+	GetRandomItems(count int, isSpecial bool) ([]models.Item, error)
+	CharacterOwnsItem(characterID, itemID int) (bool, error)
+	AddItemToCharacter(characterID, itemID, quantity int) error
+	GetCharacterItems(characterID int) ([]models.CharacterItem, error)
+}
+
+// CombatStore persists combat logs
+type CombatStore interface {
+	AddCombatLog(log *models.CombatLog) error
+	GetCombatLogByID(id int) (*models.CombatLog, error)
+	GetCombatHistory(limit int) ([]models.CombatLog, error)
+	// CountCombatWins returns how many logged fights the character won
+	CountCombatWins(characterID int) (int, error)
+}
+
+// EventStore persists general game events
+type EventStore interface {
+	CreateEvent(event *models.Event) error
+	GetEventByID(id int) (*models.Event, error)
+	GetLatestEvents(limit int) ([]models.Event, error)
+	MarkEventTriggered(id int) error
+}
+
+// MerchantStore persists merchant events and their item offers
+type MerchantStore interface {
+	CreateMerchantEvent(eventType string, startTime, endTime time.Time, offers []models.MerchantEventItem) (*models.MerchantEvent, error)
+	GetCurrentMerchantEvent() (*models.MerchantEvent, error)
+	GetMerchantEventByID(id int) (*models.MerchantEvent, error)
+	GetMerchantEventItem(id int) (*models.MerchantEventItem, error)
+	GetMerchantEventItems(merchantEventID int) ([]models.MerchantEventItem, error)
+	// PurchaseMerchantItem atomically checks that the event is active and the offer in stock,
+	// debits the price from the wallet, decrements stock, adds the item to the inventory and
+	// records the points spent. Nothing is changed if any step fails.
+	// A merchantEventID of 0 skips the offer-belongs-to-event check.
+	PurchaseMerchantItem(characterID, merchantEventID, merchantEventItemID int) (*models.MerchantPurchase, error)
+}
+
+// WalletStore persists character wallets and their ledger
+type WalletStore interface {
+	// GetWallet returns the wallet of a character, or an empty wallet if nothing was ever credited
+	GetWallet(characterID int) (*models.Wallet, error)
+	// ApplyWalletTransaction atomically appends a ledger entry and updates the balance.
+	// It fills in ID, BalanceAfter and CreatedAt, and returns ErrInsufficientFunds on overdraft.
+	ApplyWalletTransaction(transaction *models.WalletTransaction) error
+	// GetWalletTransactions returns a character's ledger, newest first
+	GetWalletTransactions(characterID, limit, offset int) ([]models.WalletTransaction, error)
+}
+
+// IdempotencyStore persists the results of idempotent requests
+type IdempotencyStore interface {
+	// ReserveIdempotencyKey inserts a pending record for scope and key. If a record already
+	// exists it is returned unchanged, unless it is still pending and was created before
+	// staleBefore, in which case the caller takes it over. A nil result means the caller holds the key.
+	ReserveIdempotencyKey(record *models.IdempotencyRecord, staleBefore time.Time) (*models.IdempotencyRecord, error)
+	// CompleteIdempotencyKey stores the response of a reserved key
+	CompleteIdempotencyKey(scope, key string, statusCode int, responseBody []byte) error
+	// ReleaseIdempotencyKey deletes a pending reservation so the request can be retried
+	ReleaseIdempotencyKey(scope, key string) error
+}
+
+// DuelStore persists duel challenges
+type DuelStore interface {
+	// CreateDuelChallenge stores a new pending challenge and fills in ID, Status and CreatedAt.
+	// A positive Wager is debited from the challenger's wallet in the same operation. It returns
+	// ErrDuelChallengePending when the pair has an unexpired pending challenge in either
+	// direction and ErrInsufficientFunds when the challenger cannot cover the wager; nothing is
+	// stored or debited then.
+	CreateDuelChallenge(challenge *models.DuelChallenge) error
+	GetDuelChallengeByID(id int) (*models.DuelChallenge, error)
+	// GetPendingDuelChallenges returns pending challenges involving a character on either side, oldest first
+	GetPendingDuelChallenges(characterID int) ([]models.DuelChallenge, error)
+	// GetExpiredDuelChallenges returns pending challenges whose deadline is at or before now
+	GetExpiredDuelChallenges(now time.Time) ([]models.DuelChallenge, error)
+	// TransitionDuelChallenge moves a challenge from one status to another and reports whether it
+	// was still in the from status, so concurrent accept, decline and expiry cannot both win
+	TransitionDuelChallenge(id int, from, to models.DuelStatus) (bool, error)
+	// SetDuelChallengeResult records the fight that settled an accepted challenge
+	SetDuelChallengeResult(id, combatLogID, winnerID int) error
+}
+
+// RatingFunc computes the new ratings of a fight's winner and loser from their
+// current ratings and number of rated fights
+type RatingFunc func(winnerRating, winnerFights, loserRating, loserFights int) (newWinnerRating, newLoserRating int)
+
+// RatingStore persists skill ratings and their history
+type RatingStore interface {
+	// ApplyFightRating locks both characters, applies update to their current ratings, counts the
+	// rated fight and appends a history entry for each, all atomically. Entries are returned winner first.
+	ApplyFightRating(combatLogID, winnerID, loserID int, update RatingFunc) ([]models.RatingHistoryEntry, error)
+	// GetRatingHistory returns a character's rating changes, newest first
+	GetRatingHistory(characterID, limit, offset int) ([]models.RatingHistoryEntry, error)
+	// GetLadder returns characters with at least one rated fight, highest rating first
+	GetLadder(limit, offset int) ([]models.Character, error)
+}
+
+// MonsterStore persists the bestiary and hunt history
+type MonsterStore interface {
+	// CreateMonster stores a monster together with its loot table
+	CreateMonster(monster *models.Monster) error
+	// GetMonsterByID returns a monster with its loot table
+	GetMonsterByID(id int) (*models.Monster, error)
+	// GetMonsters returns the bestiary with loot tables, ordered by level_min then ID
+	GetMonsters() ([]models.Monster, error)
+	AddHuntLog(log *models.HuntLog) error
+	// GetHuntHistory returns a character's hunts, newest first
+	GetHuntHistory(characterID, limit int) ([]models.HuntLog, error)
+}
+
+// RaidStore persists raid bosses and the damage characters deal to them
+type RaidStore interface {
+	// CreateRaidBoss stores a new active boss at full hit points and fills in ID, Status, CurrentHP and SpawnedAt
+	CreateRaidBoss(boss *models.RaidBoss) error
+	GetRaidBossByID(id int) (*models.RaidBoss, error)
+	// GetActiveRaidBoss returns the newest boss in the active status, or nil if there is none
+	GetActiveRaidBoss() (*models.RaidBoss, error)
+	// GetRaidBosses returns the most recent bosses, newest first
+	GetRaidBosses(limit int) ([]models.RaidBoss, error)
+	// ApplyRaidDamage atomically checks that the boss is active and before its deadline and that the
+	// character's cooldown has passed at now, then lowers the boss's hit points and adds the damage to
+	// the character's contribution. The hit that reaches zero marks the boss defeated.
+	// Fails with ErrRaidNotActive or ErrRaidCooldown without changing anything.
+	ApplyRaidDamage(raidID, characterID, damage int, now time.Time) (*models.RaidHit, error)
+	// GetRaidContributions returns a raid's contributions with usernames, most damage first
+	GetRaidContributions(raidID int) ([]models.RaidContribution, error)
+	// TransitionRaidBoss moves a boss from one status to another and reports whether it was
+	// still in the from status, so a late escape cannot overwrite a defeat
+	TransitionRaidBoss(id int, from, to models.RaidStatus) (bool, error)
+	// SetRaidReward records the share of a defeated boss's rewards paid to a character
+	SetRaidReward(raidID, characterID, points, experience int) error
+}
+
+// GameEventStore persists the game events shown by the OBS overlay
+type GameEventStore interface {
+	// CreateGameEvent stores an event and fills in ID and CreatedAt
+	CreateGameEvent(event *models.GameEvent) error
+	// GetLatestGameEvents returns the most recent events, newest first
+	GetLatestGameEvents(limit int) ([]models.GameEvent, error)
+	// GetGameEventsAfter returns up to limit events matching the filter whose ID is greater
+	// than afterID, oldest first, so a reader can page forward from the last event it saw
+	GetGameEventsAfter(afterID int, filter models.GameEventFilter, limit int) ([]models.GameEvent, error)
+	// MarkGameEventTriggered records that the overlay has shown an event
+	MarkGameEventTriggered(id int) error
+}
+
+// QuestProgressFunc computes a character quest's new progress from its current progress
+// and reports whether every requirement is now met. It runs while the quest is locked
+// and must not call back into the store.
+type QuestProgressFunc func(progress json.RawMessage) (newProgress json.RawMessage, completed bool, err error)
+
+// QuestStore persists the quest board and characters' quest progress
+type QuestStore interface {
+	// CreateQuest stores a quest and fills in its ID
+	CreateQuest(quest *models.Quest) error
+	GetQuestByID(id int) (*models.Quest, error)
+	// GetActiveQuests returns the quests that can currently be accepted, ordered by ID
+	GetActiveQuests() ([]models.Quest, error)
+	// CreateCharacterQuest starts a quest for a character and fills in ID and StartedAt.
+	// It fails with ErrQuestAlreadyAccepted if the character already has the quest in the same rotation.
+	CreateCharacterQuest(characterQuest *models.CharacterQuest) error
+	// GetCharacterQuests returns a character's quests with Quest populated, oldest first
+	GetCharacterQuests(characterID int) ([]models.CharacterQuest, error)
+	// UpdateCharacterQuestProgress locks an unfinished character quest, saves the progress returned
+	// by update and marks the quest completed when update says so, all atomically. It reports
+	// whether this call completed the quest; completed and expired quests are returned unchanged.
+	UpdateCharacterQuestProgress(id int, update QuestProgressFunc) (*models.CharacterQuest, bool, error)
+	// ExpireCharacterQuests marks unfinished quests whose rotation ended at or before now as expired
+	// and returns how many it marked
+	ExpireCharacterQuests(now time.Time) (int, error)
+
+	// CreateQuestRotation stores a rotation and fills in its ID and CreatedAt. It fails with
+	// ErrQuestRotationExists if the quest type already has a rotation starting at the same time.
+	CreateQuestRotation(rotation *models.QuestRotation) error
+	// GetQuestRotation returns the rotation of questType whose period contains at, or nil
+	GetQuestRotation(questType string, at time.Time) (*models.QuestRotation, error)
+}
+
+// AchievementStore persists the achievement tiers characters have unlocked
+type AchievementStore interface {
+	// UnlockAchievement records an unlocked tier and fills in its ID, and UnlockedAt when zero.
+	// It reports false without error when the character already has that tier.
+	UnlockAchievement(achievement *models.CharacterAchievement) (bool, error)
+	// GetCharacterAchievements returns a character's unlocked tiers in unlock order
+	GetCharacterAchievements(characterID int) ([]models.CharacterAchievement, error)
+}
+
+// OverlayQueueStore keeps a queue over the game events for every overlay consumer
+type OverlayQueueStore interface {
+	// ClaimOverlayEvents claims up to limit events for the consumer under token until
+	// visibleUntil, oldest first. Claims that expired before now are handed out again
+	// before events newer than the consumer's cursor. A consumer claiming for the first
+	// time is created with its cursor at the newest existing event.
+	ClaimOverlayEvents(consumer, token string, limit int, now, visibleUntil time.Time) ([]models.OverlayDelivery, error)
+	// AckOverlayEvents removes the consumer's claims that are still held under token and
+	// returns the IDs of the events it acknowledged
+	AckOverlayEvents(consumer, token string, eventIDs []int) ([]int, error)
+	// GetOverlayConsumer returns a consumer with its number of unacknowledged claims
+	GetOverlayConsumer(consumer string) (*models.OverlayConsumer, error)
+}
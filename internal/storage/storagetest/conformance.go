@@ -0,0 +1,1701 @@
+// Package storagetest contains the conformance suite every storage.Store
+// implementation must pass, so the MySQL and in-memory backends behave the same.
+//
+// Backends run it from their own tests:
+//
+//	storagetest.RunConformance(t, func(t *testing.T) storage.Store {
+//		return storage.NewMemoryStorage()
+//	})
+package storagetest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"twitch-rpg/internal/models"
+	"twitch-rpg/internal/storage"
+)
+
+// Factory returns a fresh store for a single subtest. Stores may contain seed
+// data, so the suite only asserts on rows it creates itself.
+type Factory func(t *testing.T) storage.Store
+
+// RunConformance runs the full conformance suite against the store returned by newStore
+func RunConformance(t *testing.T, newStore Factory) {
+	t.Run("Characters", func(t *testing.T) { testCharacters(t, newStore(t)) })
+	t.Run("CharacterOrdering", func(t *testing.T) { testCharacterOrdering(t, newStore(t)) })
+	t.Run("Identity", func(t *testing.T) { testIdentity(t, newStore(t)) })
+	t.Run("Items", func(t *testing.T) { testItems(t, newStore(t)) })
+	t.Run("Inventory", func(t *testing.T) { testInventory(t, newStore(t)) })
+	t.Run("CombatLogs", func(t *testing.T) { testCombatLogs(t, newStore(t)) })
+	t.Run("Events", func(t *testing.T) { testEvents(t, newStore(t)) })
+	t.Run("Merchant", func(t *testing.T) { testMerchant(t, newStore(t)) })
+	t.Run("Wallet", func(t *testing.T) { testWallet(t, newStore(t)) })
+	t.Run("StatUpgrade", func(t *testing.T) { testStatUpgrade(t, newStore(t)) })
+	t.Run("MerchantPurchase", func(t *testing.T) { testMerchantPurchase(t, newStore(t)) })
+	t.Run("ConcurrentPurchase", func(t *testing.T) { testConcurrentPurchase(t, newStore(t)) })
+	t.Run("Idempotency", func(t *testing.T) { testIdempotency(t, newStore(t)) })
+	t.Run("DuelChallenges", func(t *testing.T) { testDuelChallenges(t, newStore(t)) })
+	t.Run("Ratings", func(t *testing.T) { testRatings(t, newStore(t)) })
+	t.Run("Monsters", func(t *testing.T) { testMonsters(t, newStore(t)) })
+	t.Run("Raids", func(t *testing.T) { testRaids(t, newStore(t)) })
+	t.Run("GameEvents", func(t *testing.T) { testGameEvents(t, newStore(t)) })
+	t.Run("Quests", func(t *testing.T) { testQuests(t, newStore(t)) })
+	t.Run("QuestRotations", func(t *testing.T) { testQuestRotations(t, newStore(t)) })
+	t.Run("Achievements", func(t *testing.T) { testAchievements(t, newStore(t)) })
+	t.Run("OverlayQueue", func(t *testing.T) { testOverlayQueue(t, newStore(t)) })
+}
+
+// uniqueName returns a name that will not collide with seed data or earlier runs
+func uniqueName(prefix string) string {
+	return fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
+}
+
+// jsonEqual reports whether data and want decode to the same value, since MySQL
+// normalizes the formatting of stored JSON documents
+func jsonEqual(data json.RawMessage, want string) bool {
+	var got, expected any
+	if json.Unmarshal(data, &got) != nil || json.Unmarshal([]byte(want), &expected) != nil {
+		return false
+	}
+	return reflect.DeepEqual(got, expected)
+}
+
+// MustCreateCharacter creates a character with a unique username or fails the test
+func MustCreateCharacter(t *testing.T, store storage.Store) *models.Character {
+	t.Helper()
+
+	character, err := store.CreateCharacter(uniqueName("conformance"), nil)
+	if err != nil {
+		t.Fatalf("CreateCharacter: %v", err)
+	}
+	return character
+}
+
+// MustCreateItem creates an item of the given type and rarity or fails the test
+func MustCreateItem(t *testing.T, store storage.Store, itemType models.ItemType, rarity models.ItemRarity, value int) *models.Item {
+	t.Helper()
+
+	item := &models.Item{
+		Name:          uniqueName("item"),
+		Type:          itemType,
+		Rarity:        rarity,
+		StrengthBonus: 1,
+		Value:         value,
+	}
+	if err := store.CreateItem(item); err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+	if item.ID == 0 {
+		t.Fatalf("CreateItem did not assign an ID")
+	}
+	return item
+}
+
+func testCharacters(t *testing.T, store storage.Store) {
+	twitchID := uniqueName("twitch")
+	username := uniqueName("hero")
+
+	created, err := store.CreateCharacter(username, &twitchID)
+	if err != nil {
+		t.Fatalf("CreateCharacter: %v", err)
+	}
+	if created.ID == 0 || created.Username != username || created.Level != 1 || created.Strength != 10 {
+		t.Fatalf("unexpected new character: %+v", created)
+	}
+	if created.TwitchUserID == nil || *created.TwitchUserID != twitchID {
+		t.Fatalf("twitch user id not stored: %+v", created.TwitchUserID)
+	}
+
+	if _, err := store.CreateCharacter(username, nil); !errors.Is(err, storage.ErrDuplicateUsername) {
+		t.Fatalf("duplicate username: got %v, want ErrDuplicateUsername", err)
+	}
+
+	byName, err := store.GetCharacterByUsername(username)
+	if err != nil || byName == nil || byName.ID != created.ID {
+		t.Fatalf("GetCharacterByUsername = %+v, %v", byName, err)
+	}
+
+	missing, err := store.GetCharacterByID(-1)
+	if err != nil || missing != nil {
+		t.Fatalf("GetCharacterByID(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+	missing, err = store.GetCharacterByUsername(uniqueName("nobody"))
+	if err != nil || missing != nil {
+		t.Fatalf("GetCharacterByUsername(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+
+	boots := MustCreateItem(t, store, models.ItemTypeBoots, models.RarityCommon, 10)
+	created.Level = 3
+	created.Experience = 42
+	created.BootsID = &boots.ID
+	if err := store.UpdateCharacter(created); err != nil {
+		t.Fatalf("UpdateCharacter: %v", err)
+	}
+
+	// Mutating the caller's copy must not leak into the store
+	created.Level = 99
+
+	loaded, err := store.GetCharacterByID(created.ID)
+	if err != nil || loaded == nil {
+		t.Fatalf("GetCharacterByID = %+v, %v", loaded, err)
+	}
+	if loaded.Level != 3 || loaded.Experience != 42 {
+		t.Fatalf("update not persisted: %+v", loaded)
+	}
+
+	// Stats and points spent only change through upgrades and purchases
+	edited := *loaded
+	edited.Agility += 10
+	edited.ChannelPointsSpent = 500
+	if err := store.UpdateCharacter(&edited); err != nil {
+		t.Fatalf("UpdateCharacter: %v", err)
+	}
+	if kept, _ := store.GetCharacterByID(created.ID); kept.Agility != loaded.Agility || kept.ChannelPointsSpent != loaded.ChannelPointsSpent {
+		t.Fatalf("UpdateCharacter wrote stats or points spent: %+v", kept)
+	}
+	if loaded.BootsID == nil || *loaded.BootsID != boots.ID {
+		t.Fatalf("equipment slot not persisted: %+v", loaded.BootsID)
+	}
+
+	// Same update twice is not an error
+	if err := store.UpdateCharacter(loaded); err != nil {
+		t.Fatalf("idempotent UpdateCharacter: %v", err)
+	}
+
+	ghost := *loaded
+	ghost.ID = -1
+	if err := store.UpdateCharacter(&ghost); err == nil {
+		t.Fatalf("UpdateCharacter on missing character succeeded")
+	}
+}
+
+func testIdentity(t *testing.T, store storage.Store) {
+	twitchID := uniqueName("twitch")
+	linked, err := store.CreateCharacter(uniqueName("hero"), &twitchID)
+	if err != nil {
+		t.Fatalf("CreateCharacter: %v", err)
+	}
+	if _, err := store.CreateCharacter(uniqueName("hero"), &twitchID); !errors.Is(err, storage.ErrDuplicateTwitchUserID) {
+		t.Fatalf("duplicate twitch user id: got %v, want ErrDuplicateTwitchUserID", err)
+	}
+
+	byID, err := store.GetCharacterByTwitchUserID(twitchID)
+	if err != nil || byID == nil || byID.ID != linked.ID {
+		t.Fatalf("GetCharacterByTwitchUserID = %+v, %v", byID, err)
+	}
+	missing, err := store.GetCharacterByTwitchUserID(uniqueName("nobody"))
+	if err != nil || missing != nil {
+		t.Fatalf("GetCharacterByTwitchUserID(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+
+	// A character created without a Twitch account can be linked once
+	legacy := MustCreateCharacter(t, store)
+	if err := store.LinkTwitchUserID(legacy.ID, twitchID); !errors.Is(err, storage.ErrDuplicateTwitchUserID) {
+		t.Fatalf("link taken twitch user id: got %v, want ErrDuplicateTwitchUserID", err)
+	}
+	legacyID := uniqueName("twitch")
+	if err := store.LinkTwitchUserID(legacy.ID, legacyID); err != nil {
+		t.Fatalf("LinkTwitchUserID: %v", err)
+	}
+	if err := store.LinkTwitchUserID(legacy.ID, legacyID); err != nil {
+		t.Fatalf("repeated LinkTwitchUserID: %v", err)
+	}
+	if err := store.LinkTwitchUserID(legacy.ID, uniqueName("twitch")); !errors.Is(err, storage.ErrTwitchUserIDMismatch) {
+		t.Fatalf("relink: got %v, want ErrTwitchUserIDMismatch", err)
+	}
+	if err := store.LinkTwitchUserID(-1, uniqueName("twitch")); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("link missing character: got %v, want ErrNotFound", err)
+	}
+
+	oldName := linked.Username
+	newName := uniqueName("renamed")
+	change, err := store.RenameCharacter(linked.ID, newName)
+	if err != nil || change == nil || change.OldUsername != oldName || change.NewUsername != newName || change.ID == 0 {
+		t.Fatalf("RenameCharacter = %+v, %v", change, err)
+	}
+	if change, err := store.RenameCharacter(linked.ID, newName); err != nil || change != nil {
+		t.Fatalf("RenameCharacter(unchanged) = %+v, %v; want nil, nil", change, err)
+	}
+	if _, err := store.RenameCharacter(linked.ID, legacy.Username); !errors.Is(err, storage.ErrDuplicateUsername) {
+		t.Fatalf("rename to taken username: got %v, want ErrDuplicateUsername", err)
+	}
+	if _, err := store.RenameCharacter(-1, uniqueName("ghost")); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("rename missing character: got %v, want ErrNotFound", err)
+	}
+
+	renamed, err := store.GetCharacterByUsername(newName)
+	if err != nil || renamed == nil || renamed.ID != linked.ID {
+		t.Fatalf("GetCharacterByUsername(new name) = %+v, %v", renamed, err)
+	}
+	former, err := store.GetCharacterByFormerUsername(oldName)
+	if err != nil || former == nil || former.ID != linked.ID {
+		t.Fatalf("GetCharacterByFormerUsername = %+v, %v", former, err)
+	}
+
+	// The most recent holder of a former name wins
+	if _, err := store.RenameCharacter(legacy.ID, oldName); err != nil {
+		t.Fatalf("RenameCharacter to freed name: %v", err)
+	}
+	if _, err := store.RenameCharacter(legacy.ID, uniqueName("renamed")); err != nil {
+		t.Fatalf("RenameCharacter: %v", err)
+	}
+	former, err = store.GetCharacterByFormerUsername(oldName)
+	if err != nil || former == nil || former.ID != legacy.ID {
+		t.Fatalf("GetCharacterByFormerUsername after reuse = %+v, %v; want character %d", former, err, legacy.ID)
+	}
+
+	history, err := store.GetUsernameHistory(legacy.ID)
+	if err != nil || len(history) != 2 || history[0].OldUsername != oldName || history[1].NewUsername != oldName {
+		t.Fatalf("GetUsernameHistory = %+v, %v", history, err)
+	}
+	empty, err := store.GetUsernameHistory(MustCreateCharacter(t, store).ID)
+	if err != nil || empty == nil || len(empty) != 0 {
+		t.Fatalf("GetUsernameHistory(new character) = %+v, %v; want empty", empty, err)
+	}
+}
+
+func testCharacterOrdering(t *testing.T, store storage.Store) {
+	low := MustCreateCharacter(t, store)
+	high := MustCreateCharacter(t, store)
+	high.Level = 50
+	if err := store.UpdateCharacter(high); err != nil {
+		t.Fatalf("UpdateCharacter: %v", err)
+	}
+
+	all, err := store.GetAllCharacters()
+	if err != nil {
+		t.Fatalf("GetAllCharacters: %v", err)
+	}
+
+	highIndex, lowIndex := -1, -1
+	for i, char := range all {
+		switch char.ID {
+		case high.ID:
+			highIndex = i
+		case low.ID:
+			lowIndex = i
+		}
+		if i > 0 && all[i-1].Level < char.Level {
+			t.Fatalf("characters not ordered by level DESC at index %d", i)
+		}
+	}
+	if highIndex < 0 || lowIndex < 0 || highIndex > lowIndex {
+		t.Fatalf("unexpected ordering: high at %d, low at %d", highIndex, lowIndex)
+	}
+}
+
+func testItems(t *testing.T, store storage.Store) {
+	effect := "glows faintly"
+	item := &models.Item{
+		Name:              uniqueName("helmet"),
+		Type:              models.ItemTypeHelmet,
+		Rarity:            models.RarityEpic,
+		StrengthBonus:     1,
+		AgilityBonus:      2,
+		VitalityBonus:     3,
+		IntelligenceBonus: 4,
+		SpecialEffect:     &effect,
+		Value:             999,
+		IsSpecial:         true,
+	}
+	if err := store.CreateItem(item); err != nil {
+		t.Fatalf("CreateItem: %v", err)
+	}
+
+	loaded, err := store.GetItemByID(item.ID)
+	if err != nil || loaded == nil {
+		t.Fatalf("GetItemByID = %+v, %v", loaded, err)
+	}
+	if loaded.Name != item.Name || loaded.GetTotalStatBonus() != 10 || loaded.SpecialEffect == nil || *loaded.SpecialEffect != effect || !loaded.IsSpecial {
+		t.Fatalf("item round trip mismatch: %+v", loaded)
+	}
+
+	missing, err := store.GetItemByID(-1)
+	if err != nil || missing != nil {
+		t.Fatalf("GetItemByID(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+
+	random, err := store.GetRandomItems(1, true)
+	if err != nil {
+		t.Fatalf("GetRandomItems: %v", err)
+	}
+	if len(random) != 1 || !random[0].IsSpecial {
+		t.Fatalf("GetRandomItems(1, true) = %+v", random)
+	}
+
+	byType, err := store.GetItemsByType(models.ItemTypeHelmet, 1000, 0)
+	if err != nil {
+		t.Fatalf("GetItemsByType: %v", err)
+	}
+	found := false
+	for i, candidate := range byType {
+		if candidate.Type != models.ItemTypeHelmet {
+			t.Fatalf("GetItemsByType returned %s item", candidate.Type)
+		}
+		if i > 0 && byType[i-1].Rarity == candidate.Rarity && byType[i-1].Value < candidate.Value {
+			t.Fatalf("items not ordered by value DESC within rarity at index %d", i)
+		}
+		found = found || candidate.ID == item.ID
+	}
+	if !found {
+		t.Fatalf("created helmet missing from GetItemsByType")
+	}
+
+	paged, err := store.GetItemsByType(models.ItemTypeHelmet, 1, len(byType))
+	if err != nil || len(paged) != 0 {
+		t.Fatalf("offset past end = %+v, %v; want empty", paged, err)
+	}
+}
+
+func testInventory(t *testing.T, store storage.Store) {
+	character := MustCreateCharacter(t, store)
+	common := MustCreateItem(t, store, models.ItemTypeRing, models.RarityCommon, 10)
+	legendary := MustCreateItem(t, store, models.ItemTypeChain, models.RarityLegendary, 10)
+
+	owns, err := store.CharacterOwnsItem(character.ID, common.ID)
+	if err != nil || owns {
+		t.Fatalf("CharacterOwnsItem before add = %v, %v; want false", owns, err)
+	}
+
+	if err := store.AddItemToCharacter(character.ID, common.ID, 1); err != nil {
+		t.Fatalf("AddItemToCharacter: %v", err)
+	}
+	if err := store.AddItemToCharacter(character.ID, common.ID, 2); err != nil {
+		t.Fatalf("AddItemToCharacter (stack): %v", err)
+	}
+	if err := store.AddItemToCharacter(character.ID, legendary.ID, 1); err != nil {
+		t.Fatalf("AddItemToCharacter: %v", err)
+	}
+
+	owns, err = store.CharacterOwnsItem(character.ID, common.ID)
+	if err != nil || !owns {
+		t.Fatalf("CharacterOwnsItem after add = %v, %v; want true", owns, err)
+	}
+
+	items, err := store.GetCharacterItems(character.ID)
+	if err != nil {
+		t.Fatalf("GetCharacterItems: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("GetCharacterItems returned %d rows, want 2", len(items))
+	}
+	if items[0].ItemID != legendary.ID {
+		t.Fatalf("inventory not ordered by rarity DESC: first is item %d", items[0].ItemID)
+	}
+	if items[1].Quantity != 3 || items[1].Item == nil || items[1].Item.ID != common.ID {
+		t.Fatalf("stacked inventory row mismatch: %+v", items[1])
+	}
+
+	empty, err := store.GetCharacterItems(MustCreateCharacter(t, store).ID)
+	if err != nil || len(empty) != 0 {
+		t.Fatalf("GetCharacterItems(new character) = %+v, %v; want empty", empty, err)
+	}
+}
+
+func testCombatLogs(t *testing.T, store storage.Store) {
+	attacker := MustCreateCharacter(t, store)
+	defender := MustCreateCharacter(t, store)
+
+	var ids []int
+	for i := 0; i < 3; i++ {
+		log := &models.CombatLog{
+			AttackerID:    attacker.ID,
+			DefenderID:    defender.ID,
+			WinnerID:      attacker.ID,
+			AttackerPower: 100 + i,
+			DefenderPower: 90,
+			CombatLogText: fmt.Sprintf("fight %d", i),
+			Transcript: &models.CombatTranscript{
+				AttackerMaxHP: 150,
+				DefenderMaxHP: 140,
+				Rounds: []models.CombatRound{{Number: 1, Actions: []models.CombatAction{
+					{ActorID: attacker.ID, TargetID: defender.ID, Type: models.CombatActionAttack, Damage: 12 + i, TargetHP: 128 - i},
+					{ActorID: defender.ID, TargetID: attacker.ID, Type: models.CombatActionSpell, Damage: 9, TargetHP: 141},
+				}}},
+			},
+		}
+		if err := store.AddCombatLog(log); err != nil {
+			t.Fatalf("AddCombatLog: %v", err)
+		}
+		if log.ID == 0 {
+			t.Fatalf("AddCombatLog did not assign an ID")
+		}
+		ids = append(ids, log.ID)
+	}
+
+	history, err := store.GetCombatHistory(2)
+	if err != nil {
+		t.Fatalf("GetCombatHistory: %v", err)
+	}
+	if len(history) != 2 || history[0].ID != ids[2] || history[1].ID != ids[1] {
+		t.Fatalf("GetCombatHistory(2) = %+v; want newest first", history)
+	}
+	if history[0].CombatLogText != "fight 2" || history[0].AttackerPower != 102 {
+		t.Fatalf("combat log round trip mismatch: %+v", history[0])
+	}
+	transcript := history[0].Transcript
+	if transcript == nil || transcript.AttackerMaxHP != 150 || len(transcript.Rounds) != 1 || len(transcript.Rounds[0].Actions) != 2 {
+		t.Fatalf("combat transcript round trip mismatch: %+v", transcript)
+	}
+	if action := transcript.Rounds[0].Actions[0]; action.Damage != 14 || action.TargetHP != 126 || action.Type != models.CombatActionAttack {
+		t.Fatalf("combat action round trip mismatch: %+v", action)
+	}
+
+	seed := int64(-42)
+	snapshot := models.FighterSnapshot{ID: attacker.ID, Name: attacker.Username, Level: 3, Stats: models.Stats{Strength: 14, Agility: 11, Vitality: 12, Intelligence: 9}}
+	seeded := &models.CombatLog{
+		AttackerID:       attacker.ID,
+		DefenderID:       defender.ID,
+		WinnerID:         defender.ID,
+		CombatLogText:    "seeded fight",
+		Seed:             &seed,
+		AttackerSnapshot: &snapshot,
+		DefenderSnapshot: &models.FighterSnapshot{ID: defender.ID, Name: defender.Username, Level: 1},
+	}
+	if err := store.AddCombatLog(seeded); err != nil {
+		t.Fatalf("AddCombatLog(seeded): %v", err)
+	}
+	loaded, err := store.GetCombatLogByID(seeded.ID)
+	if err != nil || loaded == nil {
+		t.Fatalf("GetCombatLogByID = %+v, %v", loaded, err)
+	}
+	if loaded.Seed == nil || *loaded.Seed != seed || loaded.AttackerSnapshot == nil || *loaded.AttackerSnapshot != snapshot ||
+		loaded.DefenderSnapshot == nil || loaded.DefenderSnapshot.ID != defender.ID || loaded.Transcript != nil {
+		t.Fatalf("seeded combat log round trip mismatch: %+v", loaded)
+	}
+	if unseeded, err := store.GetCombatLogByID(ids[0]); err != nil || unseeded == nil || unseeded.Seed != nil || unseeded.AttackerSnapshot != nil {
+		t.Fatalf("GetCombatLogByID(unseeded) = %+v, %v; want log without seed", unseeded, err)
+	}
+	if missing, err := store.GetCombatLogByID(seeded.ID + 1000); err != nil || missing != nil {
+		t.Fatalf("GetCombatLogByID(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+
+	if wins, err := store.CountCombatWins(attacker.ID); err != nil || wins != 3 {
+		t.Fatalf("CountCombatWins(attacker) = %d, %v; want 3", wins, err)
+	}
+	if wins, err := store.CountCombatWins(defender.ID); err != nil || wins != 1 {
+		t.Fatalf("CountCombatWins(defender) = %d, %v; want 1", wins, err)
+	}
+}
+
+func testEvents(t *testing.T, store storage.Store) {
+	event := &models.Event{
+		Type:        "combat",
+		Title:       uniqueName("event"),
+		Description: "conformance",
+		Data:        `{"ok":true}`,
+	}
+	if err := store.CreateEvent(event); err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+
+	loaded, err := store.GetEventByID(event.ID)
+	if err != nil || loaded == nil || loaded.Title != event.Title || loaded.IsTriggered {
+		t.Fatalf("GetEventByID = %+v, %v", loaded, err)
+	}
+
+	latest, err := store.GetLatestEvents(1)
+	if err != nil || len(latest) != 1 || latest[0].ID != event.ID {
+		t.Fatalf("GetLatestEvents(1) = %+v, %v; want the new event", latest, err)
+	}
+
+	if err := store.MarkEventTriggered(event.ID); err != nil {
+		t.Fatalf("MarkEventTriggered: %v", err)
+	}
+	if err := store.MarkEventTriggered(event.ID); err != nil {
+		t.Fatalf("MarkEventTriggered twice: %v", err)
+	}
+	loaded, _ = store.GetEventByID(event.ID)
+	if loaded == nil || !loaded.IsTriggered {
+		t.Fatalf("event not marked triggered: %+v", loaded)
+	}
+
+	if err := store.MarkEventTriggered(-1); err == nil {
+		t.Fatalf("MarkEventTriggered on missing event succeeded")
+	}
+}
+
+func testMerchant(t *testing.T, store storage.Store) {
+	first := MustCreateItem(t, store, models.ItemTypeArmor, models.RarityRare, 100)
+	second := MustCreateItem(t, store, models.ItemTypeHelmet, models.RarityRare, 100)
+
+	start := time.Now().Add(-time.Minute)
+	end := time.Now().Add(time.Hour)
+
+	old, err := store.CreateMerchantEvent("random_shop", start, end, []models.MerchantEventItem{
+		{ItemID: first.ID, PriceChannelPoints: 200, Stock: 1},
+	})
+	if err != nil {
+		t.Fatalf("CreateMerchantEvent: %v", err)
+	}
+
+	event, err := store.CreateMerchantEvent("special_trader", start, end, []models.MerchantEventItem{
+		{ItemID: first.ID, PriceChannelPoints: 200, Stock: 2},
+		{ItemID: second.ID, PriceChannelPoints: 300, Stock: 1},
+	})
+	if err != nil {
+		t.Fatalf("CreateMerchantEvent: %v", err)
+	}
+	if event.ID == 0 || !event.IsActive || event.EventType != "special_trader" {
+		t.Fatalf("unexpected merchant event: %+v", event)
+	}
+
+	previous, err := store.GetMerchantEventByID(old.ID)
+	if err != nil || previous == nil || previous.IsActive {
+		t.Fatalf("creating a merchant event must deactivate the previous one: %+v, %v", previous, err)
+	}
+
+	current, err := store.GetCurrentMerchantEvent()
+	if err != nil || current == nil || current.ID != event.ID {
+		t.Fatalf("GetCurrentMerchantEvent = %+v, %v; want event %d", current, err, event.ID)
+	}
+
+	offers, err := store.GetMerchantEventItems(event.ID)
+	if err != nil || len(offers) != 2 {
+		t.Fatalf("GetMerchantEventItems = %+v, %v; want 2 offers", offers, err)
+	}
+	offer := offers[0]
+	if offer.MerchantEventID != event.ID || offer.ItemID != first.ID || offer.Stock != 2 || offer.Purchased != 0 {
+		t.Fatalf("unexpected offer: %+v", offer)
+	}
+
+	missing, err := store.GetMerchantEventByID(-1)
+	if err != nil || missing != nil {
+		t.Fatalf("GetMerchantEventByID(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+}
+
+func testStatUpgrade(t *testing.T, store storage.Store) {
+	character := MustCreateCharacter(t, store)
+
+	if _, err := store.UpgradeCharacterStat(character.ID, "strength", 2, 200); !errors.Is(err, storage.ErrInsufficientFunds) {
+		t.Fatalf("upgrade with an empty wallet: got %v, want ErrInsufficientFunds", err)
+	}
+	unchanged, _ := store.GetCharacterByID(character.ID)
+	if unchanged.Strength != character.Strength || unchanged.ChannelPointsSpent != 0 {
+		t.Fatalf("failed upgrade changed the character: %+v", unchanged)
+	}
+
+	credit := &models.WalletTransaction{CharacterID: character.ID, Type: models.TransactionCredit, Amount: 300, Reason: models.WalletReasonAdjustment}
+	if err := store.ApplyWalletTransaction(credit); err != nil {
+		t.Fatalf("credit: %v", err)
+	}
+
+	// Progress saved from an older copy of the row must survive the upgrade, and vice versa
+	stale := *unchanged
+	stale.Experience = 77
+	if err := store.UpdateCharacter(&stale); err != nil {
+		t.Fatalf("UpdateCharacter: %v", err)
+	}
+
+	debit, err := store.UpgradeCharacterStat(character.ID, "agility", 2, 200)
+	if err != nil || debit == nil || debit.Amount != 200 || debit.BalanceAfter != 100 || debit.Reason != models.WalletReasonStatUpgrade {
+		t.Fatalf("UpgradeCharacterStat = %+v, %v", debit, err)
+	}
+	upgraded, _ := store.GetCharacterByID(character.ID)
+	if upgraded.Agility != character.Agility+2 || upgraded.ChannelPointsSpent != 200 || upgraded.Experience != 77 {
+		t.Fatalf("upgrade not applied in place: %+v", upgraded)
+	}
+
+	// Saving the copy loaded before the upgrade keeps what the viewer paid for
+	stale.Experience = 78
+	if err := store.UpdateCharacter(&stale); err != nil {
+		t.Fatalf("UpdateCharacter: %v", err)
+	}
+	saved, _ := store.GetCharacterByID(character.ID)
+	if saved.Agility != upgraded.Agility || saved.ChannelPointsSpent != 200 || saved.Experience != 78 {
+		t.Fatalf("stale UpdateCharacter reverted the upgrade: %+v", saved)
+	}
+
+	if _, err := store.UpgradeCharacterStat(character.ID, "charisma", 1, 100); err == nil {
+		t.Fatalf("upgrade of an unknown stat succeeded")
+	}
+	if _, err := store.UpgradeCharacterStat(-1, "agility", 1, 100); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("upgrade of a missing character: got %v, want ErrNotFound", err)
+	}
+}
+
+func testWallet(t *testing.T, store storage.Store) {
+	character := MustCreateCharacter(t, store)
+
+	wallet, err := store.GetWallet(character.ID)
+	if err != nil || wallet == nil || wallet.Balance != 0 || wallet.CharacterID != character.ID {
+		t.Fatalf("GetWallet(new character) = %+v, %v; want empty wallet", wallet, err)
+	}
+
+	credit := &models.WalletTransaction{
+		CharacterID:   character.ID,
+		Type:          models.TransactionCredit,
+		Amount:        500,
+		Reason:        models.WalletReasonRedemption,
+		ReferenceType: models.ReferenceTwitchRedemption,
+		ReferenceID:   "abc-123",
+	}
+	if err := store.ApplyWalletTransaction(credit); err != nil {
+		t.Fatalf("credit: %v", err)
+	}
+	if credit.ID == 0 || credit.BalanceAfter != 500 {
+		t.Fatalf("credit not filled in: %+v", credit)
+	}
+
+	debit := &models.WalletTransaction{
+		CharacterID: character.ID,
+		Type:        models.TransactionDebit,
+		Amount:      200,
+		Reason:      models.WalletReasonStatUpgrade,
+	}
+	if err := store.ApplyWalletTransaction(debit); err != nil {
+		t.Fatalf("debit: %v", err)
+	}
+	if debit.BalanceAfter != 300 {
+		t.Fatalf("debit balance_after = %d, want 300", debit.BalanceAfter)
+	}
+
+	overdraft := &models.WalletTransaction{
+		CharacterID: character.ID,
+		Type:        models.TransactionDebit,
+		Amount:      301,
+		Reason:      models.WalletReasonStatUpgrade,
+	}
+	if err := store.ApplyWalletTransaction(overdraft); !errors.Is(err, storage.ErrInsufficientFunds) {
+		t.Fatalf("overdraft: got %v, want ErrInsufficientFunds", err)
+	}
+
+	invalid := &models.WalletTransaction{CharacterID: character.ID, Type: models.TransactionCredit, Amount: 0, Reason: "x"}
+	if err := store.ApplyWalletTransaction(invalid); err == nil {
+		t.Fatalf("zero-amount transaction succeeded")
+	}
+	ghost := &models.WalletTransaction{CharacterID: -1, Type: models.TransactionCredit, Amount: 1, Reason: "x"}
+	if err := store.ApplyWalletTransaction(ghost); err == nil {
+		t.Fatalf("transaction for missing character succeeded")
+	}
+
+	wallet, err = store.GetWallet(character.ID)
+	if err != nil || wallet.Balance != 300 || wallet.LifetimeEarned != 500 || wallet.LifetimeSpent != 200 {
+		t.Fatalf("GetWallet = %+v, %v; want balance 300, earned 500, spent 200", wallet, err)
+	}
+
+	history, err := store.GetWalletTransactions(character.ID, 10, 0)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("GetWalletTransactions = %+v, %v; want 2 entries", history, err)
+	}
+	if history[0].ID != debit.ID || history[1].ID != credit.ID {
+		t.Fatalf("ledger not ordered newest first: %+v", history)
+	}
+	if history[1].ReferenceID != "abc-123" || history[1].Type != models.TransactionCredit || history[1].BalanceAfter != 500 {
+		t.Fatalf("ledger round trip mismatch: %+v", history[1])
+	}
+
+	paged, err := store.GetWalletTransactions(character.ID, 10, 1)
+	if err != nil || len(paged) != 1 || paged[0].ID != credit.ID {
+		t.Fatalf("GetWalletTransactions(offset 1) = %+v, %v", paged, err)
+	}
+}
+
+// MustFund credits a character's wallet or fails the test
+func MustFund(t *testing.T, store storage.Store, characterID, amount int) {
+	t.Helper()
+
+	err := store.ApplyWalletTransaction(&models.WalletTransaction{
+		CharacterID: characterID,
+		Type:        models.TransactionCredit,
+		Amount:      amount,
+		Reason:      models.WalletReasonAdjustment,
+	})
+	if err != nil {
+		t.Fatalf("fund wallet: %v", err)
+	}
+}
+
+func testMerchantPurchase(t *testing.T, store storage.Store) {
+	item := MustCreateItem(t, store, models.ItemTypeRing, models.RarityEpic, 100)
+	other := MustCreateItem(t, store, models.ItemTypeChain, models.RarityEpic, 100)
+	buyer := MustCreateCharacter(t, store)
+
+	start := time.Now().Add(-time.Minute)
+	end := time.Now().Add(time.Hour)
+	event, err := store.CreateMerchantEvent("random_shop", start, end, []models.MerchantEventItem{
+		{ItemID: item.ID, PriceChannelPoints: 250, Stock: 1},
+		{ItemID: other.ID, PriceChannelPoints: 100, Stock: 5},
+	})
+	if err != nil {
+		t.Fatalf("CreateMerchantEvent: %v", err)
+	}
+	offers, err := store.GetMerchantEventItems(event.ID)
+	if err != nil || len(offers) != 2 {
+		t.Fatalf("GetMerchantEventItems = %+v, %v", offers, err)
+	}
+	offer := offers[0]
+
+	// Not enough points: nothing may change
+	MustFund(t, store, buyer.ID, 249)
+	if _, err := store.PurchaseMerchantItem(buyer.ID, event.ID, offer.ID); !errors.Is(err, storage.ErrInsufficientFunds) {
+		t.Fatalf("underfunded purchase: got %v, want ErrInsufficientFunds", err)
+	}
+	assertPurchaseState(t, store, buyer.ID, offer.ID, item.ID, 249, 0, false)
+
+	if _, err := store.PurchaseMerchantItem(buyer.ID, event.ID+1000, offer.ID); !errors.Is(err, storage.ErrOfferMismatch) {
+		t.Fatalf("purchase with wrong event: got %v, want ErrOfferMismatch", err)
+	}
+	if _, err := store.PurchaseMerchantItem(buyer.ID, 0, -1); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("purchase of missing offer: got %v, want ErrNotFound", err)
+	}
+	if _, err := store.PurchaseMerchantItem(-1, event.ID, offer.ID); err == nil {
+		t.Fatalf("purchase by missing character succeeded")
+	}
+	assertPurchaseState(t, store, buyer.ID, offer.ID, item.ID, 249, 0, false)
+
+	MustFund(t, store, buyer.ID, 1)
+	purchase, err := store.PurchaseMerchantItem(buyer.ID, event.ID, offer.ID)
+	if err != nil {
+		t.Fatalf("PurchaseMerchantItem: %v", err)
+	}
+	if purchase.Price != 250 || purchase.ItemID != item.ID || purchase.RemainingStock != 0 || purchase.Transaction == nil || purchase.Transaction.BalanceAfter != 0 {
+		t.Fatalf("unexpected receipt: %+v", purchase)
+	}
+	assertPurchaseState(t, store, buyer.ID, offer.ID, item.ID, 0, 1, true)
+
+	loaded, err := store.GetCharacterByID(buyer.ID)
+	if err != nil || loaded.ChannelPointsSpent != 250 {
+		t.Fatalf("channel_points_spent = %+v, %v; want 250", loaded, err)
+	}
+
+	MustFund(t, store, buyer.ID, 1000)
+	if _, err := store.PurchaseMerchantItem(buyer.ID, event.ID, offer.ID); !errors.Is(err, storage.ErrOutOfStock) {
+		t.Fatalf("purchase past stock: got %v, want ErrOutOfStock", err)
+	}
+	assertPurchaseState(t, store, buyer.ID, offer.ID, item.ID, 1000, 1, true)
+
+	// A newer merchant event deactivates this one
+	if _, err := store.CreateMerchantEvent("random_shop", start, end, nil); err != nil {
+		t.Fatalf("CreateMerchantEvent: %v", err)
+	}
+	if _, err := store.PurchaseMerchantItem(buyer.ID, 0, offers[1].ID); !errors.Is(err, storage.ErrMerchantInactive) {
+		t.Fatalf("purchase from inactive event: got %v, want ErrMerchantInactive", err)
+	}
+}
+
+// assertPurchaseState checks wallet balance, offer purchase count and item ownership together
+func assertPurchaseState(t *testing.T, store storage.Store, characterID, offerID, itemID, balance, purchased int, owns bool) {
+	t.Helper()
+
+	wallet, err := store.GetWallet(characterID)
+	if err != nil || wallet.Balance != balance {
+		t.Fatalf("wallet = %+v, %v; want balance %d", wallet, err, balance)
+	}
+	offer, err := store.GetMerchantEventItem(offerID)
+	if err != nil || offer == nil || offer.Purchased != purchased {
+		t.Fatalf("offer = %+v, %v; want purchased %d", offer, err, purchased)
+	}
+	owned, err := store.CharacterOwnsItem(characterID, itemID)
+	if err != nil || owned != owns {
+		t.Fatalf("CharacterOwnsItem = %v, %v; want %v", owned, err, owns)
+	}
+}
+
+func testConcurrentPurchase(t *testing.T, store storage.Store) {
+	item := MustCreateItem(t, store, models.ItemTypeArmor, models.RarityLegendary, 100)
+	start := time.Now().Add(-time.Minute)
+	end := time.Now().Add(time.Hour)
+	event, err := store.CreateMerchantEvent("special_trader", start, end, []models.MerchantEventItem{
+		{ItemID: item.ID, PriceChannelPoints: 100, Stock: 1},
+	})
+	if err != nil {
+		t.Fatalf("CreateMerchantEvent: %v", err)
+	}
+	offers, err := store.GetMerchantEventItems(event.ID)
+	if err != nil || len(offers) != 1 {
+		t.Fatalf("GetMerchantEventItems = %+v, %v", offers, err)
+	}
+
+	const buyers = 8
+	ids := make([]int, buyers)
+	for i := range ids {
+		buyer := MustCreateCharacter(t, store)
+		MustFund(t, store, buyer.ID, 100)
+		ids[i] = buyer.ID
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan error, buyers)
+	for _, id := range ids {
+		wg.Add(1)
+		go func(characterID int) {
+			defer wg.Done()
+			_, err := store.PurchaseMerchantItem(characterID, event.ID, offers[0].ID)
+			results <- err
+		}(id)
+	}
+	wg.Wait()
+	close(results)
+
+	succeeded := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, storage.ErrOutOfStock):
+			t.Fatalf("concurrent purchase: unexpected error %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d buyers got the last unit, want exactly 1", succeeded)
+	}
+
+	offer, err := store.GetMerchantEventItem(offers[0].ID)
+	if err != nil || offer.Purchased != 1 {
+		t.Fatalf("offer = %+v, %v; want purchased 1", offer, err)
+	}
+
+	charged := 0
+	for _, id := range ids {
+		wallet, err := store.GetWallet(id)
+		if err != nil {
+			t.Fatalf("GetWallet: %v", err)
+		}
+		if wallet.Balance == 0 {
+			charged++
+		}
+	}
+	if charged != 1 {
+		t.Fatalf("%d buyers were charged, want exactly 1", charged)
+	}
+}
+
+func testIdempotency(t *testing.T, store storage.Store) {
+	scope := "POST /api/v1/merchant/purchase"
+	key := uniqueName("redemption")
+	staleBefore := time.Now().Add(-time.Hour)
+
+	first := &models.IdempotencyRecord{Scope: scope, Key: key, RequestHash: "hash-a"}
+	existing, err := store.ReserveIdempotencyKey(first, staleBefore)
+	if err != nil || existing != nil {
+		t.Fatalf("ReserveIdempotencyKey(new) = %+v, %v; want nil, nil", existing, err)
+	}
+
+	existing, err = store.ReserveIdempotencyKey(&models.IdempotencyRecord{Scope: scope, Key: key, RequestHash: "hash-a"}, staleBefore)
+	if err != nil || existing == nil || existing.Completed || existing.RequestHash != "hash-a" {
+		t.Fatalf("ReserveIdempotencyKey(pending) = %+v, %v; want pending record", existing, err)
+	}
+
+	// Keys are scoped, so the same key on another route is independent
+	other, err := store.ReserveIdempotencyKey(&models.IdempotencyRecord{Scope: "PUT /other", Key: key, RequestHash: "hash-b"}, staleBefore)
+	if err != nil || other != nil {
+		t.Fatalf("ReserveIdempotencyKey(other scope) = %+v, %v; want nil, nil", other, err)
+	}
+
+	response := []byte(`{"message":"ok"}`)
+	if err := store.CompleteIdempotencyKey(scope, key, 200, response); err != nil {
+		t.Fatalf("CompleteIdempotencyKey: %v", err)
+	}
+	existing, err = store.ReserveIdempotencyKey(&models.IdempotencyRecord{Scope: scope, Key: key, RequestHash: "hash-a"}, time.Now().Add(time.Hour))
+	if err != nil || existing == nil || !existing.Completed || existing.StatusCode != 200 || string(existing.ResponseBody) != string(response) {
+		t.Fatalf("ReserveIdempotencyKey(completed) = %+v, %v; want stored response", existing, err)
+	}
+
+	// Completed records are never released or taken over
+	if err := store.ReleaseIdempotencyKey(scope, key); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey(completed): %v", err)
+	}
+	existing, err = store.ReserveIdempotencyKey(&models.IdempotencyRecord{Scope: scope, Key: key, RequestHash: "hash-a"}, staleBefore)
+	if err != nil || existing == nil || !existing.Completed {
+		t.Fatalf("ReserveIdempotencyKey(after release of completed) = %+v, %v; want completed record", existing, err)
+	}
+
+	if err := store.ReleaseIdempotencyKey("PUT /other", key); err != nil {
+		t.Fatalf("ReleaseIdempotencyKey(pending): %v", err)
+	}
+	other, err = store.ReserveIdempotencyKey(&models.IdempotencyRecord{Scope: "PUT /other", Key: key, RequestHash: "hash-c"}, staleBefore)
+	if err != nil || other != nil {
+		t.Fatalf("ReserveIdempotencyKey(released) = %+v, %v; want nil, nil", other, err)
+	}
+
+	// A pending reservation older than staleBefore is taken over
+	other, err = store.ReserveIdempotencyKey(&models.IdempotencyRecord{Scope: "PUT /other", Key: key, RequestHash: "hash-d"}, time.Now().Add(time.Hour))
+	if err != nil || other != nil {
+		t.Fatalf("ReserveIdempotencyKey(stale) = %+v, %v; want nil, nil", other, err)
+	}
+
+	if err := store.CompleteIdempotencyKey(scope, uniqueName("missing"), 200, nil); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("CompleteIdempotencyKey(missing) = %v; want ErrNotFound", err)
+	}
+}
+
+func testDuelChallenges(t *testing.T, store storage.Store) {
+	challenger := MustCreateCharacter(t, store)
+	defender := MustCreateCharacter(t, store)
+	bystander := MustCreateCharacter(t, store)
+	now := time.Now()
+
+	open := &models.DuelChallenge{ChallengerID: challenger.ID, DefenderID: defender.ID, Wager: 50, ExpiresAt: now.Add(time.Hour)}
+	if err := store.CreateDuelChallenge(open); !errors.Is(err, storage.ErrInsufficientFunds) {
+		t.Fatalf("CreateDuelChallenge(unfunded) = %v; want ErrInsufficientFunds", err)
+	}
+	if pending, _ := store.GetPendingDuelChallenges(challenger.ID); len(pending) != 0 {
+		t.Fatalf("unfunded challenge was stored: %+v", pending)
+	}
+
+	credit := &models.WalletTransaction{CharacterID: challenger.ID, Type: models.TransactionCredit, Amount: 80, Reason: models.WalletReasonAdjustment}
+	if err := store.ApplyWalletTransaction(credit); err != nil {
+		t.Fatalf("credit: %v", err)
+	}
+
+	overdue := &models.DuelChallenge{ChallengerID: defender.ID, DefenderID: bystander.ID, ExpiresAt: now.Add(-time.Minute)}
+	for _, challenge := range []*models.DuelChallenge{open, overdue} {
+		if err := store.CreateDuelChallenge(challenge); err != nil {
+			t.Fatalf("CreateDuelChallenge: %v", err)
+		}
+		if challenge.ID == 0 || challenge.Status != models.DuelStatusPending {
+			t.Fatalf("CreateDuelChallenge did not assign ID and pending status: %+v", challenge)
+		}
+	}
+	wallet, err := store.GetWallet(challenger.ID)
+	if err != nil || wallet == nil || wallet.Balance != 30 {
+		t.Fatalf("wallet after escrow = %+v, %v; want balance 30", wallet, err)
+	}
+
+	// The pair is blocked in both directions while the challenge is pending, whatever the wager
+	for _, duplicate := range []*models.DuelChallenge{
+		{ChallengerID: challenger.ID, DefenderID: defender.ID, Wager: 10, ExpiresAt: now.Add(time.Hour)},
+		{ChallengerID: defender.ID, DefenderID: challenger.ID, ExpiresAt: now.Add(time.Hour)},
+	} {
+		if err := store.CreateDuelChallenge(duplicate); !errors.Is(err, storage.ErrDuelChallengePending) {
+			t.Fatalf("CreateDuelChallenge(duplicate) = %v; want ErrDuelChallengePending", err)
+		}
+	}
+	if wallet, _ := store.GetWallet(challenger.ID); wallet.Balance != 30 {
+		t.Fatalf("rejected duplicate debited the wallet: balance %d", wallet.Balance)
+	}
+	// An overdue challenge no longer blocks the pair
+	rematch := &models.DuelChallenge{ChallengerID: bystander.ID, DefenderID: defender.ID, ExpiresAt: now.Add(time.Hour)}
+	if err := store.CreateDuelChallenge(rematch); err != nil {
+		t.Fatalf("CreateDuelChallenge(after overdue) = %v", err)
+	}
+	if _, err := store.TransitionDuelChallenge(rematch.ID, models.DuelStatusPending, models.DuelStatusCancelled); err != nil {
+		t.Fatalf("TransitionDuelChallenge(rematch): %v", err)
+	}
+
+	loaded, err := store.GetDuelChallengeByID(open.ID)
+	if err != nil || loaded == nil || loaded.Wager != 50 || loaded.DefenderID != defender.ID || loaded.RespondedAt != nil {
+		t.Fatalf("GetDuelChallengeByID = %+v, %v", loaded, err)
+	}
+	if missing, err := store.GetDuelChallengeByID(overdue.ID + 1000); err != nil || missing != nil {
+		t.Fatalf("GetDuelChallengeByID(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+
+	pending, err := store.GetPendingDuelChallenges(defender.ID)
+	if err != nil || len(pending) != 2 || pending[0].ID != open.ID || pending[1].ID != overdue.ID {
+		t.Fatalf("GetPendingDuelChallenges(defender) = %+v, %v; want both challenges oldest first", pending, err)
+	}
+	expired, err := store.GetExpiredDuelChallenges(now)
+	if err != nil || len(expired) != 1 || expired[0].ID != overdue.ID {
+		t.Fatalf("GetExpiredDuelChallenges = %+v, %v; want only the overdue challenge", expired, err)
+	}
+
+	moved, err := store.TransitionDuelChallenge(open.ID, models.DuelStatusPending, models.DuelStatusAccepted)
+	if err != nil || !moved {
+		t.Fatalf("TransitionDuelChallenge(pending->accepted) = %v, %v; want true", moved, err)
+	}
+	moved, err = store.TransitionDuelChallenge(open.ID, models.DuelStatusPending, models.DuelStatusDeclined)
+	if err != nil || moved {
+		t.Fatalf("TransitionDuelChallenge(stale from) = %v, %v; want false", moved, err)
+	}
+	if _, err := store.TransitionDuelChallenge(overdue.ID+1000, models.DuelStatusPending, models.DuelStatusExpired); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("TransitionDuelChallenge(missing) = %v; want ErrNotFound", err)
+	}
+
+	combatLog := &models.CombatLog{AttackerID: challenger.ID, DefenderID: defender.ID, WinnerID: defender.ID, CombatLogText: "duel"}
+	if err := store.AddCombatLog(combatLog); err != nil {
+		t.Fatalf("AddCombatLog: %v", err)
+	}
+	if err := store.SetDuelChallengeResult(open.ID, combatLog.ID, defender.ID); err != nil {
+		t.Fatalf("SetDuelChallengeResult: %v", err)
+	}
+	loaded, err = store.GetDuelChallengeByID(open.ID)
+	if err != nil || loaded.Status != models.DuelStatusAccepted || loaded.RespondedAt == nil ||
+		loaded.CombatLogID == nil || *loaded.CombatLogID != combatLog.ID || loaded.WinnerID == nil || *loaded.WinnerID != defender.ID {
+		t.Fatalf("accepted challenge = %+v, %v", loaded, err)
+	}
+
+	pending, err = store.GetPendingDuelChallenges(challenger.ID)
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("GetPendingDuelChallenges(after accept) = %+v, %v; want none", pending, err)
+	}
+}
+
+func testRatings(t *testing.T, store storage.Store) {
+	winner := MustCreateCharacter(t, store)
+	loser := MustCreateCharacter(t, store)
+	unrated := MustCreateCharacter(t, store)
+	if winner.Rating != models.DefaultRating || winner.RatedFights != 0 {
+		t.Fatalf("new character rating = %d (%d fights); want %d (0)", winner.Rating, winner.RatedFights, models.DefaultRating)
+	}
+
+	combatLog := &models.CombatLog{AttackerID: winner.ID, DefenderID: loser.ID, WinnerID: winner.ID, CombatLogText: "rated"}
+	if err := store.AddCombatLog(combatLog); err != nil {
+		t.Fatalf("AddCombatLog: %v", err)
+	}
+
+	entries, err := store.ApplyFightRating(combatLog.ID, winner.ID, loser.ID, func(wr, wf, lr, lf int) (int, int) {
+		if wr != models.DefaultRating || lr != models.DefaultRating || wf != 0 || lf != 0 {
+			t.Errorf("RatingFunc got (%d, %d, %d, %d); want default ratings and no fights", wr, wf, lr, lf)
+		}
+		return wr + 20, lr - 20
+	})
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("ApplyFightRating = %+v, %v", entries, err)
+	}
+	if entries[0].CharacterID != winner.ID || !entries[0].Won || entries[0].Delta() != 20 || entries[0].OpponentID != loser.ID ||
+		entries[1].CharacterID != loser.ID || entries[1].Won || entries[1].RatingAfter != models.DefaultRating-20 || entries[1].CombatLogID != combatLog.ID {
+		t.Fatalf("ApplyFightRating entries = %+v", entries)
+	}
+
+	// UpdateCharacter must not overwrite a rating changed since the character was loaded
+	winner.Level = 5
+	if err := store.UpdateCharacter(winner); err != nil {
+		t.Fatalf("UpdateCharacter: %v", err)
+	}
+	reloaded, err := store.GetCharacterByID(winner.ID)
+	if err != nil || reloaded.Rating != models.DefaultRating+20 || reloaded.RatedFights != 1 || reloaded.Level != 5 {
+		t.Fatalf("rating after UpdateCharacter = %+v, %v; want %d with 1 fight", reloaded, err, models.DefaultRating+20)
+	}
+
+	if _, err := store.ApplyFightRating(combatLog.ID, loser.ID, winner.ID, func(wr, wf, lr, lf int) (int, int) {
+		if wf != 1 || lf != 1 {
+			t.Errorf("RatingFunc fights = (%d, %d); want (1, 1)", wf, lf)
+		}
+		return wr + 5, lr - 5
+	}); err != nil {
+		t.Fatalf("ApplyFightRating(rematch): %v", err)
+	}
+
+	history, err := store.GetRatingHistory(loser.ID, 10, 0)
+	if err != nil || len(history) != 2 || !history[0].Won || history[1].Won || history[0].RatingBefore != history[1].RatingAfter {
+		t.Fatalf("GetRatingHistory = %+v, %v; want newest first", history, err)
+	}
+	if page, err := store.GetRatingHistory(loser.ID, 10, 1); err != nil || len(page) != 1 || page[0].ID != history[1].ID {
+		t.Fatalf("GetRatingHistory(offset 1) = %+v, %v", page, err)
+	}
+
+	ladder, err := store.GetLadder(10, 0)
+	if err != nil || len(ladder) != 2 || ladder[0].ID != winner.ID || ladder[1].ID != loser.ID {
+		t.Fatalf("GetLadder = %+v, %v; want rated characters by rating", ladder, err)
+	}
+	for _, character := range ladder {
+		if character.ID == unrated.ID {
+			t.Fatalf("GetLadder included a character without rated fights")
+		}
+	}
+	if page, err := store.GetLadder(10, 1); err != nil || len(page) != 1 || page[0].ID != loser.ID {
+		t.Fatalf("GetLadder(offset 1) = %+v, %v", page, err)
+	}
+
+	if _, err := store.ApplyFightRating(combatLog.ID, winner.ID, unrated.ID+1000, func(wr, wf, lr, lf int) (int, int) {
+		return wr, lr
+	}); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("ApplyFightRating(missing) = %v; want ErrNotFound", err)
+	}
+}
+
+func testMonsters(t *testing.T, store storage.Store) {
+	boots := MustCreateItem(t, store, models.ItemTypeBoots, models.RarityCommon, 10)
+	ring := MustCreateItem(t, store, models.ItemTypeRing, models.RarityRare, 90)
+
+	before, err := store.GetMonsters()
+	if err != nil {
+		t.Fatalf("GetMonsters: %v", err)
+	}
+
+	monster := &models.Monster{
+		Name:             uniqueName("monster"),
+		Description:      "test monster",
+		LevelMin:         0, // sorts ahead of any seeded monster
+		LevelMax:         4,
+		Strength:         7,
+		Agility:          6,
+		Vitality:         5,
+		Intelligence:     4,
+		ExperienceReward: 40,
+		Loot: []models.MonsterLoot{
+			{ItemID: boots.ID, DropChance: 0.25, Quantity: 1},
+			{ItemID: ring.ID, DropChance: 0.05, Quantity: 2},
+		},
+	}
+	if err := store.CreateMonster(monster); err != nil {
+		t.Fatalf("CreateMonster: %v", err)
+	}
+	if monster.ID == 0 || monster.Loot[0].MonsterID != monster.ID {
+		t.Fatalf("CreateMonster did not assign IDs: %+v", monster)
+	}
+
+	loaded, err := store.GetMonsterByID(monster.ID)
+	if err != nil || loaded == nil || loaded.Name != monster.Name || loaded.ExperienceReward != 40 || len(loaded.Loot) != 2 {
+		t.Fatalf("GetMonsterByID = %+v, %v", loaded, err)
+	}
+	for _, loot := range loaded.Loot {
+		if loot.ItemID == ring.ID && (loot.Quantity != 2 || loot.DropChance != 0.05) {
+			t.Fatalf("loot round trip mismatch: %+v", loot)
+		}
+	}
+	if missing, err := store.GetMonsterByID(monster.ID + 1000); err != nil || missing != nil {
+		t.Fatalf("GetMonsterByID(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+
+	all, err := store.GetMonsters()
+	if err != nil || len(all) != len(before)+1 || all[0].ID != monster.ID || len(all[0].Loot) != 2 {
+		t.Fatalf("GetMonsters = %+v, %v; want new monster first with loot", all, err)
+	}
+
+	character := MustCreateCharacter(t, store)
+	for i := 0; i < 2; i++ {
+		hunt := &models.HuntLog{
+			CharacterID:      character.ID,
+			MonsterID:        monster.ID,
+			MonsterLevel:     2 + i,
+			Won:              i == 1,
+			ExperienceGained: 44 * i,
+			DropItemIDs:      []int{boots.ID},
+			Seed:             int64(1000 + i),
+			Transcript:       &models.CombatTranscript{AttackerMaxHP: 150, DefenderMaxHP: 90, Rounds: []models.CombatRound{}},
+		}
+		if err := store.AddHuntLog(hunt); err != nil {
+			t.Fatalf("AddHuntLog: %v", err)
+		}
+		if hunt.ID == 0 {
+			t.Fatalf("AddHuntLog did not assign an ID")
+		}
+	}
+
+	history, err := store.GetHuntHistory(character.ID, 10)
+	if err != nil || len(history) != 2 || !history[0].Won || history[0].Seed != 1001 || history[1].Won {
+		t.Fatalf("GetHuntHistory = %+v, %v; want newest first", history, err)
+	}
+	if len(history[0].DropItemIDs) != 1 || history[0].DropItemIDs[0] != boots.ID || history[0].Transcript == nil || history[0].Transcript.DefenderMaxHP != 90 {
+		t.Fatalf("hunt log round trip mismatch: %+v", history[0])
+	}
+}
+
+func testRaids(t *testing.T, store storage.Store) {
+	boss := &models.RaidBoss{
+		Name:                  uniqueName("raid"),
+		Level:                 10,
+		Agility:               12,
+		MaxHP:                 100,
+		RewardPoints:          500,
+		AttackCooldownSeconds: 30,
+	}
+	if err := store.CreateRaidBoss(boss); err != nil {
+		t.Fatalf("CreateRaidBoss: %v", err)
+	}
+	if boss.ID == 0 || boss.Status != models.RaidStatusActive || boss.CurrentHP != 100 {
+		t.Fatalf("CreateRaidBoss did not initialise the boss: %+v", boss)
+	}
+
+	active, err := store.GetActiveRaidBoss()
+	if err != nil || active == nil || active.ID != boss.ID || active.Agility != 12 {
+		t.Fatalf("GetActiveRaidBoss = %+v, %v; want the new boss", active, err)
+	}
+
+	first := MustCreateCharacter(t, store)
+	second := MustCreateCharacter(t, store)
+	start := time.Now().Truncate(time.Second)
+
+	hit, err := store.ApplyRaidDamage(boss.ID, first.ID, 30, start)
+	if err != nil || hit.Damage != 30 || hit.HPBefore != 100 || hit.Raid.CurrentHP != 70 || hit.Defeated {
+		t.Fatalf("ApplyRaidDamage = %+v, %v; want 30 damage", hit, err)
+	}
+	if hit.Contribution.Damage != 30 || hit.Contribution.Attacks != 1 {
+		t.Fatalf("contribution after first hit = %+v", hit.Contribution)
+	}
+
+	if _, err := store.ApplyRaidDamage(boss.ID, first.ID, 30, start.Add(10*time.Second)); !errors.Is(err, storage.ErrRaidCooldown) {
+		t.Fatalf("attack during cooldown error = %v; want ErrRaidCooldown", err)
+	}
+	if _, err := store.ApplyRaidDamage(boss.ID+1000, first.ID, 30, start); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("attack on missing boss error = %v; want ErrNotFound", err)
+	}
+
+	// A dodged strike still counts as an attack and starts the cooldown
+	if hit, err := store.ApplyRaidDamage(boss.ID, second.ID, 0, start); err != nil || hit.Damage != 0 || hit.Contribution.Attacks != 1 {
+		t.Fatalf("ApplyRaidDamage(0) = %+v, %v", hit, err)
+	}
+	if hit, err := store.ApplyRaidDamage(boss.ID, second.ID, 20, start.Add(30*time.Second)); err != nil || hit.Raid.CurrentHP != 50 {
+		t.Fatalf("attack after cooldown = %+v, %v; want 50 HP left", hit, err)
+	}
+
+	// Damage is capped at the remaining hit points and the last hit defeats the boss
+	hit, err = store.ApplyRaidDamage(boss.ID, first.ID, 80, start.Add(45*time.Second))
+	if err != nil || hit.Damage != 50 || hit.Raid.CurrentHP != 0 || !hit.Defeated || hit.Raid.Status != models.RaidStatusDefeated {
+		t.Fatalf("finishing blow = %+v, %v; want 50 damage and a defeat", hit, err)
+	}
+	if hit.Raid.LastHitBy == nil || *hit.Raid.LastHitBy != first.ID || hit.Raid.EndedAt == nil {
+		t.Fatalf("defeated boss = %+v; want last hit and end time recorded", hit.Raid)
+	}
+	if _, err := store.ApplyRaidDamage(boss.ID, second.ID, 10, start.Add(time.Hour)); !errors.Is(err, storage.ErrRaidNotActive) {
+		t.Fatalf("attack on defeated boss error = %v; want ErrRaidNotActive", err)
+	}
+	if active, err := store.GetActiveRaidBoss(); err != nil || (active != nil && active.ID == boss.ID) {
+		t.Fatalf("GetActiveRaidBoss after defeat = %+v, %v", active, err)
+	}
+
+	contributions, err := store.GetRaidContributions(boss.ID)
+	if err != nil || len(contributions) != 2 {
+		t.Fatalf("GetRaidContributions = %+v, %v; want 2 contributors", contributions, err)
+	}
+	if contributions[0].CharacterID != first.ID || contributions[0].Damage != 80 || contributions[0].Attacks != 2 ||
+		contributions[0].Username != first.Username || contributions[0].RewardPoints != nil {
+		t.Fatalf("top contribution = %+v; want %s with 80 damage", contributions[0], first.Username)
+	}
+	if contributions[1].CharacterID != second.ID || contributions[1].Damage != 20 || contributions[1].Attacks != 2 {
+		t.Fatalf("second contribution = %+v", contributions[1])
+	}
+
+	if err := store.SetRaidReward(boss.ID, first.ID, 400, 80); err != nil {
+		t.Fatalf("SetRaidReward: %v", err)
+	}
+	if err := store.SetRaidReward(boss.ID, first.ID+1000, 1, 1); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("SetRaidReward(missing) error = %v; want ErrNotFound", err)
+	}
+	contributions, _ = store.GetRaidContributions(boss.ID)
+	if contributions[0].RewardPoints == nil || *contributions[0].RewardPoints != 400 || *contributions[0].RewardExperience != 80 {
+		t.Fatalf("reward not recorded: %+v", contributions[0])
+	}
+
+	// A boss past its deadline rejects attacks and can only leave the active status once
+	expiresAt := time.Now().Add(time.Minute).Truncate(time.Second)
+	escaping := &models.RaidBoss{Name: uniqueName("raid"), Level: 1, MaxHP: 50, AttackCooldownSeconds: 5, ExpiresAt: &expiresAt}
+	if err := store.CreateRaidBoss(escaping); err != nil {
+		t.Fatalf("CreateRaidBoss: %v", err)
+	}
+	if _, err := store.ApplyRaidDamage(escaping.ID, first.ID, 10, expiresAt); !errors.Is(err, storage.ErrRaidNotActive) {
+		t.Fatalf("attack after deadline error = %v; want ErrRaidNotActive", err)
+	}
+	if ok, err := store.TransitionRaidBoss(escaping.ID, models.RaidStatusActive, models.RaidStatusEscaped); err != nil || !ok {
+		t.Fatalf("TransitionRaidBoss = %v, %v; want true", ok, err)
+	}
+	if ok, err := store.TransitionRaidBoss(escaping.ID, models.RaidStatusActive, models.RaidStatusDefeated); err != nil || ok {
+		t.Fatalf("second TransitionRaidBoss = %v, %v; want false", ok, err)
+	}
+	if _, err := store.TransitionRaidBoss(escaping.ID+1000, models.RaidStatusActive, models.RaidStatusEscaped); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("TransitionRaidBoss(missing) error = %v; want ErrNotFound", err)
+	}
+
+	loaded, err := store.GetRaidBossByID(escaping.ID)
+	if err != nil || loaded == nil || loaded.Status != models.RaidStatusEscaped || loaded.EndedAt == nil || loaded.ExpiresAt == nil {
+		t.Fatalf("GetRaidBossByID = %+v, %v; want escaped boss", loaded, err)
+	}
+	if missing, err := store.GetRaidBossByID(escaping.ID + 1000); err != nil || missing != nil {
+		t.Fatalf("GetRaidBossByID(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+
+	recent, err := store.GetRaidBosses(2)
+	if err != nil || len(recent) != 2 || recent[0].ID != escaping.ID || recent[1].ID != boss.ID {
+		t.Fatalf("GetRaidBosses = %+v, %v; want newest first", recent, err)
+	}
+}
+
+func testGameEvents(t *testing.T, store storage.Store) {
+	character := MustCreateCharacter(t, store)
+
+	first, err := models.CreateLevelUpEvent(character)
+	if err != nil {
+		t.Fatalf("CreateLevelUpEvent: %v", err)
+	}
+	if err := store.CreateGameEvent(first); err != nil {
+		t.Fatalf("CreateGameEvent: %v", err)
+	}
+	second, _ := models.CreateRaidSpawnedEvent(&models.RaidBoss{ID: 7, Name: "Drache", Level: 20, MaxHP: 5000, CurrentHP: 5000})
+	if err := store.CreateGameEvent(second); err != nil {
+		t.Fatalf("CreateGameEvent: %v", err)
+	}
+	if first.ID == 0 || second.ID <= first.ID {
+		t.Fatalf("CreateGameEvent did not assign increasing IDs: %d, %d", first.ID, second.ID)
+	}
+
+	latest, err := store.GetLatestGameEvents(2)
+	if err != nil || len(latest) != 2 || latest[0].ID != second.ID || latest[1].ID != first.ID {
+		t.Fatalf("GetLatestGameEvents = %+v, %v; want newest first", latest, err)
+	}
+	if latest[0].EventType != models.EventTypeRaidSpawned || latest[0].CharacterID != nil {
+		t.Fatalf("raid event round trip mismatch: %+v", latest[0])
+	}
+	if latest[1].CharacterID == nil || *latest[1].CharacterID != character.ID {
+		t.Fatalf("level up event round trip mismatch: %+v", latest[1])
+	}
+
+	var data models.RaidEventData
+	if err := json.Unmarshal(latest[0].EventData, &data); err != nil || data.BossName != "Drache" || data.MaxHP != 5000 {
+		t.Fatalf("raid event data = %+v, %v", data, err)
+	}
+
+	if err := store.MarkGameEventTriggered(first.ID); err != nil {
+		t.Fatalf("MarkGameEventTriggered: %v", err)
+	}
+	if err := store.MarkGameEventTriggered(first.ID); err != nil {
+		t.Fatalf("MarkGameEventTriggered on a triggered event: %v", err)
+	}
+	if err := store.MarkGameEventTriggered(second.ID + 1000); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("MarkGameEventTriggered on a missing event = %v; want ErrNotFound", err)
+	}
+	latest, err = store.GetLatestGameEvents(2)
+	if err != nil || len(latest) != 2 || latest[0].OBSTriggered || !latest[1].OBSTriggered {
+		t.Fatalf("GetLatestGameEvents after trigger = %+v, %v", latest, err)
+	}
+
+	third, _ := models.CreateLevelUpEvent(character)
+	if err := store.CreateGameEvent(third); err != nil {
+		t.Fatalf("CreateGameEvent: %v", err)
+	}
+
+	after, err := store.GetGameEventsAfter(first.ID, models.GameEventFilter{}, 10)
+	if err != nil || len(after) != 2 || after[0].ID != second.ID || after[1].ID != third.ID {
+		t.Fatalf("GetGameEventsAfter = %+v, %v; want the two later events, oldest first", after, err)
+	}
+	after, err = store.GetGameEventsAfter(first.ID-1, models.GameEventFilter{}, 1)
+	if err != nil || len(after) != 1 || after[0].ID != first.ID {
+		t.Fatalf("GetGameEventsAfter with limit = %+v, %v; want the first event", after, err)
+	}
+	after, err = store.GetGameEventsAfter(first.ID-1, models.GameEventFilter{Types: []models.GameEventType{models.EventTypeLevelUp}}, 10)
+	if err != nil || len(after) != 2 || after[0].ID != first.ID || after[1].ID != third.ID {
+		t.Fatalf("GetGameEventsAfter by type = %+v, %v; want both level ups", after, err)
+	}
+	after, err = store.GetGameEventsAfter(first.ID, models.GameEventFilter{CharacterID: &character.ID}, 10)
+	if err != nil || len(after) != 1 || after[0].ID != third.ID {
+		t.Fatalf("GetGameEventsAfter by character = %+v, %v; want the later level up", after, err)
+	}
+	after, err = store.GetGameEventsAfter(third.ID, models.GameEventFilter{}, 10)
+	if err != nil || len(after) != 0 {
+		t.Fatalf("GetGameEventsAfter the newest event = %+v, %v; want none", after, err)
+	}
+}
+
+func testQuests(t *testing.T, store storage.Store) {
+	character := MustCreateCharacter(t, store)
+
+	active := &models.Quest{
+		Name:             "Conformance Quest",
+		QuestType:        models.QuestTypeDaily,
+		Requirements:     json.RawMessage(`[{"type": "win_fights", "count": 2}]`),
+		Rewards:          json.RawMessage(`{"channel_points": 10}`),
+		ChannelPointCost: 5,
+		IsActive:         true,
+	}
+	inactive := &models.Quest{
+		Name:         "Retired Quest",
+		QuestType:    models.QuestTypeWeekly,
+		Requirements: json.RawMessage(`[{"type": "reach_level", "level": 3}]`),
+	}
+	for _, quest := range []*models.Quest{active, inactive} {
+		if err := store.CreateQuest(quest); err != nil {
+			t.Fatalf("CreateQuest: %v", err)
+		}
+	}
+
+	got, err := store.GetQuestByID(active.ID)
+	if err != nil || got == nil || got.Name != active.Name || got.ChannelPointCost != 5 || !got.IsActive {
+		t.Fatalf("GetQuestByID = %+v, %v", got, err)
+	}
+	if missing, err := store.GetQuestByID(active.ID + 1000); err != nil || missing != nil {
+		t.Fatalf("GetQuestByID(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+
+	quests, err := store.GetActiveQuests()
+	if err != nil {
+		t.Fatalf("GetActiveQuests: %v", err)
+	}
+	foundActive := false
+	for _, quest := range quests {
+		foundActive = foundActive || quest.ID == active.ID
+		if quest.ID == inactive.ID {
+			t.Fatalf("GetActiveQuests returned inactive quest %d", inactive.ID)
+		}
+	}
+	if !foundActive {
+		t.Fatalf("GetActiveQuests did not return active quest %d", active.ID)
+	}
+
+	characterQuest := &models.CharacterQuest{CharacterID: character.ID, QuestID: active.ID, Progress: json.RawMessage(`{"step": 0}`)}
+	if err := store.CreateCharacterQuest(characterQuest); err != nil || characterQuest.ID == 0 {
+		t.Fatalf("CreateCharacterQuest = %v (ID %d)", err, characterQuest.ID)
+	}
+	duplicate := &models.CharacterQuest{CharacterID: character.ID, QuestID: active.ID}
+	if err := store.CreateCharacterQuest(duplicate); !errors.Is(err, storage.ErrQuestAlreadyAccepted) {
+		t.Fatalf("CreateCharacterQuest(duplicate) = %v; want ErrQuestAlreadyAccepted", err)
+	}
+	unknown := &models.CharacterQuest{CharacterID: character.ID, QuestID: active.ID + 1000}
+	if err := store.CreateCharacterQuest(unknown); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("CreateCharacterQuest(unknown quest) = %v; want ErrNotFound", err)
+	}
+
+	updated, completed, err := store.UpdateCharacterQuestProgress(characterQuest.ID, func(progress json.RawMessage) (json.RawMessage, bool, error) {
+		if !jsonEqual(progress, `{"step": 0}`) {
+			t.Errorf("update saw progress %s", progress)
+		}
+		return json.RawMessage(`{"step": 1}`), false, nil
+	})
+	if err != nil || completed || updated.Completed || !jsonEqual(updated.Progress, `{"step": 1}`) {
+		t.Fatalf("UpdateCharacterQuestProgress = %+v, %v, %v", updated, completed, err)
+	}
+
+	updated, completed, err = store.UpdateCharacterQuestProgress(characterQuest.ID, func(json.RawMessage) (json.RawMessage, bool, error) {
+		return json.RawMessage(`{"step": 2}`), true, nil
+	})
+	if err != nil || !completed || !updated.Completed || updated.CompletedAt == nil {
+		t.Fatalf("completing UpdateCharacterQuestProgress = %+v, %v, %v", updated, completed, err)
+	}
+
+	// A completed quest is never handed to the callback again
+	_, completed, err = store.UpdateCharacterQuestProgress(characterQuest.ID, func(json.RawMessage) (json.RawMessage, bool, error) {
+		t.Errorf("update called for a completed quest")
+		return nil, true, nil
+	})
+	if err != nil || completed {
+		t.Fatalf("UpdateCharacterQuestProgress(completed) = %v, %v; want not newly completed", completed, err)
+	}
+
+	if _, _, err := store.UpdateCharacterQuestProgress(characterQuest.ID+1000, nil); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("UpdateCharacterQuestProgress(missing) = %v; want ErrNotFound", err)
+	}
+
+	characterQuests, err := store.GetCharacterQuests(character.ID)
+	if err != nil || len(characterQuests) != 1 {
+		t.Fatalf("GetCharacterQuests = %+v, %v; want 1 quest", characterQuests, err)
+	}
+	if cq := characterQuests[0]; !cq.Completed || cq.Quest == nil || cq.Quest.Name != active.Name || !jsonEqual(cq.Progress, `{"step": 2}`) {
+		t.Fatalf("GetCharacterQuests[0] = %+v", cq)
+	}
+}
+
+func testQuestRotations(t *testing.T, store storage.Store) {
+	character := MustCreateCharacter(t, store)
+	daily := &models.Quest{
+		Name:         "Rotating Quest",
+		QuestType:    models.QuestTypeDaily,
+		Requirements: json.RawMessage(`[{"type": "win_fights", "count": 1}]`),
+		IsActive:     true,
+	}
+	if err := store.CreateQuest(daily); err != nil {
+		t.Fatalf("CreateQuest: %v", err)
+	}
+
+	// Periods land somewhere in the past so reruns against a shared database do not collide
+	start := time.Unix(946684800+time.Now().UnixNano()%700000000, 0).UTC()
+	first := &models.QuestRotation{
+		QuestType:   models.QuestTypeDaily,
+		PeriodStart: start,
+		PeriodEnd:   start.Add(time.Hour),
+		QuestIDs:    []int{daily.ID},
+	}
+	if err := store.CreateQuestRotation(first); err != nil || first.ID == 0 {
+		t.Fatalf("CreateQuestRotation = %v (ID %d)", err, first.ID)
+	}
+	duplicate := &models.QuestRotation{QuestType: models.QuestTypeDaily, PeriodStart: start, PeriodEnd: start.Add(time.Hour), QuestIDs: []int{}}
+	if err := store.CreateQuestRotation(duplicate); !errors.Is(err, storage.ErrQuestRotationExists) {
+		t.Fatalf("CreateQuestRotation(duplicate) = %v; want ErrQuestRotationExists", err)
+	}
+	second := &models.QuestRotation{
+		QuestType:   models.QuestTypeDaily,
+		PeriodStart: first.PeriodEnd,
+		PeriodEnd:   first.PeriodEnd.Add(time.Hour),
+		QuestIDs:    []int{daily.ID},
+	}
+	if err := store.CreateQuestRotation(second); err != nil {
+		t.Fatalf("CreateQuestRotation(next period) = %v", err)
+	}
+
+	got, err := store.GetQuestRotation(models.QuestTypeDaily, start.Add(30*time.Minute))
+	if err != nil || got == nil || got.ID != first.ID || !got.PeriodEnd.Equal(first.PeriodEnd) || !got.Includes(daily.ID) {
+		t.Fatalf("GetQuestRotation = %+v, %v; want rotation %d", got, err, first.ID)
+	}
+	if got, err := store.GetQuestRotation(models.QuestTypeDaily, first.PeriodEnd); err != nil || got == nil || got.ID != second.ID {
+		t.Fatalf("GetQuestRotation(at reset) = %+v, %v; want rotation %d", got, err, second.ID)
+	}
+	if got, err := store.GetQuestRotation(models.QuestTypeWeekly, start.Add(30*time.Minute)); err != nil || got != nil {
+		t.Fatalf("GetQuestRotation(weekly) = %+v, %v; want nil, nil", got, err)
+	}
+
+	// The same quest can be accepted once per rotation
+	expiresAt := first.PeriodEnd
+	old := &models.CharacterQuest{CharacterID: character.ID, QuestID: daily.ID, RotationID: first.ID, ExpiresAt: &expiresAt}
+	if err := store.CreateCharacterQuest(old); err != nil {
+		t.Fatalf("CreateCharacterQuest: %v", err)
+	}
+	again := &models.CharacterQuest{CharacterID: character.ID, QuestID: daily.ID, RotationID: first.ID, ExpiresAt: &expiresAt}
+	if err := store.CreateCharacterQuest(again); !errors.Is(err, storage.ErrQuestAlreadyAccepted) {
+		t.Fatalf("CreateCharacterQuest(same rotation) = %v; want ErrQuestAlreadyAccepted", err)
+	}
+	current := &models.CharacterQuest{CharacterID: character.ID, QuestID: daily.ID, RotationID: second.ID}
+	if err := store.CreateCharacterQuest(current); err != nil {
+		t.Fatalf("CreateCharacterQuest(next rotation) = %v", err)
+	}
+
+	expired, err := store.ExpireCharacterQuests(time.Now())
+	if err != nil || expired < 1 {
+		t.Fatalf("ExpireCharacterQuests = %d, %v; want at least 1", expired, err)
+	}
+	if _, completed, err := store.UpdateCharacterQuestProgress(old.ID, func(json.RawMessage) (json.RawMessage, bool, error) {
+		t.Errorf("update called for an expired quest")
+		return nil, true, nil
+	}); err != nil || completed {
+		t.Fatalf("UpdateCharacterQuestProgress(expired) = %v, %v; want not completed", completed, err)
+	}
+
+	characterQuests, err := store.GetCharacterQuests(character.ID)
+	if err != nil || len(characterQuests) != 2 {
+		t.Fatalf("GetCharacterQuests = %+v, %v; want 2 quests", characterQuests, err)
+	}
+	if cq := characterQuests[0]; !cq.Expired || cq.RotationID != first.ID || cq.ExpiresAt == nil || !cq.ExpiresAt.Equal(expiresAt) {
+		t.Fatalf("expired character quest = %+v", cq)
+	}
+	if cq := characterQuests[1]; cq.Expired || cq.RotationID != second.ID || cq.ExpiresAt != nil {
+		t.Fatalf("open character quest = %+v", cq)
+	}
+}
+
+func testAchievements(t *testing.T, store storage.Store) {
+	character := MustCreateCharacter(t, store)
+	other := MustCreateCharacter(t, store)
+
+	earlier := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	first := &models.CharacterAchievement{CharacterID: character.ID, AchievementKey: "veteran", Tier: models.AchievementTierSilver}
+	second := &models.CharacterAchievement{CharacterID: character.ID, AchievementKey: "veteran", Tier: models.AchievementTierBronze, UnlockedAt: earlier}
+	for _, achievement := range []*models.CharacterAchievement{first, second} {
+		isNew, err := store.UnlockAchievement(achievement)
+		if err != nil || !isNew || achievement.ID == 0 || achievement.UnlockedAt.IsZero() {
+			t.Fatalf("UnlockAchievement = %v, %v (%+v)", isNew, err, achievement)
+		}
+	}
+
+	again := &models.CharacterAchievement{CharacterID: character.ID, AchievementKey: "veteran", Tier: models.AchievementTierSilver}
+	if isNew, err := store.UnlockAchievement(again); err != nil || isNew {
+		t.Fatalf("UnlockAchievement(again) = %v, %v; want false, nil", isNew, err)
+	}
+	otherBadge := &models.CharacterAchievement{CharacterID: other.ID, AchievementKey: "veteran", Tier: models.AchievementTierSilver}
+	if isNew, err := store.UnlockAchievement(otherBadge); err != nil || !isNew {
+		t.Fatalf("UnlockAchievement(other character) = %v, %v; want true, nil", isNew, err)
+	}
+	missing := &models.CharacterAchievement{CharacterID: other.ID + 1000, AchievementKey: "veteran", Tier: models.AchievementTierGold}
+	if _, err := store.UnlockAchievement(missing); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("UnlockAchievement(missing character) = %v; want ErrNotFound", err)
+	}
+
+	achievements, err := store.GetCharacterAchievements(character.ID)
+	if err != nil || len(achievements) != 2 {
+		t.Fatalf("GetCharacterAchievements = %+v, %v; want 2 tiers", achievements, err)
+	}
+	if achievements[0].ID != second.ID || achievements[1].ID != first.ID {
+		t.Fatalf("GetCharacterAchievements = %+v; want unlock order", achievements)
+	}
+	if achievements[0].Tier != models.AchievementTierBronze || !achievements[0].UnlockedAt.Equal(earlier) {
+		t.Fatalf("achievement round trip mismatch: %+v", achievements[0])
+	}
+}
+
+func testOverlayQueue(t *testing.T, store storage.Store) {
+	character := MustCreateCharacter(t, store)
+	createEvent := func() *models.GameEvent {
+		t.Helper()
+		event, _ := models.CreateLevelUpEvent(character)
+		if err := store.CreateGameEvent(event); err != nil {
+			t.Fatalf("CreateGameEvent: %v", err)
+		}
+		return event
+	}
+	ids := func(deliveries []models.OverlayDelivery) []int {
+		eventIDs := []int{}
+		for _, delivery := range deliveries {
+			eventIDs = append(eventIDs, delivery.EventID)
+		}
+		return eventIDs
+	}
+
+	history := createEvent()
+	alerts, ticker := uniqueName("alerts"), uniqueName("ticker")
+	now := time.Now().Truncate(time.Millisecond)
+	visibleUntil := now.Add(30 * time.Second)
+	tokenA, tokenB, tokenC := strings.Repeat("a", 32), strings.Repeat("b", 32), strings.Repeat("c", 32)
+
+	// New consumers start after the newest event
+	claimed, err := store.ClaimOverlayEvents(alerts, tokenA, 5, now, visibleUntil)
+	if err != nil || len(claimed) != 0 {
+		t.Fatalf("first ClaimOverlayEvents = %+v, %v; want nothing", claimed, err)
+	}
+	if _, err := store.ClaimOverlayEvents(ticker, tokenA, 5, now, visibleUntil); err != nil {
+		t.Fatalf("ClaimOverlayEvents: %v", err)
+	}
+	consumer, err := store.GetOverlayConsumer(alerts)
+	if err != nil || consumer == nil || consumer.Name != alerts || consumer.Cursor != history.ID || consumer.InFlight != 0 {
+		t.Fatalf("GetOverlayConsumer = %+v, %v; want cursor at %d", consumer, err, history.ID)
+	}
+	if missing, err := store.GetOverlayConsumer(uniqueName("missing")); err != nil || missing != nil {
+		t.Fatalf("GetOverlayConsumer(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+
+	first, second, third := createEvent(), createEvent(), createEvent()
+
+	claimed, err = store.ClaimOverlayEvents(alerts, tokenA, 2, now, visibleUntil)
+	if err != nil || !reflect.DeepEqual(ids(claimed), []int{first.ID, second.ID}) {
+		t.Fatalf("ClaimOverlayEvents = %v, %v; want the first two events", ids(claimed), err)
+	}
+	if claimed[0].Attempts != 1 || !claimed[0].ClaimedUntil.Equal(visibleUntil) || claimed[0].ClaimToken != tokenA ||
+		claimed[0].Event == nil || claimed[0].Event.ID != first.ID || claimed[0].Event.EventType != models.EventTypeLevelUp {
+		t.Fatalf("claimed delivery = %+v", claimed[0])
+	}
+
+	// A second instance of the consumer gets the rest, never the events already claimed
+	claimed, err = store.ClaimOverlayEvents(alerts, tokenB, 5, now, visibleUntil)
+	if err != nil || !reflect.DeepEqual(ids(claimed), []int{third.ID}) {
+		t.Fatalf("second ClaimOverlayEvents = %v, %v; want the third event", ids(claimed), err)
+	}
+	claimed, err = store.ClaimOverlayEvents(alerts, tokenC, 5, now, visibleUntil)
+	if err != nil || len(claimed) != 0 {
+		t.Fatalf("ClaimOverlayEvents with everything claimed = %v, %v; want nothing", ids(claimed), err)
+	}
+
+	// Other consumers have their own cursor
+	claimed, err = store.ClaimOverlayEvents(ticker, tokenA, 5, now, visibleUntil)
+	if err != nil || !reflect.DeepEqual(ids(claimed), []int{first.ID, second.ID, third.ID}) {
+		t.Fatalf("ticker ClaimOverlayEvents = %v, %v; want every new event", ids(claimed), err)
+	}
+
+	acked, err := store.AckOverlayEvents(alerts, tokenB, []int{first.ID})
+	if err != nil || len(acked) != 0 {
+		t.Fatalf("AckOverlayEvents with another claim's token = %v, %v; want nothing acknowledged", acked, err)
+	}
+	acked, err = store.AckOverlayEvents(alerts, tokenA, []int{first.ID})
+	if err != nil || !reflect.DeepEqual(acked, []int{first.ID}) {
+		t.Fatalf("AckOverlayEvents = %v, %v; want the first event", acked, err)
+	}
+	consumer, err = store.GetOverlayConsumer(alerts)
+	if err != nil || consumer.Cursor != third.ID || consumer.InFlight != 2 {
+		t.Fatalf("GetOverlayConsumer after ack = %+v, %v; want 2 in flight", consumer, err)
+	}
+
+	// Unacknowledged claims are handed out again once their visibility expired
+	later := visibleUntil
+	claimed, err = store.ClaimOverlayEvents(alerts, tokenC, 1, later, later.Add(30*time.Second))
+	if err != nil || !reflect.DeepEqual(ids(claimed), []int{second.ID}) || claimed[0].Attempts != 2 {
+		t.Fatalf("ClaimOverlayEvents after expiry = %+v, %v; want the second event again", claimed, err)
+	}
+	acked, err = store.AckOverlayEvents(alerts, tokenA, []int{second.ID})
+	if err != nil || len(acked) != 0 {
+		t.Fatalf("AckOverlayEvents with an expired token = %v, %v; want nothing acknowledged", acked, err)
+	}
+	acked, err = store.AckOverlayEvents(alerts, tokenC, []int{second.ID})
+	if err != nil || !reflect.DeepEqual(acked, []int{second.ID}) {
+		t.Fatalf("AckOverlayEvents with the new token = %v, %v; want the second event", acked, err)
+	}
+
+	// The expired third event comes back, followed by new events
+	fourth := createEvent()
+	claimed, err = store.ClaimOverlayEvents(alerts, tokenA, 5, later, later.Add(30*time.Second))
+	if err != nil || !reflect.DeepEqual(ids(claimed), []int{third.ID, fourth.ID}) {
+		t.Fatalf("ClaimOverlayEvents = %v, %v; want the expired third and the new fourth event", ids(claimed), err)
+	}
+}
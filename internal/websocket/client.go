@@ -0,0 +1,122 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Dial opens a WebSocket connection to a ws:// or wss:// URL, offering the given
+// subprotocols during the handshake
+func Dial(ctx context.Context, rawURL string, protocols ...string) (*Conn, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("websocket: invalid URL: %v", err)
+	}
+
+	address := target.Host
+	switch target.Scheme {
+	case "ws":
+		if target.Port() == "" {
+			address = net.JoinHostPort(target.Hostname(), "80")
+		}
+	case "wss":
+		if target.Port() == "" {
+			address = net.JoinHostPort(target.Hostname(), "443")
+		}
+	default:
+		return nil, fmt.Errorf("websocket: unsupported URL scheme %q", target.Scheme)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme == "wss" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: target.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	ws, err := handshake(ctx, conn, target, protocols)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ws, nil
+}
+
+func handshake(ctx context.Context, conn net.Conn, target *url.URL, protocols []string) (*Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(noDeadline)
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	requestURL := *target
+	requestURL.Scheme = "http"
+	if target.Scheme == "wss" {
+		requestURL.Scheme = "https"
+	}
+	req, err := http.NewRequest(http.MethodGet, requestURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(protocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(protocols, ", "))
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("websocket: handshake failed with status %s", resp.Status)
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") ||
+		resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return nil, fmt.Errorf("websocket: invalid handshake response")
+	}
+
+	protocol := resp.Header.Get("Sec-WebSocket-Protocol")
+	if protocol != "" && !containsToken(protocols, protocol) {
+		return nil, fmt.Errorf("websocket: server selected unknown subprotocol %q", protocol)
+	}
+
+	return newConn(conn, reader, true, protocol), nil
+}
+
+func containsToken(tokens []string, token string) bool {
+	for _, candidate := range tokens {
+		if strings.EqualFold(strings.TrimSpace(candidate), token) {
+			return true
+		}
+	}
+	return false
+}
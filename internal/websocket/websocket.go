@@ -0,0 +1,289 @@
+// Package websocket is a minimal RFC 6455 WebSocket implementation covering what the
+// OBS integration needs: dialing a server, accepting connections in test servers and
+// exchanging text messages. It handles fragmentation, ping/pong and the closing
+// handshake, but no extensions such as compression.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Message and control frame opcodes
+const (
+	OpContinuation = 0x0
+	OpText         = 0x1
+	OpBinary       = 0x2
+	OpClose        = 0x8
+	OpPing         = 0x9
+	OpPong         = 0xA
+)
+
+// Close status codes used by this package
+const (
+	CloseNormal        = 1000
+	CloseProtocolError = 1002
+	CloseTooBig        = 1009
+	CloseNoStatus      = 1005
+)
+
+// MaxMessageSize limits the size of a received message, fragments included
+const MaxMessageSize = 16 << 20
+
+// acceptGUID is appended to the client's key to compute Sec-WebSocket-Accept
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ErrMessageTooBig is returned when a received message exceeds MaxMessageSize
+var ErrMessageTooBig = errors.New("websocket: message too big")
+
+// CloseError is returned by ReadMessage after the peer closed the connection
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket: closed with status %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: closed with status %d: %s", e.Code, e.Reason)
+}
+
+// Conn is an established WebSocket connection. Reads must come from a single
+// goroutine; writes may be concurrent.
+type Conn struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	isClient bool // clients mask the frames they send
+	protocol string
+
+	writeMutex sync.Mutex
+	closeOnce  sync.Once
+}
+
+func newConn(conn net.Conn, reader *bufio.Reader, isClient bool, protocol string) *Conn {
+	if reader == nil {
+		reader = bufio.NewReader(conn)
+	}
+	return &Conn{conn: conn, reader: reader, isClient: isClient, protocol: protocol}
+}
+
+// Subprotocol returns the subprotocol negotiated during the handshake
+func (c *Conn) Subprotocol() string {
+	return c.protocol
+}
+
+// ReadMessage reads the next text or binary message. Pings are answered while waiting.
+// After the peer closed the connection it returns a *CloseError.
+func (c *Conn) ReadMessage() (opcode int, payload []byte, err error) {
+	opcode = -1
+	for {
+		fin, frameOpcode, data, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch frameOpcode {
+		case OpPing:
+			if err := c.writeFrame(OpPong, data); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case OpPong:
+			continue
+		case OpClose:
+			closeErr := &CloseError{Code: CloseNoStatus}
+			if len(data) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(data))
+				closeErr.Reason = string(data[2:])
+			}
+			// Echo the status to complete the closing handshake
+			c.closeOnce.Do(func() {
+				c.writeFrame(OpClose, data[:min(len(data), 2)])
+				c.conn.Close()
+			})
+			return 0, nil, closeErr
+		case OpContinuation:
+			if opcode < 0 {
+				c.fail(CloseProtocolError, "unexpected continuation frame")
+				return 0, nil, errors.New("websocket: unexpected continuation frame")
+			}
+		case OpText, OpBinary:
+			if opcode >= 0 {
+				c.fail(CloseProtocolError, "expected continuation frame")
+				return 0, nil, errors.New("websocket: expected continuation frame")
+			}
+			opcode = frameOpcode
+		default:
+			c.fail(CloseProtocolError, "unknown opcode")
+			return 0, nil, fmt.Errorf("websocket: unknown opcode %d", frameOpcode)
+		}
+
+		if len(payload)+len(data) > MaxMessageSize {
+			c.fail(CloseTooBig, "message too big")
+			return 0, nil, ErrMessageTooBig
+		}
+		payload = append(payload, data...)
+		if fin {
+			return opcode, payload, nil
+		}
+	}
+}
+
+// WriteMessage sends a text or binary message in a single frame
+func (c *Conn) WriteMessage(opcode int, payload []byte) error {
+	return c.writeFrame(opcode, payload)
+}
+
+// Ping sends a ping frame; the answer is consumed by ReadMessage
+func (c *Conn) Ping(payload []byte) error {
+	return c.writeFrame(OpPing, payload)
+}
+
+// SetReadDeadline sets the deadline for reading the next frames
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// Close starts the closing handshake with a normal status and closes the connection
+func (c *Conn) Close() error {
+	return c.CloseWithStatus(CloseNormal, "")
+}
+
+// CloseWithStatus sends a close frame with the given status and reason and closes the
+// connection without waiting for the peer's answer
+func (c *Conn) CloseWithStatus(code int, reason string) error {
+	var err error
+	c.closeOnce.Do(func() {
+		data := make([]byte, 2, 2+len(reason))
+		binary.BigEndian.PutUint16(data, uint16(code))
+		c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		c.writeFrame(OpClose, append(data, reason...))
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// fail closes the connection after a protocol violation by the peer
+func (c *Conn) fail(code int, reason string) {
+	c.CloseWithStatus(code, reason)
+}
+
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	opcode = int(header[0] & 0x0F)
+	if header[0]&0x70 != 0 {
+		c.fail(CloseProtocolError, "reserved bits set")
+		return false, 0, nil, errors.New("websocket: reserved bits set")
+	}
+	masked := header[1]&0x80 != 0
+	if masked == c.isClient {
+		// Servers never mask their frames, clients always do
+		c.fail(CloseProtocolError, "invalid masking")
+		return false, 0, nil, errors.New("websocket: invalid frame masking")
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if opcode >= OpClose && (length > 125 || !fin) {
+		c.fail(CloseProtocolError, "invalid control frame")
+		return false, 0, nil, errors.New("websocket: invalid control frame")
+	}
+	if length > MaxMessageSize {
+		c.fail(CloseTooBig, "message too big")
+		return false, 0, nil, ErrMessageTooBig
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		maskBytes(payload, mask)
+	}
+
+	return fin, opcode, payload, nil
+}
+
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, 0x80|byte(opcode))
+
+	maskBit := byte(0)
+	if c.isClient {
+		maskBit = 0x80
+	}
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, maskBit|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+
+	if c.isClient {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		maskBytes(frame[start:], mask)
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+func maskBytes(data []byte, mask [4]byte) {
+	for i := range data {
+		data[i] ^= mask[i%4]
+	}
+}
+
+// acceptKey computes the Sec-WebSocket-Accept value for a client key
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,287 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tcpPair returns both ends of a loopback TCP connection, which unlike net.Pipe buffers
+// writes so a test can send several frames before reading
+func tcpPair(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := listener.Accept()
+		accepted <- conn
+	}()
+	dialed, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	peer := <-accepted
+	if peer == nil {
+		t.Fatalf("Accept failed")
+	}
+	t.Cleanup(func() { dialed.Close(); peer.Close() })
+	dialed.SetDeadline(time.Now().Add(5 * time.Second))
+	peer.SetDeadline(time.Now().Add(5 * time.Second))
+	return dialed, peer
+}
+
+// frame encodes a single frame, masked with a fixed key when mask is set
+func frame(fin bool, opcode int, payload []byte, mask bool) []byte {
+	first := byte(opcode)
+	if fin {
+		first |= 0x80
+	}
+	encoded := []byte{first}
+	maskBit := byte(0)
+	if mask {
+		maskBit = 0x80
+	}
+	switch {
+	case len(payload) <= 125:
+		encoded = append(encoded, maskBit|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		encoded = append(encoded, maskBit|126)
+		encoded = binary.BigEndian.AppendUint16(encoded, uint16(len(payload)))
+	default:
+		encoded = append(encoded, maskBit|127)
+		encoded = binary.BigEndian.AppendUint64(encoded, uint64(len(payload)))
+	}
+	if !mask {
+		return append(encoded, payload...)
+	}
+	key := [4]byte{0x12, 0x34, 0x56, 0x78}
+	encoded = append(encoded, key[:]...)
+	start := len(encoded)
+	encoded = append(encoded, payload...)
+	maskBytes(encoded[start:], key)
+	return encoded
+}
+
+// readRawFrame reads one frame as sent by a Conn, unmasking it
+func readRawFrame(t *testing.T, reader *bufio.Reader) (opcode int, masked bool, payload []byte) {
+	t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		t.Fatalf("reading frame header: %v", err)
+	}
+	opcode = int(header[0] & 0x0F)
+	masked = header[1]&0x80 != 0
+	length := int(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		io.ReadFull(reader, extended[:])
+		length = int(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		io.ReadFull(reader, extended[:])
+		length = int(binary.BigEndian.Uint64(extended[:]))
+	}
+	var key [4]byte
+	if masked {
+		io.ReadFull(reader, key[:])
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		t.Fatalf("reading frame payload: %v", err)
+	}
+	if masked {
+		maskBytes(payload, key)
+	}
+	return opcode, masked, payload
+}
+
+func TestMasking(t *testing.T) {
+	clientSide, peer := tcpPair(t)
+	client := newConn(clientSide, nil, true, "")
+	reader := bufio.NewReader(peer)
+
+	message := bytes.Repeat([]byte("mask me "), 40) // long enough for a 16 bit length
+	if err := client.WriteMessage(OpText, message); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+	opcode, masked, payload := readRawFrame(t, reader)
+	if opcode != OpText || !masked || !bytes.Equal(payload, message) {
+		t.Fatalf("client frame = op %d masked %v %q; want a masked text frame of the message", opcode, masked, payload)
+	}
+
+	serverSide, peer := tcpPair(t)
+	server := newConn(serverSide, nil, false, "")
+	reader = bufio.NewReader(peer)
+	if err := server.WriteMessage(OpText, []byte("plain")); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+	if opcode, masked, payload := readRawFrame(t, reader); opcode != OpText || masked || string(payload) != "plain" {
+		t.Fatalf("server frame = op %d masked %v %q; want an unmasked text frame", opcode, masked, payload)
+	}
+
+	// A server fails the connection when a client does not mask
+	peer.Write(frame(true, OpText, []byte("unmasked"), false))
+	if _, _, err := server.ReadMessage(); err == nil {
+		t.Fatalf("ReadMessage accepted an unmasked client frame")
+	}
+	if opcode, _, payload := readRawFrame(t, reader); opcode != OpClose || binary.BigEndian.Uint16(payload) != CloseProtocolError {
+		t.Fatalf("answer to an unmasked frame = op %d %v; want close %d", opcode, payload, CloseProtocolError)
+	}
+}
+
+func TestFragmentedMessageWithPing(t *testing.T) {
+	serverSide, peer := tcpPair(t)
+	server := newConn(serverSide, nil, false, "")
+	reader := bufio.NewReader(peer)
+
+	// Control frames may arrive between the fragments of a message
+	peer.Write(frame(false, OpText, []byte("Hel"), true))
+	peer.Write(frame(true, OpPing, []byte("are you there"), true))
+	peer.Write(frame(false, OpContinuation, []byte("lo, "), true))
+	peer.Write(frame(true, OpContinuation, []byte("world"), true))
+
+	opcode, payload, err := server.ReadMessage()
+	if err != nil || opcode != OpText || string(payload) != "Hello, world" {
+		t.Fatalf("ReadMessage = %d %q %v; want the reassembled text", opcode, payload, err)
+	}
+	if opcode, _, payload := readRawFrame(t, reader); opcode != OpPong || string(payload) != "are you there" {
+		t.Fatalf("answer to ping = op %d %q; want a pong with its payload", opcode, payload)
+	}
+
+	// An unsolicited pong is skipped
+	peer.Write(frame(true, OpPong, nil, true))
+	peer.Write(frame(true, OpBinary, []byte{1, 2, 3}, true))
+	if opcode, payload, err := server.ReadMessage(); err != nil || opcode != OpBinary || !bytes.Equal(payload, []byte{1, 2, 3}) {
+		t.Fatalf("ReadMessage after pong = %d %v %v; want the binary message", opcode, payload, err)
+	}
+}
+
+func TestFragmentationErrors(t *testing.T) {
+	cases := map[string][][]byte{
+		"continuation without a message": {frame(true, OpContinuation, []byte("x"), true)},
+		"new message inside a message":   {frame(false, OpText, []byte("a"), true), frame(true, OpText, []byte("b"), true)},
+		"fragmented control frame":       {frame(false, OpPing, nil, true)},
+		"unknown opcode":                 {frame(true, 0x3, nil, true)},
+	}
+	for name, frames := range cases {
+		t.Run(name, func(t *testing.T) {
+			serverSide, peer := tcpPair(t)
+			server := newConn(serverSide, nil, false, "")
+			for _, f := range frames {
+				peer.Write(f)
+			}
+			if _, _, err := server.ReadMessage(); err == nil {
+				t.Fatalf("ReadMessage accepted the frames")
+			}
+			opcode, _, payload := readRawFrame(t, bufio.NewReader(peer))
+			if opcode != OpClose || binary.BigEndian.Uint16(payload) != CloseProtocolError {
+				t.Fatalf("answer = op %d %v; want close %d", opcode, payload, CloseProtocolError)
+			}
+		})
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	clientSide, peer := tcpPair(t)
+	client := newConn(clientSide, nil, true, "")
+	reader := bufio.NewReader(peer)
+
+	// The peer closes; the status is reported and echoed
+	closing := binary.BigEndian.AppendUint16(nil, 1001)
+	peer.Write(frame(true, OpClose, append(closing, "going away"...), false))
+	_, _, err := client.ReadMessage()
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != 1001 || closeErr.Reason != "going away" {
+		t.Fatalf("ReadMessage = %v; want a CloseError 1001 going away", err)
+	}
+	if opcode, masked, payload := readRawFrame(t, reader); opcode != OpClose || !masked || binary.BigEndian.Uint16(payload) != 1001 {
+		t.Fatalf("echo = op %d masked %v %v; want a masked close 1001", opcode, masked, payload)
+	}
+	if _, err := reader.ReadByte(); err != io.EOF {
+		t.Fatalf("connection still open after the closing handshake: %v", err)
+	}
+
+	// A close without a status is reported as CloseNoStatus
+	clientSide, peer = tcpPair(t)
+	client = newConn(clientSide, nil, true, "")
+	peer.Write(frame(true, OpClose, nil, false))
+	if _, _, err := client.ReadMessage(); !errors.As(err, &closeErr) || closeErr.Code != CloseNoStatus {
+		t.Fatalf("ReadMessage = %v; want a CloseError %d", err, CloseNoStatus)
+	}
+
+	// Closing locally sends the status once
+	clientSide, peer = tcpPair(t)
+	client = newConn(clientSide, nil, true, "")
+	reader = bufio.NewReader(peer)
+	client.CloseWithStatus(4000, "done")
+	client.Close()
+	if opcode, _, payload := readRawFrame(t, reader); opcode != OpClose || binary.BigEndian.Uint16(payload) != 4000 || string(payload[2:]) != "done" {
+		t.Fatalf("close frame = op %d %q; want close 4000 done", opcode, payload)
+	}
+	if _, err := reader.ReadByte(); err != io.EOF {
+		t.Fatalf("more data after the close frame: %v", err)
+	}
+}
+
+func TestDialAndAccept(t *testing.T) {
+	accepted := make(chan *Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Accept(w, r, "chat.v2", "chat.v1")
+		if err != nil {
+			return
+		}
+		accepted <- conn
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := Dial(ctx, url, "chat.v1", "chat.v2")
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer client.Close()
+	conn := <-accepted
+	defer conn.Close()
+	if client.Subprotocol() != "chat.v1" || conn.Subprotocol() != "chat.v1" {
+		t.Fatalf("subprotocols = %q, %q; want the client's first choice the server supports", client.Subprotocol(), conn.Subprotocol())
+	}
+
+	if err := client.WriteMessage(OpText, []byte("ping?")); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+	if _, payload, err := conn.ReadMessage(); err != nil || string(payload) != "ping?" {
+		t.Fatalf("server ReadMessage = %q, %v", payload, err)
+	}
+	if err := conn.WriteMessage(OpText, []byte("pong!")); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+	if _, payload, err := client.ReadMessage(); err != nil || string(payload) != "pong!" {
+		t.Fatalf("client ReadMessage = %q, %v", payload, err)
+	}
+
+	if _, err := Dial(ctx, "http"+strings.TrimPrefix(server.URL, "http")); err == nil {
+		t.Fatalf("Dial accepted an http:// URL")
+	}
+	plain := httptest.NewServer(http.NotFoundHandler())
+	defer plain.Close()
+	if _, err := Dial(ctx, "ws"+strings.TrimPrefix(plain.URL, "http")); err == nil {
+		t.Fatalf("Dial succeeded against a server that does not upgrade")
+	}
+}
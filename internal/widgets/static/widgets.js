@@ -0,0 +1,75 @@
+// Shared by the overlay widgets: follows the game event stream of the server, loads API
+// data and shows alerts one after another. widgetTheme is set by each page.
+const Widgets = (() => {
+  // Alerts waiting beyond this many are dropped so a burst never backs up for minutes
+  const maxQueuedAlerts = 10;
+  // Milliseconds the fade out of an alert takes before the next one is shown
+  const fadeOut = 500;
+
+  // stream opens the game event stream for the given types and calls onEvent with every
+  // game event and its decoded event data. The browser reconnects on its own and resumes
+  // after the last event it received.
+  function stream(types, onEvent) {
+    const source = new EventSource("/api/v1/events/stream?types=" + encodeURIComponent(types.join(",")));
+    for (const type of types) {
+      source.addEventListener(type, (message) => {
+        const event = JSON.parse(message.data);
+        onEvent(event, event.event_data || {});
+      });
+    }
+    return source;
+  }
+
+  // alertQueue returns a function queueing data for element. Each alert is rendered into
+  // the element, shown for the theme's duration and hidden again before the next one.
+  function alertQueue(element, render) {
+    const queue = [];
+    let showing = false;
+
+    function next() {
+      if (queue.length === 0) {
+        showing = false;
+        return;
+      }
+      showing = true;
+      render(element, queue.shift());
+      element.classList.add("visible");
+      setTimeout(() => {
+        element.classList.remove("visible");
+        setTimeout(next, fadeOut);
+      }, widgetTheme.duration * 1000);
+    }
+
+    return (data) => {
+      if (queue.length >= maxQueuedAlerts) {
+        return;
+      }
+      queue.push(data);
+      if (!showing) {
+        next();
+      }
+    };
+  }
+
+  // fetchJSON loads an API path, resolving to null when it failed or was not found
+  async function fetchJSON(path) {
+    try {
+      const response = await fetch(path, {cache: "no-store"});
+      return response.ok ? await response.json() : null;
+    } catch (err) {
+      return null;
+    }
+  }
+
+  // element creates an element with a class and optional text content
+  function element(tag, className, text) {
+    const el = document.createElement(tag);
+    el.className = className;
+    if (text !== undefined) {
+      el.textContent = text;
+    }
+    return el;
+  }
+
+  return {stream, alertQueue, fetchJSON, element};
+})();
@@ -0,0 +1,168 @@
+// Package widgets serves ready-made overlay pages for OBS browser sources.
+//
+// Every widget is an HTML page embedded in the binary that follows the game event
+// stream and loads whatever else it shows from the JSON API of the same server, so a
+// streamer only has to add the widget's URL as a browser source. Theme parameters in
+// the URL query string (colors, font, sizes and timings) restyle a widget without
+// editing any HTML.
+package widgets
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+//go:embed static
+var staticFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// Widget describes one overlay page
+type Widget struct {
+	Name        string
+	Title       string
+	Description string
+}
+
+// Widgets lists the overlay pages in the order the index shows them
+var Widgets = []Widget{
+	{Name: "combat", Title: "Combat alert", Description: "Announces the winner of every fight"},
+	{Name: "level-up", Title: "Level-up banner", Description: "Celebrates characters reaching a new level"},
+	{Name: "merchant", Title: "Merchant countdown", Description: "Shows the wandering merchant's offers, stock and time left"},
+	{Name: "leaderboard", Title: "Leaderboard ticker", Description: "Scrolls the top 10 of the ranked ladder"},
+}
+
+// Find returns the widget with the given name
+func Find(name string) (Widget, bool) {
+	for _, widget := range Widgets {
+		if widget.Name == name {
+			return widget, true
+		}
+	}
+	return Widget{}, false
+}
+
+// Theme styles a widget. Colors are CSS hex colors such as #9146ff or #000000c0, or
+// plain color names; the leading # may be left out so URLs need no escaping.
+type Theme struct {
+	TextColor   string
+	AccentColor string
+	Background  string
+	Font        string
+	FontSize    int // base font size in pixels
+	Duration    int // seconds an alert stays on screen
+	Speed       int // ticker scroll speed in pixels per second
+}
+
+// DefaultTheme is used for every parameter missing from the query string
+var DefaultTheme = Theme{
+	TextColor:   "#ffffff",
+	AccentColor: "#9146ff",
+	Background:  "#0e0e10cc",
+	Font:        "Segoe UI",
+	FontSize:    32,
+	Duration:    6,
+	Speed:       80,
+}
+
+var (
+	hexColorPattern  = regexp.MustCompile(`^#?([0-9A-Fa-f]{3,4}|[0-9A-Fa-f]{6}|[0-9A-Fa-f]{8})$`)
+	colorNamePattern = regexp.MustCompile(`^[A-Za-z]{3,20}$`)
+	fontPattern      = regexp.MustCompile(`^[A-Za-z0-9 -]{1,40}$`)
+)
+
+// ParseTheme reads theme parameters from a query string: text_color, accent_color,
+// background, font, font_size, duration and speed
+func ParseTheme(query url.Values) (Theme, error) {
+	theme := DefaultTheme
+
+	colors := []struct {
+		param string
+		value *string
+	}{
+		{"text_color", &theme.TextColor},
+		{"accent_color", &theme.AccentColor},
+		{"background", &theme.Background},
+	}
+	for _, color := range colors {
+		value := query.Get(color.param)
+		if value == "" {
+			continue
+		}
+		switch {
+		case hexColorPattern.MatchString(value):
+			*color.value = "#" + strings.TrimPrefix(value, "#")
+		case colorNamePattern.MatchString(value):
+			*color.value = strings.ToLower(value)
+		default:
+			return theme, fmt.Errorf("invalid %s %q", color.param, value)
+		}
+	}
+
+	if font := query.Get("font"); font != "" {
+		if !fontPattern.MatchString(font) {
+			return theme, fmt.Errorf("invalid font %q", font)
+		}
+		theme.Font = font
+	}
+
+	numbers := []struct {
+		param    string
+		value    *int
+		min, max int
+	}{
+		{"font_size", &theme.FontSize, 8, 200},
+		{"duration", &theme.Duration, 1, 120},
+		{"speed", &theme.Speed, 10, 1000},
+	}
+	for _, number := range numbers {
+		value := query.Get(number.param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < number.min || n > number.max {
+			return theme, fmt.Errorf("%s must be a number between %d and %d", number.param, number.min, number.max)
+		}
+		*number.value = n
+	}
+
+	return theme, nil
+}
+
+// page is the data every template is executed with
+type page struct {
+	Title   string
+	Widget  Widget
+	Widgets []Widget
+	Theme   Theme
+}
+
+// Render writes the page of a widget styled with theme
+func Render(w io.Writer, widget Widget, theme Theme) error {
+	return templates.ExecuteTemplate(w, widget.Name+".html", page{Title: widget.Title, Widget: widget, Theme: theme})
+}
+
+// RenderIndex writes the page listing every widget with its theme parameters
+func RenderIndex(w io.Writer) error {
+	return templates.ExecuteTemplate(w, "index.html", page{Title: "Overlay widgets", Widgets: Widgets, Theme: DefaultTheme})
+}
+
+// Static returns the scripts and stylesheets shared by the widget pages
+func Static() fs.FS {
+	static, err := fs.Sub(staticFS, "static")
+	if err != nil {
+		panic(fmt.Sprintf("widgets: missing static files: %v", err))
+	}
+	return static
+}
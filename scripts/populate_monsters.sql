@@ -0,0 +1,35 @@
+-- Populate the bestiary and loot tables (run after populate_items.sql)
+USE twitch_rpg;
+
+INSERT INTO monsters (name, description, level_min, level_max, strength, agility, vitality, intelligence, experience_reward) VALUES
+('Riesenratte', 'Eine fette Ratte aus der Kanalisation', 1, 3, 6, 8, 5, 1, 30),
+('Goblin', 'Ein gieriger kleiner Dieb mit rostigem Messer', 1, 5, 8, 9, 7, 3, 45),
+('Wolf', 'Jagt im Rudel und beißt schnell zu', 3, 8, 11, 13, 9, 2, 70),
+('Skelettkrieger', 'Ein untoter Soldat, der nicht ruhen will', 5, 12, 14, 8, 13, 4, 110),
+('Waldhexe', 'Wirft Flüche aus dem Unterholz', 8, 15, 6, 10, 11, 18, 150),
+('Troll', 'Zäh, dumm und sehr, sehr stark', 12, 20, 22, 6, 24, 3, 220),
+('Schattenassassine', 'Man sieht ihn erst, wenn es zu spät ist', 15, 25, 18, 26, 14, 10, 300),
+('Drache', 'Der Schrecken des Königreichs', 25, 50, 35, 18, 40, 30, 600);
+
+INSERT INTO monster_loot (monster_id, item_id, drop_chance, quantity)
+SELECT m.id, i.id, l.drop_chance, 1
+FROM (
+    SELECT 'Riesenratte' AS monster, 'Lederstiefel' AS item, 0.1500 AS drop_chance UNION ALL
+    SELECT 'Riesenratte', 'Holzring', 0.1000 UNION ALL
+    SELECT 'Goblin', 'Kupferring', 0.1500 UNION ALL
+    SELECT 'Goblin', 'Lederhose', 0.1200 UNION ALL
+    SELECT 'Wolf', 'Eisenring', 0.1200 UNION ALL
+    SELECT 'Wolf', 'Silberkette', 0.0800 UNION ALL
+    SELECT 'Skelettkrieger', 'Kampfhelm', 0.1500 UNION ALL
+    SELECT 'Skelettkrieger', 'Ritterrüstung', 0.0500 UNION ALL
+    SELECT 'Waldhexe', 'Amethystkette', 0.0800 UNION ALL
+    SELECT 'Waldhexe', 'Magische Hose', 0.0600 UNION ALL
+    SELECT 'Troll', 'Titanhelm', 0.0400 UNION ALL
+    SELECT 'Troll', 'Onyx Ring', 0.0800 UNION ALL
+    SELECT 'Schattenassassine', 'Elfenstiefel', 0.0800 UNION ALL
+    SELECT 'Schattenassassine', 'Blitzrüstung', 0.0300 UNION ALL
+    SELECT 'Drache', 'Drachenring', 0.0500 UNION ALL
+    SELECT 'Drache', 'Stiefel der Ewigkeit', 0.0100
+) l
+JOIN monsters m ON m.name = l.monster
+JOIN items i ON i.name = l.item;
@@ -0,0 +1,21 @@
+-- Populate the quest board (run after populate_items.sql)
+USE twitch_rpg;
+
+INSERT INTO quests (name, description, quest_type, requirements, rewards, channel_point_cost, is_active) VALUES
+('Aufwärmen in der Arena', 'Gewinne zwei Kämpfe in der Arena', 'daily',
+    '[{"type": "win_fights", "count": 2}]',
+    '{"experience": 60, "channel_points": 50}', 0, TRUE),
+('Schaufensterbummel', 'Kaufe etwas beim fahrenden Händler', 'daily',
+    '[{"type": "merchant_purchase", "count": 1}]',
+    '{"channel_points": 75}', 0, TRUE),
+('Arenachampion', 'Gewinne zehn Kämpfe in einer Woche', 'weekly',
+    '[{"type": "win_fights", "count": 10}]',
+    '{"experience": 400, "channel_points": 500}', 100, TRUE),
+('Erfahrener Abenteurer', 'Erreiche Level 5 und besitze einen seltenen Gegenstand', 'weekly',
+    '[{"type": "reach_level", "level": 5}, {"type": "own_item_rarity", "rarity": "rare"}]',
+    '{"experience": 250, "channel_points": 300}', 50, TRUE);
+
+-- Reward the adventurer quest with a pair of knight boots
+UPDATE quests
+SET rewards = JSON_SET(rewards, '$.item_ids', JSON_ARRAY((SELECT id FROM items WHERE name = 'Ritterstiefel' LIMIT 1)))
+WHERE name = 'Erfahrener Abenteurer';